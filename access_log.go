@@ -0,0 +1,188 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful/logging"
+	"github.com/nokia/restful/trace/tracer"
+)
+
+// AccessLogField is an optional field of access log records.
+type AccessLogField string
+
+// Optional access log fields. Method, path, status code and remote address are always logged.
+// In CLF the host is the client IP, see ClientIP.
+const (
+	AccessLogLatency   AccessLogField = "latency_ms"
+	AccessLogBytes     AccessLogField = "bytes"
+	AccessLogUserAgent AccessLogField = "user_agent"
+	AccessLogTraceID   AccessLogField = "trace_id"
+	AccessLogRoute     AccessLogField = "route"
+	AccessLogRequestID AccessLogField = "request_id"
+	AccessLogClientIP  AccessLogField = "client_ip"
+)
+
+// AccessLogger logs a record for each request served, after the response is sent.
+// Records of requests served successfully may be sampled, while errors and slow requests are always logged.
+//
+//	accessLog := restful.NewAccessLogger().SampleSuccess(0.01).Slow(time.Second)
+//	restful.NewServer().Addr(":8080").Handler(accessLog.Handler(router)).ListenAndServe()
+type AccessLogger struct {
+	fields   []AccessLogField
+	clf      io.Writer
+	mutex    sync.Mutex
+	fraction float64
+	slow     time.Duration
+}
+
+// NewAccessLogger creates an access logger, logging all the requests with all the optional fields,
+// in JSON format, via the logger of the logging package at info level.
+func NewAccessLogger() *AccessLogger {
+	return &AccessLogger{
+		fields:   []AccessLogField{AccessLogLatency, AccessLogBytes, AccessLogUserAgent, AccessLogTraceID, AccessLogRoute, AccessLogRequestID, AccessLogClientIP},
+		fraction: 1,
+	}
+}
+
+// Fields sets the optional fields to be logged.
+func (a *AccessLogger) Fields(fields ...AccessLogField) *AccessLogger {
+	a.fields = fields
+	return a
+}
+
+// CLF makes records written to w in Common Log Format, instead of JSON.
+// Optional fields are appended, user agent in quotes as in Combined Log Format, others as key=value pairs.
+//
+//	127.0.0.1 - joe [10/Oct/2000:13:55:36 -0700] "GET /users/1 HTTP/1.1" 200 2326 "curl/8.0" latency_ms=1.024
+func (a *AccessLogger) CLF(w io.Writer) *AccessLogger {
+	a.clf = w
+	return a
+}
+
+// SampleSuccess sets the fraction of records logged for requests served without error, i.e. status code below 400.
+// E.g. 0.01 for 1%. Default is 1, i.e. all logged.
+func (a *AccessLogger) SampleSuccess(fraction float64) *AccessLogger {
+	a.fraction = fraction
+	return a
+}
+
+// Slow makes requests lasting at least threshold always logged, even if sampling would drop them.
+func (a *AccessLogger) Slow(threshold time.Duration) *AccessLogger {
+	a.slow = threshold
+	return a
+}
+
+// Handler wraps the handler, logging the requests served.
+func (a *AccessLogger) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		aw := &responseWriter{ResponseWriter: w}
+		r = WithRouteTemplate(r)
+		h.ServeHTTP(aw, r)
+		if statusCode, latency := aw.statusCode(), time.Since(start); a.logged(statusCode, latency) {
+			a.log(r, aw.Header(), start, statusCode, aw.bytes, latency)
+		}
+	})
+}
+
+func (a *AccessLogger) logged(statusCode int, latency time.Duration) bool {
+	return statusCode >= 400 || (a.slow > 0 && latency >= a.slow) || a.fraction >= 1 || (a.fraction > 0 && rand.Float64() < a.fraction)
+}
+
+func (a *AccessLogger) log(r *http.Request, header http.Header, start time.Time, statusCode int, bytes int64, latency time.Duration) {
+	if a.clf != nil {
+		a.logCLF(r, header, start, statusCode, bytes, latency)
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", statusCode),
+		slog.String("remote_addr", r.RemoteAddr),
+	}
+	for _, field := range a.fields {
+		if value := accessLogValue(field, r, header, bytes, latency); value != nil {
+			attrs = append(attrs, slog.Any(string(field), value))
+		}
+	}
+	logging.Logger().LogAttrs(context.Background(), slog.LevelInfo, "access", attrs...)
+}
+
+func (a *AccessLogger) logCLF(r *http.Request, header http.Header, start time.Time, statusCode int, bytes int64, latency time.Duration) {
+	host := clientHost(r)
+	user, _, _ := r.BasicAuth()
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - %s [%s] %q %d %s", host, clfValue(user), start.Format("02/Jan/2006:15:04:05 -0700"), r.Method+" "+r.URL.RequestURI()+" "+r.Proto, statusCode, size)
+	for _, field := range a.fields {
+		value := accessLogValue(field, r, header, bytes, latency)
+		switch {
+		case field == AccessLogUserAgent:
+			fmt.Fprintf(&b, " %q", r.UserAgent())
+		case field == AccessLogBytes || field == AccessLogClientIP || value == nil: // Bytes and client IP are part of CLF. Missing values are omitted.
+		default:
+			fmt.Fprintf(&b, " %s=%v", field, value)
+		}
+	}
+	b.WriteByte('\n')
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	_, _ = io.WriteString(a.clf, b.String())
+}
+
+func clfValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func accessLogValue(field AccessLogField, r *http.Request, header http.Header, bytes int64, latency time.Duration) any {
+	switch field {
+	case AccessLogLatency:
+		return float64(latency.Microseconds()) / 1000
+	case AccessLogBytes:
+		return bytes
+	case AccessLogUserAgent:
+		return r.UserAgent()
+	case AccessLogTraceID:
+		if trace := traceFromContext(r.Context()); trace != nil {
+			return trace.TraceID()
+		}
+		if trace := tracer.NewFromRequest(r); trace != nil {
+			return trace.TraceID()
+		}
+	case AccessLogRoute:
+		if route := RouteTemplate(r); route != "" {
+			return route
+		}
+	case AccessLogRequestID: // Response header is set even if RequestID middleware is inside.
+		if id := header.Get(RequestIDHeader); id != "" {
+			return id
+		}
+	case AccessLogClientIP: // Differs from remote address if received from trusted proxies, see SetTrustedProxies.
+		if addr := ClientIP(r); addr.IsValid() {
+			return addr.String()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAccessLogRouter() *Router {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/0":
+			w.WriteHeader(http.StatusNotFound)
+		case "/users/slow":
+			time.Sleep(10 * time.Millisecond)
+		default:
+			_, _ = w.Write([]byte("hello"))
+		}
+	})
+	return router
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer logging.SetLogger(nil)
+
+	h := NewAccessLogger().Handler(newAccessLogRouter())
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("User-Agent", "test/1.0")
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	assert.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal("access", record["msg"])
+	assert.Equal("GET", record["method"])
+	assert.Equal("/users/1", record["path"])
+	assert.Equal(200.0, record["status"])
+	assert.Equal(5.0, record["bytes"])
+	assert.Equal("test/1.0", record["user_agent"])
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", record["trace_id"])
+	assert.Equal("/users/{id}", record["route"])
+	assert.Contains(record, "latency_ms")
+}
+
+func TestAccessLogCLFSampling(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	h := NewAccessLogger().CLF(&buf).Fields(AccessLogUserAgent, AccessLogRoute).SampleSuccess(0).Slow(5 * time.Millisecond).Handler(newAccessLogRouter())
+
+	for _, path := range []string{"/users/1", "/users/0", "/users/slow"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth("joe", "secret")
+		req.Header.Set("User-Agent", "test/1.0")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(lines, 2) { // Success sampled out.
+		assert.Regexp(`^192\.0\.2\.1 - joe \[[^]]+\] "GET /users/0 HTTP/1.1" 404 - "test/1.0" route=/users/\{id\}$`, lines[0])
+		assert.Contains(lines[1], `"GET /users/slow HTTP/1.1" 200 -`)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package admin provides debug endpoints to be served on an admin port, off the main listener.
+// See restful.Server.Admin.
+//
+//   - /debug/pprof/ profiles, for go tool pprof.
+//   - /debug/vars expvar variables.
+//   - /debug/routes routes of the Router.
+//   - /debug/maintenance states of maintenance modes, switched by PUT /debug/maintenance/{name} with {"enabled":true} body.
+//   - /debug/faults states of fault injectors, switched by PUT /debug/faults/{name} with {"enabled":true} body.
+//   - /debug/captures names of body captures, /debug/captures/{name} exchanges captured, filtered by status and limit query parameters.
+//   - Health endpoints, i.e. restful.LivenessProbePath, restful.ReadinessProbePath and restful.HealthCheckPath.
+//
+// Profiling is served without importing net/http/pprof, so that profiling endpoints are not registered at http.DefaultServeMux.
+// Importing this package registers /debug/vars there, though, as expvar does that. Do not serve http.DefaultServeMux on a public port.
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"html"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nokia/restful"
+)
+
+// Paths of the admin endpoints.
+const (
+	PprofPath       = "/debug/pprof/"
+	VarsPath        = "/debug/vars"
+	RoutesPath      = "/debug/routes"
+	MaintenancePath = "/debug/maintenance"
+	CapturesPath    = "/debug/captures"
+	FaultsPath      = "/debug/faults"
+)
+
+// NewServeMux creates a mux serving the admin endpoints. Routes of the router are served, if router is not nil.
+// Further endpoints can be added, e.g. metrics or log level handler. Protect those changing state, as anyone reaching the admin address may call them.
+//
+//	mux := admin.NewServeMux(router)
+//	mux.Handle("/debug/loglevel", logging.LevelHandler(isOperator)) // isOperator checks the credentials of the request.
+//	restful.NewServer().Addr(":8080").Handler(router).Admin("127.0.0.1:9090", mux).Graceful(0).ListenAndServe()
+func NewServeMux(router *restful.Router) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+PprofPath, pprofIndex)
+	mux.HandleFunc("GET "+PprofPath+"profile", pprofCPU)
+	mux.HandleFunc("GET "+PprofPath+"trace", pprofTrace)
+	mux.HandleFunc("GET "+PprofPath+"{name}", pprofProfile)
+	mux.Handle("GET "+VarsPath, expvar.Handler())
+	if router != nil {
+		mux.Handle("GET "+RoutesPath, routesHandler(router))
+	}
+	mux.HandleFunc("GET "+MaintenancePath, getMaintenance)
+	mux.HandleFunc("PUT "+MaintenancePath+"/{name}", putMaintenance)
+	mux.HandleFunc("GET "+FaultsPath, getFaults)
+	mux.HandleFunc("PUT "+FaultsPath+"/{name}", putFaults)
+	mux.HandleFunc("GET "+CapturesPath, getCaptureNames)
+	mux.HandleFunc("GET "+CapturesPath+"/{name}", getCaptures)
+	mux.Handle(restful.LivenessProbePath, restful.LivenessHandler())
+	mux.Handle(restful.HealthCheckPath, restful.LivenessHandler())
+	mux.Handle(restful.ReadinessProbePath, restful.ReadinessHandler())
+	return mux
+}
+
+func routesHandler(router *restful.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(router.Routes())
+	})
+}
+
+func getMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(restful.MaintenanceStates())
+}
+
+func putMaintenance(w http.ResponseWriter, r *http.Request) {
+	putSwitch(w, r, restful.SetMaintenance)
+}
+
+func getFaults(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(restful.FaultInjectionStates())
+}
+
+func putFaults(w http.ResponseWriter, r *http.Request) {
+	putSwitch(w, r, restful.SetFaultInjection)
+}
+
+// putSwitch enables or disables the named thing by {"enabled":true} body.
+func putSwitch(w http.ResponseWriter, r *http.Request, set func(name string, enabled bool) error) {
+	var state struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		http.Error(w, "Bad body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := set(r.PathValue("name"), state.Enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func getCaptureNames(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(restful.BodyCaptureNames())
+}
+
+// getCaptures serves the exchanges captured, newest first.
+// Parameter status filters by status code, e.g. status=500, limit limits the number of exchanges.
+func getCaptures(w http.ResponseWriter, r *http.Request) {
+	captured, err := restful.CapturedBodies(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	exchanges := []restful.CapturedExchange{}
+	for _, e := range slices.Backward(captured) {
+		if limit > 0 && len(exchanges) >= limit {
+			break
+		}
+		if status == 0 || e.StatusCode == status {
+			exchanges = append(exchanges, e)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(exchanges)
+}
+
+func secondsParam(r *http.Request, def int) time.Duration {
+	if sec, err := strconv.Atoi(r.URL.Query().Get("seconds")); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return time.Duration(def) * time.Second
+}
+
+func setAttachment(w http.ResponseWriter, name string) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+}
+
+func pprofIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PprofPath {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, "<html><head><title>/debug/pprof/</title></head><body><p>Profiles:</p><ul>\n")
+	for _, p := range pprof.Profiles() {
+		name := html.EscapeString(p.Name())
+		fmt.Fprintf(w, "<li>%d <a href=\"%s?debug=1\">%s</a></li>\n", p.Count(), name, name)
+	}
+	fmt.Fprint(w, "<li><a href=\"profile?seconds=30\">profile</a> (CPU)</li>\n<li><a href=\"trace?seconds=1\">trace</a></li>\n</ul></body></html>\n")
+}
+
+// pprofProfile serves a named profile, e.g. heap. Parameter debug=1 makes it human readable, gc=1 runs GC before a heap profile.
+func pprofProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.Error(w, "Unknown profile", http.StatusNotFound)
+		return
+	}
+	if name == "heap" && r.URL.Query().Get("gc") != "" {
+		runtime.GC()
+	}
+	debug, _ := strconv.Atoi(r.URL.Query().Get("debug"))
+	if debug != 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		setAttachment(w, name)
+	}
+	_ = p.WriteTo(w, debug)
+}
+
+// pprofCPU serves CPU profile for the seconds given, 30 by default.
+func pprofCPU(w http.ResponseWriter, r *http.Request) {
+	setAttachment(w, "profile")
+	if err := pprof.StartCPUProfile(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, "Could not enable CPU profiling: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, secondsParam(r, 30))
+	pprof.StopCPUProfile()
+}
+
+// pprofTrace serves execution trace for the seconds given, 1 by default.
+func pprofTrace(w http.ResponseWriter, r *http.Request) {
+	setAttachment(w, "trace")
+	if err := trace.Start(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, "Could not enable tracing: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleep(r, secondsParam(r, 1))
+	trace.Stop()
+}
+
+func sleep(r *http.Request, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestServeMux(t *testing.T) {
+	assert := assert.New(t)
+	router := restful.NewRouter()
+	router.HandleFunc("/users/{id}", func() {})
+	mux := NewServeMux(router)
+
+	w := get(mux, PprofPath)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), "goroutine")
+
+	w = get(mux, PprofPath+"goroutine?debug=1")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), "goroutine profile")
+
+	w = get(mux, PprofPath+"heap?gc=1")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("application/octet-stream", w.Header().Get("Content-Type"))
+	assert.NotEmpty(w.Body.Bytes())
+
+	assert.Equal(http.StatusNotFound, get(mux, PprofPath+"nothing").Code)
+
+	w = get(mux, PprofPath+"profile?seconds=1")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotEmpty(w.Body.Bytes())
+
+	w = get(mux, VarsPath)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), "memstats")
+
+	w = get(mux, RoutesPath)
+	var routes []restful.RouteInfo
+	assert.NoError(json.Unmarshal(w.Body.Bytes(), &routes))
+	assert.Equal([]restful.RouteInfo{{Path: "/users/{id}", Handler: true}}, routes)
+
+	assert.Equal(http.StatusOK, get(mux, restful.LivenessProbePath).Code)
+	assert.Equal(http.StatusOK, get(mux, restful.ReadinessProbePath).Code)
+	assert.Equal(http.StatusNotFound, get(NewServeMux(nil), RoutesPath).Code)
+}
+
+func TestMaintenance(t *testing.T) {
+	assert := assert.New(t)
+	m := restful.NewMaintenance("admin-test")
+	mux := NewServeMux(nil)
+
+	put := func(path, body string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, path, strings.NewReader(body)))
+		return w.Code
+	}
+	assert.Equal(http.StatusNoContent, put(MaintenancePath+"/admin-test", `{"enabled":true}`))
+	assert.True(m.Enabled())
+	assert.Equal(http.StatusNotFound, put(MaintenancePath+"/nope", `{"enabled":true}`))
+	assert.Equal(http.StatusBadRequest, put(MaintenancePath+"/admin-test", `{`))
+
+	var states map[string]bool
+	assert.NoError(json.Unmarshal(get(mux, MaintenancePath).Body.Bytes(), &states))
+	assert.True(states["admin-test"])
+}
+
+func TestFaults(t *testing.T) {
+	assert := assert.New(t)
+	f := restful.NewFaultInjector("admin-test")
+	mux := NewServeMux(nil)
+
+	put := func(path, body string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, path, strings.NewReader(body)))
+		return w.Code
+	}
+	assert.Equal(http.StatusNoContent, put(FaultsPath+"/admin-test", `{"enabled":true}`))
+	assert.True(f.Enabled())
+	assert.Equal(http.StatusNotFound, put(FaultsPath+"/nope", `{"enabled":true}`))
+	assert.Equal(http.StatusBadRequest, put(FaultsPath+"/admin-test", `{`))
+
+	var states map[string]bool
+	assert.NoError(json.Unmarshal(get(mux, FaultsPath).Body.Bytes(), &states))
+	assert.True(states["admin-test"])
+}
+
+func TestCaptures(t *testing.T) {
+	assert := assert.New(t)
+	capture := restful.NewBodyCapture("admin-test").Buffer(10).Log(false)
+	h := capture.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	for _, path := range []string{"/a", "/fail", "/b"} {
+		get(h, path)
+	}
+	mux := NewServeMux(nil)
+
+	var names []string
+	assert.NoError(json.Unmarshal(get(mux, CapturesPath).Body.Bytes(), &names))
+	assert.Contains(names, "admin-test")
+
+	var exchanges []restful.CapturedExchange
+	assert.NoError(json.Unmarshal(get(mux, CapturesPath+"/admin-test?limit=2").Body.Bytes(), &exchanges))
+	if assert.Len(exchanges, 2) {
+		assert.Equal("/b", exchanges[0].Path)
+		assert.Equal(`{"path":"/b"}`, exchanges[0].ResponseBody)
+	}
+	assert.NoError(json.Unmarshal(get(mux, CapturesPath+"/admin-test?status=500").Body.Bytes(), &exchanges))
+	if assert.Len(exchanges, 1) {
+		assert.Equal("/fail", exchanges[0].Path)
+	}
+	assert.Equal(http.StatusNotFound, get(mux, CapturesPath+"/nope").Code)
+}
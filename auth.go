@@ -0,0 +1,133 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Claims are the claims of an authenticated request, e.g. of a JWT.
+// Authentication middlewares store them in the request context, see ClaimsFromContext.
+type Claims map[string]any
+
+// String returns a string claim, or empty string if not found or not a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Scopes returns the scopes granted, taken from space-separated "scope" claim or from "scp" array.
+func (c Claims) Scopes() []string {
+	if scope := c.String("scope"); scope != "" {
+		return strings.Fields(scope)
+	}
+	switch scp := c["scp"].(type) {
+	case []string:
+		return scp
+	case []any:
+		scopes := make([]string, 0, len(scp))
+		for _, s := range scp {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return scopes
+	case string:
+		return strings.Fields(scp)
+	}
+	return nil
+}
+
+type claimsCtxKeyType string
+
+const claimsCtxName = claimsCtxKeyType("restfulClaims")
+
+// ContextWithClaims returns a context derived from ctx, holding the claims.
+func ContextWithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxName, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated request, or nil if not authenticated.
+//
+//	func handle(ctx context.Context) error {
+//	    user := restful.ClaimsFromContext(ctx).Subject()
+//	}
+func ClaimsFromContext(ctx context.Context) Claims {
+	claims, _ := ctx.Value(claimsCtxName).(Claims)
+	return claims
+}
+
+// bearerToken returns the token of "Authorization: Bearer" header, or empty string.
+func bearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// sendUnauthorized sends 401 problem with WWW-Authenticate header of the Bearer scheme, see RFC 6750.
+// If errCode is empty, then the request had no token, and the header has no error attributes.
+func sendUnauthorized(w http.ResponseWriter, r *http.Request, realm, errCode, description string) {
+	challenge := `Bearer realm="` + realm + `"`
+	if errCode != "" {
+		challenge += `, error="` + errCode + `", error_description="` + strings.ReplaceAll(description, `"`, "'") + `"`
+	}
+	sendChallenge(w, r, challenge, description)
+}
+
+// sendChallenge sends 401 problem with WWW-Authenticate header of the challenge given.
+func sendChallenge(w http.ResponseWriter, r *http.Request, challenge, description string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	if description == "" {
+		description = "authentication required"
+	}
+	pd := ProblemDetails{Title: http.StatusText(http.StatusUnauthorized), Status: http.StatusUnauthorized, Detail: description}
+	_ = SendProblemResponse(w, r, http.StatusUnauthorized, pd.String())
+}
+
+// sendForbiddenScope sends 403 problem with WWW-Authenticate header indicating the scopes required, see RFC 6750.
+func sendForbiddenScope(w http.ResponseWriter, r *http.Request, required, missing []string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(required, " ")+`"`)
+	pd := ProblemDetails{Title: http.StatusText(http.StatusForbidden), Status: http.StatusForbidden, Detail: "missing scope: " + strings.Join(missing, " ")}
+	_ = SendProblemResponse(w, r, http.StatusForbidden, pd.String())
+}
+
+// missingScopes returns the scopes required but not granted.
+func missingScopes(granted, required []string) (missing []string) {
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return
+}
+
+// checkRouteScopes checks the scopes required by the route of the request, if any.
+// Called just before the handler of the route, so the authentication middlewares, e.g. monitors of the router, have already stored the claims.
+// Returns false if the request is responded.
+func checkRouteScopes(w http.ResponseWriter, r *http.Request, required []string) bool {
+	if required == nil {
+		return true
+	}
+	claims := ClaimsFromContext(r.Context())
+	if claims == nil {
+		sendUnauthorized(w, r, "restful", "", "")
+		return false
+	}
+	if missing := missingScopes(claims.Scopes(), required); len(missing) > 0 {
+		sendForbiddenScope(w, r, required, missing)
+		return false
+	}
+	return true
+}
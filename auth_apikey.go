@@ -0,0 +1,114 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/nokia/restful/logging"
+)
+
+// APIKeyLookup returns the claims of the API key, or nil if the key is not valid.
+// Implementations should compare keys in constant time, e.g. by crypto/subtle, or look up hashes of the keys.
+type APIKeyLookup func(ctx context.Context, key string) (Claims, error)
+
+// APIKeyAuth authenticates requests by an API key received in a header.
+// Meant for internal tooling endpoints, always use TLS.
+// Claims of the key are stored in the request context, see ClaimsFromContext.
+//
+//	auth := restful.NewAPIKeyAuth().Key(os.Getenv("TOOLING_API_KEY"), "tooling")
+//	router.PathPrefix("/internal").Subrouter().Monitor(auth.Pre, nil)
+type APIKeyAuth struct {
+	header string
+	realm  string
+	keys   []apiKey
+	lookup APIKeyLookup
+}
+
+type apiKey struct {
+	sum     [sha256.Size]byte
+	subject string
+}
+
+// NewAPIKeyAuth creates an API key authenticator, reading the key from "X-API-Key" header.
+// Set keys by Key or Lookup.
+func NewAPIKeyAuth() *APIKeyAuth {
+	return &APIKeyAuth{header: "X-API-Key", realm: "restful"}
+}
+
+// Header sets the name of the header holding the API key.
+func (a *APIKeyAuth) Header(header string) *APIKeyAuth {
+	a.header = header
+	return a
+}
+
+// Realm sets the realm of WWW-Authenticate header. Default is "restful".
+func (a *APIKeyAuth) Realm(realm string) *APIKeyAuth {
+	a.realm = realm
+	return a
+}
+
+// Key adds a static API key. Subject is stored as "sub" claim, telling who the key belongs to.
+// Keys are compared in constant time.
+func (a *APIKeyAuth) Key(key, subject string) *APIKeyAuth {
+	if key != "" {
+		a.keys = append(a.keys, apiKey{sum: sha256.Sum256([]byte(key)), subject: subject})
+	}
+	return a
+}
+
+// Lookup sets a function validating keys not found among static keys, e.g. by a database.
+func (a *APIKeyAuth) Lookup(lookup APIKeyLookup) *APIKeyAuth {
+	a.lookup = lookup
+	return a
+}
+
+// Verify returns the claims of the API key, or nil if not valid.
+func (a *APIKeyAuth) Verify(ctx context.Context, key string) (Claims, error) {
+	sum := sha256.Sum256([]byte(key))
+	var found *apiKey
+	for i := range a.keys { // All the keys are compared, so timing does not tell which one matched.
+		if subtle.ConstantTimeCompare(sum[:], a.keys[i].sum[:]) == 1 {
+			found = &a.keys[i]
+		}
+	}
+	if found != nil {
+		return Claims{"sub": found.subject}, nil
+	}
+	if a.lookup != nil {
+		return a.lookup(ctx, key)
+	}
+	return nil, nil
+}
+
+// Pre is a Monitor pre function, authenticating the request. May be used for route groups, by Router's Monitor.
+// If the lookup function fails, then the request is responded 503.
+func (a *APIKeyAuth) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	challenge := `APIKey realm="` + a.realm + `", header="` + a.header + `"`
+	key := r.Header.Get(a.header)
+	if key == "" {
+		sendChallenge(w, r, challenge, "")
+		return nil
+	}
+	claims, err := a.Verify(r.Context(), key)
+	if err != nil {
+		logging.Errorf(r.Context(), "API key lookup failed: %v", err)
+		_ = SendProblemResponse(w, r, http.StatusServiceUnavailable, "API key lookup failed")
+		return nil
+	}
+	if claims == nil {
+		sendChallenge(w, r, challenge, "invalid API key")
+		return nil
+	}
+	return r.WithContext(ContextWithClaims(r.Context(), claims))
+}
+
+// Handler wraps the handler, authenticating all requests.
+func (a *APIKeyAuth) Handler(h http.Handler) http.Handler {
+	return Monitor(h, a.Pre, nil)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	assert := assert.New(t)
+	auth := NewAPIKeyAuth().Header("X-Tool-Key").Key("k1", "ci").Key("k2", "ops").Lookup(func(ctx context.Context, key string) (Claims, error) {
+		switch key {
+		case "db-key":
+			return Claims{"sub": "db", "scope": "tools"}, nil
+		case "fail":
+			return nil, errors.New("db down")
+		}
+		return nil, nil
+	})
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(ClaimsFromContext(r.Context()).Subject()))
+	}))
+
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-Tool-Key", key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for key, subject := range map[string]string{"k1": "ci", "k2": "ops", "db-key": "db"} {
+		w := request(key)
+		assert.Equal(http.StatusOK, w.Code, key)
+		assert.Equal(subject, w.Body.String())
+	}
+
+	w := request("")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.Equal(`APIKey realm="restful", header="X-Tool-Key"`, w.Header().Get("WWW-Authenticate"))
+	w = request("bad")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.Contains(w.Body.String(), "invalid API key")
+	assert.Equal(http.StatusServiceUnavailable, request("fail").Code)
+
+	// Empty key is never valid.
+	claims, err := NewAPIKeyAuth().Key("", "nobody").Verify(context.Background(), "")
+	assert.NoError(err)
+	assert.Nil(claims)
+}
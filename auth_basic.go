@@ -0,0 +1,134 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nokia/restful/logging"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuth authenticates requests by HTTP basic authentication, see RFC 7617.
+// Meant for internal tooling endpoints, always use TLS.
+// The user name is stored in the request context as "sub" claim, see ClaimsFromContext.
+//
+//	auth := restful.NewBasicAuth().User("admin", os.Getenv("ADMIN_PASSWORD"))
+//	router.PathPrefix("/internal").Subrouter().Monitor(auth.Pre, nil)
+type BasicAuth struct {
+	realm string
+	mutex sync.RWMutex
+	users map[string]string // User name -> password hash, htpasswd style.
+}
+
+// NewBasicAuth creates a basic authenticator. Add users by User or LoadHtpasswd.
+func NewBasicAuth() *BasicAuth {
+	return &BasicAuth{realm: "restful", users: map[string]string{}}
+}
+
+// Realm sets the realm of WWW-Authenticate header. Default is "restful".
+func (a *BasicAuth) Realm(realm string) *BasicAuth {
+	a.realm = realm
+	return a
+}
+
+// User adds a user with a password.
+// Password may be a hash as in htpasswd files: bcrypt ("$2y$..."), SHA1 ("{SHA}..."). Otherwise plain text.
+func (a *BasicAuth) User(username, password string) *BasicAuth {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.users[username] = password
+	return a
+}
+
+// LoadHtpasswd loads users from an htpasswd file, replacing users loaded before.
+// Each line has "username:hash" format. Supported hashes are bcrypt ("$2y$...", e.g. htpasswd -B) and SHA1 ("{SHA}...", htpasswd -s).
+// May be called again to reload the file, e.g. when changed.
+func (a *BasicAuth) LoadHtpasswd(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	users, err := parseHtpasswd(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.users = users
+	return nil
+}
+
+func parseHtpasswd(r io.Reader) (map[string]string, error) {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		username, hash, found := strings.Cut(text, ":")
+		if !found || username == "" {
+			return nil, fmt.Errorf("line %d: bad format", line)
+		}
+		if !strings.HasPrefix(hash, "$2") && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("line %d: unsupported hash of user %q", line, username)
+		}
+		users[username] = hash
+	}
+	return users, scanner.Err()
+}
+
+// passwordMatch tells if the password matches the hash, as in htpasswd files.
+func passwordMatch(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		return subtle.ConstantTimeCompare([]byte(hash[len("{SHA}"):]), []byte(base64.StdEncoding.EncodeToString(sum[:]))) == 1
+	}
+	hashSum, passwordSum := sha256.Sum256([]byte(hash)), sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare(hashSum[:], passwordSum[:]) == 1
+}
+
+// Verify tells if the user name and password are valid.
+func (a *BasicAuth) Verify(username, password string) bool {
+	a.mutex.RLock()
+	hash, ok := a.users[username]
+	a.mutex.RUnlock()
+	return ok && passwordMatch(hash, password)
+}
+
+// Pre is a Monitor pre function, authenticating the request. May be used for route groups, by Router's Monitor.
+func (a *BasicAuth) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	username, password, ok := r.BasicAuth()
+	if !ok || !a.Verify(username, password) {
+		if ok {
+			logging.Debugf(r.Context(), "basic auth of user %q rejected", username)
+		}
+		sendChallenge(w, r, `Basic realm="`+a.realm+`", charset="UTF-8"`, "")
+		return nil
+	}
+	return r.WithContext(ContextWithClaims(r.Context(), Claims{"sub": username}))
+}
+
+// Handler wraps the handler, authenticating all requests.
+func (a *BasicAuth) Handler(h http.Handler) http.Handler {
+	return Monitor(h, a.Pre, nil)
+}
@@ -0,0 +1,67 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	assert := assert.New(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("bcrypt-pass"), bcrypt.MinCost)
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	htpasswd := "# users\nalice:" + string(hash) + "\nbob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n" // SHA1 of "password"
+	assert.NoError(os.WriteFile(path, []byte(htpasswd), 0o600))
+
+	auth := NewBasicAuth().Realm("tools")
+	assert.NoError(auth.LoadHtpasswd(path))
+	auth.User("carol", "plain")
+	router := NewRouter().Monitor(auth.Pre, nil)
+	router.HandleFunc("/", func(ctx context.Context) (string, error) {
+		return ClaimsFromContext(ctx).Subject(), nil
+	})
+
+	request := func(username, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for username, password := range map[string]string{"alice": "bcrypt-pass", "bob": "password", "carol": "plain"} {
+		w := request(username, password)
+		assert.Equal(http.StatusOK, w.Code, username)
+		assert.Equal(`"`+username+`"`, w.Body.String())
+		assert.Equal(http.StatusUnauthorized, request(username, password+"x").Code, username)
+	}
+
+	w := request("", "")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.Equal(`Basic realm="tools", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(http.StatusUnauthorized, request("dave", "plain").Code)
+
+	// Reload replaces users loaded.
+	assert.NoError(os.WriteFile(path, []byte("bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"), 0o600))
+	assert.NoError(auth.LoadHtpasswd(path))
+	assert.Equal(http.StatusUnauthorized, request("alice", "bcrypt-pass").Code)
+
+	_, err := parseHtpasswd(strings.NewReader("eve:$apr1$salt$hash\n"))
+	assert.ErrorContains(err, "unsupported hash")
+	_, err = parseHtpasswd(strings.NewReader("eve\n"))
+	assert.ErrorContains(err, "line 1")
+	assert.Error(auth.LoadHtpasswd(filepath.Join(t.TempDir(), "missing")))
+}
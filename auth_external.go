@@ -0,0 +1,169 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// AuthzInput is the request metadata a policy decides on.
+type AuthzInput struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Route    string            `json:"route,omitempty"`   // Route template, e.g. "/users/{id}", if used at a Router's Monitor.
+	Query    map[string]string `json:"query,omitempty"`   // Multiple values joined by comma.
+	Headers  map[string]string `json:"headers,omitempty"` // Canonical names, multiple values joined by comma.
+	ClientIP string            `json:"client_ip"`         // See ClientIP.
+	Claims   Claims            `json:"claims,omitempty"`  // Claims of the authenticated user, if any. See ClaimsFromContext.
+	Subject  string            `json:"subject,omitempty"` // Subject of the claims.
+}
+
+// AuthzDecision is the decision of a policy.
+type AuthzDecision struct {
+	Allow           bool              `json:"allow"`
+	Status          int               `json:"status,omitempty"`           // Status code responded if denied. Default is 403.
+	Reason          string            `json:"reason,omitempty"`           // Detail of the problem responded if denied.
+	Headers         map[string]string `json:"headers,omitempty"`          // Request headers set for the handler if allowed, e.g. user roles.
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"` // Response headers set.
+}
+
+// AuthzFunc decides on a request, e.g. by an embedded OPA policy. Error makes the request responded 503, unless failing open.
+type AuthzFunc func(ctx context.Context, input AuthzInput) (AuthzDecision, error)
+
+// ExternalAuthz authorizes requests by a policy outside the handlers, e.g. an OPA server or an embedded policy engine.
+// The policy gets request metadata, and decides whether to allow or deny the request. It may set request headers for the handler.
+// Apply it after authentication, so that the policy sees the claims.
+//
+//	authz := restful.NewExternalAuthz("http://localhost:8181/v1/data/orders/authz")
+//	router := restful.NewRouter().Monitor(authz.Pre, nil).Monitor(jwtAuth.Pre, nil) // Monitors added later run first.
+type ExternalAuthz struct {
+	decide   AuthzFunc
+	client   *Client
+	failOpen bool
+}
+
+// NewExternalAuthz creates an authorizer asking a policy endpoint of OPA data API style.
+// The request metadata is posted as {"input": AuthzInput}, and the response is expected as {"result": AuthzDecision}.
+// The result may be a bare boolean, too, e.g. of an OPA allow rule.
+func NewExternalAuthz(endpoint string) *ExternalAuthz {
+	a := &ExternalAuthz{client: NewClient().Timeout(5 * time.Second)}
+	a.decide = func(ctx context.Context, input AuthzInput) (AuthzDecision, error) {
+		return a.ask(ctx, endpoint, input)
+	}
+	return a
+}
+
+// NewAuthzFunc creates an authorizer calling the function given, e.g. evaluating an embedded OPA policy.
+func NewAuthzFunc(decide AuthzFunc) *ExternalAuthz {
+	return &ExternalAuthz{decide: decide}
+}
+
+// Client sets the client used for calling the policy endpoint.
+func (a *ExternalAuthz) Client(client *Client) *ExternalAuthz {
+	a.client = client
+	return a
+}
+
+// FailOpen makes requests allowed if the policy cannot be evaluated, e.g. the endpoint is down.
+// By default those are responded 503.
+func (a *ExternalAuthz) FailOpen(failOpen bool) *ExternalAuthz {
+	a.failOpen = failOpen
+	return a
+}
+
+func (a *ExternalAuthz) ask(ctx context.Context, endpoint string, input AuthzInput) (AuthzDecision, error) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if _, err := a.client.Post(ctx, endpoint, map[string]any{"input": input}, &resp); err != nil {
+		return AuthzDecision{}, err
+	}
+	var decision AuthzDecision
+	if len(resp.Result) == 0 { // Undefined result denies.
+		return decision, nil
+	}
+	if err := json.Unmarshal(resp.Result, &decision.Allow); err == nil {
+		return decision, nil
+	}
+	err := json.Unmarshal(resp.Result, &decision)
+	return decision, err
+}
+
+func joinValues(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	joined := make(map[string]string, len(values))
+	for k, v := range values {
+		joined[k] = strings.Join(v, ",")
+	}
+	return joined
+}
+
+func authzInput(r *http.Request) AuthzInput {
+	claims := ClaimsFromContext(r.Context())
+	input := AuthzInput{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Route:   RouteTemplate(r),
+		Query:   joinValues(r.URL.Query()),
+		Headers: joinValues(r.Header),
+		Claims:  claims,
+		Subject: claims.Subject(),
+	}
+	if ip := ClientIP(r); ip.IsValid() {
+		input.ClientIP = ip.String()
+	}
+	return input
+}
+
+// Pre is a Monitor pre function, enforcing the decision of the policy. May be used for route groups, by Router's Monitor.
+func (a *ExternalAuthz) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	decision, err := a.decide(r.Context(), authzInput(r))
+	if err != nil {
+		logging.Errorf(r.Context(), "authorization policy failed: %v", err)
+		if !a.failOpen {
+			_ = SendProblemResponse(w, r, http.StatusServiceUnavailable, "authorization policy failed")
+			return nil
+		}
+		decision = AuthzDecision{Allow: true}
+	}
+
+	for name, value := range decision.ResponseHeaders {
+		w.Header().Set(name, value)
+	}
+	if !decision.Allow {
+		status := decision.Status
+		if status < 400 {
+			status = http.StatusForbidden
+		}
+		detail := decision.Reason
+		if detail == "" {
+			detail = "denied by policy"
+		}
+		pd := ProblemDetails{Title: http.StatusText(status), Status: status, Detail: detail}
+		_ = SendProblemResponse(w, r, status, pd.String())
+		return nil
+	}
+
+	if len(decision.Headers) > 0 {
+		r = r.Clone(r.Context())
+		for name, value := range decision.Headers {
+			r.Header.Set(name, value)
+		}
+	}
+	return r
+}
+
+// Handler wraps the handler, authorizing all requests.
+func (a *ExternalAuthz) Handler(h http.Handler) http.Handler {
+	return Monitor(h, a.Pre, nil)
+}
@@ -0,0 +1,93 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalAuthz(t *testing.T) {
+	assert := assert.New(t)
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input AuthzInput `json:"input"`
+		}
+		assert.NoError(json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal("/orders/{id}", req.Input.Route)
+		assert.NotEmpty(req.Input.ClientIP)
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Input.Headers["X-User"] {
+		case "admin":
+			_, _ = w.Write([]byte(`{"result":{"allow":true,"headers":{"X-Roles":"admin"},"response_headers":{"X-Policy":"v1"}}}`))
+		case "joe":
+			_, _ = w.Write([]byte(`{"result":true}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "limited":
+			_, _ = w.Write([]byte(`{"result":{"allow":false,"status":429,"reason":"quota exceeded"}}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer opa.Close()
+
+	authz := NewExternalAuthz(opa.URL)
+	router := NewRouter()
+	router.Monitor(authz.Pre, nil)
+	router.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Roles")))
+	})
+	h := NewServer().Handler(router).server.Handler
+
+	request := func(user string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		req.Header.Set("X-User", user)
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("admin")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("admin", w.Body.String())
+	assert.Equal("v1", w.Header().Get("X-Policy"))
+	assert.Equal(http.StatusOK, request("joe").Code)
+	assert.Equal(http.StatusForbidden, request("nobody").Code)
+	w = request("limited")
+	assert.Equal(http.StatusTooManyRequests, w.Code)
+	assert.Contains(w.Body.String(), "quota exceeded")
+	assert.Equal(http.StatusServiceUnavailable, request("broken").Code)
+	authz.FailOpen(true)
+	assert.Equal(http.StatusOK, request("broken").Code)
+}
+
+func TestAuthzFunc(t *testing.T) {
+	assert := assert.New(t)
+	authz := NewAuthzFunc(func(ctx context.Context, input AuthzInput) (AuthzDecision, error) {
+		if input.Method == http.MethodDelete {
+			return AuthzDecision{}, errors.New("policy not loaded")
+		}
+		return AuthzDecision{Allow: input.Subject == "joe"}, nil
+	})
+	h := authz.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(method string, claims Claims) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/", nil)
+		h.ServeHTTP(w, req.WithContext(ContextWithClaims(req.Context(), claims)))
+		return w.Code
+	}
+	assert.Equal(http.StatusOK, request(http.MethodGet, Claims{"sub": "joe"}))
+	assert.Equal(http.StatusForbidden, request(http.MethodGet, Claims{"sub": "eve"}))
+	assert.Equal(http.StatusForbidden, request(http.MethodGet, nil))
+	assert.Equal(http.StatusServiceUnavailable, request(http.MethodDelete, Claims{"sub": "joe"}))
+}
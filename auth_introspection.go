@@ -0,0 +1,172 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// ErrTokenInactive is returned if the introspection endpoint reports the token inactive.
+var ErrTokenInactive = errors.New("token inactive")
+
+// IntrospectionCacheSize is the max number of tokens cached by a TokenIntrospection.
+var IntrospectionCacheSize = 10000
+
+// TokenIntrospection authenticates requests by opaque bearer tokens, asking an OAuth 2.0 token introspection endpoint, see RFC 7662.
+// Results are cached, so the endpoint is not asked at each request.
+// The introspection response is stored in the request context as claims, see ClaimsFromContext.
+// Requests without an active token are responded 401 with WWW-Authenticate header, see RFC 6750.
+//
+//	auth := restful.NewTokenIntrospection("https://idp.example.com/introspect").ClientCredentials("orders", "secret")
+//	router := restful.NewRouter().Monitor(auth.Pre, nil)
+type TokenIntrospection struct {
+	endpoint string
+	client   *Client
+	realm    string
+	cacheTTL time.Duration
+
+	mutex sync.Mutex
+	cache map[[sha256.Size]byte]introspectionResult
+}
+
+type introspectionResult struct {
+	claims  Claims // nil if inactive.
+	expires time.Time
+}
+
+// NewTokenIntrospection creates a token introspection authenticator, using the endpoint given.
+// Results are cached for 1 minute by default, but not after the token expires.
+func NewTokenIntrospection(endpoint string) *TokenIntrospection {
+	return &TokenIntrospection{
+		endpoint: endpoint,
+		client:   NewClient().Timeout(10 * time.Second),
+		realm:    "restful",
+		cacheTTL: time.Minute,
+		cache:    map[[sha256.Size]byte]introspectionResult{},
+	}
+}
+
+// Client sets the client used for calling the introspection endpoint, e.g. one having OAuth2 client credentials grant configured.
+func (a *TokenIntrospection) Client(client *Client) *TokenIntrospection {
+	a.client = client
+	return a
+}
+
+// ClientCredentials sets the credentials the introspection endpoint is called with, by basic authentication.
+func (a *TokenIntrospection) ClientCredentials(clientID, clientSecret string) *TokenIntrospection {
+	a.client.SetBasicAuth(clientID, clientSecret)
+	return a
+}
+
+// Realm sets the realm of WWW-Authenticate header. Default is "restful".
+func (a *TokenIntrospection) Realm(realm string) *TokenIntrospection {
+	a.realm = realm
+	return a
+}
+
+// CacheTTL sets how long introspection results are cached. Inactive tokens are cached, too. 0 disables caching.
+func (a *TokenIntrospection) CacheTTL(ttl time.Duration) *TokenIntrospection {
+	a.cacheTTL = ttl
+	return a
+}
+
+func (a *TokenIntrospection) cached(key [sha256.Size]byte) (introspectionResult, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	res, ok := a.cache[key]
+	if ok && time.Now().After(res.expires) {
+		delete(a.cache, key)
+		return res, false
+	}
+	return res, ok
+}
+
+func (a *TokenIntrospection) store(key [sha256.Size]byte, res introspectionResult) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(a.cache) >= IntrospectionCacheSize {
+		now := time.Now()
+		for k, v := range a.cache {
+			if now.After(v.expires) {
+				delete(a.cache, k)
+			}
+		}
+		if len(a.cache) >= IntrospectionCacheSize {
+			clear(a.cache)
+		}
+	}
+	a.cache[key] = res
+}
+
+// Introspect returns the claims of the token, asking the introspection endpoint if not cached.
+// Returns ErrTokenInactive if the token is not active, e.g. expired or revoked.
+func (a *TokenIntrospection) Introspect(ctx context.Context, token string) (Claims, error) {
+	key := sha256.Sum256([]byte(token))
+	if res, ok := a.cached(key); ok && a.cacheTTL > 0 {
+		if res.claims == nil {
+			return nil, ErrTokenInactive
+		}
+		return res.claims, nil
+	}
+
+	var claims Claims
+	if _, err := a.client.PostForm(ctx, a.endpoint, url.Values{"token": {token}, "token_type_hint": {"access_token"}}, &claims); err != nil {
+		return nil, err
+	}
+
+	res := introspectionResult{expires: time.Now().Add(a.cacheTTL)}
+	if active, _ := claims["active"].(bool); active {
+		if exp, ok := claims["exp"].(float64); ok {
+			expires := time.Unix(int64(exp), 0)
+			if expires.Before(time.Now()) {
+				claims = nil
+			} else if expires.Before(res.expires) {
+				res.expires = expires
+			}
+		}
+		res.claims = claims
+	}
+	if a.cacheTTL > 0 {
+		a.store(key, res)
+	}
+	if res.claims == nil {
+		return nil, ErrTokenInactive
+	}
+	return res.claims, nil
+}
+
+// Pre is a Monitor pre function, authenticating the request. May be used for route groups, by Router's Monitor.
+// If the introspection endpoint cannot be reached, then the request is responded 503.
+func (a *TokenIntrospection) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	token := bearerToken(r)
+	if token == "" {
+		sendUnauthorized(w, r, a.realm, "", "")
+		return nil
+	}
+	claims, err := a.Introspect(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrTokenInactive) {
+			sendUnauthorized(w, r, a.realm, "invalid_token", err.Error())
+		} else {
+			logging.Errorf(r.Context(), "token introspection failed: %v", err)
+			_ = SendProblemResponse(w, r, http.StatusServiceUnavailable, "token introspection failed")
+		}
+		return nil
+	}
+	return r.WithContext(ContextWithClaims(r.Context(), claims))
+}
+
+// Handler wraps the handler, authenticating all requests.
+func (a *TokenIntrospection) Handler(h http.Handler) http.Handler {
+	return Monitor(h, a.Pre, nil)
+}
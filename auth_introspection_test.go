@@ -0,0 +1,146 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenIntrospection(t *testing.T) {
+	assert := assert.New(t)
+	var calls atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal("orders", user)
+		assert.Equal("secret", pass)
+		assert.Equal("access_token", r.FormValue("token_type_hint"))
+		resp := map[string]any{"active": false}
+		switch r.FormValue("token") {
+		case "good":
+			resp = map[string]any{"active": true, "sub": "joe", "scope": "orders:read", "exp": time.Now().Add(time.Hour).Unix()}
+		case "expired":
+			resp = map[string]any{"active": true, "sub": "joe", "exp": time.Now().Add(-time.Minute).Unix()}
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer idp.Close()
+
+	auth := NewTokenIntrospection(idp.URL).ClientCredentials("orders", "secret")
+	router := NewRouter().Monitor(auth.Pre, nil)
+	router.HandleFunc("/orders", func(ctx context.Context) (string, error) {
+		return ClaimsFromContext(ctx).Subject(), nil
+	})
+
+	w := authRequest(router, "good")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(`"joe"`, w.Body.String())
+	w = authRequest(router, "good")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(int32(1), calls.Load()) // Cached.
+
+	w = authRequest(router, "")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	for _, token := range []string{"revoked", "expired", "revoked"} {
+		w = authRequest(router, token)
+		assert.Equal(http.StatusUnauthorized, w.Code, token)
+		assert.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`, token)
+	}
+	assert.Equal(int32(3), calls.Load()) // Inactive cached, too.
+
+	w = authRequest(router, "broken")
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+
+	// Cache disabled
+	auth.CacheTTL(0)
+	authRequest(router, "good")
+	authRequest(router, "good")
+	assert.Equal(int32(6), calls.Load())
+}
+
+func TestTokenIntrospectionCacheSize(t *testing.T) {
+	assert := assert.New(t)
+	defer func(size int) { IntrospectionCacheSize = size }(IntrospectionCacheSize)
+	IntrospectionCacheSize = 2
+
+	auth := NewTokenIntrospection("")
+	for _, b := range []byte{1, 2, 3} {
+		auth.store([32]byte{b}, introspectionResult{expires: time.Now().Add(time.Minute)})
+	}
+	assert.Len(auth.cache, 1)
+	_, ok := auth.cached([32]byte{3})
+	assert.True(ok)
+
+	auth.store([32]byte{4}, introspectionResult{expires: time.Now().Add(-time.Minute)})
+	_, ok = auth.cached([32]byte{4})
+	assert.False(ok)
+	assert.Len(auth.cache, 1)
+}
+
+func TestRouteScopes(t *testing.T) {
+	assert := assert.New(t)
+	scopesOf := map[string]string{"reader": "orders:read", "writer": "orders:read orders:write"}
+	auth := MonitorFuncPre(func(w http.ResponseWriter, r *http.Request) *http.Request {
+		if scope, ok := scopesOf[bearerToken(r)]; ok {
+			return r.WithContext(ContextWithClaims(r.Context(), Claims{"scope": scope}))
+		}
+		return r
+	})
+
+	router := NewRouter().Monitor(auth, nil)
+	router.HandleFunc("/orders", func() {}).Methods(http.MethodGet).Scopes("orders:read")
+	router.HandleFunc("/orders", func() {}).Methods(http.MethodPost).Scopes("orders:read", "orders:write")
+	router.HandleFunc("/status", func() {})
+	router.Path("/raw").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).Scopes("admin")
+	router.Path("/admin").Scopes("admin").HandlerFunc(func() {}) // Set before the handler.
+	router.HandleFunc("/named", func() {}).Name("named")
+	router.Get("named").Scopes("orders:write")
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(http.StatusNoContent, request(http.MethodGet, "/orders", "reader").Code)
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, "/orders", "writer").Code)
+	assert.Equal(http.StatusNoContent, request(http.MethodGet, "/status", "").Code)
+	assert.Equal(http.StatusForbidden, request(http.MethodGet, "/admin", "writer").Code)
+	assert.Equal(http.StatusForbidden, request(http.MethodGet, "/named", "reader").Code)
+	assert.Equal(http.StatusNoContent, request(http.MethodGet, "/named", "writer").Code)
+
+	w := request(http.MethodPost, "/orders", "reader")
+	assert.Equal(http.StatusForbidden, w.Code)
+	assert.Equal(`Bearer error="insufficient_scope", scope="orders:read orders:write"`, w.Header().Get("WWW-Authenticate"))
+	assert.Contains(w.Body.String(), "missing scope: orders:write")
+
+	w = request(http.MethodGet, "/orders", "")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	// Authentication outside the router
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/raw", nil)
+	req = req.WithContext(ContextWithClaims(req.Context(), Claims{"scp": []any{"admin"}}))
+	router.ServeHTTP(w, req)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(http.StatusUnauthorized, request(http.MethodGet, "/raw", "writer").Code) // Router's Monitor does not apply to Handler.
+}
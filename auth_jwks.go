@@ -0,0 +1,223 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nokia/restful/logging"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrUnknownKey is returned if no key is found for verifying a token.
+var ErrUnknownKey = errors.New("unknown key")
+
+// jwk is a JSON Web Key, see RFC 7517. Public keys of RSA, EC and OKP (Ed25519) types are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// publicKey returns the public key of the JWK.
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		return k.rsaPublicKey()
+	case "EC":
+		return k.ecPublicKey()
+	case "OKP":
+		return k.okpPublicKey()
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func (k jwk) rsaPublicKey() (any, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("bad RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecPublicKey() (any, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch k.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	point := append([]byte{4}, append(x.FillBytes(make([]byte, size)), y.FillBytes(make([]byte, size))...)...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil { // Validates the point.
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func (k jwk) okpPublicKey() (any, error) {
+	if k.Crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("bad Ed25519 key size")
+	}
+	return ed25519.PublicKey(x), nil
+}
+
+const jwksFetchTimeout = 10 * time.Second
+
+// jwks is a JSON Web Key Set fetched from a URL, cached and refreshed.
+// Keys are refreshed when older than refresh, or when a key ID not known is looked up, supporting key rotation.
+// Fetching is done without holding the lock, and concurrent refreshes are merged into one fetch.
+// On fetch failure the keys known are kept, and fetching is retried after minInterval.
+type jwks struct {
+	url         string
+	client      *Client
+	refresh     time.Duration
+	minInterval time.Duration // Min time between fetches, against unknown key ID floods.
+	group       singleflight.Group
+
+	mutex   sync.Mutex
+	keys    map[string]any
+	fetched time.Time // Last successful fetch.
+	failed  time.Time // Last failed fetch.
+}
+
+func newJWKS(url string, refresh time.Duration) *jwks {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	return &jwks{url: url, client: NewClient().Timeout(jwksFetchTimeout), refresh: refresh, minInterval: min(refresh, time.Minute)}
+}
+
+func (s *jwks) fetch(ctx context.Context) (map[string]any, error) {
+	var set jwkSet
+	if err := s.client.Get(ctx, s.url, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			logging.Debugf(ctx, "JWKS key %q skipped: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// stale tells if the keys are to be fetched for looking up the ID.
+func (s *jwks) stale(kid string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if time.Since(s.failed) < s.minInterval {
+		return false
+	}
+	_, known := s.keys[kid]
+	since := time.Since(s.fetched)
+	return s.fetched.IsZero() || since >= s.refresh || (kid != "" && !known && since >= s.minInterval)
+}
+
+// update fetches the keys. The fetch is detached from the request, so that its cancellation does not fail it for others.
+func (s *jwks) update(ctx context.Context) {
+	_, _, _ = s.group.Do("", func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+		defer cancel()
+		keys, err := s.fetch(ctx)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if err != nil {
+			s.failed = time.Now()
+			logging.Warnf(ctx, "JWKS fetch from %s failed: %v", s.url, err)
+			return nil, err
+		}
+		s.keys, s.fetched = keys, time.Now()
+		return nil, nil
+	})
+}
+
+// key returns the key of the ID. If the ID is empty, then all the keys.
+func (s *jwks) key(ctx context.Context, kid string) (any, error) {
+	if s.stale(kid) {
+		s.update(ctx)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if kid == "" {
+		set := jwt.VerificationKeySet{}
+		for _, key := range s.keys {
+			set.Keys = append(set.Keys, key)
+		}
+		if len(set.Keys) == 0 {
+			return nil, ErrUnknownKey
+		}
+		return set, nil
+	}
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
@@ -0,0 +1,143 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nokia/restful/logging"
+)
+
+// JWTAuth authenticates requests by JWT bearer tokens, checking signature, issuer, audience, expiry and not-before time.
+// Claims of the token are stored in the request context, see ClaimsFromContext.
+// Requests without a valid token are responded 401 with WWW-Authenticate header, see RFC 6750.
+//
+//	auth := restful.NewJWTAuth().Issuer("https://idp.example.com").Audience("orders").JWKS("https://idp.example.com/.well-known/jwks.json", time.Hour)
+//	router := restful.NewRouter().Monitor(auth.Pre, nil)
+type JWTAuth struct {
+	realm      string
+	issuer     string
+	audience   []string
+	algorithms []string
+	leeway     time.Duration
+	keys       map[string]any
+	jwks       *jwks
+}
+
+// NewJWTAuth creates a JWT authenticator. Set keys by Key or JWKS.
+// By default asymmetric algorithms are accepted: RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512 and EdDSA.
+func NewJWTAuth() *JWTAuth {
+	return &JWTAuth{
+		realm:      "restful",
+		algorithms: []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"},
+		keys:       map[string]any{},
+	}
+}
+
+// Realm sets the realm of WWW-Authenticate header. Default is "restful".
+func (a *JWTAuth) Realm(realm string) *JWTAuth {
+	a.realm = realm
+	return a
+}
+
+// Issuer sets the issuer required, i.e. "iss" claim.
+func (a *JWTAuth) Issuer(issuer string) *JWTAuth {
+	a.issuer = issuer
+	return a
+}
+
+// Audience sets the audiences accepted. Claim "aud" must contain at least one of them.
+func (a *JWTAuth) Audience(audience ...string) *JWTAuth {
+	a.audience = audience
+	return a
+}
+
+// Algorithms sets the signing algorithms accepted, e.g. "RS256". HMAC algorithms, such as "HS256", are accepted only if listed here.
+func (a *JWTAuth) Algorithms(algorithms ...string) *JWTAuth {
+	a.algorithms = algorithms
+	return a
+}
+
+// Leeway sets the clock skew tolerated at checking expiry and not-before time.
+func (a *JWTAuth) Leeway(leeway time.Duration) *JWTAuth {
+	a.leeway = leeway
+	return a
+}
+
+// Key adds a verification key of the key ID, i.e. "kid" header of the token.
+// Empty key ID means the key is used for tokens having a key ID not found, too.
+// Key is *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey, or []byte for HMAC.
+func (a *JWTAuth) Key(kid string, key any) *JWTAuth {
+	a.keys[kid] = key
+	return a
+}
+
+// JWKS sets the URL of the JSON Web Key Set of the verification keys, e.g. "https://idp.example.com/.well-known/jwks.json".
+// Keys are fetched on first use and cached. Refreshed when older than refresh (default 1 hour, if 0),
+// or when a token with a key ID not known is received, at most once a minute. So key rotation is supported.
+func (a *JWTAuth) JWKS(url string, refresh time.Duration) *JWTAuth {
+	a.jwks = newJWKS(url, refresh)
+	return a
+}
+
+// keyFunc returns the verification key of the token.
+func (a *JWTAuth) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := a.keys[kid]; ok {
+			return key, nil
+		}
+		if a.jwks != nil {
+			return a.jwks.key(ctx, kid)
+		}
+		if key, ok := a.keys[""]; ok {
+			return key, nil
+		}
+		return nil, ErrUnknownKey
+	}
+}
+
+// Validate validates the token, returning its claims.
+func (a *JWTAuth) Validate(ctx context.Context, token string) (Claims, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(a.algorithms), jwt.WithExpirationRequired(), jwt.WithLeeway(a.leeway)}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	if len(a.audience) > 0 {
+		opts = append(opts, jwt.WithAudience(a.audience...))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, a.keyFunc(ctx), opts...); err != nil {
+		return nil, err
+	}
+	return Claims(claims), nil
+}
+
+// Pre is a Monitor pre function, authenticating the request. May be used for route groups, by Router's Monitor.
+//
+//	admin := router.PathPrefix("/admin").Subrouter().Monitor(auth.Pre, nil)
+func (a *JWTAuth) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	token := bearerToken(r)
+	if token == "" {
+		sendUnauthorized(w, r, a.realm, "", "")
+		return nil
+	}
+	claims, err := a.Validate(r.Context(), token)
+	if err != nil {
+		logging.Debugf(r.Context(), "JWT rejected: %v", err)
+		sendUnauthorized(w, r, a.realm, "invalid_token", err.Error())
+		return nil
+	}
+	return r.WithContext(ContextWithClaims(r.Context(), claims))
+}
+
+// Handler wraps the handler, authenticating all requests.
+func (a *JWTAuth) Handler(h http.Handler) http.Handler {
+	return Monitor(h, a.Pre, nil)
+}
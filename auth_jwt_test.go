@@ -0,0 +1,212 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type testJWKS struct {
+	mutex   sync.Mutex
+	keys    []jwk
+	fetches int
+}
+
+func (s *testJWKS) set(keys ...jwk) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys = keys
+}
+
+func (s *testJWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fetches++
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(jwkSet{Keys: s.keys})
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jwk {
+	return jwk{Kty: "RSA", Kid: kid, Use: "sig", N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func signJWT(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return s
+}
+
+func authRequest(h http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestJWTAuth(t *testing.T) {
+	assert := assert.New(t)
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keySet := &testJWKS{}
+	keySet.set(rsaJWK("k1", key1))
+	jwksServer := httptest.NewServer(keySet)
+	defer jwksServer.Close()
+
+	auth := NewJWTAuth().Issuer("https://idp").Audience("orders").JWKS(jwksServer.URL, time.Hour)
+	auth.jwks.minInterval = 0
+	router := NewRouter().Monitor(auth.Pre, nil)
+	router.HandleFunc("/orders", func(ctx context.Context) (string, error) {
+		return ClaimsFromContext(ctx).Subject(), nil
+	})
+
+	exp := time.Now().Add(time.Minute).Unix()
+	valid := jwt.MapClaims{"iss": "https://idp", "aud": "orders", "sub": "joe", "exp": exp}
+
+	w := authRequest(router, signJWT(t, jwt.SigningMethodRS256, "k1", key1, valid))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(`"joe"`, w.Body.String())
+
+	w = authRequest(router, "")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.Equal(`Bearer realm="restful"`, w.Header().Get("WWW-Authenticate"))
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"expired":   {"iss": "https://idp", "aud": "orders", "exp": time.Now().Add(-time.Minute).Unix()},
+		"no expiry": {"iss": "https://idp", "aud": "orders"},
+		"not yet":   {"iss": "https://idp", "aud": "orders", "exp": exp, "nbf": exp},
+		"issuer":    {"iss": "https://evil", "aud": "orders", "exp": exp},
+		"audience":  {"iss": "https://idp", "aud": "users", "exp": exp},
+	} {
+		w = authRequest(router, signJWT(t, jwt.SigningMethodRS256, "k1", key1, claims))
+		assert.Equal(http.StatusUnauthorized, w.Code, name)
+		assert.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`, name)
+		assert.Contains(w.Body.String(), `"status":401`, name)
+	}
+
+	// HMAC signed with the public key is not accepted.
+	w = authRequest(router, signJWT(t, jwt.SigningMethodHS256, "k1", key1.PublicKey.N.Bytes(), valid))
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	// Key rotation: unknown key ID makes keys fetched again.
+	w = authRequest(router, signJWT(t, jwt.SigningMethodRS256, "k2", key2, valid))
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	keySet.set(rsaJWK("k1", key1), rsaJWK("k2", key2))
+	w = authRequest(router, signJWT(t, jwt.SigningMethodRS256, "k2", key2, valid))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(3, keySet.fetches)
+
+	// No key ID: all keys tried.
+	w = authRequest(router, signJWT(t, jwt.SigningMethodRS256, "", key2, valid))
+	assert.Equal(http.StatusOK, w.Code)
+}
+
+func TestJWTAuthKeys(t *testing.T) {
+	assert := assert.New(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	hmacKey := []byte("secret")
+	claims := jwt.MapClaims{"exp": time.Now().Add(time.Minute).Unix(), "scope": "read write"}
+
+	auth := NewJWTAuth().Algorithms("ES256", "EdDSA", "HS256").Key("ec", &ecKey.PublicKey).Key("ed", edPub).Key("", hmacKey)
+	h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal([]string{"read", "write"}, ClaimsFromContext(r.Context()).Scopes())
+	}))
+	assert.Equal(http.StatusOK, authRequest(h, signJWT(t, jwt.SigningMethodES256, "ec", ecKey, claims)).Code)
+	assert.Equal(http.StatusOK, authRequest(h, signJWT(t, jwt.SigningMethodEdDSA, "ed", edKey, claims)).Code)
+	assert.Equal(http.StatusOK, authRequest(h, signJWT(t, jwt.SigningMethodHS256, "other", hmacKey, claims)).Code)
+	assert.Equal(http.StatusUnauthorized, authRequest(h, signJWT(t, jwt.SigningMethodHS256, "", []byte("bad"), claims)).Code)
+
+	// JWK parsing
+	key, err := jwk{Kty: "EC", Crv: "P-256", X: b64(ecKey.X.Bytes()), Y: b64(ecKey.Y.Bytes())}.publicKey()
+	assert.NoError(err)
+	assert.True(ecKey.PublicKey.Equal(key))
+	_, err = jwk{Kty: "EC", Crv: "P-256", X: b64(ecKey.X.Bytes()), Y: b64(ecKey.X.Bytes())}.publicKey()
+	assert.Error(err) // Not on curve.
+	key, err = jwk{Kty: "OKP", Crv: "Ed25519", X: b64(edPub)}.publicKey()
+	assert.NoError(err)
+	assert.Equal(edPub, key)
+	_, err = jwk{Kty: "oct"}.publicKey()
+	assert.Error(err)
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	assert := assert.New(t)
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var mutex sync.Mutex
+	fetches, fail := 0, true
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		fetches++
+		failing := fail
+		mutex.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{rsaJWK("k1", key)}})
+	}))
+	defer srv.Close()
+	s := newJWKS(srv.URL, time.Hour)
+
+	_, err := s.key(context.Background(), "k1")
+	assert.Equal(ErrUnknownKey, err)
+	mutex.Lock()
+	fail = false
+	mutex.Unlock()
+	_, err = s.key(context.Background(), "k1") // Backing off.
+	assert.Equal(ErrUnknownKey, err)
+	mutex.Lock()
+	assert.Equal(1, fetches)
+	mutex.Unlock()
+
+	s.failed = time.Time{}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.key(ctx, "k1")
+			assert.NoError(err)
+		}()
+	}
+	cancel() // Canceling the request does not fail the fetch.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	mutex.Lock()
+	assert.Equal(2, fetches) // Merged.
+	mutex.Unlock()
+}
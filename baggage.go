@@ -0,0 +1,40 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// Baggage returns the W3C Baggage of the context.
+// In Lambda context that is the baggage received in the request.
+func Baggage(ctx context.Context) baggage.Baggage {
+	return baggage.FromContext(ctx)
+}
+
+// BaggageValue returns the value of a W3C Baggage member of the context. Returns empty string if not found.
+//
+//	tenant := restful.BaggageValue(ctx, "tenant")
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
+
+// ContextWithBaggageValue returns a context with the W3C Baggage member set.
+// Client functions send the baggage of the context in the request header.
+//
+//	ctx, err = restful.ContextWithBaggageValue(ctx, "tenant", "a")
+func ContextWithBaggageValue(ctx context.Context, key, value string) (context.Context, error) {
+	member, err := baggage.NewMember(key, value)
+	if err != nil {
+		return ctx, err
+	}
+	b, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, err
+	}
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
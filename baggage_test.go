@@ -0,0 +1,45 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaggagePropagation(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = SendResp(w, r, nil, r.Header.Get("Baggage"))
+	}))
+	defer backend.Close()
+
+	r := NewRouter()
+	r.HandleFunc("/", func(ctx context.Context) (string, error) {
+		assert.Equal("a", BaggageValue(ctx, "tenant"))
+		ctx, err := ContextWithBaggageValue(ctx, "user", "joe")
+		assert.NoError(err)
+		_, err = ContextWithBaggageValue(ctx, "bad key", "x")
+		assert.Error(err)
+
+		var received string
+		err = Get(ctx, backend.URL, &received)
+		return received, err
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	var baggage string
+	_, err := NewClient().SendRecv2xx(context.Background(), http.MethodGet, srv.URL, http.Header{"Baggage": {"tenant=a"}}, nil, &baggage)
+	assert.NoError(err)
+	assert.Contains(baggage, "tenant=a")
+	assert.Contains(baggage, "user=joe")
+	assert.Zero(Baggage(context.Background()).Len())
+}
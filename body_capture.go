@@ -0,0 +1,295 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// ErrUnknownBodyCapture is returned if no body capture is found by the name given.
+var ErrUnknownBodyCapture = errors.New("unknown body capture")
+
+// CapturedExchange is a request and its response captured by BodyCapture.
+// Secret headers, JSON fields and form parameters are redacted, see DumpRedactedHeaders, DumpRedactedJSONFields and DumpRedactedParams.
+type CapturedExchange struct {
+	Time           time.Time `json:"time"`
+	RequestID      string    `json:"request_id,omitempty"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Route          string    `json:"route,omitempty"`
+	StatusCode     int       `json:"status"`
+	LatencyMs      float64   `json:"latency_ms"`
+	RequestHeader  string    `json:"request_header"`
+	RequestBody    string    `json:"request_body,omitempty"`
+	ResponseHeader string    `json:"response_header"`
+	ResponseBody   string    `json:"response_body,omitempty"`
+}
+
+// BodyCapture captures request and response bodies of the requests matching its filter, for troubleshooting.
+// Bodies are truncated and secrets are redacted. Captured exchanges are logged at info level, and/or kept in a ring buffer,
+// readable by Captured or on the admin endpoint by name.
+// Only the part of the request body read by the handler is captured.
+//
+//	capture := restful.NewBodyCapture("users").Routes("/users/{id}").Sample(0.1).Buffer(100)
+//	router.BodyCapture(capture)
+//
+// Capturing costs memory and CPU, so use it for a limited set of requests, e.g. by a debug header.
+type BodyCapture struct {
+	name        string
+	routes      []string
+	header      string
+	headerValue string
+	fraction    float64
+	maxLen      int
+	log         bool
+
+	mutex sync.Mutex
+	ring  []CapturedExchange
+	next  int
+	full  bool
+}
+
+var (
+	bodyCapturesMutex sync.Mutex
+	bodyCaptures      = map[string]*BodyCapture{}
+)
+
+// NewBodyCapture creates a body capture, capturing all the requests, logging them, with bodies truncated to DumpBodyMaxLen.
+// Name identifies the capture, e.g. at CapturedBodies. Creating another capture by the same name replaces the former one there.
+func NewBodyCapture(name string) *BodyCapture {
+	c := &BodyCapture{name: name, fraction: 1, maxLen: DumpBodyMaxLen, log: true}
+	bodyCapturesMutex.Lock()
+	defer bodyCapturesMutex.Unlock()
+	bodyCaptures[name] = c
+	return c
+}
+
+// Routes restricts capturing to the route templates given, e.g. "/users/{id}".
+// Requests not matching a route are not captured then.
+func (c *BodyCapture) Routes(templates ...string) *BodyCapture {
+	c.routes = templates
+	return c
+}
+
+// Header restricts capturing to requests having the header, e.g. a debug header set by the tester.
+// Empty value means any value.
+func (c *BodyCapture) Header(name, value string) *BodyCapture {
+	c.header = name
+	c.headerValue = value
+	return c
+}
+
+// Sample sets the fraction of matching requests captured. E.g. 0.01 for 1%. Default is 1, i.e. all.
+func (c *BodyCapture) Sample(fraction float64) *BodyCapture {
+	c.fraction = fraction
+	return c
+}
+
+// MaxLen sets the max number of body bytes captured, per body.
+func (c *BodyCapture) MaxLen(maxLen int) *BodyCapture {
+	c.maxLen = max(maxLen, 0)
+	return c
+}
+
+// Log enables or disables logging captured exchanges. Enabled by default.
+func (c *BodyCapture) Log(enabled bool) *BodyCapture {
+	c.log = enabled
+	return c
+}
+
+// Buffer keeps the last size captured exchanges in memory, readable by Captured. Zero disables that, which is the default.
+func (c *BodyCapture) Buffer(size int) *BodyCapture {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ring = make([]CapturedExchange, max(size, 0))
+	c.next = 0
+	c.full = false
+	return c
+}
+
+// Captured returns the exchanges kept in the buffer, oldest first.
+func (c *BodyCapture) Captured() []CapturedExchange {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.full {
+		return slices.Clone(c.ring[:c.next])
+	}
+	return append(slices.Clone(c.ring[c.next:]), c.ring[:c.next]...)
+}
+
+// CapturedBodies returns the exchanges kept in the buffer of the body capture of the name given, oldest first.
+func CapturedBodies(name string) ([]CapturedExchange, error) {
+	bodyCapturesMutex.Lock()
+	c, ok := bodyCaptures[name]
+	bodyCapturesMutex.Unlock()
+	if !ok {
+		return nil, ErrUnknownBodyCapture
+	}
+	return c.Captured(), nil
+}
+
+// BodyCaptureNames returns the names of body captures, sorted.
+func BodyCaptureNames() []string {
+	bodyCapturesMutex.Lock()
+	defer bodyCapturesMutex.Unlock()
+	names := make([]string, 0, len(bodyCaptures))
+	for name := range bodyCaptures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchesBeforeServing checks the filters known before routing.
+func (c *BodyCapture) matchesBeforeServing(r *http.Request) bool {
+	if c.header != "" {
+		if values, ok := r.Header[http.CanonicalHeaderKey(c.header)]; !ok || (c.headerValue != "" && !slices.Contains(values, c.headerValue)) {
+			return false
+		}
+	}
+	return c.fraction >= 1 || (c.fraction > 0 && rand.Float64() < c.fraction)
+}
+
+func (c *BodyCapture) matchesRoute(route string) bool {
+	return len(c.routes) == 0 || slices.Contains(c.routes, route)
+}
+
+// captureBuffer keeps the first max bytes written, remembering if there were more.
+type captureBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *captureBuffer) String() string {
+	s := string(redactDumpBody(b.buf.Bytes()))
+	if b.truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
+
+// captureReader copies what the handler reads from the request body.
+type captureReader struct {
+	io.ReadCloser
+	capture *captureBuffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.capture.Write(p[:n])
+	return n, err
+}
+
+// captureWriter copies the response body. Flush, Hijack and Unwrap are passed through by the embedded writer.
+type captureWriter struct {
+	*responseWriter
+	capture *captureBuffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	n, err := w.responseWriter.Write(b)
+	_, _ = w.capture.Write(b[:n])
+	return n, err
+}
+
+// ReadFrom copies via Write, so that the body is captured. Sendfile is not used then.
+func (w *captureWriter) ReadFrom(src io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{w}, src)
+}
+
+// Handler wraps the handler, capturing the requests matching.
+// If the handler is a Router, then routes are matched after serving.
+func (c *BodyCapture) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.matchesBeforeServing(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		r = WithRouteTemplate(r)
+		reqHeader := dumpHeader(r.Header)
+		reqBody := &captureBuffer{max: c.maxLen}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &captureReader{ReadCloser: r.Body, capture: reqBody}
+		}
+		cw := &captureWriter{responseWriter: &responseWriter{ResponseWriter: w}, capture: &captureBuffer{max: c.maxLen}}
+		h.ServeHTTP(cw, r)
+
+		route := RouteTemplate(r)
+		if !c.matchesRoute(route) {
+			return
+		}
+		c.add(r, CapturedExchange{
+			Time:           start,
+			RequestID:      RequestIDFromContext(r.Context()),
+			Method:         r.Method,
+			Path:           r.URL.Path,
+			Route:          route,
+			StatusCode:     cw.statusCode(),
+			LatencyMs:      float64(time.Since(start).Microseconds()) / 1000,
+			RequestHeader:  reqHeader,
+			RequestBody:    reqBody.String(),
+			ResponseHeader: dumpHeader(cw.Header()),
+			ResponseBody:   cw.capture.String(),
+		})
+	})
+}
+
+func (c *BodyCapture) add(r *http.Request, e CapturedExchange) {
+	if c.log {
+		logging.Logger().LogAttrs(r.Context(), slog.LevelInfo, "body capture",
+			slog.String("capture", c.name),
+			slog.String("method", e.Method),
+			slog.String("path", e.Path),
+			slog.Int("status", e.StatusCode),
+			slog.String("request_header", e.RequestHeader),
+			slog.String("request_body", e.RequestBody),
+			slog.String("response_header", e.ResponseHeader),
+			slog.String("response_body", e.ResponseBody),
+		)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.ring) == 0 {
+		return
+	}
+	c.ring[c.next] = e
+	c.next++
+	if c.next == len(c.ring) {
+		c.next = 0
+		c.full = true
+	}
+}
+
+// BodyCapture captures bodies of the requests served by the handlers added after calling this function, as Monitor does.
+// Subrouters inherit that, so capturing can be set per route group. See BodyCapture.
+func (r *Router) BodyCapture(c *BodyCapture) *Router {
+	r.monitors.appendWrapper(c.Handler)
+	return r
+}
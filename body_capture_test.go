@@ -0,0 +1,96 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyCapture(t *testing.T) {
+	assert := assert.New(t)
+	capture := NewBodyCapture("test").Routes("/users/{id}").MaxLen(40).Buffer(2).Log(false)
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+	router.HandleFunc("/other", func() {})
+	h := capture.Handler(router)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/users/1", `{"name":"joe","password":"secret","about":"a long text over the limit"}`)
+	assert.Equal(http.StatusCreated, w.Code)
+	assert.Contains(w.Body.String(), "a long text over the limit") // Response not altered.
+	post("/other", `{}`)
+
+	captured := capture.Captured()
+	if assert.Len(captured, 1) {
+		e := captured[0]
+		assert.Equal("/users/{id}", e.Route)
+		assert.Equal(http.StatusCreated, e.StatusCode)
+		assert.Contains(e.RequestHeader, "Authorization: REDACTED")
+		assert.Contains(e.ResponseHeader, "Set-Cookie: REDACTED")
+		assert.NotContains(e.RequestBody, "secret")
+		assert.True(strings.HasSuffix(e.RequestBody, "...(truncated)"))
+		assert.Equal(e.RequestBody, e.ResponseBody)
+	}
+
+	// Ring buffer keeps the last ones.
+	post("/users/2", `{}`)
+	post("/users/3", `{}`)
+	captured, err := CapturedBodies("test")
+	assert.NoError(err)
+	if assert.Len(captured, 2) {
+		assert.Equal("/users/2", captured[0].Path)
+		assert.Equal("/users/3", captured[1].Path)
+	}
+	_, err = CapturedBodies("nope")
+	assert.ErrorIs(err, ErrUnknownBodyCapture)
+	assert.Contains(BodyCaptureNames(), "test")
+}
+
+func TestBodyCaptureFilter(t *testing.T) {
+	assert := assert.New(t)
+	capture := NewBodyCapture("filter").Header("X-Debug", "1").Buffer(10)
+	router := NewRouter()
+	group := router.PathPrefix("/api").Subrouter().BodyCapture(capture)
+	group.HandleFunc("/data", func() map[string]string { return map[string]string{"a": "b"} })
+	h := NewServer().Handler(router).server.Handler
+
+	request := func(debug string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+		if debug != "" {
+			req.Header.Set("X-Debug", debug)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	request("")
+	request("0")
+	request("1")
+	captured := capture.Captured()
+	if assert.Len(captured, 1) {
+		assert.Equal("/api/data", captured[0].Route)
+		assert.JSONEq(`{"a":"b"}`, captured[0].ResponseBody)
+	}
+
+	NewBodyCapture("none").Sample(0).Handler(router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/data", nil))
+	captured, _ = CapturedBodies("none")
+	assert.Empty(captured)
+}
@@ -15,19 +15,17 @@ import (
 	"net"
 	"net/http"
 	"net/url"
-	"reflect"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/nokia/restful/jsonschema"
+	"github.com/nokia/restful/logging"
 	"github.com/nokia/restful/messagepack"
 	"github.com/nokia/restful/trace/tracecommon"
 	"github.com/nokia/restful/trace/tracedata"
-	"github.com/nokia/restful/trace/traceotel"
 	"github.com/nokia/restful/trace/tracer"
-	log "github.com/sirupsen/logrus"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/net/http2"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
@@ -141,7 +139,12 @@ type Client struct {
 		tokenMutex sync.RWMutex
 		client     *http.Client
 	}
-	msgpackUsage msgpackUsage
+	msgpackUsage   msgpackUsage
+	responseSchema *jsonschema.Schema
+	metrics        *clientMetrics
+	spanFilter     func(req *http.Request) bool
+	scp            *url.URL
+	dump           sync.Map
 }
 
 // NewClient creates a RESTful client instance.
@@ -180,7 +183,7 @@ func NewClientWInterface(networkInterface string) *Client {
 
 	var rt http.RoundTripper = t
 	if isTraced && tracer.GetOTel() {
-		rt = otelhttp.NewTransport(t)
+		rt = newOTelTransport(t)
 	}
 
 	c := &Client{Kind: KindBasic}
@@ -188,6 +191,9 @@ func NewClientWInterface(networkInterface string) *Client {
 		Timeout:   10 * time.Second,
 		Transport: rt,
 	}
+	if tracer.GetOTelMetrics() {
+		c.Metrics()
+	}
 
 	c.acceptProblemJSON = true /* backward compatible */
 	return c
@@ -209,9 +215,12 @@ func NewH2ClientWInterface(networkInterface string) *Client {
 	c := &Client{Kind: KindH2}
 	var rt http.RoundTripper = getH2Transport(networkInterface)
 	if isTraced && tracer.GetOTel() {
-		rt = otelhttp.NewTransport(rt)
+		rt = newOTelTransport(rt)
 	}
 	c.Client = &http.Client{Transport: rt}
+	if tracer.GetOTelMetrics() {
+		c.Metrics()
+	}
 	return c
 }
 
@@ -222,9 +231,12 @@ func NewH2CClientWInterface(networkInterface string) *Client {
 	c := &Client{Kind: KindH2C}
 	var rt http.RoundTripper = getH2CTransport(networkInterface)
 	if isTraced && tracer.GetOTel() {
-		rt = otelhttp.NewTransport(rt)
+		rt = newOTelTransport(rt)
 	}
 	c.Client = &http.Client{Transport: rt}
+	if tracer.GetOTelMetrics() {
+		c.Metrics()
+	}
 	return c
 }
 
@@ -236,8 +248,8 @@ func getH2Transport(iface string) *http2.Transport {
 
 func getH2CTransport(iface string) *http2.Transport {
 	return &http2.Transport{
-		AllowHTTP: true,
-		DialTLSContext:   getDialTLSCallback(iface, false),
+		AllowHTTP:      true,
+		DialTLSContext: getDialTLSCallback(iface, false),
 	}
 }
 
@@ -369,6 +381,15 @@ func (c *Client) Retry(retries int, backoffInit time.Duration, backoffMax time.D
 	return c
 }
 
+// SpanFilter sets a filter telling whether OpenTelemetry client spans are created for a request, e.g. to suppress spans of noisy targets.
+// The filter returns false to suppress. Trace headers are propagated anyway.
+//
+//	client := restful.NewClient().SpanFilter(func(req *http.Request) bool { return req.URL.Path != "/heartbeat" })
+func (c *Client) SpanFilter(filter func(req *http.Request) bool) *Client {
+	c.spanFilter = filter
+	return c
+}
+
 // Timeout sets client timeout.
 // Timeout and request context timeout are similar concepts.
 // However, Timeout specified here applies to a single attempt, i.e. if Retry is used, then applies to each attempt separately, while context applies to all attempts together.
@@ -406,11 +427,11 @@ func (c *Client) SetOauth2Conf(config oauth2.Config, tokenClient *http.Client, g
 		tokenURL, err := url.Parse(config.Endpoint.TokenURL)
 		if err == nil {
 			if !c.httpsCfg.isAllowed(tokenURL) {
-				log.Error("token URL: ", ErrNonHTTPSURL)
+				logging.Errorf(context.Background(), "token URL: %v", ErrNonHTTPSURL)
 				return c
 			}
 		} else {
-			log.Error("token URL is not valid: ", err)
+			logging.Errorf(context.Background(), "token URL is not valid: %v", err)
 		}
 	}
 	if len(grant) > 0 {
@@ -446,21 +467,25 @@ func errDeadlineOrCancel(err error) bool {
 	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
 }
 
-func traceFromContext(ctx context.Context) (trace tracedata.TraceData) {
-	if l := L(ctx); l != nil {
-		trace = l.Trace
-	} else if tracer.GetOTel() {
-		trace = traceotel.NewFromContext(ctx)
+// traceFromContext returns the trace data of the context, or untyped nil if not found.
+// Callers may compare the result to nil, without reflection.
+func traceFromContext(ctx context.Context) tracedata.TraceData {
+	if l := L(ctx); l != nil && l.Trace != nil {
+		if t, ok := l.Trace.(*tracer.Tracer); !ok || t != nil {
+			return l.Trace
+		}
 	}
-	return
+	if t := tracer.FromContext(ctx); t != nil {
+		return t
+	}
+	return nil
 }
 
-func traceFromContextOrRequestOrRandom(req *http.Request) (trace tracedata.TraceData) {
-	trace = traceFromContext(req.Context())
-	if trace == nil || reflect.ValueOf(trace).IsNil() {
-		trace = tracer.NewFromRequestOrRandom(req)
+func traceFromContextOrRequestOrRandom(req *http.Request) tracedata.TraceData {
+	if trace := traceFromContext(req.Context()); trace != nil {
+		return trace
 	}
-	return
+	return tracer.NewFromRequestOrRandom(req)
 }
 
 func doSpan(req *http.Request) (*http.Request, string) {
@@ -537,7 +562,7 @@ func (c *Client) obtainOauth2Token(ctx context.Context) error {
 			token, err = conf.TokenSource(oauthCtx).Token()
 		}
 		if err != nil {
-			log.Error(err)
+			logging.Errorf(ctx, "%v", err)
 			return err
 		}
 		c.oauth2.token = *token
@@ -559,6 +584,17 @@ func (c *Client) setOauth2Auth(ctx context.Context, req *http.Request) error {
 	return nil
 }
 
+// setAuth sets the basic or OAuth2 authorization header of the request, if any.
+func (c *Client) setAuth(ctx context.Context, req *http.Request) error {
+	if c.oauth2.config != nil {
+		return c.setOauth2Auth(ctx, req)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return nil
+}
+
 // Do sends an HTTP request and returns an HTTP response.
 // All the rules of http.Client.Do() apply.
 // If URL of req is relative path then root defined at client.Root is added as prefix.
@@ -573,20 +609,24 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 		req.Header = make(http.Header)
 	}
 
+	if err := c.rerouteSbi(req); err != nil {
+		return nil, err
+	}
+
 	target, err := c.setReqTarget(req)
 	if err != nil {
 		return nil, err
 	}
 
+	c.setSbi(req)
+
 	c.setUA(req)
+	tracer.SetBaggageHeader(ctx, req.Header)
+	setRequestIDHeader(ctx, req.Header)
+	setTenantHeader(ctx, req.Header)
 
-	if c.username != "" && c.oauth2.config == nil {
-		req.SetBasicAuth(c.username, c.password)
-	}
-	if c.oauth2.config != nil {
-		if err := c.setOauth2Auth(ctx, req); err != nil {
-			return nil, err
-		}
+	if err := c.setAuth(ctx, req); err != nil {
+		return nil, err
 	}
 
 	for i := len(c.monitor) - 1; i >= 0; i-- {
@@ -599,7 +639,14 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	}
 
 	req, spanStr := doSpan(req)
-	resp, err := c.doLog(spanStr, req, target)
+	dump := c.dumpEnabled(req)
+	if dump {
+		c.dumpRequest(spanStr, req)
+	}
+	resp, err := c.doMetrics(spanStr, req, target)
+	if dump {
+		c.dumpResponse(spanStr, req, resp, err)
+	}
 
 	for i := 0; i < len(c.monitor); i++ {
 		if c.monitor[i].post != nil {
@@ -613,20 +660,27 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	return resp, err
 }
 
-func (c *Client) doWithRetry(req *http.Request, spanStr, target string) (*http.Response, error) {
+func (c *Client) doWithRetry(req *http.Request, spanStr, target string) (resp *http.Response, err error) {
+	req, endSpan := c.startAttemptsSpan(req)
+	defer func() { endSpan(resp, err) }()
+
 	clonedBody := c.cloneBody(req)
-	resp, err := c.do(req)
+	resp, err = c.do(req)
 
 	for retries := 0; retries < c.retries && !errDeadlineOrCancel(err) && retryResp(resp); retries++ { // Gateway error or overload responses.
 		if resp != nil {
 			_ = resp.Body.Close()
 		}
 
+		reason := retryReason(resp, err)
 		req.Body = clonedBody
 		clonedBody = c.cloneBody(req)
 
-		time.Sleep(c.calcBackoff(retries))
-		log.Debugf("[%s] Send rty(%d): %s %s: err=%v", spanStr, retries, req.Method, target, err)
+		wait := c.calcBackoff(retries)
+		time.Sleep(wait)
+		c.countRetry(req)
+		req = withRetryInfo(req, retries+1, wait, reason)
+		logging.Debugf(req.Context(), "[%s] Send rty(%d): %s %s: err=%v", spanStr, retries, req.Method, target, err)
 		resp, err = c.do(req)
 	}
 
@@ -634,12 +688,12 @@ func (c *Client) doWithRetry(req *http.Request, spanStr, target string) (*http.R
 }
 
 func (c *Client) doLog(spanStr string, req *http.Request, target string) (*http.Response, error) {
-	log.Debugf("[%s] Sent req: %s %s", spanStr, req.Method, target)
+	logging.Debugf(req.Context(), "[%s] Sent req: %s %s", spanStr, req.Method, target)
 	resp, err := c.doWithRetry(req, spanStr, target)
 	if err != nil {
-		log.Debugf("[%s] Fail req: %s %s", spanStr, req.Method, target)
+		logging.Debugf(req.Context(), "[%s] Fail req: %s %s", spanStr, req.Method, target)
 	} else {
-		log.Debugf("[%s] Recv rsp: %s", spanStr, resp.Status)
+		logging.Debugf(req.Context(), "[%s] Recv rsp: %s", spanStr, resp.Status)
 	}
 	return resp, err
 }
@@ -806,7 +860,7 @@ func (c *Client) SendRecv(ctx context.Context, method string, target string, hea
 
 	c.setMsgPackUse(resp)
 
-	return resp, GetResponseData(resp, c.maxBytesToParse, respData)
+	return resp, c.getResponseData(ctx, resp, respData)
 }
 
 // SendRecv2xx sends request with given data and returns response data and expects a 2xx response code.
@@ -835,7 +889,7 @@ func (c *Client) SendRecv2xx(ctx context.Context, method string, target string,
 
 	c.setMsgPackUse(resp)
 
-	return resp, GetResponseData(resp, c.maxBytesToParse, respData)
+	return resp, c.getResponseData(ctx, resp, respData)
 }
 
 // BroadcastRequest sends a HTTP request with JSON data to all of the IP addresses received in the DNS response
@@ -1008,17 +1062,17 @@ func getIPFromInterface(networkInterface string) (theIPs localIPs) {
 	}
 	ifaces, err := netInterfaces()
 	if err != nil {
-		log.Errorf("getIpFromInterface: %+v", err.Error())
+		logging.Errorf(context.Background(), "getIpFromInterface: %+v", err.Error())
 		return
 	}
-	log.Debugf("netInterfaces: %+v", ifaces)
+	logging.Debugf(context.Background(), "netInterfaces: %+v", ifaces)
 	for _, i := range ifaces {
 		if i.Name != networkInterface {
 			continue
 		}
 		addrs, err := netInterfaceAddrs(&i) // #nosec G601
 		if err != nil {
-			log.Errorf("getIpFromInterface: %+v", err.Error())
+			logging.Errorf(context.Background(), "getIpFromInterface: %+v", err.Error())
 			continue
 		}
 		for _, a := range addrs {
@@ -0,0 +1,106 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// BroadcastResult is the outcome of sending a request to one of the targets of a broadcast.
+type BroadcastResult struct {
+	// Target is the URL the request was sent to.
+	Target string
+
+	// Response is the received response, if any.
+	// Its body is read into memory, so it can be processed after the broadcast completed, e.g. by GetResponseData.
+	Response *http.Response
+
+	// Err is the error of the request. Similarly to SendRecv2xx, non-2xx responses are errors, too.
+	Err error
+}
+
+// BroadcastResults is the list of results of a broadcast, in the order of the targets.
+type BroadcastResults []BroadcastResult
+
+// Err returns the joined errors of all the failed targets, or nil if all succeeded.
+func (results BroadcastResults) Err() error {
+	var errs []error
+	for i := range results {
+		if results[i].Err != nil {
+			errs = append(errs, DetailError(results[i].Err, "error at target "+results[i].Target))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Failed returns the results of the targets that failed.
+func (results BroadcastResults) Failed() BroadcastResults {
+	var failed BroadcastResults
+	for i := range results {
+		if results[i].Err != nil {
+			failed = append(failed, results[i])
+		}
+	}
+	return failed
+}
+
+func (c *Client) broadcastOne(ctx context.Context, method string, target string, headers http.Header, body *[]byte) BroadcastResult {
+	result := BroadcastResult{Target: target}
+	resp, err := c.sendRequestBytes(ctx, method, target, headers, body, false)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	respBody, err := GetDataBytes(resp.Header, resp.Body, c.maxBytesToParse)
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	result.Response = resp
+	if err != nil {
+		result.Err = err
+	} else if resp.StatusCode >= 300 {
+		result.Err = NewErrorWithBody(nil, resp.StatusCode, resp.Header.Get(ContentTypeHeader), respBody)
+	}
+	return result
+}
+
+// BroadcastListRequest sends the same request to all the targets concurrently and waits for all of them to complete.
+// Unlike SendRecvListFirst2xxParallel, it does not stop at the first positive response,
+// but collects the result of each target. Useful for e.g. cache invalidation or configuration push.
+// Non-2xx responses are reported as errors in the per-target results. See BroadcastResults.Err.
+func (c *Client) BroadcastListRequest(ctx context.Context, method string, targets []string, headers http.Header, reqData any) (BroadcastResults, error) {
+	body, err := c.makeBodyBytes(reqData)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(BroadcastResults, len(targets))
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	for i := range targets {
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.broadcastOne(ctx, method, targets[i], headers, &body)
+		}(i)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// BroadcastResolveRequest sends the same request to all the resolved servers of the target concurrently, such as of Kubernetes headless service.
+// Unlike BroadcastRequest, requests are sent in parallel and the result of each server is collected. See BroadcastListRequest.
+func (c *Client) BroadcastResolveRequest(ctx context.Context, method string, target string, headers http.Header, reqData any) (BroadcastResults, error) {
+	targets, err := c.target2URLs(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.BroadcastListRequest(ctx, method, targets, headers, reqData)
+}
@@ -0,0 +1,94 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastListRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	type reqType struct {
+		Hello string `json:"hello"`
+	}
+
+	type respType struct {
+		ID int `json:"id"`
+	}
+
+	srvs := make([]*httptest.Server, 5)
+	srvURLs := make([]string, len(srvs))
+	for i := range srvs {
+		srvs[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recvd, err := io.ReadAll(r.Body)
+			assert.NoError(err)
+			assert.Equal(`{"hello":"Hello"}`, string(recvd))
+			if i == 3 {
+				SendResp(w, r, NewError(nil, http.StatusServiceUnavailable, "busy"), nil)
+				return
+			}
+			SendResp(w, r, nil, respType{ID: i})
+		}))
+		defer srvs[i].Close()
+		srvURLs[i] = srvs[i].URL + "/" + strconv.Itoa(i)
+	}
+	srvURLs = append(srvURLs, "http://127.0.0.1:0/unreachable")
+
+	results, err := NewClient().BroadcastListRequest(context.Background(), http.MethodPost, srvURLs, nil, &reqType{Hello: "Hello"})
+	assert.NoError(err)
+	assert.Len(results, len(srvURLs))
+	assert.Error(results.Err())
+	assert.Len(results.Failed(), 2)
+
+	for i := range srvs {
+		assert.Equal(srvURLs[i], results[i].Target)
+		if i == 3 {
+			assert.Equal(http.StatusServiceUnavailable, GetErrStatusCode(results[i].Err))
+			continue
+		}
+		assert.NoError(results[i].Err)
+		var resp respType
+		assert.NoError(GetResponseData(results[i].Response, 0, &resp))
+		assert.Equal(i, resp.ID)
+	}
+
+	assert.Error(results[len(srvs)].Err)
+	assert.Nil(results[len(srvs)].Response)
+}
+
+func TestBroadcastListRequestAllOK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	results, err := NewClient().BroadcastListRequest(context.Background(), http.MethodDelete, []string{srv.URL + "/a", srv.URL + "/b"}, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, results.Err())
+	assert.Empty(t, results.Failed())
+}
+
+func TestBroadcastResolveRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	results, err := NewClient().Root(srv.URL).BroadcastResolveRequest(context.Background(), http.MethodPut, "/cache", nil, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results)
+	assert.NoError(t, results.Err())
+
+	_, err = NewClient().BroadcastResolveRequest(context.Background(), http.MethodPut, ":::-1", nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,174 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nokia/restful/logging"
+)
+
+var (
+	// DumpBodyMaxLen is the max number of body bytes dumped.
+	DumpBodyMaxLen = 1024
+
+	// DumpRedactedHeaders are the headers which values are not dumped.
+	DumpRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+	// DumpRedactedJSONFields are the JSON object fields, at any depth, which values are not dumped.
+	DumpRedactedJSONFields = []string{"password", "client_secret", "access_token", "refresh_token", "id_token"}
+
+	// DumpRedactedParams are the query and form parameters which values are not dumped.
+	DumpRedactedParams = []string{"password", "client_secret", "access_token", "refresh_token", "id_token", "api_key"}
+)
+
+const dumpRedacted = "REDACTED"
+
+var (
+	// dumpJSONFieldRegexp matches a string JSON field, capturing the part before the value and the name.
+	dumpJSONFieldRegexp = regexp.MustCompile(`("((?:[^"\\]|\\.)*)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+	// dumpParamRegexp matches a query or form parameter, capturing the part before the value and the name.
+	dumpParamRegexp = regexp.MustCompile(`((?:^|[?&])([^=&]*)=)[^&]*`)
+)
+
+func dumpSecret(secrets []string, name string) bool {
+	return slices.ContainsFunc(secrets, func(s string) bool { return strings.EqualFold(s, name) })
+}
+
+// Dump enables or disables dumping outbound requests and responses to the target host to the logger, at info level.
+// Host may contain port, e.g. "example.com:8080". Empty host means all the targets.
+// Headers and bodies are dumped, with secrets redacted and bodies truncated to DumpBodyMaxLen.
+// Can be switched at runtime, for troubleshooting interop issues in production.
+//
+//	client.Dump("nrf.5gc", true)
+func (c *Client) Dump(host string, enabled bool) *Client {
+	if enabled {
+		c.dump.Store(host, true)
+	} else {
+		c.dump.Delete(host)
+	}
+	return c
+}
+
+func (c *Client) dumpEnabled(req *http.Request) bool {
+	for _, host := range []string{"", req.URL.Host, req.URL.Hostname()} {
+		if _, ok := c.dump.Load(host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func dumpHeader(header http.Header) string {
+	var sb strings.Builder
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		values := header[k]
+		if dumpSecret(DumpRedactedHeaders, k) {
+			values = []string{dumpRedacted}
+		}
+		for _, v := range values {
+			sb.WriteString(k + ": " + v + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// redactMatches replaces the values of matches of re, which name is a secret. Names are compared unescaped.
+// The first group of re is the part before the value, the second one is the name.
+func redactMatches(re *regexp.Regexp, b []byte, secrets []string, redacted string) []byte {
+	return re.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := re.FindSubmatch(m)
+		name := string(sub[2])
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !dumpSecret(secrets, name) {
+			return m
+		}
+		return append(slices.Clip(sub[1]), redacted...)
+	})
+}
+
+// redactDumpBody replaces string values of secret JSON fields, and values of secret form parameters.
+// Works on truncated bodies, too.
+func redactDumpBody(body []byte) []byte {
+	body = redactMatches(dumpJSONFieldRegexp, body, DumpRedactedJSONFields, `"`+dumpRedacted+`"`)
+	return redactMatches(dumpParamRegexp, body, DumpRedactedParams, dumpRedacted)
+}
+
+// redactDumpURL returns the URL with values of secret query parameters and password replaced.
+func redactDumpURL(u *url.URL) string {
+	if u.RawQuery != "" {
+		redacted := *u
+		redacted.RawQuery = string(redactMatches(dumpParamRegexp, []byte(u.RawQuery), DumpRedactedParams, dumpRedacted))
+		u = &redacted
+	}
+	return u.Redacted()
+}
+
+// redactDumpError returns the error with the URL redacted, if any.
+func redactDumpError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		redacted := *urlErr
+		redacted.URL = redactDumpURL(u)
+		return &redacted
+	}
+	return err
+}
+
+// readDumpBody reads the first DumpBodyMaxLen bytes of the body. Read bytes are returned, too.
+func readDumpBody(r io.Reader) (read []byte, dump string) {
+	read, _ = io.ReadAll(io.LimitReader(r, int64(DumpBodyMaxLen)+1))
+	if len(read) > DumpBodyMaxLen {
+		return read, string(redactDumpBody(read[:DumpBodyMaxLen])) + "...(truncated)"
+	}
+	return read, string(redactDumpBody(read))
+}
+
+func (c *Client) dumpRequest(spanStr string, req *http.Request) {
+	var body string
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			_, body = readDumpBody(rc)
+			_ = rc.Close()
+		}
+	}
+	logging.Infof(req.Context(), "[%s] Dump req: %s %s\n%s\n%s", spanStr, req.Method, redactDumpURL(req.URL), dumpHeader(req.Header), body)
+}
+
+// dumpResponse dumps the response of the request. The body is read partially, and the response body is replaced to be readable from the beginning.
+func (c *Client) dumpResponse(spanStr string, req *http.Request, resp *http.Response, err error) {
+	if err != nil {
+		logging.Infof(req.Context(), "[%s] Dump rsp: error: %v", spanStr, redactDumpError(err))
+		return
+	}
+
+	var body string
+	if resp.Body != nil {
+		var read []byte
+		read, body = readDumpBody(resp.Body)
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(read), resp.Body), resp.Body}
+	}
+	logging.Infof(req.Context(), "[%s] Dump rsp: %s %s\n%s\n%s", spanStr, resp.Proto, resp.Status, dumpHeader(resp.Header), body)
+}
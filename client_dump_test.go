@@ -0,0 +1,76 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nokia/restful/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactDumpBody(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`{"a":"password","Password":"REDACTED","n":1,"id_token":"REDACTED"`, string(redactDumpBody([]byte(`{"a":"password","Password":"x\"y","n":1,"id_token":"trunc`))))
+	assert.Equal(`password=REDACTED&user=joe&client%5Fsecret=REDACTED`, string(redactDumpBody([]byte(`password=x&user=joe&client%5Fsecret=y`))))
+}
+
+func TestClientDump(t *testing.T) {
+	assert := assert.New(t)
+	var logs bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer logging.SetLogger(nil)
+
+	long := strings.Repeat("x", DumpBodyMaxLen)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = SendResp(w, r, nil, map[string]string{"access_token": "secret-token", "long": long})
+	}))
+	defer srv.Close()
+
+	client := NewClient().SetBasicAuth("user", "secret-pwd").Dump(strings.TrimPrefix(srv.URL, "http://"), true)
+	var resp map[string]string
+	_, err := client.Post(context.Background(), srv.URL+"/login", map[string]string{"user": "joe", "password": "secret-pwd"}, &resp)
+	assert.NoError(err)
+	assert.Equal("secret-token", resp["access_token"]) // Body is intact.
+	assert.Equal(long, resp["long"])
+
+	out := logs.String()
+	assert.Contains(out, "Dump req: POST "+srv.URL+"/login")
+	assert.Contains(out, "Dump rsp: HTTP/1.1 200 OK")
+	assert.Contains(out, "Authorization: REDACTED")
+	assert.Contains(out, "truncated")
+	assert.NotContains(out, "secret-")
+
+	logs.Reset()
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	form := "grant_type=client_credentials&client_secret=secret-form&scope=a"
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/token?api_key=secret-query&x=1", strings.NewReader(form))
+	req.Header.Set(ContentTypeHeader, "application/x-www-form-urlencoded")
+	_, err = client.Do(req)
+	assert.NoError(err)
+	out = logs.String()
+	assert.Contains(out, "/token?api_key=REDACTED&x=1")
+	assert.Contains(out, "client_secret=REDACTED&scope=a")
+	assert.Equal(2, strings.Count(out, "request_id=req-1")) // Response is dumped by the logger of the request, too.
+	assert.NotContains(out, "secret-")
+
+	logs.Reset()
+	err = client.Dump("127.0.0.1:1", true).Get(ctx, "http://127.0.0.1:1/?password=secret-pwd", nil)
+	assert.Error(err)
+	assert.Contains(logs.String(), "Dump rsp: error: ")
+	assert.NotContains(logs.String(), "secret-")
+
+	logs.Reset()
+	client.Dump(strings.TrimPrefix(srv.URL, "http://"), false)
+	_, err = client.Post(context.Background(), srv.URL+"/login", nil, &resp)
+	assert.NoError(err)
+	assert.NotContains(logs.String(), "Dump")
+}
@@ -0,0 +1,78 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+/* Typed variants of the client functions.
+ * Response data is returned instead of being written to an output parameter, e.g.
+ *
+ *	user, err := restful.GetAs[User](ctx, client, "/users/1")
+ *
+ * Client parameter may be nil, then the default client is used.
+ */
+
+func clientOrDefault(c *Client) *Client {
+	if c == nil {
+		return defaultClient
+	}
+	return c
+}
+
+func reqDataAny[Req any](reqData Req) any {
+	v := reflect.ValueOf(reqData)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil
+	}
+	return reqData
+}
+
+// SendRecv2xxAs sends a request with given data and returns the decoded response data, expecting a 2xx response code.
+// Similar to SendRecv2xx. Received response is returned, too. E.g. resp.Header["Location"].
+// If c is nil, then the default client is used.
+func SendRecv2xxAs[Req, Resp any](ctx context.Context, c *Client, method string, target string, headers http.Header, reqData Req) (Resp, *http.Response, error) {
+	var respData Resp
+	resp, err := clientOrDefault(c).SendRecv2xx(ctx, method, target, headers, reqDataAny(reqData), &respData)
+	return respData, resp, err
+}
+
+// GetAs gets a resource and returns it decoded.
+// If c is nil, then the default client is used.
+func GetAs[Resp any](ctx context.Context, c *Client, target string) (Resp, error) {
+	var respData Resp
+	err := clientOrDefault(c).Get(ctx, target, &respData)
+	return respData, err
+}
+
+// PostAs sends a POST request and returns the decoded response data and the Location of the created resource.
+// Location may be nil.
+// If c is nil, then the default client is used.
+func PostAs[Req, Resp any](ctx context.Context, c *Client, target string, reqData Req) (Resp, *url.URL, error) {
+	var respData Resp
+	location, err := clientOrDefault(c).Post(ctx, target, reqDataAny(reqData), &respData)
+	return respData, location, err
+}
+
+// PutAs updates a resource and returns the decoded response data. Might return Location of the created resource, otherwise nil.
+// If c is nil, then the default client is used.
+func PutAs[Req, Resp any](ctx context.Context, c *Client, target string, reqData Req) (Resp, *url.URL, error) {
+	var respData Resp
+	location, err := clientOrDefault(c).Put(ctx, target, reqDataAny(reqData), &respData)
+	return respData, location, err
+}
+
+// PatchAs partially updates a resource and returns the decoded response data.
+// See Patch for Content-Type selection.
+// If c is nil, then the default client is used.
+func PatchAs[Req, Resp any](ctx context.Context, c *Client, target string, reqData Req) (Resp, error) {
+	var respData Resp
+	err := clientOrDefault(c).Patch(ctx, target, reqDataAny(reqData), &respData)
+	return respData, err
+}
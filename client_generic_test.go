@@ -0,0 +1,94 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type genericUser struct {
+	Name string `json:"name"`
+	ID   int    `json:"id,omitempty"`
+}
+
+func newGenericServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			SendResp(w, r, nil, genericUser{Name: "Joe", ID: 1})
+		case http.MethodPost:
+			var u genericUser
+			assert.NoError(t, GetRequestData(r, 0, &u))
+			u.ID = 2
+			w.Header().Set("Location", "/users/2")
+			SendResp(w, r, nil, u)
+		case http.MethodPut, http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			assert.Empty(t, body)
+			SendResp(w, r, nil, nil)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+func TestGetAs(t *testing.T) {
+	assert := assert.New(t)
+	srv := newGenericServer(t)
+	defer srv.Close()
+
+	u, err := GetAs[genericUser](context.Background(), NewClient().Root(srv.URL), "/users/1")
+	assert.NoError(err)
+	assert.Equal(genericUser{Name: "Joe", ID: 1}, u)
+
+	up, err := GetAs[*genericUser](context.Background(), nil, srv.URL+"/users/1")
+	assert.NoError(err)
+	assert.Equal("Joe", up.Name)
+}
+
+func TestPostAs(t *testing.T) {
+	assert := assert.New(t)
+	srv := newGenericServer(t)
+	defer srv.Close()
+
+	u, location, err := PostAs[genericUser, genericUser](context.Background(), NewClient().Root(srv.URL), "/users", genericUser{Name: "Jane"})
+	assert.NoError(err)
+	assert.Equal(genericUser{Name: "Jane", ID: 2}, u)
+	assert.Equal("/users/2", location.Path)
+}
+
+func TestPutPatchAs(t *testing.T) {
+	assert := assert.New(t)
+	srv := newGenericServer(t)
+	defer srv.Close()
+	c := NewClient().Root(srv.URL)
+
+	var nilUser *genericUser
+	_, _, err := PutAs[*genericUser, any](context.Background(), c, "/users/1", nilUser)
+	assert.NoError(err)
+
+	_, err = PatchAs[*genericUser, any](context.Background(), c, "/users/1", nil)
+	assert.NoError(err)
+}
+
+func TestSendRecv2xxAs(t *testing.T) {
+	assert := assert.New(t)
+	srv := newGenericServer(t)
+	defer srv.Close()
+
+	u, resp, err := SendRecv2xxAs[any, genericUser](context.Background(), NewClient(), http.MethodGet, srv.URL, nil, nil)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("Joe", u.Name)
+
+	_, _, err = SendRecv2xxAs[any, genericUser](context.Background(), NewClient(), http.MethodDelete, srv.URL, nil, nil)
+	assert.Error(err)
+}
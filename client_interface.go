@@ -0,0 +1,29 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ClientInterface contains the request sending methods of Client.
+// Accept this interface in your code instead of *Client, so that it can be replaced by a test double, such as restfultest.MockClient.
+type ClientInterface interface {
+	Do(req *http.Request) (*http.Response, error)
+	SendRequest(ctx context.Context, method string, target string, headers http.Header, data any) (*http.Response, error)
+	SendRecv(ctx context.Context, method string, target string, headers http.Header, reqData, respData any) (*http.Response, error)
+	SendRecv2xx(ctx context.Context, method string, target string, headers http.Header, reqData, respData any) (*http.Response, error)
+	Get(ctx context.Context, target string, respData any) error
+	Head(ctx context.Context, target string) (map[string][]string, error)
+	Post(ctx context.Context, target string, reqData, respData any) (*url.URL, error)
+	PostForm(ctx context.Context, target string, reqData url.Values, respData any) (*url.URL, error)
+	Put(ctx context.Context, target string, reqData, respData any) (*url.URL, error)
+	Patch(ctx context.Context, target string, reqData, respData any) error
+	Delete(ctx context.Context, target string) error
+}
+
+var _ ClientInterface = (*Client)(nil)
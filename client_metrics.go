@@ -0,0 +1,187 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MetricsScope is the instrumentation scope name of metrics emitted by restful.
+const MetricsScope = "github.com/nokia/restful"
+
+// StatusClassKey is the attribute key of the status class, e.g. "2xx", of a response.
+const StatusClassKey = attribute.Key("http.response.status_class")
+
+type clientMetrics struct {
+	duration   metric.Float64Histogram
+	active     metric.Int64UpDownCounter
+	retries    metric.Int64Counter
+	dials      metric.Int64Counter
+	inUseConns atomic.Int64
+}
+
+var (
+	clientMetricsMutex sync.Mutex
+	clientMetricsCache = map[metric.MeterProvider]*clientMetrics{}
+)
+
+// Metrics makes the client emit OpenTelemetry metrics using the global MeterProvider, see otel.SetMeterProvider.
+// Prometheus exposure is possible using the OpenTelemetry Prometheus exporter as MeterProvider reader.
+// Instruments are shared by the clients, so connection gauges tell the sum of all the clients with metrics.
+//
+//   - http.client.request.duration histogram, labeled by method, target host, status class and tenant of the context if TenantMetrics is set, see ContextWithTenant.
+//   - http.client.active_requests of requests being sent.
+//   - http.client.request.retries counter.
+//   - http.client.connection.dials counter of new connections.
+//   - http.client.connection.in_use gauge.
+//
+// Idle connections are not counted, as the transport does not tell when it closes them.
+// There are no circuit breaker metrics, as the client has no circuit breaker.
+func (c *Client) Metrics() *Client {
+	c.metrics = getClientMetrics()
+	return c
+}
+
+func getClientMetrics() *clientMetrics {
+	mp := otel.GetMeterProvider()
+	clientMetricsMutex.Lock()
+	defer clientMetricsMutex.Unlock()
+	if m, ok := clientMetricsCache[mp]; ok {
+		return m
+	}
+
+	meter := mp.Meter(MetricsScope)
+	m := &clientMetrics{}
+	m.duration, _ = meter.Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of HTTP client requests."), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	m.active, _ = meter.Int64UpDownCounter("http.client.active_requests",
+		metric.WithDescription("Number of active HTTP client requests."), metric.WithUnit("{request}"))
+	m.retries, _ = meter.Int64Counter("http.client.request.retries",
+		metric.WithDescription("Number of HTTP client request retries."), metric.WithUnit("{retry}"))
+	m.dials, _ = meter.Int64Counter("http.client.connection.dials",
+		metric.WithDescription("Number of new HTTP client connections."), metric.WithUnit("{connection}"))
+	inUse, _ := meter.Int64ObservableGauge("http.client.connection.in_use",
+		metric.WithDescription("Number of HTTP client connections serving a request."), metric.WithUnit("{connection}"))
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(inUse, m.inUseConns.Load())
+		return nil
+	}, inUse)
+	clientMetricsCache[mp] = m
+	return m
+}
+
+// durationBuckets are the histogram bucket boundaries of request durations, in seconds, as recommended by semantic conventions.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+func statusClass(statusCode int) string {
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case IsTimeout(err):
+		return "timeout"
+	case IsConnectionRefused(err):
+		return "connection_refused"
+	case IsDNSError(err):
+		return "dns"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+	return "_OTHER"
+}
+
+// traceConns adds connection pool tracking to the request context.
+// Returns a function to be called when the request is done.
+func (m *clientMetrics) traceConns(req *http.Request) (*http.Request, func()) {
+	var gotConn atomic.Bool
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				m.dials.Add(req.Context(), 1, metric.WithAttributes(semconv.ServerAddress(req.URL.Hostname())))
+			}
+			if !gotConn.Swap(true) {
+				m.inUseConns.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return req, func() {
+		if gotConn.Swap(false) {
+			m.inUseConns.Add(-1)
+		}
+	}
+}
+
+func (c *Client) doMetrics(spanStr string, req *http.Request, target string) (*http.Response, error) {
+	m := c.metrics
+	if m == nil {
+		return c.doLog(spanStr, req, target)
+	}
+
+	ctx := req.Context()
+	attrs := append([]attribute.KeyValue{semconv.HTTPRequestMethodKey.String(req.Method), semconv.ServerAddress(req.URL.Hostname())}, tenantAttributes(ctx)...)
+	m.active.Add(ctx, 1, metric.WithAttributes(attrs...))
+	req, done := m.traceConns(req)
+	span := &clientMetricsSpan{}
+	req = req.WithContext(context.WithValue(req.Context(), clientMetricsSpanCtxName, span))
+	start := time.Now()
+
+	resp, err := c.doLog(spanStr, req, target)
+
+	done()
+	m.active.Add(ctx, -1, metric.WithAttributes(attrs...))
+	if err != nil {
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType(err)))
+	} else {
+		attrs = append(attrs, StatusClassKey.String(statusClass(resp.StatusCode)))
+	}
+	if span.spanCtx.IsValid() { // Exemplar refers to the client span.
+		ctx = trace.ContextWithSpanContext(ctx, span.spanCtx)
+	}
+	m.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return resp, err
+}
+
+type clientMetricsSpanCtxKeyType string
+
+const clientMetricsSpanCtxName = clientMetricsSpanCtxKeyType("restfulClientMetricsSpan")
+
+// clientMetricsSpan is stored in the request context, so that the span of the last attempt can be referred by duration exemplars.
+type clientMetricsSpan struct {
+	spanCtx trace.SpanContext
+}
+
+// setClientMetricsSpan stores the span context of the client span, if client metrics are collected.
+func setClientMetricsSpan(req *http.Request, spanCtx trace.SpanContext) {
+	if span, ok := req.Context().Value(clientMetricsSpanCtxName).(*clientMetricsSpan); ok {
+		span.spanCtx = spanCtx
+	}
+}
+
+func (c *Client) countRetry(req *http.Request) {
+	if c.metrics != nil {
+		c.metrics.retries.Add(req.Context(), 1, metric.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.ServerAddress(req.URL.Hostname())))
+	}
+}
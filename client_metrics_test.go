@@ -0,0 +1,92 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func findMetric(rm metricdata.ResourceMetrics, name string) *metricdata.Metrics {
+	for _, sm := range rm.ScopeMetrics {
+		for i := range sm.Metrics {
+			if sm.Metrics[i].Name == name {
+				return &sm.Metrics[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestClientMetrics(t *testing.T) {
+	assert := assert.New(t)
+	reader := sdkmetric.NewManualReader()
+	prevProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prevProvider)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := NewClient().Root(srv.URL).Retry(1, 0, 0).Metrics()
+	err := client.Get(context.Background(), "/x", nil)
+	assert.Equal(http.StatusNotFound, GetErrStatusCode(err))
+	srv.Close()
+	assert.Error(client.Get(context.Background(), "/x", nil))
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &rm))
+
+	duration := findMetric(rm, "http.client.request.duration")
+	if assert.NotNil(duration) {
+		hist := duration.Data.(metricdata.Histogram[float64])
+		assert.Len(hist.DataPoints, 2)
+		classes := []string{}
+		for _, dp := range hist.DataPoints {
+			assert.Equal(uint64(1), dp.Count)
+			if v, ok := dp.Attributes.Value(StatusClassKey); ok {
+				classes = append(classes, v.AsString())
+			}
+			if v, ok := dp.Attributes.Value(attribute.Key("error.type")); ok {
+				classes = append(classes, v.AsString())
+			}
+			host, _ := dp.Attributes.Value(attribute.Key("server.address"))
+			assert.Equal("127.0.0.1", host.AsString())
+		}
+		assert.ElementsMatch([]string{"4xx", "connection_refused"}, classes)
+	}
+
+	retries := findMetric(rm, "http.client.request.retries")
+	if assert.NotNil(retries) {
+		// 503 and connection refused are both retried.
+		assert.Equal(int64(2), retries.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+	}
+
+	dials := findMetric(rm, "http.client.connection.dials")
+	if assert.NotNil(dials) {
+		assert.GreaterOrEqual(dials.Data.(metricdata.Sum[int64]).DataPoints[0].Value, int64(1))
+	}
+
+	inUse := findMetric(rm, "http.client.connection.in_use")
+	if assert.NotNil(inUse) {
+		assert.Equal(int64(0), inUse.Data.(metricdata.Gauge[int64]).DataPoints[0].Value)
+	}
+}
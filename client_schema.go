@@ -0,0 +1,67 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/nokia/restful/jsonschema"
+)
+
+// ErrResponseSchema is returned if a received response does not match the expected JSON schema.
+// It is joined with jsonschema.ValidationErrors, so use errors.Is() for checking and errors.As() for details.
+var ErrResponseSchema = errors.New("response schema violation")
+
+type responseSchemaCtxKeyType string
+
+const responseSchemaCtxName = responseSchemaCtxKeyType("restfulRespSchema")
+
+// ResponseSchema sets a JSON schema the 2xx JSON responses of the client are validated against.
+// Validation happens before decoding the response data, and ErrResponseSchema is returned on violation.
+// Useful in staging environments for catching upstream contract drift early.
+// See ContextWithResponseSchema for setting a schema per call.
+func (c *Client) ResponseSchema(schema *jsonschema.Schema) *Client {
+	c.responseSchema = schema
+	return c
+}
+
+// ContextWithResponseSchema returns a context that instructs the client functions to validate the response against the schema.
+// Overrides the schema set for the client by ResponseSchema.
+//
+//	err := client.Get(restful.ContextWithResponseSchema(ctx, userSchema), "/users/1", &user)
+func ContextWithResponseSchema(ctx context.Context, schema *jsonschema.Schema) context.Context {
+	return context.WithValue(ctx, responseSchemaCtxName, schema)
+}
+
+func (c *Client) getResponseSchema(ctx context.Context) *jsonschema.Schema {
+	if schema, ok := ctx.Value(responseSchemaCtxName).(*jsonschema.Schema); ok {
+		return schema
+	}
+	return c.responseSchema
+}
+
+// getResponseData is like GetResponseData, but validates the response against the schema set, if any.
+func (c *Client) getResponseData(ctx context.Context, resp *http.Response, respData any) error {
+	schema := c.getResponseSchema(ctx)
+	if schema == nil || resp.StatusCode < 200 || resp.StatusCode >= 300 || !isJSONContentType(GetBaseContentType(resp.Header)) {
+		return GetResponseData(resp, c.maxBytesToParse, respData)
+	}
+
+	body, err := GetDataBytes(resp.Header, resp.Body, c.maxBytesToParse)
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		if err := schema.Validate(body); err != nil {
+			return errors.Join(ErrResponseSchema, err)
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return GetResponseData(resp, 0, respData)
+}
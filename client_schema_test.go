@@ -0,0 +1,50 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nokia/restful/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientResponseSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			SendResp(w, r, nil, map[string]any{"id": "one"})
+			return
+		}
+		SendResp(w, r, nil, map[string]any{"id": 1})
+	}))
+	defer srv.Close()
+
+	schema := jsonschema.MustNew(`{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}`)
+	client := NewClient().Root(srv.URL).ResponseSchema(schema)
+
+	var data struct {
+		ID int `json:"id"`
+	}
+	assert.NoError(client.Get(context.Background(), "/good", &data))
+	assert.Equal(1, data.ID)
+
+	err := client.Get(context.Background(), "/bad", &data)
+	assert.ErrorIs(err, ErrResponseSchema)
+	var verrs jsonschema.ValidationErrors
+	assert.True(errors.As(err, &verrs))
+	assert.Equal("/id", verrs[0].InstancePath)
+
+	// Per-call schema overrides client's.
+	ctx := ContextWithResponseSchema(context.Background(), jsonschema.MustNew(`{"type":"object"}`))
+	assert.NoError(client.Get(ctx, "/bad", nil))
+	ctx = ContextWithResponseSchema(context.Background(), jsonschema.MustNew(`{"type":"array"}`))
+	assert.ErrorIs(NewClient().Root(srv.URL).Get(ctx, "/good", &data), ErrResponseSchema)
+}
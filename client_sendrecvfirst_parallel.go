@@ -78,7 +78,7 @@ func (c *Client) SendRecvListFirst2xxParallel(ctx context.Context, method string
 
 	select {
 	case resp := <-respChan:
-		return resp, GetResponseData(resp, c.maxBytesToParse, respData)
+		return resp, c.getResponseData(ctx, resp, respData)
 	case <-waitChan:
 		return nil, errors.New("no positive response")
 	case <-ctx.Done():
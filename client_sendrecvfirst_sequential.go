@@ -24,7 +24,7 @@ func (c *Client) SendRecvListFirst2xxSequential(ctx context.Context, method stri
 		if err != nil || resp.StatusCode >= 300 { // Errors are silently omitted
 			continue
 		}
-		return resp, GetResponseData(resp, c.maxBytesToParse, respData)
+		return resp, c.getResponseData(ctx, resp, respData)
 	}
 	return nil, errors.New("no positive response")
 }
@@ -5,6 +5,7 @@
 package restful
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"net/http"
@@ -12,8 +13,7 @@ import (
 	"path/filepath"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"github.com/nokia/restful/logging"
 	"golang.org/x/net/http2"
 )
 
@@ -29,7 +29,7 @@ func (c *Client) TLS(tlsConfig *tls.Config) *Client {
 		transport.TLSClientConfig = tlsConfig
 	} else {
 		if isTraced {
-			c.Client.Transport = otelhttp.NewTransport(&http.Transport{TLSClientConfig: tlsConfig})
+			c.Client.Transport = newOTelTransport(&http.Transport{TLSClientConfig: tlsConfig})
 		} else {
 			c.Client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
 		}
@@ -40,13 +40,13 @@ func (c *Client) TLS(tlsConfig *tls.Config) *Client {
 func appendCert(path string, pool *x509.CertPool) {
 	pem, err := os.ReadFile(path) // #nosec
 	if err != nil {
-		log.Errorf("Error reading CA from '%s': %v", path, err)
+		logging.Errorf(context.Background(), "Error reading CA from '%s': %v", path, err)
 		return
 	}
 	if !pool.AppendCertsFromPEM(pem) {
-		log.Errorf("Error parsing CA at '%s': %v", path, err)
+		logging.Errorf(context.Background(), "Error parsing CA at '%s': %v", path, err)
 	}
-	log.Debugf("Appended cert from '%s'", path)
+	logging.Debugf(context.Background(), "Appended cert from '%s'", path)
 }
 
 // NewCertPool adds PEM certificates from given path in a way that is usable at TLS() as RootCAs.
@@ -56,7 +56,8 @@ func appendCert(path string, pool *x509.CertPool) {
 func NewCertPool(path string, loadSystemCerts bool) *x509.CertPool {
 	pool, err := initialCertPool(loadSystemCerts)
 	if err != nil {
-		log.Fatalf("Failed to init certificate pool: %v", err)
+		logging.Errorf(context.Background(), "Failed to init certificate pool: %v", err)
+		os.Exit(1)
 	}
 
 	if path == "" {
@@ -75,7 +76,7 @@ func NewCertPool(path string, loadSystemCerts bool) *x509.CertPool {
 
 	err = filepath.Walk(path, walkFn)
 	if err != nil {
-		log.Errorf("Error finding CA files at '%s': %v", path, err)
+		logging.Errorf(context.Background(), "Error finding CA files at '%s': %v", path, err)
 	}
 
 	return pool
@@ -131,13 +132,45 @@ func (c *Client) TLSRootCerts(path string, loadSystemCerts bool) *Client {
 func (c *Client) TLSOwnCerts(dir string) *Client {
 	cert, err := tls.LoadX509KeyPair(dir+"/tls.crt", dir+"/tls.key")
 	if err != nil {
-		log.Errorf("Cannot load client cert+key: %v", err)
+		logging.Errorf(context.Background(), "Cannot load client cert+key: %v", err)
 	} else {
 		c.haveTLSClientConfig().Certificates = []tls.Certificate{cert}
 	}
 	return c
 }
 
+// TLSVersions sets the min and max TLS versions used, e.g. tls.VersionTLS13. Zero max means the latest supported.
+// Min is TLS 1.2 by default.
+func (c *Client) TLSVersions(minVersion, maxVersion uint16) *Client {
+	config := c.haveTLSClientConfig()
+	config.MinVersion = minVersion
+	config.MaxVersion = maxVersion
+	return c
+}
+
+// TLSCipherSuites sets the cipher suites of TLS 1.2 offered. See ParseCipherSuites for configuring by names.
+func (c *Client) TLSCipherSuites(suites ...uint16) *Client {
+	c.haveTLSClientConfig().CipherSuites = suites
+	return c
+}
+
+// TLSCurves sets the key exchange mechanisms, in preference order, e.g. tls.X25519.
+func (c *Client) TLSCurves(curves ...tls.CurveID) *Client {
+	c.haveTLSClientConfig().CurvePreferences = curves
+	return c
+}
+
+// TLSSessionCache sets the number of TLS sessions cached for resumption, making new connections to the same servers faster.
+// Zero disables resumption.
+func (c *Client) TLSSessionCache(size int) *Client {
+	if size > 0 {
+		c.haveTLSClientConfig().ClientSessionCache = tls.NewLRUClientSessionCache(size)
+	} else {
+		c.haveTLSClientConfig().ClientSessionCache = nil
+	}
+	return c
+}
+
 // Insecure makes client skip server name checking.
 func (c *Client) Insecure() *Client {
 	c.haveTLSClientConfig().InsecureSkipVerify = true
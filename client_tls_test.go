@@ -108,3 +108,23 @@ func TestAppendCert(t *testing.T) {
 	appendCert("kutyafüle", nil)
 	appendCert("client_tls_test.go", nil)
 }
+
+func TestClientTLSSettings(t *testing.T) {
+	assert := assert.New(t)
+	client := NewClient().TLSVersions(tls.VersionTLS13, tls.VersionTLS13).TLSCurves(tls.X25519).
+		TLSCipherSuites(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384).TLSSessionCache(10)
+	config := client.haveTLSClientConfig()
+	assert.Equal(uint16(tls.VersionTLS13), config.MinVersion)
+	assert.Equal(uint16(tls.VersionTLS13), config.MaxVersion)
+	assert.Equal([]tls.CurveID{tls.X25519}, config.CurvePreferences)
+	assert.Equal([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, config.CipherSuites)
+	assert.NotNil(config.ClientSessionCache)
+	assert.Nil(client.TLSSessionCache(0).haveTLSClientConfig().ClientSessionCache)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+	assert.Error(NewClient().Insecure().TLSVersions(tls.VersionTLS13, 0).Get(context.Background(), srv.URL, nil))
+	assert.NoError(NewClient().Insecure().TLSVersions(tls.VersionTLS12, 0).Get(context.Background(), srv.URL, nil))
+}
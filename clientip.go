@@ -0,0 +1,172 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+var trustedProxies atomic.Pointer[[]netip.Prefix]
+
+// SetTrustedProxies sets the addresses of the proxies trusted, e.g. load balancers and ingress controllers.
+// CIDRs such as "10.0.0.0/8", or single addresses.
+// Client IP is taken from Forwarded or X-Forwarded-For headers added by trusted proxies only, see ClientIP.
+// PROXY protocol headers are accepted from trusted proxies only, see Server's ProxyProtocol.
+// By default no proxies are trusted.
+func SetTrustedProxies(cidrs ...string) error {
+	prefixes, err := parsePrefixes(cidrs)
+	if err != nil {
+		return err
+	}
+	trustedProxies.Store(&prefixes)
+	return nil
+}
+
+// parsePrefixes parses CIDRs. Single addresses are accepted, too.
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("bad address %q: %w", cidr, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("bad CIDR %q: %w", cidr, err)
+		}
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(prefix.Bits()-96, 0))
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func prefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrustedProxy(addr netip.Addr) bool {
+	prefixes := trustedProxies.Load()
+	return prefixes != nil && prefixesContain(*prefixes, addr)
+}
+
+func trustedProxiesSet() bool {
+	prefixes := trustedProxies.Load()
+	return prefixes != nil && len(*prefixes) > 0
+}
+
+// parseAddr parses an IP address, with or without port, IPv6 with or without brackets.
+// Returns invalid address if cannot be parsed, e.g. "unknown".
+func parseAddr(s string) netip.Addr {
+	s = strings.TrimSpace(s)
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	addr, _ := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	return addr.Unmap()
+}
+
+// forwardedFor returns the addresses of the hops, client first, as received in Forwarded header (RFC 7239), or if not present, in X-Forwarded-For header.
+// Invalid address is returned for hops not identified, e.g. "unknown" or obfuscated.
+func forwardedFor(header http.Header) (hops []netip.Addr) {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+					if strings.EqualFold(key, "for") {
+						hops = append(hops, parseAddr(strings.Trim(value, `"`)))
+					}
+				}
+			}
+		}
+		return hops
+	}
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, parseAddr(hop))
+		}
+	}
+	return hops
+}
+
+// clientIP resolves the client IP of the request.
+func clientIP(r *http.Request) netip.Addr {
+	addr := parseAddr(r.RemoteAddr)
+	if !isTrustedProxy(addr) {
+		return addr
+	}
+	hops := forwardedFor(r.Header)
+	for i := len(hops) - 1; i >= 0; i-- { // Rightmost hops are added by the proxies closest.
+		if !hops[i].IsValid() {
+			break
+		}
+		addr = hops[i]
+		if !isTrustedProxy(addr) {
+			break
+		}
+	}
+	return addr
+}
+
+type clientIPCtxKeyType string
+
+const clientIPCtxName = clientIPCtxKeyType("restfulClientIP")
+
+// ClientIP returns the IP address of the client sending the request.
+// If the request is received from a trusted proxy, then Forwarded or X-Forwarded-For header is walked from right to left,
+// skipping trusted proxies. So addresses set by the client itself are not taken, unless the client is a trusted proxy.
+// See SetTrustedProxies.
+// Returns invalid address if not known, e.g. on a Unix socket.
+func ClientIP(r *http.Request) netip.Addr {
+	if addr, ok := r.Context().Value(clientIPCtxName).(netip.Addr); ok {
+		return addr
+	}
+	return clientIP(r)
+}
+
+// ClientIPFromContext returns the IP address of the client, as resolved by ClientIP.
+// Available in the context of requests received by Server, e.g. in Lambda functions.
+// Returns invalid address if not known.
+func ClientIPFromContext(ctx context.Context) netip.Addr {
+	addr, _ := ctx.Value(clientIPCtxName).(netip.Addr)
+	return addr
+}
+
+// clientIPHandler stores the client IP in the request context.
+func clientIPHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPCtxName, clientIP(r))))
+	})
+}
+
+// clientHost returns the client IP as string, or the remote address if the client IP is not known.
+func clientHost(r *http.Request) string {
+	if addr := ClientIP(r); addr.IsValid() {
+		return addr.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,55 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { _ = SetTrustedProxies() }()
+	assert.Error(SetTrustedProxies("10.0.0.0/33"))
+	assert.Error(SetTrustedProxies("proxy"))
+	assert.NoError(SetTrustedProxies("10.0.0.0/8", "2001:db8::1", "::ffff:192.168.0.0/112"))
+
+	request := func(remoteAddr string, header ...string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Add(header[i], header[i+1])
+		}
+		return ClientIP(req).String()
+	}
+
+	// Untrusted peer: headers ignored.
+	assert.Equal("1.2.3.4", request("1.2.3.4:1234", "X-Forwarded-For", "5.6.7.8"))
+	// Trusted peer: rightmost untrusted hop.
+	assert.Equal("5.6.7.8", request("10.0.0.1:1234", "X-Forwarded-For", "6.6.6.6, 5.6.7.8"))
+	assert.Equal("5.6.7.8", request("10.0.0.1:1234", "X-Forwarded-For", "6.6.6.6", "X-Forwarded-For", "5.6.7.8, 10.1.1.1"))
+	assert.Equal("6.6.6.6", request("[2001:db8::1]:1234", "X-Forwarded-For", "6.6.6.6, 192.168.1.1"))
+	assert.Equal("10.1.1.1", request("10.0.0.1:1234", "X-Forwarded-For", "10.1.1.1"))
+	assert.Equal("10.0.0.1", request("10.0.0.1:1234"))
+	// Forwarded header has priority.
+	assert.Equal("2001:db8::2", request("10.0.0.1:1234", "Forwarded", `for="[2001:db8::2]:4711";proto=https, for=10.2.2.2`, "X-Forwarded-For", "5.6.7.8"))
+	assert.Equal("10.2.2.2", request("10.0.0.1:1234", "Forwarded", "for=unknown, for=10.2.2.2"))
+	assert.Equal("invalid IP", request("@"))
+
+	// Server stores the client IP in the context.
+	var ctxIP string
+	router := NewRouter()
+	router.HandleFunc("/", func(ctx context.Context) { ctxIP = ClientIPFromContext(ctx).String() })
+	server := NewServer().Handler(router)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "5.6.7.8")
+	server.server.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal("5.6.7.8", ctxIP)
+}
@@ -0,0 +1,49 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Command restful-gen generates Go types, Lambda handler registration and a typed client of an OpenAPI document.
+//
+//	go run github.com/nokia/restful/cmd/restful-gen -spec openapi.yaml -package api -o api/api.gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nokia/restful/gen"
+)
+
+func main() {
+	spec := flag.String("spec", "", "OpenAPI document, JSON or YAML")
+	pkg := flag.String("package", "api", "package name of the generated code")
+	out := flag.String("o", "", "output file, standard output if empty")
+	noServer := flag.Bool("noserver", false, "do not generate server interface and handler registration")
+	noClient := flag.Bool("noclient", false, "do not generate client")
+	flag.Parse()
+
+	if err := run(*spec, *out, gen.Options{Package: *pkg, NoServer: *noServer, NoClient: *noClient}); err != nil {
+		fmt.Fprintln(os.Stderr, "restful-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(spec, out string, opts gen.Options) error {
+	if spec == "" {
+		return fmt.Errorf("-spec is mandatory")
+	}
+	doc, err := os.ReadFile(spec)
+	if err != nil {
+		return err
+	}
+	code, err := gen.Generate(doc, opts)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
@@ -0,0 +1,127 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package config
+
+import (
+	"time"
+
+	"github.com/nokia/restful"
+)
+
+// Apply sets the server according to the configuration. Zero address and TLS files are not set.
+func (c *Server) Apply(server *restful.Server) error {
+	if c.Addr != "" {
+		server.Addr(c.Addr)
+	}
+	if c.HTTPAddr != "" {
+		server.HTTPAddr(c.HTTPAddr)
+	}
+	if c.HTTPRedirect {
+		server.HTTPRedirect()
+	}
+	server.ReadHeaderTimeout(time.Duration(c.ReadHeaderTimeout)).ReadTimeout(time.Duration(c.ReadTimeout)).
+		WriteTimeout(time.Duration(c.WriteTimeout)).IdleTimeout(time.Duration(c.IdleTimeout)).
+		MaxHeaderBytes(c.MaxHeaderBytes).MaxRequestsPerConn(c.MaxRequestsPerConn).MaxConnAge(time.Duration(c.MaxConnAge)).
+		MaxConns(c.MaxConns).ConnRateLimit(c.ConnReadRate, c.ConnWriteRate)
+	if c.Graceful {
+		server.Graceful(time.Duration(c.GracePeriod))
+	}
+	server.DrainTimeout(time.Duration(c.DrainTimeout))
+	if c.CloseOnDrain {
+		server.CloseOnDrain()
+	}
+	if c.ProxyProtocol {
+		server.ProxyProtocol()
+	}
+
+	if c.TLS.CertFile != "" {
+		server.TLSServerCert(c.TLS.CertFile, c.TLS.KeyFile)
+	}
+	if c.TLS.ClientCAs != "" {
+		server.TLSClientCert(c.TLS.ClientCAs, false)
+	}
+	if c.TLS.Reload {
+		server.TLSReload()
+	}
+	minVersion, maxVersion, suites, err := parseTLS(c.TLS.MinVersion, c.TLS.MaxVersion, c.TLS.CipherSuites)
+	if err != nil {
+		return err
+	}
+	if minVersion != 0 || maxVersion != 0 {
+		server.TLSVersions(minVersion, maxVersion)
+	}
+	if len(suites) > 0 {
+		server.TLSCipherSuites(suites...)
+	}
+	return nil
+}
+
+// Apply sets the client according to the configuration.
+func (c *Client) Apply(client *restful.Client) error {
+	client.Timeout(time.Duration(c.Timeout))
+	if c.Retries > 0 {
+		client.Retry(c.Retries, time.Duration(c.RetryBackoffInit), time.Duration(c.RetryBackoffMax))
+	}
+	if c.UserAgent != "" {
+		client.UserAgent(c.UserAgent)
+	}
+	if c.TLS.RootCAs != "" {
+		client.TLSRootCerts(c.TLS.RootCAs, true)
+	}
+	if c.TLS.OwnCerts != "" {
+		client.TLSOwnCerts(c.TLS.OwnCerts)
+	}
+	minVersion, maxVersion, suites, err := parseTLS(c.TLS.MinVersion, c.TLS.MaxVersion, c.TLS.CipherSuites)
+	if err != nil {
+		return err
+	}
+	if minVersion != 0 || maxVersion != 0 {
+		client.TLSVersions(minVersion, maxVersion)
+	}
+	if len(suites) > 0 {
+		client.TLSCipherSuites(suites...)
+	}
+	return nil
+}
+
+// Apply enables tracing according to the configuration, if the endpoint is set.
+// Call before creating clients, as those are instrumented on creation.
+func (c *Tracing) Apply() error {
+	if c.Endpoint == "" {
+		return nil
+	}
+	if c.ServiceName != "" {
+		restful.SetOTelResource(c.ServiceName, c.ServiceVersion, c.Environment)
+	}
+	if c.Protocol == "http" {
+		return restful.SetOTelHTTP(c.Endpoint, c.Fraction)
+	}
+	return restful.SetOTelGrpc(c.Endpoint, c.Fraction)
+}
+
+// Apply enables metrics according to the configuration, if the endpoint is set.
+// Call before creating servers and clients, as those are instrumented on creation.
+func (c *Metrics) Apply() error {
+	if c.Endpoint == "" {
+		return nil
+	}
+	if c.Protocol == "http" {
+		return restful.SetOTelMetricsHTTP(c.Endpoint, time.Duration(c.Interval))
+	}
+	return restful.SetOTelMetricsGrpc(c.Endpoint, time.Duration(c.Interval))
+}
+
+func parseTLS(minName, maxName string, suiteNames []string) (minVersion, maxVersion uint16, suites []uint16, err error) {
+	if minVersion, err = parseTLSVersion(minName); err != nil {
+		return
+	}
+	if maxVersion, err = parseTLSVersion(maxName); err != nil {
+		return
+	}
+	if len(suiteNames) > 0 {
+		suites, err = restful.ParseCipherSuites(suiteNames...)
+	}
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package config
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	assert := assert.New(t)
+	cfg := Default()
+	cfg.Server.Addr = "127.0.0.1:18460"
+	cfg.Server.TLS = ServerTLS{CertFile: "../test_certs/tls.crt", KeyFile: "../test_certs/tls.key", MinVersion: "1.2", MaxVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}
+	cfg.Client.TLS = ClientTLS{RootCAs: "../test_certs", MinVersion: "1.2", MaxVersion: "1.2"}
+	cfg.Client.Retries = 1
+	assert.NoError(cfg.Validate())
+
+	server := restful.NewServer().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+	assert.NoError(cfg.Server.Apply(server))
+	go server.ListenAndServe()
+	defer server.Close()
+
+	client := restful.NewClient()
+	assert.NoError(cfg.Client.Apply(client))
+	var err error
+	for range 100 {
+		if err = client.Get(context.Background(), "https://localhost:18460", nil); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(err)
+
+	cfg.Client.TLS.MinVersion, cfg.Client.TLS.MaxVersion = "1.3", ""
+	client = restful.NewClient()
+	assert.NoError(cfg.Client.Apply(client))
+	assert.Error(client.Get(context.Background(), "https://localhost:18460", nil)) // Version mismatch.
+
+	cfg.Client.TLS.CipherSuites = []string{"NOPE"}
+	assert.Error(cfg.Client.Apply(restful.NewClient()))
+	assert.NoError((&Tracing{}).Apply())
+	assert.NoError((&Metrics{}).Apply())
+}
@@ -0,0 +1,282 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package config loads server, client, tracing and metrics settings from an optional YAML or JSON file and environment variables,
+// so that ports, timeouts and TLS can be tuned without code changes.
+//
+// Defaults are overridden by the file, which is overridden by environment variables.
+// Variable names are made of EnvPrefix and the path of the setting, e.g. RESTFUL_SERVER_ADDR or RESTFUL_SERVER_TLS_CERT_FILE.
+// Durations are like "30s", lists are comma separated.
+//
+//	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	server := restful.NewServer().Handler(router)
+//	if err := cfg.Server.Apply(server); err != nil {
+//	    log.Fatal(err)
+//	}
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nokia/restful"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables overriding settings.
+var EnvPrefix = "RESTFUL"
+
+// Duration is a time.Duration read as string, e.g. "1m30s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration expected as string, e.g. \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	*d = Duration(parsed)
+	return err
+}
+
+// MarshalJSON formats the duration as string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Config is the configuration of the application.
+type Config struct {
+	Server  Server  `json:"server"`
+	Client  Client  `json:"client"`
+	Tracing Tracing `json:"tracing"`
+	Metrics Metrics `json:"metrics"`
+}
+
+// Server is the configuration of restful.Server.
+type Server struct {
+	Addr               string    `json:"addr"`      // E.g. ":8080".
+	HTTPAddr           string    `json:"http_addr"` // Plain HTTP address of HTTPS servers, see restful.Server's HTTPAddr.
+	HTTPRedirect       bool      `json:"http_redirect"`
+	ReadHeaderTimeout  Duration  `json:"read_header_timeout" validate:"gte=0"`
+	ReadTimeout        Duration  `json:"read_timeout" validate:"gte=0"`
+	WriteTimeout       Duration  `json:"write_timeout" validate:"gte=0"`
+	IdleTimeout        Duration  `json:"idle_timeout" validate:"gte=0"`
+	MaxHeaderBytes     int       `json:"max_header_bytes" validate:"gte=0"`
+	MaxRequestsPerConn int       `json:"max_requests_per_conn" validate:"gte=0"`
+	MaxConnAge         Duration  `json:"max_conn_age" validate:"gte=0"`
+	MaxConns           int       `json:"max_conns" validate:"gte=0"`
+	ConnReadRate       int       `json:"conn_read_rate" validate:"gte=0"`  // Bytes per second.
+	ConnWriteRate      int       `json:"conn_write_rate" validate:"gte=0"` // Bytes per second.
+	Graceful           bool      `json:"graceful"`
+	GracePeriod        Duration  `json:"grace_period" validate:"gte=0"`
+	DrainTimeout       Duration  `json:"drain_timeout" validate:"gte=0"`
+	CloseOnDrain       bool      `json:"close_on_drain"`
+	ProxyProtocol      bool      `json:"proxy_protocol"`
+	TLS                ServerTLS `json:"tls"`
+}
+
+// ServerTLS is the TLS configuration of restful.Server.
+type ServerTLS struct {
+	CertFile     string   `json:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile      string   `json:"key_file" validate:"required_with=CertFile"`
+	ClientCAs    string   `json:"client_cas"` // File or directory of CA certs, enabling mTLS.
+	Reload       bool     `json:"reload"`     // Reload files when changed.
+	MinVersion   string   `json:"min_version"`
+	MaxVersion   string   `json:"max_version"`
+	CipherSuites []string `json:"cipher_suites"`
+}
+
+// Client is the configuration of restful.Client.
+type Client struct {
+	Timeout          Duration  `json:"timeout" validate:"gte=0"`
+	Retries          int       `json:"retries" validate:"gte=0,lte=10"`
+	RetryBackoffInit Duration  `json:"retry_backoff_init" validate:"gte=0"`
+	RetryBackoffMax  Duration  `json:"retry_backoff_max" validate:"gte=0"`
+	UserAgent        string    `json:"user_agent"`
+	TLS              ClientTLS `json:"tls"`
+}
+
+// ClientTLS is the TLS configuration of restful.Client.
+type ClientTLS struct {
+	RootCAs      string   `json:"root_cas"`  // File or directory of CA certs trusted, besides the system ones.
+	OwnCerts     string   `json:"own_certs"` // Directory of tls.crt and tls.key of the client, for mTLS.
+	MinVersion   string   `json:"min_version"`
+	MaxVersion   string   `json:"max_version"`
+	CipherSuites []string `json:"cipher_suites"`
+}
+
+// Tracing is the configuration of OpenTelemetry tracing.
+type Tracing struct {
+	Endpoint       string  `json:"endpoint"` // OTLP collector, e.g. "collector:4317" or "http://collector:4318/v1/traces". Empty disables.
+	Protocol       string  `json:"protocol" validate:"oneof=grpc http"`
+	Fraction       float64 `json:"fraction" validate:"gte=0,lte=1"` // Sampling ratio.
+	ServiceName    string  `json:"service_name"`
+	ServiceVersion string  `json:"service_version"`
+	Environment    string  `json:"environment"`
+}
+
+// Metrics is the configuration of OpenTelemetry metrics.
+type Metrics struct {
+	Endpoint string   `json:"endpoint"` // OTLP collector, e.g. "collector:4317" or "http://collector:4318/v1/metrics". Empty disables.
+	Protocol string   `json:"protocol" validate:"oneof=grpc http"`
+	Interval Duration `json:"interval" validate:"gte=0"` // Export interval. 0 means 60s.
+}
+
+// Default returns the default configuration.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			ReadHeaderTimeout:  Duration(restful.ServerReadHeaderTimeout),
+			ReadTimeout:        Duration(restful.ServerReadTimeout),
+			WriteTimeout:       Duration(restful.ServerWriteTimeout),
+			IdleTimeout:        Duration(restful.ServerIdleTimeout),
+			MaxHeaderBytes:     restful.ServerMaxHeaderBytes,
+			MaxRequestsPerConn: restful.ServerMaxRequestsPerConn,
+			MaxConnAge:         Duration(restful.ServerMaxConnAge),
+			MaxConns:           restful.ServerMaxConns,
+			ConnReadRate:       restful.ServerConnReadRate,
+			ConnWriteRate:      restful.ServerConnWriteRate,
+		},
+		Client:  Client{Timeout: Duration(10 * time.Second)},
+		Tracing: Tracing{Protocol: "grpc", Fraction: 1},
+		Metrics: Metrics{Protocol: "grpc"},
+	}
+}
+
+// Load returns the default configuration, overridden by the file, if path is not empty, and environment variables, then validated.
+// The file is YAML if its extension is .yaml or .yml, JSON otherwise. Unknown settings are errors.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := loadEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if doc == nil { // Empty file.
+			return nil
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+var durationType = reflect.TypeFor[Duration]()
+
+// loadEnv sets the fields of the struct from environment variables named by the prefix and json tags.
+func loadEnv(v reflect.Value, prefix string) error {
+	for i := range v.NumField() {
+		field, value := v.Type().Field(i), v.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		name = prefix + "_" + strings.ToUpper(name)
+		if field.Type.Kind() == reflect.Struct {
+			if err := loadEnv(value, name); err != nil {
+				return err
+			}
+			continue
+		}
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvValue(value, env); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setEnvValue(value reflect.Value, env string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(env)
+		value.SetInt(int64(d))
+		return err
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(env)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(env)
+		value.SetBool(b)
+		return err
+	case reflect.Int:
+		n, err := strconv.Atoi(env)
+		value.SetInt(int64(n))
+		return err
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(env, 64)
+		value.SetFloat(f)
+		return err
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(env, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return errors.New("unsupported type")
+	}
+	return nil
+}
+
+// Validate checks the configuration, including TLS versions and cipher suites.
+func (cfg *Config) Validate() error {
+	if err := restful.Validate.Struct(cfg); err != nil {
+		return err
+	}
+	for _, name := range []string{cfg.Server.TLS.MinVersion, cfg.Server.TLS.MaxVersion, cfg.Client.TLS.MinVersion, cfg.Client.TLS.MaxVersion} {
+		if _, err := parseTLSVersion(name); err != nil {
+			return err
+		}
+	}
+	if _, err := restful.ParseCipherSuites(cfg.Server.TLS.CipherSuites...); err != nil {
+		return err
+	}
+	_, err := restful.ParseCipherSuites(cfg.Client.TLS.CipherSuites...)
+	return err
+}
+
+// parseTLSVersion parses the TLS version, if set.
+func parseTLSVersion(name string) (uint16, error) {
+	if name == "" {
+		return 0, nil
+	}
+	return restful.ParseTLSVersion(name)
+}
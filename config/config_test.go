@@ -0,0 +1,105 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDefault(t *testing.T) {
+	assert := assert.New(t)
+	cfg, err := Load("")
+	assert.NoError(err)
+	assert.Equal(Default(), cfg)
+	assert.Equal(Duration(10*time.Second), cfg.Client.Timeout)
+}
+
+func TestLoadYAML(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(os.WriteFile(path, []byte(`
+server:
+  addr: ":8443"
+  write_timeout: 2m
+  max_requests_per_conn: 1000
+  tls:
+    cert_file: /tls/tls.crt
+    key_file: /tls/tls.key
+    min_version: "1.3"
+client:
+  retries: 3
+  retry_backoff_init: 100ms
+tracing:
+  endpoint: collector:4317
+  fraction: 0.1
+`), 0o600))
+	t.Setenv("RESTFUL_SERVER_ADDR", ":9443")
+	t.Setenv("RESTFUL_SERVER_TLS_CIPHER_SUITES", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+	t.Setenv("RESTFUL_CLIENT_TIMEOUT", "3s")
+	t.Setenv("RESTFUL_SERVER_GRACEFUL", "true")
+
+	cfg, err := Load(path)
+	assert.NoError(err)
+	assert.Equal(":9443", cfg.Server.Addr) // Env overrides file.
+	assert.Equal(Duration(2*time.Minute), cfg.Server.WriteTimeout)
+	assert.Equal(1000, cfg.Server.MaxRequestsPerConn)
+	assert.Equal("/tls/tls.key", cfg.Server.TLS.KeyFile)
+	assert.Equal("1.3", cfg.Server.TLS.MinVersion)
+	assert.Equal([]string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}, cfg.Server.TLS.CipherSuites)
+	assert.True(cfg.Server.Graceful)
+	assert.Equal(Duration(3*time.Second), cfg.Client.Timeout)
+	assert.Equal(3, cfg.Client.Retries)
+	assert.Equal(Duration(100*time.Millisecond), cfg.Client.RetryBackoffInit)
+	assert.Equal("collector:4317", cfg.Tracing.Endpoint)
+	assert.Equal(0.1, cfg.Tracing.Fraction)
+	assert.Equal("grpc", cfg.Tracing.Protocol) // Default kept.
+	assert.Equal(Default().Server.ReadTimeout, cfg.Server.ReadTimeout)
+}
+
+func TestLoadJSON(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(os.WriteFile(path, []byte(`{"server": {"addr": ":8080", "idle_timeout": "5m"}}`), 0o600))
+	cfg, err := Load(path)
+	assert.NoError(err)
+	assert.Equal(":8080", cfg.Server.Addr)
+	assert.Equal(Duration(5*time.Minute), cfg.Server.IdleTimeout)
+
+	data, err := json.Marshal(cfg.Server.IdleTimeout)
+	assert.NoError(err)
+	assert.Equal(`"5m0s"`, string(data))
+}
+
+func TestLoadErrors(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown.json":  `{"server": {"port": 8080}}`,
+		"duration.json": `{"server": {"read_timeout": 30}}`,
+		"keyless.yaml":  "server:\n  tls:\n    cert_file: /tls/tls.crt\n",
+		"version.yaml":  "client:\n  tls:\n    min_version: \"1.9\"\n",
+		"suite.yaml":    "server:\n  tls:\n    cipher_suites: [NOPE]\n",
+		"protocol.yaml": "tracing:\n  protocol: udp\n",
+		"fraction.yaml": "tracing:\n  fraction: 2\n",
+		"broken.yaml":   "server: [",
+	} {
+		path := filepath.Join(dir, name)
+		assert.NoError(os.WriteFile(path, []byte(content), 0o600))
+		_, err := Load(path)
+		assert.Error(err, name)
+	}
+	_, err := Load(filepath.Join(dir, "missing.yaml"))
+	assert.Error(err)
+
+	t.Setenv("RESTFUL_SERVER_MAX_HEADER_BYTES", "lots")
+	_, err = Load("")
+	assert.ErrorContains(err, "RESTFUL_SERVER_MAX_HEADER_BYTES")
+}
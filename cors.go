@@ -0,0 +1,255 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CORS is a Cross-Origin Resource Sharing policy, telling browsers which origins may access the resources.
+// Set it for the routes of a Router, or for all requests by Handler.
+//
+//	cors := restful.NewCORS().AllowOrigins("https://app.example.com", "https://*.example.org").AllowCredentials()
+//	router := restful.NewRouter().CORS(cors)
+type CORS struct {
+	allowAll    bool
+	origins     []string
+	wildcards   [][2]string // Prefix and suffix around "*".
+	regexps     []*regexp.Regexp
+	methods     []string
+	headers     []string
+	allHeaders  bool
+	expose      []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+var corsUsed atomic.Bool // Any router has a CORS policy, so routes are to be matched for their policies.
+
+// NewCORS creates a CORS policy allowing no origins.
+// Methods GET, HEAD, POST, PUT, PATCH and DELETE are allowed, with headers Accept, Content-Type and X-Requested-With.
+func NewCORS() *CORS {
+	return &CORS{
+		methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		headers: []string{"Accept", "Content-Type", "X-Requested-With"},
+	}
+}
+
+// AllowOrigins adds allowed origins, e.g. "https://app.example.com".
+// "*" allows all origins. A single "*" within an origin is a wildcard, e.g. "https://*.example.com".
+func (c *CORS) AllowOrigins(origins ...string) *CORS {
+	for _, origin := range origins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			c.allowAll = true
+		} else if prefix, suffix, found := strings.Cut(origin, "*"); found {
+			c.wildcards = append(c.wildcards, [2]string{prefix, suffix})
+		} else {
+			c.origins = append(c.origins, origin)
+		}
+	}
+	return c
+}
+
+// AllowOriginRegexp adds regular expressions of allowed origins. Make sure to anchor them.
+//
+//	cors.AllowOriginRegexp(regexp.MustCompile(`^https://[a-z]+\.example\.com(:[0-9]+)?$`))
+func (c *CORS) AllowOriginRegexp(regexps ...*regexp.Regexp) *CORS {
+	c.regexps = append(c.regexps, regexps...)
+	return c
+}
+
+// AllowMethods sets the methods allowed.
+func (c *CORS) AllowMethods(methods ...string) *CORS {
+	c.methods = methods
+	return c
+}
+
+// AllowHeaders sets the request headers allowed, besides CORS-safelisted ones. "*" allows all.
+func (c *CORS) AllowHeaders(headers ...string) *CORS {
+	c.headers, c.allHeaders = nil, false
+	for _, header := range headers {
+		if header == "*" {
+			c.allHeaders = true
+		} else {
+			c.headers = append(c.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+	return c
+}
+
+// ExposeHeaders sets the response headers readable by the scripts, besides CORS-safelisted ones. E.g. "Location" or "X-Request-Id".
+func (c *CORS) ExposeHeaders(headers ...string) *CORS {
+	c.expose = headers
+	return c
+}
+
+// AllowCredentials allows requests having credentials, such as cookies or Authorization header.
+// Then the origin is echoed in the response, even if all origins are allowed, as browsers do not accept "*" then.
+func (c *CORS) AllowCredentials() *CORS {
+	c.credentials = true
+	return c
+}
+
+// MaxAge sets how long browsers may cache preflight responses. Browsers apply their own limits, too.
+func (c *CORS) MaxAge(maxAge time.Duration) *CORS {
+	c.maxAge = maxAge
+	return c
+}
+
+func (c *CORS) originAllowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if slices.Contains(c.origins, lower) {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, re := range c.regexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) setOrigin(h http.Header, origin string) {
+	if c.allowAll && !c.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflight responds a preflight request.
+// If the origin, method or headers requested are not allowed, then CORS headers are not sent, so the browser blocks the request.
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	if c.originAllowed(origin) && slices.Contains(c.methods, r.Header.Get("Access-Control-Request-Method")) {
+		if headers, ok := c.requestedHeaders(r); ok {
+			c.setOrigin(h, origin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			if c.maxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.maxAge.Seconds())))
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestedHeaders returns the headers requested, if all allowed.
+func (c *CORS) requestedHeaders(r *http.Request) (string, bool) {
+	var headers []string
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				if !c.allHeaders && !slices.Contains(c.headers, http.CanonicalHeaderKey(header)) {
+					return "", false
+				}
+				headers = append(headers, header)
+			}
+		}
+	}
+	return strings.Join(headers, ", "), true
+}
+
+// actual sets the CORS headers of an actual request.
+func (c *CORS) actual(w http.ResponseWriter, origin string) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	if !c.originAllowed(origin) {
+		return
+	}
+	c.setOrigin(h, origin)
+	if len(c.expose) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(c.expose, ", "))
+	}
+}
+
+// Handler wraps the handler, applying the CORS policy to all requests.
+// If the handler is a Router, then policies set for its routes by Router.CORS override that.
+//
+//	restful.NewServer().Addr(":8080").Handler(cors.Handler(router)).ListenAndServe()
+func (c *CORS) Handler(h http.Handler) http.Handler {
+	if router, ok := h.(*Router); ok {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !serveCORS(w, r, router.router, c) {
+				router.router.ServeHTTP(w, r)
+			}
+		})
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !serveCORS(w, r, nil, c) {
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// serveCORS applies the policy of the route matched, or the default one.
+// Returns true if the request was a preflight served.
+func serveCORS(w http.ResponseWriter, r *http.Request, router *mux.Router, def *CORS) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	c := def
+	if router != nil && corsUsed.Load() {
+		matchReq := r
+		if preflight {
+			matchReq = r.Clone(r.Context())
+			matchReq.Method = r.Header.Get("Access-Control-Request-Method")
+		}
+		var match mux.RouteMatch
+		if router.Match(matchReq, &match) && match.Route != nil {
+			if settings := routeSettingsOf(match.Route); settings != nil && settings.cors != nil {
+				c = settings.cors
+			}
+		}
+	}
+	if c == nil {
+		return false
+	}
+	if preflight {
+		c.preflight(w, r, origin)
+		return true
+	}
+	c.actual(w, origin)
+	return false
+}
+
+// CORS sets the CORS policy of the routes added to the router after calling this function, as Monitor does.
+// Subrouters of those routes inherit the policy, and may override that.
+// Preflight requests are responded by the router, even if the route does not allow OPTIONS method.
+//
+//	router := restful.NewRouter()
+//	router.PathPrefix("/public").Subrouter().CORS(restful.NewCORS().AllowOrigins("*")).HandleFunc("/status", status)
+func (r *Router) CORS(c *CORS) *Router {
+	r.cors = c
+	corsUsed.Store(true)
+	return r
+}
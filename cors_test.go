@@ -0,0 +1,116 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func corsRequest(h http.Handler, method, path, origin string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSOrigins(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCORS().AllowOrigins("https://app.example.com", "https://*.example.org").AllowOriginRegexp(regexp.MustCompile(`^http://localhost:[0-9]+$`))
+	assert.True(c.originAllowed("https://APP.example.com"))
+	assert.True(c.originAllowed("https://a.example.org"))
+	assert.False(c.originAllowed("https://.example.org"))
+	assert.False(c.originAllowed("https://example.org"))
+	assert.True(c.originAllowed("http://localhost:3000"))
+	assert.False(c.originAllowed("http://localhost:3000.evil.com"))
+	assert.False(c.originAllowed("https://evil.com"))
+	assert.True(NewCORS().AllowOrigins("*").originAllowed("https://evil.com"))
+}
+
+func TestCORSHandler(t *testing.T) {
+	assert := assert.New(t)
+	c := NewCORS().AllowOrigins("*").AllowHeaders("Content-Type", "Authorization").ExposeHeaders("Location").MaxAge(time.Hour)
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := corsRequest(h, http.MethodOptions, "/", "https://a.com", "Access-Control-Request-Method", "PUT", "Access-Control-Request-Headers", "content-type, authorization")
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(w.Header().Get("Access-Control-Allow-Methods"), "PUT")
+	assert.Equal("content-type, authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal("3600", w.Header().Get("Access-Control-Max-Age"))
+
+	w = corsRequest(h, http.MethodOptions, "/", "https://a.com", "Access-Control-Request-Method", "PUT", "Access-Control-Request-Headers", "X-Secret")
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(h, http.MethodOptions, "/", "https://a.com", "Access-Control-Request-Method", "CONNECT")
+	assert.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(h, http.MethodGet, "/", "https://a.com")
+	assert.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal("Location", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal("Origin", w.Header().Get("Vary"))
+
+	w = corsRequest(h, http.MethodGet, "/", "")
+	assert.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+
+	c.AllowCredentials()
+	w = corsRequest(h, http.MethodGet, "/", "https://a.com")
+	assert.Equal("https://a.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal("true", w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSRouter(t *testing.T) {
+	assert := assert.New(t)
+	router := NewRouter()
+	router.HandleFunc("/private", func() {}).Methods(http.MethodGet)
+	public := router.PathPrefix("/public").Subrouter().CORS(NewCORS().AllowOrigins("*"))
+	public.HandleFunc("/status", func() {}).Methods(http.MethodGet)
+	partner := public.PathPrefix("/partner").Subrouter()
+	partner.HandleFunc("/orders", func() {}).Methods(http.MethodPost)
+	partner.CORS(NewCORS().AllowOrigins("https://partner.com"))
+	partner.HandleFunc("/invoices", func() {}).Methods(http.MethodGet)
+
+	// Route policy
+	w := corsRequest(router, http.MethodOptions, "/public/status", "https://a.com", "Access-Control-Request-Method", "GET")
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsRequest(router, http.MethodGet, "/public/status", "https://a.com")
+	assert.Equal(http.StatusNoContent, w.Code) // Served by the lambda.
+	assert.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Inherited and overridden policies
+	w = corsRequest(router, http.MethodPost, "/public/partner/orders", "https://a.com")
+	assert.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsRequest(router, http.MethodGet, "/public/partner/invoices", "https://a.com")
+	assert.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsRequest(router, http.MethodGet, "/public/partner/invoices", "https://partner.com")
+	assert.Equal("https://partner.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// No policy
+	w = corsRequest(router, http.MethodOptions, "/private", "https://a.com", "Access-Control-Request-Method", "GET")
+	assert.Equal(http.StatusMethodNotAllowed, w.Code)
+	w = corsRequest(router, http.MethodGet, "/private", "https://a.com")
+	assert.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Global policy, overridden by route policy
+	h := NewCORS().AllowOrigins("https://app.com").Handler(router)
+	w = corsRequest(h, http.MethodGet, "/private", "https://app.com")
+	assert.Equal("https://app.com", w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsRequest(h, http.MethodGet, "/public/status", "https://a.com")
+	assert.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal([]string{"Origin"}, w.Header().Values("Vary"))
+}
@@ -0,0 +1,154 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/nokia/restful/logging"
+)
+
+// CSRF protects browser-facing routes against cross-site request forgery, by double-submit cookie.
+// Safe requests, such as GET, get a token in a SameSite cookie, if not having one.
+// Unsafe requests must send the token of the cookie back in a header or form field, and must not come from other origins.
+// Requests with bearer tokens are exempt, as browsers do not send those automatically.
+// Rejected requests are responded 403.
+//
+//	csrf := restful.NewCSRF().TrustedOrigins("https://app.example.com")
+//	ui := router.PathPrefix("/ui").Subrouter().Monitor(csrf.Pre, nil)
+type CSRF struct {
+	cookieName     string
+	headerName     string
+	formField      string
+	sameSite       http.SameSite
+	trustedOrigins []string
+	exempt         func(r *http.Request) bool
+}
+
+// NewCSRF creates a CSRF protection, using "csrf_token" cookie, "X-CSRF-Token" header and "csrf_token" form field.
+func NewCSRF() *CSRF {
+	return &CSRF{cookieName: "csrf_token", headerName: "X-CSRF-Token", formField: "csrf_token", sameSite: http.SameSiteLaxMode}
+}
+
+// CookieName sets the name of the cookie holding the token.
+func (c *CSRF) CookieName(name string) *CSRF {
+	c.cookieName = name
+	return c
+}
+
+// HeaderName sets the name of the header the token is sent back in, e.g. by scripts.
+func (c *CSRF) HeaderName(name string) *CSRF {
+	c.headerName = name
+	return c
+}
+
+// FormField sets the name of the form field the token is sent back in, e.g. by HTML forms. Empty string disables forms.
+func (c *CSRF) FormField(name string) *CSRF {
+	c.formField = name
+	return c
+}
+
+// SameSite sets the SameSite attribute of the cookie. Default is Lax, Strict is stricter.
+func (c *CSRF) SameSite(sameSite http.SameSite) *CSRF {
+	c.sameSite = sameSite
+	return c
+}
+
+// TrustedOrigins sets the other origins unsafe requests are accepted from, e.g. "https://app.example.com".
+// Requests of the origin of the server are always accepted.
+func (c *CSRF) TrustedOrigins(origins ...string) *CSRF {
+	c.trustedOrigins = origins
+	return c
+}
+
+// Exempt sets a function telling if a request is exempt from the check, e.g. authenticated by an API key.
+// Requests with bearer token are always exempt.
+func (c *CSRF) Exempt(exempt func(r *http.Request) bool) *CSRF {
+	c.exempt = exempt
+	return c
+}
+
+// NewCSRFToken generates a random token.
+func NewCSRFToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type csrfCtxKeyType string
+
+const csrfCtxName = csrfCtxKeyType("restfulCSRFToken")
+
+// CSRFTokenFromContext returns the CSRF token of the request, to be embedded in forms or pages, e.g. as a hidden field.
+// Empty string if CSRF protection is not applied.
+func CSRFTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(csrfCtxName).(string)
+	return token
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions || method == http.MethodTrace
+}
+
+// originAllowed tells if the Origin header, if any, is the origin of the server or a trusted one.
+func (c *CSRF) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return r.Header.Get("Sec-Fetch-Site") != "cross-site"
+	}
+	if slices.Contains(c.trustedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (c *CSRF) sentToken(r *http.Request) string {
+	if token := r.Header.Get(c.headerName); token != "" {
+		return token
+	}
+	if c.formField != "" {
+		return r.PostFormValue(c.formField)
+	}
+	return ""
+}
+
+// Pre is a Monitor pre function, checking the request. May be used for route groups, by Router's Monitor.
+func (c *CSRF) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	if bearerToken(r) != "" || (c.exempt != nil && c.exempt(r)) {
+		return r
+	}
+
+	var token string
+	if cookie, err := r.Cookie(c.cookieName); err == nil {
+		token = cookie.Value
+	}
+
+	if !isSafeMethod(r.Method) {
+		sent := c.sentToken(r)
+		if !c.originAllowed(r) || token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			logging.Debugf(r.Context(), "CSRF check failed: %s %s", r.Method, r.URL.Path)
+			pd := ProblemDetails{Title: http.StatusText(http.StatusForbidden), Status: http.StatusForbidden, Detail: "CSRF token missing or invalid"}
+			_ = SendProblemResponse(w, r, http.StatusForbidden, pd.String())
+			return nil
+		}
+	} else if token == "" {
+		token = NewCSRFToken()
+		http.SetCookie(w, &http.Cookie{Name: c.cookieName, Value: token, Path: "/", Secure: isHTTPS(r), SameSite: c.sameSite}) // Not HttpOnly, scripts read it.
+	}
+	return r.WithContext(context.WithValue(r.Context(), csrfCtxName, token))
+}
+
+// Handler wraps the handler, checking all requests.
+func (c *CSRF) Handler(h http.Handler) http.Handler {
+	return Monitor(h, c.Pre, nil)
+}
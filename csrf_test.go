@@ -0,0 +1,72 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRF(t *testing.T) {
+	assert := assert.New(t)
+	csrf := NewCSRF().TrustedOrigins("https://app.example.com").Exempt(func(r *http.Request) bool { return r.Header.Get("X-API-Key") != "" })
+	router := NewRouter().Monitor(csrf.Pre, nil)
+	router.HandleFunc("/form", func(ctx context.Context) (string, error) { return CSRFTokenFromContext(ctx), nil }).Methods(http.MethodGet)
+	router.HandleFunc("/form", func() {}).Methods(http.MethodPost)
+
+	request := func(method string, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://example.com/form", strings.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Token issued
+	w := request(http.MethodGet, "")
+	assert.Equal(http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+	if assert.Len(cookies, 1) {
+		assert.Equal("csrf_token", cookies[0].Name)
+		assert.Equal(http.SameSiteLaxMode, cookies[0].SameSite)
+		assert.Equal(`"`+cookies[0].Value+`"`, w.Body.String())
+	}
+	token := cookies[0].Value
+	cookie := "csrf_token=" + token
+
+	// Existing token kept
+	w = request(http.MethodGet, "", "Cookie", cookie)
+	assert.Empty(w.Result().Cookies())
+	assert.Equal(`"`+token+`"`, w.Body.String())
+
+	// Token sent back
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, "", "Cookie", cookie, "X-CSRF-Token", token).Code)
+	form := url.Values{"csrf_token": {token}}.Encode()
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, form, "Cookie", cookie, "Content-Type", "application/x-www-form-urlencoded").Code)
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, "", "Cookie", cookie, "X-CSRF-Token", token, "Origin", "https://app.example.com").Code)
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, "", "Cookie", cookie, "X-CSRF-Token", token, "Origin", "http://example.com").Code)
+
+	// Rejected
+	w = request(http.MethodPost, "", "Cookie", cookie)
+	assert.Equal(http.StatusForbidden, w.Code)
+	assert.Contains(w.Body.String(), "CSRF")
+	assert.Equal(http.StatusForbidden, request(http.MethodPost, "", "Cookie", cookie, "X-CSRF-Token", "other").Code)
+	assert.Equal(http.StatusForbidden, request(http.MethodPost, "", "X-CSRF-Token", token).Code)
+	assert.Equal(http.StatusForbidden, request(http.MethodPost, "", "Cookie", cookie, "X-CSRF-Token", token, "Origin", "https://evil.com").Code)
+	assert.Equal(http.StatusForbidden, request(http.MethodPost, "", "Cookie", cookie, "X-CSRF-Token", token, "Sec-Fetch-Site", "cross-site").Code)
+
+	// Exempt
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, "", "Authorization", "Bearer abc").Code)
+	assert.Equal(http.StatusNoContent, request(http.MethodPost, "", "X-API-Key", "abc").Code)
+	assert.Equal(http.StatusForbidden, request(http.MethodPost, "", "Authorization", "Basic YTpi").Code)
+}
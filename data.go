@@ -15,8 +15,8 @@ import (
 	"strconv"
 
 	"github.com/gorilla/schema"
+	"github.com/nokia/restful/logging"
 	"github.com/nokia/restful/messagepack"
-	log "github.com/sirupsen/logrus"
 )
 
 var (
@@ -136,7 +136,7 @@ func getDataJSON(ctx context.Context, body []byte, data any, request bool, recvd
 	}
 
 	if recvdContentType == ContentTypeProblemJSON {
-		log.Debug("Problem: ", string(body))
+		logging.Debugf(ctx, "Problem: %s", body)
 	}
 
 	ioBody := io.NopCloser(bytes.NewReader(body))
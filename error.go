@@ -5,10 +5,13 @@
 package restful
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"strings"
+	"syscall"
 )
 
 // ErrorBodyMaxLen is the maximum length of the body
@@ -155,6 +158,38 @@ func IsConnectError(err error) bool {
 	return status >= 502 && status <= 504
 }
 
+// StatusOf returns the HTTP status code associated with the given error.
+// Returns 0 if there is no HTTP status in the error, e.g. in case of a transport error,
+// and http.StatusOK if the error is nil.
+func StatusOf(err error) int {
+	return GetErrStatusCodeElse(err, 0)
+}
+
+// IsTimeout determines if the given error is a timeout.
+// I.e. client or dial timeout, context deadline exceeded, or a 504 Gateway Timeout response.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || StatusOf(err) == http.StatusGatewayTimeout {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsConnectionRefused determines if the given error is caused by the peer refusing the connection.
+// E.g. there is no server listening on the target port.
+func IsConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// IsDNSError determines if the given error is caused by failing to resolve the target host name.
+func IsDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
 // GetErrBody returns the content-type and raw body stored within the given error.
 // Note that the body may be chopped if too long. See ErrorBodyMaxLen.
 //
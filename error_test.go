@@ -7,9 +7,11 @@ package restful
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -126,3 +128,40 @@ func Test_GetErrBody_None(t *testing.T) {
 	assert.Empty(ct)
 	assert.Empty(body)
 }
+
+func TestErrorClassification(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(200, StatusOf(nil))
+	assert.Equal(0, StatusOf(errors.New("transport")))
+	assert.Equal(404, StatusOf(DetailError(NewError(nil, 404), "not there")))
+
+	assert.False(IsTimeout(nil))
+	assert.True(IsTimeout(NewError(nil, http.StatusGatewayTimeout)))
+	assert.False(IsTimeout(NewError(nil, http.StatusBadGateway)))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer srv.Close()
+	err := NewClient().Timeout(10*time.Millisecond).Get(context.Background(), srv.URL, nil)
+	assert.True(IsTimeout(err))
+	assert.False(IsConnectionRefused(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = NewClient().Get(ctx, srv.URL, nil)
+	assert.True(IsTimeout(err))
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+	err = NewClient().Get(context.Background(), "http://"+addr, nil)
+	assert.True(IsConnectionRefused(err))
+	assert.False(IsDNSError(err))
+	assert.Equal(0, StatusOf(err))
+
+	err = NewClient().Get(context.Background(), "http://nonexistent.invalid", nil)
+	assert.True(IsDNSError(err))
+	assert.False(IsTimeout(err))
+}
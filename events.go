@@ -0,0 +1,204 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// EventKind is the kind of a server event.
+type EventKind int
+
+// Server event kinds.
+const (
+	// EventPanic is emitted when a handler panics.
+	EventPanic EventKind = iota
+	// EventServerError is emitted when a 5xx response is sent.
+	EventServerError
+	// EventSlowRequest is emitted when serving a request lasts at least SlowRequestThreshold.
+	EventSlowRequest
+	numEventKinds
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventPanic:
+		return "panic"
+	case EventServerError:
+		return "server_error"
+	case EventSlowRequest:
+		return "slow_request"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event is a server event, passed to subscribers. See OnEvent.
+type Event struct {
+	Kind       EventKind
+	Time       time.Time
+	Method     string
+	Path       string
+	Route      string // Route template, e.g. "/users/{id}", if a Router route matched.
+	RequestID  string
+	StatusCode int           // Status code sent. 0 for a panic before sending any.
+	Duration   time.Duration // Time elapsed serving the request.
+	Panic      any           // Value passed to panic, for EventPanic.
+	Stack      []byte        // Stack trace of the panic, for EventPanic.
+}
+
+// SlowRequestThreshold is the duration of serving a request, EventSlowRequest is emitted at. 0 disables slow request events.
+var SlowRequestThreshold time.Duration
+
+type eventSubscriber struct {
+	f func(Event)
+}
+
+var (
+	eventMutex       sync.RWMutex
+	eventSubscribers []*eventSubscriber
+	eventCounts      [numEventKinds]atomic.Uint64
+)
+
+// OnEvent subscribes f to server events: panics, 5xx responses and slow requests.
+// f is called synchronously, by the goroutine serving the request, after the response is sent. So it must be fast.
+// Returns a function unsubscribing f.
+//
+//	unsubscribe := restful.OnEvent(func(e restful.Event) {
+//	    if e.Kind == restful.EventServerError { breaker.Failure() }
+//	})
+func OnEvent(f func(Event)) (unsubscribe func()) {
+	s := &eventSubscriber{f: f}
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+	eventSubscribers = append(eventSubscribers, s)
+	return func() {
+		eventMutex.Lock()
+		defer eventMutex.Unlock()
+		eventSubscribers = slices.DeleteFunc(slices.Clone(eventSubscribers), func(e *eventSubscriber) bool { return e == s })
+	}
+}
+
+// SubscribeEvents subscribes a channel to server events. See OnEvent.
+// Events are dropped if the channel is full, serving requests is never blocked.
+// Returns a function unsubscribing the channel. The channel is not closed.
+//
+//	events := make(chan restful.Event, 100)
+//	restful.SubscribeEvents(events)
+//	go func() { for e := range events { alert(e) } }()
+func SubscribeEvents(ch chan<- Event) (unsubscribe func()) {
+	return OnEvent(func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+}
+
+// EventCount returns the number of events of the kind emitted since the start of the process.
+// Rates can be calculated by sampling that periodically.
+func EventCount(kind EventKind) uint64 {
+	if kind < 0 || kind >= numEventKinds {
+		return 0
+	}
+	return eventCounts[kind].Load()
+}
+
+func emitEvent(e Event) {
+	eventCounts[e.Kind].Add(1)
+	eventMutex.RLock()
+	subscribers := eventSubscribers
+	eventMutex.RUnlock()
+	for _, s := range subscribers {
+		s.f(e)
+	}
+}
+
+func newEvent(kind EventKind, r *http.Request, header http.Header, start time.Time, statusCode int) Event {
+	now := time.Now()
+	requestID := RequestIDFromContext(r.Context())
+	if requestID == "" { // RequestID middleware may be inside.
+		requestID = header.Get(RequestIDHeader)
+	}
+	return Event{
+		Kind:       kind,
+		Time:       now,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Route:      RouteTemplate(r),
+		RequestID:  requestID,
+		StatusCode: statusCode,
+		Duration:   now.Sub(start),
+	}
+}
+
+// EventHandler wraps the handler, emitting events of panics, 5xx responses and slow requests. See OnEvent.
+// Panics are passed on, after the event is emitted. See Recover for responding 500 instead.
+// Server does that automatically.
+func EventHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ew := &responseWriter{ResponseWriter: w}
+		r = WithRouteTemplate(r)
+		defer func() {
+			if p := recover(); p != nil {
+				if p != http.ErrAbortHandler {
+					emitPanicEvent(r, ew.Header(), start, ew.status, p, debug.Stack())
+				}
+				panic(p)
+			}
+		}()
+
+		h.ServeHTTP(ew, r)
+		statusCode := ew.statusCode()
+		if statusCode >= 500 {
+			emitEvent(newEvent(EventServerError, r, ew.Header(), start, statusCode))
+		}
+		if SlowRequestThreshold > 0 && time.Since(start) >= SlowRequestThreshold {
+			emitEvent(newEvent(EventSlowRequest, r, ew.Header(), start, statusCode))
+		}
+	})
+}
+
+func emitPanicEvent(r *http.Request, header http.Header, start time.Time, statusCode int, p any, stack []byte) {
+	e := newEvent(EventPanic, r, header, start, statusCode)
+	e.Panic, e.Stack = p, stack
+	emitEvent(e)
+}
+
+// Recover wraps the handler, recovering from panics of the handler.
+// The panic is logged with its stack trace, EventPanic is emitted, and 500 Internal Server Error is sent, unless a response was sent already.
+// Without that, the server closes the connection of a panicking handler.
+//
+//	restful.NewServer().Addr(":8080").Handler(restful.Recover(router)).ListenAndServe()
+func Recover(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+		r = WithRouteTemplate(r)
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				stack := debug.Stack()
+				logging.FromContext(r.Context()).ErrorContext(r.Context(), "panic serving request", "method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(p), "stack", string(stack))
+				emitPanicEvent(r, rw.Header(), start, rw.status, p, stack)
+				if rw.status == 0 {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}
+		}()
+		h.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEventRouter() *Router {
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/panic":
+			panic("boom")
+		case "/users/fail":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/users/slow":
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+	return router
+}
+
+func TestEvents(t *testing.T) {
+	assert := assert.New(t)
+	SlowRequestThreshold = 10 * time.Millisecond
+	defer func() { SlowRequestThreshold = 0 }()
+
+	var events []Event
+	unsubscribe := OnEvent(func(e Event) { events = append(events, e) })
+	ch := make(chan Event, 1)
+	unsubscribeCh := SubscribeEvents(ch)
+	serverErrors := EventCount(EventServerError)
+
+	h := EventHandler(RequestID(newEventRouter()))
+	for _, path := range []string{"/users/1", "/users/fail", "/users/slow"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Panics(func() { h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/panic", nil)) })
+
+	if assert.Len(events, 3) {
+		assert.Equal(EventServerError, events[0].Kind)
+		assert.Equal(http.StatusBadGateway, events[0].StatusCode)
+		assert.Equal("/users/{id}", events[0].Route)
+		assert.NotEmpty(events[0].RequestID)
+		assert.Equal(EventSlowRequest, events[1].Kind)
+		assert.GreaterOrEqual(events[1].Duration, SlowRequestThreshold)
+		assert.Equal(EventPanic, events[2].Kind)
+		assert.Equal("boom", events[2].Panic)
+		assert.NotEmpty(events[2].Stack)
+	}
+	assert.Equal(serverErrors+1, EventCount(EventServerError))
+	assert.Equal(EventServerError, (<-ch).Kind)
+	assert.Len(ch, 0) // Dropped when full.
+
+	unsubscribe()
+	unsubscribeCh()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/fail", nil))
+	assert.Len(events, 3)
+	assert.Equal(serverErrors+2, EventCount(EventServerError))
+	assert.Equal("slow_request", EventSlowRequest.String())
+	assert.Zero(EventCount(EventKind(-1)))
+}
+
+func TestRecover(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer logging.SetLogger(nil)
+
+	var kinds []EventKind
+	defer OnEvent(func(e Event) { kinds = append(kinds, e.Kind) })()
+
+	h := EventHandler(Recover(newEventRouter()))
+	w := httptest.NewRecorder()
+	assert.NotPanics(func() { h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/panic", nil)) })
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	assert.Equal([]EventKind{EventPanic, EventServerError}, kinds)
+	assert.Contains(buf.String(), `"panic":"boom"`)
+}
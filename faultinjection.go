@@ -0,0 +1,237 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// ErrFaultInjected is returned by clients for requests failed by a FaultInjector, e.g. connection reset.
+var ErrFaultInjected = errors.New("fault injected")
+
+// ErrUnknownFaultInjector is returned if no fault injector is found by the name given.
+var ErrUnknownFaultInjector = errors.New("unknown fault injector")
+
+// FaultRule tells which requests are faulted, and how. Empty conditions match any request.
+// Latency is added first, then the request is failed by Reset or Status, if set.
+type FaultRule struct {
+	Percent     float64       // Share of the matching requests faulted, 0-100.
+	Method      string        // Method of the requests, e.g. "POST".
+	PathPrefix  string        // Path prefix of the requests, e.g. "/users/".
+	Header      string        // Header the requests have, e.g. "X-Chaos". Allows faulting test traffic only.
+	HeaderValue string        // Value of the header. Any value if empty.
+	Latency     time.Duration // Latency added.
+	Status      int           // Status code responded, e.g. 503.
+	Reset       bool          // Connection closed without response.
+}
+
+// FaultInjector injects latency, errors and connection resets into requests, for resilience testing, e.g. in staging.
+// The first rule matching a request applies. Disabled by default, switched by Set, or by name via SetFaultInjection, e.g. on an admin API.
+// Used by servers, for all requests or route groups by Router's Monitor, and by clients, see Client's FaultInjection.
+// Health endpoints, such as LivenessProbePath, are not faulted by servers.
+//
+//	chaos := restful.NewFaultInjector("chaos").Rule(restful.FaultRule{Percent: 10, Latency: 2 * time.Second}).Rule(restful.FaultRule{Percent: 1, Status: 503})
+//	restful.NewServer().Addr(":8080").Handler(chaos.Handler(router))
+//	chaos.Set(os.Getenv("CHAOS") != "")
+type FaultInjector struct {
+	name    string
+	enabled atomic.Bool
+	mutex   sync.RWMutex
+	rules   []FaultRule
+}
+
+var (
+	faultInjectorsMutex sync.Mutex
+	faultInjectors      = map[string]*FaultInjector{}
+)
+
+// NewFaultInjector creates a fault injector, disabled. Name identifies the injector, e.g. at SetFaultInjection.
+// Creating another injector by the same name replaces the former one at switching by name.
+func NewFaultInjector(name string) *FaultInjector {
+	f := &FaultInjector{name: name}
+	faultInjectorsMutex.Lock()
+	defer faultInjectorsMutex.Unlock()
+	faultInjectors[name] = f
+	return f
+}
+
+// Rule adds a rule.
+func (f *FaultInjector) Rule(rule FaultRule) *FaultInjector {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.rules = append(f.rules, rule)
+	return f
+}
+
+// SetRules replaces the rules, e.g. at runtime.
+func (f *FaultInjector) SetRules(rules []FaultRule) *FaultInjector {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.rules = append([]FaultRule(nil), rules...)
+	return f
+}
+
+// Set enables or disables fault injection.
+func (f *FaultInjector) Set(enabled bool) *FaultInjector {
+	if f.enabled.Swap(enabled) != enabled {
+		logging.Infof(context.Background(), "fault injection %q enabled: %v", f.name, enabled)
+	}
+	return f
+}
+
+// Enabled tells if fault injection is enabled.
+func (f *FaultInjector) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// SetFaultInjection enables or disables the fault injector of the name given.
+func SetFaultInjection(name string, enabled bool) error {
+	faultInjectorsMutex.Lock()
+	f, ok := faultInjectors[name]
+	faultInjectorsMutex.Unlock()
+	if !ok {
+		return ErrUnknownFaultInjector
+	}
+	f.Set(enabled)
+	return nil
+}
+
+// FaultInjectionStates returns whether fault injectors are enabled, by name.
+func FaultInjectionStates() map[string]bool {
+	faultInjectorsMutex.Lock()
+	defer faultInjectorsMutex.Unlock()
+	states := make(map[string]bool, len(faultInjectors))
+	for name, f := range faultInjectors {
+		states[name] = f.Enabled()
+	}
+	return states
+}
+
+// fault returns the rule faulting the request, if any.
+func (f *FaultInjector) fault(r *http.Request) *FaultRule {
+	if !f.enabled.Load() {
+		return nil
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	for i := range f.rules {
+		if rule := &f.rules[i]; rule.matches(r) {
+			if rand.Float64()*100 >= rule.Percent {
+				return nil
+			}
+			copied := *rule
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (rule *FaultRule) matches(r *http.Request) bool {
+	if rule.Method != "" && rule.Method != r.Method || !strings.HasPrefix(r.URL.Path, rule.PathPrefix) {
+		return false
+	}
+	if rule.Header == "" {
+		return true
+	}
+	values := r.Header.Values(rule.Header)
+	return len(values) > 0 && (rule.HeaderValue == "" || values[0] == rule.HeaderValue)
+}
+
+// delay waits the latency of the rule, returning false if the context is done meanwhile.
+func (rule *FaultRule) delay(ctx context.Context) bool {
+	if rule.Latency <= 0 {
+		return true
+	}
+	timer := time.NewTimer(rule.Latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Pre is a Monitor pre function, injecting faults. May be used for route groups, by Router's Monitor.
+func (f *FaultInjector) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	if r.URL.Path == LivenessProbePath || r.URL.Path == ReadinessProbePath || r.URL.Path == HealthCheckPath {
+		return r
+	}
+	rule := f.fault(r)
+	if rule == nil {
+		return r
+	}
+	if !rule.delay(r.Context()) {
+		return nil
+	}
+	switch {
+	case rule.Reset:
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				_ = conn.Close()
+				return nil
+			}
+		}
+		panic(http.ErrAbortHandler) // Aborts the response, e.g. HTTP/2 stream.
+	case rule.Status != 0:
+		pd := ProblemDetails{Title: http.StatusText(rule.Status), Status: rule.Status, Detail: ErrFaultInjected.Error()}
+		_ = SendProblemResponse(w, r, rule.Status, pd.String())
+		return nil
+	}
+	return r
+}
+
+// Handler wraps the handler, injecting faults to all requests.
+func (f *FaultInjector) Handler(h http.Handler) http.Handler {
+	return Monitor(h, f.Pre, nil)
+}
+
+// ClientPre is a client Monitor pre function, injecting faults to requests sent. See Client's FaultInjection.
+func (f *FaultInjector) ClientPre(req *http.Request) (*http.Response, error) {
+	rule := f.fault(req)
+	if rule == nil {
+		return nil, nil
+	}
+	if !rule.delay(req.Context()) {
+		return nil, req.Context().Err()
+	}
+	switch {
+	case rule.Reset:
+		return nil, fmt.Errorf("%w: connection reset", ErrFaultInjected)
+	case rule.Status != 0:
+		pd := ProblemDetails{Title: http.StatusText(rule.Status), Status: rule.Status, Detail: ErrFaultInjected.Error()}
+		body := []byte(pd.String())
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", rule.Status, http.StatusText(rule.Status)),
+			StatusCode:    rule.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{ContentTypeHeader: {ContentTypeProblemJSON}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, nil
+}
+
+// FaultInjection makes the client inject faults to the requests sent, when the fault injector is enabled.
+// Meant for testing how the application copes with failing dependencies.
+func (c *Client) FaultInjection(f *FaultInjector) *Client {
+	return c.Monitor(f.ClientPre, nil)
+}
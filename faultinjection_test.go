@@ -0,0 +1,91 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjector(t *testing.T) {
+	assert := assert.New(t)
+	f := NewFaultInjector("test").
+		Rule(FaultRule{Percent: 100, Method: http.MethodPost, Status: http.StatusServiceUnavailable}).
+		Rule(FaultRule{Percent: 100, PathPrefix: "/slow", Latency: 50 * time.Millisecond}).
+		Rule(FaultRule{Percent: 100, Header: "X-Chaos", HeaderValue: "reset", Reset: true}).
+		Rule(FaultRule{Percent: 0, Status: http.StatusInternalServerError})
+	srv := httptest.NewServer(f.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer srv.Close()
+
+	send := func(method, path string, header http.Header) (int, error) {
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	status, err := send(http.MethodPost, "/users", nil)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, status) // Disabled.
+
+	assert.False(f.Enabled())
+	assert.NoError(SetFaultInjection("test", true))
+	assert.True(f.Enabled())
+	status, _ = send(http.MethodPost, "/users", nil)
+	assert.Equal(http.StatusServiceUnavailable, status)
+	status, _ = send(http.MethodPost, LivenessProbePath, nil)
+	assert.Equal(http.StatusOK, status)
+
+	start := time.Now()
+	status, _ = send(http.MethodGet, "/slow/report", nil)
+	assert.Equal(http.StatusOK, status)
+	assert.GreaterOrEqual(time.Since(start), 50*time.Millisecond)
+
+	_, err = send(http.MethodGet, "/users", http.Header{"X-Chaos": {"reset"}})
+	assert.Error(err)
+	status, _ = send(http.MethodGet, "/users", http.Header{"X-Chaos": {"other"}})
+	assert.Equal(http.StatusOK, status) // Percent 0.
+
+	f.SetRules(nil)
+	status, _ = send(http.MethodPost, "/users", nil)
+	assert.Equal(http.StatusOK, status)
+
+	assert.ErrorIs(SetFaultInjection("unknown", true), ErrUnknownFaultInjector)
+	assert.True(FaultInjectionStates()["test"])
+}
+
+func TestClientFaultInjection(t *testing.T) {
+	assert := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	f := NewFaultInjector("client").
+		Rule(FaultRule{Percent: 100, PathPrefix: "/unavailable", Status: http.StatusServiceUnavailable}).
+		Rule(FaultRule{Percent: 100, PathPrefix: "/reset", Reset: true}).
+		Rule(FaultRule{Percent: 100, PathPrefix: "/slow", Latency: time.Second}).
+		Set(true)
+	client := NewClient().Root(srv.URL).FaultInjection(f)
+	ctx := context.Background()
+
+	err := client.Get(ctx, "/unavailable", nil)
+	assert.Equal(http.StatusServiceUnavailable, GetErrStatusCode(err))
+	assert.ErrorIs(client.Get(ctx, "/reset", nil), ErrFaultInjected)
+	assert.NoError(client.Get(ctx, "/other", nil))
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(client.Get(ctxTimeout, "/slow", nil), context.DeadlineExceeded)
+
+	f.Set(false)
+	assert.NoError(client.Get(ctx, "/unavailable", nil))
+}
@@ -0,0 +1,141 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// writeClient writes the typed Client, having a method per operation.
+func (g *generator) writeClient(b *bytes.Buffer, ops []genOperation) {
+	for _, imp := range []string{"context", "net/http", "strings", restfulImport} {
+		g.imports[imp] = true
+	}
+	b.WriteString(`// Client is a typed client of the API.
+type Client struct {
+	Client *restful.Client
+	Root   string // Root URL of the API, e.g. "http://users:8080/api/v1".
+}
+
+// NewClient creates a typed client of the API at the root URL. A new restful client is created if client is nil.
+func NewClient(root string, client *restful.Client) *Client {
+	if client == nil {
+		client = restful.NewClient()
+	}
+	return &Client{Client: client, Root: strings.TrimSuffix(root, "/")}
+}
+
+`)
+	for _, op := range ops {
+		g.writeClientMethod(b, op)
+	}
+}
+
+func (g *generator) writeClientMethod(b *bytes.Buffer, op genOperation) {
+	op.comment(b, "")
+	fmt.Fprintf(b, "func (c *Client) %s%s {\n", op.name, op.signature())
+	fmt.Fprintf(b, "\ttarget := c.Root + %s\n", g.clientPath(op))
+	header := "nil"
+	if op.hasQuery() {
+		g.imports["net/url"] = true
+		b.WriteString("\tquery := url.Values{}\n")
+	}
+	if len(op.params) > 0 && !op.hasOnlyQuery() {
+		header = "header"
+		b.WriteString("\theader := http.Header{}\n")
+	}
+	for _, p := range op.params {
+		g.writeParamSet(b, p)
+	}
+	if op.hasQuery() {
+		b.WriteString("\tif len(query) > 0 {\n\t\ttarget += \"?\" + query.Encode()\n\t}\n")
+	}
+	body := "nil"
+	if op.bodyType != "" {
+		body = "body"
+	}
+	method := "http.Method" + strings.ToUpper(op.method[:1]) + strings.ToLower(op.method[1:])
+	if op.respType == "" {
+		fmt.Fprintf(b, "\t_, err := c.Client.SendRecv2xx(ctx, %s, target, %s, %s, nil)\n\treturn err\n}\n\n", method, header, body)
+		return
+	}
+	fmt.Fprintf(b, "\tvar resp %s\n", op.respType)
+	fmt.Fprintf(b, "\tif _, err := c.Client.SendRecv2xx(ctx, %s, target, %s, %s, &resp); err != nil {\n\t\treturn %s, err\n\t}\n", method, header, body, op.zero())
+	if strings.HasPrefix(op.result(), "*") {
+		b.WriteString("\treturn &resp, nil\n}\n\n")
+	} else {
+		b.WriteString("\treturn resp, nil\n}\n\n")
+	}
+}
+
+// clientPath returns the expression of the path of the operation, having escaped path parameters.
+func (g *generator) clientPath(op genOperation) string {
+	var parts []string
+	rest := op.path
+	for {
+		start := strings.Index(rest, "{")
+		end := strings.Index(rest, "}")
+		if start < 0 || end < start {
+			break
+		}
+		if start > 0 {
+			parts = append(parts, strconv.Quote(rest[:start]))
+		}
+		parts = append(parts, g.pathParamExpr(op, rest[start+1:end]))
+		rest = rest[end+1:]
+	}
+	if rest != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(rest))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (g *generator) pathParamExpr(op genOperation, name string) string {
+	g.imports["net/url"] = true
+	for _, p := range op.pathParams {
+		if p.name == name {
+			if p.typ == "string" {
+				return "url.PathEscape(" + p.ident + ")"
+			}
+			g.imports["fmt"] = true
+			return "url.PathEscape(fmt.Sprint(" + p.ident + "))"
+		}
+	}
+	return strconv.Quote("{" + name + "}")
+}
+
+func (g *generator) writeParamSet(b *bytes.Buffer, p genParam) {
+	target := "query"
+	if p.in == "header" {
+		target = "header"
+	}
+	value := func(v string) string {
+		if p.typ == "string" {
+			return v
+		}
+		g.imports["fmt"] = true
+		return "fmt.Sprint(" + v + ")"
+	}
+	switch {
+	case p.array:
+		fmt.Fprintf(b, "\tfor _, v := range params.%s {\n\t\t%s.Add(%q, %s)\n\t}\n", p.field, target, p.name, value("v"))
+	case p.required:
+		fmt.Fprintf(b, "\t%s.Set(%q, %s)\n", target, p.name, value("params."+p.field))
+	default:
+		fmt.Fprintf(b, "\tif params.%s != nil {\n\t\t%s.Set(%q, %s)\n\t}\n", p.field, target, p.name, value("*params."+p.field))
+	}
+}
+
+func (op genOperation) hasOnlyQuery() bool {
+	for _, p := range op.params {
+		if p.in != "query" {
+			return false
+		}
+	}
+	return true
+}
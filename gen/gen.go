@@ -0,0 +1,549 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package gen generates Go code from an OpenAPI document: types of the schemas, a Server interface of the operations,
+// route registration of restful Lambda handlers calling the Server, and a typed Client.
+//
+//	code, err := gen.Generate(spec, gen.Options{Package: "api"})
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Options of code generation.
+type Options struct {
+	Package  string // Package name of the generated code. Default "api".
+	NoServer bool   // Do not generate Server interface and RegisterHandlers.
+	NoClient bool   // Do not generate Client.
+}
+
+var methodOrder = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+type generator struct {
+	doc      *document
+	opts     Options
+	decls    []string
+	declared map[string]bool
+	imports  map[string]bool
+}
+
+type genParam struct {
+	name     string // As in the document.
+	in       string
+	ident    string // Go variable name.
+	field    string // Go field name.
+	typ      string // Go type.
+	required bool
+	array    bool
+}
+
+type genOperation struct {
+	name       string
+	id         string
+	summary    string
+	method     string
+	path       string
+	pathParams []genParam
+	params     []genParam // Query and header parameters.
+	bodyType   string
+	respType   string
+	status     int
+}
+
+// Generate returns formatted Go source generated from the OpenAPI document in JSON or YAML format.
+func Generate(spec []byte, opts Options) ([]byte, error) {
+	doc, err := parseDocument(spec)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		opts.Package = "api"
+	}
+	g := &generator{doc: doc, opts: opts, declared: map[string]bool{}, imports: map[string]bool{}}
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		g.declareSchema(exportName(name), doc.Components.Schemas[name])
+	}
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	for _, decl := range g.decls {
+		body.WriteString(decl)
+	}
+	g.writeParamTypes(&body, ops)
+	if !opts.NoServer {
+		g.writeServer(&body, ops)
+	}
+	if !opts.NoClient {
+		g.writeClient(&body, ops)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by restful-gen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", opts.Package)
+	for _, imp := range slices.Sorted(maps.Keys(g.imports)) {
+		if imp != restfulImport {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+	}
+	if g.imports[restfulImport] {
+		fmt.Fprintf(&out, "\n\t%q\n", restfulImport)
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// declareSchema declares a named type of a schema.
+func (g *generator) declareSchema(name string, s *schema) {
+	if g.declared[name] {
+		return
+	}
+	g.declared[name] = true
+	var b strings.Builder
+	writeComment(&b, s.Description)
+	if s.Ref == "" && s.typeName() == "object" && len(s.Properties) > 0 {
+		g.writeStruct(&b, name, s)
+	} else {
+		fmt.Fprintf(&b, "type %s %s\n\n", name, g.goType(s, name+"Item"))
+	}
+	g.decls = append(g.decls, b.String())
+}
+
+func writeComment(b *strings.Builder, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		fmt.Fprintf(b, "// %s\n", strings.TrimSpace(line))
+	}
+}
+
+func (g *generator) writeStruct(b *strings.Builder, name string, s *schema) {
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, prop := range sortedKeys(s.Properties) {
+		ps := s.Properties[prop]
+		field := exportName(prop)
+		required := slices.Contains(s.Required, prop)
+		typ := g.goType(ps, name+field)
+		if !required && g.isStruct(ps) {
+			typ = "*" + typ
+		}
+		tag := `json:"` + prop
+		if !required {
+			tag += ",omitempty"
+		}
+		tag += `"`
+		if rules := g.validateTag(ps, required); rules != "" {
+			tag += ` validate:"` + rules + `"`
+		}
+		fmt.Fprintf(b, "\t%s %s `%s`\n", field, typ, tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+// goType returns the Go type of a schema. Inline objects get a type named by the hint.
+func (g *generator) goType(s *schema, hint string) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		if name, err := refName(s.Ref, "schemas"); err == nil {
+			return exportName(name)
+		}
+		return "any"
+	}
+	if len(s.AllOf) == 1 {
+		return g.goType(s.AllOf[0], hint)
+	}
+	switch s.typeName() {
+	case "string":
+		if s.Format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(s.Items, hint)
+	case "object":
+		return g.objectType(s, hint)
+	}
+	return "any"
+}
+
+func (g *generator) objectType(s *schema, hint string) string {
+	if len(s.Properties) > 0 {
+		g.declareSchema(hint, s)
+		return hint
+	}
+	if additional := s.additionalProperties(); additional != nil {
+		return "map[string]" + g.goType(additional, hint+"Value")
+	}
+	return "map[string]any"
+}
+
+func (g *generator) isStruct(s *schema) bool {
+	s = g.doc.resolve(s)
+	return s != nil && s.Ref == "" && s.typeName() == "object" && len(s.Properties) > 0
+}
+
+// validateTag returns the rules of the validator package for a property.
+// Required is not set for numbers and booleans, as zero is a valid value of those.
+func (g *generator) validateTag(s *schema, required bool) string {
+	if s.Ref != "" {
+		if t := g.doc.resolve(s); t == nil || !required || slices.Contains([]string{"integer", "number", "boolean"}, t.typeName()) {
+			return ""
+		}
+		return "required"
+	}
+	rules, zeroValid := typeRules(s)
+	switch {
+	case required && !zeroValid:
+		rules = append([]string{"required"}, rules...)
+	case !required && len(rules) > 0:
+		rules = append([]string{"omitempty"}, rules...)
+	}
+	return strings.Join(rules, ",")
+}
+
+// typeRules returns the rules of the validator package by the type of the schema, and whether zero is a valid value of the type.
+func typeRules(s *schema) (rules []string, zeroValid bool) {
+	switch s.typeName() {
+	case "string":
+		rules = appendLimit(rules, "min", s.MinLength)
+		rules = appendLimit(rules, "max", s.MaxLength)
+		if enum := stringEnum(s.Enum); enum != "" {
+			rules = append(rules, "oneof="+enum)
+		}
+		if format, ok := validateFormats[s.Format]; ok {
+			rules = append(rules, format)
+		}
+	case "integer", "number":
+		if s.Minimum != nil {
+			rules = append(rules, "gte="+strconv.FormatFloat(*s.Minimum, 'f', -1, 64))
+		}
+		if s.Maximum != nil {
+			rules = append(rules, "lte="+strconv.FormatFloat(*s.Maximum, 'f', -1, 64))
+		}
+		zeroValid = true
+	case "boolean":
+		zeroValid = true
+	case "array":
+		rules = appendLimit(rules, "min", s.MinItems)
+		rules = appendLimit(rules, "max", s.MaxItems)
+	}
+	return
+}
+
+var validateFormats = map[string]string{"email": "email", "uuid": "uuid", "uri": "url", "ipv4": "ipv4", "ipv6": "ipv6", "hostname": "hostname"}
+
+func appendLimit(rules []string, rule string, limit *int) []string {
+	if limit == nil {
+		return rules
+	}
+	return append(rules, rule+"="+strconv.Itoa(*limit))
+}
+
+// stringEnum returns the enum values separated by spaces, or empty if not all of them can be expressed that way.
+func stringEnum(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, v := range enum {
+		s, ok := v.(string)
+		if !ok || s == "" || strings.ContainsAny(s, " ,|'") {
+			return ""
+		}
+		values = append(values, s)
+	}
+	return strings.Join(values, " ")
+}
+
+// operations returns the operations of the document, sorted by path and method.
+func (g *generator) operations() ([]genOperation, error) {
+	var ops []genOperation
+	names := map[string]string{}
+	for _, path := range sortedKeys(g.doc.Paths) {
+		item := g.doc.Paths[path]
+		var common []*parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &common); err != nil {
+				return nil, fmt.Errorf("parameters of %s: %w", path, err)
+			}
+		}
+		for _, method := range methodOrder {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var o operation
+			if err := json.Unmarshal(raw, &o); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			op, err := g.operation(path, strings.ToUpper(method), &o, common)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			if prev, ok := names[op.name]; ok {
+				return nil, fmt.Errorf("%s %s: operation name %s already used by %s", op.method, path, op.name, prev)
+			}
+			names[op.name] = op.method + " " + path
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+func (g *generator) operation(path, method string, o *operation, common []*parameter) (genOperation, error) {
+	op := genOperation{id: o.OperationID, summary: o.Summary, method: method, path: path, name: exportName(o.OperationID)}
+	if op.name == "" {
+		op.name = exportName(strings.ToLower(method) + " " + strings.NewReplacer("{", "", "}", "").Replace(path))
+	}
+	if err := g.operationParams(&op, append(common, o.Parameters...)); err != nil {
+		return op, err
+	}
+	body, err := g.doc.requestBody(o.RequestBody)
+	if err != nil {
+		return op, err
+	}
+	if body != nil {
+		if s, ok := jsonContent(body.Content); ok {
+			op.bodyType = g.goType(s, op.name+"Request")
+		}
+	}
+	return op, g.operationResponse(&op, o.Responses)
+}
+
+// operationParams sets path, query and header parameters. Operation level parameters override path level ones.
+func (g *generator) operationParams(op *genOperation, params []*parameter) error {
+	seen := map[string]int{}
+	var all []genParam
+	for _, p := range params {
+		p, err := g.doc.parameter(p)
+		if err != nil {
+			return err
+		}
+		if p.In == "cookie" {
+			continue
+		}
+		param := genParam{name: p.Name, in: p.In, ident: varName(p.Name), field: exportName(p.Name), required: p.Required || p.In == "path"}
+		param.typ, param.array = paramType(g.doc.resolve(p.Schema))
+		if i, ok := seen[p.In+" "+p.Name]; ok {
+			all[i] = param
+			continue
+		}
+		seen[p.In+" "+p.Name] = len(all)
+		all = append(all, param)
+	}
+	for _, param := range all {
+		if param.in == "path" {
+			op.pathParams = append(op.pathParams, param)
+		} else {
+			op.params = append(op.params, param)
+		}
+	}
+	return nil
+}
+
+// paramType returns the Go type of a parameter. Only primitive types and arrays of those are supported, other ones are strings.
+func paramType(s *schema) (typ string, array bool) {
+	if s == nil {
+		return "string", false
+	}
+	switch s.typeName() {
+	case "integer":
+		if s.Format == "int32" {
+			return "int32", false
+		}
+		return "int64", false
+	case "number":
+		if s.Format == "float" {
+			return "float32", false
+		}
+		return "float64", false
+	case "boolean":
+		return "bool", false
+	case "array":
+		if s.Items != nil && s.Items.typeName() != "array" {
+			typ, _ := paramType(s.Items)
+			return typ, true
+		}
+	}
+	return "string", false
+}
+
+// operationResponse sets the response type and status by the first successful response.
+func (g *generator) operationResponse(op *genOperation, responses map[string]*response) error {
+	op.status = http.StatusOK
+	for _, code := range sortedKeys(responses) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		if status, err := strconv.Atoi(code); err == nil {
+			op.status = status
+		}
+		resp, err := g.doc.response(responses[code])
+		if err != nil {
+			return err
+		}
+		if s, ok := jsonContent(resp.Content); ok {
+			op.respType = g.goType(s, op.name+"Response")
+		}
+		return nil
+	}
+	return nil
+}
+
+// writeParamTypes writes the types of query and header parameters of operations.
+func (g *generator) writeParamTypes(b *bytes.Buffer, ops []genOperation) {
+	for _, op := range ops {
+		if len(op.params) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "// %sParams are the query and header parameters of %s. Optional ones are pointers or slices.\ntype %sParams struct {\n", op.name, op.name, op.name)
+		for _, p := range op.params {
+			in := "Header"
+			if p.in == "query" {
+				in = "Query"
+			}
+			fmt.Fprintf(b, "\t%s %s // %s %s\n", p.field, p.fieldType(), in, p.name)
+		}
+		b.WriteString("}\n\n")
+	}
+}
+
+func (p genParam) fieldType() string {
+	switch {
+	case p.array:
+		return "[]" + p.typ
+	case p.required:
+		return p.typ
+	}
+	return "*" + p.typ
+}
+
+// signature returns the parameters and results of the operation method.
+func (op genOperation) signature() string {
+	args := []string{"ctx context.Context"}
+	for _, p := range op.pathParams {
+		args = append(args, p.ident+" "+p.fieldType())
+	}
+	if len(op.params) > 0 {
+		args = append(args, "params "+op.name+"Params")
+	}
+	if op.bodyType != "" {
+		args = append(args, "body "+op.bodyType)
+	}
+	if op.respType == "" {
+		return "(" + strings.Join(args, ", ") + ") error"
+	}
+	return "(" + strings.Join(args, ", ") + ") (" + op.result() + ", error)"
+}
+
+// result returns the result type of the operation. Structs are returned as pointers.
+func (op genOperation) result() string {
+	if op.respType == "" || isBuiltin(op.respType) || strings.HasPrefix(op.respType, "[]") || strings.HasPrefix(op.respType, "map[") {
+		return op.respType
+	}
+	return "*" + op.respType
+}
+
+// zero returns the zero value of the result.
+func (op genOperation) zero() string {
+	switch r := op.result(); {
+	case strings.HasPrefix(r, "*"), strings.HasPrefix(r, "[]"), strings.HasPrefix(r, "map["), r == "any":
+		return "nil"
+	default:
+		return "*new(" + r + ")"
+	}
+}
+
+func (op genOperation) comment(b *bytes.Buffer, indent string) {
+	if op.summary != "" {
+		fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimSpace(strings.ReplaceAll(op.summary, "\n", " ")))
+	} else {
+		fmt.Fprintf(b, "%s// %s calls %s %s.\n", indent, op.name, op.method, op.path)
+	}
+}
+
+func isBuiltin(typ string) bool {
+	switch typ {
+	case "string", "int32", "int64", "float32", "float64", "bool", "any", "time.Time":
+		return true
+	}
+	return false
+}
+
+var initialisms = map[string]string{"id": "ID", "ids": "IDs", "url": "URL", "uri": "URI", "http": "HTTP", "api": "API", "json": "JSON", "uuid": "UUID", "ip": "IP"}
+
+// exportName returns an exported Go identifier of a name, e.g. "UserID" of "user_id" or "user-id".
+func exportName(name string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, word := range words {
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+	s := b.String()
+	if s != "" && unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// varName returns an unexported Go identifier of a name, e.g. "userID" of "user_id".
+func varName(name string) string {
+	s := exportName(name)
+	if s == "" {
+		return "param"
+	}
+	runes := []rune(s)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) && (i == 0 || i+1 == len(runes) || unicode.IsUpper(runes[i+1])) {
+		runes[i] = unicode.ToLower(runes[i])
+		i++
+	}
+	s = string(runes)
+	if token.IsKeyword(s) || reserved[s] {
+		s += "Param"
+	}
+	return s
+}
+
+// reserved are the identifiers used by the generated code.
+var reserved = map[string]bool{"ctx": true, "body": true, "params": true, "l": true, "q": true, "r": true, "s": true, "c": true,
+	"target": true, "query": true, "header": true, "resp": true, "err": true, "v": true, "ok": true}
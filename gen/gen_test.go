@@ -0,0 +1,74 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package gen
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateUsers(t *testing.T) {
+	assert := assert.New(t)
+	spec, err := os.ReadFile("testdata/users.yaml")
+	assert.NoError(err)
+	code, err := Generate(spec, Options{Package: "users"})
+	assert.NoError(err)
+	expected, err := os.ReadFile("internal/users/users.gen.go")
+	assert.NoError(err)
+	assert.Equal(string(expected), string(code), "run go generate ./gen/...")
+}
+
+func TestGenerateOptions(t *testing.T) {
+	assert := assert.New(t)
+	spec := []byte(`{"paths": {"/ping": {"get": {"responses": {"204": {"description": "Pong"}}}}}}`)
+
+	code, err := Generate(spec, Options{NoClient: true})
+	assert.NoError(err)
+	assert.Contains(string(code), "package api\n")
+	assert.Contains(string(code), "GetPing(ctx context.Context) error\n")
+	assert.NotContains(string(code), "type Client struct")
+
+	code, err = Generate(spec, Options{NoServer: true})
+	assert.NoError(err)
+	assert.Contains(string(code), "func (c *Client) GetPing(ctx context.Context) error {")
+	assert.NotContains(string(code), "RegisterHandlers")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := Generate([]byte(":"), Options{})
+	assert.Error(err)
+	_, err = Generate([]byte(`{"paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/Missing"}]}}}}`), Options{})
+	assert.ErrorContains(err, "not found")
+	_, err = Generate([]byte(`{"paths": {"/a": {"get": {"operationId": "op"}}, "/b": {"get": {"operationId": "op"}}}}`), Options{})
+	assert.ErrorContains(err, "already used")
+}
+
+func TestGenerateTypes(t *testing.T) {
+	assert := assert.New(t)
+	spec := []byte(`{"components": {"schemas": {
+		"Labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"Point": {"type": "object", "properties": {"x": {"type": "number", "minimum": 0}, "y": {"type": "number", "format": "float"}, "valid": {"type": ["boolean", "null"]}}, "required": ["x"]}
+	}}}`)
+	code, err := Generate(spec, Options{})
+	assert.NoError(err)
+	assert.Contains(string(code), "type Labels map[string]string\n")
+	assert.Contains(string(code), "X     float64 `json:\"x\" validate:\"gte=0\"`")
+	assert.Contains(string(code), "Y     float32 `json:\"y,omitempty\"`")
+	assert.Contains(string(code), "Valid bool    `json:\"valid,omitempty\"`")
+}
+
+func TestNames(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("UserID", exportName("user_id"))
+	assert.Equal("GetUsersID", exportName("get /users/id"))
+	assert.Equal("X2fa", exportName("2fa"))
+	assert.Equal("userID", varName("user-id"))
+	assert.Equal("id", varName("id"))
+	assert.Equal("typeParam", varName("type"))
+	assert.Equal("ctxParam", varName("ctx"))
+}
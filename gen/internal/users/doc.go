@@ -0,0 +1,8 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package users is generated from the test document of the gen package.
+package users
+
+//go:generate go run ../../../cmd/restful-gen -spec ../../testdata/users.yaml -package users -o users.gen.go
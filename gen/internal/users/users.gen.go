@@ -0,0 +1,224 @@
+// Code generated by restful-gen. DO NOT EDIT.
+
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nokia/restful"
+)
+
+type Role string
+
+type UserAddress struct {
+	City string `json:"city,omitempty"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+// User of the service.
+type User struct {
+	Address *UserAddress `json:"address,omitempty"`
+	Created time.Time    `json:"created,omitempty"`
+	Email   string       `json:"email" validate:"required,email"`
+	ID      int64        `json:"id,omitempty"`
+	Name    string       `json:"name" validate:"required,min=1,max=64"`
+	Role    Role         `json:"role,omitempty"`
+	Tags    []string     `json:"tags,omitempty" validate:"omitempty,max=8"`
+}
+
+// ListUsersParams are the query and header parameters of ListUsers. Optional ones are pointers or slices.
+type ListUsersParams struct {
+	Limit   *int32   // Query limit
+	Role    []string // Query role
+	XTenant string   // Header X-Tenant
+}
+
+// Server is implemented by the handlers of the API operations.
+type Server interface {
+	// List users
+	ListUsers(ctx context.Context, params ListUsersParams) ([]User, error)
+	// Create a user
+	CreateUser(ctx context.Context, body User) (*User, error)
+	// GetUser calls GET /users/{id}.
+	GetUser(ctx context.Context, id int64) (*User, error)
+	// DeleteUsersID calls DELETE /users/{id}.
+	DeleteUsersID(ctx context.Context, id int64) error
+}
+
+// RegisterHandlers registers the operations of the API at the router, calling the server.
+// Use a subrouter for the base path of the API, e.g. r.PathPrefix("/api/v1").Subrouter().
+func RegisterHandlers(r *restful.Router, s Server) {
+	r.HandleFunc("/users", func(ctx context.Context) ([]User, error) {
+		l := restful.L(ctx)
+		q := l.RequestURL().Query()
+		var params ListUsersParams
+		if v, ok, err := getParam[int32](q["limit"], "limit", false); err != nil {
+			return nil, err
+		} else if ok {
+			params.Limit = &v
+		}
+		if v, err := getParams[string](q["role"], "role", false); err != nil {
+			return nil, err
+		} else {
+			params.Role = v
+		}
+		if v, _, err := getParam[string](l.RequestHeaderValues("X-Tenant"), "X-Tenant", true); err != nil {
+			return nil, err
+		} else {
+			params.XTenant = v
+		}
+		return s.ListUsers(ctx, params)
+	}).Methods(http.MethodGet).Name("listUsers").Summary("List users")
+	r.HandleFunc("/users", func(ctx context.Context, body User) (*User, error) {
+		resp, err := s.CreateUser(ctx, body)
+		if err == nil {
+			restful.L(ctx).ResponseStatus(201)
+		}
+		return resp, err
+	}).Methods(http.MethodPost).Name("createUser").Summary("Create a user")
+	r.HandleFunc("/users/{id}", func(ctx context.Context) (*User, error) {
+		l := restful.L(ctx)
+		var id int64
+		if v, _, err := getParam[int64]([]string{l.RequestVars()["id"]}, "id", true); err != nil {
+			return nil, err
+		} else {
+			id = v
+		}
+		return s.GetUser(ctx, id)
+	}).Methods(http.MethodGet).Name("getUser")
+	r.HandleFunc("/users/{id}", func(ctx context.Context) error {
+		l := restful.L(ctx)
+		var id int64
+		if v, _, err := getParam[int64]([]string{l.RequestVars()["id"]}, "id", true); err != nil {
+			return err
+		} else {
+			id = v
+		}
+		return s.DeleteUsersID(ctx, id)
+	}).Methods(http.MethodDelete)
+}
+
+type paramValue interface {
+	string | int32 | int64 | float32 | float64 | bool
+}
+
+// getParam returns the first value of a parameter, telling whether it is present.
+func getParam[T paramValue](values []string, name string, required bool) (value T, ok bool, err error) {
+	if len(values) == 0 {
+		if required {
+			return value, false, restful.NewError(nil, http.StatusBadRequest, "missing parameter: "+name)
+		}
+		return value, false, nil
+	}
+	if value, err = parseParam[T](values[0]); err != nil {
+		return value, false, restful.NewError(err, http.StatusBadRequest, "invalid parameter: "+name)
+	}
+	return value, true, nil
+}
+
+// getParams returns all the values of a parameter.
+func getParams[T paramValue](values []string, name string, required bool) ([]T, error) {
+	if len(values) == 0 && required {
+		return nil, restful.NewError(nil, http.StatusBadRequest, "missing parameter: "+name)
+	}
+	var list []T
+	for _, s := range values {
+		value, err := parseParam[T](s)
+		if err != nil {
+			return nil, restful.NewError(err, http.StatusBadRequest, "invalid parameter: "+name)
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+func parseParam[T paramValue](s string) (value T, err error) {
+	switch p := any(&value).(type) {
+	case *string:
+		*p = s
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		*p = int32(n)
+	case *int64:
+		*p, err = strconv.ParseInt(s, 10, 64)
+	case *float32:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		*p = float32(f)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
+	case *bool:
+		*p, err = strconv.ParseBool(s)
+	}
+	return value, err
+}
+
+// Client is a typed client of the API.
+type Client struct {
+	Client *restful.Client
+	Root   string // Root URL of the API, e.g. "http://users:8080/api/v1".
+}
+
+// NewClient creates a typed client of the API at the root URL. A new restful client is created if client is nil.
+func NewClient(root string, client *restful.Client) *Client {
+	if client == nil {
+		client = restful.NewClient()
+	}
+	return &Client{Client: client, Root: strings.TrimSuffix(root, "/")}
+}
+
+// List users
+func (c *Client) ListUsers(ctx context.Context, params ListUsersParams) ([]User, error) {
+	target := c.Root + "/users"
+	query := url.Values{}
+	header := http.Header{}
+	if params.Limit != nil {
+		query.Set("limit", fmt.Sprint(*params.Limit))
+	}
+	for _, v := range params.Role {
+		query.Add("role", v)
+	}
+	header.Set("X-Tenant", params.XTenant)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var resp []User
+	if _, err := c.Client.SendRecv2xx(ctx, http.MethodGet, target, header, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Create a user
+func (c *Client) CreateUser(ctx context.Context, body User) (*User, error) {
+	target := c.Root + "/users"
+	var resp User
+	if _, err := c.Client.SendRecv2xx(ctx, http.MethodPost, target, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetUser calls GET /users/{id}.
+func (c *Client) GetUser(ctx context.Context, id int64) (*User, error) {
+	target := c.Root + "/users/" + url.PathEscape(fmt.Sprint(id))
+	var resp User
+	if _, err := c.Client.SendRecv2xx(ctx, http.MethodGet, target, nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteUsersID calls DELETE /users/{id}.
+func (c *Client) DeleteUsersID(ctx context.Context, id int64) error {
+	target := c.Root + "/users/" + url.PathEscape(fmt.Sprint(id))
+	_, err := c.Client.SendRecv2xx(ctx, http.MethodDelete, target, nil, nil, nil)
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type server struct {
+	users []User
+}
+
+func (s *server) ListUsers(ctx context.Context, params ListUsersParams) ([]User, error) {
+	if params.XTenant != "test" {
+		return nil, restful.NewError(nil, http.StatusForbidden)
+	}
+	var users []User
+	for _, user := range s.users {
+		if len(params.Role) == 0 || slices.Contains(params.Role, string(user.Role)) {
+			users = append(users, user)
+		}
+	}
+	if params.Limit != nil && len(users) > int(*params.Limit) {
+		users = users[:*params.Limit]
+	}
+	return users, nil
+}
+
+func (s *server) CreateUser(ctx context.Context, body User) (*User, error) {
+	body.ID = int64(len(s.users) + 1)
+	s.users = append(s.users, body)
+	return &body, nil
+}
+
+func (s *server) GetUser(ctx context.Context, id int64) (*User, error) {
+	if id < 1 || id > int64(len(s.users)) {
+		return nil, restful.NewError(nil, http.StatusNotFound)
+	}
+	return &s.users[id-1], nil
+}
+
+func (s *server) DeleteUsersID(ctx context.Context, id int64) error {
+	return nil
+}
+
+func TestUsers(t *testing.T) {
+	assert := assert.New(t)
+	r := restful.NewRouter()
+	RegisterHandlers(r.PathPrefix("/api/v1").Subrouter(), &server{})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	client := NewClient(srv.URL+"/api/v1/", nil)
+	ctx := context.Background()
+
+	user, err := client.CreateUser(ctx, User{Name: "Joe", Email: "joe@example.com", Role: "admin"})
+	assert.NoError(err)
+	assert.Equal(int64(1), user.ID)
+	_, err = client.CreateUser(ctx, User{Name: "Jane", Email: "jane@example.com", Role: "user", Address: &UserAddress{City: "Espoo"}})
+	assert.NoError(err)
+	_, err = client.CreateUser(ctx, User{Name: "Bad", Email: "bad"})
+	assert.Equal(http.StatusUnprocessableEntity, restful.GetErrStatusCode(err))
+
+	user, err = client.GetUser(ctx, 2)
+	assert.NoError(err)
+	assert.Equal("Espoo", user.Address.City)
+	_, err = client.GetUser(ctx, 3)
+	assert.Equal(http.StatusNotFound, restful.GetErrStatusCode(err))
+
+	users, err := client.ListUsers(ctx, ListUsersParams{XTenant: "test", Role: []string{"user"}})
+	assert.NoError(err)
+	assert.Len(users, 1)
+	limit := int32(1)
+	users, err = client.ListUsers(ctx, ListUsersParams{XTenant: "test", Limit: &limit})
+	assert.NoError(err)
+	assert.Equal("Joe", users[0].Name)
+	_, err = client.ListUsers(ctx, ListUsersParams{})
+	assert.Equal(http.StatusForbidden, restful.GetErrStatusCode(err))
+
+	assert.NoError(client.DeleteUsersID(ctx, 1))
+}
+
+func TestUsersInvalidParams(t *testing.T) {
+	assert := assert.New(t)
+	r := restful.NewRouter()
+	RegisterHandlers(r, &server{})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	for _, target := range []string{"/users/x", "/users?limit=x"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+target, nil)
+		req.Header.Set("X-Tenant", "test")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(err)
+		resp.Body.Close()
+		assert.Equal(http.StatusBadRequest, resp.StatusCode, target)
+	}
+	resp, err := http.Get(srv.URL + "/users")
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,185 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const restfulImport = "github.com/nokia/restful"
+
+// writeServer writes the Server interface, and RegisterHandlers registering Lambda handlers calling it.
+func (g *generator) writeServer(b *bytes.Buffer, ops []genOperation) {
+	for _, imp := range []string{"context", "net/http", "strconv", restfulImport} {
+		g.imports[imp] = true
+	}
+	b.WriteString("// Server is implemented by the handlers of the API operations.\ntype Server interface {\n")
+	for _, op := range ops {
+		op.comment(b, "\t")
+		fmt.Fprintf(b, "\t%s%s\n", op.name, op.signature())
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("// RegisterHandlers registers the operations of the API at the router, calling the server.\n")
+	b.WriteString("// Use a subrouter for the base path of the API, e.g. r.PathPrefix(\"/api/v1\").Subrouter().\n")
+	b.WriteString("func RegisterHandlers(r *restful.Router, s Server) {\n")
+	for _, op := range ops {
+		writeHandler(b, op)
+	}
+	b.WriteString("}\n\n")
+	b.WriteString(serverHelpers)
+}
+
+func writeHandler(b *bytes.Buffer, op genOperation) {
+	ret := "return err"
+	results := "error"
+	if op.respType != "" {
+		ret = "return " + op.zero() + ", err"
+		results = "(" + op.result() + ", error)"
+	}
+	args := "ctx context.Context"
+	if op.bodyType != "" {
+		args += ", body " + op.bodyType
+	}
+	fmt.Fprintf(b, "\tr.HandleFunc(%q, func(%s) %s {\n", op.path, args, results)
+	if len(op.pathParams)+len(op.params) > 0 {
+		b.WriteString("\t\tl := restful.L(ctx)\n")
+	}
+	if op.hasQuery() {
+		b.WriteString("\t\tq := l.RequestURL().Query()\n")
+	}
+	for _, p := range op.pathParams {
+		fmt.Fprintf(b, "\t\tvar %s %s\n", p.ident, p.typ)
+		writeParamGet(b, p, fmt.Sprintf("[]string{l.RequestVars()[%q]}", p.name), p.ident, ret)
+	}
+	if len(op.params) > 0 {
+		fmt.Fprintf(b, "\t\tvar params %sParams\n", op.name)
+	}
+	for _, p := range op.params {
+		src := fmt.Sprintf("q[%q]", p.name)
+		if p.in == "header" {
+			src = fmt.Sprintf("l.RequestHeaderValues(%q)", p.name)
+		}
+		writeParamGet(b, p, src, "params."+p.field, ret)
+	}
+	writeHandlerCall(b, op)
+	fmt.Fprintf(b, "\t}).Methods(http.Method%s)", strings.ToUpper(op.method[:1])+strings.ToLower(op.method[1:]))
+	if op.id != "" {
+		fmt.Fprintf(b, ".Name(%q)", op.id)
+	}
+	if op.summary != "" {
+		fmt.Fprintf(b, ".Summary(%q)", op.summary)
+	}
+	b.WriteString("\n")
+}
+
+func writeParamGet(b *bytes.Buffer, p genParam, src, target, ret string) {
+	switch {
+	case p.array:
+		fmt.Fprintf(b, "\t\tif v, err := getParams[%s](%s, %q, %t); err != nil {\n\t\t\t%s\n\t\t} else {\n\t\t\t%s = v\n\t\t}\n", p.typ, src, p.name, p.required, ret, target)
+	case p.required:
+		fmt.Fprintf(b, "\t\tif v, _, err := getParam[%s](%s, %q, true); err != nil {\n\t\t\t%s\n\t\t} else {\n\t\t\t%s = v\n\t\t}\n", p.typ, src, p.name, ret, target)
+	default:
+		fmt.Fprintf(b, "\t\tif v, ok, err := getParam[%s](%s, %q, false); err != nil {\n\t\t\t%s\n\t\t} else if ok {\n\t\t\t%s = &v\n\t\t}\n", p.typ, src, p.name, ret, target)
+	}
+}
+
+// writeHandlerCall writes calling the server, setting the response status if other than the default one.
+func writeHandlerCall(b *bytes.Buffer, op genOperation) {
+	args := []string{"ctx"}
+	for _, p := range op.pathParams {
+		args = append(args, p.ident)
+	}
+	if len(op.params) > 0 {
+		args = append(args, "params")
+	}
+	if op.bodyType != "" {
+		args = append(args, "body")
+	}
+	call := fmt.Sprintf("s.%s(%s)", op.name, strings.Join(args, ", "))
+	if op.status == 200 || op.status == 204 {
+		fmt.Fprintf(b, "\t\treturn %s\n", call)
+		return
+	}
+	if op.respType == "" {
+		fmt.Fprintf(b, "\t\terr := %s\n", call)
+	} else {
+		fmt.Fprintf(b, "\t\tresp, err := %s\n", call)
+	}
+	fmt.Fprintf(b, "\t\tif err == nil {\n\t\t\trestful.L(ctx).ResponseStatus(%d)\n\t\t}\n", op.status)
+	if op.respType == "" {
+		b.WriteString("\t\treturn err\n")
+	} else {
+		b.WriteString("\t\treturn resp, err\n")
+	}
+}
+
+func (op genOperation) hasQuery() bool {
+	for _, p := range op.params {
+		if p.in == "query" {
+			return true
+		}
+	}
+	return false
+}
+
+const serverHelpers = `type paramValue interface {
+	string | int32 | int64 | float32 | float64 | bool
+}
+
+// getParam returns the first value of a parameter, telling whether it is present.
+func getParam[T paramValue](values []string, name string, required bool) (value T, ok bool, err error) {
+	if len(values) == 0 {
+		if required {
+			return value, false, restful.NewError(nil, http.StatusBadRequest, "missing parameter: "+name)
+		}
+		return value, false, nil
+	}
+	if value, err = parseParam[T](values[0]); err != nil {
+		return value, false, restful.NewError(err, http.StatusBadRequest, "invalid parameter: "+name)
+	}
+	return value, true, nil
+}
+
+// getParams returns all the values of a parameter.
+func getParams[T paramValue](values []string, name string, required bool) ([]T, error) {
+	if len(values) == 0 && required {
+		return nil, restful.NewError(nil, http.StatusBadRequest, "missing parameter: "+name)
+	}
+	var list []T
+	for _, s := range values {
+		value, err := parseParam[T](s)
+		if err != nil {
+			return nil, restful.NewError(err, http.StatusBadRequest, "invalid parameter: "+name)
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+func parseParam[T paramValue](s string) (value T, err error) {
+	switch p := any(&value).(type) {
+	case *string:
+		*p = s
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		*p = int32(n)
+	case *int64:
+		*p, err = strconv.ParseInt(s, 10, 64)
+	case *float32:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		*p = float32(f)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
+	case *bool:
+		*p, err = strconv.ParseBool(s)
+	}
+	return value, err
+}
+
+`
@@ -0,0 +1,194 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is the part of an OpenAPI document used for generation.
+type document struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"` // Path -> method or "parameters" -> object.
+	Components struct {
+		Schemas       map[string]*schema      `json:"schemas"`
+		Parameters    map[string]*parameter   `json:"parameters"`
+		RequestBodies map[string]*requestBody `json:"requestBodies"`
+		Responses     map[string]*response    `json:"responses"`
+	} `json:"components"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 any                `json:"type"` // String, or array of strings in OpenAPI 3.1.
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	AllOf                []*schema          `json:"allOf"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+}
+
+type parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref      string               `json:"$ref"`
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref     string               `json:"$ref"`
+	Content map[string]mediaType `json:"content"`
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+// parseDocument parses an OpenAPI document in JSON or YAML format.
+func parseDocument(spec []byte) (*document, error) {
+	var v any
+	if err := yaml.Unmarshal(spec, &v); err != nil { // JSON is YAML, too.
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	var doc document
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return &doc, nil
+}
+
+// refName returns the name of a local component reference, e.g. "User" of "#/components/schemas/User".
+func refName(ref, kind string) (string, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !ok {
+		return "", fmt.Errorf("unsupported reference %q", ref)
+	}
+	return name, nil
+}
+
+func (d *document) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Parameters[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("parameter %q not found", p.Ref)
+}
+
+func (d *document) requestBody(b *requestBody) (*requestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.RequestBodies[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("request body %q not found", b.Ref)
+}
+
+func (d *document) response(r *response) (*response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := d.Components.Responses[name]; ok {
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("response %q not found", r.Ref)
+}
+
+// resolve returns the schema referenced, if a reference.
+func (d *document) resolve(s *schema) *schema {
+	for range 10 {
+		if s == nil || s.Ref == "" {
+			return s
+		}
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return nil
+		}
+		s = d.Components.Schemas[name]
+	}
+	return nil
+}
+
+// typeName returns the type of the schema, the first non-null one if several.
+func (s *schema) typeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for i := range t {
+			if name, ok := t[i].(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+// additionalProperties returns the schema of additional properties, or nil if not a schema.
+func (s *schema) additionalProperties() *schema {
+	var additional schema
+	if len(s.AdditionalProperties) == 0 || s.AdditionalProperties[0] != '{' || json.Unmarshal(s.AdditionalProperties, &additional) != nil {
+		return nil
+	}
+	return &additional
+}
+
+// jsonContent returns the schema of the JSON media type of the content, if any.
+func jsonContent(content map[string]mediaType) (*schema, bool) {
+	for _, key := range sortedKeys(content) {
+		if key == "application/json" || strings.HasSuffix(key, "+json") {
+			return content[key].Schema, true
+		}
+	}
+	return nil, false
+}
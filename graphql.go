@@ -0,0 +1,161 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ContentTypeGraphQL is the content type of a GraphQL query sent as request body.
+const ContentTypeGraphQL = "application/graphql"
+
+// GraphQLRequest is a GraphQL operation requested.
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is a location of an error in the query.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is an error of a GraphQL response.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// Error returns the message of the error.
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// NewGraphQLError converts an error returned by a resolver to a GraphQL error.
+// The status code of restful errors, e.g. 404 of NewError(nil, http.StatusNotFound), is told by extension "status".
+func NewGraphQLError(err error, path ...any) GraphQLError {
+	e := GraphQLError{Message: err.Error(), Path: path}
+	if status := GetErrStatusCode(err); status != http.StatusInternalServerError {
+		e.Extensions = map[string]any{"status": status}
+	}
+	return e
+}
+
+// GraphQLResponse is the result of a GraphQL operation.
+type GraphQLResponse struct {
+	Data       any            `json:"data,omitempty"`
+	Errors     []GraphQLError `json:"errors,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLExecutor executes GraphQL operations, e.g. by a schema of a GraphQL library.
+// Context is a Lambda context, so that L(ctx) and tracing of client requests work in resolvers, the same way as in Lambda functions.
+type GraphQLExecutor interface {
+	ExecuteGraphQL(ctx context.Context, req *GraphQLRequest) *GraphQLResponse
+}
+
+// GraphQLExecutorFunc is a function implementing GraphQLExecutor.
+type GraphQLExecutorFunc func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse
+
+// ExecuteGraphQL calls f.
+func (f GraphQLExecutorFunc) ExecuteGraphQL(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+	return f(ctx, req)
+}
+
+// GraphQL is an http.Handler passing GraphQL requests to an executor, which parses and resolves the operations.
+// POST requests may have JSON or application/graphql body. GET requests have query, operationName and variables query parameters,
+// mutations are not allowed by GET, though.
+// Malformed requests are responded by problem details, as REST ones. Errors of the operation are in the GraphQL response of 200 OK.
+//
+//	router.Handle("/graphql", restful.NewGraphQL(schema)).Methods(http.MethodGet, http.MethodPost)
+type GraphQL struct {
+	executor GraphQLExecutor
+}
+
+// NewGraphQL creates a GraphQL handler of the executor.
+func NewGraphQL(executor GraphQLExecutor) *GraphQL {
+	return &GraphQL{executor: executor}
+}
+
+// ServeHTTP serves a GraphQL request.
+func (g *GraphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := NewRequestCtx(w, r)
+	r = r.WithContext(ctx)
+	req, err := getGraphQLRequest(r)
+	if err != nil {
+		if GetErrStatusCode(err) == http.StatusMethodNotAllowed {
+			allow := "GET, POST"
+			if r.Method == http.MethodGet {
+				allow = http.MethodPost // Mutation.
+			}
+			w.Header().Set("Allow", allow)
+		}
+		_ = SendResp(w, r, err, nil)
+		return
+	}
+	resp := g.executor.ExecuteGraphQL(ctx, req)
+	if resp == nil {
+		resp = &GraphQLResponse{}
+	}
+	_ = SendResp(w, r, nil, resp)
+}
+
+var graphQLMutationRe = regexp.MustCompile(`(^|\})\s*mutation\b`)
+
+func getGraphQLRequest(r *http.Request) (*GraphQLRequest, error) {
+	var req GraphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+		for param, v := range map[string]*map[string]any{"variables": &req.Variables, "extensions": &req.Extensions} {
+			if s := query.Get(param); s != "" {
+				if err := json.Unmarshal([]byte(s), v); err != nil {
+					return nil, NewError(err, http.StatusBadRequest, "Invalid "+param)
+				}
+			}
+		}
+		if graphQLMutationRe.MatchString(stripGraphQLComments(req.Query)) {
+			return nil, NewError(nil, http.StatusMethodNotAllowed, "Mutations are not allowed by GET")
+		}
+	case http.MethodPost:
+		if GetBaseContentType(r.Header) == ContentTypeGraphQL {
+			body, err := GetDataBytes(r.Header, r.Body, LambdaMaxBytesToParse)
+			if err != nil {
+				return nil, NewError(err, http.StatusBadRequest, "Failed to read request")
+			}
+			req.Query = string(body)
+		} else if err := GetRequestData(r, LambdaMaxBytesToParse, &req); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, NewError(nil, http.StatusMethodNotAllowed)
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, NewError(errors.New("no query"), http.StatusBadRequest)
+	}
+	return &req, nil
+}
+
+// stripGraphQLComments removes comments, so that those do not hide or fake operation types.
+func stripGraphQLComments(query string) string {
+	lines := strings.Split(query, "\n")
+	for i, line := range lines {
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			lines[i] = line[:comment]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,126 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQL(t *testing.T) {
+	assert := assert.New(t)
+
+	var received *GraphQLRequest
+	r := NewRouter()
+	r.Handle("/graphql", NewGraphQL(GraphQLExecutorFunc(func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+		received = req
+		return &GraphQLResponse{Data: map[string]any{"tenant": L(ctx).RequestHeaderGet("X-Tenant")}}
+	})))
+
+	{ // JSON body
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"query User($id: ID!) { user(id: $id) { name } }","operationName":"User","variables":{"id":"1"}}`))
+		req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		req.Header.Set("X-Tenant", "acme")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.JSONEq(`{"data":{"tenant":"acme"}}`, rr.Body.String())
+		assert.Equal("User", received.OperationName)
+		assert.Equal(map[string]any{"id": "1"}, received.Variables)
+	}
+	{ // GraphQL body is the query itself
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{ users { name } }`))
+		req.Header.Set(ContentTypeHeader, ContentTypeGraphQL)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.Equal("{ users { name } }", received.Query)
+	}
+	{ // GET with variables as JSON query parameter
+		query := url.Values{"query": {"{ user(id: $id) { name } }"}, "variables": {`{"id":"2"}`}}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.Equal(map[string]any{"id": "2"}, received.Variables)
+	}
+	{ // GET of a query mentioning mutation in a comment only
+		query := url.Values{"query": {"# mutation\n{ users { name } }"}}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+		assert.Equal(http.StatusOK, rr.Code)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRouter()
+	r.Handle("/graphql", NewGraphQL(GraphQLExecutorFunc(func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+		if req.Query == "{ nothing }" {
+			return nil
+		}
+		return &GraphQLResponse{Errors: []GraphQLError{NewGraphQLError(NewError(nil, http.StatusNotFound, "no such user"), "user")}}
+	})))
+
+	{ // Errors of the operation are in the response of 200 OK, with the status of restful errors
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ user(id: 1) { name } }"}`))
+		req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.JSONEq(`{"errors":[{"message":"no such user","path":["user"],"extensions":{"status":404}}]}`, rr.Body.String())
+	}
+	{ // No response of the executor
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ nothing }"}`))
+		req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.JSONEq(`{}`, rr.Body.String())
+	}
+	{ // Mutation by GET
+		query := url.Values{"query": {"# comment\nmutation { deleteUser(id: 1) }"}}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+		assert.Equal(http.StatusMethodNotAllowed, rr.Code)
+		assert.Equal(http.MethodPost, rr.Header().Get("Allow"))
+	}
+	{ // Bad method
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/graphql", nil))
+		assert.Equal(http.StatusMethodNotAllowed, rr.Code)
+		assert.Equal("GET, POST", rr.Header().Get("Allow"))
+	}
+	{ // Malformed requests are responded by problem details
+		query := url.Values{"query": {"{ users }"}, "variables": {"{"}}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+		assert.Equal(http.StatusBadRequest, rr.Code)
+
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+		assert.Equal(http.StatusBadRequest, rr.Code)
+
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":`))
+		req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestNewGraphQLError(t *testing.T) {
+	assert := assert.New(t)
+	e := NewGraphQLError(errors.New("boom"))
+	assert.Equal("boom", e.Error())
+	assert.Nil(e.Extensions)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpcgw
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// findField returns the field of the message by proto or JSON name.
+func findField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if fd := md.Fields().ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return md.Fields().ByJSONName(name)
+}
+
+// setField sets the field of the path, e.g. "user.name", to the values of a path variable or query parameter.
+// Values are parsed as JSON of protobuf would be, so that enums by name and well-known types, e.g. Timestamp, work, too.
+// Repeated fields take all the values. Returns false if the message has no field of the path.
+func setField(msg protoreflect.Message, path string, values []string) (bool, error) {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		fd := findField(msg.Descriptor(), name)
+		if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return false, nil
+		}
+		msg = msg.Mutable(fd).Message()
+	}
+	fd := findField(msg.Descriptor(), names[len(names)-1])
+	if fd == nil || fd.IsMap() {
+		return false, nil
+	}
+
+	var value []byte
+	if fd.IsList() {
+		elems := make([]json.RawMessage, len(values))
+		for i, v := range values {
+			elems[i] = jsonValue(fd, v)
+		}
+		value, _ = json.Marshal(elems)
+	} else {
+		if len(values) != 1 {
+			return true, fmt.Errorf("field %s: %d values, expected one", path, len(values))
+		}
+		value = jsonValue(fd, values[0])
+	}
+	object, _ := json.Marshal(map[string]json.RawMessage{fd.JSONName(): value})
+	parsed := msg.New()
+	if err := protojson.Unmarshal(object, parsed.Interface()); err != nil {
+		return true, fmt.Errorf("field %s: %w", path, err)
+	}
+	msg.Set(fd, parsed.Get(fd))
+	return true, nil
+}
+
+// jsonValue returns the JSON value of the string of the field. Numbers may be JSON strings for protojson.
+func jsonValue(fd protoreflect.FieldDescriptor, s string) json.RawMessage {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return json.RawMessage(strconv.FormatBool(b))
+		}
+	case protoreflect.EnumKind:
+		if _, err := strconv.Atoi(s); err == nil {
+			return json.RawMessage(s)
+		}
+	}
+	quoted, _ := json.Marshal(s)
+	return quoted
+}
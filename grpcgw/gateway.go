@@ -0,0 +1,349 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package grpcgw transcodes HTTP/JSON requests on restful routes into gRPC calls, so that gRPC services can be fronted by a restful gateway,
+// having the same tracing, metrics, auth and other middlewares as REST routes.
+//
+// Routes are taken from the google.api.http options of the methods, or given explicitly.
+// Server streaming methods are served as server-sent events. gRPC status codes are translated to HTTP ones, e.g. NotFound to 404.
+//
+//	conn, err := grpc.NewClient("users:9090", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	gw := grpcgw.NewGateway(conn)
+//	err = gw.RegisterService(router, userspb.File_users_proto.Services().ByName("Users"))
+package grpcgw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nokia/restful"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ContentTypeEventStream is the content type of server-sent events, responded for server streaming methods.
+const ContentTypeEventStream = "text/event-stream"
+
+// ForwardedHeaders are the request headers sent as gRPC metadata, having lowercase keys. Trace context is always sent.
+var ForwardedHeaders = []string{"Authorization", "X-Request-Id", "Accept-Language"}
+
+// Rule maps HTTP requests to a gRPC method, the same way as a google.api.http option does.
+type Rule struct {
+	Method       string // HTTP method, e.g. "GET".
+	Path         string // Path template, e.g. "/v1/{name=users/*}". Variables set the fields of the request message.
+	Body         string // Field of the request message the body is decoded to, "*" for the whole message, empty if no body.
+	ResponseBody string // Field of the response message encoded as body, empty for the whole message.
+}
+
+// Gateway transcodes HTTP/JSON requests into gRPC calls on a client connection.
+// Query parameters set the fields of the request message not bound by the path or the body. Unknown parameters are ignored.
+type Gateway struct {
+	conn    grpc.ClientConnInterface
+	marshal protojson.MarshalOptions
+}
+
+// NewGateway creates a gateway calling gRPC methods on the connection.
+func NewGateway(conn grpc.ClientConnInterface) *Gateway {
+	return &Gateway{conn: conn}
+}
+
+// UseProtoNames makes responses have the proto names of fields, e.g. "display_name", instead of lowerCamelCase JSON names.
+func (g *Gateway) UseProtoNames() *Gateway {
+	g.marshal.UseProtoNames = true
+	return g
+}
+
+// EmitUnpopulated makes responses have fields of zero values, too.
+func (g *Gateway) EmitUnpopulated() *Gateway {
+	g.marshal.EmitUnpopulated = true
+	return g
+}
+
+// RegisterService registers routes of the methods of the service having google.api.http option, including its additional bindings.
+// Methods without the option are skipped. Returns the errors of the rules, e.g. a field not found, or a client streaming method.
+func (g *Gateway) RegisterService(router *restful.Router, service protoreflect.ServiceDescriptor) error {
+	var errs []error
+	methods := service.Methods()
+	for i := range methods.Len() {
+		method := methods.Get(i)
+		if !proto.HasExtension(method.Options(), annotations.E_Http) {
+			continue
+		}
+		httpRule, _ := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		for _, r := range append([]*annotations.HttpRule{httpRule}, httpRule.GetAdditionalBindings()...) {
+			if _, err := g.Handle(router, method, ruleOf(r)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func ruleOf(r *annotations.HttpRule) Rule {
+	rule := Rule{Body: r.GetBody(), ResponseBody: r.GetResponseBody()}
+	switch p := r.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		rule.Method, rule.Path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		rule.Method, rule.Path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		rule.Method, rule.Path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		rule.Method, rule.Path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		rule.Method, rule.Path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		rule.Method, rule.Path = p.Custom.GetKind(), p.Custom.GetPath()
+	}
+	return rule
+}
+
+// Handle registers a route transcoding the requests of the rule to the gRPC method, e.g. of a service not having google.api.http options.
+//
+//	gw.Handle(router, healthpb.File_grpc_health_v1_health_proto.Services().Get(0).Methods().ByName("Check"), grpcgw.Rule{Method: "GET", Path: "/health"})
+func (g *Gateway) Handle(router *restful.Router, method protoreflect.MethodDescriptor, rule Rule) (*restful.Route, error) {
+	if method.IsStreamingClient() {
+		return nil, fmt.Errorf("%s: client streaming is not supported", method.FullName())
+	}
+	path, fields, err := muxTemplate(rule.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method.FullName(), err)
+	}
+	if err := checkRule(method, rule, fields); err != nil {
+		return nil, fmt.Errorf("%s: %w", method.FullName(), err)
+	}
+	h := &handler{
+		gateway:    g,
+		method:     method,
+		fullMethod: "/" + string(method.Parent().FullName()) + "/" + string(method.Name()),
+		rule:       rule,
+		fields:     fields,
+	}
+	return router.Handle(path, h).Methods(rule.Method), nil
+}
+
+func checkRule(method protoreflect.MethodDescriptor, rule Rule, fields []string) error {
+	for _, field := range fields {
+		if !hasField(method.Input(), field) {
+			return fmt.Errorf("no field %q of path variable", field)
+		}
+	}
+	if rule.Body != "" && rule.Body != "*" && findField(method.Input(), rule.Body) == nil {
+		return fmt.Errorf("no field %q of body", rule.Body)
+	}
+	if rule.ResponseBody != "" && findField(method.Output(), rule.ResponseBody) == nil {
+		return fmt.Errorf("no field %q of response body", rule.ResponseBody)
+	}
+	return nil
+}
+
+// hasField tells if the message has a field of the path, e.g. "user.name".
+func hasField(md protoreflect.MessageDescriptor, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return false
+		}
+		fd := findField(md, name)
+		if fd == nil || fd.IsList() || fd.IsMap() {
+			return false
+		}
+		md = fd.Message()
+	}
+	return true
+}
+
+type handler struct {
+	gateway    *Gateway
+	method     protoreflect.MethodDescriptor
+	fullMethod string
+	rule       Rule
+	fields     []string
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := restful.NewRequestCtx(w, r)
+	r = r.WithContext(ctx)
+	req, err := h.request(ctx, r)
+	if err != nil {
+		_ = restful.SendResp(w, r, err, nil)
+		return
+	}
+	ctx = metadata.NewOutgoingContext(ctx, outgoingMetadata(ctx, r.Header))
+	if h.method.IsStreamingServer() {
+		h.stream(ctx, w, r, req)
+		return
+	}
+
+	resp := dynamicpb.NewMessage(h.method.Output())
+	if err := h.gateway.conn.Invoke(ctx, h.fullMethod, req, resp); err != nil {
+		sendError(w, r, err)
+		return
+	}
+	body, err := h.responseBody(resp)
+	if err != nil {
+		_ = restful.SendResp(w, r, err, nil)
+		return
+	}
+	w.Header().Set(restful.ContentTypeHeader, restful.ContentTypeApplicationJSON)
+	_, _ = w.Write(body)
+}
+
+// request builds the request message of the body, path variables and query parameters.
+func (h *handler) request(ctx context.Context, r *http.Request) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(h.method.Input())
+	if h.rule.Body != "" {
+		body, err := restful.GetDataBytes(r.Header, r.Body, restful.LambdaMaxBytesToParse)
+		if err != nil {
+			return nil, restful.NewError(err, http.StatusBadRequest, "Failed to read request")
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			if h.rule.Body != "*" {
+				body, _ = json.Marshal(map[string]json.RawMessage{h.rule.Body: body})
+			}
+			if err := protojson.Unmarshal(body, msg); err != nil {
+				return nil, restful.NewError(err, http.StatusBadRequest, "Invalid JSON content")
+			}
+		}
+	}
+
+	vars := restful.L(ctx).RequestVars()
+	for _, field := range h.fields {
+		if _, err := setField(msg, field, []string{vars[field]}); err != nil {
+			return nil, restful.NewError(err, http.StatusBadRequest)
+		}
+	}
+	if h.rule.Body == "*" {
+		return msg, nil
+	}
+	for param, values := range r.URL.Query() {
+		if slices.Contains(h.fields, param) || param == h.rule.Body {
+			continue
+		}
+		if _, err := setField(msg, param, values); err != nil {
+			return nil, restful.NewError(err, http.StatusBadRequest)
+		}
+	}
+	return msg, nil
+}
+
+func outgoingMetadata(ctx context.Context, header http.Header) metadata.MD {
+	m := map[string]string{}
+	restful.InjectTraceMap(ctx, m)
+	for _, name := range ForwardedHeaders {
+		if value := header.Get(name); value != "" {
+			m[strings.ToLower(name)] = value
+		}
+	}
+	return metadata.New(m)
+}
+
+// responseBody returns the JSON of the response message, or of its field of ResponseBody.
+func (h *handler) responseBody(resp proto.Message) ([]byte, error) {
+	if h.rule.ResponseBody == "" {
+		return h.gateway.marshal.Marshal(resp)
+	}
+	options := h.gateway.marshal
+	options.EmitUnpopulated = true
+	b, err := options.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	fd := findField(h.method.Output(), h.rule.ResponseBody)
+	if options.UseProtoNames {
+		return fields[string(fd.Name())], nil
+	}
+	return fields[fd.JSONName()], nil
+}
+
+// stream serves the messages of a server streaming method as server-sent events.
+// Errors before the first message are responded as usual, later ones are sent as events of type "error".
+func (h *handler) stream(ctx context.Context, w http.ResponseWriter, r *http.Request, req proto.Message) {
+	stream, err := h.gateway.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, h.fullMethod)
+	if err == nil {
+		err = stream.SendMsg(req)
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	started := false
+	for {
+		resp := dynamicpb.NewMessage(h.method.Output())
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			break
+		}
+		var body []byte
+		if err == nil {
+			body, err = h.responseBody(resp)
+		}
+		if err != nil && !started {
+			sendError(w, r, err)
+			return
+		}
+		if !started {
+			startEventStream(w)
+			started = true
+		}
+		if err != nil {
+			writeEvent(w, "error", statusJSON(err))
+			_ = rc.Flush()
+			return
+		}
+		writeEvent(w, "", body)
+		_ = rc.Flush()
+	}
+	if !started {
+		startEventStream(w)
+	}
+}
+
+func startEventStream(w http.ResponseWriter) {
+	w.Header().Set(restful.ContentTypeHeader, ContentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeEvent(w io.Writer, event string, data []byte) {
+	if event != "" {
+		_, _ = fmt.Fprintf(w, "event: %s\n", event)
+	}
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+func statusJSON(err error) []byte {
+	st := status.Convert(err)
+	b, _ := json.Marshal(map[string]any{"code": st.Code().String(), "message": st.Message(), "status": HTTPStatus(st.Code())})
+	return b
+}
+
+// sendError responds the gRPC error by the HTTP status of its code. Other errors are responded as restful does.
+func sendError(w http.ResponseWriter, r *http.Request, err error) {
+	if st, ok := status.FromError(err); ok {
+		err = restful.NewError(nil, HTTPStatus(st.Code()), st.Message())
+	}
+	_ = restful.SendResp(w, r, err, nil)
+}
@@ -0,0 +1,333 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpcgw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb" // Imported by the test proto.
+)
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+	label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	if repeated {
+		label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	}
+	f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func method(name, input, output string, streaming bool, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+	m := &descriptorpb.MethodDescriptorProto{Name: proto.String(name), InputType: proto.String(input), OutputType: proto.String(output)}
+	if streaming {
+		m.ServerStreaming = proto.Bool(true)
+	}
+	if rule != nil {
+		m.Options = &descriptorpb.MethodOptions{}
+		proto.SetExtension(m.Options, annotations.E_Http, rule)
+	}
+	return m
+}
+
+// usersService describes a test service:
+//
+//	service Users {
+//	  rpc GetUser(GetUserRequest) returns (User) {option (google.api.http) = {get: "/v1/{name=users/*}" additional_bindings {get: "/v1/people/{name}"}};}
+//	  rpc CreateUser(CreateUserRequest) returns (User) {option (google.api.http) = {post: "/v1/{parent=orgs/*}/users" body: "user"};}
+//	  rpc RenameUser(User) returns (User) {option (google.api.http) = {patch: "/v1/{name=users/*}:rename" body: "*" response_body: "display_name"};}
+//	  rpc ListUsers(ListUsersRequest) returns (stream User) {option (google.api.http) = {get: "/v1/users"};}
+//	  rpc Ping(ListUsersRequest) returns (ListUsersRequest);
+//	}
+func usersService(t *testing.T) protoreflect.ServiceDescriptor {
+	const (
+		str = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		i32 = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		boo = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+		enm = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		msg = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("grpcgw_test.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("State"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("STATE_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("ACTIVE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("User"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, str, "", false),
+				field("display_name", 2, str, "", false),
+				field("age", 3, i32, "", false),
+				field("tags", 4, str, "", true),
+			}},
+			{Name: proto.String("Filter"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("active", 1, boo, "", false),
+				field("state", 2, enm, ".test.v1.State", false),
+			}},
+			{Name: proto.String("GetUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, str, "", false),
+				field("filter", 2, msg, ".test.v1.Filter", false),
+				field("tags", 3, str, "", true),
+				field("since", 4, msg, ".google.protobuf.Timestamp", false),
+				field("limit", 5, i32, "", false),
+			}},
+			{Name: proto.String("CreateUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("parent", 1, str, "", false),
+				field("user", 2, msg, ".test.v1.User", false),
+			}},
+			{Name: proto.String("ListUsersRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("limit", 1, i32, "", false),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Users"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetUser", ".test.v1.GetUserRequest", ".test.v1.User", false, &annotations.HttpRule{
+					Pattern:            &annotations.HttpRule_Get{Get: "/v1/{name=users/*}"},
+					AdditionalBindings: []*annotations.HttpRule{{Pattern: &annotations.HttpRule_Get{Get: "/v1/people/{name}"}}},
+				}),
+				method("CreateUser", ".test.v1.CreateUserRequest", ".test.v1.User", false, &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Post{Post: "/v1/{parent=orgs/*}/users"}, Body: "user",
+				}),
+				method("RenameUser", ".test.v1.User", ".test.v1.User", false, &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Patch{Patch: "/v1/{name=users/*}:rename"}, Body: "*", ResponseBody: "display_name",
+				}),
+				method("ListUsers", ".test.v1.ListUsersRequest", ".test.v1.User", true, &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/users"},
+				}),
+				method("Ping", ".test.v1.ListUsersRequest", ".test.v1.ListUsersRequest", false, nil),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Services().Get(0)
+}
+
+func set(m *dynamicpb.Message, name string, v protoreflect.Value) *dynamicpb.Message {
+	m.Set(m.Descriptor().Fields().ByName(protoreflect.Name(name)), v)
+	return m
+}
+
+func get(m *dynamicpb.Message, name string) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+// serveUsers implements the test service: GetUser echoes the request as display name and the metadata as tags,
+// ListUsers streams the number of users asked, and fails mid-stream on limit 99.
+func serveUsers(service protoreflect.ServiceDescriptor) grpc.StreamHandler {
+	user := service.ParentFile().Messages().ByName("User")
+	return func(_ any, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		md := service.Methods().ByName(protoreflect.Name(path.Base(fullMethod)))
+		in := dynamicpb.NewMessage(md.Input())
+		if err := stream.RecvMsg(in); err != nil {
+			return err
+		}
+		out := dynamicpb.NewMessage(user)
+		switch md.Name() {
+		case "GetUser":
+			if get(in, "name").String() == "users/missing" {
+				return status.Error(codes.NotFound, "user not found")
+			}
+			echo, _ := protojson.Marshal(in)
+			set(out, "name", get(in, "name"))
+			set(out, "display_name", protoreflect.ValueOfString(string(echo)))
+			meta, _ := metadata.FromIncomingContext(stream.Context())
+			tags := out.Mutable(user.Fields().ByName("tags")).List()
+			for _, key := range []string{"authorization", "traceparent"} {
+				if len(meta.Get(key)) > 0 {
+					tags.Append(protoreflect.ValueOfString(key))
+				}
+			}
+		case "CreateUser":
+			out = get(in, "user").Message().Interface().(*dynamicpb.Message)
+			set(out, "name", protoreflect.ValueOfString(get(in, "parent").String()+"/users/1"))
+		case "RenameUser":
+			out = in
+		case "ListUsers":
+			limit := int(get(in, "limit").Int())
+			if limit == 0 {
+				return status.Error(codes.FailedPrecondition, "no limit")
+			}
+			for i := range limit {
+				if i == 1 && limit == 99 {
+					return status.Error(codes.Internal, "broken")
+				}
+				if err := stream.SendMsg(set(dynamicpb.NewMessage(user), "age", protoreflect.ValueOfInt32(int32(i)))); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return stream.SendMsg(out)
+	}
+}
+
+func newTestGateway(t *testing.T) (*restful.Router, protoreflect.ServiceDescriptor) {
+	service := usersService(t)
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(serveUsers(service)))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	router := restful.NewRouter()
+	if err := NewGateway(conn).RegisterService(router, service); err != nil {
+		t.Fatal(err)
+	}
+	return router, service
+}
+
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer x")
+	req.Header.Set(restful.ContentTypeHeader, restful.ContentTypeApplicationJSON)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGatewayUnary(t *testing.T) {
+	assert := assert.New(t)
+	router, _ := newTestGateway(t)
+
+	w := serve(router, http.MethodGet, "/v1/users/1?filter.active=true&filter.state=ACTIVE&tags=a&tags=b&since=2024-01-02T03:04:05Z&limit=5&unknown=x", "")
+	assert.Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(restful.ContentTypeApplicationJSON, w.Header().Get(restful.ContentTypeHeader))
+	var user struct {
+		Name        string   `json:"name"`
+		DisplayName string   `json:"displayName"`
+		Tags        []string `json:"tags"`
+	}
+	assert.NoError(json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal("users/1", user.Name)
+	assert.JSONEq(`{"name":"users/1","filter":{"active":true,"state":"ACTIVE"},"tags":["a","b"],"since":"2024-01-02T03:04:05Z","limit":5}`, user.DisplayName)
+	assert.Contains(user.Tags, "authorization")
+
+	w = serve(router, http.MethodGet, "/v1/people/joe", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), `"name":"joe"`)
+
+	w = serve(router, http.MethodPost, "/v1/orgs/nokia/users?age=3", `{"displayName":"Joe","age":42}`)
+	assert.Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(`{"name":"orgs/nokia/users/1","displayName":"Joe","age":42}`, w.Body.String())
+
+	w = serve(router, http.MethodPatch, "/v1/users/1:rename?display_name=ignored", `{"display_name":"Joe"}`)
+	assert.Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(`"Joe"`, w.Body.String())
+}
+
+func TestGatewayErrors(t *testing.T) {
+	assert := assert.New(t)
+	router, _ := newTestGateway(t)
+
+	w := serve(router, http.MethodGet, "/v1/users/missing", "")
+	assert.Equal(http.StatusNotFound, w.Code)
+	assert.Contains(w.Body.String(), "user not found")
+	assert.NotContains(w.Body.String(), "rpc error")
+
+	w = serve(router, http.MethodGet, "/v1/users/1?limit=x", "")
+	assert.Equal(http.StatusBadRequest, w.Code)
+	w = serve(router, http.MethodGet, "/v1/users/1?limit=1&limit=2", "")
+	assert.Equal(http.StatusBadRequest, w.Code)
+	w = serve(router, http.MethodPost, "/v1/orgs/nokia/users", `{"age":"old"}`)
+	assert.Equal(http.StatusBadRequest, w.Code)
+	w = serve(router, http.MethodGet, "/v1/groups/1", "")
+	assert.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestGatewayStreaming(t *testing.T) {
+	assert := assert.New(t)
+	router, _ := newTestGateway(t)
+
+	w := serve(router, http.MethodGet, "/v1/users?limit=2", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(ContentTypeEventStream, w.Header().Get(restful.ContentTypeHeader))
+	assert.Equal("data: {}\n\ndata: {\"age\":1}\n\n", w.Body.String())
+
+	w = serve(router, http.MethodGet, "/v1/users?limit=99", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("data: {}\n\nevent: error\ndata: {\"code\":\"Internal\",\"message\":\"broken\",\"status\":500}\n\n", w.Body.String())
+
+	w = serve(router, http.MethodGet, "/v1/users", "")
+	assert.Equal(http.StatusBadRequest, w.Code) // FailedPrecondition before the first message.
+}
+
+func TestGatewayHandle(t *testing.T) {
+	assert := assert.New(t)
+	router, service := newTestGateway(t)
+	conn, err := grpc.NewClient("passthrough:///nowhere",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return nil, errors.New("refused") }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(err)
+	defer conn.Close()
+	gw := NewGateway(conn).UseProtoNames().EmitUnpopulated()
+	ping := service.Methods().ByName("Ping")
+
+	_, err = gw.Handle(router, ping, Rule{Method: http.MethodGet, Path: "/v1/ping/{count}"})
+	assert.ErrorContains(err, `no field "count"`)
+	_, err = gw.Handle(router, ping, Rule{Method: http.MethodPost, Path: "/v1/ping", Body: "nope"})
+	assert.ErrorContains(err, `no field "nope" of body`)
+	_, err = gw.Handle(router, ping, Rule{Method: http.MethodGet, Path: "/v1/ping", ResponseBody: "nope"})
+	assert.ErrorContains(err, `no field "nope" of response body`)
+	_, err = gw.Handle(router, ping, Rule{Method: http.MethodGet, Path: "v1/ping"})
+	assert.ErrorContains(err, "not starting by /")
+	_, err = gw.Handle(router, ping, Rule{Method: http.MethodGet, Path: "/v1/{limit"})
+	assert.ErrorContains(err, "unbalanced braces")
+
+	_, err = gw.Handle(router, ping, Rule{Method: http.MethodGet, Path: "/v1/ping/{limit}"})
+	assert.NoError(err)
+	w := serve(router, http.MethodGet, "/v1/ping/3", "")
+	assert.Equal(http.StatusServiceUnavailable, w.Code) // No connection.
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(http.StatusOK, HTTPStatus(codes.OK))
+	assert.Equal(http.StatusUnauthorized, HTTPStatus(codes.Unauthenticated))
+	assert.Equal(StatusClientClosedRequest, HTTPStatus(codes.Canceled))
+	assert.Equal(http.StatusInternalServerError, HTTPStatus(codes.Code(100)))
+}
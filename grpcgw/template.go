@@ -0,0 +1,88 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpcgw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// muxTemplate converts a google.api.http path template, e.g. "/v1/{name=users/*}:rename", to a Router path template,
+// e.g. "/v1/{name:users/[^/]+}:rename". Variables of the Router template are named by the field paths they are bound to.
+// Returns the field paths bound.
+func muxTemplate(template string) (path string, fields []string, err error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", nil, fmt.Errorf("path template %q: not starting by /", template)
+	}
+	var b strings.Builder
+	wildcards := 0
+	for rest := template[1:]; ; {
+		b.WriteByte('/')
+		var segment string
+		segment, rest, err = nextSegment(rest)
+		if err != nil {
+			return "", nil, fmt.Errorf("path template %q: %w", template, err)
+		}
+		switch {
+		case strings.HasPrefix(segment, "{"):
+			end := strings.IndexByte(segment, '}')
+			field, pattern, found := strings.Cut(segment[1:end], "=")
+			if !found {
+				pattern = "*"
+			}
+			fmt.Fprintf(&b, "{%s:%s}%s", field, segmentsRegexp(pattern), segment[end+1:]) // Verb, if any, follows.
+			fields = append(fields, field)
+		case segment == "*" || segment == "**":
+			fmt.Fprintf(&b, "{_%d:%s}", wildcards, segmentsRegexp(segment))
+			wildcards++
+		default:
+			b.WriteString(segment)
+		}
+		if rest == "" {
+			return b.String(), fields, nil
+		}
+	}
+}
+
+// nextSegment returns the next segment of the template, a variable being one segment even if having slashes.
+func nextSegment(s string) (segment, rest string, err error) {
+	depth := 0
+	for i := range len(s) {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				return s[:i], s[i+1:], nil
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return "", "", fmt.Errorf("unbalanced braces")
+		}
+	}
+	if depth != 0 {
+		return "", "", fmt.Errorf("unbalanced braces")
+	}
+	return s, "", nil
+}
+
+// segmentsRegexp returns the regexp of segments of a variable, e.g. "users/*".
+func segmentsRegexp(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		switch segment {
+		case "*":
+			segments[i] = "[^/]+"
+		case "**":
+			segments[i] = ".+"
+		default:
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
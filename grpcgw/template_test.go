@@ -0,0 +1,38 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpcgw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxTemplate(t *testing.T) {
+	assert := assert.New(t)
+	for template, expected := range map[string]string{
+		"/v1/users":                         "/v1/users",
+		"/v1/{name}":                        "/v1/{name:[^/]+}",
+		"/v1/{name=users/*}":                "/v1/{name:users/[^/]+}",
+		"/v1/{name=users/*}:rename":         "/v1/{name:users/[^/]+}:rename",
+		"/v1/{book.name=shelves/*/books/*}": "/v1/{book.name:shelves/[^/]+/books/[^/]+}",
+		"/v1/*/files/{path=**}":             "/v1/{_0:[^/]+}/files/{path:.+}",
+		"/v1/users:batchGet":                "/v1/users:batchGet",
+		"/v1/{name=a.b/*}":                  `/v1/{name:a\.b/[^/]+}`,
+	} {
+		path, _, err := muxTemplate(template)
+		assert.NoError(err, template)
+		assert.Equal(expected, path, template)
+	}
+
+	_, fields, err := muxTemplate("/v1/{parent=orgs/*}/users/{user.id}")
+	assert.NoError(err)
+	assert.Equal([]string{"parent", "user.id"}, fields)
+
+	for _, template := range []string{"v1", "/v1/{name", "/v1/name}", "/v1/{a{b}}"} {
+		_, _, err := muxTemplate(template)
+		assert.Error(err, template)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+type headerOp int
+
+const (
+	headerSet headerOp = iota
+	headerAdd
+	headerRemove
+	headerRename
+)
+
+type headerRule struct {
+	op    headerOp
+	name  string
+	value string // New name on rename.
+}
+
+func applyHeaderRules(header http.Header, rules []headerRule) {
+	for _, rule := range rules {
+		switch rule.op {
+		case headerSet:
+			header.Set(rule.name, rule.value)
+		case headerAdd:
+			header.Add(rule.name, rule.value)
+		case headerRemove:
+			if prefix, ok := strings.CutSuffix(rule.name, "*"); ok {
+				prefix = http.CanonicalHeaderKey(prefix)
+				for name := range header {
+					if strings.HasPrefix(http.CanonicalHeaderKey(name), prefix) {
+						delete(header, name)
+					}
+				}
+			} else {
+				header.Del(rule.name)
+			}
+		case headerRename:
+			if values := header.Values(rule.name); len(values) > 0 {
+				header.Del(rule.name)
+				header[http.CanonicalHeaderKey(rule.value)] = values
+			}
+		}
+	}
+}
+
+// HeaderTransform adds, removes and renames request and response headers, by rules applied in the order given.
+// E.g. strips internal headers from responses leaving the service, or injects a static header into requests.
+// Applied to route groups by Router's HeaderTransform, or to all requests by Handler.
+//
+//	t := restful.NewHeaderTransform().SetRequest("X-Tenant", "acme").RemoveResponse("X-Internal-*")
+//	router.PathPrefix("/acme").Subrouter().HeaderTransform(t)
+type HeaderTransform struct {
+	request  []headerRule
+	response []headerRule
+}
+
+// NewHeaderTransform creates a header transformation, having no rules.
+func NewHeaderTransform() *HeaderTransform {
+	return &HeaderTransform{}
+}
+
+// SetRequest sets a request header, replacing the values received.
+func (t *HeaderTransform) SetRequest(name, value string) *HeaderTransform {
+	t.request = append(t.request, headerRule{op: headerSet, name: name, value: value})
+	return t
+}
+
+// AddRequest adds a value to a request header.
+func (t *HeaderTransform) AddRequest(name, value string) *HeaderTransform {
+	t.request = append(t.request, headerRule{op: headerAdd, name: name, value: value})
+	return t
+}
+
+// RemoveRequest removes request headers. A name ending in "*" removes all the headers having that prefix, e.g. "X-Internal-*".
+func (t *HeaderTransform) RemoveRequest(names ...string) *HeaderTransform {
+	for _, name := range names {
+		t.request = append(t.request, headerRule{op: headerRemove, name: name})
+	}
+	return t
+}
+
+// RenameRequest renames a request header, keeping its values. Replaces the values of the new name, if any.
+func (t *HeaderTransform) RenameRequest(from, to string) *HeaderTransform {
+	t.request = append(t.request, headerRule{op: headerRename, name: from, value: to})
+	return t
+}
+
+// SetResponse sets a response header, replacing the values set by the handler.
+func (t *HeaderTransform) SetResponse(name, value string) *HeaderTransform {
+	t.response = append(t.response, headerRule{op: headerSet, name: name, value: value})
+	return t
+}
+
+// AddResponse adds a value to a response header.
+func (t *HeaderTransform) AddResponse(name, value string) *HeaderTransform {
+	t.response = append(t.response, headerRule{op: headerAdd, name: name, value: value})
+	return t
+}
+
+// RemoveResponse removes response headers. A name ending in "*" removes all the headers having that prefix, e.g. "X-Internal-*".
+func (t *HeaderTransform) RemoveResponse(names ...string) *HeaderTransform {
+	for _, name := range names {
+		t.response = append(t.response, headerRule{op: headerRemove, name: name})
+	}
+	return t
+}
+
+// RenameResponse renames a response header, keeping its values. Replaces the values of the new name, if any.
+func (t *HeaderTransform) RenameResponse(from, to string) *HeaderTransform {
+	t.response = append(t.response, headerRule{op: headerRename, name: from, value: to})
+	return t
+}
+
+// headerTransformWriter applies response rules right before the header is sent.
+type headerTransformWriter struct {
+	*responseWriter
+	rules   []headerRule
+	applied bool
+}
+
+func (w *headerTransformWriter) apply() {
+	if !w.applied {
+		w.applied = true
+		applyHeaderRules(w.Header(), w.rules)
+	}
+}
+
+func (w *headerTransformWriter) WriteHeader(statusCode int) {
+	if statusCode >= 200 { // Informational responses are sent as they are.
+		w.apply()
+	}
+	w.responseWriter.WriteHeader(statusCode)
+}
+
+func (w *headerTransformWriter) Write(b []byte) (int, error) {
+	w.apply()
+	return w.responseWriter.Write(b)
+}
+
+func (w *headerTransformWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.apply()
+	return w.responseWriter.ReadFrom(src)
+}
+
+func (w *headerTransformWriter) Flush() {
+	w.apply()
+	w.responseWriter.Flush()
+}
+
+// Handler wraps the handler, transforming the headers of all requests and responses.
+func (t *HeaderTransform) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(t.request) > 0 {
+			r = r.Clone(r.Context())
+			applyHeaderRules(r.Header, t.request)
+		}
+		if len(t.response) == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		tw := &headerTransformWriter{responseWriter: &responseWriter{ResponseWriter: w}, rules: t.response}
+		h.ServeHTTP(tw, r)
+		tw.apply() // Nothing written, the server sends the header afterwards.
+	})
+}
+
+// HeaderTransform transforms headers of the requests served by the handlers added after calling this function, as Monitor does.
+// Subrouters inherit that, so headers can be transformed per route group. See HeaderTransform.
+func (r *Router) HeaderTransform(t *HeaderTransform) *Router {
+	r.monitors.appendWrapper(t.Handler)
+	return r
+}
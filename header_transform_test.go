@@ -0,0 +1,64 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderTransform(t *testing.T) {
+	assert := assert.New(t)
+	transform := NewHeaderTransform().
+		SetRequest("X-Tenant", "acme").AddRequest("X-Via", "gw").RemoveRequest("X-Debug").RenameRequest("X-Old", "X-New").
+		RemoveResponse("X-Internal-*", "Server").RenameResponse("X-Backend-Id", "X-Served-By").SetResponse("Cache-Control", "no-store")
+	router := NewRouter()
+	group := router.PathPrefix("/acme").Subrouter().HeaderTransform(transform)
+	group.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("acme", r.Header.Get("X-Tenant"))
+		assert.Equal([]string{"client", "gw"}, r.Header.Values("X-Via"))
+		assert.Empty(r.Header.Get("X-Debug"))
+		assert.Empty(r.Header.Get("X-Old"))
+		assert.Equal("v", r.Header.Get("X-New"))
+		w.Header().Set("X-Internal-Node", "n1")
+		w.Header().Set("X-Internal-Zone", "z1")
+		w.Header().Set("Server", "backend")
+		w.Header().Set("X-Backend-Id", "b1")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	group.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Internal-Node", "n1")
+	})
+	router.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Internal-Node", "n1")
+	})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Tenant", "evil")
+		req.Header.Set("X-Via", "client")
+		req.Header.Set("X-Debug", "1")
+		req.Header.Set("X-Old", "v")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/acme/echo")
+	assert.Equal(http.StatusAccepted, w.Code)
+	assert.Empty(w.Header().Get("X-Internal-Node"))
+	assert.Empty(w.Header().Get("X-Internal-Zone"))
+	assert.Empty(w.Header().Get("Server"))
+	assert.Empty(w.Header().Get("X-Backend-Id"))
+	assert.Equal("b1", w.Header().Get("X-Served-By"))
+	assert.Equal("no-store", w.Header().Get("Cache-Control"))
+
+	assert.Empty(request("/acme/empty").Header().Get("X-Internal-Node"))
+	assert.Equal("n1", request("/other").Header().Get("X-Internal-Node"))
+}
@@ -0,0 +1,179 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheckFunc checks a dependency, e.g. a database or a downstream service. Returns error if unhealthy.
+// E.g. (*sql.DB).PingContext is a HealthCheckFunc.
+type HealthCheckFunc func(ctx context.Context) error
+
+type healthCheck struct {
+	name     string
+	check    HealthCheckFunc
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mutex     sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+func (c *healthCheck) run(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cacheTTL > 0 && time.Since(c.checkedAt) < c.cacheTTL {
+		return c.err
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	c.err = c.check(ctx)
+	c.checkedAt = time.Now()
+	return c.err
+}
+
+type healthChecks struct {
+	mutex  sync.RWMutex
+	checks []*healthCheck
+}
+
+func (hc *healthChecks) add(name string, check HealthCheckFunc, timeout, cacheTTL time.Duration) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+	hc.checks = append(hc.checks, &healthCheck{name: name, check: check, timeout: timeout, cacheTTL: cacheTTL})
+}
+
+func (hc *healthChecks) len() int {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+	return len(hc.checks)
+}
+
+// run runs the checks in parallel. Returns the results by name, "ok" or the error string.
+func (hc *healthChecks) run(ctx context.Context) (results map[string]string, healthy bool) {
+	hc.mutex.RLock()
+	checks := hc.checks
+	hc.mutex.RUnlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	results, healthy = make(map[string]string, len(checks)), true
+	for i, c := range checks {
+		results[c.name] = "ok"
+		if errs[i] != nil {
+			results[c.name], healthy = errs[i].Error(), false
+		}
+	}
+	return
+}
+
+var (
+	livenessChecks  healthChecks
+	readinessChecks healthChecks
+	notReady        atomic.Bool
+)
+
+// AddLivenessCheck registers a named check run on LivenessProbePath and HealthCheckPath requests, served by Logger.
+// Keep liveness checks cheap and local, e.g. deadlock detection. A failing liveness probe makes the container restarted.
+//
+// The check is canceled after timeout, if positive.
+// The result is cached for cacheTTL, if positive, so that frequent probes do not overload the dependency.
+func AddLivenessCheck(name string, check HealthCheckFunc, timeout, cacheTTL time.Duration) {
+	livenessChecks.add(name, check, timeout, cacheTTL)
+}
+
+// AddReadinessCheck registers a named check run on ReadinessProbePath requests, served by Logger, if there are readiness checks.
+// E.g. a database ping, downstream reachability or disk space.
+//
+//	restful.AddReadinessCheck("db", db.PingContext, time.Second, 5*time.Second)
+//	restful.AddReadinessCheck("users", restful.NewClient().PingCheck("http://users/healthz"), time.Second, 5*time.Second)
+//	restful.AddReadinessCheck("disk", restful.DiskSpaceCheck("/data", 100<<20), time.Second, time.Minute)
+//
+// The check is canceled after timeout, if positive.
+// The result is cached for cacheTTL, if positive, so that frequent probes do not overload the dependency.
+func AddReadinessCheck(name string, check HealthCheckFunc, timeout, cacheTTL time.Duration) {
+	readinessChecks.add(name, check, timeout, cacheTTL)
+}
+
+// ErrNotReady is reported by readiness probes when the application is set not ready, e.g. on graceful shutdown.
+var ErrNotReady = errors.New("not ready")
+
+// SetReady sets whether the application is ready to serve requests. By default it is.
+// Readiness probes fail if not ready, regardless of the readiness checks.
+// Graceful Server sets not ready automatically on shutdown signal, so that no new requests are routed to it.
+func SetReady(ready bool) {
+	notReady.Store(!ready)
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func serveHealth(w http.ResponseWriter, r *http.Request, checks *healthChecks, readiness bool) {
+	w.Header().Set("Cache-Control", "no-cache")
+	resp := healthResponse{Status: "ok"}
+	var healthy bool
+	resp.Checks, healthy = checks.run(r.Context())
+	if readiness && notReady.Load() {
+		resp.Checks["ready"], healthy = ErrNotReady.Error(), false
+	}
+
+	if len(resp.Checks) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	statusCode := http.StatusOK
+	if !healthy {
+		resp.Status, statusCode = "fail", http.StatusServiceUnavailable
+	}
+	w.Header().Set(ContentTypeHeader, ContentTypeApplicationJSON)
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// LivenessHandler returns a handler running the liveness checks. Responds 200 OK if all pass, 503 otherwise.
+// Logger serves that at LivenessProbePath and HealthCheckPath automatically.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveHealth(w, r, &livenessChecks, false)
+	})
+}
+
+// ReadinessHandler returns a handler running the readiness checks. Responds 200 OK if all pass and the application is ready, 503 otherwise.
+// Logger serves that at ReadinessProbePath automatically, if there are readiness checks or the application is not ready.
+func ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveHealth(w, r, &readinessChecks, true)
+	})
+}
+
+// PingCheck returns a health check sending a GET request to the URL, expecting a 2xx response.
+func (c *Client) PingCheck(url string) HealthCheckFunc {
+	return func(ctx context.Context) error {
+		return c.Get(ctx, url, nil)
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build linux || darwin || freebsd
+
+package restful
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// DiskSpaceCheck returns a health check verifying that the file system of path has at least minFree bytes available.
+func DiskSpaceCheck(path string, minFree uint64) HealthCheckFunc {
+	return func(ctx context.Context) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return err
+		}
+		if free := uint64(stat.Bavail) * uint64(stat.Bsize); free < minFree {
+			return fmt.Errorf("%d bytes available at %s, less than %d", free, path, minFree)
+		}
+		return nil
+	}
+}
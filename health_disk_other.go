@@ -0,0 +1,20 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !(linux || darwin || freebsd)
+
+package restful
+
+import (
+	"context"
+	"errors"
+)
+
+// DiskSpaceCheck returns a health check verifying that the file system of path has at least minFree bytes available.
+// Not supported on this platform, the check always fails.
+func DiskSpaceCheck(path string, minFree uint64) HealthCheckFunc {
+	return func(ctx context.Context) error {
+		return errors.New("disk space check not supported")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetHealthChecks() {
+	livenessChecks = healthChecks{}
+	readinessChecks = healthChecks{}
+	SetReady(true)
+}
+
+func TestHealthDefault(t *testing.T) {
+	assert := assert.New(t)
+	resetHealthChecks()
+	h := Logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+
+	for path, statusCode := range map[string]int{LivenessProbePath: 200, HealthCheckPath: 200, ReadinessProbePath: http.StatusTeapot} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(statusCode, w.Code, path)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	assert := assert.New(t)
+	defer resetHealthChecks()
+	h := Logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+
+	calls := 0
+	AddLivenessCheck("loop", func(ctx context.Context) error { return nil }, 0, 0)
+	AddReadinessCheck("db", func(ctx context.Context) error { calls++; return nil }, time.Second, time.Hour)
+	AddReadinessCheck("slow", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, 10*time.Millisecond, 0)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivenessProbePath, nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.JSONEq(`{"status":"ok","checks":{"loop":"ok"}}`, w.Body.String())
+
+	for range 2 {
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessProbePath, nil))
+		assert.Equal(http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(`{"status":"fail","checks":{"db":"ok","slow":"context deadline exceeded"}}`, w.Body.String())
+	}
+	assert.Equal(1, calls) // Cached.
+}
+
+func TestHealthNotReady(t *testing.T) {
+	assert := assert.New(t)
+	defer resetHealthChecks()
+	h := Logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+
+	SetReady(false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessProbePath, nil))
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(`{"status":"fail","checks":{"ready":"not ready"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivenessProbePath, nil))
+	assert.Equal(http.StatusOK, w.Code)
+}
+
+func TestHealthCheckHelpers(t *testing.T) {
+	assert := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	assert.NoError(client.PingCheck(srv.URL + "/up")(context.Background()))
+	assert.Error(client.PingCheck(srv.URL + "/down")(context.Background()))
+
+	assert.NoError(DiskSpaceCheck(t.TempDir(), 1)(context.Background()))
+	err := DiskSpaceCheck(t.TempDir(), 1<<62)(context.Background())
+	assert.Error(err)
+	assert.False(errors.Is(err, context.DeadlineExceeded))
+}
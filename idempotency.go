@@ -0,0 +1,274 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Headers of idempotent request handling.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// ErrIdempotencyInFlight is returned by IdempotencyStore if a request of the same idempotency key is being served.
+var ErrIdempotencyInFlight = errors.New("request of the same idempotency key is in progress")
+
+// IdempotentResponse is the response stored for an idempotency key.
+type IdempotentResponse struct {
+	// Fingerprint of the request, so that reusing the key for another request is detected.
+	Fingerprint string      `json:"fingerprint"`
+	StatusCode  int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// IdempotencyStore stores responses by idempotency keys.
+// Implement it by a shared store, e.g. Redis, if the requests are served by several instances.
+type IdempotencyStore interface {
+	// Begin reserves the key for a request being served, for ttl at most. Returns the stored response of the key, if any.
+	// Returns ErrIdempotencyInFlight if the key is reserved by another request.
+	Begin(key string, ttl time.Duration) (*IdempotentResponse, error)
+	// Complete stores the response of the key for ttl, replacing the reservation.
+	Complete(key string, resp *IdempotentResponse, ttl time.Duration) error
+	// Release removes the reservation of the key, so that the request can be retried.
+	Release(key string) error
+}
+
+type idempotencyEntry struct {
+	resp   *IdempotentResponse // Nil while in flight.
+	expiry time.Time
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore.
+type MemoryIdempotencyStore struct {
+	mutex   sync.Mutex
+	entries map[string]idempotencyEntry
+	purged  time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry), purged: time.Now()}
+}
+
+// Begin reserves the key, or returns its stored response.
+func (s *MemoryIdempotencyStore) Begin(key string, ttl time.Duration) (*IdempotentResponse, error) {
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.purged) >= time.Minute {
+		for k, e := range s.entries {
+			if !now.Before(e.expiry) {
+				delete(s.entries, k)
+			}
+		}
+		s.purged = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiry) {
+		if e.resp == nil {
+			return nil, ErrIdempotencyInFlight
+		}
+		return e.resp, nil
+	}
+	s.entries[key] = idempotencyEntry{expiry: now.Add(ttl)}
+	return nil, nil
+}
+
+// Complete stores the response of the key.
+func (s *MemoryIdempotencyStore) Complete(key string, resp *IdempotentResponse, ttl time.Duration) error {
+	s.mutex.Lock()
+	s.entries[key] = idempotencyEntry{resp: resp, expiry: time.Now().Add(ttl)}
+	s.mutex.Unlock()
+	return nil
+}
+
+// Release removes the key.
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mutex.Lock()
+	delete(s.entries, key)
+	s.mutex.Unlock()
+	return nil
+}
+
+// Idempotency makes requests with Idempotency-Key header idempotent, e.g. POST requests retried by clients.
+// The first response of a key is stored, and replayed for requests of the same key, with Idempotent-Replayed header.
+// Requests of a key being served are responded by 409 Conflict, and reusing a key for another request by 422.
+// Server errors, i.e. 5xx and 429 responses, are not stored, so that the request can be retried.
+// Keys are scoped by tenant, Authorization header, method and path, so that clients cannot replay responses of others.
+//
+//	router.Idempotency(restful.NewIdempotency(nil).TTL(time.Hour))
+type Idempotency struct {
+	store    IdempotencyStore
+	ttl      time.Duration
+	methods  []string
+	required bool
+}
+
+// NewIdempotency creates idempotent request handling of POST requests, storing responses in store for 24 hours.
+// If store is nil, an in-memory store is used.
+func NewIdempotency(store IdempotencyStore) *Idempotency {
+	if store == nil {
+		store = NewMemoryIdempotencyStore()
+	}
+	return &Idempotency{store: store, ttl: 24 * time.Hour, methods: []string{http.MethodPost}}
+}
+
+// TTL sets how long responses are stored, and requests being served may reserve their key.
+func (i *Idempotency) TTL(ttl time.Duration) *Idempotency {
+	i.ttl = ttl
+	return i
+}
+
+// Methods sets the methods handled, e.g. POST and PATCH. Default is POST.
+func (i *Idempotency) Methods(methods ...string) *Idempotency {
+	i.methods = methods
+	return i
+}
+
+// Required makes requests of the methods handled without Idempotency-Key header responded by 400 Bad Request.
+func (i *Idempotency) Required() *Idempotency {
+	i.required = true
+	return i
+}
+
+// idempotencyWriter copies the response. Flush, Hijack and Unwrap are passed through by the embedded writer.
+type idempotencyWriter struct {
+	*responseWriter
+	header http.Header // As sent.
+	body   bytes.Buffer
+}
+
+func (w *idempotencyWriter) WriteHeader(statusCode int) {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+	w.responseWriter.WriteHeader(statusCode)
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+	n, err := w.responseWriter.Write(b)
+	w.body.Write(b[:n])
+	return n, err
+}
+
+// ReadFrom copies via Write, so that the body is stored.
+func (w *idempotencyWriter) ReadFrom(src io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{w}, src)
+}
+
+func (w *idempotencyWriter) response(fingerprint string) *IdempotentResponse {
+	header := w.header
+	if header == nil {
+		header = w.Header().Clone()
+	}
+	return &IdempotentResponse{Fingerprint: fingerprint, StatusCode: w.statusCode(), Header: header, Body: w.body.Bytes()}
+}
+
+func idempotencyHash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, resp *IdempotentResponse) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(resp.Body)
+}
+
+// Handler wraps the handler, making requests of the methods set idempotent.
+func (i *Idempotency) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if !slices.Contains(i.methods, r.Method) || (key == "" && !i.required) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if key == "" {
+			_ = SendResp(w, r, NewError(nil, http.StatusBadRequest, IdempotencyKeyHeader+" header required"), nil)
+			return
+		}
+
+		body, err := GetDataBytes(r.Header, r.Body, LambdaMaxBytesToParse)
+		if err != nil {
+			_ = SendResp(w, r, NewError(err, http.StatusBadRequest, "Failed to read request"), nil)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := idempotencyHash([]byte(r.Method), []byte(r.URL.RequestURI()), body)
+		key = idempotencyHash([]byte(TenantFromContext(r.Context())), []byte(r.Header.Get("Authorization")), []byte(r.Method), []byte(r.URL.Path), []byte(key))
+
+		resp, err := i.store.Begin(key, i.ttl)
+		if err != nil {
+			i.sendBeginError(w, r, err)
+			return
+		}
+		if resp != nil {
+			if resp.Fingerprint != fingerprint {
+				_ = SendResp(w, r, NewError(nil, http.StatusUnprocessableEntity, IdempotencyKeyHeader+" reused for another request"), nil)
+				return
+			}
+			replayIdempotentResponse(w, resp)
+			return
+		}
+
+		i.serve(h, &idempotencyWriter{responseWriter: &responseWriter{ResponseWriter: w}}, r, key, fingerprint)
+	})
+}
+
+func (i *Idempotency) sendBeginError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrIdempotencyInFlight) {
+		w.Header().Set("Retry-After", "1")
+		_ = SendResp(w, r, NewError(err, http.StatusConflict), nil)
+		return
+	}
+	_ = SendResp(w, r, NewError(err, http.StatusServiceUnavailable, "Idempotency store error"), nil)
+}
+
+// serve serves the request, storing its response, or releasing the key on server error or panic.
+func (i *Idempotency) serve(h http.Handler, w *idempotencyWriter, r *http.Request, key, fingerprint string) {
+	completed := false
+	defer func() {
+		if !completed {
+			_ = i.store.Release(key)
+		}
+	}()
+	h.ServeHTTP(w, r)
+
+	if status := w.statusCode(); status >= 500 || status == http.StatusTooManyRequests {
+		return
+	}
+	if err := i.store.Complete(key, w.response(fingerprint), i.ttl); err == nil {
+		completed = true
+	}
+}
+
+// Idempotency makes requests served by the handlers added after calling this function idempotent, as Monitor does.
+// Subrouters inherit that, so it can be set per route group. See Idempotency.
+func (r *Router) Idempotency(i *Idempotency) *Router {
+	r.monitors.appendWrapper(i.Handler)
+	return r
+}
@@ -0,0 +1,161 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type idempotencyUser struct {
+	Name string `json:"name"`
+}
+
+func idempotentPost(r http.Handler, key, auth, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	assert := assert.New(t)
+	var created atomic.Int32
+	r := NewRouter()
+	r.Idempotency(NewIdempotency(nil))
+	r.HandleFunc("/users", func(ctx context.Context, user *idempotencyUser) (*idempotencyUser, error) {
+		created.Add(1)
+		L(ctx).ResponseStatus(http.StatusCreated)
+		L(ctx).ResponseHeaderSet("Location", "/users/"+user.Name)
+		return user, nil
+	}).Methods(http.MethodPost)
+	r.HandleFunc("/users", func() []idempotencyUser { return nil }).Methods(http.MethodGet)
+
+	w := idempotentPost(r, "k1", "", `{"name":"joe"}`)
+	assert.Equal(http.StatusCreated, w.Code)
+	assert.Empty(w.Header().Get(IdempotentReplayedHeader))
+
+	w = idempotentPost(r, "k1", "", `{"name":"joe"}`)
+	assert.Equal(http.StatusCreated, w.Code)
+	assert.Equal("true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal("/users/joe", w.Header().Get("Location"))
+	assert.Equal(ContentTypeApplicationJSON, w.Header().Get(ContentTypeHeader))
+	assert.JSONEq(`{"name":"joe"}`, w.Body.String())
+	assert.Equal(int32(1), created.Load())
+
+	w = idempotentPost(r, "k1", "", `{"name":"jane"}`)
+	assert.Equal(http.StatusUnprocessableEntity, w.Code)
+
+	w = idempotentPost(r, "k1", "Bearer other", `{"name":"joe"}`) // Other client.
+	assert.Equal(http.StatusCreated, w.Code)
+	assert.Empty(w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(int32(2), created.Load())
+
+	w = idempotentPost(r, "", "", `{"name":"joe"}`)
+	assert.Equal(http.StatusCreated, w.Code)
+	assert.Equal(int32(3), created.Load())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(http.StatusOK, w.Code)
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	assert := assert.New(t)
+	var calls atomic.Int32
+	r := NewRouter()
+	r.Idempotency(NewIdempotency(nil).Required())
+	r.HandleFunc("/users", func(user idempotencyUser) error {
+		if calls.Add(1) == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	w := idempotentPost(r, "k", "", `{"name":"joe"}`)
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	w = idempotentPost(r, "k", "", `{"name":"joe"}`) // Not stored, retried.
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Empty(w.Header().Get(IdempotentReplayedHeader))
+	w = idempotentPost(r, "k", "", `{"name":"joe"}`)
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal("true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(int32(2), calls.Load())
+
+	w = idempotentPost(r, "", "", `{"name":"joe"}`)
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	assert := assert.New(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := NewIdempotency(nil).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentPost(h, "k", "", `{}`) }()
+	<-started
+	w := idempotentPost(h, "k", "", `{}`)
+	assert.Equal(http.StatusConflict, w.Code)
+	assert.Equal("1", w.Header().Get("Retry-After"))
+	close(release)
+	assert.Equal(http.StatusAccepted, (<-done).Code)
+
+	w = idempotentPost(h, "k", "", `{}`)
+	assert.Equal(http.StatusAccepted, w.Code)
+	assert.Equal("true", w.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotencyPanic(t *testing.T) {
+	assert := assert.New(t)
+	h := NewIdempotency(nil).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	assert.Panics(func() { idempotentPost(h, "k", "", `{}`) })
+	assert.Panics(func() { idempotentPost(h, "k", "", `{}`) }) // Released, not 409.
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	assert := assert.New(t)
+	s := NewMemoryIdempotencyStore()
+
+	resp, err := s.Begin("a", 10*time.Millisecond)
+	assert.NoError(err)
+	assert.Nil(resp)
+	_, err = s.Begin("a", time.Minute)
+	assert.Equal(ErrIdempotencyInFlight, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = s.Begin("a", time.Minute) // Reservation expired.
+	assert.NoError(err)
+
+	assert.NoError(s.Complete("a", &IdempotentResponse{StatusCode: http.StatusCreated}, time.Minute))
+	resp, err = s.Begin("a", time.Minute)
+	assert.NoError(err)
+	assert.Equal(http.StatusCreated, resp.StatusCode)
+
+	assert.NoError(s.Release("a"))
+	resp, err = s.Begin("a", time.Minute)
+	assert.NoError(err)
+	assert.Nil(resp)
+}
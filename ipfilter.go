@@ -0,0 +1,60 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/nokia/restful/logging"
+)
+
+// IPFilter allows or denies requests by the client IP, see ClientIP.
+// Denied requests are responded 403.
+//
+//	filter, err := restful.NewIPFilter([]string{"10.0.0.0/8", "192.168.0.0/16"}, []string{"10.6.6.0/24"})
+//	admin := router.PathPrefix("/admin").Subrouter().Monitor(filter.Pre, nil)
+type IPFilter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewIPFilter creates an IP filter of CIDRs, such as "10.0.0.0/8", or single addresses.
+// Clients in the deny list are denied. If the allow list is not empty, then only clients in the allow list are allowed.
+func NewIPFilter(allow, deny []string) (*IPFilter, error) {
+	allowPrefixes, err := parsePrefixes(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyPrefixes, err := parsePrefixes(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &IPFilter{allow: allowPrefixes, deny: denyPrefixes}, nil
+}
+
+// Allowed tells if the address is allowed. Invalid address is allowed only if there is no allow list.
+func (f *IPFilter) Allowed(addr netip.Addr) bool {
+	if prefixesContain(f.deny, addr) {
+		return false
+	}
+	return len(f.allow) == 0 || prefixesContain(f.allow, addr)
+}
+
+// Pre is a Monitor pre function, filtering the request. May be used for route groups, by Router's Monitor.
+func (f *IPFilter) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	if addr := ClientIP(r); !f.Allowed(addr) {
+		logging.Debugf(r.Context(), "client %v denied", addr)
+		pd := ProblemDetails{Title: http.StatusText(http.StatusForbidden), Status: http.StatusForbidden, Detail: "client address not allowed"}
+		_ = SendProblemResponse(w, r, http.StatusForbidden, pd.String())
+		return nil
+	}
+	return r
+}
+
+// Handler wraps the handler, filtering all requests.
+func (f *IPFilter) Handler(h http.Handler) http.Handler {
+	return Monitor(h, f.Pre, nil)
+}
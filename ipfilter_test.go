@@ -0,0 +1,51 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilter(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewIPFilter([]string{"10.0.0.0/x"}, nil)
+	assert.Error(err)
+
+	filter, err := NewIPFilter([]string{"10.0.0.0/8", "2001:db8::/32"}, []string{"10.6.6.0/24"})
+	assert.NoError(err)
+	assert.True(filter.Allowed(netip.MustParseAddr("10.1.2.3")))
+	assert.True(filter.Allowed(netip.MustParseAddr("2001:db8::1")))
+	assert.False(filter.Allowed(netip.MustParseAddr("10.6.6.6")))
+	assert.False(filter.Allowed(netip.MustParseAddr("1.2.3.4")))
+	assert.False(filter.Allowed(netip.Addr{}))
+
+	denyOnly, _ := NewIPFilter(nil, []string{"1.2.3.4"})
+	assert.False(denyOnly.Allowed(netip.MustParseAddr("1.2.3.4")))
+	assert.True(denyOnly.Allowed(netip.MustParseAddr("1.2.3.5")))
+
+	router := NewRouter()
+	router.HandleFunc("/", func() {})
+	admin := router.PathPrefix("/admin").Subrouter().Monitor(filter.Pre, nil)
+	admin.HandleFunc("/status", func() {})
+
+	request := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(http.StatusNoContent, request("/", "1.2.3.4:1234").Code)
+	assert.Equal(http.StatusNoContent, request("/admin/status", "10.1.2.3:1234").Code)
+	w := request("/admin/status", "1.2.3.4:1234")
+	assert.Equal(http.StatusForbidden, w.Code)
+	assert.Contains(w.Body.String(), "not allowed")
+	assert.Equal(http.StatusForbidden, request("/admin/status", "[::ffff:10.6.6.1]:1234").Code)
+}
@@ -0,0 +1,269 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/nokia/restful/logging"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+	JSONRPCServerError    = -32000
+)
+
+// JSONRPCError is the error object of a JSON-RPC 2.0 response.
+// Methods may return it to control the error code sent.
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error returns the message of the error.
+func (e *JSONRPCError) Error() string {
+	return e.Message
+}
+
+// NewJSONRPCError converts an error returned by a method to a JSON-RPC error.
+// Status 400 and LambdaValidationErrorStatus of restful errors are told as invalid params, others as server error.
+// The status code is told by data "status", unless it is 500.
+func NewJSONRPCError(err error) *JSONRPCError {
+	var e *JSONRPCError
+	if errors.As(err, &e) {
+		return e
+	}
+
+	status := GetErrStatusCode(err)
+	e = &JSONRPCError{Code: JSONRPCServerError, Message: err.Error()}
+	if status == http.StatusBadRequest || status == LambdaValidationErrorStatus {
+		e.Code = JSONRPCInvalidParams
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	if status != http.StatusInternalServerError {
+		e.Data = map[string]any{"status": status}
+	}
+	return e
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func newJSONRPCResponse(id json.RawMessage, result any, err error) *jsonRPCResponse {
+	resp := &jsonRPCResponse{JSONRPC: "2.0", ID: id}
+	if err == nil {
+		if resp.Result, err = json.Marshal(result); err != nil {
+			err = &JSONRPCError{Code: JSONRPCInternalError, Message: err.Error()}
+		}
+	}
+	if err != nil {
+		resp.Result = nil
+		resp.Error = NewJSONRPCError(err)
+	}
+	return resp
+}
+
+// JSONRPC is an http.Handler of JSON-RPC 2.0 requests sent by POST, calling the Lambda-style function registered by the method name.
+// Batches are served in order. Notifications are not responded, if all the requests are notifications then 204 No Content is sent.
+// Errors, including the ones of parsing, are in the JSON-RPC response of 200 OK.
+//
+//	router.Handle("/rpc", restful.NewJSONRPC().Method("user.get", getUser)).Methods(http.MethodPost)
+type JSONRPC struct {
+	methods map[string]any
+}
+
+// NewJSONRPC creates a JSON-RPC handler with no methods.
+func NewJSONRPC() *JSONRPC {
+	return &JSONRPC{methods: map[string]any{}}
+}
+
+// Method registers a Lambda-style function as method name.
+// The function may have a context and a params parameter, and may return a result and an error, similarly to Lambda functions.
+// Params are validated as Lambda request bodies are. Panics if f is not such a function.
+func (j *JSONRPC) Method(name string, f any) *JSONRPC {
+	t := reflect.TypeOf(f)
+	if t == nil || t.Kind() != reflect.Func {
+		panic("function expected")
+	}
+	if _, _, err := lambdaTypes(t); err != nil {
+		panic(err)
+	}
+	if t.NumOut() > 2 {
+		panic(fmt.Sprintf("too many results: %s", t))
+	}
+	j.methods[name] = f
+	return j
+}
+
+// ServeHTTP serves a JSON-RPC request or batch.
+func (j *JSONRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		_ = SendResp(w, r, NewError(nil, http.StatusMethodNotAllowed), nil)
+		return
+	}
+	ctx := NewRequestCtx(w, r)
+	r = r.WithContext(ctx)
+	body, err := GetDataBytes(r.Header, r.Body, LambdaMaxBytesToParse)
+	if err != nil {
+		_ = SendResp(w, r, NewError(err, http.StatusBadRequest, "Failed to read request"), nil)
+		return
+	}
+
+	resp := j.serve(ctx, bytes.TrimSpace(body))
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	_ = SendResp(w, r, nil, resp)
+}
+
+func (j *JSONRPC) serve(ctx context.Context, body []byte) any {
+	if !json.Valid(body) {
+		return newJSONRPCResponse(nil, nil, &JSONRPCError{Code: JSONRPCParseError, Message: "Parse error"})
+	}
+	if body[0] != '[' {
+		if resp := j.call(ctx, body); resp != nil {
+			return resp
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	_ = json.Unmarshal(body, &batch)
+	if len(batch) == 0 {
+		return newJSONRPCResponse(nil, nil, &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "Empty batch"})
+	}
+	var resps []*jsonRPCResponse
+	for _, req := range batch {
+		if resp := j.call(ctx, req); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+	return resps
+}
+
+// call calls the method of the request. Returns nil for notifications.
+func (j *JSONRPC) call(ctx context.Context, req json.RawMessage) *jsonRPCResponse {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(req, &fields); err != nil {
+		return newJSONRPCResponse(nil, nil, &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "Request object expected"})
+	}
+	id, hasID := fields["id"]
+	if hasID && !validJSONRPCID(id) {
+		return newJSONRPCResponse(nil, nil, &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "Invalid id"})
+	}
+
+	var version, method string
+	if json.Unmarshal(fields["jsonrpc"], &version) != nil || version != "2.0" ||
+		json.Unmarshal(fields["method"], &method) != nil || method == "" {
+		return newJSONRPCResponse(id, nil, &JSONRPCError{Code: JSONRPCInvalidRequest, Message: "Invalid request"})
+	}
+
+	result, err := j.invoke(ctx, method, fields["params"])
+	if !hasID {
+		return nil
+	}
+	return newJSONRPCResponse(id, result, err)
+}
+
+// validJSONRPCID tells if id is a string, number or null.
+func validJSONRPCID(id json.RawMessage) bool {
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+func (j *JSONRPC) invoke(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	f, ok := j.methods[method]
+	if !ok {
+		return nil, &JSONRPCError{Code: JSONRPCMethodNotFound, Message: "Method not found: " + method}
+	}
+
+	t := reflect.TypeOf(f)
+	args := make([]reflect.Value, t.NumIn())
+	paramsIdx := 0
+	if t.NumIn() > 0 && t.In(0).Implements(contextType) {
+		args[0] = reflect.ValueOf(ctx)
+		paramsIdx = 1
+	}
+	if paramsIdx < t.NumIn() {
+		arg, err := getJSONRPCParams(t.In(paramsIdx), params)
+		if err != nil {
+			return nil, err
+		}
+		args[paramsIdx] = arg
+	}
+
+	return callJSONRPCMethod(ctx, method, f, args)
+}
+
+// callJSONRPCMethod calls the method. A panic is responded as Internal error of that request only, so that others of a batch are served.
+func callJSONRPCMethod(ctx context.Context, method string, f any, args []reflect.Value) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			logging.Errorf(ctx, "JSON-RPC method %s panicked: %v\n%s", method, p, debug.Stack())
+			result, err = nil, &JSONRPCError{Code: JSONRPCInternalError, Message: "Internal error"}
+		}
+	}()
+
+	res := reflect.ValueOf(f).Call(args)
+	switch len(res) {
+	case 0:
+		return nil, nil
+	case 1:
+		return lambdaHandleRes1(nil, res[0])
+	default:
+		return lambdaHandleRes2(nil, res)
+	}
+}
+
+// getJSONRPCParams parses and validates params, by-name or by-position, to a value of type t.
+func getJSONRPCParams(t reflect.Type, params json.RawMessage) (reflect.Value, error) {
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, v.Interface()); err != nil {
+			return v, &JSONRPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+		}
+	}
+	if err := validateRequestData(v.Interface()); err != nil {
+		return v, err
+	}
+	if !isPtr {
+		v = v.Elem()
+	}
+	return v, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonRPCUser struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestJSONRPC(t *testing.T) {
+	assert := assert.New(t)
+
+	var notified []string
+	r := NewRouter()
+	r.Handle("/rpc", NewJSONRPC().
+		Method("hello", func(ctx context.Context, user *jsonRPCUser) (string, error) {
+			return "Hello " + user.Name + L(ctx).RequestHeaderGet("X-Suffix"), nil
+		}).
+		Method("sum", func(numbers []int) int { return numbers[0] + numbers[1] }).
+		Method("notify", func(user jsonRPCUser) { notified = append(notified, user.Name) }))
+
+	{ // Params by name, with request context
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"hello","params":{"name":"Joe"},"id":1}`))
+		req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		req.Header.Set("X-Suffix", "!")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.Equal(ContentTypeApplicationJSON, rr.Header().Get(ContentTypeHeader))
+		assert.JSONEq(`{"jsonrpc":"2.0","result":"Hello Joe!","id":1}`, rr.Body.String())
+	}
+	{ // Params by position, string id
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"sum","params":[1,2],"id":"a"}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.JSONEq(`{"jsonrpc":"2.0","result":3,"id":"a"}`, rr.Body.String())
+	}
+	{ // Notification is not responded
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"notify","params":{"name":"Jane"}}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusNoContent, rr.Code)
+		assert.Empty(rr.Body.String())
+		assert.Equal([]string{"Jane"}, notified)
+	}
+	{ // Null id is not a notification
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","method":"notify","params":{"name":"Jack"},"id":null}`))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.JSONEq(`{"jsonrpc":"2.0","result":null,"id":null}`, rr.Body.String())
+	}
+	{ // POST only
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/rpc", nil))
+		assert.Equal(http.StatusMethodNotAllowed, rr.Code)
+		assert.Equal(http.MethodPost, rr.Header().Get("Allow"))
+	}
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []string
+	rpc := NewJSONRPC().
+		Method("call", func(name string) string { calls = append(calls, name); return name }).
+		Method("panic", func() { panic("boom") })
+
+	{ // Served in order, notifications not responded, a panic fails its own request only
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[
+			{"jsonrpc":"2.0","method":"call","params":"a","id":1},
+			{"jsonrpc":"2.0","method":"call","params":"b"},
+			{"jsonrpc":"2.0","method":"panic","id":2},
+			1,
+			{"jsonrpc":"2.0","method":"call","params":"c","id":3}
+		]`))
+		rr := httptest.NewRecorder()
+		rpc.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.JSONEq(`[
+			{"jsonrpc":"2.0","result":"a","id":1},
+			{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":2},
+			{"jsonrpc":"2.0","error":{"code":-32600,"message":"Request object expected"},"id":null},
+			{"jsonrpc":"2.0","result":"c","id":3}
+		]`, rr.Body.String())
+		assert.Equal([]string{"a", "b", "c"}, calls)
+	}
+	{ // Notifications only
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[{"jsonrpc":"2.0","method":"call","params":"d"}]`))
+		rr := httptest.NewRecorder()
+		rpc.ServeHTTP(rr, req)
+		assert.Equal(http.StatusNoContent, rr.Code)
+	}
+	{ // Empty batch is a single error
+		req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`[]`))
+		rr := httptest.NewRecorder()
+		rpc.ServeHTTP(rr, req)
+		assert.JSONEq(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Empty batch"},"id":null}`, rr.Body.String())
+	}
+}
+
+func TestJSONRPCErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	rpc := NewJSONRPC().
+		Method("hello", func(user jsonRPCUser) string { return "Hello " + user.Name }).
+		Method("missing", func() (*jsonRPCUser, error) { return nil, NewError(nil, http.StatusNotFound, "no such user") }).
+		Method("fail", func() error { return errors.New("failed") }).
+		Method("custom", func() error { return &JSONRPCError{Code: 42, Message: "custom", Data: "details"} })
+	call := func(body string) (resp struct {
+		Error JSONRPCError    `json:"error"`
+		ID    json.RawMessage `json:"id"`
+	}) {
+		rr := httptest.NewRecorder()
+		rpc.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+		assert.Equal(http.StatusOK, rr.Code, body)
+		assert.NoError(json.Unmarshal(rr.Body.Bytes(), &resp), body)
+		return
+	}
+
+	{ // Validation error is invalid params, telling the status
+		resp := call(`{"jsonrpc":"2.0","method":"hello","params":{"name":""},"id":1}`)
+		assert.Equal(JSONRPCInvalidParams, resp.Error.Code)
+		assert.Equal(map[string]any{"status": float64(LambdaValidationErrorStatus)}, resp.Error.Data)
+	}
+	{ // Params of wrong type
+		resp := call(`{"jsonrpc":"2.0","method":"hello","params":[1],"id":1}`)
+		assert.Equal(JSONRPCInvalidParams, resp.Error.Code)
+	}
+	{ // Restful error is server error, telling the status
+		resp := call(`{"jsonrpc":"2.0","method":"missing","id":1}`)
+		assert.Equal(JSONRPCError{Code: JSONRPCServerError, Message: "no such user", Data: map[string]any{"status": float64(http.StatusNotFound)}}, resp.Error)
+	}
+	{ // Plain error is server error, without status
+		resp := call(`{"jsonrpc":"2.0","method":"fail","id":1}`)
+		assert.Equal(JSONRPCError{Code: JSONRPCServerError, Message: "failed"}, resp.Error)
+	}
+	{ // JSON-RPC error is sent as is
+		resp := call(`{"jsonrpc":"2.0","method":"custom","id":1}`)
+		assert.Equal(JSONRPCError{Code: 42, Message: "custom", Data: "details"}, resp.Error)
+	}
+	{ // Unknown method
+		resp := call(`{"jsonrpc":"2.0","method":"nope","id":1}`)
+		assert.Equal(JSONRPCError{Code: JSONRPCMethodNotFound, Message: "Method not found: nope"}, resp.Error)
+	}
+	{ // Invalid requests keep the id, if valid
+		resp := call(`{"jsonrpc":"1.0","method":"hello","id":7}`)
+		assert.Equal(JSONRPCInvalidRequest, resp.Error.Code)
+		assert.Equal("7", string(resp.ID))
+		resp = call(`{"jsonrpc":"2.0","method":"hello","id":{}}`)
+		assert.Equal(JSONRPCInvalidRequest, resp.Error.Code)
+		assert.Equal("null", string(resp.ID))
+	}
+	{ // Parse error
+		resp := call(`{"jsonrpc":"2.0","method":"hello"`)
+		assert.Equal(JSONRPCParseError, resp.Error.Code)
+		assert.Equal("null", string(resp.ID))
+	}
+}
+
+func TestJSONRPCMethodPanics(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() { NewJSONRPC().Method("x", "x") })
+	assert.Panics(func() { NewJSONRPC().Method("x", func(a, b int) {}) })
+	assert.Panics(func() { NewJSONRPC().Method("x", func() (int, int, error) { return 0, 0, nil }) })
+}
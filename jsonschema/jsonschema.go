@@ -0,0 +1,491 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package jsonschema is a lightweight JSON Schema validator.
+// It supports the commonly used subset of JSON Schema and OpenAPI schema objects:
+// type, nullable, enum, const, properties, required, additionalProperties, items, min/maxItems, uniqueItems,
+// min/maxProperties, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, min/maxLength, pattern,
+// allOf, anyOf, oneOf, not and local $ref references, such as "#/$defs/x" or "#/components/schemas/x".
+// Other keywords, e.g. format, are ignored.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrInvalidSchema is returned if the schema document cannot be used.
+var ErrInvalidSchema = errors.New("invalid JSON schema")
+
+// ValidationError describes a single violation of a schema.
+type ValidationError struct {
+	// InstancePath is a JSON pointer to the offending part of the validated document, e.g. "/users/0/name".
+	InstancePath string
+
+	// SchemaPath is a JSON pointer to the schema keyword failed, e.g. "#/properties/users/items/required".
+	SchemaPath string
+
+	// Message is a human readable description of the problem.
+	Message string
+}
+
+// Error returns error string.
+func (e ValidationError) Error() string {
+	path := e.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidationErrors is the list of violations found. Returned by Validate functions.
+type ValidationErrors []ValidationError
+
+// Error returns error string.
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i := range e {
+		s[i] = e[i].Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Schema is a compiled JSON schema.
+type Schema struct {
+	doc     any    // Root document $ref references are resolved against.
+	pointer string // Pointer of the schema within the document.
+	node    any
+
+	regexps sync.Map // pattern -> *regexp.Regexp
+}
+
+// New creates a schema from JSON document.
+func New(schema []byte) (*Schema, error) {
+	return NewFromDocument(schema, "")
+}
+
+// NewFromDocument creates a schema from a part of a JSON document, identified by JSON pointer.
+// References are resolved against the whole document. E.g. for an OpenAPI document:
+//
+//	s, err := jsonschema.NewFromDocument(spec, "/components/schemas/User")
+func NewFromDocument(document []byte, pointer string) (*Schema, error) {
+	var doc any
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, errors.Join(ErrInvalidSchema, err)
+	}
+	return NewFromValue(doc, pointer)
+}
+
+// NewFromValue creates a schema from an already decoded JSON document, identified by JSON pointer.
+// See NewFromDocument.
+func NewFromValue(document any, pointer string) (*Schema, error) {
+	node, err := resolvePointer(document, pointer)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{doc: document, pointer: pointer, node: node}, nil
+}
+
+// MustNew is like New but panics on error. Useful at initializing global variables.
+func MustNew(schema string) *Schema {
+	s, err := New([]byte(schema))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate validates a JSON document against the schema.
+// Returns ValidationErrors if the document does not match the schema.
+func (s *Schema) Validate(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return ValidationErrors{{Message: "invalid JSON: " + err.Error()}}
+	}
+	return s.ValidateValue(v)
+}
+
+// ValidateValue validates a decoded JSON value against the schema.
+// Value is expected to be made of the types json.Unmarshal produces for interface values,
+// numbers being either float64 or json.Number.
+func (s *Schema) ValidateValue(v any) error {
+	var errs ValidationErrors
+	s.validate(s.node, v, "", "#"+s.pointer, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func unescapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func resolvePointer(doc any, pointer string) (any, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: bad pointer %q", ErrInvalidSchema, pointer)
+	}
+	node := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescapePointerToken(token)
+		switch n := node.(type) {
+		case map[string]any:
+			var ok bool
+			if node, ok = n[token]; !ok {
+				return nil, fmt.Errorf("%w: %q not found", ErrInvalidSchema, pointer)
+			}
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("%w: %q not found", ErrInvalidSchema, pointer)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%w: %q not found", ErrInvalidSchema, pointer)
+		}
+	}
+	return node, nil
+}
+
+func addErr(errs *ValidationErrors, instancePath, schemaPath, format string, args ...any) {
+	*errs = append(*errs, ValidationError{InstancePath: instancePath, SchemaPath: schemaPath, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *Schema) validate(node any, v any, instancePath, schemaPath string, errs *ValidationErrors) {
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			addErr(errs, instancePath, schemaPath, "not allowed")
+		}
+		return
+	case map[string]any:
+		s.validateObjectSchema(n, v, instancePath, schemaPath, errs)
+	default:
+		addErr(errs, instancePath, schemaPath, "invalid schema node")
+	}
+}
+
+func (s *Schema) validateObjectSchema(n map[string]any, v any, instancePath, schemaPath string, errs *ValidationErrors) {
+	if ref, ok := n["$ref"].(string); ok {
+		if !s.validateRef(ref, v, instancePath, schemaPath, errs) || len(n) == 1 {
+			return
+		}
+	}
+
+	if v == nil {
+		if nullable, _ := n["nullable"].(bool); nullable {
+			return
+		}
+	}
+
+	if !validateValue(n, v, instancePath, schemaPath, errs) {
+		return
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		s.validateObject(n, val, instancePath, schemaPath, errs)
+	case []any:
+		s.validateArray(n, val, instancePath, schemaPath, errs)
+	case string:
+		s.validateString(n, val, instancePath, schemaPath, errs)
+	case float64, json.Number:
+		validateNumber(n, toFloat(val), instancePath, schemaPath, errs)
+	}
+
+	s.validateCombinators(n, v, instancePath, schemaPath, errs)
+}
+
+// validateRef validates v against the schema referenced. Returns false if the reference cannot be resolved.
+func (s *Schema) validateRef(ref string, v any, instancePath, schemaPath string, errs *ValidationErrors) bool {
+	if !strings.HasPrefix(ref, "#") {
+		addErr(errs, instancePath, schemaPath+"/$ref", "unsupported reference %q", ref)
+		return false
+	}
+	target, err := resolvePointer(s.doc, ref[1:])
+	if err != nil {
+		addErr(errs, instancePath, schemaPath+"/$ref", "%v", err)
+		return false
+	}
+	s.validate(target, v, instancePath, ref, errs)
+	return true
+}
+
+// validateValue validates type, enum and const keywords. Returns false if the type does not match, so no other keywords apply.
+func validateValue(n map[string]any, v any, instancePath, schemaPath string, errs *ValidationErrors) bool {
+	if t, ok := n["type"]; ok && !typeMatches(t, v) {
+		addErr(errs, instancePath, schemaPath+"/type", "expected %v, got %s", t, typeName(v))
+		return false
+	}
+	if enum, ok := n["enum"].([]any); ok && !containsValue(enum, v) {
+		addErr(errs, instancePath, schemaPath+"/enum", "value not in enum")
+	}
+	if c, ok := n["const"]; ok && !equalValues(c, v) {
+		addErr(errs, instancePath, schemaPath+"/const", "value does not match const")
+	}
+	return true
+}
+
+func (s *Schema) validateCombinators(n map[string]any, v any, instancePath, schemaPath string, errs *ValidationErrors) {
+	if allOf, ok := n["allOf"].([]any); ok {
+		for i := range allOf {
+			s.validate(allOf[i], v, instancePath, schemaPath+"/allOf/"+strconv.Itoa(i), errs)
+		}
+	}
+	if anyOf, ok := n["anyOf"].([]any); ok {
+		if s.countMatching(anyOf, v, instancePath, schemaPath) == 0 {
+			addErr(errs, instancePath, schemaPath+"/anyOf", "does not match any of the schemas")
+		}
+	}
+	if oneOf, ok := n["oneOf"].([]any); ok {
+		if count := s.countMatching(oneOf, v, instancePath, schemaPath); count != 1 {
+			addErr(errs, instancePath, schemaPath+"/oneOf", "matches %d schemas instead of exactly one", count)
+		}
+	}
+	if not, ok := n["not"]; ok {
+		var notErrs ValidationErrors
+		s.validate(not, v, instancePath, schemaPath+"/not", &notErrs)
+		if len(notErrs) == 0 {
+			addErr(errs, instancePath, schemaPath+"/not", "must not match schema")
+		}
+	}
+}
+
+func (s *Schema) countMatching(schemas []any, v any, instancePath, schemaPath string) (count int) {
+	for i := range schemas {
+		var subErrs ValidationErrors
+		s.validate(schemas[i], v, instancePath, schemaPath, &subErrs)
+		if len(subErrs) == 0 {
+			count++
+		}
+	}
+	return
+}
+
+func (s *Schema) validateObject(n map[string]any, v map[string]any, instancePath, schemaPath string, errs *ValidationErrors) {
+	if required, ok := n["required"].([]any); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, found := v[name]; !found {
+					addErr(errs, instancePath, schemaPath+"/required", "missing property %q", name)
+				}
+			}
+		}
+	}
+	if min, ok := toInt(n["minProperties"]); ok && len(v) < min {
+		addErr(errs, instancePath, schemaPath+"/minProperties", "too few properties: %d < %d", len(v), min)
+	}
+	if max, ok := toInt(n["maxProperties"]); ok && len(v) > max {
+		addErr(errs, instancePath, schemaPath+"/maxProperties", "too many properties: %d > %d", len(v), max)
+	}
+
+	properties, _ := n["properties"].(map[string]any)
+	additional, hasAdditional := n["additionalProperties"]
+	for name, value := range v {
+		propPath := instancePath + "/" + escapePointerToken(name)
+		if prop, ok := properties[name]; ok {
+			s.validate(prop, value, propPath, schemaPath+"/properties/"+escapePointerToken(name), errs)
+		} else if hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				addErr(errs, propPath, schemaPath+"/additionalProperties", "unknown property %q", name)
+			} else if !ok {
+				s.validate(additional, value, propPath, schemaPath+"/additionalProperties", errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateArray(n map[string]any, v []any, instancePath, schemaPath string, errs *ValidationErrors) {
+	if min, ok := toInt(n["minItems"]); ok && len(v) < min {
+		addErr(errs, instancePath, schemaPath+"/minItems", "too few items: %d < %d", len(v), min)
+	}
+	if max, ok := toInt(n["maxItems"]); ok && len(v) > max {
+		addErr(errs, instancePath, schemaPath+"/maxItems", "too many items: %d > %d", len(v), max)
+	}
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := 1; i < len(v); i++ {
+			if containsValue(v[:i], v[i]) {
+				addErr(errs, instancePath, schemaPath+"/uniqueItems", "duplicate items")
+				break
+			}
+		}
+	}
+	if items, ok := n["items"]; ok {
+		for i := range v {
+			s.validate(items, v[i], instancePath+"/"+strconv.Itoa(i), schemaPath+"/items", errs)
+		}
+	}
+}
+
+func (s *Schema) validateString(n map[string]any, v string, instancePath, schemaPath string, errs *ValidationErrors) {
+	length := utf8.RuneCountInString(v)
+	if min, ok := toInt(n["minLength"]); ok && length < min {
+		addErr(errs, instancePath, schemaPath+"/minLength", "too short: %d < %d", length, min)
+	}
+	if max, ok := toInt(n["maxLength"]); ok && length > max {
+		addErr(errs, instancePath, schemaPath+"/maxLength", "too long: %d > %d", length, max)
+	}
+	if pattern, ok := n["pattern"].(string); ok {
+		re, err := s.regexp(pattern)
+		if err != nil {
+			addErr(errs, instancePath, schemaPath+"/pattern", "bad pattern: %v", err)
+		} else if !re.MatchString(v) {
+			addErr(errs, instancePath, schemaPath+"/pattern", "does not match pattern %q", pattern)
+		}
+	}
+}
+
+func (s *Schema) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := s.regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.regexps.Store(pattern, re)
+	return re, nil
+}
+
+func validateNumber(n map[string]any, v float64, instancePath, schemaPath string, errs *ValidationErrors) {
+	validateRange(n, v, instancePath, schemaPath, errs)
+	if m, ok := toFloatOK(n["multipleOf"]); ok && m > 0 {
+		if q := v / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			addErr(errs, instancePath, schemaPath+"/multipleOf", "must be multiple of %v", m)
+		}
+	}
+}
+
+// validateRange validates minimum and maximum keywords, exclusive ones of both OpenAPI 3.0 and JSON Schema style.
+func validateRange(n map[string]any, v float64, instancePath, schemaPath string, errs *ValidationErrors) {
+	if min, ok := toFloatOK(n["minimum"]); ok {
+		if exclusive, _ := n["exclusiveMinimum"].(bool); exclusive && v <= min { // OpenAPI 3.0 style
+			addErr(errs, instancePath, schemaPath+"/exclusiveMinimum", "must be > %v", min)
+		} else if v < min {
+			addErr(errs, instancePath, schemaPath+"/minimum", "must be >= %v", min)
+		}
+	}
+	if max, ok := toFloatOK(n["maximum"]); ok {
+		if exclusive, _ := n["exclusiveMaximum"].(bool); exclusive && v >= max {
+			addErr(errs, instancePath, schemaPath+"/exclusiveMaximum", "must be < %v", max)
+		} else if v > max {
+			addErr(errs, instancePath, schemaPath+"/maximum", "must be <= %v", max)
+		}
+	}
+	if min, ok := toFloatOK(n["exclusiveMinimum"]); ok && v <= min {
+		addErr(errs, instancePath, schemaPath+"/exclusiveMinimum", "must be > %v", min)
+	}
+	if max, ok := toFloatOK(n["exclusiveMaximum"]); ok && v >= max {
+		addErr(errs, instancePath, schemaPath+"/exclusiveMaximum", "must be < %v", max)
+	}
+}
+
+func typeName(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case float64, json.Number:
+		if f := toFloat(val); f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeMatches(t any, v any) bool {
+	switch tt := t.(type) {
+	case string:
+		actual := typeName(v)
+		return tt == actual || (tt == "number" && actual == "integer")
+	case []any:
+		for i := range tt {
+			if typeMatches(tt[i], v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func toFloat(v any) float64 {
+	f, _ := toFloatOK(v)
+	return f
+}
+
+func toFloatOK(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(v any) (int, bool) {
+	f, ok := toFloatOK(v)
+	return int(f), ok
+}
+
+func normalize(v any) any {
+	switch n := v.(type) {
+	case json.Number:
+		return toFloat(n)
+	case []any:
+		out := make([]any, len(n))
+		for i := range n {
+			out[i] = normalize(n[i])
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(n))
+		for k := range n {
+			out[k] = normalize(n[k])
+		}
+		return out
+	}
+	return v
+}
+
+func equalValues(a, b any) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func containsValue(list []any, v any) bool {
+	for i := range list {
+		if equalValues(list[i], v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 8, "pattern": "^[A-Z]"},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 200},
+		"role": {"enum": ["admin", "user"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true},
+		"address": {"$ref": "#/$defs/address"},
+		"nick": {"type": "string", "nullable": true}
+	},
+	"$defs": {
+		"address": {"type": "object", "properties": {"zip": {"type": "string"}}, "required": ["zip"]}
+	}
+}`
+
+func TestValid(t *testing.T) {
+	s := MustNew(userSchema)
+	assert.NoError(t, s.Validate([]byte(`{"name":"Joe","age":42,"role":"admin","tags":["a","b"],"address":{"zip":"02610"},"nick":null}`)))
+}
+
+func TestInvalid(t *testing.T) {
+	assert := assert.New(t)
+	s := MustNew(userSchema)
+
+	err := s.Validate([]byte(`{"age":4.5,"role":"root","tags":["a","a","b"],"address":{},"extra":1}`))
+	var verrs ValidationErrors
+	assert.True(errors.As(err, &verrs))
+
+	paths := map[string]string{}
+	for _, e := range verrs {
+		paths[e.SchemaPath] = e.InstancePath
+	}
+	assert.Equal("", paths["#/required"])
+	assert.Equal("/age", paths["#/properties/age/type"])
+	assert.Equal("/role", paths["#/properties/role/enum"])
+	assert.Equal("/tags", paths["#/properties/tags/maxItems"])
+	assert.Equal("/tags", paths["#/properties/tags/uniqueItems"])
+	assert.Equal("/address", paths["#/$defs/address/required"])
+	assert.Equal("/extra", paths["#/additionalProperties"])
+
+	assert.Error(s.Validate([]byte(`{"name":"joe"}`)))
+	assert.Error(s.Validate([]byte(`{"name":"Joeeeeeeeeee"}`)))
+	assert.Error(s.Validate([]byte(`{"name":"Joe","age":200}`)))
+	assert.Error(s.Validate([]byte(`[]`)))
+	assert.Error(s.Validate([]byte(`{`)))
+}
+
+func TestCombinators(t *testing.T) {
+	assert := assert.New(t)
+	s := MustNew(`{"oneOf":[{"type":"string"},{"type":"integer"}],"not":{"const":"x"},"anyOf":[{"maxLength":3},{"type":"integer","minimum":10}]}`)
+	assert.NoError(s.Validate([]byte(`"ab"`)))
+	assert.NoError(s.Validate([]byte(`12`)))
+	assert.Error(s.Validate([]byte(`"x"`)))
+	assert.Error(s.Validate([]byte(`true`)))
+	assert.Error(s.Validate([]byte(`"abcd"`)))
+
+	s = MustNew(`{"allOf":[{"type":"number","multipleOf":0.5},{"maximum":10}]}`)
+	assert.NoError(s.Validate([]byte(`1.5`)))
+	assert.Error(s.Validate([]byte(`1.2`)))
+	assert.Error(s.Validate([]byte(`11`)))
+}
+
+func TestFromDocument(t *testing.T) {
+	assert := assert.New(t)
+	spec := []byte(`{"components":{"schemas":{
+		"Users":{"type":"array","items":{"$ref":"#/components/schemas/User"}},
+		"User":{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}}}}`)
+	s, err := NewFromDocument(spec, "/components/schemas/Users")
+	assert.NoError(err)
+	assert.NoError(s.Validate([]byte(`[{"id":1},{"id":2}]`)))
+	err = s.Validate([]byte(`[{"id":1},{"name":"x"}]`))
+	assert.ErrorContains(err, "/1: missing property")
+
+	_, err = NewFromDocument(spec, "/components/schemas/Nope")
+	assert.ErrorIs(err, ErrInvalidSchema)
+	_, err = New([]byte(`{`))
+	assert.ErrorIs(err, ErrInvalidSchema)
+}
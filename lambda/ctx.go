@@ -11,10 +11,13 @@ package lambda
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"github.com/gorilla/mux"
+	"github.com/nokia/restful/logging"
 	"github.com/nokia/restful/trace/tracedata"
 	"github.com/nokia/restful/trace/traceotel"
 	"github.com/nokia/restful/trace/tracer"
@@ -38,19 +41,49 @@ type Lambda struct {
 
 	// Trace contains the tracing data of the handler context.
 	Trace tracedata.TraceData
+
+	logger func() *slog.Logger
+}
+
+func newLambda(w http.ResponseWriter, r *http.Request, vars map[string]string, t *tracer.Tracer) *Lambda {
+	l := &Lambda{w: w, r: r, Trace: t, vars: vars}
+	l.logger = sync.OnceValue(l.newLogger)
+	return l
 }
 
-func newLambda(w http.ResponseWriter, r *http.Request, vars map[string]string) *Lambda {
-	return &Lambda{w: w, r: r, Trace: tracer.NewFromRequestOrRandom(r), vars: vars} // Ensures consistent traceID.
+// newLogger creates the per-request logger, with trace ID, span ID, method and route template attributes.
+// Derived from the logger of the request context, so attributes of middlewares, e.g. request ID, are kept.
+func (l *Lambda) newLogger() *slog.Logger {
+	attrs := make([]any, 0, 8)
+	if l.Trace != nil {
+		if traceID := l.Trace.TraceID(); traceID != "" {
+			attrs = append(attrs, slog.String("trace_id", traceID))
+		}
+		if spanID := l.Trace.SpanID(); spanID != "" {
+			attrs = append(attrs, slog.String("span_id", spanID))
+		}
+	}
+	attrs = append(attrs, slog.String("method", l.r.Method))
+	if route := mux.CurrentRoute(l.r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			attrs = append(attrs, slog.String("route", tmpl))
+		}
+	}
+	return logging.FromContext(l.r.Context()).With(attrs...)
 }
 
 // NewRequestCtx adds request related data to r.Context().
 // You may use this at traditional http handler functions, and that is what happens at Lambda functions automatically.
 // Returns new derived context. That can be used at client functions, silently propagating tracing headers.
+// The tracer of the request is stored in the context, see tracer.FromContext.
+// So is the per-request logger, see Logger.
 //
 // E.g. ctx := NewRequestCtx(w, r)
 func NewRequestCtx(w http.ResponseWriter, r *http.Request) context.Context {
-	return context.WithValue(r.Context(), ctxName, newLambda(w, r, mux.Vars(r)))
+	t := tracer.NewFromRequestOrRandom(r) // Ensures consistent traceID.
+	ctx := tracer.NewContext(tracer.BaggageToContext(r.Context(), r), t)
+	l := newLambda(w, r, mux.Vars(r), t)
+	return logging.NewContextFunc(context.WithValue(ctx, ctxName, l), l.logger)
 }
 
 // L returns lambda-related data from context.
@@ -144,9 +177,23 @@ func (l *Lambda) TraceID() string {
 	return l.Trace.TraceID()
 }
 
+// Logger returns the per-request logger, having trace_id, span_id, method and route attributes.
+// The logger is stored in the Lambda context, so restful packages log with it when called with that context.
+//
+//	restful.L(ctx).Logger().Info("user created", "id", id)
+func (l *Lambda) Logger() *slog.Logger {
+	if l.logger == nil {
+		return logging.Logger()
+	}
+	return l.logger()
+}
+
 // AddLambdaToContext will return the context with value of Lambda
 func AddLambdaToContext(parentCtx context.Context, l *Lambda) context.Context {
-	ctx := context.WithValue(parentCtx, ctxName, l)
+	ctx := context.WithValue(tracer.BaggageToContext(parentCtx, l.r), ctxName, l)
+	if l.logger != nil {
+		ctx = logging.NewContextFunc(ctx, l.logger)
+	}
 	if tracer.GetOTel() {
 		ctx, _ = traceotel.TraceHeadersToContext(ctx, l.r)
 	}
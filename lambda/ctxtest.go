@@ -8,6 +8,8 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+
+	"github.com/nokia/restful/trace/tracer"
 )
 
 type testFakeWriter struct {
@@ -37,7 +39,8 @@ func NewTestCtx(method string, rawurl string, header http.Header, vars map[strin
 	r.Method = method
 	r.Header = header
 	r.URL, _ = url.Parse(rawurl)
-	return context.WithValue(context.Background(), ctxName, newLambda(newTestFakeWriter(), &r, vars))
+	t := tracer.NewFromRequestOrRandom(&r)
+	return context.WithValue(tracer.NewContext(context.Background(), t), ctxName, newLambda(newTestFakeWriter(), &r, vars, t))
 }
 
 // ResponseHeader return response header map to be sent.
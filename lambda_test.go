@@ -13,7 +13,7 @@ import (
 	"strings"
 	"testing"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/nokia/restful/logging"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
@@ -283,7 +283,7 @@ func TestContextOnly(t *testing.T) {
 }
 
 func TestContextTracing(t *testing.T) {
-	log.SetLevel(log.TraceLevel)
+	logging.Level.Set(logging.LevelTrace)
 	assert := assert.New(t)
 	traceparent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
 
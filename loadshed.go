@@ -0,0 +1,253 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrOverloaded is returned if a request is shed, as too many requests are being served.
+var ErrOverloaded = errors.New("overloaded")
+
+// LoadShedder limits the number of requests served concurrently.
+// Requests over the limit wait in a queue, and are shed, i.e. responded 503 with Retry-After header, if the queue is full or waited too long.
+// The limit may be adaptive, decreased when requests are slow and increased when fast again.
+// A shedder can be used for all requests, or per route group, by Router's Monitor.
+//
+//	shedder := restful.NewLoadShedder(100).Queue(200, time.Second)
+//	restful.NewServer().Addr(":8080").Handler(shedder.Handler(router))
+//	reportsShedder := restful.NewLoadShedder(4)
+//	reports := router.PathPrefix("/reports").Subrouter().Monitor(reportsShedder.Pre, reportsShedder.Post)
+//
+// OpenTelemetry metrics are emitted using the global MeterProvider, see SetOTelMetrics:
+//
+//   - http.server.queued_requests of requests waiting in the queue, and
+//   - http.server.shed_requests counter of requests rejected, by reason: queue_full or queue_timeout.
+//
+// Both labeled by the name of the shedder.
+type LoadShedder struct {
+	name         string
+	maxLimit     int
+	minLimit     int
+	target       time.Duration
+	queueSize    int
+	queueTimeout time.Duration
+	retryAfter   string
+
+	mutex    sync.Mutex
+	limit    float64
+	inFlight int
+	queue    list.List // Of chan struct{}, closed when admitted.
+	shed     atomic.Int64
+}
+
+// NewLoadShedder creates a load shedder, serving at most maxConcurrent requests at the same time.
+// There is no queue by default, requests over the limit are shed immediately.
+func NewLoadShedder(maxConcurrent int) *LoadShedder {
+	maxConcurrent = max(maxConcurrent, 1)
+	return &LoadShedder{name: "default", maxLimit: maxConcurrent, minLimit: maxConcurrent, limit: float64(maxConcurrent), retryAfter: "1"}
+}
+
+// Name sets the name of the shedder, used as "limiter" label of metrics.
+func (l *LoadShedder) Name(name string) *LoadShedder {
+	l.name = name
+	return l
+}
+
+// Queue sets the max number of requests waiting, and how long they may wait.
+func (l *LoadShedder) Queue(size int, timeout time.Duration) *LoadShedder {
+	l.queueSize = size
+	l.queueTimeout = timeout
+	return l
+}
+
+// RetryAfter sets the value of Retry-After header of responses of requests shed. Default is 1 second.
+func (l *LoadShedder) RetryAfter(d time.Duration) *LoadShedder {
+	l.retryAfter = strconv.Itoa(max(int(d.Round(time.Second).Seconds()), 1))
+	return l
+}
+
+// Adaptive makes the concurrency limit adaptive, between minConcurrent and the max set at creation.
+// If a request takes longer than targetLatency, then the limit is decreased by 10%. Otherwise it is increased slowly.
+func (l *LoadShedder) Adaptive(targetLatency time.Duration, minConcurrent int) *LoadShedder {
+	l.target = targetLatency
+	l.minLimit = min(max(minConcurrent, 1), l.maxLimit)
+	return l
+}
+
+// Limit returns the current concurrency limit.
+func (l *LoadShedder) Limit() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests being served.
+func (l *LoadShedder) InFlight() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.inFlight
+}
+
+// Queued returns the number of requests waiting.
+func (l *LoadShedder) Queued() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.queue.Len()
+}
+
+// Shed returns the number of requests shed so far.
+func (l *LoadShedder) Shed() int64 {
+	return l.shed.Load()
+}
+
+func (l *LoadShedder) reject(ctx context.Context, reason string) error {
+	l.shed.Add(1)
+	getLoadShedMetrics().shed.Add(ctx, 1, metric.WithAttributes(attribute.String("limiter", l.name), attribute.String("reason", reason)))
+	return ErrOverloaded
+}
+
+// acquire admits a request, waiting in the queue if needed.
+func (l *LoadShedder) acquire(ctx context.Context) error {
+	l.mutex.Lock()
+	if l.inFlight < int(l.limit) && l.queue.Len() == 0 {
+		l.inFlight++
+		l.mutex.Unlock()
+		return nil
+	}
+	if l.queue.Len() >= l.queueSize {
+		l.mutex.Unlock()
+		return l.reject(ctx, "queue_full")
+	}
+	admitted := make(chan struct{})
+	elem := l.queue.PushBack(admitted)
+	l.mutex.Unlock()
+
+	queued := getLoadShedMetrics().queued
+	attrs := metric.WithAttributes(attribute.String("limiter", l.name))
+	queued.Add(ctx, 1, attrs)
+	defer queued.Add(ctx, -1, attrs)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case <-admitted:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mutex.Lock()
+	select {
+	case <-admitted: // Admitted meanwhile.
+		l.mutex.Unlock()
+		return nil
+	default:
+		l.queue.Remove(elem)
+	}
+	l.mutex.Unlock()
+	return l.reject(ctx, "queue_timeout")
+}
+
+// release ends serving a request, admitting waiting ones.
+// Latency is used for adapting the limit, if positive.
+func (l *LoadShedder) release(latency time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.inFlight--
+	if l.target > 0 && latency > 0 {
+		if latency > l.target {
+			l.limit = max(l.limit*0.9, float64(l.minLimit))
+		} else {
+			l.limit = min(l.limit+1/l.limit, float64(l.maxLimit))
+		}
+	}
+	for l.inFlight < int(l.limit) && l.queue.Len() > 0 {
+		l.inFlight++
+		close(l.queue.Remove(l.queue.Front()).(chan struct{}))
+	}
+}
+
+func (l *LoadShedder) sendOverloaded(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", l.retryAfter)
+	pd := ProblemDetails{Title: http.StatusText(http.StatusServiceUnavailable), Status: http.StatusServiceUnavailable, Detail: "server overloaded"}
+	_ = SendProblemResponse(w, r, http.StatusServiceUnavailable, pd.String())
+}
+
+// loadShedCtxKey is the context key of the admission time of a request, per shedder, so that shedders can be nested.
+type loadShedCtxKey struct {
+	shedder *LoadShedder
+}
+
+// Pre is a Monitor pre function, admitting the request or shedding it. Use with Post, for route groups by Router's Monitor.
+//
+//	router.PathPrefix("/reports").Subrouter().Monitor(shedder.Pre, shedder.Post)
+func (l *LoadShedder) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	if err := l.acquire(r.Context()); err != nil {
+		l.sendOverloaded(w, r)
+		return nil
+	}
+	return r.WithContext(context.WithValue(r.Context(), loadShedCtxKey{l}, time.Now()))
+}
+
+// Post is a Monitor post function, releasing the request admitted by Pre.
+// The request is released when its handler returns, or panics, not when its client disconnects, as the handler may still be running.
+func (l *LoadShedder) Post(w http.ResponseWriter, r *http.Request, statusCode int) {
+	if start, ok := r.Context().Value(loadShedCtxKey{l}).(time.Time); ok {
+		l.release(time.Since(start))
+	}
+}
+
+// Handler wraps the handler, limiting all requests.
+func (l *LoadShedder) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := l.acquire(r.Context()); err != nil {
+			l.sendOverloaded(w, r)
+			return
+		}
+		start := time.Now()
+		defer func() { l.release(time.Since(start)) }()
+		h.ServeHTTP(w, r)
+	})
+}
+
+type loadShedMetrics struct {
+	queued metric.Int64UpDownCounter
+	shed   metric.Int64Counter
+}
+
+var (
+	loadShedMetricsMutex sync.Mutex
+	loadShedMetricsCache = map[metric.MeterProvider]*loadShedMetrics{}
+)
+
+func getLoadShedMetrics() *loadShedMetrics {
+	mp := otel.GetMeterProvider()
+	loadShedMetricsMutex.Lock()
+	defer loadShedMetricsMutex.Unlock()
+	if m, ok := loadShedMetricsCache[mp]; ok {
+		return m
+	}
+
+	meter := mp.Meter(MetricsScope)
+	m := &loadShedMetrics{}
+	m.queued, _ = meter.Int64UpDownCounter("http.server.queued_requests",
+		metric.WithDescription("Number of HTTP server requests waiting to be served."), metric.WithUnit("{request}"))
+	m.shed, _ = meter.Int64Counter("http.server.shed_requests",
+		metric.WithDescription("Number of HTTP server requests shed due to overload."), metric.WithUnit("{request}"))
+	loadShedMetricsCache[mp] = m
+	return m
+}
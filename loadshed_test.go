@@ -0,0 +1,165 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestLoadShedder(t *testing.T) {
+	assert := assert.New(t)
+	reader := sdkmetric.NewManualReader()
+	prevProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prevProvider)
+
+	shedder := NewLoadShedder(1).Name("api").Queue(1, time.Minute).RetryAfter(3 * time.Second)
+	block := make(chan struct{})
+	started := make(chan struct{}, 3)
+	h := shedder.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-block
+	}))
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes <- w.Code
+		}()
+	}
+	<-started
+	assert.Eventually(func() bool { return shedder.Queued() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(1, shedder.InFlight())
+
+	// Queue full
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.Equal("3", w.Header().Get("Retry-After"))
+	assert.Equal(int64(1), shedder.Shed())
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &rm))
+	if queued := findMetric(rm, "http.server.queued_requests"); assert.NotNil(queued) {
+		assert.Equal(int64(1), queued.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+	}
+	if shed := findMetric(rm, "http.server.shed_requests"); assert.NotNil(shed) {
+		dp := shed.Data.(metricdata.Sum[int64]).DataPoints[0]
+		reason, _ := dp.Attributes.Value("reason")
+		assert.Equal("queue_full", reason.AsString())
+	}
+
+	close(block)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		assert.Equal(http.StatusOK, code)
+	}
+	assert.Equal(0, shedder.InFlight())
+	assert.Equal(0, shedder.Queued())
+}
+
+func TestLoadShedderQueueTimeout(t *testing.T) {
+	assert := assert.New(t)
+	shedder := NewLoadShedder(1).Queue(10, 10*time.Millisecond)
+	assert.NoError(shedder.acquire(context.Background()))
+	assert.ErrorIs(shedder.acquire(context.Background()), ErrOverloaded)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(shedder.acquire(ctx), ErrOverloaded)
+	assert.Equal(0, shedder.Queued())
+	shedder.release(0)
+	assert.NoError(shedder.acquire(context.Background()))
+}
+
+func TestLoadShedderAdaptive(t *testing.T) {
+	assert := assert.New(t)
+	shedder := NewLoadShedder(10).Adaptive(100*time.Millisecond, 2)
+	for range 20 {
+		assert.NoError(shedder.acquire(context.Background()))
+		shedder.release(time.Second)
+	}
+	assert.Equal(2, shedder.Limit())
+	for range 30 {
+		assert.NoError(shedder.acquire(context.Background()))
+		shedder.release(time.Millisecond)
+	}
+	assert.Greater(shedder.Limit(), 4)
+	assert.LessOrEqual(shedder.Limit(), 10)
+}
+
+func TestLoadShedderRouter(t *testing.T) {
+	assert := assert.New(t)
+	shedder := NewLoadShedder(1)
+	router := NewRouter()
+	reports := router.PathPrefix("/reports").Subrouter().Monitor(shedder.Pre, shedder.Post)
+	reports.HandleFunc("/daily", func() {})
+	reports.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("oops") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/daily", nil))
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal(0, shedder.InFlight())
+
+	// Released even if panicked.
+	assert.Panics(func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reports/panic", nil))
+	})
+	assert.Equal(0, shedder.InFlight())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/daily", nil))
+	assert.Equal(http.StatusNoContent, w.Code)
+}
+
+func TestLoadShedderClientDisconnect(t *testing.T) {
+	assert := assert.New(t)
+	shedder := NewLoadShedder(1)
+	disconnected := make(chan struct{})
+	release := make(chan struct{})
+	router := NewRouter().Monitor(shedder.Pre, shedder.Post)
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(disconnected)
+		<-release // Still running after the client is gone.
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/slow", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	assert.Eventually(func() bool { return shedder.InFlight() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	<-disconnected
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(1, shedder.InFlight()) // Not released by the cancel of the request context.
+	resp, err := http.Get(srv.URL + "/slow")
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	close(release)
+	assert.Eventually(func() bool { return shedder.InFlight() == 0 }, time.Second, time.Millisecond)
+}
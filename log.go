@@ -18,4 +18,34 @@ func init() {
 	logrus.SetLevel(logLevel)
 
 	logrus.SetFormatter(&logrus.JSONFormatter{})
+	logrus.AddHook(TraceLogHook{})
+}
+
+// TraceLogHook is a logrus hook adding trace_id and span_id fields to log entries logged with a context,
+// e.g. log.WithContext(ctx).Info("..."), in a handler or in a client call made with the context.
+// That lets logs be correlated with traces.
+// Added to logrus standard logger automatically. Add it to your own logrus loggers, if any.
+type TraceLogHook struct{}
+
+// Levels returns the levels the hook is fired at.
+func (TraceLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds trace fields to the log entry.
+func (TraceLogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	trace := traceFromContext(entry.Context)
+	if trace == nil {
+		return nil
+	}
+	if traceID := trace.TraceID(); traceID != "" {
+		entry.Data["trace_id"] = traceID
+	}
+	if spanID := trace.SpanID(); spanID != "" {
+		entry.Data["span_id"] = spanID
+	}
+	return nil
 }
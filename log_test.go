@@ -0,0 +1,75 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nokia/restful/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestTraceLogHook(t *testing.T) {
+	assert := assert.New(t)
+	SetOTel(true, sdktrace.NewTracerProvider())
+	defer SetOTel(false, nil)
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(TraceLogHook{})
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	logger.WithContext(ctx).Info("in span")
+
+	var fields map[string]any
+	assert.NoError(json.Unmarshal(buf.Bytes(), &fields))
+	assert.Equal(span.SpanContext().TraceID().String(), fields["trace_id"])
+	assert.Equal(span.SpanContext().SpanID().String(), fields["span_id"])
+
+	buf.Reset()
+	logger.WithContext(context.Background()).Info("no span")
+	fields = nil
+	assert.NoError(json.Unmarshal(buf.Bytes(), &fields))
+	assert.NotContains(fields, "trace_id")
+}
+
+func TestLambdaLogger(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer logging.SetLogger(nil)
+
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context) error {
+		L(ctx).Logger().Info("handling")
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		_ = json.Unmarshal(line, &record)
+		if record["msg"] == "handling" {
+			break
+		}
+	}
+	assert.Equal("handling", record["msg"])
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", record["trace_id"])
+	assert.Equal(http.MethodGet, record["method"])
+	assert.Equal("/users/{id}", record["route"])
+}
@@ -0,0 +1,215 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ModuleKey is the attribute key telling the module of a logger. See Module.
+const ModuleKey = "module"
+
+var (
+	levelMutex   sync.RWMutex
+	moduleLevels = map[string]slog.Level{}
+)
+
+// SetModuleLevel sets the level of a module, overriding Level for loggers having that module attribute, see Module.
+// Restful modules are "nrf", "subscription" and "tracer".
+// The default logger applies module levels lower than Level, too. Loggers set by SetLogger apply their own levels, as well.
+func SetModuleLevel(module string, level slog.Level) {
+	levelMutex.Lock()
+	defer levelMutex.Unlock()
+	moduleLevels[module] = level
+}
+
+// ResetModuleLevel makes the module use Level again.
+func ResetModuleLevel(module string) {
+	levelMutex.Lock()
+	defer levelMutex.Unlock()
+	delete(moduleLevels, module)
+}
+
+// ModuleLevels returns the levels set for modules.
+func ModuleLevels() map[string]slog.Level {
+	levelMutex.RLock()
+	defer levelMutex.RUnlock()
+	levels := make(map[string]slog.Level, len(moduleLevels))
+	for module, level := range moduleLevels {
+		levels[module] = level
+	}
+	return levels
+}
+
+func getModuleLevel(module string) (slog.Level, bool) {
+	if module == "" {
+		return 0, false
+	}
+	levelMutex.RLock()
+	defer levelMutex.RUnlock()
+	level, ok := moduleLevels[module]
+	return level, ok
+}
+
+// lowestLevel is the lowest of Level and module levels.
+type lowestLevel struct{}
+
+// Level returns the lowest level.
+func (lowestLevel) Level() slog.Level {
+	lowest := Level.Level()
+	levelMutex.RLock()
+	defer levelMutex.RUnlock()
+	for _, level := range moduleLevels {
+		lowest = min(lowest, level)
+	}
+	return lowest
+}
+
+// moduleHandler drops records below the level of its module, if set.
+// Otherwise below Level, if levelled, i.e. the next handler is the default one.
+type moduleHandler struct {
+	next     slog.Handler
+	module   string
+	levelled bool
+}
+
+// Enabled tells whether the level is enabled for the module, and by the next handler.
+func (h moduleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if moduleLevel, ok := getModuleLevel(h.module); ok {
+		if level < moduleLevel {
+			return false
+		}
+	} else if h.levelled && level < Level.Level() {
+		return false
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes the record to the next handler.
+func (h moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler having the attributes. A module attribute sets the module of the handler.
+func (h moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	module := h.module
+	for _, a := range attrs {
+		if a.Key == ModuleKey {
+			module = a.Value.String()
+		}
+	}
+	return moduleHandler{next: h.next.WithAttrs(attrs), module: module, levelled: h.levelled}
+}
+
+// WithGroup returns a handler having the group.
+func (h moduleHandler) WithGroup(name string) slog.Handler {
+	return moduleHandler{next: h.next.WithGroup(name), module: h.module, levelled: h.levelled}
+}
+
+// ParseLevel parses a level name, such as "debug", "INFO", "warning" or "trace", case insensitively.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "trace":
+		return LevelTrace, nil
+	case "warning":
+		return slog.LevelWarn, nil
+	case "fatal", "panic":
+		return slog.LevelError, nil
+	}
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+func levelName(level slog.Level) string {
+	if level == LevelTrace {
+		return "TRACE"
+	}
+	return level.String()
+}
+
+// LevelRequest is the body of level change requests and responses of LevelHandler.
+type LevelRequest struct {
+	// Level is the level of the module, or Level if no module given. Empty string resets the module level.
+	Level string `json:"level"`
+
+	// Module is the module the level is set for. Optional.
+	Module string `json:"module,omitempty"`
+
+	// Modules are the module levels, in responses.
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// LevelHandler returns a handler to query and change log levels at runtime, without restart.
+//
+//   - GET responds with the level and module levels, e.g. {"level":"INFO","modules":{"nrf":"DEBUG"}}.
+//   - PUT or POST sets the level, e.g. {"level":"debug"}, or a module level, e.g. {"module":"nrf","level":"debug"}.
+//
+// Requests not authorized by authorize get 403 Forbidden. Nil authorize means no protection, for handlers served behind authorization already.
+//
+//	isOperator := func(r *http.Request) bool {
+//		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminToken)) == 1
+//	}
+//	router.Handle("/admin/loglevel", logging.LevelHandler(isOperator))
+func LevelHandler(authorize func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorize != nil && !authorize(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			if err := setLevel(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp := LevelRequest{Level: levelName(Level.Level()), Modules: map[string]string{}}
+		for module, level := range ModuleLevels() {
+			resp.Modules[module] = levelName(level)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func setLevel(r *http.Request) error {
+	var req LevelRequest
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 4096)).Decode(&req); err != nil {
+		return err
+	}
+	if req.Level == "" {
+		if req.Module == "" {
+			return errors.New("level missing")
+		}
+		ResetModuleLevel(req.Module)
+		Infof(r.Context(), "Log level of module %s reset", req.Module)
+		return nil
+	}
+
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	if req.Module == "" {
+		Level.Set(level)
+	} else {
+		SetModuleLevel(req.Module, level)
+	}
+	Infof(r.Context(), "Log level of module %q set to %s", req.Module, levelName(level))
+	return nil
+}
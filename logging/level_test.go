@@ -0,0 +1,95 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleLevel(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer SetLogger(nil)
+	defer ResetModuleLevel("nrf")
+
+	Module("nrf").Debug("debug")
+	assert.Contains(buf.String(), "msg=debug module=nrf")
+
+	SetModuleLevel("nrf", slog.LevelWarn)
+	buf.Reset()
+	Module("nrf").Info("dropped")
+	Logger().Info("kept")
+	assert.NotContains(buf.String(), "dropped")
+	assert.Contains(buf.String(), "kept")
+	assert.Equal(map[string]slog.Level{"nrf": slog.LevelWarn}, ModuleLevels())
+
+	ResetModuleLevel("nrf")
+	buf.Reset()
+	Module("nrf").Info("again")
+	assert.Contains(buf.String(), "again")
+}
+
+func TestModuleLevelLowered(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	l := slog.New(moduleHandler{next: slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: lowestLevel{}}), levelled: true})
+	SetModuleLevel("x", LevelTrace)
+	defer ResetModuleLevel("x")
+
+	l.Debug("dropped")
+	l.With(ModuleKey, "x").Log(context.Background(), LevelTrace, "kept")
+	assert.NotContains(buf.String(), "dropped")
+	assert.Contains(buf.String(), "kept")
+}
+
+func TestLevelHandler(t *testing.T) {
+	assert := assert.New(t)
+	h := LevelHandler(func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer admin" })
+	prevLevel := Level.Level()
+	defer Level.Set(prevLevel)
+	defer ResetModuleLevel("nrf")
+
+	send := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/loglevel", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer admin")
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send(http.MethodPut, `{"module":"nrf","level":"debug"}`)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), `"modules":{"nrf":"DEBUG"}`)
+
+	w = send(http.MethodPost, `{"level":"trace"}`)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(LevelTrace, Level.Level())
+
+	w = send(http.MethodGet, "")
+	assert.JSONEq(`{"level":"TRACE","modules":{"nrf":"DEBUG"}}`, w.Body.String())
+
+	assert.Equal(http.StatusBadRequest, send(http.MethodPut, `{"level":"verbose"}`).Code)
+	assert.Equal(http.StatusBadRequest, send(http.MethodPut, `{}`).Code)
+	assert.Equal(http.StatusMethodNotAllowed, send(http.MethodDelete, "").Code)
+
+	w = send(http.MethodPut, `{"module":"nrf","level":""}`)
+	assert.JSONEq(`{"level":"TRACE"}`, w.Body.String())
+
+	{ // Unauthorized
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"error"}`)))
+		assert.Equal(http.StatusForbidden, w.Code)
+		assert.Equal(LevelTrace, Level.Level())
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package logging is the logging abstraction of restful packages, based on log/slog.
+//
+// Restful packages log via the logger of the context, if any, or the one set by SetLogger.
+// Lambda handlers get a per-request logger in their context, with trace ID, method and route attributes.
+// Loggers of other libraries, e.g. zap or zerolog, can be plugged in by slog handler adapters. See subpackages.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// LevelTrace is a level more verbose than debug. Level names "trace" and "TRACE" are printed for that.
+const LevelTrace = slog.LevelDebug - 4
+
+// Level is the level of the default logger, set by LOG_LEVEL environment variable, e.g. "debug". Default is info.
+// Can be changed at runtime, see LevelHandler.
+var Level = newLevelVar(os.Getenv("LOG_LEVEL"))
+
+func newLevelVar(s string) *slog.LevelVar {
+	level := new(slog.LevelVar)
+	if l, err := ParseLevel(s); err == nil {
+		level.Set(l)
+	}
+	return level
+}
+
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok && level == LevelTrace {
+			a.Value = slog.StringValue("TRACE")
+		}
+	}
+	return a
+}
+
+// newDefaultHandler creates the handler of the default logger, writing JSON records to standard error.
+// Its level is the lowest of Level and module levels, while moduleHandler applies the exact ones.
+func newDefaultHandler() slog.Handler {
+	return slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: lowestLevel{}, ReplaceAttr: replaceLevel})
+}
+
+var (
+	mutex       sync.RWMutex
+	base        = slog.New(newDefaultHandler())
+	baseDefault = true
+	hooks       []slog.Handler
+	logger      = newHookedLogger()
+	modules     = map[string]*slog.Logger{}
+)
+
+// SetLogger sets the logger used by restful packages. Nil restores the default one.
+//
+//	logging.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+func SetLogger(l *slog.Logger) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	base, baseDefault = l, l == nil
+	if baseDefault {
+		base = slog.New(newDefaultHandler())
+	}
+	logger = newHookedLogger()
+}
+
+// AddHook adds a handler the records logged are passed to, as well, e.g. for exporting them.
+// Records are passed if the logger is enabled at their level, and so is the hook.
+// Hooks are kept when the logger is replaced by SetLogger.
+func AddHook(h slog.Handler) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	hooks = append(hooks, h)
+	logger = newHookedLogger()
+}
+
+func newHookedLogger() *slog.Logger {
+	modules = map[string]*slog.Logger{}
+	h := base.Handler()
+	if len(hooks) > 0 {
+		h = hookHandler{base: h, hooks: hooks}
+	}
+	return slog.New(moduleHandler{next: h, levelled: baseDefault})
+}
+
+// Logger returns the logger used by restful packages.
+func Logger() *slog.Logger {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return logger
+}
+
+// Module returns the logger of a module, having a "module" attribute. Its level can be set by SetModuleLevel.
+//
+//	logging.Module("nrf").Warn("NRF heartbeat failed", "error", err)
+func Module(name string) *slog.Logger {
+	mutex.RLock()
+	l, ok := modules[name]
+	mutex.RUnlock()
+	if ok {
+		return l
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	l = logger.With(ModuleKey, name)
+	modules[name] = l
+	return l
+}
+
+type loggerCtxKeyType string
+
+const loggerCtxName = loggerCtxKeyType("restfulLogger")
+
+// NewContext returns a context derived from ctx, holding the logger.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxName, l)
+}
+
+// NewContextFunc returns a context derived from ctx, holding a logger created by newLogger on first use.
+// Useful if creating the logger, e.g. adding attributes by With, is expensive and may be unnecessary.
+func NewContextFunc(ctx context.Context, newLogger func() *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxName, sync.OnceValue(newLogger))
+}
+
+// FromContext returns the logger of the context, e.g. the per-request logger of a Lambda handler.
+// If there is none, then the one returned by Logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		switch l := ctx.Value(loggerCtxName).(type) {
+		case *slog.Logger:
+			return l
+		case func() *slog.Logger:
+			return l()
+		}
+	}
+	return Logger()
+}
+
+// hookHandler passes records to the base handler, and to the hooks.
+type hookHandler struct {
+	base  slog.Handler
+	hooks []slog.Handler
+}
+
+// Enabled tells whether the base handler handles records of the level. Hooks follow that, like logrus hooks.
+func (h hookHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base.Enabled(ctx, level)
+}
+
+// Handle passes the record to the base handler, and to the hooks enabled.
+func (h hookHandler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.base.Handle(ctx, r)
+	for _, hook := range h.hooks {
+		if hook.Enabled(ctx, r.Level) {
+			_ = hook.Handle(ctx, r.Clone())
+		}
+	}
+	return err
+}
+
+// WithAttrs returns a handler whose base and hooks have the attributes.
+func (h hookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hooks := make([]slog.Handler, len(h.hooks))
+	for i, hook := range h.hooks {
+		hooks[i] = hook.WithAttrs(attrs)
+	}
+	return hookHandler{base: h.base.WithAttrs(attrs), hooks: hooks}
+}
+
+// WithGroup returns a handler whose base and hooks have the group.
+func (h hookHandler) WithGroup(name string) slog.Handler {
+	hooks := make([]slog.Handler, len(h.hooks))
+	for i, hook := range h.hooks {
+		hooks[i] = hook.WithGroup(name)
+	}
+	return hookHandler{base: h.base.WithGroup(name), hooks: hooks}
+}
+
+// Logf logs a formatted message at the level, by the logger of the context.
+// Formatting is skipped if the level is not enabled.
+func Logf(ctx context.Context, level slog.Level, format string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l := FromContext(ctx)
+	if l.Enabled(ctx, level) {
+		l.Log(ctx, level, fmt.Sprintf(format, args...))
+	}
+}
+
+// Debugf logs a formatted message at debug level, by the logger of the context.
+func Debugf(ctx context.Context, format string, args ...any) {
+	Logf(ctx, slog.LevelDebug, format, args...)
+}
+
+// Infof logs a formatted message at info level, by the logger of the context.
+func Infof(ctx context.Context, format string, args ...any) {
+	Logf(ctx, slog.LevelInfo, format, args...)
+}
+
+// Warnf logs a formatted message at warning level, by the logger of the context.
+func Warnf(ctx context.Context, format string, args ...any) {
+	Logf(ctx, slog.LevelWarn, format, args...)
+}
+
+// Errorf logs a formatted message at error level, by the logger of the context.
+func Errorf(ctx context.Context, format string, args ...any) {
+	Logf(ctx, slog.LevelError, format, args...)
+}
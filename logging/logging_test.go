@@ -0,0 +1,98 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(slog.LevelInfo, newLevelVar("").Level())
+	assert.Equal(slog.LevelInfo, newLevelVar("bad").Level())
+	assert.Equal(slog.LevelDebug, newLevelVar("debug").Level())
+	assert.Equal(LevelTrace, newLevelVar("TRACE").Level())
+	assert.Equal(slog.LevelWarn, newLevelVar("warning").Level())
+	assert.Equal(slog.LevelError, newLevelVar("ERROR").Level())
+}
+
+func TestSetLogger(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer SetLogger(nil)
+
+	Debugf(context.Background(), "hello %s", "joe")
+	assert.Contains(buf.String(), `level=DEBUG msg="hello joe"`)
+
+	buf.Reset()
+	ctx := NewContext(context.Background(), Logger().With("user", "joe"))
+	Infof(ctx, "ctx")
+	assert.Contains(buf.String(), "msg=ctx user=joe")
+
+	created := 0
+	ctx = NewContextFunc(context.Background(), func() *slog.Logger { created++; return Logger().With("lazy", true) })
+	assert.Zero(created)
+	Warnf(ctx, "lazy")
+	Errorf(ctx, "lazy")
+	assert.Equal(1, created)
+	assert.Contains(buf.String(), "level=ERROR msg=lazy lazy=true")
+
+	SetLogger(nil)
+	buf.Reset()
+	Errorf(context.Background(), "default")
+	assert.Empty(buf.String())
+}
+
+type recordingHandler struct {
+	level   slog.Level
+	records *[]slog.Record
+	attrs   []slog.Attr
+}
+
+func (h recordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+func (h recordingHandler) WithGroup(name string) slog.Handler { return h }
+
+func (h recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return recordingHandler{level: h.level, records: h.records, attrs: append(h.attrs, attrs...)}
+}
+
+func (h recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(h.attrs...)
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+func TestAddHook(t *testing.T) {
+	assert := assert.New(t)
+	var records, hooked []slog.Record
+	SetLogger(slog.New(recordingHandler{level: slog.LevelInfo, records: &records}))
+	defer SetLogger(nil)
+
+	prevHooks := hooks
+	AddHook(recordingHandler{level: slog.LevelWarn, records: &hooked})
+	defer func() { hooks = prevHooks; SetLogger(nil) }()
+
+	l := Logger().With("user", "joe")
+	l.Debug("none")
+	l.Info("base only")
+	l.Warn("both")
+
+	assert.Len(records, 2)
+	if assert.Len(hooked, 1) {
+		assert.Equal("both", hooked[0].Message)
+		hooked[0].Attrs(func(a slog.Attr) bool {
+			assert.Equal("user", a.Key)
+			return true
+		})
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package zapadapter plugs a zap logger into restful logging.
+//
+//	logging.SetLogger(zapadapter.New(zapLogger))
+package zapadapter
+
+import (
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
+)
+
+// NewHandler creates a slog handler writing records to the zap logger.
+func NewHandler(l *zap.Logger) slog.Handler {
+	return zapslog.NewHandler(l.Core(), zapslog.WithCaller(true))
+}
+
+// New creates a slog logger writing records to the zap logger.
+func New(l *zap.Logger) *slog.Logger {
+	return slog.New(NewHandler(l))
+}
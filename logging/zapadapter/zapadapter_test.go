@@ -0,0 +1,30 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapadapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := New(zap.New(core)).With("trace_id", "abc")
+
+	l.Debug("not logged")
+	l.Warn("hello", "user", "joe")
+
+	if assert.Equal(1, logs.Len()) {
+		entry := logs.All()[0]
+		assert.Equal("hello", entry.Message)
+		assert.Equal(zapcore.WarnLevel, entry.Level)
+		assert.Equal(map[string]any{"trace_id": "abc", "user": "joe"}, entry.ContextMap())
+	}
+}
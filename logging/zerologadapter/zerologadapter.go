@@ -0,0 +1,103 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package zerologadapter plugs a zerolog logger into restful logging.
+//
+//	logging.SetLogger(zerologadapter.New(zerolog.New(os.Stderr).With().Timestamp().Logger()))
+package zerologadapter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// handler is a slog handler writing records to a zerolog logger.
+// Groups are flattened into dotted key prefixes.
+type handler struct {
+	logger zerolog.Logger
+	prefix string
+}
+
+// NewHandler creates a slog handler writing records to the zerolog logger.
+func NewHandler(l zerolog.Logger) slog.Handler {
+	return handler{logger: l}
+}
+
+// New creates a slog logger writing records to the zerolog logger.
+func New(l zerolog.Logger) *slog.Logger {
+	return slog.New(NewHandler(l))
+}
+
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// Enabled tells whether the zerolog logger writes records of the level.
+func (h handler) Enabled(ctx context.Context, level slog.Level) bool {
+	zl := zerologLevel(level)
+	return zl >= h.logger.GetLevel() && zl >= zerolog.GlobalLevel()
+}
+
+// Handle writes the record.
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	event := h.logger.WithLevel(zerologLevel(r.Level))
+	if event == nil {
+		return nil
+	}
+	fields := make(map[string]any, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		addField(fields, h.prefix, a)
+		return true
+	})
+	event.Fields(fields).Ctx(ctx).Msg(r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler whose logger has the attributes.
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := map[string]any{}
+	for _, a := range attrs {
+		addField(fields, h.prefix, a)
+	}
+	return handler{logger: h.logger.With().Fields(fields).Logger(), prefix: h.prefix}
+}
+
+// WithGroup returns a handler prefixing keys with the group name.
+func (h handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return handler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+func addField(fields map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addField(fields, groupPrefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
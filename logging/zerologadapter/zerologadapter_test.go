@@ -0,0 +1,27 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zerologadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	l := New(zerolog.New(&buf).Level(zerolog.InfoLevel)).With("trace_id", "abc").WithGroup("req")
+
+	l.Debug("not logged")
+	l.Warn("hello", "user", "joe", "size", 42)
+
+	var record map[string]any
+	assert.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(map[string]any{"level": "warn", "message": "hello", "trace_id": "abc", "req.user": "joe", "req.size": 42.0}, record)
+}
@@ -0,0 +1,152 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// ErrUnknownMaintenance is returned if no maintenance mode is found by the name given.
+var ErrUnknownMaintenance = errors.New("unknown maintenance mode")
+
+// Maintenance is a runtime switch, making requests responded 503 with Retry-After header, e.g. for planned maintenance windows
+// or as a kill-switch of a feature. Applied to route groups by Router's Monitor, or to all requests.
+// Health endpoints, such as LivenessProbePath, are served even in maintenance mode.
+// Switched by Set, by name via SetMaintenance, e.g. on an admin API, or on signals, see ToggleMaintenanceOnSignal.
+//
+//	reportsMaintenance := restful.NewMaintenance("reports").Message("Reports are being migrated.").RetryAfter(time.Hour)
+//	router.PathPrefix("/reports").Subrouter().Monitor(reportsMaintenance.Pre, nil)
+type Maintenance struct {
+	name       string
+	enabled    atomic.Bool
+	detail     string
+	retryAfter string
+}
+
+var (
+	maintenancesMutex sync.Mutex
+	maintenances      = map[string]*Maintenance{}
+)
+
+// NewMaintenance creates a maintenance mode switch, disabled. Name identifies the switch, e.g. at SetMaintenance.
+// Creating another switch by the same name replaces the former one at switching by name.
+func NewMaintenance(name string) *Maintenance {
+	m := &Maintenance{name: name, detail: "service under maintenance", retryAfter: "60"}
+	maintenancesMutex.Lock()
+	defer maintenancesMutex.Unlock()
+	maintenances[name] = m
+	return m
+}
+
+// Message sets the detail of the problem responded.
+func (m *Maintenance) Message(detail string) *Maintenance {
+	m.detail = detail
+	return m
+}
+
+// RetryAfter sets the value of Retry-After header, telling clients when to come back. Default is 1 minute.
+func (m *Maintenance) RetryAfter(d time.Duration) *Maintenance {
+	m.retryAfter = strconv.Itoa(max(int(d.Round(time.Second).Seconds()), 1))
+	return m
+}
+
+// Set enables or disables maintenance mode.
+func (m *Maintenance) Set(enabled bool) *Maintenance {
+	if m.enabled.Swap(enabled) != enabled {
+		logging.Infof(context.Background(), "maintenance mode %q enabled: %v", m.name, enabled)
+	}
+	return m
+}
+
+// Enabled tells if maintenance mode is enabled.
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Pre is a Monitor pre function, responding 503 in maintenance mode. May be used for route groups, by Router's Monitor.
+func (m *Maintenance) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	if !m.enabled.Load() || r.URL.Path == LivenessProbePath || r.URL.Path == ReadinessProbePath || r.URL.Path == HealthCheckPath {
+		return r
+	}
+	w.Header().Set("Retry-After", m.retryAfter)
+	pd := ProblemDetails{Title: http.StatusText(http.StatusServiceUnavailable), Status: http.StatusServiceUnavailable, Detail: m.detail}
+	_ = SendProblemResponse(w, r, http.StatusServiceUnavailable, pd.String())
+	return nil
+}
+
+// Handler wraps the handler, responding 503 to all requests in maintenance mode.
+func (m *Maintenance) Handler(h http.Handler) http.Handler {
+	return Monitor(h, m.Pre, nil)
+}
+
+// SetMaintenance enables or disables the maintenance mode of the name given.
+func SetMaintenance(name string, enabled bool) error {
+	maintenancesMutex.Lock()
+	m, ok := maintenances[name]
+	maintenancesMutex.Unlock()
+	if !ok {
+		return ErrUnknownMaintenance
+	}
+	m.Set(enabled)
+	return nil
+}
+
+// MaintenanceStates returns whether maintenance modes are enabled, by name.
+func MaintenanceStates() map[string]bool {
+	maintenancesMutex.Lock()
+	defer maintenancesMutex.Unlock()
+	states := make(map[string]bool, len(maintenances))
+	for name, m := range maintenances {
+		states[name] = m.Enabled()
+	}
+	return states
+}
+
+// ToggleMaintenanceOnSignal toggles the maintenance modes of the names given, or all if none given, on receiving a signal, e.g. syscall.SIGUSR1.
+// Returns a function stopping that.
+//
+//	stop := restful.ToggleMaintenanceOnSignal(syscall.SIGUSR1, "reports")
+//	defer stop()
+func ToggleMaintenanceOnSignal(sig os.Signal, names ...string) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, sig)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				maintenancesMutex.Lock()
+				toggled := maps.Clone(maintenances)
+				maintenancesMutex.Unlock()
+				for name, m := range toggled {
+					if len(names) == 0 || slices.Contains(names, name) {
+						m.Set(!m.Enabled())
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
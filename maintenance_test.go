@@ -0,0 +1,67 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenance(t *testing.T) {
+	assert := assert.New(t)
+	reports := NewMaintenance("reports").Message("Reports are being migrated.").RetryAfter(time.Hour)
+	router := NewRouter()
+	router.HandleFunc("/users", func() {})
+	group := router.PathPrefix("/reports").Subrouter().Monitor(reports.Pre, nil)
+	group.HandleFunc("/daily", func() {})
+	h := NewServer().Handler(router).server.Handler
+
+	request := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(http.StatusNoContent, request("/reports/daily").Code)
+	assert.NoError(SetMaintenance("reports", true))
+	assert.True(MaintenanceStates()["reports"])
+
+	w := request("/reports/daily")
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.Equal("3600", w.Header().Get("Retry-After"))
+	assert.Contains(w.Body.String(), "Reports are being migrated.")
+	assert.Equal(http.StatusNoContent, request("/users").Code)
+	assert.Equal(http.StatusOK, request(LivenessProbePath).Code)
+
+	assert.ErrorIs(SetMaintenance("nope", true), ErrUnknownMaintenance)
+
+	// Global, probes alive
+	global := NewMaintenance("global").Set(true)
+	h = global.Handler(router)
+	assert.Equal(http.StatusServiceUnavailable, request("/users").Code)
+	assert.NotEqual(http.StatusServiceUnavailable, request(HealthCheckPath).Code)
+	assert.Equal("60", request("/users").Header().Get("Retry-After"))
+}
+
+func TestMaintenanceSignal(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMaintenance("signal")
+	other := NewMaintenance("signal-other")
+	stop := ToggleMaintenanceOnSignal(syscall.SIGUSR1, "signal")
+	defer stop()
+
+	assert.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(m.Enabled, time.Second, time.Millisecond)
+	assert.False(other.Enabled())
+	assert.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(func() bool { return !m.Enabled() }, time.Second, time.Millisecond)
+	stop()
+	stop()
+}
@@ -0,0 +1,287 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package metrics provides Prometheus RED metrics of HTTP servers, i.e. request rate, errors and duration,
+// labeled by method, route template matched and status class. Plus a handler serving the metrics.
+// Go runtime metrics, process metrics and build info are exposed, too.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Path is the conventional path of the metrics endpoint.
+const Path = "/metrics"
+
+var labelNames = []string{"method", "route", "status"}
+
+// Metrics is a set of Prometheus RED metrics.
+//
+//   - http_requests_total counter, labeled by method, route and status class, e.g. "GET", "/users/{id}", "2xx".
+//   - http_request_duration_seconds histogram, with the same labels.
+//   - http_requests_in_flight gauge of requests being served, labeled by method.
+//
+// Route is the template matched by the Router, or empty if none matched.
+// Runtime metrics are registered, as well, see Runtime.
+//
+// Configure the metrics before serving the first request. Metrics are registered then.
+type Metrics struct {
+	registerer   prometheus.Registerer
+	gatherer     prometheus.Gatherer
+	buckets      []float64
+	groups       []routeGroup
+	nativeFactor float64
+	openMetrics  bool
+	runtime      bool
+
+	registerOnce sync.Once
+	requests     *prometheus.CounterVec
+	duration     *durationHistograms
+	inFlight     *prometheus.GaugeVec
+	handler      http.Handler
+}
+
+// routeGroup is a set of routes sharing the same histogram buckets.
+type routeGroup struct {
+	prefix  string
+	buckets []float64
+	vec     *prometheus.HistogramVec
+}
+
+// New creates metrics to be registered at the registry. Nil registry means the Prometheus default one.
+func New(reg *prometheus.Registry) *Metrics {
+	m := &Metrics{registerer: prometheus.DefaultRegisterer, gatherer: prometheus.DefaultGatherer, buckets: prometheus.DefBuckets, runtime: true}
+	if reg != nil {
+		m.registerer, m.gatherer = reg, reg
+	}
+	return m
+}
+
+// Buckets sets the duration histogram buckets of the routes whose template starts with prefix, e.g. "/api/v1/reports".
+// The longest prefix matching wins. Empty prefix sets the buckets of all other routes, by default prometheus.DefBuckets.
+//
+//	m := metrics.New(nil).Buckets("", prometheus.ExponentialBuckets(0.001, 2, 10)...).Buckets("/reports", 1, 2.5, 5, 10, 30)
+func (m *Metrics) Buckets(prefix string, buckets ...float64) *Metrics {
+	if prefix == "" {
+		m.buckets = buckets
+		return m
+	}
+	m.groups = append(m.groups, routeGroup{prefix: prefix, buckets: buckets})
+	sort.SliceStable(m.groups, func(i, j int) bool { return len(m.groups[i].prefix) > len(m.groups[j].prefix) })
+	return m
+}
+
+// NativeHistograms makes the duration histograms native (sparse) ones, besides the classic buckets.
+// Factor is the maximal growth of bucket boundaries, e.g. 1.1 for 10%.
+// Native histograms are exposed in protobuf format only, scraped by Prometheus if native histograms are enabled there.
+func (m *Metrics) NativeHistograms(factor float64) *Metrics {
+	m.nativeFactor = factor
+	return m
+}
+
+// OpenMetrics makes the handler serve OpenMetrics format, if requested by the scraper.
+// Exemplars are exposed in that format only.
+func (m *Metrics) OpenMetrics() *Metrics {
+	m.openMetrics = true
+	return m
+}
+
+// Runtime enables or disables runtime metrics. Enabled by default.
+//
+//   - Go runtime metrics, e.g. go_gc_duration_seconds, go_goroutines and go_memstats_heap_alloc_bytes.
+//   - Process metrics, e.g. process_cpu_seconds_total, process_resident_memory_bytes and process_open_fds.
+//   - go_build_info, having Go version, main module path and version labels.
+//
+// The Prometheus default registry has Go and process collectors registered already. Disabling removes those.
+func (m *Metrics) Runtime(enabled bool) *Metrics {
+	m.runtime = enabled
+	return m
+}
+
+func runtimeCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewBuildInfoCollector(),
+	}
+}
+
+// registerRuntime registers runtime collectors, unless registered already, or unregisters them if disabled.
+func (m *Metrics) registerRuntime() {
+	for _, c := range runtimeCollectors() {
+		if !m.runtime {
+			m.registerer.Unregister(c)
+			continue
+		}
+		if err := m.registerer.Register(c); err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			panic(err)
+		}
+	}
+}
+
+func (m *Metrics) histogramVec(buckets []float64) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests served.",
+		Buckets: buckets,
+	}
+	if m.nativeFactor > 1 {
+		opts.NativeHistogramBucketFactor = m.nativeFactor
+		opts.NativeHistogramMaxBucketNumber = 160
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return prometheus.NewHistogramVec(opts, labelNames)
+}
+
+func (m *Metrics) register() {
+	m.registerOnce.Do(func() {
+		m.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests served.",
+		}, labelNames)
+		m.inFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being served.",
+		}, []string{"method"})
+		m.duration = &durationHistograms{fallback: m.histogramVec(m.buckets)}
+		for _, g := range m.groups {
+			g.vec = m.histogramVec(g.buckets)
+			m.duration.groups = append(m.duration.groups, g)
+		}
+		m.registerer.MustRegister(m.requests, m.duration, m.inFlight)
+		m.registerRuntime()
+		m.handler = promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{EnableOpenMetrics: m.openMetrics})
+	})
+}
+
+type startCtxKeyType string
+
+const startCtxName = startCtxKeyType("restfulMetricsStart")
+
+// Pre is the Monitor pre function of the metrics.
+//
+//	server := restful.NewServer().Monitor(m.Pre, m.Post)
+func (m *Metrics) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	m.register()
+	m.inFlight.WithLabelValues(r.Method).Inc()
+	r = restful.WithRouteTemplate(r)
+	return r.WithContext(context.WithValue(r.Context(), startCtxName, time.Now()))
+}
+
+// Post is the Monitor post function of the metrics.
+// If the request has a sampled OpenTelemetry span, then its trace ID and span ID are attached as exemplar.
+func (m *Metrics) Post(w http.ResponseWriter, r *http.Request, statusCode int) {
+	m.inFlight.WithLabelValues(r.Method).Dec()
+
+	start, ok := r.Context().Value(startCtxName).(time.Time)
+	if !ok {
+		return
+	}
+	route := restful.RouteTemplate(r)
+	labels := []string{r.Method, route, statusClass(statusCode)}
+	duration := time.Since(start).Seconds()
+	if exemplar := exemplarLabels(r.Context()); exemplar != nil {
+		m.requests.WithLabelValues(labels...).(prometheus.ExemplarAdder).AddWithExemplar(1, exemplar)
+		m.duration.vec(route).WithLabelValues(labels...).(prometheus.ExemplarObserver).ObserveWithExemplar(duration, exemplar)
+		return
+	}
+	m.requests.WithLabelValues(labels...).Inc()
+	m.duration.vec(route).WithLabelValues(labels...).Observe(duration)
+}
+
+// Monitor wraps the handler, collecting the metrics of the requests served.
+func (m *Metrics) Monitor(h http.Handler) http.Handler {
+	return restful.Monitor(h, m.Pre, m.Post)
+}
+
+// Handler returns a handler serving the metrics of the registry, e.g. at Path.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.register()
+		m.handler.ServeHTTP(w, r)
+	})
+}
+
+func exemplarLabels(ctx context.Context) prometheus.Labels {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": spanCtx.TraceID().String(), "span_id": spanCtx.SpanID().String()}
+}
+
+func statusClass(statusCode int) string {
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+// durationHistograms is a collector of duration histograms of the route groups, each having its own buckets.
+// Routes belong to a single group, so label values of the histograms do not collide.
+type durationHistograms struct {
+	groups   []routeGroup
+	fallback *prometheus.HistogramVec
+}
+
+// Describe sends the descriptor of the histograms. That is the same for all groups.
+func (d *durationHistograms) Describe(ch chan<- *prometheus.Desc) {
+	d.fallback.Describe(ch)
+}
+
+// Collect sends the histograms of all groups.
+func (d *durationHistograms) Collect(ch chan<- prometheus.Metric) {
+	d.fallback.Collect(ch)
+	for _, g := range d.groups {
+		g.vec.Collect(ch)
+	}
+}
+
+func (d *durationHistograms) vec(route string) *prometheus.HistogramVec {
+	for _, g := range d.groups {
+		if strings.HasPrefix(route, g.prefix) {
+			return g.vec
+		}
+	}
+	return d.fallback
+}
+
+var (
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
+)
+
+// Default returns the metrics registered at the Prometheus default registry, created on first use.
+// May be configured before serving the first request.
+func Default() *Metrics {
+	defaultMetricsOnce.Do(func() { defaultMetrics = New(nil) })
+	return defaultMetrics
+}
+
+// Monitor wraps the handler, collecting the default metrics of the requests served.
+//
+//	router := restful.NewRouter()
+//	router.Handle(metrics.Path, metrics.Handler())
+//	restful.NewServer().Addr(":8080").Handler(metrics.Monitor(router)).ListenAndServe()
+func Monitor(h http.Handler) http.Handler {
+	return Default().Monitor(h)
+}
+
+// Handler returns a handler serving the metrics of the Prometheus default registry, including the default metrics.
+func Handler() http.Handler {
+	return Default().Handler()
+}
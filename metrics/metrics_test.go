@@ -0,0 +1,126 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	m := New(prometheus.NewRegistry())
+
+	router := restful.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(1.0, testutil.ToFloat64(m.inFlight.WithLabelValues(http.MethodGet)))
+		if r.URL.Path == "/users/0" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	router.Handle(Path, m.Handler())
+	h := m.Monitor(router)
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/nothing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/users/{id}", "2xx")))
+	assert.Equal(1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/users/{id}", "4xx")))
+	assert.Equal(1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "", "4xx")))
+	assert.Equal(0.0, testutil.ToFloat64(m.inFlight.WithLabelValues(http.MethodGet)))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+	body, _ := io.ReadAll(w.Result().Body)
+	assert.Contains(string(body), `http_request_duration_seconds_count{method="GET",route="/users/{id}",status="2xx"} 2`)
+	assert.Contains(string(body), "http_requests_in_flight")
+}
+
+func TestDefault(t *testing.T) {
+	assert := assert.New(t)
+	assert.Same(Default(), Default())
+
+	w := httptest.NewRecorder()
+	Monitor(Handler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), "go_goroutines")
+}
+
+func TestBucketsNativeExemplars(t *testing.T) {
+	assert := assert.New(t)
+	reg := prometheus.NewRegistry()
+	m := New(reg).Buckets("", 0.001, 0.01).Buckets("/reports", 1, 10).NativeHistograms(1.1).OpenMetrics()
+
+	router := restful.NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("/reports/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Handle(Path, m.Handler())
+	h := m.Monitor(router)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled})
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(trace.ContextWithSpanContext(req.Context(), spanCtx)))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/reports/1", nil))
+
+	families, err := reg.Gather()
+	assert.NoError(err)
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		if assert.Len(family.Metric, 2) {
+			for _, metric := range family.Metric {
+				histogram := metric.GetHistogram()
+				assert.Len(histogram.Bucket, 2)
+				assert.NotNil(histogram.Schema) // Native.
+				for _, label := range metric.Label {
+					if label.GetName() == "route" && label.GetValue() == "/reports/{id}" {
+						assert.Equal(10.0, histogram.Bucket[1].GetUpperBound())
+					}
+				}
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, Path, nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	h.ServeHTTP(w, req)
+	assert.Contains(w.Header().Get("Content-Type"), "application/openmetrics-text")
+	assert.Regexp(`http_requests_total\{.*route="/users/\{id\}".*\} 1.0 # \{(trace_id="01000000000000000000000000000000",span_id="0200000000000000"|span_id="0200000000000000",trace_id="01000000000000000000000000000000")\} 1.0`, w.Body.String()) // Label order varies.
+}
+
+func TestRuntime(t *testing.T) {
+	assert := assert.New(t)
+	gather := func(m *Metrics) string {
+		w := httptest.NewRecorder()
+		m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+		return w.Body.String()
+	}
+
+	body := gather(New(prometheus.NewRegistry()))
+	assert.Contains(body, "go_goroutines")
+	assert.Contains(body, "go_gc_duration_seconds")
+	assert.Contains(body, "go_memstats_heap_alloc_bytes")
+	assert.Contains(body, "go_build_info")
+	assert.Contains(body, "process_start_time_seconds")
+
+	body = gather(New(prometheus.NewRegistry()).Runtime(false))
+	assert.NotContains(body, "go_goroutines")
+	assert.NotContains(body, "go_build_info")
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector())
+	assert.NotPanics(func() { gather(New(reg)) }) // Registered already.
+}
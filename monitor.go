@@ -5,12 +5,18 @@
 package restful
 
 import (
+	"bufio"
+	"io"
+	"net"
 	"net/http"
+	"time"
 )
 
 type monitor struct {
-	pre  MonitorFuncPre
-	post MonitorFuncPost
+	pre          MonitorFuncPre
+	post         MonitorFuncPost
+	postResponse MonitorFuncPostResponse
+	wrapper      func(http.Handler) http.Handler // Used instead of pre and post, if set.
 }
 
 type monitors []monitor
@@ -19,15 +25,30 @@ func (m *monitors) append(pre MonitorFuncPre, post MonitorFuncPost) {
 	*m = append(*m, monitor{pre: pre, post: post})
 }
 
+func (m *monitors) appendResponse(pre MonitorFuncPre, post MonitorFuncPostResponse) {
+	*m = append(*m, monitor{pre: pre, postResponse: post})
+}
+
+// appendWrapper appends a middleware that cannot be expressed by pre and post functions, e.g. running the handler in a goroutine.
+func (m *monitors) appendWrapper(wrapper func(http.Handler) http.Handler) {
+	*m = append(*m, monitor{wrapper: wrapper})
+}
+
 func (m monitors) wrap(h http.Handler) (monitored http.Handler) {
 	monitored = h
 	for _, monitor := range m {
-		monitored = monitorHandler{origHandler: monitored, pre: monitor.pre, post: monitor.post}
+		if monitor.wrapper != nil {
+			monitored = monitor.wrapper(monitored)
+		} else {
+			monitored = monitorHandler{origHandler: monitored, pre: monitor.pre, post: monitor.post, postResponse: monitor.postResponse}
+		}
 	}
 	return
 }
 
 // MonitorFuncPost is a type of user defined function to be called after the request was served.
+// It is called even if the handler panics, the panic going on afterwards, so that resources acquired by the pre function can be released.
+// The status code is 200 if the handler wrote body only, and 0 if it wrote nothing.
 // Handle ResponseWriter with care.
 type MonitorFuncPost func(w http.ResponseWriter, r *http.Request, statusCode int)
 
@@ -36,46 +57,101 @@ type MonitorFuncPost func(w http.ResponseWriter, r *http.Request, statusCode int
 // Pre may modify the request, especially its context, and return the modified request, or nil if not modified.
 type MonitorFuncPre func(w http.ResponseWriter, r *http.Request) *http.Request
 
-type monitorWriter struct {
-	writer     http.ResponseWriter
-	statusCode *int
+// MonitoredResponse is the response observed by a monitor.
+type MonitoredResponse struct {
+	StatusCode int           // Status code sent. 200 if the handler wrote nothing or body only.
+	Bytes      int64         // Number of body bytes written.
+	Latency    time.Duration // Time from the start of the pre function till the handler returned.
+}
+
+// MonitorFuncPostResponse is a type of user defined function to be called after the request was served, observing the response.
+// See MonitorWithResponse.
+type MonitorFuncPostResponse func(w http.ResponseWriter, r *http.Request, resp MonitoredResponse)
+
+// responseWriter wraps http.ResponseWriter, recording the status code and the number of body bytes written.
+// Flush, Hijack and ReadFrom are passed through, and http.ResponseController can reach the original writer by Unwrap.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
 }
 
-// Header returns the header map to be written.
-func (w monitorWriter) Header() http.Header {
-	return w.writer.Header()
+// WriteHeader sends HTTP status code.
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
 }
 
 // Write writes supplied bytes to HTTP response.
-func (w monitorWriter) Write(b []byte) (int, error) {
-	return w.writer.Write(b)
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
 }
 
-// WriteHeader sends HTTP status code.
-func (w monitorWriter) WriteHeader(statusCode int) {
-	*w.statusCode = statusCode
-	w.writer.WriteHeader(statusCode)
+// ReadFrom copies the reader to the response, using the original writer's ReadFrom if any, e.g. sendfile.
+func (w *responseWriter) ReadFrom(src io.Reader) (n int64, err error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, src)
+	}
+	w.bytes += n
+	return n, err
 }
 
 // Flush sends any buffered data to the client.
-func (w monitorWriter) Flush() {
-	if f, ok := w.writer.(http.Flusher); ok {
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
 		f.Flush()
 	}
 }
 
+// Hijack lets the caller take over the connection, e.g. for WebSocket.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		if w.status == 0 {
+			w.status = http.StatusSwitchingProtocols
+		}
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap returns the original writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
 type monitorHandler struct {
-	origHandler http.Handler
-	pre         MonitorFuncPre
-	post        MonitorFuncPost
+	origHandler  http.Handler
+	pre          MonitorFuncPre
+	post         MonitorFuncPost
+	postResponse MonitorFuncPostResponse
 }
 
 // ServeHTTP serves HTTP request.
 func (c monitorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 	if c.pre != nil {
-		var statusCode int
-		newR := c.pre(monitorWriter{writer: w, statusCode: &statusCode}, r)
-		if statusCode != 0 { // Do not process any further.
+		pw := &responseWriter{ResponseWriter: w}
+		newR := c.pre(pw, r)
+		if pw.status != 0 { // Do not process any further.
 			return
 		}
 		if newR != nil {
@@ -83,11 +159,20 @@ func (c monitorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var statusCode int
-	c.origHandler.ServeHTTP(monitorWriter{writer: w, statusCode: &statusCode}, r)
+	rw := &responseWriter{ResponseWriter: w}
+	if c.post != nil || c.postResponse != nil {
+		defer c.postServe(w, r, rw, start)
+	}
+	c.origHandler.ServeHTTP(rw, r)
+}
 
+// postServe calls the post functions. Deferred, so that those are called even if the handler panics.
+func (c monitorHandler) postServe(w http.ResponseWriter, r *http.Request, rw *responseWriter, start time.Time) {
 	if c.post != nil {
-		c.post(w, r, statusCode)
+		c.post(w, r, rw.status)
+	}
+	if c.postResponse != nil {
+		c.postResponse(w, r, MonitoredResponse{StatusCode: rw.statusCode(), Bytes: rw.bytes, Latency: time.Since(start)})
 	}
 }
 
@@ -97,3 +182,13 @@ func (c monitorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func Monitor(h http.Handler, pre MonitorFuncPre, post MonitorFuncPost) http.Handler {
 	return monitorHandler{origHandler: h, pre: pre, post: post}
 }
+
+// MonitorWithResponse wraps handler function, as Monitor does, but the post function observes the response: status code, body size and latency.
+// The handler gets a writer passing Flush, Hijack and ReadFrom through, so streaming and WebSocket keep working.
+//
+//	h := restful.MonitorWithResponse(router, nil, func(w http.ResponseWriter, r *http.Request, resp restful.MonitoredResponse) {
+//	    log.Printf("%s %s %d %dB %v", r.Method, r.URL.Path, resp.StatusCode, resp.Bytes, resp.Latency)
+//	})
+func MonitorWithResponse(h http.Handler, pre MonitorFuncPre, post MonitorFuncPostResponse) http.Handler {
+	return monitorHandler{origHandler: h, pre: pre, postResponse: post}
+}
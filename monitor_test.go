@@ -1,9 +1,13 @@
 package restful
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -137,3 +141,93 @@ func TestMonitorsWithServer(t *testing.T) {
 	assert.Equal(8, preCount)
 	assert.Equal(8, postCount)
 }
+
+func TestMonitorPostStatusCode(t *testing.T) {
+	assert := assert.New(t)
+	var statusCodes []int
+	post := func(w http.ResponseWriter, r *http.Request, statusCode int) {
+		statusCodes = append(statusCodes, statusCode)
+	}
+
+	router := NewRouter().Monitor(nil, post)
+	router.HandleFunc("/body", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("hello")) })
+	router.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) })
+	router.HandleFunc("/nothing", func(w http.ResponseWriter, r *http.Request) {})
+	for _, path := range []string{"/body", "/status", "/nothing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal([]int{http.StatusOK, http.StatusAccepted, 0}, statusCodes) // Body only is 200, nothing written is 0.
+}
+
+func TestMonitorWithResponse(t *testing.T) {
+	assert := assert.New(t)
+	var resps []MonitoredResponse
+	post := func(w http.ResponseWriter, r *http.Request, resp MonitoredResponse) { resps = append(resps, resp) }
+
+	router := NewRouter().MonitorWithResponse(nil, post)
+	router.HandleFunc("/body", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		_, _ = w.Write([]byte("hello"))
+	})
+	router.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) })
+	router.HandleFunc("/copy", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, strings.NewReader("copied")) // ReadFrom
+	})
+	router.HandleFunc("/flush", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(http.NewResponseController(w).Flush())
+	})
+
+	for _, path := range []string{"/body", "/status", "/copy", "/flush"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if assert.Len(resps, 4) {
+		assert.Equal(MonitoredResponse{StatusCode: http.StatusOK, Bytes: 5, Latency: resps[0].Latency}, resps[0])
+		assert.GreaterOrEqual(resps[0].Latency, time.Millisecond)
+		assert.Equal(http.StatusAccepted, resps[1].StatusCode)
+		assert.Equal(int64(6), resps[2].Bytes)
+		assert.Equal(http.StatusOK, resps[3].StatusCode)
+	}
+
+	// Pre aborting
+	resps = nil
+	h := MonitorWithResponse(http.NotFoundHandler(), func(w http.ResponseWriter, r *http.Request) *http.Request {
+		_, _ = w.Write([]byte("denied"))
+		return nil
+	}, post)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal("denied", w.Body.String())
+	assert.Empty(resps)
+}
+
+func TestMonitorHijack(t *testing.T) {
+	assert := assert.New(t)
+	observed := make(chan MonitoredResponse, 1)
+	h := MonitorWithResponse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if !assert.NoError(err) {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\nhijacked")
+		_ = rw.Flush()
+	}), nil, func(w http.ResponseWriter, r *http.Request, resp MonitoredResponse) { observed <- resp })
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+	_, _ = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: x\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if assert.NoError(err) {
+		assert.Equal(http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	assert.Equal(http.StatusSwitchingProtocols, (<-observed).StatusCode)
+
+	// Not supported by the recorder.
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	_, _, err = rw.Hijack()
+	assert.ErrorIs(err, http.ErrNotSupported)
+}
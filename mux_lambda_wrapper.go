@@ -6,6 +6,7 @@ package restful
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"reflect"
 
@@ -78,7 +79,9 @@ func lambdaHandleRes2(l *lambda.Lambda, res []reflect.Value) (any, error) {
 		if res[0].IsNil() {
 			return nil, err
 		}
-		res[0] = res[0].Elem()
+		if !res[0].Type().Implements(readerType) { // E.g. *os.File is sent as is.
+			res[0] = res[0].Elem()
+		}
 	}
 	return res[0].Interface(), err
 }
@@ -97,6 +100,7 @@ func lambdaHandleRes(w http.ResponseWriter, r *http.Request, res []reflect.Value
 }
 
 var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+var readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
 
 func lambdaGetParams(w http.ResponseWriter, r *http.Request, f any) ([]reflect.Value, *http.Request, error) {
 	t := reflect.TypeOf(f)
@@ -129,16 +133,8 @@ func lambdaGetParams(w http.ResponseWriter, r *http.Request, f any) ([]reflect.V
 				return nil, r, err
 			}
 
-			if LambdaValidator && reflect.ValueOf(reqDataInterface).Elem().Kind() == reflect.Struct {
-				if err := Validate.Struct(reqDataInterface); err != nil {
-					if ValidateErrConverter != nil {
-						err = ValidateErrConverter(err)
-						if _, ok := err.(*restError); ok { // no need to wrap
-							return nil, r, err
-						}
-					}
-					return nil, r, NewError(err, LambdaValidationErrorStatus)
-				}
+			if err := validateRequestData(reqDataInterface); err != nil {
+				return nil, r, err
 			}
 
 			params[reqDataIdx] = reqData
@@ -147,6 +143,23 @@ func lambdaGetParams(w http.ResponseWriter, r *http.Request, f any) ([]reflect.V
 	return params, r, nil
 }
 
+// validateRequestData validates the struct pointed by data, if LambdaValidator is set.
+func validateRequestData(data any) error {
+	if !LambdaValidator || reflect.ValueOf(data).Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if err := Validate.Struct(data); err != nil {
+		if ValidateErrConverter != nil {
+			err = ValidateErrConverter(err)
+			if _, ok := err.(*restError); ok { // no need to wrap
+				return err
+			}
+		}
+		return NewError(err, LambdaValidationErrorStatus)
+	}
+	return nil
+}
+
 // LambdaWrap wraps a Lambda function and makes it a http.HandlerFunc.
 // This function is rarely needed, as restful's Router wraps handler functions automatically.
 // You might need it if you want to wrap a standard http.HandlerFunc.
@@ -8,16 +8,67 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Route ...
 type Route struct {
 	route    *mux.Route
 	monitors monitors
+	settings *routeSettings
 }
 
+// routeSettings are the settings of a route, set by methods of Route.
+type routeSettings struct {
+	lambda          any // Handler function, as given to HandleFunc.
+	summary         string
+	cors            *CORS
+	span            routeSpan
+	scopes          []string
+	securityHeaders *SecurityHeaders
+}
+
+// newRoute creates a route wrapper, sharing the settings of the route if it has a handler already.
 func newRoute(route *mux.Route, monitors monitors) *Route {
-	return &Route{route: route, monitors: monitors}
+	settings := routeSettingsOf(route)
+	if settings == nil {
+		settings = &routeSettings{}
+	}
+	return &Route{route: route, monitors: monitors, settings: settings}
+}
+
+// routeHandler is the handler of a mux route, keeping the settings of the route,
+// so that middlewares can look them up by the route matched, see routeSettingsOf.
+type routeHandler struct {
+	http.Handler
+	settings *routeSettings
+}
+
+// routeSettingsOf returns the settings of the route, or nil if the route has no handler set by restful.
+func routeSettingsOf(route *mux.Route) *routeSettings {
+	if route == nil {
+		return nil
+	}
+	if h, ok := route.GetHandler().(routeHandler); ok {
+		return h.settings
+	}
+	return nil
+}
+
+// setHandler sets the handler of the route, wrapped by the monitors.
+// Settings of the route, e.g. scopes required, are applied innermost, after the monitors, e.g. authentication.
+func (route *Route) setHandler(h http.Handler, monitors monitors) {
+	settings := route.settings
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if settings.securityHeaders != nil {
+			settings.securityHeaders.set(w, r)
+		}
+		if !checkRouteScopes(w, r, settings.scopes) {
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+	route.route = route.route.Handler(routeHandler{Handler: monitors.wrap(inner), settings: settings})
 }
 
 // GetError returns if building route failed.
@@ -28,14 +79,14 @@ func (route *Route) GetError() error {
 // Handler sets a handler for a route.
 // Note: Cannot use Lambda here. Router's Monitor does not apply here.
 func (route *Route) Handler(handler http.Handler) *Route {
-	route.route = route.route.Handler(handler)
+	route.setHandler(handler, nil)
 	return route
 }
 
 // HandlerFunc sets a handler function or lambda for a route.
 func (route *Route) HandlerFunc(f any) *Route {
-	wrapped := route.monitors.wrap(LambdaWrap(f))
-	route.route = route.route.Handler(wrapped)
+	route.setHandler(LambdaWrap(f), route.monitors)
+	route.settings.lambda = f
 	return route
 }
 
@@ -53,6 +104,52 @@ func (route *Route) Name(name string) *Route {
 	return route
 }
 
+// SpanName sets the name of the OpenTelemetry server spans of the route, overriding the span name formatter.
+// See SetSpanNameFormatter.
+func (route *Route) SpanName(name string) *Route {
+	route.settings.span.name = name
+	return route
+}
+
+// SpanAttributes adds attributes to the OpenTelemetry server spans of the route, e.g. API version.
+//
+//	r.HandleFunc("/v2/users", f).SpanAttributes(attribute.String("api.version", "v2"))
+func (route *Route) SpanAttributes(attrs ...attribute.KeyValue) *Route {
+	route.settings.span.attrs = append(route.settings.span.attrs, attrs...)
+	return route
+}
+
+// Sampling overrides the OpenTelemetry sampling of the requests served by the route.
+// Fraction 0 means never sampled, e.g. for noisy routes like "/metrics". 1 means always sampled.
+// Otherwise the fraction of traces to be sampled, based on the trace ID.
+// Probe paths, such as HealthCheckPath, are never sampled.
+// Applies with samplers created by restful. See tracer.NewOverridableSampler.
+//
+//	r.HandleFunc("/metrics", metrics).Sampling(0)
+func (route *Route) Sampling(fraction float64) *Route {
+	route.settings.span.sampling = &fraction
+	routeSamplingUsed.Store(true)
+	return route
+}
+
+// Scopes sets the scopes required by the route, all of them must be granted to the request.
+// Checked after authentication, e.g. by JWTAuth or TokenIntrospection, against the scopes of the claims.
+// Requests not authenticated are responded 401, requests missing a scope 403, indicating the scopes required.
+//
+//	r.HandleFunc("/orders", createOrder).Methods(http.MethodPost).Scopes("orders:write")
+func (route *Route) Scopes(scopes ...string) *Route {
+	route.settings.scopes = scopes
+	return route
+}
+
+// SecurityHeaders sets the security headers policy of the route, overriding the ones of the server or the router.
+//
+//	r.HandleFunc("/ui/", serveUI).SecurityHeaders(restful.NewSecurityHeaders().ContentSecurityPolicy("default-src 'self'").FrameOptions("SAMEORIGIN"))
+func (route *Route) SecurityHeaders(s *SecurityHeaders) *Route {
+	route.settings.securityHeaders = s
+	return route
+}
+
 // Path registers a new route with a matcher for the URL path template.
 //
 //	r.Path("/users/{id:[0-9]+}")
@@ -90,7 +187,7 @@ func (route *Route) Schemes(schemes ...string) *Route {
 //	s := r.PathPrefix("/api/v1/").Subrouter()
 //	s.HandleFunc("/users", handleAllUsers)
 //
-// Subrouter takes the existing Monitors of the parent route and apply them to the handle functions.
+// Subrouter takes the existing Monitors and CORS policy of the parent route and apply them to the handle functions.
 func (route *Route) Subrouter() *Router {
-	return &Router{router: route.route.Subrouter(), monitors: route.monitors}
+	return &Router{router: route.route.Subrouter(), monitors: route.monitors, cors: route.settings.cors}
 }
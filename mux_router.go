@@ -6,6 +6,7 @@ package restful
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -31,11 +32,14 @@ var (
 type Router struct {
 	router   *mux.Router
 	monitors monitors
+	cors     *CORS
 }
 
 // NewRouter creates new Router instance.
 func NewRouter() *Router {
-	return &Router{router: mux.NewRouter()}
+	router := mux.NewRouter()
+	router.Use(routeSpanAttributes)
+	return &Router{router: router}
 }
 
 // Monitor wraps handler function, creating a middleware in a safe and convenient fashion.
@@ -45,6 +49,12 @@ func (r *Router) Monitor(pre MonitorFuncPre, post MonitorFuncPost) *Router {
 	return r
 }
 
+// MonitorWithResponse adds pre and post functions, as Monitor does, but the post function observes the response: status code, body size and latency.
+func (r *Router) MonitorWithResponse(pre MonitorFuncPre, post MonitorFuncPostResponse) *Router {
+	r.monitors.appendResponse(pre, post)
+	return r
+}
+
 // DisallowUnknownFields instructs JSON decoder to fail if unknown field in found in the received message.
 // By default unknown fields are ignored.
 // See also JSON schema and OpenAPI Specification `additionalProperties: false`.
@@ -52,18 +62,41 @@ func (r *Router) DisallowUnknownFields() *Router {
 	return r.Monitor(disallowUnknownFieldsToCtx, nil)
 }
 
+// TraceResponseHeader instructs the router to echo the trace ID of the request back to the caller in a response header,
+// so that users can quote that in bug reports. E.g. "X-Trace-Id".
+// If the header is TraceResponseW3C, then the value is formatted according to W3C Trace Context Level 2 traceresponse.
+// If no trace was received, the one generated is used at handlers, as well.
+// Applies to the handlers added after calling this function, as Monitor does.
+//
+//	r := restful.NewRouter().TraceResponseHeader("X-Trace-Id")
+func (r *Router) TraceResponseHeader(header string) *Router {
+	return r.Monitor(traceResponsePre(header), nil)
+}
+
 // HandleFunc assigns an HTTP path to a function.
 // The function can be compatible with type http.HandlerFunc or a restful's Lambda.
 // E.g. r.HandleFunc("/users/{id:[0-9]+}", myFunc)
 func (r *Router) HandleFunc(path string, f any) *Route {
-	return r.Handle(path, LambdaWrap(f))
+	route := r.Handle(path, LambdaWrap(f))
+	route.settings.lambda = f
+	return route
 }
 
 // Handle adds traditional http.Handler to route.
 // Cannot use Lambda here.
 func (r *Router) Handle(path string, handler http.Handler) *Route {
-	wrapped := r.monitors.wrap(handler)
-	return newRoute(r.router.Handle(path, wrapped), nil)
+	route := r.newRoute(r.router.Path(path), nil)
+	route.setHandler(handler, r.monitors)
+	return route
+}
+
+// newRoute creates a route of the router, having the CORS policy of the router.
+func (r *Router) newRoute(route *mux.Route, monitors monitors) *Route {
+	wrapper := newRoute(route, monitors)
+	if r.cors != nil {
+		wrapper.settings.cors = r.cors
+	}
+	return wrapper
 }
 
 // Get returns the route registered with the given name, or nil.
@@ -74,30 +107,30 @@ func (r *Router) Get(name string) *Route {
 // Host registers a new route with a matcher for the URL host regex.
 // E.g. r.Host("{subdomain:[a-z]+}.example.com")
 func (r *Router) Host(hostRegex string) *Route {
-	return newRoute(r.router.Host(hostRegex), r.monitors)
+	return r.newRoute(r.router.Host(hostRegex), r.monitors)
 }
 
 // Methods registers a new route with a matcher for HTTP methods.
 // E.g. r.Methods(http.MethodPost, http.MethodPut)
 func (r *Router) Methods(methods ...string) *Route {
-	return newRoute(r.router.Methods(methods...), r.monitors)
+	return r.newRoute(r.router.Methods(methods...), r.monitors)
 }
 
 // Name registers a new route with a name.
 // That name can be used to query route.
 func (r *Router) Name(name string) *Route {
-	return newRoute(r.router.Name(name), r.monitors)
+	return r.newRoute(r.router.Name(name), r.monitors)
 }
 
 // Path registers a new route with a matcher for the URL path template.
 // E.g. r.Path("/users/{id:[0-9]+}")
 func (r *Router) Path(pathTemplate string) *Route {
-	return newRoute(r.router.Path(pathTemplate), r.monitors)
+	return r.newRoute(r.router.Path(pathTemplate), r.monitors)
 }
 
 // PathPrefix registers a new route with a matcher for the URL path template prefix.
 func (r *Router) PathPrefix(pathTemplate string) *Route {
-	return newRoute(r.router.PathPrefix(pathTemplate), r.monitors)
+	return r.newRoute(r.router.PathPrefix(pathTemplate), r.monitors)
 }
 
 // Queries registers a new route with a matcher for URL query values.
@@ -107,12 +140,12 @@ func (r *Router) PathPrefix(pathTemplate string) *Route {
 // The odd (1st, 3rd, etc) string is the query parameter.
 // The even (2nd, 4th, etc) string is the variable name and optional regex pattern.
 func (r *Router) Queries(pairs ...string) *Route {
-	return newRoute(r.router.Queries(pairs...), r.monitors)
+	return r.newRoute(r.router.Queries(pairs...), r.monitors)
 }
 
 // Schemes registers a new route with a matcher for URL schemes.
 func (r *Router) Schemes(schemes ...string) *Route {
-	return newRoute(r.router.Schemes(schemes...), r.monitors)
+	return r.newRoute(r.router.Schemes(schemes...), r.monitors)
 }
 
 // Start starts router on port 8080 (AddrHTTP).
@@ -162,7 +195,39 @@ func (r *Router) ListenAndServeMTLS(addr, certFile, keyFile, clientCerts string,
 	return ListenAndServeMTLS(addr, certFile, keyFile, clientCerts, loadSystemCerts, r)
 }
 
+// RouteInfo describes a route of a Router.
+type RouteInfo struct {
+	Name    string   `json:"name,omitempty"`
+	Host    string   `json:"host,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Prefix  bool     `json:"prefix,omitempty"` // Path is a prefix, see PathPrefix.
+	Methods []string `json:"methods,omitempty"`
+	Handler bool     `json:"handler"` // Route has a handler, i.e. not a subrouter.
+}
+
+// Routes returns the routes of the router, in the order of matching, including those of subrouters.
+// Useful for debugging, e.g. served on an admin port.
+func (r *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	_ = r.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		info := RouteInfo{Name: route.GetName(), Handler: route.GetHandler() != nil}
+		info.Host, _ = route.GetHostTemplate()
+		info.Path, _ = route.GetPathTemplate()
+		if re, err := route.GetPathRegexp(); err == nil {
+			info.Prefix = !strings.HasSuffix(re, "$")
+		}
+		info.Methods, _ = route.GetMethods()
+		routes = append(routes, info)
+		return nil
+	})
+	return routes
+}
+
 // ServeHTTP serves HTTP request with matching handler.
+// Applies CORS policies of the routes, if any.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if corsUsed.Load() && serveCORS(w, req, r.router, nil) {
+		return
+	}
 	r.router.ServeHTTP(w, req)
 }
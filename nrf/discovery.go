@@ -0,0 +1,54 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package nrf
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/nokia/restful"
+)
+
+// SearchResult is the result of an NF discovery.
+type SearchResult struct {
+	ValidityPeriod int         `json:"validityPeriod,omitempty"`
+	NFInstances    []NFProfile `json:"nfInstances"`
+}
+
+// Discovery sends NF discovery queries to the NRF (Nnrf_NFDiscovery).
+type Discovery struct {
+	client        *restful.Client
+	apiRoot       string
+	requesterType string
+}
+
+// NewDiscovery creates an NF discovery client for the NRF of the given apiRoot.
+// Requester NF type is sent in each query. If client is nil, a new default client is used.
+func NewDiscovery(client *restful.Client, apiRoot, requesterNFType string) *Discovery {
+	if client == nil {
+		client = restful.NewClient()
+	}
+	return &Discovery{client: client, apiRoot: strings.TrimSuffix(apiRoot, "/"), requesterType: requesterNFType}
+}
+
+// Search queries the NF instances of the target NF type.
+// Further query parameters, e.g. "service-names", can be given in query, which may be nil.
+//
+//	result, err := d.Search(ctx, "UDM", url.Values{"service-names": {"nudm-sdm"}})
+func (d *Discovery) Search(ctx context.Context, targetNFType string, query url.Values) (*SearchResult, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("target-nf-type", targetNFType)
+	q.Set("requester-nf-type", d.requesterType)
+
+	var result SearchResult
+	if err := d.client.Get(ctx, d.apiRoot+"/nnrf-disc/v1/nf-instances?"+q.Encode(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
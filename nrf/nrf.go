@@ -0,0 +1,264 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package nrf implements the NF side of 3GPP Network Repository Function services (TS 29.510).
+// NF registration with heartbeat (Nnrf_NFManagement) and NF discovery (Nnrf_NFDiscovery) are supported.
+package nrf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/nokia/restful/logging"
+)
+
+// NF status values.
+const (
+	StatusRegistered     = "REGISTERED"
+	StatusSuspended      = "SUSPENDED"
+	StatusUndiscoverable = "UNDISCOVERABLE"
+)
+
+// DefaultHeartBeatTimer is the heartbeat period used if neither the profile nor the NRF sets one.
+var DefaultHeartBeatTimer = 30 * time.Second
+
+// RetryInterval is the wait time after a failed registration before trying again.
+var RetryInterval = 5 * time.Second
+
+var (
+	// ErrNotRegistered is returned if the NF is not registered at the NRF.
+	ErrNotRegistered = errors.New("not registered at NRF")
+	// ErrStarted is returned by Start if the background loop is running already.
+	ErrStarted = errors.New("NRF registration started already")
+)
+
+// NFService is an NF service instance of an NF profile. Only the commonly used fields are listed.
+type NFService struct {
+	ServiceInstanceID string             `json:"serviceInstanceId"`
+	ServiceName       string             `json:"serviceName"`
+	Versions          []NFServiceVersion `json:"versions"`
+	Scheme            string             `json:"scheme"`
+	NFServiceStatus   string             `json:"nfServiceStatus"`
+	FQDN              string             `json:"fqdn,omitempty"`
+	APIPrefix         string             `json:"apiPrefix,omitempty"`
+	IPEndPoints       []IPEndPoint       `json:"ipEndPoints,omitempty"`
+	Priority          int                `json:"priority,omitempty"`
+	Capacity          int                `json:"capacity,omitempty"`
+}
+
+// NFServiceVersion is the API version of an NF service.
+type NFServiceVersion struct {
+	APIVersionInURI string `json:"apiVersionInUri"`
+	APIFullVersion  string `json:"apiFullVersion"`
+}
+
+// IPEndPoint is an IP address and port of an NF service.
+type IPEndPoint struct {
+	IPv4Address string `json:"ipv4Address,omitempty"`
+	IPv6Address string `json:"ipv6Address,omitempty"`
+	Transport   string `json:"transport,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+// NFProfile is the profile of an NF instance. Only the commonly used fields are listed.
+// Further fields, e.g. NF type specific info, can be added to Extra.
+type NFProfile struct {
+	NFInstanceID   string         `json:"nfInstanceId"`
+	NFType         string         `json:"nfType"`
+	NFStatus       string         `json:"nfStatus"`
+	HeartBeatTimer int            `json:"heartBeatTimer,omitempty"`
+	PLMNList       []PLMNID       `json:"plmnList,omitempty"`
+	SNssais        []Snssai       `json:"sNssais,omitempty"`
+	NFSetIDList    []string       `json:"nfSetIdList,omitempty"`
+	FQDN           string         `json:"fqdn,omitempty"`
+	IPv4Addresses  []string       `json:"ipv4Addresses,omitempty"`
+	IPv6Addresses  []string       `json:"ipv6Addresses,omitempty"`
+	Priority       int            `json:"priority,omitempty"`
+	Capacity       int            `json:"capacity,omitempty"`
+	Load           int            `json:"load,omitempty"`
+	Locality       string         `json:"locality,omitempty"`
+	NFServices     []NFService    `json:"nfServices,omitempty"`
+	Extra          map[string]any `json:"-"`
+}
+
+// PLMNID is a PLMN identifier.
+type PLMNID struct {
+	MCC string `json:"mcc"`
+	MNC string `json:"mnc"`
+}
+
+// Snssai is a network slice identifier.
+type Snssai struct {
+	SST int    `json:"sst"`
+	SD  string `json:"sd,omitempty"`
+}
+
+// Registration keeps an NF instance registered at the NRF.
+type Registration struct {
+	client  *restful.Client
+	apiRoot string
+	id      string
+
+	mutex      sync.Mutex
+	profile    NFProfile
+	registered bool
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// NewRegistration creates a registration of the NF profile at the NRF of the given apiRoot, e.g. "https://nrf.5gc.mnc012.mcc345.3gppnetwork.org".
+// If client is nil, a new default client is used.
+func NewRegistration(client *restful.Client, apiRoot string, profile NFProfile) *Registration {
+	if client == nil {
+		client = restful.NewClient()
+	}
+	if profile.NFStatus == "" {
+		profile.NFStatus = StatusRegistered
+	}
+	return &Registration{client: client, apiRoot: strings.TrimSuffix(apiRoot, "/"), id: profile.NFInstanceID, profile: profile}
+}
+
+// Profile returns the NF profile, as accepted by the NRF.
+func (r *Registration) Profile() NFProfile {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.profile
+}
+
+// Registered tells if the NF is registered at the NRF.
+func (r *Registration) Registered() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.registered
+}
+
+func (r *Registration) instanceURL() string {
+	return r.apiRoot + "/nnrf-nfm/v1/nf-instances/" + url.PathEscape(r.id)
+}
+
+func (r *Registration) heartBeatTimer() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.profile.HeartBeatTimer > 0 {
+		return time.Duration(r.profile.HeartBeatTimer) * time.Second
+	}
+	return DefaultHeartBeatTimer
+}
+
+// Register registers the NF profile at the NRF (NFRegister).
+// The NRF may change the profile, e.g. the heartbeat timer. See Profile.
+func (r *Registration) Register(ctx context.Context) error {
+	r.mutex.Lock()
+	profile := r.profile
+	r.mutex.Unlock()
+
+	var accepted NFProfile
+	_, err := r.client.Put(ctx, r.instanceURL(), &profile, &accepted)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.registered = err == nil
+	if err == nil && accepted.NFInstanceID != "" {
+		r.profile = accepted
+	}
+	return err
+}
+
+// Heartbeat sends a heartbeat to the NRF (NFUpdate with status).
+// Returns ErrNotRegistered if the NRF does not know the NF instance.
+func (r *Registration) Heartbeat(ctx context.Context) error {
+	r.mutex.Lock()
+	status := r.profile.NFStatus
+	r.mutex.Unlock()
+
+	patch := []map[string]any{{"op": "replace", "path": "/nfStatus", "value": status}}
+	err := r.client.Patch(ctx, r.instanceURL(), patch, nil)
+	if restful.GetErrStatusCodeElse(err, 0) == http.StatusNotFound {
+		err = errors.Join(ErrNotRegistered, err)
+	}
+	if err != nil {
+		r.mutex.Lock()
+		r.registered = false
+		r.mutex.Unlock()
+	}
+	return err
+}
+
+// Deregister deregisters the NF at the NRF (NFDeregister).
+func (r *Registration) Deregister(ctx context.Context) error {
+	err := r.client.Delete(ctx, r.instanceURL())
+	if err == nil || restful.GetErrStatusCodeElse(err, 0) == http.StatusNotFound {
+		r.mutex.Lock()
+		r.registered = false
+		r.mutex.Unlock()
+		return nil
+	}
+	return err
+}
+
+// Start registers the NF and keeps it registered in the background.
+// Sends heartbeats, and re-registers if a heartbeat fails.
+// The loop ends when ctx is done or Stop is called. Returns ErrStarted if the loop is running already.
+func (r *Registration) Start(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.done != nil {
+		select {
+		case <-r.done:
+			r.cancel()
+		default:
+			return ErrStarted
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel, r.done = cancel, make(chan struct{})
+	go r.run(ctx, r.done)
+	return nil
+}
+
+func (r *Registration) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		var wait time.Duration
+		if !r.Registered() {
+			if err := r.Register(ctx); err != nil {
+				logging.Module("nrf").Error("NRF registration failed", "nf_instance_id", r.id, "error", err)
+				wait = RetryInterval
+			} else {
+				wait = r.heartBeatTimer()
+			}
+		} else if err := r.Heartbeat(ctx); err != nil {
+			logging.Module("nrf").Warn("NRF heartbeat failed", "nf_instance_id", r.id, "error", err)
+			continue // Re-register at once.
+		} else {
+			wait = r.heartBeatTimer()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Stop stops the background heartbeat loop and deregisters the NF.
+func (r *Registration) Stop(ctx context.Context) error {
+	r.mutex.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mutex.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return r.Deregister(ctx)
+}
@@ -0,0 +1,127 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package nrf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNRF struct {
+	mutex      sync.Mutex
+	profiles   map[string]NFProfile
+	heartbeats int
+}
+
+func (f *fakeNRF) router() *restful.Router {
+	r := restful.NewRouter()
+	r.Methods(http.MethodPut).Path("/nnrf-nfm/v1/nf-instances/{id}").HandlerFunc(func(ctx context.Context, profile NFProfile) (NFProfile, error) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		profile.HeartBeatTimer = 1
+		f.profiles[restful.L(ctx).RequestVars()["id"]] = profile
+		restful.L(ctx).ResponseStatus(http.StatusCreated)
+		return profile, nil
+	})
+	r.Methods(http.MethodPatch).Path("/nnrf-nfm/v1/nf-instances/{id}").HandlerFunc(func(ctx context.Context, patch []map[string]any) error {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		if _, ok := f.profiles[restful.L(ctx).RequestVars()["id"]]; !ok {
+			return restful.NewError(nil, http.StatusNotFound)
+		}
+		f.heartbeats++
+		return nil
+	})
+	r.Methods(http.MethodDelete).Path("/nnrf-nfm/v1/nf-instances/{id}").HandlerFunc(func(ctx context.Context) error {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		delete(f.profiles, restful.L(ctx).RequestVars()["id"])
+		return nil
+	})
+	r.Methods(http.MethodGet).Path("/nnrf-disc/v1/nf-instances").Queries("target-nf-type", "{type}").HandlerFunc(func(ctx context.Context) (*SearchResult, error) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		result := &SearchResult{NFInstances: []NFProfile{}}
+		for _, p := range f.profiles {
+			if p.NFType == restful.L(ctx).RequestVars()["type"] {
+				result.NFInstances = append(result.NFInstances, p)
+			}
+		}
+		return result, nil
+	})
+	return r
+}
+
+func (f *fakeNRF) forget() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.profiles = map[string]NFProfile{}
+}
+
+func (f *fakeNRF) count() (profiles, heartbeats int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.profiles), f.heartbeats
+}
+
+func TestRegistration(t *testing.T) {
+	assert := assert.New(t)
+	nrf := &fakeNRF{profiles: map[string]NFProfile{}}
+	srv := httptest.NewServer(nrf.router())
+	defer srv.Close()
+
+	profile := NFProfile{NFInstanceID: "54804518-4191-46b3-955c-ac631f953ed8", NFType: "UDM", Extra: map[string]any{"udmInfo": map[string]any{"groupId": "g1"}}}
+	reg := NewRegistration(nil, srv.URL, profile)
+	assert.NoError(reg.Start(context.Background()))
+	assert.Equal(ErrStarted, reg.Start(context.Background()))
+
+	assert.Eventually(func() bool { _, hb := nrf.count(); return hb >= 1 }, 3*time.Second, 10*time.Millisecond)
+	assert.True(reg.Registered())
+	assert.Equal(1, reg.Profile().HeartBeatTimer)
+	assert.Equal("g1", reg.Profile().Extra["udmInfo"].(map[string]any)["groupId"])
+
+	// NRF restarted, lost the registration.
+	nrf.forget()
+	assert.Eventually(func() bool { n, _ := nrf.count(); return n == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	result, err := NewDiscovery(nil, srv.URL, "AMF").Search(context.Background(), "UDM", nil)
+	assert.NoError(err)
+	if assert.Len(result.NFInstances, 1) {
+		assert.Equal(profile.NFInstanceID, result.NFInstances[0].NFInstanceID)
+	}
+
+	assert.NoError(reg.Stop(context.Background()))
+	assert.False(reg.Registered())
+	n, _ := nrf.count()
+	assert.Zero(n)
+
+	// Started again after stopped, and after its context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(reg.Start(ctx))
+	assert.Eventually(func() bool { n, _ := nrf.count(); return n == 1 }, 3*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Eventually(func() bool { return reg.Start(context.Background()) == nil }, 3*time.Second, 10*time.Millisecond)
+	assert.NoError(reg.Stop(context.Background()))
+}
+
+func TestProfileJSON(t *testing.T) {
+	assert := assert.New(t)
+	b, err := json.Marshal(NFProfile{NFInstanceID: "id", NFType: "AUSF", NFStatus: StatusRegistered, Extra: map[string]any{"nfType": "bad", "ausfInfo": map[string]any{}}})
+	assert.NoError(err)
+	assert.JSONEq(`{"nfInstanceId":"id","nfType":"AUSF","nfStatus":"REGISTERED","ausfInfo":{}}`, string(b))
+
+	var p NFProfile
+	assert.NoError(json.Unmarshal(b, &p))
+	assert.Equal("AUSF", p.NFType)
+	assert.Equal(map[string]any{"ausfInfo": map[string]any{}}, p.Extra)
+}
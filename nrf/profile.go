@@ -0,0 +1,63 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package nrf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+type nfProfileFields NFProfile // Without methods, to avoid recursion.
+
+var nfProfileKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(NFProfile{})
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// MarshalJSON marshals the profile, including the Extra fields.
+func (p NFProfile) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(nfProfileFields(p))
+	if err != nil || len(p.Extra) == 0 {
+		return b, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extra {
+		if !nfProfileKeys[k] {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON unmarshals the profile. Unknown fields are stored in Extra.
+func (p *NFProfile) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*nfProfileFields)(p)); err != nil {
+		return err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	p.Extra = nil
+	for k, v := range m {
+		if !nfProfileKeys[k] {
+			if p.Extra == nil {
+				p.Extra = make(map[string]any)
+			}
+			p.Extra[k] = v
+		}
+	}
+	return nil
+}
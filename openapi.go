@@ -0,0 +1,400 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenAPIPath is the path the OpenAPI document is served at by Router's ServeOpenAPI.
+var OpenAPIPath = "/openapi.json"
+
+// OpenAPIInfo is the info object of the OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Summary sets the summary of the operation in the OpenAPI document, see Router's OpenAPI.
+func (route *Route) Summary(summary string) *Route {
+	route.settings.summary = summary
+	return route
+}
+
+// OpenAPI generates an OpenAPI 3.1 document of the routes of the router, including those of subrouters.
+// Paths are taken from route templates, path variables and queries becoming parameters.
+// Request and response schemas are reflected from the parameter and return types of Lambda functions, honoring json and validate tags.
+// Errors are described by the problem details model. Routes without methods are documented as GET, or POST if having request body.
+// Path prefix routes, e.g. serving static files, are not documented.
+// Useful for exporting the document at build time, e.g. by a test or a go:generate command writing it to a file.
+func (r *Router) OpenAPI(info OpenAPIInfo) ([]byte, error) {
+	return json.MarshalIndent(r.openAPI(info), "", "  ")
+}
+
+// ServeOpenAPI serves the OpenAPI document of the router at OpenAPIPath, see OpenAPI.
+// The document is generated on the first request, so routes added later are included, too.
+func (r *Router) ServeOpenAPI(info OpenAPIInfo) *Router {
+	document := sync.OnceValues(func() ([]byte, error) { return r.OpenAPI(info) })
+	r.Handle(OpenAPIPath, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := document()
+		if err != nil {
+			_ = SendProblemDetails(w, req, err)
+			return
+		}
+		w.Header().Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		_, _ = w.Write(body)
+	})).Methods(http.MethodGet)
+	return r
+}
+
+var pathVarRegexp = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]*(?:\{[^{}]*\}[^{}]*)*))?\}`)
+
+func (r *Router) openAPI(info OpenAPIInfo) map[string]any {
+	schemas := openAPISchemas{components: map[string]any{}, types: map[reflect.Type]string{}}
+	schemas.schema(reflect.TypeOf(ProblemDetails{}))
+	paths := map[string]any{}
+	_ = r.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil || tmpl == OpenAPIPath {
+			return nil
+		}
+		if re, err := route.GetPathRegexp(); err != nil || !strings.HasSuffix(re, "$") {
+			return nil // Prefix, e.g. static files.
+		}
+		path, params := openAPIParams(route, tmpl)
+		var f any
+		var summary string
+		if settings := routeSettingsOf(route); settings != nil {
+			f, summary = settings.lambda, settings.summary
+		}
+		op := schemas.operation(f)
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if summary != "" {
+			op["summary"] = summary
+		}
+		if name := route.GetName(); name != "" {
+			op["operationId"] = name
+		}
+
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		for _, method := range openAPIMethods(route, op) {
+			if _, ok := item[method]; !ok { // First matching route wins.
+				item[method] = op
+			}
+		}
+		return nil
+	})
+
+	return map[string]any{
+		"openapi":    "3.1.0",
+		"info":       info,
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas.components},
+	}
+}
+
+// openAPIParams returns the OpenAPI path of the route template, and the parameters of path variables and queries.
+func openAPIParams(route *mux.Route, tmpl string) (path string, params []any) {
+	path = pathVarRegexp.ReplaceAllStringFunc(tmpl, func(v string) string {
+		m := pathVarRegexp.FindStringSubmatch(v)
+		schema := map[string]any{"type": "string"}
+		if m[2] != "" {
+			schema["pattern"] = "^" + m[2] + "$"
+		}
+		params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": schema})
+		return "{" + m[1] + "}"
+	})
+	queries, _ := route.GetQueriesTemplates()
+	for _, query := range queries {
+		if name, _, ok := strings.Cut(query, "="); ok {
+			params = append(params, map[string]any{"name": name, "in": "query", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+	}
+	return
+}
+
+// openAPIMethods returns the lowercase methods of the route. GET, or POST if the operation has request body, if the route has no methods.
+func openAPIMethods(route *mux.Route, op map[string]any) []string {
+	methods, _ := route.GetMethods()
+	if len(methods) == 0 {
+		if _, ok := op["requestBody"]; ok {
+			return []string{"post"}
+		}
+		return []string{"get"}
+	}
+	lower := make([]string, len(methods))
+	for i := range methods {
+		lower[i] = strings.ToLower(methods[i])
+	}
+	return lower
+}
+
+var (
+	httpHandlerFuncType = reflect.TypeOf(func(http.ResponseWriter, *http.Request) {})
+	errorIfaceType      = reflect.TypeOf((*error)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	blobType            = reflect.TypeOf(Blob{})
+)
+
+// openAPISchemas collects the schemas of named struct types as components.
+type openAPISchemas struct {
+	components map[string]any
+	types      map[reflect.Type]string // Name of the component.
+}
+
+// operation returns the operation object of a handler function. Request body and data returned are known for Lambda functions only.
+func (s *openAPISchemas) operation(f any) map[string]any {
+	problem := map[string]any{"description": "Error", "content": map[string]any{
+		ContentTypeProblemJSON: map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/ProblemDetails"}}}}
+	op := map[string]any{"responses": map[string]any{"default": problem}}
+	responses := op["responses"].(map[string]any)
+
+	t := reflect.TypeOf(f)
+	if t == nil || t.Kind() != reflect.Func || t.ConvertibleTo(httpHandlerFuncType) {
+		responses["200"] = map[string]any{"description": "OK"}
+		return op
+	}
+
+	in := 0
+	if in < t.NumIn() && t.In(in).Implements(contextType) {
+		in++
+	}
+	if in < t.NumIn() {
+		op["requestBody"] = map[string]any{"required": true, "content": map[string]any{
+			ContentTypeApplicationJSON: map[string]any{"schema": s.schema(t.In(in))}}}
+	}
+
+	var out reflect.Type
+	for i := range t.NumOut() {
+		if !t.Out(i).Implements(errorIfaceType) {
+			out = t.Out(i)
+			break
+		}
+	}
+	switch {
+	case out == nil:
+		responses["204"] = map[string]any{"description": "No Content"}
+	case out.Implements(readerType) || out == blobType || out == reflect.PointerTo(blobType):
+		responses["200"] = map[string]any{"description": "OK", "content": map[string]any{
+			"application/octet-stream": map[string]any{"schema": map[string]any{"type": "string", "contentMediaType": "application/octet-stream"}}}}
+	default:
+		responses["200"] = map[string]any{"description": "OK", "content": map[string]any{
+			ContentTypeApplicationJSON: map[string]any{"schema": s.schema(out)}}}
+	}
+	return op
+}
+
+// schema returns the JSON schema of the type. Named struct types are referenced as components.
+func (s *openAPISchemas) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]any{} // Any.
+	}
+
+	if schema, ok := kindSchemas[t.Kind()]; ok {
+		return maps.Clone(schema)
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name, ok := s.types[t]
+		if !ok {
+			name = s.componentName(t)
+			s.types[t] = name
+			s.components[name] = map[string]any{} // Placeholder for recursive types.
+			s.components[name] = s.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{} // Any, e.g. interface.
+}
+
+// kindSchemas are the schemas of basic kinds.
+var kindSchemas = map[reflect.Kind]map[string]any{
+	reflect.Bool:    {"type": "boolean"},
+	reflect.Int8:    {"type": "integer", "format": "int32"},
+	reflect.Int16:   {"type": "integer", "format": "int32"},
+	reflect.Int32:   {"type": "integer", "format": "int32"},
+	reflect.Int:     {"type": "integer", "format": "int64"},
+	reflect.Int64:   {"type": "integer", "format": "int64"},
+	reflect.Uint8:   {"type": "integer", "format": "int32", "minimum": 0},
+	reflect.Uint16:  {"type": "integer", "format": "int32", "minimum": 0},
+	reflect.Uint32:  {"type": "integer", "format": "int32", "minimum": 0},
+	reflect.Uint:    {"type": "integer", "format": "int64", "minimum": 0},
+	reflect.Uint64:  {"type": "integer", "format": "int64", "minimum": 0},
+	reflect.Uintptr: {"type": "integer", "format": "int64", "minimum": 0},
+	reflect.Float32: {"type": "number", "format": "float"},
+	reflect.Float64: {"type": "number", "format": "double"},
+	reflect.String:  {"type": "string"},
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+var componentNameRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentName returns a unique component name of the type, e.g. "User", or "orders.User" if another package has User, too.
+func (s *openAPISchemas) componentName(t reflect.Type) string {
+	name := componentNameRegexp.ReplaceAllString(t.Name(), "_")
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()
+		name = componentNameRegexp.ReplaceAllString(pkg[strings.LastIndex(pkg, "/")+1:]+"."+t.Name(), "_")
+	}
+	for i := 2; ; i++ {
+		if _, taken := s.components[name]; !taken {
+			return name
+		}
+		name = strings.TrimRight(name, "0123456789") + strconv.Itoa(i)
+	}
+}
+
+// structSchema returns the object schema of the struct type, embedded structs flattened.
+func (s *openAPISchemas) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.addFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *openAPISchemas) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(ft, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := s.schema(field.Type)
+		if applyValidateTag(schema, field.Tag.Get("validate")) {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+// applyValidateTag adds keywords of the validate tag to the schema, e.g. "min=1" becomes minLength, minItems or minimum by the type.
+// Returns whether the field is required.
+func applyValidateTag(schema map[string]any, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	if _, ok := schema["$ref"]; ok {
+		return strings.Contains(","+tag+",", ",required,")
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" {
+			break // Rules of elements follow.
+		}
+		key, value, _ := strings.Cut(rule, "=")
+		switch {
+		case key == "required":
+			required = true
+		case key == "oneof":
+			schema["enum"] = validateEnum(value, schema["type"] != "string")
+		case validateFormats[key] != "":
+			schema["format"] = validateFormats[key]
+		default:
+			applyValidateLimit(schema, key, value)
+		}
+	}
+	return required
+}
+
+// validateFormats are the formats of validate tags.
+var validateFormats = map[string]string{"email": "email", "uuid": "uuid", "ipv4": "ipv4", "ipv6": "ipv6", "hostname": "hostname",
+	"url": "uri", "uri": "uri", "datetime": "date-time"}
+
+// validateLimitSuffixes are the suffixes of min and max keywords by schema type, e.g. minLength. Numbers have minimum and maximum.
+var validateLimitSuffixes = map[any]string{"string": "Length", "array": "Items", "object": "Properties"}
+
+func validateEnum(value string, numbers bool) (enum []any) {
+	for _, v := range strings.Fields(value) {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && numbers {
+			enum = append(enum, n)
+		} else {
+			enum = append(enum, v)
+		}
+	}
+	return
+}
+
+// applyValidateLimit adds the limit of a validate rule, e.g. "min=1", to the schema.
+func applyValidateLimit(schema map[string]any, key, value string) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	minKey, maxKey := "minimum", "maximum"
+	if suffix, ok := validateLimitSuffixes[schema["type"]]; ok {
+		minKey, maxKey = "min"+suffix, "max"+suffix
+	}
+	numeric := schema["type"] == "integer" || schema["type"] == "number"
+	switch key {
+	case "min", "gte":
+		schema[minKey] = number
+	case "max", "lte":
+		schema[maxKey] = number
+	case "len":
+		schema[minKey], schema[maxKey] = number, number
+	case "gt":
+		if numeric {
+			schema["exclusiveMinimum"] = number
+		}
+	case "lt":
+		if numeric {
+			schema["exclusiveMaximum"] = number
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var openAPIOperationMethods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace}
+
+type openAPIBinding struct {
+	path    string
+	method  string
+	id      string
+	summary string
+	handler any
+}
+
+// NewOpenAPIRouter creates a router serving the operations of the OpenAPI document by the handlers given. See BindOpenAPI.
+func NewOpenAPIRouter(document []byte, handlers map[string]any) (*Router, error) {
+	r := NewRouter()
+	if err := r.BindOpenAPI(document, handlers); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// BindOpenAPI registers the operations of the OpenAPI document, in JSON or YAML format, calling the handlers given by operation ID.
+// Handlers are Lambda functions or http.HandlerFunc. Route names and summaries are taken from the document.
+// Path of the first server URL, if any, is prepended to the paths, as done by NewOpenAPIValidator.
+//
+// Meant to be called at startup, failing fast instead of serving a contract not implemented. Nothing is registered, but errors returned
+// if an operation has no operation ID or no handler, or a handler has no operation. Lambda functions are checked, too:
+// taking a request body or returning data must match the operation, as well as the JSON type of those, and required properties must have fields.
+//
+//	err := router.BindOpenAPI(spec, map[string]any{"createUser": createUser, "getUser": getUser})
+func (r *Router) BindOpenAPI(document []byte, handlers map[string]any) error {
+	doc, basePath, err := parseOpenAPIDocument(document)
+	if err != nil {
+		return err
+	}
+	var bindings []openAPIBinding
+	var errs []error
+	bound := map[string]string{}
+	referenced := map[string]bool{}
+	paths, _ := doc["paths"].(map[string]any)
+	for _, template := range slices.Sorted(maps.Keys(paths)) {
+		item, _ := resolveRef(doc, paths[template], "")
+		for _, method := range openAPIOperationMethods {
+			op, ok := item[strings.ToLower(method)].(map[string]any)
+			if !ok {
+				continue
+			}
+			b, err := bindOpenAPIOperation(doc, template, method, op, handlers)
+			referenced[b.id] = true
+			if err == nil && bound[b.id] != "" {
+				err = fmt.Errorf("OpenAPI %s %s: operationId %q is used by %s, too", method, template, b.id, bound[b.id])
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			bound[b.id] = method + " " + template
+			bindings = append(bindings, b)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(handlers)) {
+		if !referenced[id] {
+			errs = append(errs, fmt.Errorf("OpenAPI: no operation of handler %q", id))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for _, b := range bindings {
+		route := r.HandleFunc(basePath+b.path, b.handler).Methods(b.method).Name(b.id)
+		if b.summary != "" {
+			route.Summary(b.summary)
+		}
+	}
+	return nil
+}
+
+func bindOpenAPIOperation(doc map[string]any, template, method string, op map[string]any, handlers map[string]any) (openAPIBinding, error) {
+	b := openAPIBinding{path: template, method: method}
+	b.id, _ = op["operationId"].(string)
+	b.summary, _ = op["summary"].(string)
+	if b.id == "" {
+		return b, fmt.Errorf("OpenAPI %s %s: no operationId", method, template)
+	}
+	var ok bool
+	if b.handler, ok = handlers[b.id]; !ok {
+		return b, fmt.Errorf("OpenAPI %s %s: no handler of operation %q", method, template, b.id)
+	}
+	if err := checkOpenAPIHandler(doc, op, b.handler); err != nil {
+		return b, fmt.Errorf("OpenAPI %s %s: handler of operation %q: %w", method, template, b.id, err)
+	}
+	return b, nil
+}
+
+// checkOpenAPIHandler checks the request body and response data of a Lambda function against the operation.
+func checkOpenAPIHandler(doc map[string]any, op map[string]any, f any) error {
+	t := reflect.TypeOf(f)
+	if t == nil || t.Kind() != reflect.Func {
+		return fmt.Errorf("not a function: %T", f)
+	}
+	if t.ConvertibleTo(httpHandlerFuncType) {
+		return nil
+	}
+	bodyType, dataType, err := lambdaTypes(t)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOpenAPIBody(doc, op, bodyType); err != nil {
+		return err
+	}
+	return checkOpenAPIData(doc, op, dataType)
+}
+
+func checkOpenAPIBody(doc map[string]any, op map[string]any, bodyType reflect.Type) error {
+	body, _ := resolveRef(doc, op["requestBody"], "")
+	bodySchema, hasBody := openAPIJSONSchema(body)
+	switch {
+	case hasBody && bodyType == nil:
+		return errors.New("request body is not taken")
+	case !hasBody && bodyType != nil:
+		return fmt.Errorf("takes %s, but the operation has no JSON request body", bodyType)
+	case hasBody:
+		if err := checkOpenAPIType(doc, bodySchema, bodyType); err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkOpenAPIData(doc map[string]any, op map[string]any, dataType reflect.Type) error {
+	dataSchema, content, ok := openAPISuccessSchema(doc, op)
+	switch {
+	case !ok || (content && dataSchema == nil): // Not documented, or not JSON.
+	case !content && dataType != nil:
+		return fmt.Errorf("returns %s, but the operation responds no content", dataType)
+	case content && dataType == nil:
+		return errors.New("returns no data, but the operation responds JSON")
+	default:
+		if err := checkOpenAPIType(doc, dataSchema, dataType); err != nil {
+			return fmt.Errorf("response: %w", err)
+		}
+	}
+	return nil
+}
+
+// lambdaTypes returns the type of request body and response data of a Lambda function, if any.
+func lambdaTypes(t reflect.Type) (body, data reflect.Type, err error) {
+	in := 0
+	if in < t.NumIn() && t.In(in).Implements(contextType) {
+		in++
+	}
+	if t.NumIn() > in+1 {
+		return nil, nil, fmt.Errorf("too many parameters: %s", t)
+	}
+	if in < t.NumIn() {
+		body = t.In(in)
+	}
+	for i := range t.NumOut() {
+		if !t.Out(i).Implements(errorIfaceType) {
+			data = t.Out(i)
+			break
+		}
+	}
+	return body, data, nil
+}
+
+// openAPIJSONSchema returns the schema of the JSON content of a request body or response, if any.
+func openAPIJSONSchema(node map[string]any) (schema any, ok bool) {
+	content, _ := node["content"].(map[string]any)
+	for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+		if mediaType == ContentTypeApplicationJSON || strings.HasSuffix(mediaType, "+json") {
+			mt, _ := content[mediaType].(map[string]any)
+			return mt["schema"], true
+		}
+	}
+	return nil, false
+}
+
+// openAPISuccessSchema returns the JSON schema of the first successful response, and whether it has content.
+// Schema is nil if the content is not JSON. Not ok if no successful response is documented.
+func openAPISuccessSchema(doc map[string]any, op map[string]any) (schema any, content, ok bool) {
+	responses, _ := op["responses"].(map[string]any)
+	for _, status := range slices.Sorted(maps.Keys(responses)) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		resp, _ := resolveRef(doc, responses[status], "")
+		schema, _ = openAPIJSONSchema(resp)
+		c, _ := resp["content"].(map[string]any)
+		return schema, len(c) > 0, true
+	}
+	return nil, false, false
+}
+
+// checkOpenAPIType checks the JSON type of the Go type against the schema. Properties required by object schemas must have fields.
+// Items of arrays are checked, too.
+func checkOpenAPIType(doc map[string]any, node any, t reflect.Type) error {
+	schema, _ := resolveRef(doc, node, "")
+	expected := openAPISchemaType(schema)
+	if expected == "" {
+		return nil
+	}
+	s := openAPISchemas{components: map[string]any{}, types: map[reflect.Type]string{}}
+	reflected := s.schema(t)
+	if ref, ok := reflected["$ref"].(string); ok {
+		reflected, _ = s.components[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+	}
+	actual, _ := reflected["type"].(string)
+	if actual != "" && actual != expected && (actual != "integer" || expected != "number") {
+		return fmt.Errorf("%s is %s, not %s", t, actual, expected)
+	}
+
+	switch expected {
+	case "object":
+		return checkOpenAPIRequired(schema, reflected, t)
+	case "array":
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && schema["items"] != nil {
+			return checkOpenAPIType(doc, schema["items"], t.Elem())
+		}
+	}
+	return nil
+}
+
+// checkOpenAPIRequired checks that the properties required by the schema are fields of the struct, if a struct.
+func checkOpenAPIRequired(schema, reflected map[string]any, t reflect.Type) error {
+	properties, ok := reflected["properties"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, found := properties[fmt.Sprint(name)]; !found {
+			return fmt.Errorf("%s has no field of required property %q", t, name)
+		}
+	}
+	return nil
+}
+
+// openAPISchemaType returns the type of a schema, the first non-null one if several.
+func openAPISchemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, name := range t {
+			if name != "null" {
+				return fmt.Sprint(name)
+			}
+		}
+	}
+	return ""
+}
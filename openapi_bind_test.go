@@ -0,0 +1,164 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var openAPIBindDoc = []byte(`
+openapi: 3.0.3
+info: {title: Users, version: 1.0.0}
+servers: [{url: "https://example.com/api/v1"}]
+paths:
+  /users:
+    post:
+      operationId: createUser
+      summary: Create a user
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/User"}
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/User"}
+    get:
+      operationId: listUsers
+      responses:
+        "200":
+          description: Users
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/User"}
+  /users/{id}:
+    delete:
+      operationId: deleteUser
+      responses:
+        "204": {description: Deleted}
+  /health:
+    get:
+      operationId: health
+      responses:
+        "200": {description: OK, content: {text/plain: {}}}
+components:
+  schemas:
+    User:
+      type: object
+      required: [name]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+`)
+
+type openAPIBindUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func openAPIBindHandlers() map[string]any {
+	return map[string]any{
+		"createUser": func(ctx context.Context, user openAPIBindUser) (*openAPIBindUser, error) {
+			user.ID = 1
+			L(ctx).ResponseStatus(http.StatusCreated)
+			return &user, nil
+		},
+		"listUsers": func(ctx context.Context) ([]openAPIBindUser, error) {
+			return []openAPIBindUser{{ID: 1, Name: "Joe"}}, nil
+		},
+		"deleteUser": func(ctx context.Context) error { return nil },
+		"health":     func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("OK")) },
+	}
+}
+
+func TestBindOpenAPI(t *testing.T) {
+	assert := assert.New(t)
+	r, err := NewOpenAPIRouter(openAPIBindDoc, openAPIBindHandlers())
+	assert.NoError(err)
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		resp               string
+	}{
+		{http.MethodPost, "/api/v1/users", `{"name":"Joe"}`, http.StatusCreated, `{"id":1,"name":"Joe"}`},
+		{http.MethodGet, "/api/v1/users", "", http.StatusOK, `[{"id":1,"name":"Joe"}]`},
+		{http.MethodDelete, "/api/v1/users/1", "", http.StatusNoContent, ""},
+		{http.MethodGet, "/api/v1/health", "", http.StatusOK, "OK"},
+		{http.MethodPut, "/api/v1/users", "", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/users", "", http.StatusNotFound, ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.body != "" {
+			req.Header.Set(ContentTypeHeader, ContentTypeApplicationJSON)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(tc.status, w.Code, tc.method+" "+tc.path)
+		if tc.resp != "" {
+			assert.Equal(tc.resp, strings.TrimSpace(w.Body.String()), tc.method+" "+tc.path)
+		}
+	}
+
+	routes := map[string]string{}
+	for _, route := range r.Routes() {
+		routes[route.Name] = route.Path
+	}
+	assert.Equal("/api/v1/users/{id}", routes["deleteUser"])
+}
+
+func TestBindOpenAPIErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		handlers func(map[string]any)
+		err      string
+	}{
+		"unbound":  {func(h map[string]any) { delete(h, "listUsers") }, `GET /users: no handler of operation "listUsers"`},
+		"unknown":  {func(h map[string]any) { h["getUser"] = func() {} }, `no operation of handler "getUser"`},
+		"not func": {func(h map[string]any) { h["health"] = "OK" }, `not a function: string`},
+		"no body":  {func(h map[string]any) { h["createUser"] = func(ctx context.Context) error { return nil } }, `"createUser": request body is not taken`},
+		"body":     {func(h map[string]any) { h["deleteUser"] = func(ctx context.Context, s string) error { return nil } }, `takes string, but the operation has no JSON request body`},
+		"data":     {func(h map[string]any) { h["deleteUser"] = func(ctx context.Context) (string, error) { return "", nil } }, `returns string, but the operation responds no content`},
+		"no data":  {func(h map[string]any) { h["listUsers"] = func(ctx context.Context) error { return nil } }, `returns no data, but the operation responds JSON`},
+		"type": {func(h map[string]any) {
+			h["listUsers"] = func(ctx context.Context) (map[string]any, error) { return nil, nil }
+		}, `response: map[string]interface {} is object, not array`},
+		"items": {func(h map[string]any) {
+			h["listUsers"] = func(ctx context.Context) ([]string, error) { return nil, nil }
+		}, `response: string is string, not object`},
+		"required": {func(h map[string]any) {
+			h["createUser"] = func(ctx context.Context, u struct{ ID int }) error { return nil }
+		}, `has no field of required property "name"`},
+		"too many": {func(h map[string]any) { h["deleteUser"] = func(ctx context.Context, a, b string) error { return nil } }, `too many parameters`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handlers := openAPIBindHandlers()
+			tc.handlers(handlers)
+			r := NewRouter()
+			err := r.BindOpenAPI(openAPIBindDoc, handlers)
+			assert.ErrorContains(t, err, tc.err)
+			assert.Empty(t, r.Routes())
+		})
+	}
+}
+
+func TestBindOpenAPIDocErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewOpenAPIRouter([]byte("["), nil)
+	assert.Error(err)
+	_, err = NewOpenAPIRouter([]byte(`{"paths": {"/a": {"get": {}}}}`), nil)
+	assert.ErrorContains(err, "GET /a: no operationId")
+	_, err = NewOpenAPIRouter([]byte(`{"paths": {"/a": {"get": {"operationId": "a"}}, "/b": {"get": {"operationId": "a"}}}}`), map[string]any{"a": func() {}})
+	assert.ErrorContains(err, `operationId "a" is used by GET /a, too`)
+}
@@ -0,0 +1,97 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type openAPIAddress struct {
+	City string `json:"city" validate:"required,max=64"`
+}
+
+type openAPIUser struct {
+	ID      int               `json:"id" validate:"gte=1"`
+	Name    string            `json:"name" validate:"required,min=1"`
+	Email   string            `json:"email,omitempty" validate:"omitempty,email"`
+	Role    string            `json:"role,omitempty" validate:"omitempty,oneof=admin user"`
+	Tags    []string          `json:"tags,omitempty" validate:"max=10,dive,min=1"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Created time.Time         `json:"created"`
+	Address *openAPIAddress   `json:"address,omitempty"`
+	Friends []openAPIUser     `json:"friends,omitempty"`
+	secret  string
+	Ignored string `json:"-"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRouter().ServeOpenAPI(OpenAPIInfo{Title: "Users", Version: "1.0.0"})
+	r.HandleFunc("/users", func(ctx context.Context, user openAPIUser) (*openAPIUser, error) { return &user, nil }).Methods(http.MethodPost).Name("createUser")
+	r.HandleFunc("/users/{id:[0-9]+}", func(ctx context.Context) (*openAPIUser, error) { return nil, nil }).Methods(http.MethodGet).Summary("Get a user")
+	r.HandleFunc("/users/{id:[0-9]+}", func(ctx context.Context) error { return nil }).Methods(http.MethodDelete)
+	r.HandleFunc("/users/{id}/avatar", func(ctx context.Context) (*Blob, error) { return nil, nil }).Methods(http.MethodGet)
+	r.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {}).Queries("q", "{q}")
+	r.PathPrefix("/static/").Handler(http.NotFoundHandler())
+	r.PathPrefix("/v2").Subrouter().HandleFunc("/ping", func() error { return nil })
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+	assert.Equal(http.StatusOK, rr.Code)
+	var doc map[string]any
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.Equal("3.1.0", doc["openapi"])
+	assert.Equal(map[string]any{"title": "Users", "version": "1.0.0"}, doc["info"])
+
+	paths := doc["paths"].(map[string]any)
+	assert.NotContains(paths, OpenAPIPath)
+	assert.NotContains(paths, "/static/")
+	assert.Contains(paths, "/v2/ping")
+	assert.Contains(paths["/v2/ping"], "get")
+
+	create := paths["/users"].(map[string]any)["post"].(map[string]any)
+	assert.Equal("createUser", create["operationId"])
+	assert.Equal(map[string]any{"$ref": "#/components/schemas/openAPIUser"},
+		create["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"])
+	responses := create["responses"].(map[string]any)
+	assert.Contains(responses, "200")
+	assert.Equal("#/components/schemas/ProblemDetails",
+		responses["default"].(map[string]any)["content"].(map[string]any)["application/problem+json"].(map[string]any)["schema"].(map[string]any)["$ref"])
+
+	user := paths["/users/{id}"].(map[string]any)
+	get := user["get"].(map[string]any)
+	assert.Equal("Get a user", get["summary"])
+	assert.Equal([]any{map[string]any{"name": "id", "in": "path", "required": true, "schema": map[string]any{"type": "string", "pattern": "^[0-9]+$"}}}, get["parameters"])
+	assert.Contains(user["delete"].(map[string]any)["responses"], "204")
+	assert.Contains(paths["/users/{id}/avatar"].(map[string]any)["get"].(map[string]any)["responses"].(map[string]any)["200"].(map[string]any)["content"], "application/octet-stream")
+	search := paths["/search"].(map[string]any)["get"].(map[string]any)
+	assert.Equal("query", search["parameters"].([]any)[0].(map[string]any)["in"])
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.Contains(schemas, "ProblemDetails")
+	assert.Contains(schemas, "InvalidParam")
+	userSchema := schemas["openAPIUser"].(map[string]any)
+	assert.ElementsMatch([]any{"name"}, userSchema["required"])
+	props := userSchema["properties"].(map[string]any)
+	assert.Equal(map[string]any{"type": "integer", "format": "int64", "minimum": 1.0}, props["id"])
+	assert.Equal(map[string]any{"type": "string", "minLength": 1.0}, props["name"])
+	assert.Equal(map[string]any{"type": "string", "format": "email"}, props["email"])
+	assert.Equal(map[string]any{"type": "string", "enum": []any{"admin", "user"}}, props["role"])
+	assert.Equal(map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "maxItems": 10.0}, props["tags"])
+	assert.Equal(map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}}, props["labels"])
+	assert.Equal(map[string]any{"type": "string", "format": "date-time"}, props["created"])
+	assert.Equal(map[string]any{"$ref": "#/components/schemas/openAPIAddress"}, props["address"])
+	assert.Equal(map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/openAPIUser"}}, props["friends"]) // Recursive.
+	assert.NotContains(props, "secret")
+	assert.NotContains(props, "Ignored")
+	assert.Equal(map[string]any{"type": "string", "maxLength": 64.0}, schemas["openAPIAddress"].(map[string]any)["properties"].(map[string]any)["city"])
+}
@@ -0,0 +1,497 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nokia/restful/jsonschema"
+	"github.com/nokia/restful/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIValidator validates requests, and optionally responses, against an OpenAPI 3.0 or 3.1 document.
+// Enforces the API contract at runtime, e.g. at a gateway, or catches contract drift of responses in staging.
+//
+// Requests are responded 404 if the path is not in the document, 405 if the method is not, and 400 if parameters or body do not match.
+// Responses not matching are replaced by 500. Problem details responses tell the failing parts as invalidParams,
+// e.g. param "body/address/city" with the reason telling the schema path, too.
+// Validated: path, query and header parameters, request body, response body of JSON media types. See package jsonschema for the keywords supported.
+//
+//	validator, err := restful.NewOpenAPIValidator(spec)
+//	restful.NewServer().Addr(":8080").Handler(validator.Handler(router))
+type OpenAPIValidator struct {
+	basePath          string
+	paths             []*openAPIPath
+	validateResponses bool
+}
+
+type openAPIPath struct {
+	template   string
+	regexp     *regexp.Regexp
+	vars       []string
+	operations map[string]*openAPIOperation // By method.
+}
+
+type openAPIOperation struct {
+	params       []openAPIParam
+	bodyRequired bool
+	bodies       map[string]*jsonschema.Schema // By media type. Nil schema if not validated.
+	responses    map[string]map[string]*jsonschema.Schema
+}
+
+type openAPIParam struct {
+	name     string
+	in       string
+	required bool
+	schema   *jsonschema.Schema
+	node     map[string]any
+}
+
+var openAPIPathVarRegexp = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// NewOpenAPIValidator creates a validator of the OpenAPI document, in JSON or YAML format.
+// Path of the first server URL, if any, is the base path of the paths, e.g. "/api/v1" of "https://example.com/api/v1".
+func NewOpenAPIValidator(document []byte) (*OpenAPIValidator, error) {
+	doc, basePath, err := parseOpenAPIDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &OpenAPIValidator{basePath: basePath}
+	paths, _ := doc["paths"].(map[string]any)
+	for template, item := range paths {
+		p, err := newOpenAPIPath(doc, template, item)
+		if err != nil {
+			return nil, err
+		}
+		v.paths = append(v.paths, p)
+	}
+	// Paths having fewer variables first, e.g. "/users/me" before "/users/{id}".
+	slices.SortFunc(v.paths, func(a, b *openAPIPath) int {
+		if c := len(a.vars) - len(b.vars); c != 0 {
+			return c
+		}
+		return strings.Compare(a.template, b.template)
+	})
+	return v, nil
+}
+
+// parseOpenAPIDocument parses an OpenAPI document in JSON or YAML format, having the types of JSON decoding, as expected by jsonschema.
+// Base path is the path of the first server URL, if any.
+func parseOpenAPIDocument(document []byte) (doc map[string]any, basePath string, err error) {
+	var v any
+	if err := yaml.Unmarshal(document, &v); err != nil { // JSON is YAML, too.
+		return nil, "", fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, "", fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, "", errors.New("invalid OpenAPI document: not an object")
+	}
+	if servers, ok := doc["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			if u, err := url.Parse(fmt.Sprint(server["url"])); err == nil {
+				basePath = strings.TrimSuffix(u.Path, "/")
+			}
+		}
+	}
+	return doc, basePath, nil
+}
+
+// ValidateResponses makes the validator check responses, too. Responses are buffered then.
+// Meant for test and staging environments, catching contract drift of the handlers.
+func (v *OpenAPIValidator) ValidateResponses() *OpenAPIValidator {
+	v.validateResponses = true
+	return v
+}
+
+var pointerTokenReplacer = strings.NewReplacer("~", "~0", "/", "~1")
+
+// resolveRef returns the node of a $ref, if any, and the pointer of it.
+func resolveRef(doc any, node any, pointer string) (map[string]any, string) {
+	for range 10 {
+		n, _ := node.(map[string]any)
+		ref, ok := n["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return n, pointer
+		}
+		pointer = ref[1:]
+		node = doc
+		for _, token := range strings.Split(pointer[1:], "/") {
+			m, _ := node.(map[string]any)
+			node = m[strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")]
+		}
+	}
+	return nil, pointer
+}
+
+func newOpenAPIPath(doc any, template string, itemNode any) (*openAPIPath, error) {
+	p := &openAPIPath{template: template, operations: map[string]*openAPIOperation{}}
+	pattern := "^"
+	last := 0
+	for _, m := range openAPIPathVarRegexp.FindAllStringSubmatchIndex(template, -1) {
+		pattern += regexp.QuoteMeta(template[last:m[0]]) + "([^/]+)"
+		p.vars = append(p.vars, template[m[2]:m[3]])
+		last = m[1]
+	}
+	var err error
+	if p.regexp, err = regexp.Compile(pattern + regexp.QuoteMeta(template[last:]) + "$"); err != nil {
+		return nil, err
+	}
+
+	itemPointer := "/paths/" + pointerTokenReplacer.Replace(template)
+	item, itemPointer := resolveRef(doc, itemNode, itemPointer)
+	for method, opNode := range item {
+		method = strings.ToUpper(method)
+		switch method {
+		case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace:
+		default:
+			continue
+		}
+		op, err := newOpenAPIOperation(doc, item, itemPointer, opNode, itemPointer+"/"+strings.ToLower(method))
+		if err != nil {
+			return nil, fmt.Errorf("OpenAPI %s %s: %w", method, template, err)
+		}
+		p.operations[method] = op
+	}
+	return p, nil
+}
+
+func newOpenAPIOperation(doc any, item map[string]any, itemPointer string, opNode any, opPointer string) (*openAPIOperation, error) {
+	op := &openAPIOperation{bodies: map[string]*jsonschema.Schema{}, responses: map[string]map[string]*jsonschema.Schema{}}
+	opMap, _ := opNode.(map[string]any)
+
+	// Parameters of the operation override those of the path item.
+	params := map[string]openAPIParam{}
+	for _, level := range []struct {
+		node    any
+		pointer string
+	}{{item["parameters"], itemPointer + "/parameters"}, {opMap["parameters"], opPointer + "/parameters"}} {
+		list, _ := level.node.([]any)
+		for i := range list {
+			node, pointer := resolveRef(doc, list[i], level.pointer+"/"+strconv.Itoa(i))
+			param := openAPIParam{node: node}
+			param.name, _ = node["name"].(string)
+			param.in, _ = node["in"].(string)
+			param.required, _ = node["required"].(bool)
+			if param.in == "header" {
+				param.name = http.CanonicalHeaderKey(param.name)
+			}
+			if _, ok := node["schema"]; ok {
+				schema, err := jsonschema.NewFromValue(doc, pointer+"/schema")
+				if err != nil {
+					return nil, err
+				}
+				param.schema = schema
+			}
+			params[param.in+" "+param.name] = param
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		op.params = append(op.params, params[key])
+	}
+
+	if body, pointer := resolveRef(doc, opMap["requestBody"], opPointer+"/requestBody"); body != nil {
+		op.bodyRequired, _ = body["required"].(bool)
+		var err error
+		if op.bodies, err = mediaTypeSchemas(doc, body, pointer); err != nil {
+			return nil, err
+		}
+	}
+
+	responses, _ := opMap["responses"].(map[string]any)
+	for status, respNode := range responses {
+		resp, pointer := resolveRef(doc, respNode, opPointer+"/responses/"+pointerTokenReplacer.Replace(status))
+		schemas, err := mediaTypeSchemas(doc, resp, pointer)
+		if err != nil {
+			return nil, err
+		}
+		op.responses[strings.ToUpper(status)] = schemas
+	}
+	return op, nil
+}
+
+// mediaTypeSchemas returns the schemas of the content of a request body or response, by media type.
+func mediaTypeSchemas(doc any, node map[string]any, pointer string) (map[string]*jsonschema.Schema, error) {
+	schemas := map[string]*jsonschema.Schema{}
+	content, _ := node["content"].(map[string]any)
+	for mediaType, mt := range content {
+		schemas[mediaType] = nil
+		if m, ok := mt.(map[string]any); ok && m["schema"] != nil {
+			schema, err := jsonschema.NewFromValue(doc, pointer+"/content/"+pointerTokenReplacer.Replace(mediaType)+"/schema")
+			if err != nil {
+				return nil, err
+			}
+			schemas[mediaType] = schema
+		}
+	}
+	return schemas, nil
+}
+
+// mediaTypeSchema returns the schema of the content type, and whether the content type is allowed.
+// Wildcard media ranges are matched, e.g. "application/*".
+func mediaTypeSchema(schemas map[string]*jsonschema.Schema, contentType string) (*jsonschema.Schema, bool) {
+	major, _, _ := strings.Cut(contentType, "/")
+	for _, key := range []string{contentType, major + "/*", "*/*"} {
+		if schema, ok := schemas[key]; ok {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+func (v *OpenAPIValidator) find(r *http.Request) (*openAPIPath, map[string]string) {
+	path, ok := strings.CutPrefix(r.URL.Path, v.basePath)
+	if !ok {
+		return nil, nil
+	}
+	for _, p := range v.paths {
+		if m := p.regexp.FindStringSubmatch(path); m != nil {
+			vars := make(map[string]string, len(p.vars))
+			for i, name := range p.vars {
+				vars[name], _ = url.PathUnescape(m[i+1])
+			}
+			return p, vars
+		}
+	}
+	return nil, nil
+}
+
+// validateRequest returns the operation of the request, or the status code and the invalid parameters found.
+func (v *OpenAPIValidator) validateRequest(r *http.Request) (*openAPIOperation, int, []InvalidParam) {
+	p, vars := v.find(r)
+	if p == nil {
+		return nil, http.StatusNotFound, nil
+	}
+	op, ok := p.operations[r.Method]
+	if !ok {
+		return nil, http.StatusMethodNotAllowed, nil
+	}
+	invalid := op.validateParams(r, vars)
+	status, invalidBody := op.validateBody(r)
+	if status != 0 {
+		return op, status, invalidBody
+	}
+	if invalid = append(invalid, invalidBody...); len(invalid) > 0 {
+		return op, http.StatusBadRequest, invalid
+	}
+	return op, 0, nil
+}
+
+func (op *openAPIOperation) validateParams(r *http.Request, vars map[string]string) (invalid []InvalidParam) {
+	query := r.URL.Query()
+	for _, param := range op.params {
+		var values []string
+		switch param.in {
+		case "path":
+			if value, ok := vars[param.name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[param.name]
+		case "header":
+			values = r.Header.Values(param.name)
+		default:
+			continue // Cookie.
+		}
+		if len(values) == 0 {
+			if param.required {
+				invalid = append(invalid, InvalidParam{Param: param.in + "/" + param.name, Reason: "missing"})
+			}
+			continue
+		}
+		if param.schema != nil {
+			if err := param.schema.ValidateValue(paramValue(param.node, values)); err != nil {
+				invalid = append(invalid, invalidParams(param.in+"/"+param.name, err)...)
+			}
+		}
+	}
+	return
+}
+
+// validateBody returns the invalid parts of the body, and non-zero status if the body cannot be validated at all.
+func (op *openAPIOperation) validateBody(r *http.Request) (int, []InvalidParam) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, []InvalidParam{{Param: "body", Reason: err.Error()}}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		if op.bodyRequired {
+			return 0, []InvalidParam{{Param: "body", Reason: "missing"}}
+		}
+		return 0, nil
+	}
+	if len(op.bodies) == 0 {
+		return 0, nil
+	}
+	contentType := GetBaseContentType(r.Header)
+	schema, ok := mediaTypeSchema(op.bodies, contentType)
+	if !ok {
+		return http.StatusUnsupportedMediaType, []InvalidParam{{Param: "header/Content-Type", Reason: "unsupported: " + contentType}}
+	}
+	if schema != nil && isJSONContentType(contentType) {
+		if err := schema.Validate(body); err != nil {
+			return 0, invalidParams("body", err)
+		}
+	}
+	return 0, nil
+}
+
+// paramValue converts the string values of a parameter to the type of its schema.
+func paramValue(node map[string]any, values []string) any {
+	schema, _ := node["schema"].(map[string]any)
+	convert := func(t any, s string) any {
+		switch t {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+		return s
+	}
+	if schema["type"] == "array" {
+		if len(values) == 1 && node["explode"] == false {
+			values = strings.Split(values[0], ",")
+		}
+		items, _ := schema["items"].(map[string]any)
+		array := make([]any, len(values))
+		for i := range values {
+			array[i] = convert(items["type"], values[i])
+		}
+		return array
+	}
+	return convert(schema["type"], values[0])
+}
+
+// invalidParams converts validation errors to invalid parameters, e.g. "body/address/city".
+func invalidParams(location string, err error) (params []InvalidParam) {
+	var verrs jsonschema.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []InvalidParam{{Param: location, Reason: err.Error()}}
+	}
+	for _, e := range verrs {
+		params = append(params, InvalidParam{Param: location + e.InstancePath, Reason: e.Message + " (schema " + e.SchemaPath + ")"})
+	}
+	return
+}
+
+func (v *OpenAPIValidator) sendProblem(w http.ResponseWriter, r *http.Request, status int, detail string, invalid []InvalidParam) {
+	pd := ProblemDetails{Title: http.StatusText(status), Status: status, Detail: detail, InvalidParams: invalid}
+	b, _ := json.Marshal(pd)
+	_ = SendProblemResponse(w, r, status, string(b))
+}
+
+// Pre is a Monitor pre function, validating requests. Responses are not validated this way, use Handler for that.
+//
+//	router.Monitor(validator.Pre, nil)
+func (v *OpenAPIValidator) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	if _, status, invalid := v.validateRequest(r); status != 0 {
+		v.sendProblem(w, r, status, "request does not match the API specification", invalid)
+		return nil
+	}
+	return r
+}
+
+// Handler wraps the handler, validating requests, and responses if ValidateResponses is set.
+func (v *OpenAPIValidator) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, status, invalid := v.validateRequest(r)
+		if status != 0 {
+			v.sendProblem(w, r, status, "request does not match the API specification", invalid)
+			return
+		}
+		if !v.validateResponses {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{header: w.Header(), statusCode: http.StatusOK}
+		h.ServeHTTP(bw, r)
+		if invalid := op.validateResponse(bw); len(invalid) > 0 {
+			logging.Errorf(r.Context(), "Response of %s %s does not match the API specification: %v", r.Method, r.URL.Path, invalid)
+			for key := range w.Header() {
+				w.Header().Del(key)
+			}
+			v.sendProblem(w, r, http.StatusInternalServerError, "response does not match the API specification", invalid)
+			return
+		}
+		w.WriteHeader(bw.statusCode)
+		_, _ = w.Write(bw.body.Bytes())
+	})
+}
+
+// validateResponse returns the invalid parts of the response, if any.
+func (op *openAPIOperation) validateResponse(bw *bufferedResponseWriter) []InvalidParam {
+	status := strconv.Itoa(bw.statusCode)
+	schemas, ok := op.responses[status]
+	if !ok {
+		if schemas, ok = op.responses[status[:1]+"XX"]; !ok {
+			if schemas, ok = op.responses["DEFAULT"]; !ok {
+				return []InvalidParam{{Param: "status", Reason: "undocumented status code " + status}}
+			}
+		}
+	}
+	if bw.body.Len() == 0 || len(schemas) == 0 {
+		return nil
+	}
+	contentType := GetBaseContentType(bw.header)
+	schema, ok := mediaTypeSchema(schemas, contentType)
+	if !ok {
+		return []InvalidParam{{Param: "header/Content-Type", Reason: "undocumented: " + contentType}}
+	}
+	if schema != nil && isJSONContentType(contentType) {
+		if err := schema.Validate(bw.body.Bytes()); err != nil {
+			return invalidParams("body", err)
+		}
+	}
+	return nil
+}
+
+// bufferedResponseWriter buffers the response, so that it can be checked before sending.
+type bufferedResponseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+		w.wroteHeader = true
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
@@ -0,0 +1,173 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const openAPIValidatorSpec = `
+openapi: 3.1.0
+info: {title: Users, version: 1.0.0}
+servers:
+  - url: https://example.com/api/v1
+paths:
+  /users:
+    get:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, maximum: 100}}
+        - {name: role, in: query, schema: {type: array, items: {type: string, enum: [admin, user]}}}
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/User"}}
+    post:
+      parameters:
+        - $ref: "#/components/parameters/Tenant"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/User"}
+      responses:
+        "201": {description: Created}
+        4XX:
+          description: Error
+          content:
+            application/problem+json:
+              schema: {type: object}
+  /users/me:
+    get:
+      responses:
+        "200": {description: OK}
+  /users/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer, minimum: 1}}
+    get:
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/User"}
+components:
+  parameters:
+    Tenant: {name: X-Tenant, in: header, required: true, schema: {type: string, minLength: 2}}
+  schemas:
+    User:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, minLength: 1}
+        address:
+          type: object
+          properties:
+            city: {type: string, maxLength: 5}
+`
+
+func TestOpenAPIValidatorRequest(t *testing.T) {
+	assert := assert.New(t)
+	validator, err := NewOpenAPIValidator([]byte(openAPIValidatorSpec))
+	if !assert.NoError(err) {
+		return
+	}
+	handler := validator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+
+	serve := func(method, target, body string, header http.Header) (int, ProblemDetails) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		var pd ProblemDetails
+		_ = json.Unmarshal(rr.Body.Bytes(), &pd)
+		return rr.Code, pd
+	}
+
+	status, _ := serve(http.MethodGet, "/api/v1/users?limit=10&role=admin&role=user", "", nil)
+	assert.Equal(http.StatusNoContent, status)
+	status, pd := serve(http.MethodGet, "/api/v1/users?limit=1000&role=guest", "", nil)
+	assert.Equal(http.StatusBadRequest, status)
+	assert.Equal([]InvalidParam{{Param: "query/limit", Reason: "must be <= 100 (schema #/paths/~1users/get/parameters/0/schema/maximum)"},
+		{Param: "query/role/0", Reason: "value not in enum (schema #/paths/~1users/get/parameters/1/schema/items/enum)"}}, pd.InvalidParams)
+
+	status, _ = serve(http.MethodGet, "/api/v1/users/me", "", nil)
+	assert.Equal(http.StatusNoContent, status)
+	status, _ = serve(http.MethodGet, "/api/v1/users/5", "", nil)
+	assert.Equal(http.StatusNoContent, status)
+	status, pd = serve(http.MethodGet, "/api/v1/users/0", "", nil)
+	assert.Equal(http.StatusBadRequest, status)
+	assert.Equal("path/id", pd.InvalidParams[0].Param)
+	status, _ = serve(http.MethodGet, "/users/5", "", nil) // Base path missing.
+	assert.Equal(http.StatusNotFound, status)
+	status, _ = serve(http.MethodDelete, "/api/v1/users/5", "", nil)
+	assert.Equal(http.StatusMethodNotAllowed, status)
+
+	json := http.Header{"Content-Type": {"application/json"}, "X-Tenant": {"acme"}}
+	status, _ = serve(http.MethodPost, "/api/v1/users", `{"name":"Joe"}`, json)
+	assert.Equal(http.StatusNoContent, status)
+	status, pd = serve(http.MethodPost, "/api/v1/users", `{"name":"Joe","address":{"city":"Helsinki"}}`, json)
+	assert.Equal(http.StatusBadRequest, status)
+	if assert.Len(pd.InvalidParams, 1) {
+		assert.Equal("body/address/city", pd.InvalidParams[0].Param)
+		assert.Contains(pd.InvalidParams[0].Reason, "#/components/schemas/User")
+	}
+	status, pd = serve(http.MethodPost, "/api/v1/users", "", json)
+	assert.Equal(http.StatusBadRequest, status)
+	assert.Equal([]InvalidParam{{Param: "body", Reason: "missing"}}, pd.InvalidParams)
+	status, pd = serve(http.MethodPost, "/api/v1/users", `{"name":"Joe"}`, http.Header{"Content-Type": {"application/json"}})
+	assert.Equal(http.StatusBadRequest, status)
+	assert.Equal([]InvalidParam{{Param: "header/X-Tenant", Reason: "missing"}}, pd.InvalidParams)
+	status, _ = serve(http.MethodPost, "/api/v1/users", `name=Joe`, http.Header{"Content-Type": {"text/plain"}, "X-Tenant": {"acme"}})
+	assert.Equal(http.StatusUnsupportedMediaType, status)
+
+	// As monitor.
+	r := NewRouter().Monitor(validator.Pre, nil)
+	r.HandleFunc("/api/v1/users/{id}", func() error { return nil })
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/users/x", nil))
+	assert.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func TestOpenAPIValidatorResponse(t *testing.T) {
+	assert := assert.New(t)
+	validator, err := NewOpenAPIValidator([]byte(openAPIValidatorSpec))
+	if !assert.NoError(err) {
+		return
+	}
+	validator.ValidateResponses()
+	var response string
+	handler := validator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil))
+		return rr
+	}
+
+	response = `{"name":"Joe"}`
+	rr := serve()
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal(response, rr.Body.String())
+
+	response = `{"name":""}`
+	rr = serve()
+	assert.Equal(http.StatusInternalServerError, rr.Code)
+	assert.Contains(rr.Body.String(), `"param":"body/name"`)
+
+	_, err = NewOpenAPIValidator([]byte("paths: ["))
+	assert.Error(err)
+}
@@ -0,0 +1,256 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyHeaderTimeout is the max time to wait for the PROXY protocol header of a new connection.
+var ProxyHeaderTimeout = 5 * time.Second
+
+// ErrProxyHeader is returned on reading connections having no valid PROXY protocol header.
+var ErrProxyHeader = errors.New("bad PROXY protocol header")
+
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyListener accepts connections having PROXY protocol header, see https://www.haproxy.org/download/2.9/doc/proxy-protocol.txt.
+type proxyListener struct {
+	net.Listener
+}
+
+func (l proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// proxyConn reads the PROXY protocol header on first use, not blocking Accept.
+// net/http asks the remote address before reading the request, so the read deadline set does not interfere with its own deadlines.
+type proxyConn struct {
+	net.Conn
+	reader *bufio.Reader
+	once   sync.Once
+	remote net.Addr
+	info   *ProxyInfo
+	err    error
+}
+
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		if trustedProxiesSet() && !isTrustedProxy(parseAddr(c.Conn.RemoteAddr().String())) {
+			return // Direct connection, no header.
+		}
+		_ = c.Conn.SetReadDeadline(time.Now().Add(ProxyHeaderTimeout))
+		c.remote, c.info, c.err = readProxyHeader(c.reader)
+		_ = c.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the source address of the PROXY protocol header, if any.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader reads PROXY protocol header of version 1 or 2.
+// Returns nil address if the header carries no address, e.g. health checks of the proxy.
+// Returns nil info if the header has no TLVs of interest, as of version 1.
+func readProxyHeader(r *bufio.Reader) (net.Addr, *ProxyInfo, error) {
+	start, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, nil, err
+	}
+	if bytes.Equal(start, proxyV2Signature) {
+		return readProxyHeaderV2(r)
+	}
+	if bytes.HasPrefix(start, []byte("PROXY ")) {
+		addr, err := readProxyHeaderV1(r)
+		return addr, nil, err
+	}
+	return nil, nil, ErrProxyHeader
+}
+
+func readProxyHeaderV1(r *bufio.Reader) (net.Addr, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil || len(line) > 107 || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrProxyHeader
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, ErrProxyHeader
+	}
+	addr, err := netip.ParseAddr(fields[2])
+	if err != nil {
+		return nil, ErrProxyHeader
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, ErrProxyHeader
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, uint16(port))), nil
+}
+
+func readProxyHeaderV2(r *bufio.Reader) (net.Addr, *ProxyInfo, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, ErrProxyHeader
+	}
+	if header[12]>>4 != 2 {
+		return nil, nil, ErrProxyHeader
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, ErrProxyHeader
+	}
+	if header[12]&0xf == 0 { // LOCAL command
+		return nil, nil, nil
+	}
+	switch header[13] >> 4 {
+	case 1: // IPv4
+		if len(payload) < 12 {
+			return nil, nil, ErrProxyHeader
+		}
+		addr := netip.AddrFrom4([4]byte(payload[0:4]))
+		return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, binary.BigEndian.Uint16(payload[8:10]))), parseProxyTLVs(payload[12:]), nil
+	case 2: // IPv6
+		if len(payload) < 36 {
+			return nil, nil, ErrProxyHeader
+		}
+		addr := netip.AddrFrom16([16]byte(payload[0:16]))
+		return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, binary.BigEndian.Uint16(payload[32:34]))), parseProxyTLVs(payload[36:]), nil
+	}
+	return nil, nil, nil // Unix sockets and unspecified
+}
+
+// PROXY protocol v2 TLV types.
+const (
+	proxyTLVALPN         = 0x01
+	proxyTLVAuthority    = 0x02
+	proxyTLVSSL          = 0x20
+	proxyTLVSSLVersion   = 0x21
+	proxyTLVSSLCN        = 0x22
+	proxyTLVSSLCipher    = 0x23
+	proxyClientSSL       = 0x01
+	proxyClientCertConn  = 0x02
+	proxyClientCertSess  = 0x04
+	proxySSLHeaderLength = 5 // Client flags and verify result.
+)
+
+// ProxyInfo is the information of the client connection passed by the proxy in PROXY protocol v2 header, besides the client address.
+// See ProxyInfoFromContext.
+type ProxyInfo struct {
+	ALPN      string        // Application protocol negotiated by the client with the proxy, e.g. "h2".
+	Authority string        // Host name the client asked for, e.g. by SNI.
+	TLS       *ProxyTLSInfo // Set if the client connected to the proxy over TLS.
+}
+
+// ProxyTLSInfo is the TLS connection of the client, terminated by the proxy.
+type ProxyTLSInfo struct {
+	Version    string // E.g. "TLSv1.3".
+	Cipher     string // E.g. "ECDHE-RSA-AES128-GCM-SHA256".
+	ClientCert bool   // Client presented a certificate.
+	Verified   bool   // Client certificate was verified by the proxy.
+	CommonName string // CN of the client certificate.
+}
+
+// walkProxyTLVs calls f for each TLV of the data.
+func walkProxyTLVs(data []byte, f func(typ byte, value []byte)) {
+	for len(data) >= 3 {
+		n := 3 + int(binary.BigEndian.Uint16(data[1:3]))
+		if len(data) < n {
+			return
+		}
+		f(data[0], data[3:n])
+		data = data[n:]
+	}
+}
+
+func parseProxyTLVs(data []byte) (info *ProxyInfo) {
+	walkProxyTLVs(data, func(typ byte, value []byte) {
+		if info == nil && (typ == proxyTLVALPN || typ == proxyTLVAuthority || typ == proxyTLVSSL) {
+			info = &ProxyInfo{}
+		}
+		switch typ {
+		case proxyTLVALPN:
+			info.ALPN = string(value)
+		case proxyTLVAuthority:
+			info.Authority = string(value)
+		case proxyTLVSSL:
+			if len(value) < proxySSLHeaderLength || value[0]&proxyClientSSL == 0 {
+				return
+			}
+			tlsInfo := &ProxyTLSInfo{ClientCert: value[0]&(proxyClientCertConn|proxyClientCertSess) != 0}
+			tlsInfo.Verified = tlsInfo.ClientCert && binary.BigEndian.Uint32(value[1:5]) == 0
+			walkProxyTLVs(value[proxySSLHeaderLength:], func(typ byte, value []byte) {
+				switch typ {
+				case proxyTLVSSLVersion:
+					tlsInfo.Version = string(value)
+				case proxyTLVSSLCipher:
+					tlsInfo.Cipher = string(value)
+				case proxyTLVSSLCN:
+					tlsInfo.CommonName = string(value)
+				}
+			})
+			info.TLS = tlsInfo
+		}
+	})
+	return
+}
+
+type proxyConnCtxKeyType string
+
+const proxyConnCtxName = proxyConnCtxKeyType("restfulProxyConn")
+
+// proxyConnContext stores the PROXY protocol connection in the connection context, as http.Server ConnContext.
+func proxyConnContext(ctx context.Context, c net.Conn) context.Context {
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		c = tlsConn.NetConn()
+	}
+	if conn, ok := c.(*proxyConn); ok {
+		return context.WithValue(ctx, proxyConnCtxName, conn)
+	}
+	return ctx
+}
+
+// ProxyInfoFromContext returns the information passed by the proxy in PROXY protocol v2 header, e.g. the TLS connection of the client.
+// Returns nil if there is none, e.g. the proxy sent no such TLVs or ProxyProtocol is not set. See Server's ProxyProtocol.
+// The client address is taken by ClientIP.
+func ProxyInfoFromContext(ctx context.Context) *ProxyInfo {
+	conn, ok := ctx.Value(proxyConnCtxName).(*proxyConn)
+	if !ok {
+		return nil
+	}
+	conn.init()
+	return conn.info
+}
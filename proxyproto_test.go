@@ -0,0 +1,149 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func proxyV2Header(cmd, family byte, addr []byte) string {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20|cmd, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(len(addr)))
+	return string(append(header, addr...))
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	assert := assert.New(t)
+	read := func(s string) (string, error) {
+		r := bufio.NewReader(strings.NewReader(s + "GET"))
+		addr, _, err := readProxyHeader(r)
+		if err != nil {
+			return "", err
+		}
+		rest, _ := io.ReadAll(r)
+		assert.Equal("GET", string(rest))
+		if addr == nil {
+			return "", nil
+		}
+		return addr.String(), nil
+	}
+
+	addr, err := read("PROXY TCP4 1.2.3.4 10.0.0.1 5555 80\r\n")
+	assert.NoError(err)
+	assert.Equal("1.2.3.4:5555", addr)
+	addr, err = read("PROXY TCP6 2001:db8::1 2001:db8::2 5555 443\r\n")
+	assert.NoError(err)
+	assert.Equal("[2001:db8::1]:5555", addr)
+	addr, err = read("PROXY UNKNOWN\r\n")
+	assert.NoError(err)
+	assert.Empty(addr)
+
+	ipv4 := []byte{1, 2, 3, 4, 10, 0, 0, 1, 0x15, 0xb3, 0, 80}
+	addr, err = read(proxyV2Header(1, 0x11, append(ipv4, 0x04, 0, 1, 'x'))) // With TLV.
+	assert.NoError(err)
+	assert.Equal("1.2.3.4:5555", addr)
+	ipv6 := make([]byte, 36)
+	ipv6[0], ipv6[1], ipv6[15], ipv6[32], ipv6[33] = 0x20, 0x01, 1, 0x15, 0xb3
+	addr, err = read(proxyV2Header(1, 0x21, ipv6))
+	assert.NoError(err)
+	assert.Equal("[2001::1]:5555", addr)
+	addr, err = read(proxyV2Header(0, 0x00, nil)) // LOCAL
+	assert.NoError(err)
+	assert.Empty(addr)
+
+	for _, bad := range []string{"GET / HTTP/1.1\r\n\r\n", "PROXY TCP4 1.2.3.4\r\n", "PROXY TCP4 x 10.0.0.1 5555 80\r\n", "PROXY TCP4 1.2.3.4 10.0.0.1 5555 80\n", proxyV2Header(1, 0x11, ipv4[:4])} {
+		_, err = read(bad)
+		assert.Error(err, bad)
+	}
+}
+
+func TestProxyListener(t *testing.T) {
+	assert := assert.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	server := &http.Server{Handler: clientIPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(ClientIP(r).String()))
+	}))}
+	go func() { _ = server.Serve(proxyListener{ln}) }()
+	defer server.Close()
+
+	request := func(header string) string {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if !assert.NoError(err) {
+			return ""
+		}
+		defer conn.Close()
+		_, _ = io.WriteString(conn, header+"GET / HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			return "error"
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	assert.Equal("1.2.3.4", request("PROXY TCP4 1.2.3.4 10.0.0.1 5555 80\r\n"))
+	assert.Equal("400 Bad Request", request("")) // Header required.
+
+	// Header expected from trusted proxies only.
+	defer func() { _ = SetTrustedProxies() }()
+	assert.NoError(SetTrustedProxies("10.0.0.0/8"))
+	assert.Equal("127.0.0.1", request(""))
+	assert.NoError(SetTrustedProxies("127.0.0.1"))
+	assert.Equal("1.2.3.4", request("PROXY TCP4 1.2.3.4 10.0.0.1 5555 80\r\n"))
+}
+
+func proxyTLV(typ byte, value []byte) []byte {
+	return append([]byte{typ, byte(len(value) >> 8), byte(len(value))}, value...)
+}
+
+func TestProxyInfo(t *testing.T) {
+	assert := assert.New(t)
+	ssl := append([]byte{proxyClientSSL | proxyClientCertConn, 0, 0, 0, 0}, proxyTLV(proxyTLVSSLVersion, []byte("TLSv1.3"))...)
+	ssl = append(ssl, proxyTLV(proxyTLVSSLCN, []byte("client"))...)
+	ssl = append(ssl, proxyTLV(proxyTLVSSLCipher, []byte("TLS_AES_128_GCM_SHA256"))...)
+	tlvs := append(proxyTLV(proxyTLVALPN, []byte("h2")), proxyTLV(proxyTLVAuthority, []byte("example.com"))...)
+	tlvs = append(tlvs, proxyTLV(proxyTLVSSL, ssl)...)
+	tlvs = append(tlvs, proxyTLV(0x04, []byte{'x'})...) // Ignored.
+	ipv4 := []byte{1, 2, 3, 4, 10, 0, 0, 1, 0x15, 0xb3, 0, 80}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	var info *ProxyInfo
+	server := &http.Server{ConnContext: proxyConnContext, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = ProxyInfoFromContext(r.Context())
+	})}
+	go func() { _ = server.Serve(proxyListener{ln}) }()
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+	_, _ = io.WriteString(conn, proxyV2Header(1, 0x11, append(ipv4, tlvs...))+"GET / HTTP/1.1\r\nHost: x\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if assert.NoError(err) {
+		resp.Body.Close()
+	}
+	if assert.NotNil(info) {
+		assert.Equal("h2", info.ALPN)
+		assert.Equal("example.com", info.Authority)
+		assert.Equal(&ProxyTLSInfo{Version: "TLSv1.3", Cipher: "TLS_AES_128_GCM_SHA256", ClientCert: true, Verified: true, CommonName: "client"}, info.TLS)
+	}
+
+	assert.Nil(parseProxyTLVs(proxyTLV(0x04, []byte{'x'})))
+	assert.Nil(parseProxyTLVs(proxyTLV(proxyTLVSSL, []byte{0, 0, 0, 0, 0})).TLS) // Not over TLS.
+	assert.Nil(parseProxyTLVs([]byte{proxyTLVALPN, 0, 5, 'h'}))                  // Truncated.
+	assert.Nil(ProxyInfoFromContext(context.Background()))
+}
@@ -0,0 +1,93 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nokia/restful/logging"
+)
+
+// RequestIDHeader is the header carrying the request ID, received, sent in the response and forwarded by Client.
+// By default "X-Request-Id".
+var RequestIDHeader = "X-Request-Id"
+
+// RequestIDMaxLen is the max length of a received request ID. Longer ones, or ones having non-printable characters, are replaced by a new ID.
+const RequestIDMaxLen = 128
+
+type requestIDCtxKeyType string
+
+const requestIDCtxName = requestIDCtxKeyType("restfulRequestID")
+
+// RequestIDFromContext returns the request ID of the context, or empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDCtxName).(string)
+	return id
+}
+
+// ContextWithRequestID returns a context derived from ctx, holding the request ID.
+// Client functions send that in RequestIDHeader. The logger of the context gets a "request_id" attribute.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	parent := ctx
+	ctx = context.WithValue(ctx, requestIDCtxName, id)
+	return logging.NewContextFunc(ctx, func() *slog.Logger { return logging.FromContext(parent).With("request_id", id) })
+}
+
+// NewRequestID generates a new request ID, a UUIDv7. Being time-ordered, IDs of the logs are easy to sort.
+func NewRequestID() string {
+	if id, err := uuid.NewV7(); err == nil {
+		return id.String()
+	}
+	return uuid.NewString()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > RequestIDMaxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDPre is a monitor pre function, taking the request ID received in RequestIDHeader, or generating a new one.
+// The ID is stored in the request context, see RequestIDFromContext, and is set in the response header.
+// Unlike the trace ID, that is not changed by the services of the call chain, and may be shown to customers for correlation.
+//
+//	router := restful.NewRouter().Monitor(restful.RequestIDPre, nil)
+func RequestIDPre(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = NewRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return r.WithContext(ContextWithRequestID(r.Context(), id))
+}
+
+// RequestID wraps the handler, propagating or generating request IDs. See RequestIDPre.
+//
+//	restful.NewServer().Addr(":8080").Handler(restful.RequestID(router)).ListenAndServe()
+func RequestID(h http.Handler) http.Handler {
+	return Monitor(h, RequestIDPre, nil)
+}
+
+// setRequestIDHeader sets the request ID header according to the context, unless set already.
+func setRequestIDHeader(ctx context.Context, header http.Header) {
+	if header.Get(RequestIDHeader) != "" {
+		return
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		header.Set(RequestIDHeader, id)
+	}
+}
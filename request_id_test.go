@@ -0,0 +1,89 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nokia/restful/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer logging.SetLogger(nil)
+
+	var downstreamID string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamID = r.Header.Get(RequestIDHeader)
+	}))
+	defer downstream.Close()
+
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(ctx context.Context) error {
+		L(ctx).Logger().Info("hello")
+		return NewClient().Get(ctx, downstream.URL, nil)
+	})
+	h := NewAccessLogger().Fields(AccessLogRequestID).Handler(RequestID(router))
+
+	// Received
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal("abc-123", w.Header().Get(RequestIDHeader))
+	assert.Equal("abc-123", downstreamID)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(lines, 2) {
+		var record map[string]any
+		assert.NoError(json.Unmarshal([]byte(lines[0]), &record))
+		assert.Equal("hello", record["msg"])
+		assert.Equal("abc-123", record["request_id"])
+		assert.Equal("/users/{id}", record["route"])
+		assert.NoError(json.Unmarshal([]byte(lines[1]), &record))
+		assert.Equal("access", record["msg"])
+		assert.Equal("abc-123", record["request_id"])
+	}
+
+	// Generated, as invalid received
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(RequestIDHeader, "a b")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	id, err := uuid.Parse(w.Header().Get(RequestIDHeader))
+	assert.NoError(err)
+	assert.Equal(uuid.Version(7), id.Version())
+	assert.Equal(id.String(), downstreamID)
+}
+
+func TestRequestIDContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(RequestIDFromContext(context.Background()))
+
+	ctx := ContextWithRequestID(context.Background(), "abc")
+	assert.Equal("abc", RequestIDFromContext(ctx))
+
+	header := http.Header{}
+	setRequestIDHeader(ctx, header)
+	assert.Equal("abc", header.Get(RequestIDHeader))
+
+	header.Set(RequestIDHeader, "explicit")
+	setRequestIDHeader(ctx, header)
+	assert.Equal("explicit", header.Get(RequestIDHeader))
+
+	assert.False(validRequestID(strings.Repeat("a", RequestIDMaxLen+1)))
+	assert.True(validRequestID(NewRequestID()))
+}
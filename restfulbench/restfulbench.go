@@ -0,0 +1,195 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package restfulbench measures the throughput and allocations of restful routing, request binding and marshaling,
+// on synthetic route tables and payloads, so that performance regressions are caught before release.
+//
+//	func BenchmarkRestful(b *testing.B) { restfulbench.Run(b) }
+//
+// Compare the results of two versions by benchstat.
+package restfulbench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/nokia/restful/messagepack"
+)
+
+// Codec is an encoding of request and response bodies.
+type Codec struct {
+	Name        string
+	ContentType string
+	Marshal     func(v any) ([]byte, error)
+	Unmarshal   func(data []byte, v any) error
+}
+
+// Codecs are the encodings supported by restful.
+var Codecs = []Codec{
+	{Name: "json", ContentType: restful.ContentTypeApplicationJSON, Marshal: json.Marshal, Unmarshal: json.Unmarshal},
+	{Name: "msgpack", ContentType: restful.ContentTypeMsgPack, Marshal: messagepack.Marshal, Unmarshal: messagepack.Unmarshal},
+}
+
+// Sizes used by Run.
+var (
+	RouteCounts  = []int{10, 100, 1000}
+	PayloadSizes = []int{1, 100}
+)
+
+// Item is an element of synthetic payloads, having typical field types.
+type Item struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Count      int               `json:"count"`
+	Price      float64           `json:"price"`
+	Active     bool              `json:"active"`
+	Tags       []string          `json:"tags,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Payload returns n items, the same for the same n.
+func Payload(n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		id := strconv.Itoa(i)
+		items[i] = Item{
+			ID:         "item-" + id,
+			Name:       "Item number " + id,
+			Count:      i,
+			Price:      float64(i) + 0.99,
+			Active:     i%2 == 0,
+			Tags:       []string{"tag-a", "tag-" + id},
+			Attributes: map[string]string{"color": "blue", "size": id},
+		}
+	}
+	return items
+}
+
+// RouteTemplates returns n path templates of a typical REST API, e.g. collections, resources and sub-resources.
+func RouteTemplates(n int) []string {
+	templates := make([]string, n)
+	for i := range templates {
+		base := fmt.Sprintf("/api/v1/resource%d", i/3)
+		switch i % 3 {
+		case 0:
+			templates[i] = base
+		case 1:
+			templates[i] = base + "/{id}"
+		default:
+			templates[i] = base + "/{id}/items/{item}"
+		}
+	}
+	return templates
+}
+
+// RoutePaths returns a request path for each template, path variables substituted.
+func RoutePaths(templates []string) []string {
+	replacer := strings.NewReplacer("{id}", "42", "{item}", "7")
+	paths := make([]string, len(templates))
+	for i, template := range templates {
+		paths[i] = replacer.Replace(template)
+	}
+	return paths
+}
+
+// NewRouter creates a router of the templates, responding 204 No Content to GET requests, so that routing cost dominates.
+func NewRouter(templates []string) *restful.Router {
+	r := restful.NewRouter()
+	noContent := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	for _, template := range templates {
+		r.HandleFunc(template, noContent).Methods(http.MethodGet)
+	}
+	return r
+}
+
+// Routing measures routing requests to all the routes of a router of the number of routes given, in turn.
+func Routing(b *testing.B, routes int) {
+	templates := RouteTemplates(routes)
+	router := NewRouter(templates)
+	requests := make([]*http.Request, routes)
+	for i, path := range RoutePaths(templates) {
+		requests[i] = httptest.NewRequest(http.MethodGet, path, nil)
+	}
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		router.ServeHTTP(w, requests[i%routes])
+	}
+}
+
+// Binding measures serving a Lambda function taking a path variable and a request body of the items given, and responding those.
+func Binding(b *testing.B, codec Codec, items int) {
+	router := restful.NewRouter()
+	router.HandleFunc("/api/v1/orders/{id}/items", func(ctx context.Context, items []Item) ([]Item, error) {
+		_ = restful.L(ctx).RequestVars()["id"]
+		return items, nil
+	}).Methods(http.MethodPost)
+	body, err := codec.Marshal(Payload(items))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders/42/items", bytes.NewReader(body))
+		req.Header.Set(restful.ContentTypeHeader, codec.ContentType)
+		req.Header.Set(restful.AcceptHeader, codec.ContentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("status %d: %s", w.Code, w.Body.Bytes())
+		}
+	}
+}
+
+// Marshaling measures encoding and decoding the items given by the codec.
+func Marshaling(b *testing.B, codec Codec, items int) {
+	payload := Payload(items)
+	body, err := codec.Marshal(payload)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		body, _ = codec.Marshal(payload)
+		var decoded []Item
+		if err := codec.Unmarshal(body, &decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Run runs all the benchmarks as sub-benchmarks, of RouteCounts, Codecs and PayloadSizes.
+// Names are stable, e.g. "Routing/routes=100" or "Binding/json/items=1", so that results of versions can be compared.
+func Run(b *testing.B) {
+	b.Run("Routing", func(b *testing.B) {
+		for _, n := range RouteCounts {
+			b.Run("routes="+strconv.Itoa(n), func(b *testing.B) { Routing(b, n) })
+		}
+	})
+	for _, bench := range []struct {
+		name string
+		f    func(*testing.B, Codec, int)
+	}{{"Binding", Binding}, {"Marshaling", Marshaling}} {
+		b.Run(bench.name, func(b *testing.B) {
+			for _, codec := range Codecs {
+				for _, n := range PayloadSizes {
+					b.Run(codec.Name+"/items="+strconv.Itoa(n), func(b *testing.B) { bench.f(b, codec, n) })
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfulbench
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkRestful(b *testing.B) {
+	Run(b)
+}
+
+func TestRoutes(t *testing.T) {
+	assert := assert.New(t)
+	templates := RouteTemplates(5)
+	assert.Equal([]string{"/api/v1/resource0", "/api/v1/resource0/{id}", "/api/v1/resource0/{id}/items/{item}", "/api/v1/resource1", "/api/v1/resource1/{id}"}, templates)
+	paths := RoutePaths(templates)
+	assert.Equal("/api/v1/resource0/42/items/7", paths[2])
+
+	router := NewRouter(templates)
+	for _, path := range paths {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(http.StatusNoContent, w.Code, path)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(Payload(3), Payload(3))
+	for _, codec := range Codecs {
+		body, err := codec.Marshal(Payload(3))
+		assert.NoError(err)
+		var items []Item
+		assert.NoError(codec.Unmarshal(body, &items), codec.Name)
+		assert.Equal(Payload(3), items, codec.Name)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package restfultest contains helpers for testing applications built on restful.
+package restfultest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/nokia/restful"
+)
+
+// RecordEnv is the environment variable that switches cassettes created by UseCassette to record mode, if set to non-empty value.
+// E.g. RESTFUL_RECORD=1 go test ./...
+const RecordEnv = "RESTFUL_RECORD"
+
+// Redacted is the value secrets are replaced with in the recorded cassettes.
+const Redacted = "REDACTED"
+
+// ErrInteractionNotFound is returned in replay mode if there is no recorded interaction matching the request.
+var ErrInteractionNotFound = errors.New("no recorded interaction")
+
+// Mode of a cassette.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette file, without network access.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests and records request/response pairs to be saved to the cassette file.
+	ModeRecord
+)
+
+// DefaultRedactedHeaders are the headers which values are redacted on recording.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Headers that are not recorded, as their values are random.
+var volatileHeaders = []string{"B3", "X-B3-Traceid", "X-B3-Spanid", "X-B3-Parentspanid", "X-B3-Sampled", "X-B3-Flags", "Traceparent", "Tracestate", "Baggage", "X-Request-Id"}
+
+type recordedMessage struct {
+	Method     string      `json:"method,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	StatusCode int         `json:"status,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"bodyBase64,omitempty"`
+}
+
+type interaction struct {
+	Request  recordedMessage `json:"request"`
+	Response recordedMessage `json:"response"`
+	used     bool
+}
+
+// Cassette records request/response pairs of a restful Client to a golden file, and replays them later.
+// Secrets are redacted from the recording.
+type Cassette struct {
+	path            string
+	mode            Mode
+	mutex           sync.Mutex
+	interactions    []*interaction
+	redactedHeaders []string
+	redactedFields  []string
+	ignoreBody      bool
+}
+
+// NewCassette creates a cassette for the file at the given path.
+// In replay mode the file is loaded.
+func NewCassette(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, redactedHeaders: DefaultRedactedHeaders}
+	if mode == ModeReplay {
+		b, err := os.ReadFile(path) // #nosec G304 -- test fixture path
+		if err != nil {
+			return nil, err
+		}
+		var file struct {
+			Interactions []*interaction `json:"interactions"`
+		}
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("bad cassette %s: %w", path, err)
+		}
+		c.interactions = file.Interactions
+	}
+	return c, nil
+}
+
+// UseCassette creates a cassette for test t and attaches that to the client.
+// The cassette is in record mode if RecordEnv is set, otherwise in replay mode.
+// Recorded interactions are saved at the end of the test.
+//
+//	client := restful.NewClient()
+//	restfultest.UseCassette(t, "testdata/users.json", client)
+func UseCassette(t testing.TB, path string, client *restful.Client) *Cassette {
+	t.Helper()
+	mode := ModeReplay
+	if os.Getenv(RecordEnv) != "" {
+		mode = ModeRecord
+	}
+	c, err := NewCassette(path, mode)
+	if err != nil {
+		t.Fatalf("cassette: %v", err)
+	}
+	c.Attach(client)
+	t.Cleanup(func() {
+		if err := c.Save(); err != nil {
+			t.Errorf("cassette: %v", err)
+		}
+	})
+	return c
+}
+
+// Mode returns the mode of the cassette.
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// RedactHeaders sets the headers which values are replaced by Redacted on recording.
+// Overrides DefaultRedactedHeaders.
+func (c *Cassette) RedactHeaders(headers ...string) *Cassette {
+	c.redactedHeaders = headers
+	return c
+}
+
+// RedactJSONFields sets JSON object fields, at any depth, which values are replaced by Redacted on recording.
+// E.g. "password", "access_token".
+func (c *Cassette) RedactJSONFields(fields ...string) *Cassette {
+	c.redactedFields = fields
+	return c
+}
+
+// IgnoreBody makes replay match requests on method and URL only.
+// By default request bodies must match, too.
+func (c *Cassette) IgnoreBody() *Cassette {
+	c.ignoreBody = true
+	return c
+}
+
+// Attach attaches the cassette to the client.
+func (c *Cassette) Attach(client *restful.Client) *restful.Client {
+	if c.mode == ModeRecord {
+		return client.Monitor(nil, c.record)
+	}
+	return client.Monitor(c.replay, nil)
+}
+
+// Save writes the recorded interactions to the cassette file. Does nothing in replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	b, err := json.MarshalIndent(struct {
+		Interactions []*interaction `json:"interactions"`
+	}{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(b, '\n'), 0o600)
+}
+
+func (c *Cassette) redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	h := header.Clone()
+	for _, name := range volatileHeaders {
+		h.Del(name)
+	}
+	for _, name := range c.redactedHeaders {
+		if values := h.Values(name); len(values) > 0 {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+func redactJSONValue(v any, fields []string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k := range val {
+			redacted := false
+			for _, field := range fields {
+				if strings.EqualFold(k, field) {
+					val[k] = Redacted
+					redacted = true
+					break
+				}
+			}
+			if !redacted {
+				val[k] = redactJSONValue(val[k], fields)
+			}
+		}
+	case []any:
+		for i := range val {
+			val[i] = redactJSONValue(val[i], fields)
+		}
+	}
+	return v
+}
+
+func (c *Cassette) redactBody(body []byte) []byte {
+	if len(c.redactedFields) == 0 || len(body) == 0 {
+		return body
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	redacted, err := json.Marshal(redactJSONValue(v, c.redactedFields))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func setBody(m *recordedMessage, body []byte) {
+	if utf8.Valid(body) {
+		m.Body = string(body)
+	} else {
+		m.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+}
+
+func (m *recordedMessage) body() []byte {
+	if m.BodyBase64 != "" {
+		b, _ := base64.StdEncoding.DecodeString(m.BodyBase64)
+		return b
+	}
+	return []byte(m.Body)
+}
+
+func readReqBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	b, _ := io.ReadAll(body)
+	return b
+}
+
+func (c *Cassette) record(req *http.Request, resp *http.Response, err error) *http.Response {
+	if err != nil || resp == nil {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	i := interaction{
+		Request:  recordedMessage{Method: req.Method, URL: req.URL.String(), Header: c.redactHeader(req.Header)},
+		Response: recordedMessage{StatusCode: resp.StatusCode, Header: c.redactHeader(resp.Header)},
+	}
+	setBody(&i.Request, c.redactBody(readReqBody(req)))
+	setBody(&i.Response, c.redactBody(respBody))
+
+	c.mutex.Lock()
+	c.interactions = append(c.interactions, &i)
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *Cassette) matches(i *interaction, req *http.Request, body []byte) bool {
+	if i.Request.Method != req.Method || i.Request.URL != req.URL.String() {
+		return false
+	}
+	return c.ignoreBody || bytes.Equal(i.Request.body(), c.redactBody(body))
+}
+
+func (c *Cassette) find(req *http.Request) *interaction {
+	body := readReqBody(req)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var lastUsed *interaction
+	for _, i := range c.interactions {
+		if c.matches(i, req, body) {
+			if !i.used {
+				i.used = true
+				return i
+			}
+			lastUsed = i
+		}
+	}
+	return lastUsed // Repeated requests, e.g. polling, get the last matching response.
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	i := c.find(req)
+	if i == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+	}
+
+	header := i.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	body := i.Response.body()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type token struct {
+	Token string `json:"token"`
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "golden", "login.json")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c credentials
+		assert.NoError(restful.GetRequestData(r, 0, &c))
+		w.Header().Set("Set-Cookie", "session=secret")
+		restful.SendResp(w, r, nil, token{Token: "t-" + c.User})
+	}))
+	url := srv.URL + "/login"
+
+	// Record
+	cassette, err := NewCassette(path, ModeRecord)
+	assert.NoError(err)
+	cassette.RedactJSONFields("password")
+	client := cassette.Attach(restful.NewClient().SetBasicAuth("admin", "pwd"))
+
+	var tok token
+	_, err = client.Post(context.Background(), url, &credentials{User: "joe", Password: "pwd"}, &tok)
+	assert.NoError(err)
+	assert.Equal("t-joe", tok.Token)
+	assert.NoError(cassette.Save())
+	srv.Close()
+
+	recorded, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.NotContains(string(recorded), "pwd")
+	assert.NotContains(string(recorded), "session=secret")
+	assert.Contains(string(recorded), Redacted)
+
+	// Replay without server
+	cassette, err = NewCassette(path, ModeReplay)
+	assert.NoError(err)
+	cassette.RedactJSONFields("password")
+	client = cassette.Attach(restful.NewClient())
+
+	tok = token{}
+	_, err = client.Post(context.Background(), url, &credentials{User: "joe", Password: "other"}, &tok)
+	assert.NoError(err)
+	assert.Equal("t-joe", tok.Token)
+
+	_, err = client.Post(context.Background(), url, &credentials{User: "jane"}, &tok)
+	assert.ErrorIs(err, ErrInteractionNotFound)
+
+	cassette.IgnoreBody()
+	_, err = client.Post(context.Background(), url, &credentials{User: "jane"}, &tok)
+	assert.NoError(err)
+}
+
+func TestUseCassette(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "get.json")
+	assert.NoError(os.WriteFile(path, []byte(`{"interactions":[
+		{"request":{"method":"GET","url":"https://example.com/users/1"},
+		 "response":{"status":200,"header":{"Content-Type":["application/json"]},"body":"{\"name\":\"Joe\"}"}},
+		{"request":{"method":"GET","url":"https://example.com/users/2"},"response":{"status":404}}]}`), 0o600))
+
+	client := restful.NewClient()
+	cassette := UseCassette(t, path, client)
+	assert.Equal(ModeReplay, cassette.Mode())
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	assert.NoError(client.Get(context.Background(), "https://example.com/users/1", &user))
+	assert.Equal("Joe", user.Name)
+
+	err := client.Get(context.Background(), "https://example.com/users/2", &user)
+	assert.Equal(http.StatusNotFound, restful.GetErrStatusCode(err))
+
+	_, err = NewCassette(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	assert.Error(err)
+}
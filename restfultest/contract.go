@@ -0,0 +1,363 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nokia/restful"
+)
+
+// DefaultContractHeaders are the headers recorded to contracts, if present. Other headers, e.g. authorization or tracing, are not part of the contract.
+var DefaultContractHeaders = []string{"Content-Type", "Accept"}
+
+// ContractParty is the consumer or provider of a contract.
+type ContractParty struct {
+	Name string `json:"name"`
+}
+
+// ContractRequest is the request of an interaction.
+type ContractRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// ContractResponse is the response expected of an interaction.
+type ContractResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// ContractInteraction is a request and the response expected by the consumer, in the provider state given.
+type ContractInteraction struct {
+	Description   string           `json:"description"`
+	ProviderState string           `json:"providerState,omitempty"`
+	Request       ContractRequest  `json:"request"`
+	Response      ContractResponse `json:"response"`
+}
+
+// Contract is a consumer-driven contract, in Pact specification v2 format.
+// Consumers record the interactions of their Client, e.g. with a MockServer, and hand the contract file over to the provider.
+// Providers verify their Router against the contracts in CI.
+//
+// Matching rules of Pact are not supported. Response bodies are matched leniently: objects may have extra fields, arrays and other values must be equal.
+type Contract struct {
+	Consumer     ContractParty         `json:"consumer"`
+	Provider     ContractParty         `json:"provider"`
+	Interactions []ContractInteraction `json:"interactions"`
+	Metadata     map[string]any        `json:"metadata,omitempty"`
+
+	mutex       sync.Mutex
+	headers     []string
+	state       string
+	description string
+}
+
+// NewContract creates an empty contract between the consumer and the provider.
+func NewContract(consumer, provider string) *Contract {
+	return &Contract{
+		Consumer: ContractParty{Name: consumer},
+		Provider: ContractParty{Name: provider},
+		Metadata: map[string]any{"pactSpecification": map[string]string{"version": "2.0.0"}},
+		headers:  DefaultContractHeaders,
+	}
+}
+
+// RecordContract creates a contract, records the interactions of the client, and saves the contract to dir at the end of the test,
+// unless the test failed. The file is named as usual for Pact, e.g. "pacts/web-users.json".
+//
+//	m := restfultest.NewMockServer(t)
+//	m.Expect(http.MethodGet, "/users/1").Respond(http.StatusOK, &joe)
+//	client := restful.NewClient()
+//	contract := restfultest.RecordContract(t, "pacts", "web", "users", client)
+//	contract.Given("user 1 exists").UponReceiving("get user 1")
+//	_, err := client.Get(ctx, m.URL+"/users/1", &user)
+func RecordContract(t testing.TB, dir, consumer, provider string, client *restful.Client) *Contract {
+	t.Helper()
+	c := NewContract(consumer, provider)
+	c.Attach(client)
+	t.Cleanup(func() {
+		if t.Failed() {
+			return
+		}
+		if err := c.Save(filepath.Join(dir, consumer+"-"+provider+".json")); err != nil {
+			t.Errorf("contract: %v", err)
+		}
+	})
+	return c
+}
+
+// LoadContract loads a contract file.
+func LoadContract(path string) (*Contract, error) {
+	b, err := os.ReadFile(path) // #nosec G304 -- test fixture path
+	if err != nil {
+		return nil, err
+	}
+	c := &Contract{headers: DefaultContractHeaders}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("bad contract %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Headers sets the headers recorded, if present. Overrides DefaultContractHeaders.
+func (c *Contract) Headers(headers ...string) *Contract {
+	c.headers = headers
+	return c
+}
+
+// Given sets the provider state of the next interaction recorded, e.g. "user 1 exists".
+func (c *Contract) Given(state string) *Contract {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.state = state
+	return c
+}
+
+// UponReceiving sets the description of the next interaction recorded. Defaults to method and path, e.g. "GET /users/1".
+func (c *Contract) UponReceiving(description string) *Contract {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.description = description
+	return c
+}
+
+// Attach makes the contract record the interactions of the client.
+// Requests answered without sending, e.g. by MockClient or a Cassette replaying, are not recorded. Use MockServer instead.
+func (c *Contract) Attach(client *restful.Client) *restful.Client {
+	return client.Monitor(nil, c.record)
+}
+
+// Save writes the contract to the file.
+func (c *Contract) Save(path string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
+
+func (c *Contract) contractHeaders(header http.Header) map[string]string {
+	var headers map[string]string
+	for _, name := range c.headers {
+		if value := header.Get(name); value != "" {
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return headers
+}
+
+// contractBody returns the JSON value of the body, or the body as string if not JSON.
+func contractBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		return v
+	}
+	return string(body)
+}
+
+func (c *Contract) record(req *http.Request, resp *http.Response, err error) *http.Response {
+	if err != nil || resp == nil {
+		return nil
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	i := ContractInteraction{
+		Request: ContractRequest{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   req.URL.RawQuery,
+			Headers: c.contractHeaders(req.Header),
+			Body:    contractBody(readReqBody(req)),
+		},
+		Response: ContractResponse{Status: resp.StatusCode, Headers: c.contractHeaders(resp.Header), Body: contractBody(respBody)},
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	i.ProviderState, i.Description = c.state, c.description
+	c.state, c.description = "", ""
+	if i.Description == "" {
+		i.Description = req.Method + " " + req.URL.Path
+	}
+	for _, recorded := range c.Interactions {
+		if recorded.Description == i.Description && recorded.ProviderState == i.ProviderState {
+			return nil // Repeated, e.g. polling. The first one is kept.
+		}
+	}
+	c.Interactions = append(c.Interactions, i)
+	return nil
+}
+
+// Verify sends the requests of the contract to the handler of the provider, typically a Router, and checks the responses.
+// States maps provider states to functions setting those up, e.g. storing user 1. An interaction having a state not set up fails.
+// Failures are reported to t. Returns true if all interactions passed.
+func (c *Contract) Verify(t testing.TB, handler http.Handler, states map[string]func()) bool {
+	t.Helper()
+	ok := true
+	for _, i := range c.Interactions {
+		if err := c.verify(handler, &i, states); err != nil {
+			t.Errorf("contract %s-%s: %q: %v", c.Consumer.Name, c.Provider.Name, i.Description, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// VerifyContracts verifies the handler against the contract files matching the pattern, e.g. "pacts/*-users.json". See Verify.
+func VerifyContracts(t testing.TB, handler http.Handler, pattern string, states map[string]func()) bool {
+	t.Helper()
+	paths, err := filepath.Glob(pattern)
+	if err == nil && len(paths) == 0 {
+		err = fmt.Errorf("no contract files %s", pattern)
+	}
+	if err != nil {
+		t.Errorf("contract: %v", err)
+		return false
+	}
+	ok := true
+	for _, path := range paths {
+		c, err := LoadContract(path)
+		if err != nil {
+			t.Errorf("contract: %v", err)
+			ok = false
+			continue
+		}
+		ok = c.Verify(t, handler, states) && ok
+	}
+	return ok
+}
+
+func (c *Contract) verify(handler http.Handler, i *ContractInteraction, states map[string]func()) error {
+	if i.ProviderState != "" {
+		setup, ok := states[i.ProviderState]
+		if !ok {
+			return fmt.Errorf("provider state %q not set up", i.ProviderState)
+		}
+		setup()
+	}
+
+	req, err := i.Request.httpRequest()
+	if err != nil {
+		return err
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != i.Response.Status {
+		return fmt.Errorf("status %d, expected %d: %s", w.Code, i.Response.Status, w.Body.Bytes())
+	}
+	for name, expected := range i.Response.Headers {
+		if actual := w.Header().Get(name); !headerMatches(name, expected, actual) {
+			return fmt.Errorf("header %s %q, expected %q", name, actual, expected)
+		}
+	}
+	if i.Response.Body != nil {
+		if actual := contractBody(w.Body.Bytes()); !bodyMatches(i.Response.Body, actual) {
+			return fmt.Errorf("body %s does not match %s", w.Body.Bytes(), mustJSON(i.Response.Body))
+		}
+	}
+	return nil
+}
+
+func (r *ContractRequest) httpRequest() (*http.Request, error) {
+	var body io.Reader
+	switch b := r.Body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		body = bytes.NewReader(mustJSON(b))
+	}
+	target := r.Path
+	if r.Query != "" {
+		target += "?" + r.Query
+	}
+	req, err := http.NewRequest(r.Method, "http://"+TesterHost+target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+func mustJSON(v any) []byte {
+	b, _ := json.Marshal(v) // Values decoded from JSON.
+	return b
+}
+
+// headerMatches compares header values. Content types are compared by media type, ignoring parameters like charset.
+func headerMatches(name, expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	if !strings.EqualFold(name, restful.ContentTypeHeader) {
+		return false
+	}
+	e, _, err1 := mime.ParseMediaType(expected)
+	a, _, err2 := mime.ParseMediaType(actual)
+	return errors.Join(err1, err2) == nil && e == a
+}
+
+// bodyMatches tells if the actual body satisfies the expected one. Objects may have extra fields.
+func bodyMatches(expected, actual any) bool {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range e {
+			if av, found := a[k]; !found || !bodyMatches(v, av) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !bodyMatches(e[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return expected == actual
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type contractUser struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+func recordUsersContract(t *testing.T, dir string) {
+	assert := assert.New(t)
+	m := NewMockServer(t)
+	m.Expect(http.MethodPost, "/users").WithBody(&contractUser{Name: "joe"}).RespondHeader("Location", "/users/1").Respond(http.StatusCreated, nil)
+	m.Expect(http.MethodGet, "/users/1").Respond(http.StatusOK, &contractUser{ID: "1", Name: "joe"}).Times(2)
+	m.Expect(http.MethodGet, "/users/2").Respond(http.StatusNotFound, nil)
+
+	client := restful.NewClient()
+	contract := RecordContract(t, dir, "web", "users", client)
+	ctx := context.Background()
+
+	_, err := client.Post(ctx, m.URL+"/users", &contractUser{Name: "joe"}, nil)
+	assert.NoError(err)
+	var user contractUser
+	contract.Given("user 1 exists").UponReceiving("get user 1")
+	err = client.Get(ctx, m.URL+"/users/1", &user)
+	assert.NoError(err)
+	contract.Given("user 1 exists").UponReceiving("get user 1")
+	err = client.Get(ctx, m.URL+"/users/1", &user)
+	assert.NoError(err)
+	err = client.Get(ctx, m.URL+"/users/2", &user)
+	assert.Error(err)
+	m.AssertExpectations(t)
+}
+
+func newUsersProvider(users map[string]string) *restful.Router {
+	r := restful.NewRouter()
+	r.HandleFunc("/users", func(ctx context.Context, u contractUser) error {
+		users["1"] = u.Name
+		restful.L(ctx).ResponseStatus(http.StatusCreated)
+		return nil
+	}).Methods(http.MethodPost)
+	r.HandleFunc("/users/{id}", func(ctx context.Context) (map[string]any, error) {
+		id := restful.L(ctx).RequestVars()["id"]
+		name, ok := users[id]
+		if !ok {
+			return nil, restful.NewError(nil, http.StatusNotFound)
+		}
+		return map[string]any{"id": id, "name": name, "created": "today"}, nil
+	}).Methods(http.MethodGet)
+	return r
+}
+
+func TestContract(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	t.Run("consumer", func(t *testing.T) { recordUsersContract(t, dir) })
+
+	path := filepath.Join(dir, "web-users.json")
+	contract, err := LoadContract(path)
+	assert.NoError(err)
+	assert.Equal("web", contract.Consumer.Name)
+	assert.Equal("users", contract.Provider.Name)
+	if assert.Len(contract.Interactions, 3) {
+		assert.Equal("POST /users", contract.Interactions[0].Description)
+		assert.Equal(map[string]any{"name": "joe"}, contract.Interactions[0].Request.Body)
+		assert.Equal("application/json", contract.Interactions[0].Request.Headers["Content-Type"])
+		assert.Equal("get user 1", contract.Interactions[1].Description)
+		assert.Equal("user 1 exists", contract.Interactions[1].ProviderState)
+		assert.Equal(http.StatusNotFound, contract.Interactions[2].Response.Status)
+	}
+
+	users := map[string]string{}
+	provider := newUsersProvider(users)
+	assert.True(VerifyContracts(t, provider, filepath.Join(dir, "*-users.json"), map[string]func(){
+		"user 1 exists": func() { users["1"] = "joe" },
+	}))
+
+	// Broken provider
+	rt := &recordingT{TB: t}
+	users = map[string]string{}
+	assert.False(contract.Verify(rt, newUsersProvider(users), map[string]func(){"user 1 exists": func() { users["1"] = "jane" }}))
+	assert.Len(rt.errors, 1)
+	assert.Contains(rt.errors[0], `"get user 1": body`)
+
+	rt = &recordingT{TB: t}
+	assert.False(contract.Verify(rt, provider, nil))
+	assert.Contains(rt.errors[0], `provider state "user 1 exists" not set up`)
+
+	rt = &recordingT{TB: t}
+	assert.False(VerifyContracts(rt, provider, filepath.Join(dir, "*-orders.json"), nil))
+	assert.Contains(rt.errors[0], "no contract files")
+}
+
+func TestContractNotSavedOnFailure(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	rt := &recordingT{TB: t}
+	failing := &failedT{recordingT: rt}
+	RecordContract(failing, dir, "web", "users", restful.NewClient())
+	failing.cleanup()
+	_, err := os.Stat(filepath.Join(dir, "web-users.json"))
+	assert.True(os.IsNotExist(err))
+}
+
+type failedT struct {
+	*recordingT
+	cleanups []func()
+}
+
+func (t *failedT) Failed() bool { return true }
+
+func (t *failedT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *failedT) cleanup() {
+	for _, f := range t.cleanups {
+		f()
+	}
+}
+
+func TestBodyMatches(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(bodyMatches(map[string]any{"a": 1.0}, map[string]any{"a": 1.0, "b": "x"}))
+	assert.False(bodyMatches(map[string]any{"a": 1.0}, map[string]any{"b": 1.0}))
+	assert.False(bodyMatches([]any{1.0}, []any{1.0, 2.0}))
+	assert.True(bodyMatches([]any{map[string]any{"a": true}}, []any{map[string]any{"a": true, "b": nil}}))
+	assert.True(bodyMatches("text", "text"))
+	assert.True(headerMatches("Content-Type", "application/json", "application/json; charset=utf-8"))
+	assert.False(headerMatches("Accept", "application/json", "text/plain"))
+}
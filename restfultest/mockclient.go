@@ -0,0 +1,267 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+)
+
+// ErrUnexpectedRequest is returned by MockClient if a request does not match any expectation.
+var ErrUnexpectedRequest = errors.New("unexpected request")
+
+// Expectation is an expected request of MockClient or MockServer and its canned response.
+type Expectation struct {
+	method      string
+	url         string
+	header      http.Header
+	bodyMatcher func(body []byte) bool
+	times       int // Negative means any times.
+	calls       int
+
+	status     int
+	respHeader http.Header
+	respBody   []byte
+	delay      time.Duration
+	err        error
+}
+
+// expectations are the expected requests of a mock, and the unexpected ones received.
+type expectations struct {
+	mutex        sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// MockClient is an in-memory test double of restful.Client.
+// Requests are matched against expectations and canned responses are returned without network access.
+// As it uses a real restful.Client underneath, the behavior of functions, e.g. SendRecv2xx error handling, is the same.
+//
+//	m := restfultest.NewMockClient()
+//	m.Expect(http.MethodGet, "/users/1").Respond(http.StatusOK, &user)
+//	svc := NewService(m) // Service accepts restful.ClientInterface.
+//	...
+//	m.AssertExpectations(t)
+type MockClient struct {
+	*restful.Client
+	expectations
+}
+
+var _ restful.ClientInterface = (*MockClient)(nil)
+
+// NewMockClient creates a new mock client.
+func NewMockClient() *MockClient {
+	m := &MockClient{}
+	m.Client = restful.NewClient().Monitor(m.serve, nil)
+	return m
+}
+
+// Expect adds an expectation of a request.
+// URL may be absolute, or a path with optional query, e.g. "/users?name=joe".
+// By default the expectation is to be met once and is answered by 200 OK without body.
+func (m *expectations) Expect(method, url string) *Expectation {
+	e := &Expectation{method: method, url: url, times: 1, status: http.StatusOK}
+	m.mutex.Lock()
+	m.expectations = append(m.expectations, e)
+	m.mutex.Unlock()
+	return e
+}
+
+// WithHeader expects the request to contain the header value.
+func (e *Expectation) WithHeader(header, value string) *Expectation {
+	if e.header == nil {
+		e.header = make(http.Header)
+	}
+	e.header.Add(header, value)
+	return e
+}
+
+// WithBody expects the JSON request body to be equivalent to the JSON encoding of data.
+func (e *Expectation) WithBody(data any) *Expectation {
+	expected, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	return e.WithBodyMatcher(func(body []byte) bool { return jsonEqual(expected, body) })
+}
+
+// WithBodyMatcher expects the request body to be accepted by the matcher function.
+func (e *Expectation) WithBodyMatcher(matcher func(body []byte) bool) *Expectation {
+	e.bodyMatcher = matcher
+	return e
+}
+
+// Times sets how many times the request is expected.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes makes the request expected any times, including zero.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.times = -1
+	return e
+}
+
+// RespondHeader adds a header to the response.
+func (e *Expectation) RespondHeader(header, value string) *Expectation {
+	if e.respHeader == nil {
+		e.respHeader = make(http.Header)
+	}
+	e.respHeader.Add(header, value)
+	return e
+}
+
+// Respond sets status code and data to be sent in JSON body. Data may be nil.
+func (e *Expectation) Respond(status int, data any) *Expectation {
+	e.status = status
+	e.respBody = nil
+	if data != nil {
+		body, err := json.Marshal(data)
+		if err != nil {
+			panic(err)
+		}
+		e.respBody = body
+		e.RespondHeader(restful.ContentTypeHeader, restful.ContentTypeApplicationJSON)
+	}
+	return e
+}
+
+// RespondError makes the request fail with the error, like a transport error.
+// MockServer closes the connection without response instead.
+func (e *Expectation) RespondError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Delay delays the response, e.g. to test timeouts. The request is failed if canceled meanwhile.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// wait waits the delay of the response, returning error if the context is done meanwhile.
+func (e *Expectation) wait(ctx context.Context) error {
+	if e.delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(e.delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func jsonEqual(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func (e *Expectation) matches(req *http.Request, body []byte) bool {
+	if e.times >= 0 && e.calls >= e.times {
+		return false
+	}
+	if e.method != req.Method || (e.url != req.URL.String() && e.url != req.URL.RequestURI() && e.url != req.URL.Path) {
+		return false
+	}
+	for name, values := range e.header {
+		for _, value := range values {
+			found := false
+			for _, got := range req.Header.Values(name) {
+				found = found || got == value
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return e.bodyMatcher == nil || e.bodyMatcher(body)
+}
+
+// match returns the first expectation matching the request, counting the call. Unexpected requests are recorded.
+func (m *expectations) match(req *http.Request, body []byte) (*Expectation, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, e := range m.expectations {
+		if e.matches(req, body) {
+			e.calls++
+			return e, nil
+		}
+	}
+	call := req.Method + " " + req.URL.String()
+	m.unexpected = append(m.unexpected, call)
+	return nil, fmt.Errorf("%w: %s", ErrUnexpectedRequest, call)
+}
+
+func (m *MockClient) serve(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+	}
+
+	e, err := m.match(req, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	header := e.respHeader.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.respBody)),
+		ContentLength: int64(len(e.respBody)),
+		Request:       req,
+	}, nil
+}
+
+// AssertExpectations reports test errors for unmet expectations and unexpected requests.
+// Returns true if all went fine.
+func (m *expectations) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ok := true
+	for _, e := range m.expectations {
+		if e.times >= 0 && e.calls != e.times {
+			t.Errorf("expected %s %s %d times, called %d times", e.method, e.url, e.times, e.calls)
+			ok = false
+		}
+	}
+	for _, call := range m.unexpected {
+		t.Errorf("unexpected request: %s", call)
+		ok = false
+	}
+	return ok
+}
@@ -0,0 +1,79 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func createUser(ctx context.Context, c restful.ClientInterface, name string) (string, error) {
+	location, err := c.Post(ctx, "/users", &user{Name: name}, nil)
+	if err != nil {
+		return "", err
+	}
+	return location.String(), nil
+}
+
+func TestMockClient(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMockClient()
+	m.Expect(http.MethodPost, "/users").WithBody(user{Name: "Joe"}).RespondHeader("Location", "/users/1").Respond(http.StatusCreated, nil)
+	m.Expect(http.MethodGet, "/users/1").WithHeader("X-Tenant", "a").Respond(http.StatusOK, user{Name: "Joe"}).Times(2)
+	m.Expect(http.MethodDelete, "https://example.com/users/1").Respond(http.StatusNotFound, nil)
+	m.Expect(http.MethodGet, "/health").RespondError(errors.New("connection refused")).AnyTimes()
+
+	location, err := createUser(context.Background(), m, "Joe")
+	assert.NoError(err)
+	assert.Equal("/users/1", location)
+
+	for i := 0; i < 2; i++ {
+		var u user
+		_, err = m.SendRecv2xx(context.Background(), http.MethodGet, "/users/1", http.Header{"X-Tenant": {"a"}}, nil, &u)
+		assert.NoError(err)
+		assert.Equal("Joe", u.Name)
+	}
+
+	err = m.Delete(context.Background(), "https://example.com/users/1")
+	assert.Equal(http.StatusNotFound, restful.GetErrStatusCode(err))
+
+	assert.Error(m.Get(context.Background(), "/health", nil))
+	assert.True(m.AssertExpectations(t))
+}
+
+func TestMockClientUnexpected(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMockClient()
+	m.Expect(http.MethodPost, "/users").WithBody(user{Name: "Joe"})
+
+	_, err := createUser(context.Background(), m, "Jane")
+	assert.ErrorIs(err, ErrUnexpectedRequest)
+
+	fake := &testing.T{}
+	assert.False(m.AssertExpectations(fake))
+	assert.True(fake.Failed())
+}
+
+func TestMockClientDelay(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMockClient()
+	m.Expect(http.MethodGet, "/slow").Delay(time.Second).AnyTimes()
+	m.Expect(http.MethodGet, "/fast").Delay(time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(m.Get(ctx, "/slow", nil), context.DeadlineExceeded)
+	assert.NoError(m.Get(context.Background(), "/fast", nil))
+}
@@ -0,0 +1,62 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// MockServer is an in-process HTTP server simulating a dependency of the code tested, e.g. called by a restful Client.
+// Requests are matched against expectations, the same way as by MockClient, but go over the network, so that timeouts,
+// retries and connection faults of the client are exercised, too.
+// Unexpected requests are responded by 404 Not Found.
+//
+//	m := restfultest.NewMockServer(t)
+//	m.Expect(http.MethodGet, "/users/1").Delay(100 * time.Millisecond).Respond(http.StatusOK, &user)
+//	m.Expect(http.MethodGet, "/users/2").RespondError(io.EOF) // Connection closed.
+//	svc := NewService(m.URL)
+//	...
+//	m.AssertExpectations(t)
+type MockServer struct {
+	*httptest.Server
+	expectations
+}
+
+// NewMockServer creates and starts a mock server, closed at the end of the test.
+func NewMockServer(t testing.TB) *MockServer {
+	m := &MockServer{}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	t.Cleanup(m.Server.Close)
+	return m
+}
+
+func (m *MockServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	e, err := m.match(r, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := e.wait(r.Context()); err != nil {
+		return // Client gone.
+	}
+	if e.err != nil {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler) // Aborts the response, e.g. HTTP/2 stream.
+	}
+	for header, values := range e.respHeader {
+		w.Header()[header] = values
+	}
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.respBody)
+}
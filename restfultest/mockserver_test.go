@@ -0,0 +1,48 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockServer(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMockServer(t)
+	m.Expect(http.MethodPost, "/users").WithBody(user{Name: "Joe"}).RespondHeader("Location", "/users/1").Respond(http.StatusCreated, nil)
+	m.Expect(http.MethodGet, "/users/1?fields=name").WithHeader("X-Tenant", "a").Respond(http.StatusOK, user{Name: "Joe"})
+	m.Expect(http.MethodGet, "/users/2").Delay(time.Second).Respond(http.StatusOK, user{Name: "Jane"})
+	m.Expect(http.MethodGet, "/users/3").RespondError(io.EOF)
+	client := restful.NewClient().Root(m.URL)
+	ctx := context.Background()
+
+	location, err := createUser(ctx, client, "Joe")
+	assert.NoError(err)
+	assert.Equal(m.URL+"/users/1", location)
+
+	var u user
+	_, err = client.SendRecv2xx(ctx, http.MethodGet, "/users/1?fields=name", http.Header{"X-Tenant": {"a"}}, nil, &u)
+	assert.NoError(err)
+	assert.Equal("Joe", u.Name)
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(client.Get(ctxTimeout, "/users/2", &u), context.DeadlineExceeded)
+
+	assert.Error(client.Get(ctx, "/users/3", &u))
+	assert.True(m.AssertExpectations(t))
+
+	err = client.Get(ctx, "/users/4", &u)
+	assert.Equal(http.StatusNotFound, restful.GetErrStatusCode(err))
+	fake := &testing.T{}
+	assert.False(m.AssertExpectations(fake))
+}
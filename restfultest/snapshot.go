@@ -0,0 +1,140 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that makes snapshot checks write the golden files instead of comparing, if set to non-empty value.
+// E.g. RESTFUL_UPDATE=1 go test ./...
+const UpdateEnv = "RESTFUL_UPDATE"
+
+// SnapshotHeaders are the response headers that are part of snapshots, if present.
+// Others, e.g. Date or tracing headers, vary by run.
+var SnapshotHeaders = []string{"Content-Type", "Location", "Allow", "Retry-After", "Cache-Control"}
+
+type snapshot struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   any               `json:"body,omitempty"`
+}
+
+// MatchSnapshot compares the response to the golden file at path, reporting differences to t.
+// The golden file holds the status, SnapshotHeaders and the JSON body, indented and having sorted keys, so that diffs are readable.
+// Values of the ignored JSON object fields, at any depth, are replaced by Redacted, e.g. IDs or timestamps generated.
+// If UpdateEnv is set, the golden file is written instead. Review and commit the changes to accept those.
+// Returns true if the response matches. The response body can be read afterwards.
+//
+//	resp := httptest.NewRecorder()
+//	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+//	restfultest.MatchSnapshot(t, "testdata/get_user.json", resp.Result(), "createdAt")
+func MatchSnapshot(t testing.TB, path string, resp *http.Response, ignored ...string) bool {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("snapshot %s: reading body: %v", path, err)
+		return false
+	}
+	return matchSnapshot(t, path, resp.StatusCode, resp.Header, body, ignored)
+}
+
+// Snapshot compares the response to the golden file at path. See MatchSnapshot.
+//
+//	tester.Get("/users/1").Expect(t).Status(http.StatusOK).Snapshot("testdata/get_user.json", "createdAt")
+func (r *Response) Snapshot(path string, ignored ...string) *Response {
+	r.t.Helper()
+	if !r.failed {
+		matchSnapshot(r.t, path, r.StatusCode, r.Response.Header, r.body, ignored)
+	}
+	return r
+}
+
+func matchSnapshot(t testing.TB, path string, status int, header http.Header, body []byte, ignored []string) bool {
+	t.Helper()
+	actual, err := marshalSnapshot(status, header, body, ignored)
+	if err != nil {
+		t.Errorf("snapshot %s: %v", path, err)
+		return false
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Errorf("snapshot %s: %v", path, err)
+			return false
+		}
+		if err := os.WriteFile(path, actual, 0o600); err != nil {
+			t.Errorf("snapshot %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+
+	expected, err := os.ReadFile(path) // #nosec G304 -- test fixture path
+	if err != nil {
+		t.Errorf("snapshot %s: %v, run with %s=1 to create", path, err, UpdateEnv)
+		return false
+	}
+	if line, e, a := firstDiff(expected, actual); line > 0 {
+		t.Errorf("snapshot %s differs at line %d:\n  expected: %s\n    actual: %s\nrun with %s=1 to update", path, line, e, a, UpdateEnv)
+		return false
+	}
+	return true
+}
+
+func marshalSnapshot(status int, header http.Header, body []byte, ignored []string) ([]byte, error) {
+	s := snapshot{Status: status}
+	for _, name := range SnapshotHeaders {
+		if value := header.Get(name); value != "" {
+			if s.Header == nil {
+				s.Header = map[string]string{}
+			}
+			s.Header[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	if len(body) > 0 {
+		var v any
+		if err := json.Unmarshal(body, &v); err == nil {
+			s.Body = redactJSONValue(v, ignored)
+		} else {
+			s.Body = string(body)
+		}
+	}
+	b, err := json.MarshalIndent(&s, "", "  ") // Map keys are sorted.
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// firstDiff returns the first line differing, numbered from 1, or 0 if equal.
+func firstDiff(expected, actual []byte) (line int, e, a string) {
+	if bytes.Equal(expected, actual) {
+		return 0, "", ""
+	}
+	el := strings.Split(string(expected), "\n")
+	al := strings.Split(string(actual), "\n")
+	for i := 0; ; i++ {
+		if i >= len(el) || i >= len(al) || el[i] != al[i] {
+			return i + 1, lineAt(el, i), lineAt(al, i)
+		}
+	}
+}
+
+func lineAt(lines []string, i int) string {
+	if i >= len(lines) {
+		return "<EOF>"
+	}
+	return strings.TrimSpace(lines[i])
+}
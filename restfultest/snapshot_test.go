@@ -0,0 +1,84 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "snapshots", "get_user.json")
+	name := "joe"
+	r := restful.NewRouter()
+	r.HandleFunc("/users/{id}", func(ctx context.Context) (map[string]any, error) {
+		restful.L(ctx).ResponseHeader().Set("Date", "varies")
+		return map[string]any{"name": name, "id": restful.L(ctx).RequestVars()["id"], "created": map[string]any{"at": "now"}}, nil
+	})
+	tester := NewTester(r)
+
+	t.Setenv(UpdateEnv, "1")
+	tester.Get("/users/1").Expect(t).Status(http.StatusOK).Snapshot(path, "at")
+	golden, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal(`{
+  "status": 200,
+  "header": {
+    "Content-Type": "application/json"
+  },
+  "body": {
+    "created": {
+      "at": "REDACTED"
+    },
+    "id": "1",
+    "name": "joe"
+  }
+}
+`, string(golden))
+
+	t.Setenv(UpdateEnv, "")
+	tester.Get("/users/1").Expect(t).Snapshot(path, "at")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	resp := w.Result()
+	assert.True(MatchSnapshot(t, path, resp, "at"))
+	var user map[string]any
+	assert.NoError(restful.GetResponseData(resp, 0, &user)) // Body readable again.
+
+	rt := &recordingT{TB: t}
+	name = "jane"
+	tester.Get("/users/1").Expect(rt).Snapshot(path, "at")
+	if assert.Len(rt.errors, 1) {
+		assert.Contains(rt.errors[0], `differs at line 11:`)
+		assert.Contains(rt.errors[0], `expected: "name": "joe"`)
+		assert.Contains(rt.errors[0], `actual: "name": "jane"`)
+		assert.Contains(rt.errors[0], UpdateEnv)
+	}
+
+	rt = &recordingT{TB: t}
+	assert.False(matchSnapshot(rt, filepath.Join(t.TempDir(), "missing.json"), http.StatusOK, nil, []byte("text"), nil))
+	assert.Contains(rt.errors[0], "to create")
+}
+
+func TestSnapshotText(t *testing.T) {
+	assert := assert.New(t)
+	b, err := marshalSnapshot(http.StatusNotFound, http.Header{"Content-Type": {"text/plain"}, "Date": {"now"}}, []byte("not found"), nil)
+	assert.NoError(err)
+	assert.Equal("{\n  \"status\": 404,\n  \"header\": {\n    \"Content-Type\": \"text/plain\"\n  },\n  \"body\": \"not found\"\n}\n", string(b))
+
+	line, e, a := firstDiff([]byte("a\nb"), []byte("a"))
+	assert.Equal(2, line)
+	assert.Equal("b", e)
+	assert.Equal("<EOF>", a)
+}
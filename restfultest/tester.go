@@ -0,0 +1,231 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/nokia/restful"
+)
+
+// TesterHost is the host of the requests sent by Tester. It sets the domain of the cookies stored.
+var TesterHost = "example.com"
+
+// Tester sends requests to a handler, typically a restful Router, without network access.
+// Cookies set by responses are stored in a cookie jar and sent by later requests, e.g. a session cookie of a login.
+// A traceparent header having random trace ID is added to requests not having one.
+//
+//	tester := restfultest.NewTester(router)
+//	var user User
+//	tester.Post("/users").WithJSON(&joe).Expect(t).Status(http.StatusCreated).JSON(&user)
+//	tester.Get("/users/1").Expect(t).Status(http.StatusOK).JSON(&user)
+type Tester struct {
+	handler http.Handler
+	jar     http.CookieJar
+}
+
+// NewTester creates a tester of the handler, typically a restful Router.
+func NewTester(handler http.Handler) *Tester {
+	jar, _ := cookiejar.New(nil) // No error without options.
+	return &Tester{handler: handler, jar: jar}
+}
+
+// Request is a request of a Tester, built by chained calls, sent by Expect.
+type Request struct {
+	tester *Tester
+	target string
+	req    *http.Request
+	err    error
+}
+
+// Request creates a request of the method to the target, being a path with optional query, e.g. "/users?name=joe".
+func (tester *Tester) Request(method, target string) *Request {
+	req, err := http.NewRequest(method, "http://"+TesterHost+target, nil)
+	return &Request{tester: tester, target: target, req: req, err: err}
+}
+
+// Get creates a GET request.
+func (tester *Tester) Get(target string) *Request {
+	return tester.Request(http.MethodGet, target)
+}
+
+// Post creates a POST request.
+func (tester *Tester) Post(target string) *Request {
+	return tester.Request(http.MethodPost, target)
+}
+
+// Put creates a PUT request.
+func (tester *Tester) Put(target string) *Request {
+	return tester.Request(http.MethodPut, target)
+}
+
+// Patch creates a PATCH request.
+func (tester *Tester) Patch(target string) *Request {
+	return tester.Request(http.MethodPatch, target)
+}
+
+// Delete creates a DELETE request.
+func (tester *Tester) Delete(target string) *Request {
+	return tester.Request(http.MethodDelete, target)
+}
+
+// Cookies returns the cookies stored, to be sent to the path.
+func (tester *Tester) Cookies(path string) []*http.Cookie {
+	return tester.jar.Cookies(&url.URL{Scheme: "http", Host: TesterHost, Path: path})
+}
+
+// WithHeader adds a header to the request.
+func (r *Request) WithHeader(header, value string) *Request {
+	if r.err == nil {
+		r.req.Header.Add(header, value)
+	}
+	return r
+}
+
+// WithQuery adds a query parameter to the request.
+func (r *Request) WithQuery(name, value string) *Request {
+	if r.err == nil {
+		query := r.req.URL.Query()
+		query.Add(name, value)
+		r.req.URL.RawQuery = query.Encode()
+	}
+	return r
+}
+
+// WithBasicAuth sets the Authorization header of basic authentication.
+func (r *Request) WithBasicAuth(username, password string) *Request {
+	if r.err == nil {
+		r.req.SetBasicAuth(username, password)
+	}
+	return r
+}
+
+// WithBody sets the request body and its content type.
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	if r.err == nil {
+		r.req.Body = io.NopCloser(bytes.NewReader(body))
+		r.req.ContentLength = int64(len(body))
+		r.req.Header.Set(restful.ContentTypeHeader, contentType)
+	}
+	return r
+}
+
+// WithJSON sets the JSON encoding of data as request body.
+func (r *Request) WithJSON(data any) *Request {
+	if r.err != nil {
+		return r
+	}
+	var body []byte
+	body, r.err = json.Marshal(data)
+	return r.WithBody(restful.ContentTypeApplicationJSON, body)
+}
+
+// Expect sends the request, returning the response to be checked. Failures are reported to t.
+func (r *Request) Expect(t testing.TB) *Response {
+	t.Helper()
+	resp := &Response{t: t}
+	if r.err != nil {
+		t.Errorf("request %s: %v", r.target, r.err)
+		resp.Response = &http.Response{Header: http.Header{}}
+		resp.failed = true
+		return resp
+	}
+	if r.req.Header.Get("traceparent") == "" {
+		r.req.Header.Set("traceparent", "00-"+randomHex(16)+"-"+randomHex(8)+"-01")
+	}
+	for _, cookie := range r.tester.jar.Cookies(r.req.URL) {
+		r.req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	r.tester.handler.ServeHTTP(w, r.req)
+	resp.Response = w.Result()
+	resp.Request = r.req
+	resp.body = w.Body.Bytes()
+	if traceParent := r.req.Header.Get("traceparent"); len(traceParent) >= 35 {
+		resp.traceID = traceParent[3:35]
+	}
+	r.tester.jar.SetCookies(r.req.URL, resp.Cookies())
+	return resp
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Response is the response of a request of a Tester. Its checks report failures to the test, and can be chained.
+// Checks are skipped if the request could not be sent.
+type Response struct {
+	*http.Response
+	t       testing.TB
+	failed  bool
+	body    []byte
+	traceID string
+}
+
+// Status checks the status code.
+func (r *Response) Status(status int) *Response {
+	r.t.Helper()
+	if !r.failed && r.StatusCode != status {
+		r.t.Errorf("%s %s: status %d, expected %d: %s", r.method(), r.path(), r.StatusCode, status, r.body)
+	}
+	return r
+}
+
+// Header checks a header value.
+func (r *Response) Header(header, value string) *Response {
+	r.t.Helper()
+	if got := r.Response.Header.Get(header); !r.failed && got != value {
+		r.t.Errorf("%s %s: header %s %q, expected %q", r.method(), r.path(), header, got, value)
+	}
+	return r
+}
+
+// JSON decodes the JSON body to data.
+func (r *Response) JSON(data any) *Response {
+	r.t.Helper()
+	if r.failed {
+		return r
+	}
+	if err := json.Unmarshal(r.body, data); err != nil {
+		r.t.Errorf("%s %s: invalid JSON body: %v: %s", r.method(), r.path(), err, r.body)
+	}
+	return r
+}
+
+// Body returns the response body.
+func (r *Response) Body() []byte {
+	return r.body
+}
+
+// TraceID returns the trace ID of the request, e.g. to find its log lines.
+func (r *Response) TraceID() string {
+	return r.traceID
+}
+
+func (r *Response) method() string {
+	if r.Request == nil {
+		return ""
+	}
+	return r.Request.Method
+}
+
+func (r *Response) path() string {
+	if r.Request == nil {
+		return ""
+	}
+	return r.Request.URL.RequestURI()
+}
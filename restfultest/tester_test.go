@@ -0,0 +1,82 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restfultest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func newTesterRouter() *restful.Router {
+	r := restful.NewRouter()
+	r.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		var c credentials
+		_ = restful.GetRequestData(r, 0, &c)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: c.User, Path: "/"})
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPost)
+	r.HandleFunc("/whoami", func(ctx context.Context) (map[string]string, error) {
+		l := restful.L(ctx)
+		cookie, err := (&http.Request{Header: l.RequestHeader()}).Cookie("session")
+		if err != nil {
+			return nil, restful.NewError(err, http.StatusUnauthorized)
+		}
+		l.ResponseHeaderSet("X-Trace-Id", l.TraceID())
+		return map[string]string{"user": cookie.Value, "lang": l.RequestQueryStringParameter("lang")}, nil
+	}).Methods(http.MethodGet)
+	return r
+}
+
+func TestTester(t *testing.T) {
+	assert := assert.New(t)
+	tester := NewTester(newTesterRouter())
+
+	tester.Post("/login").WithJSON(credentials{User: "joe"}).Expect(t).Status(http.StatusNoContent)
+	assert.Len(tester.Cookies("/"), 1)
+
+	var me map[string]string
+	resp := tester.Get("/whoami").WithQuery("lang", "fi").Expect(t).Status(http.StatusOK).Header(restful.ContentTypeHeader, restful.ContentTypeApplicationJSON).JSON(&me)
+	assert.Equal(map[string]string{"user": "joe", "lang": "fi"}, me)
+	assert.Len(resp.TraceID(), 32)
+	assert.Equal(resp.TraceID(), resp.Response.Header.Get("X-Trace-Id"))
+	assert.Contains(string(resp.Body()), `"user":"joe"`)
+
+	resp = tester.Get("/whoami").WithHeader("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01").Expect(t)
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", resp.TraceID())
+}
+
+func TestTesterFailures(t *testing.T) {
+	assert := assert.New(t)
+	tester := NewTester(newTesterRouter())
+	rt := &recordingT{TB: t}
+
+	var me map[string]string
+	tester.Get("/whoami").Expect(rt).Status(http.StatusOK).Header("X-Missing", "x")
+	tester.Delete("/login").Expect(rt).Status(http.StatusMethodNotAllowed).JSON(&me)
+	tester.Put("/login").WithJSON(func() {}).Expect(rt).Status(http.StatusOK)
+	tester.Patch("/%zz").Expect(rt)
+	assert.Len(rt.errors, 5)
+	assert.Contains(rt.errors[0], "GET /whoami: status 401, expected 200")
+	assert.Contains(rt.errors[1], `header X-Missing "", expected "x"`)
+	assert.Contains(rt.errors[2], "DELETE /login: invalid JSON body")
+	assert.Contains(rt.errors[3], "request /login: json: unsupported type")
+	assert.Contains(rt.errors[4], "request /%zz")
+}
@@ -0,0 +1,128 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/nokia/restful/logging"
+)
+
+// ReverseProxy is an HTTP handler forwarding requests to a target server, and sending back the responses.
+// Bodies are streamed, not buffered. Tracing headers are propagated with a new span.
+//
+//	r.PathPrefix("/users/").Handler(restful.NewReverseProxy("http://users:8080").StripPrefix("/users"))
+type ReverseProxy struct {
+	target         *url.URL
+	targetErr      error
+	proxy          *httputil.ReverseProxy
+	stripPrefix    string
+	rewritePath    func(path string) string
+	modifyRequest  []func(req *http.Request)
+	modifyResponse []func(resp *http.Response) error
+}
+
+// NewReverseProxy creates a reverse proxy handler forwarding requests to the target URL.
+// The path of the request is appended to the path of the target URL.
+// If the target is not a valid URL, the handler responds 502 Bad Gateway.
+func NewReverseProxy(target string) *ReverseProxy {
+	p := &ReverseProxy{}
+	p.target, p.targetErr = url.Parse(target)
+	if p.targetErr == nil && p.target.Host == "" {
+		p.targetErr = errors.New("invalid proxy target: " + target)
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		ModifyResponse: p.doModifyResponse,
+		ErrorHandler:   p.errorHandler,
+		Transport:      NewClient().Client.Transport,
+		FlushInterval:  -1,
+	}
+	return p
+}
+
+// Client sets the client the transport of which is used for sending the requests to the target.
+// Useful for H2C, TLS and tracing settings of the client.
+func (p *ReverseProxy) Client(client *Client) *ReverseProxy {
+	p.proxy.Transport = client.Client.Transport
+	return p
+}
+
+// StripPrefix removes the prefix from the request path before forwarding.
+func (p *ReverseProxy) StripPrefix(prefix string) *ReverseProxy {
+	p.stripPrefix = prefix
+	return p
+}
+
+// RewritePath sets a function that rewrites the request path before forwarding.
+// Applied after StripPrefix.
+func (p *ReverseProxy) RewritePath(f func(path string) string) *ReverseProxy {
+	p.rewritePath = f
+	return p
+}
+
+// ModifyRequest adds a function that can manipulate the outbound request, e.g. its headers.
+func (p *ReverseProxy) ModifyRequest(f func(req *http.Request)) *ReverseProxy {
+	p.modifyRequest = append(p.modifyRequest, f)
+	return p
+}
+
+// ModifyResponse adds a function that can manipulate the response received from the target before sending it back.
+// If the function returns an error, then 502 Bad Gateway is sent, unless the error contains another status code. See NewError.
+func (p *ReverseProxy) ModifyResponse(f func(resp *http.Response) error) *ReverseProxy {
+	p.modifyResponse = append(p.modifyResponse, f)
+	return p
+}
+
+// ServeHTTP forwards the request to the target.
+func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.targetErr != nil {
+		p.errorHandler(w, r, p.targetErr)
+		return
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+func (p *ReverseProxy) rewrite(pr *httputil.ProxyRequest) {
+	path := strings.TrimPrefix(pr.In.URL.Path, p.stripPrefix)
+	if p.rewritePath != nil {
+		path = p.rewritePath(path)
+	}
+	if path != "" && path[0] != '/' {
+		path = "/" + path
+	}
+	pr.Out.URL.Path = path
+	pr.Out.URL.RawPath = ""
+	pr.SetURL(p.target)
+	pr.SetXForwarded()
+
+	out, spanStr := doSpan(pr.Out)
+	pr.Out = out
+	for _, f := range p.modifyRequest {
+		f(pr.Out)
+	}
+	logging.Debugf(pr.Out.Context(), "[%s] Proxy req: %s %s", spanStr, pr.Out.Method, pr.Out.URL)
+}
+
+func (p *ReverseProxy) doModifyResponse(resp *http.Response) error {
+	for _, f := range p.modifyResponse {
+		if err := f(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ReverseProxy) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	logging.Debugf(r.Context(), "Proxy error: %s %s: %v", r.Method, r.URL, err)
+	if GetErrStatusCodeElse(err, 0) == 0 {
+		err = NewError(err, http.StatusBadGateway)
+	}
+	_ = SendResp(w, r, err, nil)
+}
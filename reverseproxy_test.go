@@ -0,0 +1,58 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseProxy(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := NewRouter()
+	backend.HandleFunc("/api/users/{id}", func(ctx context.Context) (map[string]string, error) {
+		l := L(ctx)
+		return map[string]string{
+			"id":      l.RequestVars()["id"],
+			"tenant":  l.RequestHeaderGet("X-Tenant"),
+			"forward": l.RequestHeaderGet("X-Forwarded-Host"),
+			"b3":      l.RequestHeaderGet("X-B3-Traceid"),
+		}, nil
+	})
+	backendSrv := httptest.NewServer(backend)
+	defer backendSrv.Close()
+
+	gw := NewRouter()
+	gw.PathPrefix("/users/").Handler(NewReverseProxy(backendSrv.URL + "/api").
+		StripPrefix("/users").
+		RewritePath(func(path string) string { return "/users" + path }).
+		ModifyRequest(func(req *http.Request) { req.Header.Set("X-Tenant", "t1") }).
+		ModifyResponse(func(resp *http.Response) error { resp.Header.Set("X-Proxied", "yes"); return nil }))
+	gw.PathPrefix("/bad/").Handler(NewReverseProxy("/no-host"))
+	gw.PathPrefix("/down/").Handler(NewReverseProxy("http://127.0.0.1:1"))
+	gwSrv := httptest.NewServer(gw)
+	defer gwSrv.Close()
+
+	var data map[string]string
+	header := http.Header{"X-B3-Traceid": {"80f198ee56343ba864fe8b2a57d3eff7"}, "X-B3-Spanid": {"e457b5a2e4d86bd1"}}
+	resp, err := NewClient().SendRecv2xx(context.Background(), http.MethodGet, gwSrv.URL+"/users/1", header, nil, &data)
+	assert.NoError(err)
+	assert.Equal("yes", resp.Header.Get("X-Proxied"))
+	assert.Equal("1", data["id"])
+	assert.Equal("t1", data["tenant"])
+	assert.True(strings.HasPrefix(data["forward"], "127.0.0.1:"))
+	assert.Equal("80f198ee56343ba864fe8b2a57d3eff7", data["b3"])
+
+	err = NewClient().Get(context.Background(), gwSrv.URL+"/bad/1", nil)
+	assert.Equal(http.StatusBadGateway, GetErrStatusCode(err))
+	err = NewClient().Get(context.Background(), gwSrv.URL+"/down/1", nil)
+	assert.Equal(http.StatusBadGateway, GetErrStatusCode(err))
+}
@@ -0,0 +1,45 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type routeTemplateCtxKeyType string
+
+const routeTemplateCtxName = routeTemplateCtxKeyType("restfulRouteTemplate")
+
+// WithRouteTemplate prepares the request so that the route template matched by the Router later is readable by RouteTemplate.
+// Useful for middlewares wrapping the Router, e.g. metrics at a Monitor pre function, which cannot see the route otherwise.
+func WithRouteTemplate(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(routeTemplateCtxName).(*string); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), routeTemplateCtxName, new(string)))
+}
+
+// RouteTemplate returns the route template matched by the Router, e.g. "/users/{id}".
+// Empty string if no route matched, or the request was not prepared by WithRouteTemplate outside the Router.
+func RouteTemplate(r *http.Request) string {
+	if tmpl, ok := r.Context().Value(routeTemplateCtxName).(*string); ok && *tmpl != "" {
+		return *tmpl
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		tmpl, _ := route.GetPathTemplate()
+		return tmpl
+	}
+	return ""
+}
+
+// setRouteTemplate stores the route template matched, if the request was prepared by WithRouteTemplate.
+func setRouteTemplate(r *http.Request, tmpl string) {
+	if p, ok := r.Context().Value(routeTemplateCtxName).(*string); ok {
+		*p = tmpl
+	}
+}
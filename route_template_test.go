@@ -0,0 +1,32 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	var inner, outer string
+	pre := func(w http.ResponseWriter, r *http.Request) *http.Request { return WithRouteTemplate(r) }
+	post := func(w http.ResponseWriter, r *http.Request, statusCode int) { outer = RouteTemplate(r) }
+	router := NewRouter()
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) { inner = RouteTemplate(r) })
+	h := Monitor(router, pre, post)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal("/users/{id}", inner)
+	assert.Equal("/users/{id}", outer)
+
+	outer = "-"
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nothing", nil))
+	assert.Empty(outer)
+}
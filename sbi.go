@@ -0,0 +1,124 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 3GPP Service Based Interface headers, see 3GPP TS 29.500.
+const (
+	SbiTargetAPIRootHeader  = "3gpp-Sbi-Target-apiRoot"
+	SbiRoutingBindingHeader = "3gpp-Sbi-Routing-Binding"
+	SbiBindingHeader        = "3gpp-Sbi-Binding"
+)
+
+type sbiRoutingBindingCtxKeyType string
+
+const sbiRoutingBindingCtxName = sbiRoutingBindingCtxKeyType("restfulSbiRoutingBinding")
+
+// SCP sets the apiRoot of the Service Communication Proxy, for indirect communication.
+// Requests to absolute URLs are sent to the SCP, with 3gpp-Sbi-Target-apiRoot header indicating the original apiRoot.
+// Path and query of the request are kept, prefixed by the path of the SCP apiRoot, if any.
+//
+//	client := restful.NewH2CClient().SCP("http://scp:8080")
+//	client.Get(ctx, "http://udm1.5gc:80/nudm-sdm/v2/imsi-1/am-data", &amData) // Sent to http://scp:8080/nudm-sdm/v2/imsi-1/am-data
+func (c *Client) SCP(apiRoot string) *Client {
+	scp, err := url.Parse(apiRoot)
+	if err != nil || scp.Host == "" {
+		c.scp = nil
+		return c
+	}
+	scp.Path = strings.TrimSuffix(scp.Path, "/")
+	c.scp = scp
+	return c
+}
+
+// ContextWithSbiRoutingBinding returns a context that makes the client functions send 3gpp-Sbi-Routing-Binding header.
+// Typically the binding is the value of 3gpp-Sbi-Binding header received earlier from the peer, see SbiBinding.
+func ContextWithSbiRoutingBinding(ctx context.Context, binding string) context.Context {
+	return context.WithValue(ctx, sbiRoutingBindingCtxName, binding)
+}
+
+// SbiTargetAPIRoot returns the 3gpp-Sbi-Target-apiRoot header of the received request at Lambda context.
+// That is the apiRoot of the target NF, when received by an SCP.
+func SbiTargetAPIRoot(ctx context.Context) string {
+	return sbiRequestHeader(ctx, SbiTargetAPIRootHeader)
+}
+
+// SbiRoutingBinding returns the 3gpp-Sbi-Routing-Binding header of the received request at Lambda context.
+func SbiRoutingBinding(ctx context.Context) string {
+	return sbiRequestHeader(ctx, SbiRoutingBindingHeader)
+}
+
+// SbiBinding returns the 3gpp-Sbi-Binding header of the received request at Lambda context.
+func SbiBinding(ctx context.Context) string {
+	return sbiRequestHeader(ctx, SbiBindingHeader)
+}
+
+// SetSbiBinding sets 3gpp-Sbi-Binding header of the response at Lambda context.
+// E.g. "bl=nfset; nfset=set1.udmset.5gc.mnc012.mcc345".
+func SetSbiBinding(ctx context.Context, binding string) {
+	if l := L(ctx); l != nil {
+		l.ResponseHeaderSet(SbiBindingHeader, binding)
+	}
+}
+
+// SbiResponseTargetAPIRoot returns the 3gpp-Sbi-Target-apiRoot header of a response received via SCP.
+// If not empty, the SCP selected an alternative producer, and subsequent requests of the resource are to be sent to that apiRoot.
+func SbiResponseTargetAPIRoot(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	return resp.Header.Get(SbiTargetAPIRootHeader)
+}
+
+func sbiRequestHeader(ctx context.Context, header string) string {
+	if l := L(ctx); l != nil {
+		return l.RequestHeaderGet(header)
+	}
+	return ""
+}
+
+// rerouteSbi sets the next hop of the request to the 3gpp-Sbi-Target-apiRoot header, if set and the client has no SCP. That is what an SCP does.
+func (c *Client) rerouteSbi(req *http.Request) error {
+	apiRoot := req.Header.Get(SbiTargetAPIRootHeader)
+	if apiRoot == "" || c.scp != nil {
+		return nil
+	}
+	u, err := url.Parse(apiRoot)
+	if err != nil || u.Host == "" {
+		return NewError(err, http.StatusBadRequest, "bad "+SbiTargetAPIRootHeader+" header")
+	}
+	req.Header.Del(SbiTargetAPIRootHeader)
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	req.URL.Path = strings.TrimSuffix(u.Path, "/") + req.URL.Path
+	req.URL.RawPath = ""
+	req.Host = ""
+	return nil
+}
+
+// setSbi adds SBI headers and sends the request via SCP, if set.
+func (c *Client) setSbi(req *http.Request) {
+	if binding, ok := req.Context().Value(sbiRoutingBindingCtxName).(string); ok && binding != "" && req.Header.Get(SbiRoutingBindingHeader) == "" {
+		req.Header.Set(SbiRoutingBindingHeader, binding)
+	}
+
+	if c.scp == nil || req.URL.Host == c.scp.Host || req.Header.Get(SbiTargetAPIRootHeader) != "" {
+		return
+	}
+	req.Header.Set(SbiTargetAPIRootHeader, req.URL.Scheme+"://"+req.URL.Host)
+	req.URL.Scheme = c.scp.Scheme
+	req.URL.Host = c.scp.Host
+	req.URL.Path = c.scp.Path + req.URL.Path
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = c.scp.Path + req.URL.RawPath
+	}
+	req.Host = ""
+}
@@ -0,0 +1,68 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSbiSCP(t *testing.T) {
+	assert := assert.New(t)
+
+	// Producer
+	producer := NewRouter()
+	producer.HandleFunc("/nudm-sdm/v2/{supi}/am-data", func(ctx context.Context) (map[string]string, error) {
+		assert.Empty(SbiTargetAPIRoot(ctx))
+		SetSbiBinding(ctx, "bl=nfset; nfset=set1")
+		return map[string]string{"supi": L(ctx).RequestVars()["supi"], "binding": SbiRoutingBinding(ctx)}, nil
+	})
+	producerSrv := httptest.NewServer(producer)
+	defer producerSrv.Close()
+
+	// SCP, forwarding to the target apiRoot.
+	scp := NewRouter()
+	scpClient := NewClient().HTTPS(&HTTPSConfig{AllowLocalhostHTTP: true})
+	scp.PathPrefix("/scp").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(producerSrv.URL, r.Header.Get(SbiTargetAPIRootHeader))
+		req, _ := http.NewRequestWithContext(r.Context(), r.Method, r.URL.Path[len("/scp"):], nil)
+		req.Header = r.Header.Clone()
+		resp, err := scpClient.Do(req)
+		if !assert.NoError(err) {
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set(SbiBindingHeader, resp.Header.Get(SbiBindingHeader))
+		w.Header().Set(ContentTypeHeader, resp.Header.Get(ContentTypeHeader))
+		w.WriteHeader(resp.StatusCode)
+		data, _ := GetDataBytes(resp.Header, resp.Body, 0)
+		_, _ = w.Write(data)
+	})
+	scpSrv := httptest.NewServer(scp)
+	defer scpSrv.Close()
+
+	// Consumer
+	client := NewClient().SCP(scpSrv.URL + "/scp/")
+	var data map[string]string
+	ctx := ContextWithSbiRoutingBinding(context.Background(), "bl=nf; nfinst=54804518-4191-46b3-955c-ac631f953ed8")
+	resp, err := client.SendRecv2xx(ctx, http.MethodGet, producerSrv.URL+"/nudm-sdm/v2/imsi-1/am-data", nil, nil, &data)
+	assert.NoError(err)
+	assert.Equal("imsi-1", data["supi"])
+	assert.Equal("bl=nf; nfinst=54804518-4191-46b3-955c-ac631f953ed8", data["binding"])
+	assert.Equal("bl=nfset; nfset=set1", resp.Header.Get(SbiBindingHeader))
+	assert.Empty(SbiResponseTargetAPIRoot(resp))
+}
+
+func TestSbiBadTargetAPIRoot(t *testing.T) {
+	assert := assert.New(t)
+	header := http.Header{}
+	header.Set(SbiTargetAPIRootHeader, "::")
+	_, err := NewClient().SendRequest(context.Background(), http.MethodGet, "/x", header, nil)
+	assert.Equal(http.StatusBadRequest, GetErrStatusCode(err))
+}
@@ -0,0 +1,71 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	assert := assert.New(t)
+	router := NewRouter()
+	router.HandleFunc("/api", func() {})
+	router.HandleFunc("/ui", func() {}).SecurityHeaders(NewSecurityHeaders().ContentSecurityPolicy("default-src 'self'").FrameOptions("").HSTS(0, false, false))
+	docs := router.PathPrefix("/docs").Subrouter().Monitor(NewSecurityHeaders().ReferrerPolicy("same-origin").Pre, nil)
+	docs.HandleFunc("/index", func() {})
+	h := NewSecurityHeaders().HSTS(time.Hour, false, true).Handler(router)
+
+	request := func(path string, secure bool) http.Header {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if secure {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	header := request("/api", true)
+	assert.Equal("max-age=3600; preload", header.Get("Strict-Transport-Security"))
+	assert.Equal("nosniff", header.Get("X-Content-Type-Options"))
+	assert.Equal("DENY", header.Get("X-Frame-Options"))
+	assert.Equal("no-referrer", header.Get("Referrer-Policy"))
+	assert.Equal("default-src 'none'; frame-ancestors 'none'", header.Get("Content-Security-Policy"))
+	assert.Empty(request("/api", false).Get("Strict-Transport-Security"))
+
+	// Route override
+	header = request("/ui", true)
+	assert.Equal("default-src 'self'", header.Get("Content-Security-Policy"))
+	assert.NotContains(header, "X-Frame-Options")
+	assert.NotContains(header, "Strict-Transport-Security")
+	assert.Equal("nosniff", header.Get("X-Content-Type-Options"))
+
+	// Route group override
+	header = request("/docs/index", true)
+	assert.Equal("same-origin", header.Get("Referrer-Policy"))
+	assert.Equal("max-age=31536000; includeSubDomains", header.Get("Strict-Transport-Security"))
+}
+
+func TestSecurityHeadersProxy(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { _ = SetTrustedProxies() }()
+	assert.NoError(SetTrustedProxies("10.0.0.0/8"))
+	h := NewSecurityHeaders().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for remoteAddr, hsts := range map[string]bool{"10.0.0.1:1234": true, "1.2.3.4:1234": false} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(hsts, w.Header().Get("Strict-Transport-Security") != "", remoteAddr)
+	}
+}
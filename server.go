@@ -6,47 +6,103 @@ package restful
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/nokia/restful/logging"
 	"github.com/nokia/restful/trace/tracer"
-	log "github.com/sirupsen/logrus"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 // Server represents a server instance.
 type Server struct {
-	server      *http.Server
-	serverMutex sync.Mutex
-	certFile    string
-	keyFile     string
-	graceful    bool
-	restarting  bool
-	gracePeriod time.Duration
-	monitors    monitors
+	server             *http.Server
+	serverMutex        sync.Mutex
+	certFile           string
+	keyFile            string
+	clientCAs          string
+	clientCAsSystem    bool
+	tlsReload          bool
+	sniCerts           []sniCert
+	reloader           *certReloader
+	graceful           bool
+	restarting         bool
+	gracePeriod        time.Duration
+	monitors           monitors
+	admin              *http.Server
+	acme               *autocert.Manager
+	acmeHTTP           *http.Server
+	plainHTTP          *http.Server
+	listenerFile       *os.File
+	listeners          sync.Map // Addr -> net.Listener
+	upgradeSignal      os.Signal
+	httpRedirect       bool
+	proxyProto         bool
+	onStart            []lifecycleHook
+	onStop             []lifecycleHook
+	draining           atomic.Bool
+	closeOnDrain       bool
+	drainTimeout       time.Duration
+	maxRequestsPerConn int
+	maxConnAge         time.Duration
+	ports              []*http.Server
+	maxConns           int
+	connReadRate       int
+	connWriteRate      int
 }
 
 // ServerReadHeaderTimeout is the amount of time allowed to read request headers.
-var ServerReadHeaderTimeout = 5 * time.Second
+// May be set by RESTFUL_SERVER_READ_HEADER_TIMEOUT environment variable, e.g. "10s".
+var ServerReadHeaderTimeout = envDuration("RESTFUL_SERVER_READ_HEADER_TIMEOUT", 5*time.Second)
 
 // ServerReadTimeout is the amount of time allowed to read request body.
-// Default 60s is quite liberal.
-var ServerReadTimeout = 60 * time.Second
+// Default 60s is quite liberal. May be set by RESTFUL_SERVER_READ_TIMEOUT environment variable.
+var ServerReadTimeout = envDuration("RESTFUL_SERVER_READ_TIMEOUT", 60*time.Second)
+
+// ServerWriteTimeout is the amount of time allowed to serve a request and write the response.
+// Handlers streaming longer responses may extend that by http.ResponseController's SetWriteDeadline.
+// May be set by RESTFUL_SERVER_WRITE_TIMEOUT environment variable.
+var ServerWriteTimeout = envDuration("RESTFUL_SERVER_WRITE_TIMEOUT", 90*time.Second)
+
+// ServerIdleTimeout is the amount of time a keep-alive connection may wait for the next request.
+// May be set by RESTFUL_SERVER_IDLE_TIMEOUT environment variable.
+var ServerIdleTimeout = envDuration("RESTFUL_SERVER_IDLE_TIMEOUT", 120*time.Second)
+
+// ServerMaxHeaderBytes is the max size of request headers. May be set by RESTFUL_SERVER_MAX_HEADER_BYTES environment variable.
+var ServerMaxHeaderBytes = envInt("RESTFUL_SERVER_MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes)
+
+// ServerMaxRequestsPerConn is the max number of requests served on a connection by default, see Server's MaxRequestsPerConn.
+// Zero means no limit. May be set by RESTFUL_SERVER_MAX_REQUESTS_PER_CONN environment variable.
+var ServerMaxRequestsPerConn = envInt("RESTFUL_SERVER_MAX_REQUESTS_PER_CONN", 0)
+
+// ServerMaxConnAge is the max age of a connection by default, see Server's MaxConnAge.
+// Zero means no limit. May be set by RESTFUL_SERVER_MAX_CONN_AGE environment variable.
+var ServerMaxConnAge = envDuration("RESTFUL_SERVER_MAX_CONN_AGE", 0)
+
+// TraceShutdownTimeout is the max time to wait for flushing batched spans to the OpenTelemetry collector on graceful shutdown.
+var TraceShutdownTimeout = 5 * time.Second
 
 // NewServer creates a new Server instance.
 func NewServer() *Server {
-	server := Server{server: &http.Server{ReadHeaderTimeout: ServerReadHeaderTimeout, ReadTimeout: ServerReadTimeout}}
+	server := Server{server: newHTTPServer("", nil), maxRequestsPerConn: ServerMaxRequestsPerConn, maxConnAge: ServerMaxConnAge,
+		maxConns: ServerMaxConns, connReadRate: ServerConnReadRate, connWriteRate: ServerConnWriteRate}
 	return &server
 }
 
 // Graceful enables graceful shutdown.
 // Awaits TERM/INT signals and exits when http shutdown completed, i.e. clients are served.
 // Caller may define gracePeriod to wait before shutting down listening point.
-// Client connection shutdown awaited indefinitely.
+// Readiness probes fail from the signal on, see SetReady, while new connections are still accepted during the grace period,
+// so that K8s endpoints are updated before the listener is closed. Set the grace period longer than the readiness probe period.
+// Client connection shutdown awaited indefinitely, unless DrainTimeout is set. See CloseOnDrain, too.
 func (s *Server) Graceful(gracePeriod time.Duration) *Server {
 	s.graceful = true
 	s.gracePeriod = gracePeriod
@@ -71,28 +127,123 @@ func (s *Server) Monitor(pre MonitorFuncPre, post MonitorFuncPost) *Server {
 	return s
 }
 
+// MonitorWithResponse sets monitor functions for the server, as Monitor does, but the post function observes the response: status code, body size and latency.
+func (s *Server) MonitorWithResponse(pre MonitorFuncPre, post MonitorFuncPostResponse) *Server {
+	s.monitors.appendResponse(pre, post)
+	if s.server.Handler != nil {
+		s.server.Handler = s.monitors.wrap(s.server.Handler)
+		s.monitors = nil
+	}
+	return s
+}
+
+// Admin sets an admin server listening on a separate address, e.g. "127.0.0.1:9090", serving the handler.
+// Typically debug endpoints not to be exposed on the main listener, see package admin.
+// Admin server is started by ListenAndServe, and is stopped when the server stops, on Close, Shutdown or graceful shutdown.
+// Admin server is not logged, and is not restarted by Restart.
+//
+//	restful.NewServer().Addr(":8080").Handler(router).Admin("127.0.0.1:9090", admin.NewServeMux(router)).Graceful(0).ListenAndServe()
+func (s *Server) Admin(addr string, handler http.Handler) *Server {
+	s.admin = newHTTPServer(addr, handler)
+	return s
+}
+
+// sideServers returns the servers started and stopped together with the main one, i.e. admin, ACME HTTP challenge, plain HTTP and additional port servers.
+func (s *Server) sideServers() (servers []*http.Server) {
+	for _, server := range []*http.Server{s.admin, s.acmeHTTP, s.plainHTTP} {
+		if server != nil {
+			servers = append(servers, server)
+		}
+	}
+	return append(servers, s.ports...)
+}
+
+func (s *Server) startSideServers() {
+	for _, server := range s.sideServers() {
+		go func() {
+			addr := server.Addr
+			if addr == "" {
+				addr = ":http"
+			}
+			ln, err := s.listenAddr(addr, nil)
+			if err == nil {
+				if slices.Contains(s.ports, server) {
+					ln = s.limitListener(ln)
+				}
+				err = server.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				logging.Errorf(context.Background(), "server at '%s' failed: %v", server.Addr, err)
+			}
+		}()
+	}
+}
+
+func (s *Server) shutdownSideServers(ctx context.Context) error {
+	var errs []error
+	for _, server := range s.sideServers() {
+		errs = append(errs, server.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// ProxyProtocol makes the server expect PROXY protocol header, version 1 or 2, at the start of connections,
+// as sent by load balancers, e.g. HAProxy or AWS NLB. So the address of the client is known, even if TCP connections are proxied.
+// If trusted proxies are set, then the header is expected only on connections from them, see SetTrustedProxies.
+// Requests on connections without a valid header are not served.
+func (s *Server) ProxyProtocol() *Server {
+	s.proxyProto = true
+	return s
+}
+
 // Handler defines handlers for server.
 // Logs, except for automatically served LivenessProbePath and HealthCheckPath.
+// Emits events of panics, 5xx responses and slow requests, see OnEvent.
 func (s *Server) Handler(handler http.Handler) *Server {
 	if handler == nil {
 		DefaultServeMux.PathPrefix("/").HandlerFunc(http.DefaultServeMux.ServeHTTP) // In case http.HandleFunc() was used.
 		handler = DefaultServeMux
 	}
-	s.server.Handler = Logger(s.monitors.wrap(handler))
-	if isTraced && tracer.GetOTel() {
-		s.server.Handler = otelhttp.NewHandler(s.server.Handler, "", otelhttp.WithSpanNameFormatter(spanNameFormatter))
-	}
+	s.server.Handler = s.wrapHandler(handler)
 	s.monitors = nil
 	return s
 }
 
+// wrapHandler wraps the handler by logging, metrics, tracing and monitors of the server.
+func (s *Server) wrapHandler(handler http.Handler) (wrapped http.Handler) {
+	if tracer.GetOTelMetrics() {
+		wrapped = Logger(ServerMetrics(EventHandler(s.monitors.wrap(handler))))
+	} else {
+		wrapped = Logger(EventHandler(s.monitors.wrap(handler)))
+	}
+	if isTraced && tracer.GetOTel() {
+		wrapped = otelhttp.NewHandler(serverSpanAttributes(wrapped), "", otelhttp.WithSpanNameFormatter(spanNameFormatter))
+		wrapped = routeSampling(wrapped, handler)
+	}
+	return clientIPHandler(peerIdentityHandler(s.connCloseHandler(wrapped)))
+}
+
 // ListenAndServe starts listening and serves requests, blocking the caller.
 // Uses HTTPS if server key+cert is set, otherwise HTTP.
 // Port is set according to scheme, if listening address is not set.
 // When Graceful() is used it may return nil.
+// Start hooks are executed before listening, stop hooks after stopped, see OnStart and OnStop.
 func (s *Server) ListenAndServe() error {
+	if s.server.Handler == nil {
+		s.Handler(http.DefaultServeMux)
+	}
+	if s.plainHTTP != nil {
+		s.plainHTTP.Handler = s.plainHandler()
+	}
+	s.configurePorts()
+	if err := s.runStartHooks(); err != nil {
+		return err
+	}
+	s.startSideServers()
 	if !s.graceful {
-		return s.listenAndServe()
+		err := s.listenAndServe()
+		_ = s.shutdownSideServers(context.Background())
+		return s.withStopHooks(err)
 	}
 
 	stopErrCh := make(chan error)
@@ -106,28 +257,32 @@ func (s *Server) ListenAndServe() error {
 	}()
 
 	go waitForSignal(stopErrCh)
+	if s.upgradeSignal != nil {
+		go s.waitForUpgrade(stopErrCh)
+	}
 
 	if err := <-stopErrCh; err != nil {
-		return err
+		return s.withStopHooks(err)
+	}
+	err := s.drain()
+	if sideErr := s.shutdownSideServers(context.Background()); sideErr != nil {
+		logging.Errorf(context.Background(), "admin, ACME, HTTP or port server shutdown incomplete: %v", sideErr)
 	}
+	logging.Debugf(context.Background(), "Shutdown completed")
+	err = s.withStopHooks(err)
 
-	if s.gracePeriod > 0 {
-		log.Debug("Waiting grace period: ", s.gracePeriod)
-		time.Sleep(s.gracePeriod) // Still accept new connections.
-		log.Debug("Grace period over")
-	} else {
-		time.Sleep(10 * time.Millisecond) // Clients just connected to be served. E.g. K8s endpoint just deleted.
+	ctx, cancel := context.WithTimeout(context.Background(), TraceShutdownTimeout)
+	defer cancel()
+	if traceErr := tracer.Shutdown(ctx); traceErr != nil {
+		logging.Errorf(context.Background(), "trace shutdown incomplete: %v", traceErr)
 	}
-	log.Debug("Waiting client connections to shut down")
-	err := s.server.Shutdown(context.Background())
-	log.Debug("Shutdown completed")
 	return err
 }
 
 func waitForSignal(c chan error) {
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, syscall.SIGTERM, syscall.SIGINT)
-	log.Info("Signal received: ", <-signalChannel)
+	logging.Infof(context.Background(), "Signal received: %v", <-signalChannel)
 	c <- nil
 }
 
@@ -137,12 +292,7 @@ func (s *Server) listenAndServe() error {
 	}
 
 	for {
-		var err error
-		if s.keyFile != "" && s.certFile != "" {
-			err = s.server.ListenAndServeTLS(s.certFile, s.keyFile)
-		} else {
-			err = s.server.ListenAndServe()
-		}
+		err := s.serve()
 
 		if !s.restarting {
 			return err
@@ -150,17 +300,44 @@ func (s *Server) listenAndServe() error {
 		s.restarting = false
 
 		s.serverMutex.Lock() // ListenAndServe routines and Close are executed in parallel.
-		s.server = &http.Server{Handler: s.server.Handler, Addr: s.server.Addr, ReadHeaderTimeout: ServerReadHeaderTimeout, ReadTimeout: ServerReadTimeout}
+		s.server = &http.Server{Handler: s.server.Handler, Addr: s.server.Addr, TLSConfig: s.server.TLSConfig, TLSNextProto: s.server.TLSNextProto,
+			ReadHeaderTimeout: s.server.ReadHeaderTimeout, ReadTimeout: s.server.ReadTimeout, WriteTimeout: s.server.WriteTimeout, IdleTimeout: s.server.IdleTimeout, MaxHeaderBytes: s.server.MaxHeaderBytes}
 		s.serverMutex.Unlock()
 	}
 }
 
+// serve listens, or uses the listener file given, and serves HTTPS or HTTP, expecting PROXY protocol header if set.
+func (s *Server) serve() error {
+	tls := s.tlsEnabled()
+	var certFile, keyFile string
+	if tls {
+		var err error
+		if certFile, keyFile, err = s.tlsFiles(); err != nil {
+			return err
+		}
+	}
+	ln, err := s.listen(tls)
+	if err != nil {
+		return err
+	}
+	defer ln.Close() // In case serving fails before accepting, e.g. broken cert.
+	ln = s.limitListener(ln)
+	if s.proxyProto {
+		ln = proxyListener{ln}
+	}
+	s.server.ConnContext = s.connContext()
+	if tls {
+		return s.server.ServeTLS(ln, certFile, keyFile)
+	}
+	return s.server.Serve(ln)
+}
+
 // Restart restarts the server abruptly.
 // During restart active connections are dropped and there may be an outage.
 func (s *Server) Restart() {
 	s.restarting = true
 	if err := s.server.Close(); err != nil {
-		log.Errorf("restart close incomplete: %v", err)
+		logging.Errorf(context.Background(), "restart close incomplete: %v", err)
 	}
 }
 
@@ -168,6 +345,9 @@ func (s *Server) Restart() {
 func (s *Server) Close() error {
 	s.serverMutex.Lock()
 	defer s.serverMutex.Unlock()
+	for _, server := range s.sideServers() {
+		_ = server.Close()
+	}
 	return s.server.Close()
 }
 
@@ -176,7 +356,7 @@ func (s *Server) Close() error {
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.serverMutex.Lock()
 	defer s.serverMutex.Unlock()
-	return s.server.Shutdown(ctx)
+	return errors.Join(s.server.Shutdown(ctx), s.shutdownSideServers(ctx))
 }
 
 // ListenAndServe acts like standard http.ListenAndServe().
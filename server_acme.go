@@ -0,0 +1,67 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"slices"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMECacheDir is the default directory certs obtained by ACME are cached in, see ACMECache.
+var ACMECacheDir = "acme-certs"
+
+func (s *Server) acmeManager() *autocert.Manager {
+	if s.acme == nil {
+		s.acme = &autocert.Manager{Prompt: autocert.AcceptTOS, Cache: autocert.DirCache(ACMECacheDir)}
+	}
+	return s.acme
+}
+
+// ACME makes the server obtain and renew its cert automatically from an ACME CA, by default Let's Encrypt, for the hosts given.
+// Accepts the terms of service of the CA. Email is the contact of the account, may be empty.
+// Listens on HTTPS, port 443 by default. TLS-ALPN-01 challenge is answered there, see ACMEHTTPChallenge for HTTP-01.
+// Certs are cached in ACMECacheDir by default, so that those survive restarts and rate limits of the CA are not hit.
+//
+//	restful.NewServer().Handler(router).ACME("admin@example.com", "example.com", "www.example.com").ListenAndServe()
+func (s *Server) ACME(email string, hosts ...string) *Server {
+	m := s.acmeManager()
+	m.Email = email
+	m.HostPolicy = autocert.HostWhitelist(hosts...)
+	return s
+}
+
+// ACMECache sets the storage of certs and account key obtained by ACME, e.g. a K8s secret or a database shared by the replicas.
+// Use autocert.DirCache for a directory.
+func (s *Server) ACMECache(cache autocert.Cache) *Server {
+	s.acmeManager().Cache = cache
+	return s
+}
+
+// ACMEDirectory sets the directory URL of the ACME CA. E.g. the staging environment of Let's Encrypt for testing,
+// "https://acme-staging-v02.api.letsencrypt.org/directory".
+func (s *Server) ACMEDirectory(url string) *Server {
+	s.acmeManager().Client = &acme.Client{DirectoryURL: url}
+	return s
+}
+
+// ACMEHTTPChallenge makes the server answer HTTP-01 challenges on a plain HTTP address, typically ":80".
+// Other requests there are redirected to HTTPS.
+// The HTTP server is started and stopped together with the server.
+func (s *Server) ACMEHTTPChallenge(addr string) *Server {
+	s.acmeHTTP = newHTTPServer(addr, s.acmeManager().HTTPHandler(nil))
+	return s
+}
+
+// installACME makes the TLS config use the certs obtained by ACME, and answer TLS-ALPN-01 challenges.
+func (s *Server) installACME() {
+	config := s.haveTLSConfig()
+	config.Certificates = nil
+	config.GetCertificate = s.acme.GetCertificate
+	if !slices.Contains(config.NextProtos, acme.ALPNProto) { // Only ACME validators ask for it, so its position does not matter.
+		config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+	}
+}
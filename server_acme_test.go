@@ -0,0 +1,57 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestACME(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	server := NewServer().Addr("127.0.0.1:18446").Handler(http.NotFoundHandler()).
+		ACMECache(autocert.DirCache(dir)).ACMEDirectory("https://acme.invalid/directory").
+		ACME("admin@example.com", "localhost").ACMEHTTPChallenge("127.0.0.1:18447")
+	assert.True(server.tlsEnabled())
+	assert.Equal(autocert.DirCache(dir), server.acme.Cache)
+	assert.Equal("https://acme.invalid/directory", server.acme.Client.DirectoryURL)
+	assert.Equal("admin@example.com", server.acme.Email)
+
+	go server.ListenAndServe()
+	defer server.Close()
+
+	// HTTP challenge server redirects other requests to HTTPS.
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	var resp *http.Response
+	var err error
+	for range 100 {
+		if resp, err = client.Get("http://127.0.0.1:18447/users"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusFound, resp.StatusCode)
+		assert.Equal("https://127.0.0.1:443/users", resp.Header.Get("Location"))
+	}
+}
+
+func TestACMETLSConfig(t *testing.T) {
+	assert := assert.New(t)
+	server := NewServer().ACME("", "example.com")
+	certFile, keyFile, err := server.tlsFiles()
+	assert.NoError(err)
+	assert.Empty(certFile + keyFile)
+	assert.NotNil(server.server.TLSConfig.GetCertificate)
+	assert.Equal([]string{acme.ALPNProto}, server.server.TLSConfig.NextProtos)
+	assert.Equal(autocert.DirCache(ACMECacheDir), server.acme.Cache)
+}
@@ -0,0 +1,152 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// ServerMaxConns is the max number of concurrent connections of a listener by default, see Server's MaxConns.
+// Zero means no limit. May be set by RESTFUL_SERVER_MAX_CONNS environment variable.
+var ServerMaxConns = envInt("RESTFUL_SERVER_MAX_CONNS", 0)
+
+// ServerConnReadRate is the max bytes per second read from a connection by default, see Server's ConnRateLimit.
+// Zero means no limit. May be set by RESTFUL_SERVER_CONN_READ_RATE environment variable.
+var ServerConnReadRate = envInt("RESTFUL_SERVER_CONN_READ_RATE", 0)
+
+// ServerConnWriteRate is the max bytes per second written to a connection by default, see Server's ConnRateLimit.
+// Zero means no limit. May be set by RESTFUL_SERVER_CONN_WRITE_RATE environment variable.
+var ServerConnWriteRate = envInt("RESTFUL_SERVER_CONN_WRITE_RATE", 0)
+
+// MaxConns sets the max number of concurrent connections of the listener, including idle keep-alive ones. Zero means no limit.
+// Connections over the limit are closed right after accepted, before reading anything, so that clients fail fast and may retry other replicas.
+// Protects small pods from connection floods before requests reach any handler. Applies to the main listener and those added by Port, each.
+// See ServerMaxConns.
+func (s *Server) MaxConns(n int) *Server {
+	s.maxConns = n
+	return s
+}
+
+// ConnRateLimit sets the max bytes per second read from and written to each connection. Zero means no limit.
+// Reading and writing are delayed when exceeding the rate, bursts of one second are allowed.
+// Applies to the main listener and those added by Port. See ServerConnReadRate and ServerConnWriteRate.
+func (s *Server) ConnRateLimit(readBytesPerSec, writeBytesPerSec int) *Server {
+	s.connReadRate = readBytesPerSec
+	s.connWriteRate = writeBytesPerSec
+	return s
+}
+
+// limitListener returns the listener limited as set by MaxConns and ConnRateLimit, or the listener itself if there are no limits.
+func (s *Server) limitListener(ln net.Listener) net.Listener {
+	if s.maxConns <= 0 && s.connReadRate <= 0 && s.connWriteRate <= 0 {
+		return ln
+	}
+	return &limitedListener{Listener: ln, maxConns: int64(s.maxConns), readRate: s.connReadRate, writeRate: s.connWriteRate}
+}
+
+// limitedListener limits the number of its connections, and the rate of those.
+type limitedListener struct {
+	net.Listener
+	maxConns  int64
+	conns     atomic.Int64
+	readRate  int
+	writeRate int
+}
+
+func (l *limitedListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.maxConns > 0 && l.conns.Add(1) > l.maxConns {
+			l.conns.Add(-1)
+			logging.Debugf(context.Background(), "Max connections %d reached, closing connection from %s", l.maxConns, conn.RemoteAddr())
+			_ = conn.Close()
+			continue
+		}
+		return &limitedConn{Conn: conn, listener: l, reader: newByteRateLimiter(l.readRate), writer: newByteRateLimiter(l.writeRate)}, nil
+	}
+}
+
+// limitedConn is a connection of limitedListener.
+type limitedConn struct {
+	net.Conn
+	listener *limitedListener
+	once     sync.Once
+	reader   *byteRateLimiter // nil if not limited.
+	writer   *byteRateLimiter // nil if not limited.
+}
+
+func (c *limitedConn) Read(b []byte) (int, error) {
+	if c.reader == nil {
+		return c.Conn.Read(b)
+	}
+	n, err := c.Conn.Read(b[:min(len(b), c.reader.burst)])
+	c.reader.wait(n)
+	return n, err
+}
+
+func (c *limitedConn) Write(b []byte) (written int, err error) {
+	if c.writer == nil {
+		return c.Conn.Write(b)
+	}
+	for len(b) > 0 {
+		chunk := b[:min(len(b), c.writer.burst)]
+		c.writer.wait(len(chunk))
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+func (c *limitedConn) Close() error {
+	c.once.Do(func() {
+		if c.listener.maxConns > 0 {
+			c.listener.conns.Add(-1)
+		}
+	})
+	return c.Conn.Close()
+}
+
+// byteRateLimiter is a token bucket of bytes, refilled at the rate, holding at most burst bytes.
+type byteRateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// newByteRateLimiter creates a limiter of bytes per second, allowing a burst of one second. Returns nil if not limited.
+func newByteRateLimiter(bytesPerSec int) *byteRateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &byteRateLimiter{rate: float64(bytesPerSec), burst: bytesPerSec, tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+// wait takes n bytes from the bucket, sleeping until those are available.
+func (l *byteRateLimiter) wait(n int) {
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, float64(l.burst)) - float64(n)
+	l.last = now
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mutex.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
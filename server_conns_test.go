@@ -0,0 +1,79 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxConns(t *testing.T) {
+	assert := assert.New(t)
+	addr := "127.0.0.1:18457"
+	server := NewServer().Addr(addr).MaxConns(1).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	go server.ListenAndServe()
+	defer server.Close()
+
+	var first net.Conn
+	for range 100 {
+		var err error
+		if first, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !assert.NotNil(first) {
+		return
+	}
+	_, err := first.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	assert.NoError(err)
+	buf := make([]byte, 12)
+	_, err = io.ReadFull(first, buf)
+	assert.NoError(err)
+	assert.Equal("HTTP/1.1 200", string(buf))
+
+	// Over the limit.
+	second, err := net.Dial("tcp", addr)
+	if assert.NoError(err) {
+		_ = second.SetReadDeadline(time.Now().Add(time.Second))
+		_, err = second.Read(buf)
+		assert.Error(err) // EOF, not timeout.
+		second.Close()
+	}
+
+	// Connection released.
+	first.Close()
+	client := &http.Client{Transport: &http.Transport{}}
+	var resp *http.Response
+	for range 100 {
+		if resp, err = client.Get("http://" + addr); err == nil {
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(err)
+}
+
+func TestConnRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(net.Listener(nil), NewServer().MaxConns(0).ConnRateLimit(0, 0).limitListener(nil))
+
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := &limitedConn{Conn: server, listener: &limitedListener{}, writer: newByteRateLimiter(1000)}
+	go func() { _, _ = io.Copy(io.Discard, client) }()
+	start := time.Now()
+	n, err := conn.Write(make([]byte, 1500)) // Burst of 1000, then 500 more in 0.5s.
+	assert.NoError(err)
+	assert.Equal(1500, n)
+	assert.GreaterOrEqual(time.Since(start), 400*time.Millisecond)
+	assert.NoError(conn.Close())
+}
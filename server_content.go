@@ -0,0 +1,67 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// Blob is a binary response of a Lambda handler or SendResp, e.g. a media file, having a name and modification time.
+// The name sets Content-Type by its extension, unless set by the handler. The modification time sets Last-Modified, unless zero.
+//
+//	func getImage(ctx context.Context) (*restful.Blob, error) {
+//		return &restful.Blob{Name: "cat.png", ModTime: modTime, Content: bytes.NewReader(image)}, nil
+//	}
+type Blob struct {
+	Name    string
+	ModTime time.Time
+	Content io.Reader // If an io.ReadSeeker, Range requests are supported. Closed after sent, if an io.Closer.
+}
+
+// asBlob tells whether the data is a binary response, i.e. a Blob or an io.Reader, such as *os.File or *bytes.Reader.
+// Name and modification time of files are taken by Stat.
+func asBlob(data any) (*Blob, bool) {
+	switch v := data.(type) {
+	case *Blob:
+		return v, v != nil
+	case Blob:
+		return &v, true
+	case io.Reader:
+		blob := Blob{Content: v}
+		if f, ok := v.(interface{ Stat() (fs.FileInfo, error) }); ok {
+			if info, err := f.Stat(); err == nil {
+				blob.Name, blob.ModTime = info.Name(), info.ModTime()
+			}
+		}
+		return &blob, true
+	}
+	return nil, false
+}
+
+// send sends the blob. Seekable content is served by http.ServeContent, supporting Range, If-Range and conditional requests,
+// responding 206 with Content-Range header for ranges. Other content is streamed.
+func (b *Blob) send(w http.ResponseWriter, r *http.Request) (err error) {
+	if closer, ok := b.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if content, ok := b.Content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, b.Name, b.ModTime, content)
+		return nil
+	}
+	if w.Header().Get(ContentTypeHeader) == "" {
+		w.Header().Set(ContentTypeHeader, "application/octet-stream")
+	}
+	if !b.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", b.ModTime.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead && b.Content != nil {
+		_, err = io.Copy(w, b.Content)
+	}
+	return err
+}
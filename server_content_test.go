@@ -0,0 +1,80 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobRange(t *testing.T) {
+	assert := assert.New(t)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := NewRouter()
+	r.HandleFunc("/blob", func() (*Blob, error) {
+		return &Blob{Name: "video.mp4", ModTime: modTime, Content: bytes.NewReader([]byte("0123456789"))}, nil
+	})
+
+	serve := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/blob", nil)
+		req.Header = header
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(http.Header{})
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("0123456789", rr.Body.String())
+	assert.Equal("video/mp4", rr.Header().Get("Content-Type"))
+	assert.Equal("bytes", rr.Header().Get("Accept-Ranges"))
+	assert.Equal(modTime.Format(http.TimeFormat), rr.Header().Get("Last-Modified"))
+
+	rr = serve(http.Header{"Range": {"bytes=2-5"}})
+	assert.Equal(http.StatusPartialContent, rr.Code)
+	assert.Equal("2345", rr.Body.String())
+	assert.Equal("bytes 2-5/10", rr.Header().Get("Content-Range"))
+
+	// Range ignored if content changed since.
+	rr = serve(http.Header{"Range": {"bytes=2-5"}, "If-Range": {modTime.Add(-time.Hour).Format(http.TimeFormat)}})
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("0123456789", rr.Body.String())
+
+	rr = serve(http.Header{"Range": {"bytes=20-"}})
+	assert.Equal(http.StatusRequestedRangeNotSatisfiable, rr.Code)
+}
+
+func TestBlobFile(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "data.txt")
+	assert.NoError(os.WriteFile(path, []byte("hello world"), 0o600))
+	r := NewRouter()
+	r.HandleFunc("/file", func() (*os.File, error) { return os.Open(path) })
+	r.HandleFunc("/stream", func() (any, error) { return io.MultiReader(strings.NewReader("stream")), nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Range", "bytes=6-")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(http.StatusPartialContent, rr.Code)
+	assert.Equal("world", rr.Body.String())
+	assert.Equal("bytes 6-10/11", rr.Header().Get("Content-Range"))
+	assert.True(strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain"))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stream", nil)) // Not seekable.
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("stream", rr.Body.String())
+	assert.Equal("application/octet-stream", rr.Header().Get("Content-Type"))
+}
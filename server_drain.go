@@ -0,0 +1,64 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// CloseOnDrain makes responses sent during graceful shutdown have "Connection: close" header, HTTP/2 connections get GOAWAY.
+// So that keep-alive clients reconnect to other replicas during the grace period, instead of finding the connection closed later.
+// See Graceful.
+func (s *Server) CloseOnDrain() *Server {
+	s.closeOnDrain = true
+	return s
+}
+
+// DrainTimeout sets the max time to wait for connections to complete on graceful shutdown, after the grace period.
+// Connections still active then are closed. By default awaited indefinitely. See Graceful.
+func (s *Server) DrainTimeout(timeout time.Duration) *Server {
+	s.drainTimeout = timeout
+	return s
+}
+
+// Draining tells whether the server is shutting down gracefully, i.e. a shutdown signal was received.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// drain shuts down the server gracefully: fails readiness, keeps serving for the grace period, then waits for connections to complete.
+func (s *Server) drain() error {
+	s.draining.Store(true)
+	SetReady(false) // No new requests routed here, while shutting down.
+
+	if s.gracePeriod > 0 {
+		logging.Debugf(context.Background(), "Waiting grace period: %v", s.gracePeriod)
+		time.Sleep(s.gracePeriod) // Still accept new connections.
+		logging.Debugf(context.Background(), "Grace period over")
+	} else {
+		time.Sleep(10 * time.Millisecond) // Clients just connected to be served. E.g. K8s endpoint just deleted.
+	}
+
+	logging.Debugf(context.Background(), "Waiting client connections to shut down")
+	ctx := context.Background()
+	if s.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.drainTimeout)
+		defer cancel()
+	}
+	s.serverMutex.Lock()
+	server := s.server
+	s.serverMutex.Unlock()
+	err := server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logging.Errorf(context.Background(), "Drain timeout %v exceeded, closing connections", s.drainTimeout)
+		_ = server.Close()
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseOnDrain(t *testing.T) {
+	assert := assert.New(t)
+	server := NewServer().CloseOnDrain().Handler(http.NotFoundHandler())
+	serve := func() string {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Header().Get("Connection")
+	}
+	assert.Empty(serve())
+	server.draining.Store(true)
+	assert.True(server.Draining())
+	assert.Equal("close", serve())
+}
+
+func TestDrainTimeout(t *testing.T) {
+	assert := assert.New(t)
+	defer SetReady(true)
+	addr := "127.0.0.1:18453"
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	server := NewServer().Addr(addr).DrainTimeout(50 * time.Millisecond).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+	go server.ListenAndServe()
+	go func() {
+		for range 100 {
+			if resp, err := http.Get("http://" + addr); err == nil {
+				resp.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	<-entered // Request in progress.
+
+	start := time.Now()
+	assert.ErrorIs(server.drain(), context.DeadlineExceeded)
+	assert.Less(time.Since(start), time.Second)
+	assert.True(server.Draining())
+	assert.True(notReady.Load())
+}
@@ -0,0 +1,88 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// LifecycleHookTimeout is the max time a start or stop hook may run, see Server's OnStart and OnStop.
+var LifecycleHookTimeout = 30 * time.Second
+
+// LifecycleHookFunc is a start or stop hook. The context is canceled on LifecycleHookTimeout.
+type LifecycleHookFunc func(ctx context.Context) error
+
+type lifecycleHook struct {
+	name string
+	f    LifecycleHookFunc
+}
+
+// OnStart adds a hook executed by ListenAndServe before listening, e.g. warming caches or opening DB pools.
+// Hooks are executed in the order added. If one fails, ListenAndServe returns its error, and stop hooks are not executed.
+func (s *Server) OnStart(name string, f LifecycleHookFunc) *Server {
+	s.onStart = append(s.onStart, lifecycleHook{name: name, f: f})
+	return s
+}
+
+// OnStop adds a hook executed by ListenAndServe after the server stopped, and connections were drained if Graceful is set.
+// E.g. deregistering from a service registry or closing DB pools.
+// Hooks are executed in the reverse order added, all of them even if some fail. Errors are returned by ListenAndServe.
+func (s *Server) OnStop(name string, f LifecycleHookFunc) *Server {
+	s.onStop = append(s.onStop, lifecycleHook{name: name, f: f})
+	return s
+}
+
+// run executes the hook, not waiting for it longer than LifecycleHookTimeout.
+func (h lifecycleHook) run(phase string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), LifecycleHookTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- h.f(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook '%s': %w", phase, h.name, err)
+	}
+	logging.Debugf(context.Background(), "Executed %s hook '%s'", phase, h.name)
+	return nil
+}
+
+func (s *Server) runStartHooks() error {
+	for _, hook := range s.onStart {
+		if err := hook.run("start"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) runStopHooks() error {
+	var errs []error
+	for _, hook := range slices.Backward(s.onStop) {
+		if err := hook.run("stop"); err != nil {
+			logging.Errorf(context.Background(), "%v", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withStopHooks executes the stop hooks, and returns their errors joined to err, if any.
+func (s *Server) withStopHooks(err error) error {
+	if stopErr := s.runStopHooks(); stopErr != nil {
+		return errors.Join(err, stopErr)
+	}
+	return err
+}
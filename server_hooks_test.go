@@ -0,0 +1,51 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	assert := assert.New(t)
+	var executed []string
+	hook := func(name string, err error) LifecycleHookFunc {
+		return func(ctx context.Context) error {
+			executed = append(executed, name)
+			return err
+		}
+	}
+	errStop := errors.New("stop failed")
+	server := NewServer().Addr(":-1") // Listening fails.
+	server.OnStart("cache", hook("start cache", nil)).OnStart("db", hook("start db", nil))
+	server.OnStop("cache", hook("stop cache", errStop)).OnStop("db", hook("stop db", nil))
+	err := server.ListenAndServe()
+	assert.Error(err)
+	assert.ErrorIs(err, errStop)
+	assert.Equal([]string{"start cache", "start db", "stop db", "stop cache"}, executed)
+
+	// Failing start hook stops starting.
+	executed = nil
+	errStart := errors.New("start failed")
+	err = NewServer().Addr(":-1").OnStart("db", hook("start db", errStart)).OnStart("cache", hook("start cache", nil)).OnStop("db", hook("stop db", nil)).ListenAndServe()
+	assert.ErrorIs(err, errStart)
+	assert.ErrorContains(err, "start hook 'db'")
+	assert.Equal([]string{"start db"}, executed)
+}
+
+func TestLifecycleHookTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { LifecycleHookTimeout = timeout }(LifecycleHookTimeout)
+	LifecycleHookTimeout = 10 * time.Millisecond
+	hang := lifecycleHook{name: "hang", f: func(ctx context.Context) error {
+		time.Sleep(time.Second) // Not respecting the context.
+		return nil
+	}}
+	assert.ErrorIs(t, hang.run("start"), context.DeadlineExceeded)
+}
@@ -0,0 +1,61 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HTTPAddr makes an HTTPS server listen on a plain HTTP address, too, typically ":80", serving the same handler.
+// See HTTPRedirect for redirecting those requests to HTTPS instead.
+// If ACME is used, HTTP-01 challenges are answered there, too.
+// The HTTP server is started and stopped together with the server.
+//
+//	restful.NewServer().Addr(":443").HTTPAddr(":80").HTTPRedirect().Handler(router).ACME("", "example.com").ListenAndServe()
+func (s *Server) HTTPAddr(addr string) *Server {
+	s.plainHTTP = newHTTPServer(addr, nil)
+	return s
+}
+
+// HTTPRedirect makes requests received at HTTPAddr redirected to HTTPS, with 308 Permanent Redirect, keeping method and body.
+func (s *Server) HTTPRedirect() *Server {
+	s.httpRedirect = true
+	return s
+}
+
+// plainHandler returns the handler of the plain HTTP server.
+func (s *Server) plainHandler() http.Handler {
+	handler := s.server.Handler
+	if s.httpRedirect {
+		handler = httpsRedirectHandler(s.server.Addr)
+	}
+	if s.acme != nil {
+		handler = s.acme.HTTPHandler(handler)
+	}
+	return handler
+}
+
+// httpsRedirectHandler redirects requests to the same host and URI on HTTPS, listening on the address given.
+func httpsRedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	if port == "https" {
+		port = "443"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") { // IPv6
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestHTTPAddr(t *testing.T) {
+	assert := assert.New(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	server := NewServer().Addr("127.0.0.1:18450").HTTPAddr("127.0.0.1:18451").Handler(handler).TLSServerCert("test_certs/tls.crt", "test_certs/tls.key")
+	go server.ListenAndServe()
+	defer server.Close()
+
+	var resp *http.Response
+	var err error
+	for range 100 {
+		if resp, err = http.Get("http://127.0.0.1:18451/users"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusNoContent, resp.StatusCode)
+	}
+
+	err = NewClient().TLSRootCerts("test_certs", false).Get(context.Background(), "https://localhost:18450/users", nil)
+	assert.NoError(err)
+}
+
+func TestHTTPRedirect(t *testing.T) {
+	assert := assert.New(t)
+	server := NewServer().Addr(":8443").HTTPAddr(":8080").HTTPRedirect().Handler(http.NotFoundHandler())
+
+	for _, tc := range []struct{ host, location string }{
+		{"example.com:8080", "https://example.com:8443/users?id=1"},
+		{"example.com", "https://example.com:8443/users?id=1"},
+		{"[::1]:8080", "https://[::1]:8443/users?id=1"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "http://"+tc.host+"/users?id=1", nil)
+		w := httptest.NewRecorder()
+		server.plainHandler().ServeHTTP(w, req)
+		assert.Equal(http.StatusPermanentRedirect, w.Code)
+		assert.Equal(tc.location, w.Header().Get("Location"))
+	}
+
+	for _, addr := range []string{"", ":443", ":https"} {
+		req := httptest.NewRequest(http.MethodGet, "http://[::1]/", nil)
+		w := httptest.NewRecorder()
+		httpsRedirectHandler(addr).ServeHTTP(w, req)
+		assert.Equal("https://[::1]/", w.Header().Get("Location"))
+	}
+
+	// ACME challenges are not redirected.
+	server.ACMECache(autocert.DirCache(t.TempDir())).ACME("", "example.com")
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/acme-challenge/token", nil)
+	w := httptest.NewRecorder()
+	server.plainHandler().ServeHTTP(w, req)
+	assert.Equal(http.StatusNotFound, w.Code)
+}
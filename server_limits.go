@@ -0,0 +1,169 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+)
+
+// envDuration returns the duration of the environment variable, e.g. "30s", or the default if not set or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		logging.Errorf(context.Background(), "Invalid %s '%s', using %v", name, value, def)
+		return def
+	}
+	return d
+}
+
+// envInt returns the integer of the environment variable, or the default if not set or invalid.
+func envInt(name string, def int) int {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		logging.Errorf(context.Background(), "Invalid %s '%s', using %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// newHTTPServer creates an HTTP server having the default timeouts and limits.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: ServerReadHeaderTimeout,
+		ReadTimeout:       ServerReadTimeout,
+		WriteTimeout:      ServerWriteTimeout,
+		IdleTimeout:       ServerIdleTimeout,
+		MaxHeaderBytes:    ServerMaxHeaderBytes,
+	}
+}
+
+// ReadHeaderTimeout sets the time allowed to read request headers. Zero means no timeout. See ServerReadHeaderTimeout.
+func (s *Server) ReadHeaderTimeout(timeout time.Duration) *Server {
+	s.server.ReadHeaderTimeout = timeout
+	return s
+}
+
+// ReadTimeout sets the time allowed to read the whole request, including the body. Zero means no timeout. See ServerReadTimeout.
+func (s *Server) ReadTimeout(timeout time.Duration) *Server {
+	s.server.ReadTimeout = timeout
+	return s
+}
+
+// WriteTimeout sets the time allowed to serve a request and write the response, from the end of reading the headers.
+// Zero means no timeout. See ServerWriteTimeout.
+func (s *Server) WriteTimeout(timeout time.Duration) *Server {
+	s.server.WriteTimeout = timeout
+	return s
+}
+
+// IdleTimeout sets the time a keep-alive connection may wait for the next request. Zero means ReadTimeout. See ServerIdleTimeout.
+func (s *Server) IdleTimeout(timeout time.Duration) *Server {
+	s.server.IdleTimeout = timeout
+	return s
+}
+
+// MaxHeaderBytes sets the max size of request headers, including the request line. See ServerMaxHeaderBytes.
+func (s *Server) MaxHeaderBytes(n int) *Server {
+	s.server.MaxHeaderBytes = n
+	return s
+}
+
+// MaxRequestsPerConn sets the max number of requests served on a connection. The connection is closed after the response of the last one,
+// so that clients reconnect, and load is rebalanced among the replicas, even if connections are long-lived. Zero means no limit.
+// See ServerMaxRequestsPerConn.
+func (s *Server) MaxRequestsPerConn(n int) *Server {
+	s.maxRequestsPerConn = n
+	return s
+}
+
+// MaxConnAge sets the max age of a connection. The connection is closed after the response of the first request served when that age is reached,
+// so that clients reconnect, and load is rebalanced among the replicas, even if connections are long-lived. Zero means no limit.
+// The age is randomized by +/-10% per connection, so that connections opened together are not closed together. See ServerMaxConnAge.
+func (s *Server) MaxConnAge(age time.Duration) *Server {
+	s.maxConnAge = age
+	return s
+}
+
+type connLimitsCtxKeyType string
+
+const connLimitsCtxName = connLimitsCtxKeyType("restfulConnLimits")
+
+// connLimits tells when a connection is to be closed.
+type connLimits struct {
+	maxRequests int32 // Zero means no limit.
+	requests    atomic.Int32
+	expires     time.Time // Zero means no limit.
+}
+
+// newConnLimits returns the limits of a new connection, or nil if there are none.
+func (s *Server) newConnLimits() *connLimits {
+	if s.maxRequestsPerConn <= 0 && s.maxConnAge <= 0 {
+		return nil
+	}
+	c := connLimits{maxRequests: int32(min(s.maxRequestsPerConn, 1<<31-1))} // #nosec G115
+	if s.maxConnAge > 0 {
+		jitter := time.Duration(rand.Int64N(int64(s.maxConnAge)/5+1)) - s.maxConnAge/10 // #nosec G404
+		c.expires = time.Now().Add(s.maxConnAge + jitter)
+	}
+	return &c
+}
+
+// closing tells whether the connection is to be closed after the response of the current request.
+func (c *connLimits) closing() bool {
+	if c.maxRequests > 0 && c.requests.Add(1) >= c.maxRequests {
+		return true
+	}
+	return !c.expires.IsZero() && time.Now().After(c.expires)
+}
+
+// connContext returns the http.Server ConnContext function of the server, if any needed.
+func (s *Server) connContext() func(ctx context.Context, c net.Conn) context.Context {
+	if !s.proxyProto && s.maxRequestsPerConn <= 0 && s.maxConnAge <= 0 {
+		return nil
+	}
+	return func(ctx context.Context, c net.Conn) context.Context {
+		if s.proxyProto {
+			ctx = proxyConnContext(ctx, c)
+		}
+		if limits := s.newConnLimits(); limits != nil {
+			ctx = context.WithValue(ctx, connLimitsCtxName, limits)
+		}
+		return ctx
+	}
+}
+
+// connCloseHandler makes the connection closed after the response of its last request allowed, when it is too old,
+// or while draining if CloseOnDrain is set. HTTP/2 connections are closed gracefully by GOAWAY.
+func (s *Server) connCloseHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		closing := s.closeOnDrain && s.draining.Load()
+		if c, ok := r.Context().Value(connLimitsCtxName).(*connLimits); ok && c.closing() {
+			closing = true
+		}
+		if closing {
+			w.Header().Set("Connection", "close")
+		}
+		h.ServeHTTP(w, r)
+	})
+}
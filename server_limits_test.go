@@ -0,0 +1,116 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvLimits(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("RESTFUL_TEST_DURATION", "30s")
+	assert.Equal(30*time.Second, envDuration("RESTFUL_TEST_DURATION", time.Second))
+	t.Setenv("RESTFUL_TEST_DURATION", "30")
+	assert.Equal(time.Second, envDuration("RESTFUL_TEST_DURATION", time.Second))
+	assert.Equal(time.Second, envDuration("RESTFUL_TEST_NONE", time.Second))
+
+	t.Setenv("RESTFUL_TEST_INT", "100")
+	assert.Equal(100, envInt("RESTFUL_TEST_INT", 1))
+	t.Setenv("RESTFUL_TEST_INT", "-1")
+	assert.Equal(1, envInt("RESTFUL_TEST_INT", 1))
+	assert.Equal(1, envInt("RESTFUL_TEST_NONE", 1))
+}
+
+func TestServerLimits(t *testing.T) {
+	assert := assert.New(t)
+	server := NewServer()
+	assert.Equal(ServerWriteTimeout, server.server.WriteTimeout)
+	assert.Equal(ServerIdleTimeout, server.server.IdleTimeout)
+	assert.Equal(http.DefaultMaxHeaderBytes, server.server.MaxHeaderBytes)
+	assert.Nil(server.connContext())
+
+	server.ReadHeaderTimeout(time.Second).ReadTimeout(2 * time.Second).WriteTimeout(3 * time.Second).IdleTimeout(4 * time.Second).MaxHeaderBytes(1024).MaxRequestsPerConn(2)
+	assert.Equal(time.Second, server.server.ReadHeaderTimeout)
+	assert.Equal(2*time.Second, server.server.ReadTimeout)
+	assert.Equal(3*time.Second, server.server.WriteTimeout)
+	assert.Equal(4*time.Second, server.server.IdleTimeout)
+	assert.Equal(1024, server.server.MaxHeaderBytes)
+	assert.NotNil(server.connContext())
+}
+
+func TestMaxRequestsPerConn(t *testing.T) {
+	assert := assert.New(t)
+	addr := "127.0.0.1:18452"
+	server := NewServer().Addr(addr).MaxRequestsPerConn(2).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	go server.ListenAndServe()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	get := func() *http.Response {
+		for range 100 {
+			if resp, err := client.Get("http://" + addr); err == nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				return resp
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	}
+	for range 2 { // Each connection serves 2 requests.
+		if resp := get(); assert.NotNil(resp) {
+			assert.False(resp.Close)
+		}
+		if resp := get(); assert.NotNil(resp) {
+			assert.True(resp.Close)
+		}
+	}
+}
+
+func TestMaxConnAge(t *testing.T) {
+	assert := assert.New(t)
+	addr := "127.0.0.1:18454"
+	server := NewServer().Addr(addr).MaxConnAge(100 * time.Millisecond).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	go server.ListenAndServe()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	get := func() *http.Response {
+		for range 100 {
+			if resp, err := client.Get("http://" + addr); err == nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				return resp
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	}
+	if resp := get(); assert.NotNil(resp) {
+		assert.False(resp.Close)
+	}
+	time.Sleep(150 * time.Millisecond) // Max age with max jitter is 110ms.
+	if resp := get(); assert.NotNil(resp) {
+		assert.True(resp.Close)
+	}
+	if resp := get(); assert.NotNil(resp) { // New connection.
+		assert.False(resp.Close)
+	}
+}
+
+func TestConnLimits(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(NewServer().MaxRequestsPerConn(0).MaxConnAge(0).newConnLimits())
+	for range 100 {
+		c := NewServer().MaxConnAge(time.Minute).newConnLimits()
+		assert.WithinRange(c.expires, time.Now().Add(54*time.Second), time.Now().Add(66*time.Second))
+		assert.False(c.closing())
+	}
+}
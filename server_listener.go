@@ -0,0 +1,82 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ListenerFile makes the server serve on an already listening socket, instead of listening on Addr.
+// E.g. one inherited from systemd, see SystemdListener, or from a supervisor process restarting the server without downtime.
+// The file is kept open, so that Restart can serve on it again.
+func (s *Server) ListenerFile(f *os.File) *Server {
+	s.listenerFile = f
+	return s
+}
+
+// SystemdListener makes the server serve on a socket passed by systemd socket activation.
+// Name selects the socket by FileDescriptorName of the socket unit, or the first one is used if name is empty.
+// If there is no such socket, e.g. the server was not started by systemd, the server listens on Addr as usual.
+//
+//	restful.NewServer().Addr(":8080").SystemdListener("").Handler(router).ListenAndServe()
+func (s *Server) SystemdListener(name string) *Server {
+	for _, f := range SystemdListenerFiles() {
+		if name == "" || f.Name() == name {
+			return s.ListenerFile(f)
+		}
+	}
+	return s
+}
+
+// SystemdListenerFiles returns the sockets passed by systemd socket activation, as of LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES environment variables.
+// Files are named by FileDescriptorName of the socket unit, or "LISTEN_FD_<fd>" if unnamed.
+// The variables are unset, so that child processes do not take those over.
+func SystemdListenerFiles() []*os.File {
+	return systemdListenerFilesOnce()
+}
+
+var systemdListenerFilesOnce = sync.OnceValue(systemdListenerFiles)
+
+func systemdListenerFiles() (files []*os.File) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	const listenFDsStart = 3
+	for i := range n {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		files = append(files, os.NewFile(uintptr(fd), name))
+	}
+	return files
+}
+
+// listen returns the listener of the listener file, if set, or listens on Addr, or the default port of the scheme.
+func (s *Server) listen(tls bool) (net.Listener, error) {
+	addr := s.server.Addr
+	if addr == "" {
+		addr = ":http"
+		if tls {
+			addr = ":https"
+		}
+	}
+	return s.listenAddr(addr, s.listenerFile)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenerFile(t *testing.T) {
+	assert := assert.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	f, err := ln.(*net.TCPListener).File()
+	assert.NoError(err)
+	defer f.Close()
+	assert.NoError(ln.Close()) // The file holds the socket.
+
+	server := NewServer().Addr("127.0.0.1:1").ListenerFile(f).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+	go server.ListenAndServe()
+	defer server.Close()
+
+	get := func() int {
+		for range 100 {
+			if resp, err := http.Get("http://" + ln.Addr().String()); err == nil {
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return 0
+	}
+	assert.Equal(http.StatusNoContent, get())
+
+	server.Restart() // Serves on the file again.
+	assert.Equal(http.StatusNoContent, get())
+}
+
+func TestSystemdListenerFiles(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	assert.Empty(systemdListenerFiles())
+	_, ok := os.LookupEnv("LISTEN_FDS")
+	assert.False(ok)
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "0")
+	assert.Empty(systemdListenerFiles())
+
+	assert.Nil(NewServer().SystemdListener("web").listenerFile) // Not activated by systemd.
+}
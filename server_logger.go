@@ -6,9 +6,10 @@ package restful
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/nokia/restful/logging"
 )
 
 type loggerCtxKey string
@@ -24,12 +25,13 @@ var (
 	HealthCheckPath = "/healthz"
 
 	// LivenessProbePath is the path of liveness probes.
-	// Handled automatically, 200 OK sent.
+	// Handled automatically, 200 OK sent, or 503 if a liveness check fails. See AddLivenessCheck.
 	// Ignored at logging. By default "/livez".
 	LivenessProbePath = "/livez"
 
 	// ReadinessProbePath is the path of readiess probes.
-	// Not handled automatically. But a custom endpoint is needed.
+	// Handled automatically if there are readiness checks or the application is not ready, see AddReadinessCheck and SetReady.
+	// Otherwise a custom endpoint is needed.
 	// Ignored at logging. By default "/readyz".
 	ReadinessProbePath = "/readyz"
 )
@@ -38,24 +40,24 @@ func loggerPost(w http.ResponseWriter, r *http.Request, statusCode int) {
 	v := r.Context().Value(loggerCtxName)
 	if v != nil {
 		if traceStr, ok := v.(string); ok {
-			log.Debugf("[%s] Sent rsp: %d", traceStr, statusCode)
+			logging.Debugf(r.Context(), "[%s] Sent rsp: %d", traceStr, statusCode)
 		}
 	}
 }
 
 func loggerPre(w http.ResponseWriter, r *http.Request) *http.Request {
 	if r.URL.Path == LivenessProbePath {
-		w.Header().Set("Cache-Control", "no-cache")
-		w.WriteHeader(http.StatusOK) // No logs, stop processing.
+		serveHealth(w, r, &livenessChecks, false) // No logs, stop processing.
 	} else if r.URL.Path == HealthCheckPath {
-		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "close")
-		w.WriteHeader(http.StatusOK) // No logs, stop processing.
-	} else if r.URL.Path != ReadinessProbePath && log.IsLevelEnabled(log.DebugLevel) { // If log won't be printed, then omit context and trace operations.
+		serveHealth(w, r, &livenessChecks, false) // No logs, stop processing.
+	} else if r.URL.Path == ReadinessProbePath && (readinessChecks.len() > 0 || notReady.Load()) {
+		serveHealth(w, r, &readinessChecks, true) // No logs, stop processing.
+	} else if r.URL.Path != ReadinessProbePath && logging.FromContext(r.Context()).Enabled(r.Context(), slog.LevelDebug) { // If log won't be printed, then omit context and trace operations.
 		trace := traceFromContextOrRequestOrRandom(r)
 		traceStr := trace.String()
 		r = r.WithContext(context.WithValue(r.Context(), loggerCtxName, traceStr)) // Add trace string to req context, to be retrieved at response logging.
-		log.Debugf("[%s] Recv req: %s %s", traceStr, r.Method, r.URL.Path)
+		logging.Debugf(r.Context(), "[%s] Recv req: %s %s", traceStr, r.Method, r.URL.Path)
 	}
 	return r
 }
@@ -65,9 +67,10 @@ func loggerPre(w http.ResponseWriter, r *http.Request) *http.Request {
 // Logs contain the received or generated semi-random trace IDs to be able to correlate requests and responses.
 //
 //   - If path matches LivenessProbePath or HealthCheckPath then it does not log anything
-//     and responds with 200 OK without any further processing.
-//   - If path matches ReadinessProbePath then it does not log anything,
-//     but the request is processed, as usual.
+//     and responds with 200 OK without any further processing, or 503 if a liveness check fails.
+//   - If path matches ReadinessProbePath then it does not log anything.
+//     If there are readiness checks or the application is not ready, then responds without any further processing.
+//     Otherwise the request is processed, as usual.
 func Logger(h http.Handler) http.Handler {
 	return Monitor(h, loggerPre, loggerPost)
 }
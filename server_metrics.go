@@ -0,0 +1,110 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+type serverMetrics struct {
+	duration metric.Float64Histogram
+	active   metric.Int64UpDownCounter
+}
+
+var (
+	serverMetricsMutex sync.Mutex
+	serverMetricsCache = map[metric.MeterProvider]*serverMetrics{}
+)
+
+func getServerMetrics() *serverMetrics {
+	mp := otel.GetMeterProvider()
+	serverMetricsMutex.Lock()
+	defer serverMetricsMutex.Unlock()
+	if m, ok := serverMetricsCache[mp]; ok {
+		return m
+	}
+
+	meter := mp.Meter(MetricsScope)
+	m := &serverMetrics{}
+	m.duration, _ = meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests."), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	m.active, _ = meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of active HTTP server requests."), metric.WithUnit("{request}"))
+	serverMetricsCache[mp] = m
+	return m
+}
+
+type serverMetricsCtxKeyType string
+
+const serverMetricsCtxName = serverMetricsCtxKeyType("restfulServerMetrics")
+
+// serverMetricsData is stored in the request context, so that the Router can tell the route matched.
+type serverMetricsData struct {
+	start  time.Time
+	route  string
+	tenant string
+}
+
+func serverRequestAttributes(r *http.Request) []attribute.KeyValue {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLScheme(scheme)}
+}
+
+// ServerMetrics wraps the handler, emitting OpenTelemetry metrics using the global MeterProvider.
+// Server does that automatically if OTel metrics are enabled, see SetOTelMetrics.
+//
+//   - http.server.request.duration histogram, labeled by method, scheme, route template of Router, status code and tenant if TenantMetrics is set, see TenantExtractor.
+//   - http.server.active_requests of requests being served.
+func ServerMetrics(h http.Handler) http.Handler {
+	m := getServerMetrics()
+	return Monitor(h,
+		func(w http.ResponseWriter, r *http.Request) *http.Request {
+			m.active.Add(r.Context(), 1, metric.WithAttributes(serverRequestAttributes(r)...))
+			return r.WithContext(context.WithValue(r.Context(), serverMetricsCtxName, &serverMetricsData{start: time.Now()}))
+		},
+		func(w http.ResponseWriter, r *http.Request, statusCode int) {
+			data := r.Context().Value(serverMetricsCtxName).(*serverMetricsData)
+			attrs := serverRequestAttributes(r)
+			m.active.Add(r.Context(), -1, metric.WithAttributes(attrs...))
+
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+			attrs = append(attrs, semconv.HTTPResponseStatusCode(statusCode))
+			if data.route != "" {
+				attrs = append(attrs, semconv.HTTPRoute(data.route))
+			}
+			if TenantMetrics && data.tenant != "" {
+				attrs = append(attrs, TenantKey.String(data.tenant))
+			}
+			m.duration.Record(r.Context(), time.Since(data.start).Seconds(), metric.WithAttributes(attrs...))
+		})
+}
+
+// setServerMetricsRoute stores the route template matched, if server metrics are collected.
+func setServerMetricsRoute(r *http.Request, route string) {
+	if data, ok := r.Context().Value(serverMetricsCtxName).(*serverMetricsData); ok {
+		data.route = route
+	}
+}
+
+// setServerMetricsTenant stores the tenant extracted, if server metrics are collected.
+func setServerMetricsTenant(r *http.Request, tenant string) {
+	if data, ok := r.Context().Value(serverMetricsCtxName).(*serverMetricsData); ok {
+		data.tenant = tenant
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nokia/restful/trace/tracer"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestServerMetrics(t *testing.T) {
+	assert := assert.New(t)
+	reader := sdkmetric.NewManualReader()
+	prevProvider := otel.GetMeterProvider()
+	SetOTelMetrics(true, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer func() {
+		SetOTelMetrics(false, nil)
+		otel.SetMeterProvider(prevProvider)
+	}()
+
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func(ctx context.Context) error { return nil }).Methods(http.MethodGet)
+	handler := NewServer().Handler(r).server.Handler
+	for _, path := range []string{"/users/1", "/users/2", "/nope"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &rm))
+
+	duration := findMetric(rm, "http.server.request.duration")
+	if assert.NotNil(duration) {
+		hist := duration.Data.(metricdata.Histogram[float64])
+		assert.Len(hist.DataPoints, 2)
+		for _, dp := range hist.DataPoints {
+			status, _ := dp.Attributes.Value(semconv.HTTPResponseStatusCodeKey)
+			route, hasRoute := dp.Attributes.Value(semconv.HTTPRouteKey)
+			switch status.AsInt64() {
+			case http.StatusNoContent:
+				assert.Equal(uint64(2), dp.Count)
+				assert.Equal("/users/{id}", route.AsString())
+			case http.StatusNotFound:
+				assert.Equal(uint64(1), dp.Count)
+				assert.False(hasRoute)
+			default:
+				assert.Fail("unexpected status", status.Emit())
+			}
+		}
+	}
+
+	active := findMetric(rm, "http.server.active_requests")
+	if assert.NotNil(active) {
+		assert.Equal(int64(0), active.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+	}
+
+	assert.True(tracer.GetOTelMetrics())
+	assert.NotNil(NewClient().metrics)
+}
+
+func TestMetricsExemplars(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+	reader := sdkmetric.NewManualReader()
+	prevProvider := otel.GetMeterProvider()
+	SetOTelMetrics(true, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer func() {
+		SetOTelMetrics(false, nil)
+		otel.SetMeterProvider(prevProvider)
+	}()
+
+	r := NewRouter()
+	r.HandleFunc("/x", func(ctx context.Context) error { return nil })
+	srv := httptest.NewServer(NewServer().Handler(r).server.Handler)
+	defer srv.Close()
+	assert.NoError(NewClient().Root(srv.URL).Get(context.Background(), "/x", nil))
+
+	spanIDs := map[string]string{}
+	for _, span := range exporter.GetSpans() {
+		spanIDs[span.SpanKind.String()] = span.SpanContext.SpanID().String()
+	}
+	assert.Contains(spanIDs, "server")
+	assert.Contains(spanIDs, "client")
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &rm))
+	for name, kind := range map[string]string{"http.server.request.duration": "server", "http.client.request.duration": "client"} {
+		duration := findMetric(rm, name)
+		if assert.NotNil(duration, name) {
+			dps := duration.Data.(metricdata.Histogram[float64]).DataPoints
+			if assert.Len(dps, 1) && assert.Len(dps[0].Exemplars, 1, name) {
+				assert.Equal(spanIDs[kind], hex.EncodeToString(dps[0].Exemplars[0].SpanID), name)
+			}
+		}
+	}
+}
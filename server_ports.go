@@ -0,0 +1,33 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net/http"
+)
+
+// Port adds a plain HTTP listener on a separate address, e.g. ":9090", serving the handler, typically another Router.
+// E.g. the public API on the main address, internal callbacks on another port.
+// The handler is logged, traced and measured as the main one, monitors set before are applied, too.
+// It is started by ListenAndServe, and is stopped when the server stops, on Close, Shutdown or graceful shutdown, after the main listener drained.
+// It is not restarted by Restart. Timeouts and connection limits of the server are applied.
+//
+//	restful.NewServer().Addr(":8080").Handler(api).Port(":9090", callbacks).Admin("127.0.0.1:9100", admin.NewServeMux(api)).Graceful(0).ListenAndServe()
+func (s *Server) Port(addr string, handler http.Handler) *Server {
+	if handler == nil {
+		handler = http.NotFoundHandler()
+	}
+	s.ports = append(s.ports, newHTTPServer(addr, s.wrapHandler(handler)))
+	return s
+}
+
+// configurePorts applies the timeouts and connection limits of the server to the additional port servers.
+func (s *Server) configurePorts() {
+	for _, server := range s.ports {
+		server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout = s.server.ReadHeaderTimeout, s.server.ReadTimeout, s.server.WriteTimeout
+		server.IdleTimeout, server.MaxHeaderBytes = s.server.IdleTimeout, s.server.MaxHeaderBytes
+		server.ConnContext = s.connContext()
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPort(t *testing.T) {
+	assert := assert.New(t)
+	api := NewRouter()
+	api.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("api")) })
+	callbacks := NewRouter()
+	callbacks.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("callback")) })
+	server := NewServer().Addr("127.0.0.1:18455").Handler(api).Port("127.0.0.1:18456", callbacks).WriteTimeout(time.Minute).MaxRequestsPerConn(1)
+	go server.ListenAndServe()
+
+	get := func(url string) (int, string) {
+		for range 100 {
+			if resp, err := http.Get(url); err == nil {
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				return resp.StatusCode, string(body)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return 0, ""
+	}
+	status, body := get("http://127.0.0.1:18455/api")
+	assert.Equal(http.StatusOK, status)
+	assert.Equal("api", body)
+	status, body = get("http://127.0.0.1:18456/callback")
+	assert.Equal(http.StatusOK, status)
+	assert.Equal("callback", body)
+	status, _ = get("http://127.0.0.1:18456/api") // Distinct routes.
+	assert.Equal(http.StatusNotFound, status)
+	assert.Equal(time.Minute, server.ports[0].WriteTimeout)
+	assert.NotNil(server.ports[0].ConnContext)
+
+	assert.NoError(server.Shutdown(context.Background()))
+	_, err := http.Get("http://127.0.0.1:18456/callback")
+	assert.Error(err)
+}
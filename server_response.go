@@ -9,8 +9,8 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/nokia/restful/logging"
 	"github.com/nokia/restful/messagepack"
-	log "github.com/sirupsen/logrus"
 )
 
 func getJSONBody(data any, sanitizeJSON bool) ([]byte, error) {
@@ -53,6 +53,9 @@ func sendResponse(w http.ResponseWriter, r *http.Request, data any, sanitizeJSON
 		w.WriteHeader(okStatus)
 		return nil
 	}
+	if blob, ok := asBlob(data); ok {
+		return blob.send(w, r)
+	}
 
 	useMsgPack := false
 	writeHeaders := w.Header()
@@ -96,6 +99,7 @@ func getOkStatus(w http.ResponseWriter, r *http.Request, data any) int {
 
 // SendResp sends an HTTP response with data.
 // On no error 200/201/204 sent according to the request.
+// Blob or io.Reader data, e.g. *os.File, is sent as is, supporting Range requests if seekable, see Blob.
 // On error send response depending on whether the error is created by NewError and the client supports RFC 7807.
 // Caller may set additional headers like `w.Header().Set("Location", "https://me")` before calling this function.
 func SendResp(w http.ResponseWriter, r *http.Request, err error, data any) error {
@@ -104,7 +108,8 @@ func SendResp(w http.ResponseWriter, r *http.Request, err error, data any) error
 	}
 
 	if errStr := err.Error(); errStr != "" { // In some cases status like 404 does not indicate error, just a plain result. E.g. on a distributed cache query.
-		log.Error(errStr)
+		logging.Errorf(r.Context(), "%s", errStr)
+		recordSpanError(r, err, GetErrStatusCode(err))
 	}
 
 	body, _ := getJSONBody(data, LambdaSanitizeJSON)
@@ -5,7 +5,9 @@
 package restful
 
 import (
+	"context"
 	"errors"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
@@ -93,3 +95,48 @@ func TestRestart(t *testing.T) {
 func TestHTTPServerBadAddrNilHandler(t *testing.T) {
 	assert.Error(t, ListenAndServe(":-1", nil))
 }
+
+func TestServerAdmin(t *testing.T) {
+	assert := assert.New(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	adminAddr := l.Addr().String()
+	l.Close()
+
+	admin := http.NewServeMux()
+	admin.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {})
+	s := NewServer().Addr("127.0.0.1:0").Handler(NewRouter()).Admin(adminAddr, admin)
+	done := make(chan error)
+	go func() { done <- s.ListenAndServe() }()
+
+	assert.Eventually(func() bool {
+		resp, err := http.Get("http://" + adminAddr + "/admin")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(s.Shutdown(context.Background()))
+	assert.ErrorIs(<-done, http.ErrServerClosed)
+	_, err = http.Get("http://" + adminAddr + "/admin")
+	assert.Error(err)
+}
+
+func TestRouterRoutes(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func() {}).Methods(http.MethodGet).Name("user")
+	r.PathPrefix("/static/").Handler(http.NotFoundHandler())
+	sub := r.PathPrefix("/v2").Subrouter()
+	sub.HandleFunc("/items", func() {})
+
+	routes := r.Routes()
+	if assert.Len(routes, 4) {
+		assert.Equal(RouteInfo{Name: "user", Path: "/users/{id}", Methods: []string{http.MethodGet}, Handler: true}, routes[0])
+		assert.Equal(RouteInfo{Path: "/static/", Prefix: true, Handler: true}, routes[1])
+		assert.Equal(RouteInfo{Path: "/v2", Prefix: true}, routes[2])
+		assert.Equal(RouteInfo{Path: "/v2/items", Handler: true}, routes[3])
+	}
+}
@@ -5,21 +5,66 @@
 package restful
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
 )
 
+// TLSReloadInterval is the min time between checking the cert files for changes, if TLSReload is set.
+var TLSReloadInterval = 10 * time.Second
+
+// haveTLSConfig returns the TLS config of the server, creating one if not having yet.
+func (s *Server) haveTLSConfig() *tls.Config {
+	if s.server.TLSConfig == nil {
+		s.server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return s.server.TLSConfig
+}
+
 // TLSClientCert adds client certs to server, enabling mutual TLS (mTLS).
 // If path is a directory then scans for files recursively. If path is not set then defaults to /etc.
 // If loadSystemCerts is true, clients with CA from system CA pool are accepted, too.
 // As the role of mTLS is to authorize certain clients to connect, enable system CAs only if those are reasonable for auth.
 // File names should match *.crt or *.pem.
 func (s *Server) TLSClientCert(path string, loadSystemCerts bool) *Server {
-	if s.server.TLSConfig == nil {
-		s.server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	config := s.haveTLSConfig()
+	config.ClientCAs = NewCertPool(path, loadSystemCerts)
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	s.clientCAs = path
+	s.clientCAsSystem = loadSystemCerts
+	return s
+}
+
+// TLSVerifyPeer adds a hook verifying the peer certificates, called after the normal verification, e.g. checking revocation.
+// Hooks are called in the order added. Error rejects the handshake.
+// The identity of the client verified is available for handlers, see PeerIdentityFromContext.
+//
+//	revocation := restful.NewRevocationChecker().CRL("/etc/crl").OCSP()
+//	server.TLSClientCert("/etc/clientcas", false).TLSVerifyPeer(revocation.VerifyPeerCertificate)
+func (s *Server) TLSVerifyPeer(verify func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error) *Server {
+	config := s.haveTLSConfig()
+	prev := config.VerifyPeerCertificate
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if prev != nil {
+			if err := prev(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+		return verify(rawCerts, verifiedChains)
 	}
-	s.server.TLSConfig.ClientCAs = NewCertPool(path, loadSystemCerts)
-	s.server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	return s
 }
 
@@ -30,6 +75,305 @@ func (s *Server) TLSServerCert(certFile, keyFile string) *Server {
 	return s
 }
 
+// TLSReload makes the server reload its cert and key, and the client CAs of TLSClientCert, when the files change on disk.
+// E.g. when cert-manager rotates the certs, so that no restart is needed.
+// Files are checked at TLS handshakes, at most once per TLSReloadInterval.
+// If the new files cannot be loaded, e.g. the key does not match the cert yet, then the former ones are used until the next check.
+//
+//	restful.NewServer().Addr(":8443").Handler(router).TLSServerCert("/tls/tls.crt", "/tls/tls.key").TLSReload().ListenAndServe()
+func (s *Server) TLSReload() *Server {
+	s.tlsReload = true
+	return s
+}
+
+// certReloader serves the current cert and client CA pool, reloading them if the files changed.
+type certReloader struct {
+	certFile        string
+	keyFile         string
+	clientCAs       string
+	clientCAsSystem bool
+	watch           bool // Reload if files changed.
+
+	cert      atomic.Pointer[tls.Certificate]
+	pool      atomic.Pointer[x509.CertPool]
+	nextCheck atomic.Int64 // Unix nano.
+
+	mutex       sync.Mutex
+	certModTime time.Time
+	caModTime   time.Time
+}
+
+func newCertReloader(certFile, keyFile, clientCAs string, clientCAsSystem, watch bool) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile, clientCAs: clientCAs, clientCAsSystem: clientCAsSystem, watch: watch}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	c.nextCheck.Store(time.Now().Add(TLSReloadInterval).UnixNano())
+	return c, nil
+}
+
+// latestModTime returns the latest modification time of the files given, walking directories.
+func latestModTime(paths ...string) (latest time.Time) {
+	for _, path := range paths {
+		_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+			if err == nil {
+				if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+					latest = info.ModTime()
+				}
+			}
+			return nil
+		})
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) { // Symlinks, as of K8s secret volumes.
+			latest = info.ModTime()
+		}
+	}
+	return
+}
+
+// reload loads the files changed since the last successful load.
+func (c *certReloader) reload() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if modTime := latestModTime(c.certFile, c.keyFile); c.certFile != "" && (c.cert.Load() == nil || !modTime.Equal(c.certModTime)) {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			return err
+		}
+		c.cert.Store(&cert)
+		c.certModTime = modTime
+		logging.Infof(context.Background(), "Loaded server cert '%s'", c.certFile)
+	}
+	if c.clientCAs != "" {
+		if modTime := latestModTime(c.clientCAs); !modTime.Equal(c.caModTime) {
+			c.pool.Store(NewCertPool(c.clientCAs, c.clientCAsSystem))
+			c.caModTime = modTime
+		}
+	}
+	return nil
+}
+
+func (c *certReloader) check() {
+	now := time.Now()
+	next := c.nextCheck.Load()
+	if !c.watch || now.UnixNano() < next || !c.nextCheck.CompareAndSwap(next, now.Add(TLSReloadInterval).UnixNano()) {
+		return
+	}
+	if err := c.reload(); err != nil {
+		logging.Errorf(context.Background(), "Failed to reload server cert '%s': %v", c.certFile, err)
+	}
+}
+
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.check()
+	if cert := c.cert.Load(); cert != nil {
+		return cert, nil
+	}
+	return nil, errors.New("no server cert")
+}
+
+// names returns the DNS names of the cert, or its CN if it has no DNS names.
+func (c *certReloader) names() []string {
+	cert := c.cert.Load()
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	if len(leaf.DNSNames) > 0 {
+		return leaf.DNSNames
+	}
+	return []string{leaf.Subject.CommonName}
+}
+
+// install makes the TLS config use the current cert, unless selected otherwise, and client CAs.
+func (c *certReloader) install(config *tls.Config) {
+	config.Certificates = nil
+	if config.GetCertificate == nil {
+		config.GetCertificate = c.getCertificate
+	}
+	if c.clientCAs == "" {
+		return
+	}
+	base := config.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c.check()
+		cfg := base.Clone()
+		cfg.ClientCAs = c.pool.Load()
+		return cfg, nil
+	}
+}
+
+// TLSServerCertSNI adds a cert + key served to clients asking for the names given by SNI, e.g. "api.example.com" or "*.example.com".
+// If no names are given, then those of the cert are used. A wildcard name matches a single label.
+// Clients asking for other names, or not using SNI, get the cert of TLSServerCert, or the first one added here if that is not set.
+// Each cert is reloaded on its own if TLSReload is set.
+//
+//	server.TLSServerCertSNI("/tls/a/tls.crt", "/tls/a/tls.key").TLSServerCertSNI("/tls/b/tls.crt", "/tls/b/tls.key", "*.b.example.com")
+func (s *Server) TLSServerCertSNI(certFile, keyFile string, names ...string) *Server {
+	s.sniCerts = append(s.sniCerts, sniCert{certFile: certFile, keyFile: keyFile, names: names})
+	return s
+}
+
+type sniCert struct {
+	certFile string
+	keyFile  string
+	names    []string
+}
+
+// certSelector selects the cert by SNI.
+type certSelector struct {
+	byName   map[string]*certReloader // Lowercase names, e.g. "*.example.com".
+	fallback *certReloader
+}
+
+func newCertSelector(certs []sniCert, fallback *certReloader, watch bool) (*certSelector, error) {
+	selector := &certSelector{byName: map[string]*certReloader{}, fallback: fallback}
+	for _, c := range certs {
+		reloader, err := newCertReloader(c.certFile, c.keyFile, "", false, watch)
+		if err != nil {
+			return nil, err
+		}
+		names := c.names
+		if len(names) == 0 {
+			names = reloader.names()
+		}
+		for _, name := range names {
+			selector.byName[strings.ToLower(name)] = reloader
+		}
+		if selector.fallback == nil {
+			selector.fallback = reloader
+		}
+	}
+	return selector, nil
+}
+
+func (c *certSelector) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if reloader, ok := c.byName[name]; ok {
+		return reloader.getCertificate(hello)
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if reloader, ok := c.byName["*"+name[i:]]; ok {
+			return reloader.getCertificate(hello)
+		}
+	}
+	return c.fallback.getCertificate(hello)
+}
+
+// tlsEnabled tells if the server serves HTTPS.
+func (s *Server) tlsEnabled() bool {
+	return (s.keyFile != "" && s.certFile != "") || len(s.sniCerts) > 0 || s.acme != nil
+}
+
+// tlsFiles returns the cert and key files to be passed to ServeTLS. Empty if TLS config has them, as of TLSReload or ACME.
+func (s *Server) tlsFiles() (certFile, keyFile string, err error) {
+	if s.acme != nil {
+		s.installACME()
+		return "", "", nil
+	}
+	if !s.tlsReload && len(s.sniCerts) == 0 {
+		return s.certFile, s.keyFile, nil
+	}
+	if s.reloader == nil {
+		if s.reloader, err = newCertReloader(s.certFile, s.keyFile, s.clientCAs, s.clientCAsSystem, s.tlsReload); err != nil {
+			return "", "", err
+		}
+		if len(s.sniCerts) > 0 {
+			var fallback *certReloader
+			if s.certFile != "" {
+				fallback = s.reloader
+			}
+			selector, err := newCertSelector(s.sniCerts, fallback, s.tlsReload)
+			if err != nil {
+				s.reloader = nil
+				return "", "", err
+			}
+			s.haveTLSConfig().GetCertificate = selector.getCertificate
+		}
+	}
+	s.reloader.install(s.haveTLSConfig())
+	return "", "", nil
+}
+
+// TLSVersions sets the min and max TLS versions accepted, e.g. tls.VersionTLS13. Zero max means the latest supported.
+// Min is TLS 1.2 by default.
+func (s *Server) TLSVersions(minVersion, maxVersion uint16) *Server {
+	config := s.haveTLSConfig()
+	config.MinVersion = minVersion
+	config.MaxVersion = maxVersion
+	return s
+}
+
+// TLSCipherSuites sets the cipher suites of TLS 1.2 accepted, e.g. tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384. TLS 1.3 suites are not configurable.
+// See ParseCipherSuites for configuring by names.
+func (s *Server) TLSCipherSuites(suites ...uint16) *Server {
+	s.haveTLSConfig().CipherSuites = suites
+	return s
+}
+
+// TLSCurves sets the key exchange mechanisms, in preference order, e.g. tls.X25519.
+func (s *Server) TLSCurves(curves ...tls.CurveID) *Server {
+	s.haveTLSConfig().CurvePreferences = curves
+	return s
+}
+
+// TLSNextProtos sets the application protocols offered by ALPN, in preference order, e.g. "h2" and "http/1.1".
+// HTTP/2 is disabled if "h2" is not listed.
+func (s *Server) TLSNextProtos(protos ...string) *Server {
+	s.haveTLSConfig().NextProtos = protos
+	if !slices.Contains(protos, "h2") {
+		s.server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return s
+}
+
+// TLSSessionTickets enables or disables session resumption by session tickets. Enabled by default.
+// Disabling that makes each connection do a full handshake, but keys of tickets cannot compromise forward secrecy.
+func (s *Server) TLSSessionTickets(enabled bool) *Server {
+	s.haveTLSConfig().SessionTicketsDisabled = !enabled
+	return s
+}
+
+// TLSClientAuth sets the policy of client authentication, e.g. tls.VerifyClientCertIfGiven accepting clients without cert, too.
+// Call it after TLSClientCert, which sets tls.RequireAndVerifyClientCert.
+func (s *Server) TLSClientAuth(clientAuth tls.ClientAuthType) *Server {
+	s.haveTLSConfig().ClientAuth = clientAuth
+	return s
+}
+
+// ParseTLSVersion parses a TLS version name, e.g. "1.2", "TLS1.3" or "TLS 1.3", as in configuration files.
+func ParseTLSVersion(name string) (uint16, error) {
+	version := strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "TLS"))
+	switch strings.TrimPrefix(version, "V") {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version: %q", name)
+}
+
+// ParseCipherSuites parses cipher suite names, e.g. "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", as in configuration files.
+// Insecure suites are accepted, too, so that legacy peers can be served if needed.
+func ParseCipherSuites(names ...string) ([]uint16, error) {
+	suites := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		idx := slices.IndexFunc(suites, func(suite *tls.CipherSuite) bool { return suite.Name == strings.TrimSpace(name) })
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown cipher suite: %q", name)
+		}
+		ids = append(ids, suites[idx].ID)
+	}
+	return ids, nil
+}
+
 // ListenAndServeTLS acts like standard http.ListenAndServeTLS().
 // Logs, except for automatically served LivenessProbePath and HealthCheckPath.
 func ListenAndServeTLS(addr, certFile, keyFile string, handler http.Handler) error {
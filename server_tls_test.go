@@ -6,8 +6,20 @@ package restful
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -36,3 +48,218 @@ func TestHTTPSServerNoOOP(t *testing.T) {
 	ListenAndServeTLS(":-1", "test_certs/tls.crt", "test_certs/tls.key", nil)
 	ListenAndServeMTLS(":-1", "test_certs/tls.crt", "test_certs/tls.key", "test_certs", false, nil)
 }
+
+func writeTestCert(t *testing.T, dir, cn string, dnsNames ...string) {
+	if len(dnsNames) == 0 {
+		dnsNames = []string{"localhost"}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func TestTLSReload(t *testing.T) {
+	assert := assert.New(t)
+	prevInterval := TLSReloadInterval
+	TLSReloadInterval = 10 * time.Millisecond
+	defer func() { TLSReloadInterval = prevInterval }()
+
+	dir := t.TempDir()
+	writeTestCert(t, dir, "first")
+	addr := "127.0.0.1:18444"
+	server := NewServer().Addr(addr).Handler(http.NotFoundHandler()).TLSServerCert(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")).TLSReload()
+	go server.ListenAndServe()
+	defer server.Close()
+
+	peerCN := func() string {
+		for range 100 {
+			conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true}) // #nosec test
+			if err == nil {
+				defer conn.Close()
+				return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return ""
+	}
+	assert.Equal("first", peerCN())
+
+	writeTestCert(t, dir, "second")
+	future := time.Now().Add(time.Second) // Make sure modification time differs.
+	assert.NoError(os.Chtimes(filepath.Join(dir, "tls.crt"), future, future))
+	time.Sleep(20 * time.Millisecond)
+	peerCN() // Triggers the check.
+	assert.Equal("second", peerCN())
+
+	// Broken key keeps the former cert.
+	assert.NoError(os.WriteFile(filepath.Join(dir, "tls.key"), []byte("broken"), 0o600))
+	future = future.Add(time.Second)
+	assert.NoError(os.Chtimes(filepath.Join(dir, "tls.key"), future, future))
+	time.Sleep(20 * time.Millisecond)
+	peerCN()
+	assert.Equal("second", peerCN())
+}
+
+func TestTLSReloadMissingFiles(t *testing.T) {
+	err := NewServer().Addr("127.0.0.1:18445").TLSServerCert("nope.crt", "nope.key").TLSReload().ListenAndServe()
+	assert.Error(t, err)
+}
+
+func TestTLSReloadClientCAs(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	writeTestCert(t, dir, "ca")
+	reloader, err := newCertReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), dir, false, true)
+	assert.NoError(err)
+	config := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12}
+	reloader.install(config)
+	cfg, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(err)
+	assert.Equal(tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	assert.Same(reloader.pool.Load(), cfg.ClientCAs)
+	assert.NotNil(cfg.GetCertificate)
+}
+
+func TestTLSServerCertSNI(t *testing.T) {
+	assert := assert.New(t)
+	prevInterval := TLSReloadInterval
+	TLSReloadInterval = 10 * time.Millisecond
+	defer func() { TLSReloadInterval = prevInterval }()
+
+	dirDefault, dirA, dirB := t.TempDir(), t.TempDir(), t.TempDir()
+	writeTestCert(t, dirDefault, "default")
+	writeTestCert(t, dirA, "a", "a.example.com")
+	writeTestCert(t, dirB, "b", "b.example.com")
+	addr := "127.0.0.1:18449"
+	server := NewServer().Addr(addr).Handler(http.NotFoundHandler()).TLSReload().
+		TLSServerCert(filepath.Join(dirDefault, "tls.crt"), filepath.Join(dirDefault, "tls.key")).
+		TLSServerCertSNI(filepath.Join(dirA, "tls.crt"), filepath.Join(dirA, "tls.key")).
+		TLSServerCertSNI(filepath.Join(dirB, "tls.crt"), filepath.Join(dirB, "tls.key"), "*.b.example.com", "B.Example.Com")
+	go server.ListenAndServe()
+	defer server.Close()
+
+	peerCN := func(serverName string) string {
+		for range 100 {
+			conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: serverName, InsecureSkipVerify: true}) // #nosec test
+			if err == nil {
+				defer conn.Close()
+				return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return ""
+	}
+	assert.Equal("a", peerCN("a.example.com"))
+	assert.Equal("b", peerCN("b.example.com"))
+	assert.Equal("b", peerCN("api.b.example.com"))
+	assert.Equal("default", peerCN("x.y.b.example.com")) // Wildcard matches a single label.
+	assert.Equal("default", peerCN("c.example.com"))
+	assert.Equal("default", peerCN(""))
+
+	writeTestCert(t, dirA, "a2", "a.example.com")
+	future := time.Now().Add(time.Second)
+	assert.NoError(os.Chtimes(filepath.Join(dirA, "tls.crt"), future, future))
+	time.Sleep(20 * time.Millisecond)
+	peerCN("a.example.com") // Triggers the check.
+	assert.Equal("a2", peerCN("a.example.com"))
+	assert.Equal("b", peerCN("b.example.com"))
+}
+
+func TestTLSServerCertSNIFallback(t *testing.T) {
+	assert := assert.New(t)
+	dirA, dirB := t.TempDir(), t.TempDir()
+	writeTestCert(t, dirA, "a", "a.example.com")
+	writeTestCert(t, dirB, "b", "b.example.com")
+	selector, err := newCertSelector([]sniCert{
+		{certFile: filepath.Join(dirA, "tls.crt"), keyFile: filepath.Join(dirA, "tls.key")},
+		{certFile: filepath.Join(dirB, "tls.crt"), keyFile: filepath.Join(dirB, "tls.key")},
+	}, nil, false)
+	assert.NoError(err)
+	cert, err := selector.getCertificate(&tls.ClientHelloInfo{ServerName: "b.example.com."})
+	assert.NoError(err)
+	assert.Equal("b", cert.Leaf.Subject.CommonName)
+	cert, err = selector.getCertificate(&tls.ClientHelloInfo{})
+	assert.NoError(err)
+	assert.Equal("a", cert.Leaf.Subject.CommonName) // First one.
+
+	_, err = newCertSelector([]sniCert{{certFile: "nope.crt", keyFile: "nope.key"}}, nil, false)
+	assert.Error(err)
+}
+
+func TestTLSVerifyPeer(t *testing.T) {
+	assert := assert.New(t)
+	var calls []string
+	s := NewServer().
+		TLSVerifyPeer(func([][]byte, [][]*x509.Certificate) error { calls = append(calls, "first"); return nil }).
+		TLSVerifyPeer(func([][]byte, [][]*x509.Certificate) error { calls = append(calls, "second"); return ErrCertRevoked })
+	assert.ErrorIs(s.server.TLSConfig.VerifyPeerCertificate(nil, nil), ErrCertRevoked)
+	assert.Equal([]string{"first", "second"}, calls)
+}
+
+func TestTLSSettings(t *testing.T) {
+	assert := assert.New(t)
+	addr := "127.0.0.1:18448"
+	server := NewServer().Addr(addr).Handler(http.NotFoundHandler()).TLSServerCert("test_certs/tls.crt", "test_certs/tls.key").
+		TLSVersions(tls.VersionTLS13, 0).TLSCurves(tls.X25519).TLSNextProtos("http/1.1").TLSSessionTickets(false)
+	go server.ListenAndServe()
+	defer server.Close()
+
+	dial := func(config *tls.Config) (state tls.ConnectionState, err error) {
+		config.InsecureSkipVerify = true // #nosec test
+		for range 100 {
+			var conn *tls.Conn
+			if conn, err = tls.Dial("tcp", addr, config); err == nil {
+				defer conn.Close()
+				return conn.ConnectionState(), nil
+			}
+			if !strings.Contains(err.Error(), "connection refused") {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return
+	}
+
+	state, err := dial(&tls.Config{NextProtos: []string{"h2", "http/1.1"}})
+	if assert.NoError(err) {
+		assert.Equal(uint16(tls.VersionTLS13), state.Version)
+		assert.Equal("http/1.1", state.NegotiatedProtocol)
+	}
+	_, err = dial(&tls.Config{MaxVersion: tls.VersionTLS12})
+	assert.Error(err)
+	_, err = dial(&tls.Config{CurvePreferences: []tls.CurveID{tls.CurveP256}})
+	assert.Error(err)
+}
+
+func TestParseTLS(t *testing.T) {
+	assert := assert.New(t)
+	for _, name := range []string{"1.3", "TLS1.3", "tls 1.3", "TLSv1.3"} {
+		version, err := ParseTLSVersion(name)
+		assert.NoError(err)
+		assert.Equal(uint16(tls.VersionTLS13), version)
+	}
+	_, err := ParseTLSVersion("1.4")
+	assert.Error(err)
+
+	suites, err := ParseCipherSuites("TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", " TLS_RSA_WITH_AES_128_CBC_SHA")
+	assert.NoError(err)
+	assert.Equal([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_RSA_WITH_AES_128_CBC_SHA}, suites)
+	_, err = ParseCipherSuites("nope")
+	assert.Error(err)
+
+	s := NewServer().TLSCipherSuites(suites...).TLSClientCert("test_certs", false).TLSClientAuth(tls.VerifyClientCertIfGiven)
+	assert.Equal(suites, s.server.TLSConfig.CipherSuites)
+	assert.Equal(tls.VerifyClientCertIfGiven, s.server.TLSConfig.ClientAuth)
+}
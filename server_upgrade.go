@@ -0,0 +1,152 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/nokia/restful/logging"
+)
+
+// upgradeListenersEnv lists the addresses of the listeners passed to the upgraded process, as file descriptors from 3 on.
+const upgradeListenersEnv = "RESTFUL_UPGRADE_LISTENERS"
+
+// UpgradeOnSignal makes the server replaced by a new process of its binary on receiving a signal, e.g. syscall.SIGUSR2, without downtime.
+// The listening sockets, including admin and plain HTTP ones, are passed to the new process, which serves them the same way.
+// The old process then shuts down gracefully, completing the requests in progress. Enables Graceful, if not set.
+// For deployments without a load balancer, where a new binary is to take over. Needs Unix, as file descriptors are inherited.
+// If the new process cannot be started, the old one keeps serving.
+//
+//	restful.NewServer().Addr(":8080").Handler(router).UpgradeOnSignal(syscall.SIGUSR2).ListenAndServe()
+//	// Then: cp new-binary /usr/bin/app && kill -USR2 <pid>
+func (s *Server) UpgradeOnSignal(sig os.Signal) *Server {
+	s.upgradeSignal = sig
+	s.graceful = true
+	return s
+}
+
+// inheritedListeners are the listener files passed by the process upgraded, by address. Guarded by inheritedMutex.
+var inheritedListeners = sync.OnceValue(func() map[string]*os.File {
+	addrs := os.Getenv(upgradeListenersEnv)
+	_ = os.Unsetenv(upgradeListenersEnv)
+	files := map[string]*os.File{}
+	if addrs == "" {
+		return files
+	}
+	const firstFD = 3
+	for i, addr := range strings.Split(addrs, ",") {
+		files[addr] = os.NewFile(uintptr(firstFD+i), addr)
+	}
+	return files
+})
+
+var inheritedMutex sync.Mutex
+
+// takeInheritedListener returns the listener file inherited for the address, if any, removing it from the inherited ones.
+func takeInheritedListener(addr string) *os.File {
+	files := inheritedListeners()
+	inheritedMutex.Lock()
+	defer inheritedMutex.Unlock()
+	f := files[addr]
+	delete(files, addr)
+	return f
+}
+
+// listenAddr listens on the address, or takes over the listener of the process upgraded, see UpgradeOnSignal.
+// The listener is kept track of, so that it can be passed on at the next upgrade.
+func (s *Server) listenAddr(addr string, f *os.File) (ln net.Listener, err error) {
+	inherited := f == nil
+	if inherited {
+		f = takeInheritedListener(addr)
+	}
+	if f != nil {
+		ln, err = net.FileListener(f) // Dups the file descriptor.
+		if inherited {
+			_ = f.Close()
+		}
+	} else {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err == nil {
+		s.listeners.Store(addr, ln)
+	}
+	return
+}
+
+// listenerFiles returns the addresses and file descriptor duplicates of the listeners.
+func (s *Server) listenerFiles() (addrs []string, files []*os.File, err error) {
+	s.listeners.Range(func(key, value any) bool {
+		filer, ok := value.(interface{ File() (*os.File, error) })
+		if !ok {
+			return true
+		}
+		var f *os.File
+		if f, err = filer.File(); err != nil {
+			return false
+		}
+		addrs = append(addrs, key.(string))
+		files = append(files, f)
+		return true
+	})
+	if err != nil {
+		for _, f := range files {
+			_ = f.Close()
+		}
+		return nil, nil, err
+	}
+	return addrs, files, nil
+}
+
+// upgrade starts a new process of the binary, passing the listeners.
+func (s *Server) upgrade() error {
+	addrs, files, err := s.listenerFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	if len(files) == 0 {
+		return errors.New("no listeners")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...) // #nosec G204 own binary
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), upgradeListenersEnv+"="+strings.Join(addrs, ","))
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	logging.Infof(context.Background(), "Started upgraded process %d, passing listeners %v", cmd.Process.Pid, addrs)
+	return cmd.Process.Release()
+}
+
+// waitForUpgrade upgrades on receiving the signal, then makes the server shut down gracefully.
+func (s *Server) waitForUpgrade(c chan error) {
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, s.upgradeSignal)
+	for sig := range signalChannel {
+		logging.Infof(context.Background(), "Signal received: %v", sig)
+		if err := s.upgrade(); err != nil {
+			logging.Errorf(context.Background(), "upgrade failed, keep serving: %v", err)
+			continue
+		}
+		signal.Stop(signalChannel)
+		c <- nil
+		return
+	}
+}
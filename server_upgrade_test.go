@@ -0,0 +1,89 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUpgradeProcess is the upgraded process, started by TestUpgradeTakeOver.
+func TestUpgradeProcess(t *testing.T) {
+	addr := os.Getenv("RESTFUL_TEST_UPGRADE_ADDR")
+	if addr == "" {
+		t.Skip("not an upgraded process")
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) })
+	_ = NewServer().Addr(addr).Handler(handler).ListenAndServe()
+}
+
+func TestUpgradeTakeOver(t *testing.T) {
+	assert := assert.New(t)
+	server := NewServer().UpgradeOnSignal(syscall.SIGUSR2)
+	assert.True(server.graceful)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	addr := ln.Addr().String()
+	server.listeners.Store(addr, ln)
+	addrs, files, err := server.listenerFiles()
+	assert.NoError(err)
+	assert.Equal([]string{addr}, addrs)
+	assert.NoError(ln.Close()) // The file holds the socket.
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestUpgradeProcess$") // #nosec test
+	cmd.Env = append(os.Environ(), "RESTFUL_TEST_UPGRADE_ADDR="+addr, upgradeListenersEnv+"="+addr)
+	cmd.ExtraFiles = files
+	assert.NoError(cmd.Start())
+	defer func() {
+		_ = files[0].Close() // Kept open, so that the port cannot be listened on again.
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	var resp *http.Response
+	for range 500 {
+		if resp, err = http.Get("http://" + addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusAccepted, resp.StatusCode)
+	}
+}
+
+func TestUpgradeInheritedListenerClosed(t *testing.T) {
+	assert := assert.New(t)
+	orig, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	addr := orig.Addr().String()
+	f, err := orig.(*net.TCPListener).File()
+	assert.NoError(err)
+	assert.NoError(orig.Close())
+	inheritedMutex.Lock()
+	inheritedListeners()[addr] = f
+	inheritedMutex.Unlock()
+
+	server := NewServer()
+	ln, err := server.listenAddr(addr, nil)
+	if assert.NoError(err) {
+		assert.Equal(addr, ln.Addr().String())
+		assert.NoError(ln.Close())
+	}
+	assert.ErrorIs(f.Close(), os.ErrClosed) // Closed by listenAddr.
+	assert.Nil(takeInheritedListener(addr))
+}
+
+func TestUpgradeNoListeners(t *testing.T) {
+	assert.Error(t, NewServer().upgrade())
+}
@@ -0,0 +1,357 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// SOAP content types and envelope namespaces.
+const (
+	ContentTypeTextXML = "text/xml"             // SOAP 1.1
+	ContentTypeSOAP    = "application/soap+xml" // SOAP 1.2
+	SOAP11Namespace    = "http://schemas.xmlsoap.org/soap/envelope/"
+	SOAP12Namespace    = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAP fault codes. Client and Server are sent as Sender and Receiver in SOAP 1.2.
+const (
+	SOAPFaultVersionMismatch = "VersionMismatch"
+	SOAPFaultMustUnderstand  = "MustUnderstand"
+	SOAPFaultClient          = "Client"
+	SOAPFaultServer          = "Server"
+)
+
+// SOAPFault is a fault of a SOAP response.
+// Operations may return it to control the fault code and detail sent.
+// Detail, if any, is marshaled as the XML entry of the detail element.
+type SOAPFault struct {
+	Code   string
+	String string
+	Detail any
+}
+
+// Error returns the fault string.
+func (f *SOAPFault) Error() string {
+	return f.String
+}
+
+// NewSOAPFault converts an error returned by an operation to a SOAP fault.
+// Restful errors of 4xx status codes are client faults, others are server faults.
+func NewSOAPFault(err error) *SOAPFault {
+	var f *SOAPFault
+	if errors.As(err, &f) {
+		return f
+	}
+
+	status := GetErrStatusCode(err)
+	f = &SOAPFault{Code: SOAPFaultServer, String: err.Error()}
+	if status >= 400 && status < 500 {
+		f.Code = SOAPFaultClient
+	}
+	if f.String == "" {
+		f.String = http.StatusText(status)
+	}
+	return f
+}
+
+type soap11Fault struct {
+	XMLName xml.Name    `xml:"soap:Fault"`
+	Code    string      `xml:"faultcode"`
+	String  string      `xml:"faultstring"`
+	Detail  *soapDetail `xml:"detail"`
+}
+
+type soap12Fault struct {
+	XMLName xml.Name    `xml:"soap:Fault"`
+	Code    string      `xml:"soap:Code>soap:Value"`
+	Reason  soap12Text  `xml:"soap:Reason>soap:Text"`
+	Detail  *soapDetail `xml:"soap:Detail"`
+}
+
+type soap12Text struct {
+	Lang string `xml:"xml:lang,attr"`
+	Text string `xml:",chardata"`
+}
+
+type soapDetail struct {
+	Entry []byte `xml:",innerxml"`
+}
+
+// envelopeFault returns the fault element of the SOAP version, and the HTTP status code to send it with.
+func (f *SOAPFault) envelopeFault(namespace string) (any, int) {
+	var detail *soapDetail
+	if f.Detail != nil {
+		if entry, err := xml.Marshal(f.Detail); err == nil {
+			detail = &soapDetail{Entry: entry}
+		}
+	}
+	if namespace == SOAP11Namespace {
+		return soap11Fault{Code: "soap:" + f.Code, String: f.String, Detail: detail}, http.StatusInternalServerError
+	}
+
+	code, _, _ := strings.Cut(f.Code, ".") // SOAP 1.2 has subcodes instead.
+	status := http.StatusInternalServerError
+	switch code {
+	case SOAPFaultClient:
+		code, status = "Sender", http.StatusBadRequest
+	case SOAPFaultServer:
+		code = "Receiver"
+	}
+	return soap12Fault{Code: "soap:" + code, Reason: soap12Text{Lang: "en", Text: f.String}, Detail: detail}, status
+}
+
+// SOAP is an http.Handler of a SOAP 1.1 and 1.2 endpoint, e.g. for keeping legacy integrations.
+// The version is told by the envelope namespace, and faults are sent in the envelope of the same version.
+// Operations are told by the element in the SOAP body, as of document/literal wrapped style.
+// Headers are not processed, ones that must be understood are responded by a fault.
+//
+//	router.Handle("/soap", restful.NewSOAP().Operation("GetUser", getUser)).Methods(http.MethodPost)
+type SOAP struct {
+	operations map[string]any
+}
+
+// NewSOAP creates a SOAP handler with no operations.
+func NewSOAP() *SOAP {
+	return &SOAP{operations: map[string]any{}}
+}
+
+// Operation registers a Lambda-style function as operation name, i.e. the local name of the element in the SOAP body.
+// The function may have a context and a request parameter, and may return a response and an error, similarly to Lambda functions.
+// The request is unmarshaled from the operation element by encoding/xml, and validated as Lambda request bodies are.
+// The response is sent as the element of its XMLName field, or as the operation name with "Response" suffix, in the namespace of the operation.
+// Panics if f is not such a function.
+func (s *SOAP) Operation(name string, f any) *SOAP {
+	t := reflect.TypeOf(f)
+	if t == nil || t.Kind() != reflect.Func {
+		panic("function expected")
+	}
+	if _, _, err := lambdaTypes(t); err != nil {
+		panic(err)
+	}
+	if t.NumOut() > 2 {
+		panic(fmt.Sprintf("too many results: %s", t))
+	}
+	s.operations[name] = f
+	return s
+}
+
+// ServeHTTP serves a SOAP request.
+func (s *SOAP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		_ = SendResp(w, r, NewError(nil, http.StatusMethodNotAllowed), nil)
+		return
+	}
+	ctx := NewRequestCtx(w, r)
+	r = r.WithContext(ctx)
+	namespace := SOAP11Namespace
+	if GetBaseContentType(r.Header) == ContentTypeSOAP {
+		namespace = SOAP12Namespace
+	}
+
+	body, err := GetDataBytes(r.Header, r.Body, LambdaMaxBytesToParse)
+	if err != nil {
+		sendSOAPFault(w, namespace, &SOAPFault{Code: SOAPFaultClient, String: err.Error()})
+		return
+	}
+	req, err := readSOAPRequest(body)
+	if req != nil {
+		namespace = req.namespace
+	}
+	if err != nil {
+		sendSOAPFault(w, namespace, NewSOAPFault(err))
+		return
+	}
+	result, err := s.call(ctx, req)
+	if err != nil {
+		sendSOAPFault(w, namespace, NewSOAPFault(err))
+		return
+	}
+	response := xml.Name{Space: req.operation.Name.Space, Local: req.operation.Name.Local + "Response"}
+	if err := sendSOAP(w, namespace, http.StatusOK, result, response); err != nil {
+		sendSOAPFault(w, namespace, &SOAPFault{Code: SOAPFaultServer, String: err.Error()})
+	}
+}
+
+type soapRequest struct {
+	namespace string
+	decoder   *xml.Decoder
+	operation xml.StartElement
+}
+
+type soapHeader struct {
+	Blocks []struct {
+		XMLName        xml.Name
+		MustUnderstand string `xml:"mustUnderstand,attr"`
+	} `xml:",any"`
+}
+
+// readSOAPRequest reads the envelope till the operation element. Returns nil request if the SOAP version is not known.
+func readSOAPRequest(body []byte) (*soapRequest, error) {
+	req := soapRequest{decoder: xml.NewDecoder(bytes.NewReader(body))}
+	envelope, err := nextSOAPElement(req.decoder)
+	if err != nil || envelope.Name.Local != "Envelope" {
+		return nil, &SOAPFault{Code: SOAPFaultClient, String: "SOAP envelope expected"}
+	}
+	req.namespace = envelope.Name.Space
+	if req.namespace != SOAP11Namespace && req.namespace != SOAP12Namespace {
+		return nil, &SOAPFault{Code: SOAPFaultVersionMismatch, String: "Unknown envelope namespace: " + req.namespace}
+	}
+
+	for {
+		start, err := nextSOAPElement(req.decoder)
+		if err != nil || start.Name.Space != req.namespace {
+			return &req, &SOAPFault{Code: SOAPFaultClient, String: "SOAP body expected"}
+		}
+		if start.Name.Local == "Body" {
+			if req.operation, err = nextSOAPElement(req.decoder); err != nil {
+				return &req, &SOAPFault{Code: SOAPFaultClient, String: "Operation expected"}
+			}
+			return &req, nil
+		}
+		if err := checkSOAPHeader(req.decoder, start); err != nil {
+			return &req, err
+		}
+	}
+}
+
+// checkSOAPHeader checks that no header block must be understood.
+func checkSOAPHeader(decoder *xml.Decoder, start xml.StartElement) error {
+	var header soapHeader
+	if start.Name.Local != "Header" || decoder.DecodeElement(&header, &start) != nil {
+		return &SOAPFault{Code: SOAPFaultClient, String: "Invalid SOAP header"}
+	}
+	for _, block := range header.Blocks {
+		if block.MustUnderstand == "1" || block.MustUnderstand == "true" {
+			return &SOAPFault{Code: SOAPFaultMustUnderstand, String: "Header not understood: " + block.XMLName.Local}
+		}
+	}
+	return nil
+}
+
+// nextSOAPElement returns the next start element, or error if an end element or EOF comes first.
+func nextSOAPElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t, nil
+		case xml.EndElement:
+			return xml.StartElement{}, errors.New("unexpected end element")
+		}
+	}
+}
+
+func (s *SOAP) call(ctx context.Context, req *soapRequest) (any, error) {
+	f, ok := s.operations[req.operation.Name.Local]
+	if !ok {
+		return nil, &SOAPFault{Code: SOAPFaultClient, String: "Unknown operation: " + req.operation.Name.Local}
+	}
+
+	t := reflect.TypeOf(f)
+	args := make([]reflect.Value, t.NumIn())
+	reqIdx := 0
+	if t.NumIn() > 0 && t.In(0).Implements(contextType) {
+		args[0] = reflect.ValueOf(ctx)
+		reqIdx = 1
+	}
+	if reqIdx < t.NumIn() {
+		arg, err := getSOAPRequestData(t.In(reqIdx), req)
+		if err != nil {
+			return nil, err
+		}
+		args[reqIdx] = arg
+	}
+
+	res := reflect.ValueOf(f).Call(args)
+	switch len(res) {
+	case 0:
+		return nil, nil
+	case 1:
+		return lambdaHandleRes1(nil, res[0])
+	default:
+		return lambdaHandleRes2(nil, res)
+	}
+}
+
+// getSOAPRequestData unmarshals and validates the operation element to a value of type t.
+func getSOAPRequestData(t reflect.Type, req *soapRequest) (reflect.Value, error) {
+	isPtr := t.Kind() == reflect.Ptr
+	if isPtr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if err := req.decoder.DecodeElement(v.Interface(), &req.operation); err != nil {
+		return v, &SOAPFault{Code: SOAPFaultClient, String: err.Error()}
+	}
+	if err := validateRequestData(v.Interface()); err != nil {
+		return v, err
+	}
+	if !isPtr {
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+// sendSOAP sends the envelope of the body element. The element is named by its XMLName field, or by name if it has none.
+func sendSOAP(w http.ResponseWriter, namespace string, status int, body any, name xml.Name) error {
+	content, err := encodeSOAPBody(body, name)
+	if err != nil {
+		return err
+	}
+
+	contentType := ContentTypeSOAP
+	if namespace == SOAP11Namespace {
+		contentType = ContentTypeTextXML
+	}
+	w.Header().Set(ContentTypeHeader, contentType+"; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = fmt.Fprintf(w, `%s<soap:Envelope xmlns:soap="%s"><soap:Body>%s</soap:Body></soap:Envelope>`, xml.Header, namespace, content)
+	return err
+}
+
+func sendSOAPFault(w http.ResponseWriter, namespace string, f *SOAPFault) {
+	fault, status := f.envelopeFault(namespace)
+	_ = sendSOAP(w, namespace, status, fault, xml.Name{})
+}
+
+func encodeSOAPBody(body any, name xml.Name) ([]byte, error) {
+	v := reflect.ValueOf(body)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return xml.Marshal(struct {
+			XMLName xml.Name
+		}{XMLName: name})
+	}
+	if hasXMLName(v) {
+		return xml.Marshal(body)
+	}
+	var content bytes.Buffer
+	err := xml.NewEncoder(&content).EncodeElement(body, xml.StartElement{Name: name})
+	return content.Bytes(), err
+}
+
+// hasXMLName tells if the struct of v has its element name by a tagged or set XMLName field.
+func hasXMLName(v reflect.Value) bool {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := v.Type().FieldByName("XMLName")
+	if !ok || f.Type != reflect.TypeOf(xml.Name{}) {
+		return false
+	}
+	return f.Tag.Get("xml") != "" || !v.FieldByIndex(f.Index).IsZero()
+}
@@ -0,0 +1,187 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type soapGetUser struct {
+	ID string `xml:"id" validate:"required"`
+}
+
+type soapUser struct {
+	Name string `xml:"name"`
+}
+
+type soapCreated struct {
+	XMLName xml.Name `xml:"urn:users Created"`
+	ID      string   `xml:"id"`
+}
+
+type soapFaultDetail struct {
+	XMLName xml.Name `xml:"urn:users Error"`
+	Reason  string   `xml:"reason"`
+}
+
+func TestSOAP(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRouter()
+	r.Handle("/soap", NewSOAP().
+		Operation("GetUser", func(ctx context.Context, req *soapGetUser) (*soapUser, error) {
+			return &soapUser{Name: "Joe" + req.ID + L(ctx).RequestHeaderGet("X-Suffix")}, nil
+		}).
+		Operation("CreateUser", func(user soapUser) soapCreated { return soapCreated{ID: user.Name} }).
+		Operation("Ping", func() {}))
+
+	{ // SOAP 1.1, response element named after the operation
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<?xml version="1.0"?>`+
+			`<env:Envelope xmlns:env="`+SOAP11Namespace+`" xmlns:u="urn:users"><env:Body><u:GetUser><u:id>1</u:id></u:GetUser></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeTextXML)
+		req.Header.Set("SOAPAction", `"GetUser"`)
+		req.Header.Set("X-Suffix", "!")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.Equal("text/xml; charset=utf-8", rr.Header().Get(ContentTypeHeader))
+		assert.Equal(xml.Header+`<soap:Envelope xmlns:soap="`+SOAP11Namespace+`"><soap:Body>`+
+			`<GetUserResponse xmlns="urn:users"><name>Joe1!</name></GetUserResponse></soap:Body></soap:Envelope>`, rr.Body.String())
+	}
+	{ // SOAP 1.2, headers not to be understood are skipped, response element of its XMLName
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP12Namespace+`">`+
+			`<env:Header><Trace xmlns="urn:trace">1</Trace></env:Header>`+
+			`<env:Body><CreateUser xmlns="urn:users"><name>Jane</name></CreateUser></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeSOAP+"; action=CreateUser")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.Equal("application/soap+xml; charset=utf-8", rr.Header().Get(ContentTypeHeader))
+		assert.Equal(xml.Header+`<soap:Envelope xmlns:soap="`+SOAP12Namespace+`"><soap:Body>`+
+			`<Created xmlns="urn:users"><id>Jane</id></Created></soap:Body></soap:Envelope>`, rr.Body.String())
+	}
+	{ // No response of the operation is an empty response element
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP11Namespace+`">`+
+			`<env:Body><Ping xmlns="urn:users"/></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeTextXML)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusOK, rr.Code)
+		assert.Contains(rr.Body.String(), `<soap:Body><PingResponse xmlns="urn:users"></PingResponse></soap:Body>`)
+	}
+	{ // POST only
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/soap?wsdl", nil))
+		assert.Equal(http.StatusMethodNotAllowed, rr.Code)
+		assert.Equal(http.MethodPost, rr.Header().Get("Allow"))
+	}
+}
+
+func TestSOAPFaults(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRouter()
+	r.Handle("/soap", NewSOAP().
+		Operation("GetUser", func(req soapGetUser) (*soapUser, error) {
+			return nil, NewError(nil, http.StatusNotFound, "no such user")
+		}).
+		Operation("Fail", func() error { return errors.New("failed") }).
+		Operation("Custom", func() error {
+			return &SOAPFault{Code: SOAPFaultClient + ".Authentication", String: "denied", Detail: soapFaultDetail{Reason: "expired"}}
+		}))
+
+	{ // SOAP 1.1 faults are sent by 500, 4xx restful errors are client faults
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP11Namespace+`">`+
+			`<env:Body><GetUser xmlns="urn:users"><id>0</id></GetUser></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeTextXML)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusInternalServerError, rr.Code)
+		assert.Equal(xml.Header+`<soap:Envelope xmlns:soap="`+SOAP11Namespace+`"><soap:Body>`+
+			`<soap:Fault><faultcode>soap:Client</faultcode><faultstring>no such user</faultstring></soap:Fault></soap:Body></soap:Envelope>`, rr.Body.String())
+	}
+	{ // SOAP 1.2 sender faults are sent by 400, as of failed validation
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP12Namespace+`">`+
+			`<env:Body><GetUser xmlns="urn:users"></GetUser></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeSOAP)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusBadRequest, rr.Code)
+		assert.Contains(rr.Body.String(), `<soap:Code><soap:Value>soap:Sender</soap:Value></soap:Code>`)
+	}
+	{ // SOAP 1.2 receiver faults of plain errors
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP12Namespace+`">`+
+			`<env:Body><Fail xmlns="urn:users"/></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeSOAP)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusInternalServerError, rr.Code)
+		assert.Contains(rr.Body.String(), `<soap:Fault><soap:Code><soap:Value>soap:Receiver</soap:Value></soap:Code>`+
+			`<soap:Reason><soap:Text xml:lang="en">failed</soap:Text></soap:Reason></soap:Fault>`)
+	}
+	{ // Custom fault with detail, subcode dropped in SOAP 1.2
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP11Namespace+`">`+
+			`<env:Body><Custom xmlns="urn:users"/></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeTextXML)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Contains(rr.Body.String(), `<faultcode>soap:Client.Authentication</faultcode><faultstring>denied</faultstring>`+
+			`<detail><Error xmlns="urn:users"><reason>expired</reason></Error></detail>`)
+
+		req = httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+SOAP12Namespace+`">`+
+			`<env:Body><Custom xmlns="urn:users"/></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeSOAP)
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Contains(rr.Body.String(), `<soap:Value>soap:Sender</soap:Value>`)
+		assert.Contains(rr.Body.String(), `<soap:Detail><Error xmlns="urn:users"><reason>expired</reason></Error></soap:Detail>`)
+	}
+	{ // Envelope faults of both versions
+		for contentType, namespace := range map[string]string{ContentTypeTextXML: SOAP11Namespace, ContentTypeSOAP: SOAP12Namespace} {
+			for body, fault := range map[string]string{
+				`<env:Body><Nope xmlns="urn:users"/></env:Body>`: "Unknown operation: Nope",
+				`<env:Header><Security xmlns="urn:sec" env:mustUnderstand="1"/></env:Header><env:Body><Fail xmlns="urn:users"/></env:Body>`: "soap:MustUnderstand",
+				`<env:Body></env:Body>`: "Operation expected",
+			} {
+				req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="`+namespace+`">`+body+`</env:Envelope>`))
+				req.Header.Set(ContentTypeHeader, contentType)
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, req)
+				assert.Contains(rr.Body.String(), fault, body)
+				assert.Contains(rr.Body.String(), `<soap:Envelope xmlns:soap="`+namespace+`">`, body)
+			}
+		}
+	}
+	{ // Unknown version is responded by version mismatch, of the version of the content type
+		req := httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<env:Envelope xmlns:env="urn:unknown"><env:Body><Fail/></env:Body></env:Envelope>`))
+		req.Header.Set(ContentTypeHeader, ContentTypeSOAP)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(http.StatusInternalServerError, rr.Code)
+		assert.Contains(rr.Body.String(), `<soap:Envelope xmlns:soap="`+SOAP12Namespace+`">`)
+		assert.Contains(rr.Body.String(), "soap:VersionMismatch")
+	}
+	{ // Not an envelope
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<Envelope`)))
+		assert.Equal(http.StatusInternalServerError, rr.Code)
+		assert.Contains(rr.Body.String(), "<faultcode>soap:Client</faultcode><faultstring>SOAP envelope expected</faultstring>")
+	}
+}
+
+func TestSOAPOperationPanics(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() { NewSOAP().Operation("x", nil) })
+	assert.Panics(func() { NewSOAP().Operation("x", func(a, b int) {}) })
+	assert.Panics(func() { NewSOAP().Operation("x", func() (int, int, error) { return 0, 0, nil }) })
+}
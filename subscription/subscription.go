@@ -0,0 +1,257 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package subscription manages notification subscriptions of webhook or 3GPP style notify patterns.
+// Subscribers provide a callback URI, notifications are POSTed there by the restful Client with retries.
+package subscription
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/nokia/restful/logging"
+)
+
+// ErrNotFound is returned if the subscription does not exist or has expired.
+var ErrNotFound = errors.New("subscription not found")
+
+// Subscription is a subscription for notifications.
+type Subscription struct {
+	// ID of the subscription.
+	ID string
+	// CallbackURI notifications are sent to.
+	CallbackURI string
+	// Expiry time of the subscription. Zero value means no expiry.
+	Expiry time.Time
+	// Data is any application specific data, e.g. the subscription filter.
+	Data any
+}
+
+// Expired tells if the subscription is expired at the given time.
+func (s *Subscription) Expired(now time.Time) bool {
+	return !s.Expiry.IsZero() && !now.Before(s.Expiry)
+}
+
+// Manager stores subscriptions and sends notifications to them.
+type Manager struct {
+	client      *restful.Client
+	attempts    int
+	backoffInit time.Duration
+	backoffMax  time.Duration
+	deadLetter  func(sub Subscription, notification any, err error)
+	onExpiry    func(sub Subscription)
+
+	mutex sync.Mutex
+	subs  map[string]*Subscription
+}
+
+// NewManager creates a subscription manager. Notifications are sent by client. If client is nil, a new default client is used.
+// By default notifications are attempted 3 times, with exponential backoff starting at 1s.
+func NewManager(client *restful.Client) *Manager {
+	if client == nil {
+		client = restful.NewClient()
+	}
+	return &Manager{client: client, attempts: 3, backoffInit: time.Second, backoffMax: 30 * time.Second, subs: make(map[string]*Subscription)}
+}
+
+// Retry sets the number of notification attempts and the exponential backoff between them.
+func (m *Manager) Retry(attempts int, backoffInit, backoffMax time.Duration) *Manager {
+	m.attempts = max(attempts, 1)
+	m.backoffInit = backoffInit
+	m.backoffMax = backoffMax
+	return m
+}
+
+// DeadLetter sets the function called if a notification could not be delivered after all the attempts.
+func (m *Manager) DeadLetter(f func(sub Subscription, notification any, err error)) *Manager {
+	m.deadLetter = f
+	return m
+}
+
+// OnExpiry sets the function called when an expired subscription is removed.
+func (m *Manager) OnExpiry(f func(sub Subscription)) *Manager {
+	m.onExpiry = f
+	return m
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Add stores the subscription and returns its ID.
+// If ID of the subscription is empty, a random one is generated.
+func (m *Manager) Add(sub Subscription) string {
+	if sub.ID == "" {
+		sub.ID = newID()
+	}
+	m.mutex.Lock()
+	m.subs[sub.ID] = &sub
+	m.mutex.Unlock()
+	return sub.ID
+}
+
+// Get returns the subscription of the ID.
+func (m *Manager) Get(id string) (Subscription, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sub, ok := m.subs[id]
+	if !ok || sub.Expired(time.Now()) {
+		return Subscription{}, ErrNotFound
+	}
+	return *sub, nil
+}
+
+// Renew sets new expiry time of the subscription.
+func (m *Manager) Renew(id string, expiry time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sub, ok := m.subs[id]
+	if !ok || sub.Expired(time.Now()) {
+		return ErrNotFound
+	}
+	sub.Expiry = expiry
+	return nil
+}
+
+// Remove deletes the subscription.
+func (m *Manager) Remove(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.subs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.subs, id)
+	return nil
+}
+
+// List returns the active subscriptions, ordered by ID.
+func (m *Manager) List() []Subscription {
+	now := time.Now()
+	m.mutex.Lock()
+	list := make([]Subscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		if !sub.Expired(now) {
+			list = append(list, *sub)
+		}
+	}
+	m.mutex.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// RemoveExpired removes the expired subscriptions, calling the function set by OnExpiry for each.
+func (m *Manager) RemoveExpired() {
+	now := time.Now()
+	var expired []Subscription
+	m.mutex.Lock()
+	for id, sub := range m.subs {
+		if sub.Expired(now) {
+			expired = append(expired, *sub)
+			delete(m.subs, id)
+		}
+	}
+	m.mutex.Unlock()
+
+	if m.onExpiry != nil {
+		for _, sub := range expired {
+			m.onExpiry(sub)
+		}
+	}
+}
+
+// Run removes expired subscriptions periodically, until ctx is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.RemoveExpired()
+		}
+	}
+}
+
+func (m *Manager) backoff(attempt int) time.Duration {
+	backoff := (1 << attempt) * m.backoffInit
+	if backoff > m.backoffMax || backoff <= 0 {
+		backoff = m.backoffMax
+	}
+	return backoff
+}
+
+// Notify sends the notification to the subscription in a POST request, expecting a 2xx response.
+// Transport errors, 5xx and 429 responses are retried with exponential backoff.
+// If all the attempts fail, the dead letter function is called, and the last error is returned.
+func (m *Manager) Notify(ctx context.Context, id string, notification any) error {
+	sub, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	return m.notify(ctx, sub, notification)
+}
+
+func retriable(err error) bool {
+	status := restful.GetErrStatusCodeElse(err, 0)
+	return status == 0 || status >= 500 || status == http.StatusTooManyRequests
+}
+
+func (m *Manager) notify(ctx context.Context, sub Subscription, notification any) error {
+	var err error
+	for attempt := 0; attempt < m.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(m.backoff(attempt - 1)):
+			}
+		}
+
+		_, err = m.client.SendRecv2xx(ctx, http.MethodPost, sub.CallbackURI, nil, notification, nil)
+		if err == nil || !retriable(err) || ctx.Err() != nil {
+			break
+		}
+		logging.Module("subscription").Debug("Notification failed", "callback_uri", sub.CallbackURI, "attempt", attempt+1, "error", err)
+	}
+
+	if err != nil && m.deadLetter != nil {
+		m.deadLetter(sub, notification, err)
+	}
+	return err
+}
+
+// NotifyAll sends the notification to all the subscriptions accepted by filter, in parallel.
+// If filter is nil, all the active subscriptions are notified.
+// Returns the joined errors of failed notifications.
+func (m *Manager) NotifyAll(ctx context.Context, filter func(sub Subscription) bool, notification any) error {
+	var wg sync.WaitGroup
+	var errMutex sync.Mutex
+	var errs []error
+	for _, sub := range m.List() {
+		if filter != nil && !filter(sub) {
+			continue
+		}
+		wg.Add(1)
+		go func(sub Subscription) {
+			defer wg.Done()
+			if err := m.notify(ctx, sub, notification); err != nil {
+				errMutex.Lock()
+				errs = append(errs, err)
+				errMutex.Unlock()
+			}
+		}(sub)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	Name string `json:"name"`
+}
+
+func TestNotify(t *testing.T) {
+	assert := assert.New(t)
+
+	var okCalls, failCalls atomic.Int32
+	r := restful.NewRouter()
+	r.HandleFunc("/ok", func(e event) error {
+		okCalls.Add(1)
+		assert.Equal("created", e.Name)
+		return nil
+	})
+	r.HandleFunc("/fail", func() error {
+		failCalls.Add(1)
+		return restful.NewError(nil, http.StatusServiceUnavailable)
+	})
+	r.HandleFunc("/gone", func() error {
+		return restful.NewError(nil, http.StatusNotFound)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	var deadLetters atomic.Int32
+	m := NewManager(nil).Retry(3, time.Millisecond, 2*time.Millisecond).DeadLetter(func(sub Subscription, notification any, err error) {
+		deadLetters.Add(1)
+	})
+	okID := m.Add(Subscription{CallbackURI: srv.URL + "/ok"})
+	failID := m.Add(Subscription{CallbackURI: srv.URL + "/fail"})
+	m.Add(Subscription{ID: "gone", CallbackURI: srv.URL + "/gone"})
+
+	assert.NoError(m.Notify(context.Background(), okID, event{Name: "created"}))
+	assert.Equal(int32(1), okCalls.Load())
+
+	err := m.Notify(context.Background(), failID, event{Name: "created"})
+	assert.Equal(http.StatusServiceUnavailable, restful.GetErrStatusCode(err))
+	assert.Equal(int32(3), failCalls.Load())
+	assert.Equal(int32(1), deadLetters.Load())
+
+	err = m.NotifyAll(context.Background(), func(sub Subscription) bool { return sub.ID != failID }, event{Name: "created"})
+	assert.Equal(http.StatusNotFound, restful.GetErrStatusCode(err)) // Not retried.
+	assert.Equal(int32(2), okCalls.Load())
+	assert.Equal(int32(2), deadLetters.Load())
+
+	assert.ErrorIs(m.Notify(context.Background(), "missing", nil), ErrNotFound)
+}
+
+func TestExpiry(t *testing.T) {
+	assert := assert.New(t)
+	var expired []string
+	m := NewManager(nil).OnExpiry(func(sub Subscription) { expired = append(expired, sub.ID) })
+	m.Add(Subscription{ID: "a", Expiry: time.Now().Add(-time.Second)})
+	m.Add(Subscription{ID: "b", Expiry: time.Now().Add(time.Hour)})
+	m.Add(Subscription{ID: "c"})
+
+	_, err := m.Get("a")
+	assert.ErrorIs(err, ErrNotFound)
+	assert.ErrorIs(m.Renew("a", time.Now().Add(time.Hour)), ErrNotFound)
+	assert.Len(m.List(), 2)
+
+	m.RemoveExpired()
+	assert.Equal([]string{"a"}, expired)
+
+	assert.NoError(m.Renew("b", time.Now().Add(-time.Second)))
+	ctx, cancel := context.WithCancel(context.Background())
+	go m.Run(ctx, time.Millisecond)
+	assert.Eventually(func() bool { return len(m.List()) == 1 }, time.Second, time.Millisecond)
+	cancel()
+
+	assert.NoError(m.Remove("c"))
+	assert.ErrorIs(m.Remove("c"), ErrNotFound)
+}
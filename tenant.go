@@ -0,0 +1,189 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/nokia/restful/logging"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TenantHeader is the header carrying the tenant, forwarded by Client. By default "X-Tenant-Id".
+var TenantHeader = "X-Tenant-Id"
+
+// TenantKey is the attribute key of the tenant, labeling metrics and logs.
+const TenantKey = attribute.Key("tenant")
+
+// TenantMaxLen is the max length of an extracted tenant. Longer ones, or ones having non-printable characters, are ignored.
+const TenantMaxLen = 64
+
+// TenantMetrics tells whether server and client metrics are labeled by tenant. Disabled by default.
+// Only tenants set by ContextWithTenant, extracted from claims, or listed by TenantExtractor's MetricsTenants label metrics,
+// so that clients cannot make metrics of high cardinality by sending arbitrary tenants.
+var TenantMetrics = false
+
+type tenantCtxKeyType string
+
+const tenantCtxName = tenantCtxKeyType("restfulTenant")
+
+type tenantCtxValue struct {
+	tenant  string
+	metrics bool // Labels metrics.
+}
+
+// TenantFromContext returns the tenant of the context, or empty string if there is none.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	value, _ := ctx.Value(tenantCtxName).(tenantCtxValue)
+	return value.tenant
+}
+
+// ContextWithTenant returns a context derived from ctx, holding the tenant.
+// Client functions send that in TenantHeader. The logger of the context gets a "tenant" attribute.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return contextWithTenant(ctx, tenant, true)
+}
+
+func contextWithTenant(ctx context.Context, tenant string, metrics bool) context.Context {
+	parent := ctx
+	ctx = context.WithValue(ctx, tenantCtxName, tenantCtxValue{tenant: tenant, metrics: metrics})
+	return logging.NewContextFunc(ctx, func() *slog.Logger { return logging.FromContext(parent).With(string(TenantKey), tenant) })
+}
+
+// validTenant tells if the tenant is not empty, not too long, and has printable ASCII characters only.
+func validTenant(tenant string) bool {
+	if tenant == "" || len(tenant) > TenantMaxLen {
+		return false
+	}
+	for i := 0; i < len(tenant); i++ {
+		if tenant[i] < 0x21 || tenant[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// setTenantHeader sets the tenant header according to the context, unless set already.
+func setTenantHeader(ctx context.Context, header http.Header) {
+	if header.Get(TenantHeader) != "" {
+		return
+	}
+	if tenant := TenantFromContext(ctx); tenant != "" {
+		header.Set(TenantHeader, tenant)
+	}
+}
+
+// tenantAttributes returns the tenant attribute of the context for metrics, if any.
+func tenantAttributes(ctx context.Context) []attribute.KeyValue {
+	if ctx == nil || !TenantMetrics {
+		return nil
+	}
+	if value, _ := ctx.Value(tenantCtxName).(tenantCtxValue); value.metrics && value.tenant != "" {
+		return []attribute.KeyValue{TenantKey.String(value.tenant)}
+	}
+	return nil
+}
+
+// TenantExtractor extracts the tenant of requests into the context, see TenantFromContext.
+// Sources are tried in the order added: header, JWT claim or first path segment. Values longer than TenantMaxLen or having
+// non-printable characters are skipped.
+// The tenant labels logs of the context, and is forwarded by Client in TenantHeader.
+// Tenants of claims label server and client metrics, too, if TenantMetrics is set. Ones of headers and paths are not authenticated,
+// those label metrics only if listed by MetricsTenants.
+//
+//	tenants := restful.NewTenantExtractor().Claim("tenant").Header(restful.TenantHeader).Required()
+//	router := restful.NewRouter().Monitor(tenants.Pre, nil).Monitor(jwtAuth.Pre, nil) // Monitors added later run first.
+type TenantExtractor struct {
+	sources        []tenantSource
+	required       bool
+	metricsTenants map[string]bool
+}
+
+type tenantSource struct {
+	extract       func(r *http.Request) string
+	authenticated bool
+}
+
+// NewTenantExtractor creates a tenant extractor, having no sources.
+func NewTenantExtractor() *TenantExtractor {
+	return &TenantExtractor{}
+}
+
+// Header extracts the tenant from a request header.
+func (t *TenantExtractor) Header(name string) *TenantExtractor {
+	t.sources = append(t.sources, tenantSource{extract: func(r *http.Request) string { return r.Header.Get(name) }})
+	return t
+}
+
+// Claim extracts the tenant from a claim of the authenticated request, see ClaimsFromContext.
+// Use after authentication.
+func (t *TenantExtractor) Claim(name string) *TenantExtractor {
+	t.sources = append(t.sources, tenantSource{extract: func(r *http.Request) string { return ClaimsFromContext(r.Context()).String(name) }, authenticated: true})
+	return t
+}
+
+// PathPrefix extracts the tenant from the first segment of the path, e.g. "acme" of "/acme/orders".
+func (t *TenantExtractor) PathPrefix() *TenantExtractor {
+	t.sources = append(t.sources, tenantSource{extract: func(r *http.Request) string {
+		tenant, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		return tenant
+	}})
+	return t
+}
+
+// MetricsTenants lists the tenants labeling metrics even if extracted from a header or path, if TenantMetrics is set.
+func (t *TenantExtractor) MetricsTenants(tenants ...string) *TenantExtractor {
+	if t.metricsTenants == nil {
+		t.metricsTenants = make(map[string]bool, len(tenants))
+	}
+	for _, tenant := range tenants {
+		t.metricsTenants[tenant] = true
+	}
+	return t
+}
+
+// Required makes requests without a valid tenant responded 400.
+func (t *TenantExtractor) Required() *TenantExtractor {
+	t.required = true
+	return t
+}
+
+// extract returns the tenant of the first source having a valid one, and whether it labels metrics.
+func (t *TenantExtractor) extract(r *http.Request) (tenant string, metrics bool) {
+	for _, source := range t.sources {
+		if tenant := source.extract(r); validTenant(tenant) {
+			return tenant, source.authenticated || t.metricsTenants[tenant]
+		}
+	}
+	return "", false
+}
+
+// Pre is a Monitor pre function, extracting the tenant. May be used for route groups, by Router's Monitor.
+func (t *TenantExtractor) Pre(w http.ResponseWriter, r *http.Request) *http.Request {
+	tenant, metrics := t.extract(r)
+	if tenant == "" {
+		if t.required {
+			pd := ProblemDetails{Title: http.StatusText(http.StatusBadRequest), Status: http.StatusBadRequest, Detail: "tenant missing or invalid"}
+			_ = SendProblemResponse(w, r, http.StatusBadRequest, pd.String())
+			return nil
+		}
+		return r
+	}
+	if metrics {
+		setServerMetricsTenant(r, tenant)
+	}
+	return r.WithContext(contextWithTenant(r.Context(), tenant, metrics))
+}
+
+// Handler wraps the handler, extracting the tenant of all requests.
+func (t *TenantExtractor) Handler(h http.Handler) http.Handler {
+	return Monitor(h, t.Pre, nil)
+}
@@ -0,0 +1,135 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nokia/restful/logging"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestTenant(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	logging.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer logging.SetLogger(nil)
+
+	var downstreamTenant string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamTenant = r.Header.Get(TenantHeader)
+	}))
+	defer downstream.Close()
+
+	tenants := NewTenantExtractor().Claim("tenant").Header(TenantHeader).Required()
+	router := NewRouter()
+	router.Monitor(tenants.Pre, nil).Monitor(func(w http.ResponseWriter, r *http.Request) *http.Request {
+		if r.Header.Get("X-User") == "joe" {
+			return r.WithContext(ContextWithClaims(r.Context(), Claims{"sub": "joe", "tenant": "acme"}))
+		}
+		return r
+	}, nil)
+	router.HandleFunc("/orders", func(ctx context.Context) error {
+		L(ctx).Logger().Info("hello")
+		return NewClient().Get(ctx, downstream.URL, nil)
+	})
+
+	request := func(user, tenant string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set("X-User", user)
+		if tenant != "" {
+			req.Header.Set(TenantHeader, tenant)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(http.StatusNoContent, request("joe", "other")) // Claim first.
+	assert.Equal("acme", downstreamTenant)
+	var record map[string]any
+	assert.NoError(json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal("acme", record["tenant"])
+
+	assert.Equal(http.StatusNoContent, request("eve", "globex"))
+	assert.Equal("globex", downstreamTenant)
+	assert.Equal(http.StatusBadRequest, request("eve", ""))
+	assert.Equal(http.StatusBadRequest, request("eve", "globex inc"))
+	assert.Equal(http.StatusBadRequest, request("eve", strings.Repeat("x", TenantMaxLen+1)))
+}
+
+func TestTenantPathPrefix(t *testing.T) {
+	assert := assert.New(t)
+	reader := sdkmetric.NewManualReader()
+	prevProvider := otel.GetMeterProvider()
+	SetOTelMetrics(true, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	TenantMetrics = true
+	defer func() {
+		TenantMetrics = false
+		SetOTelMetrics(false, nil)
+		otel.SetMeterProvider(prevProvider)
+	}()
+
+	router := NewRouter()
+	router.Monitor(NewTenantExtractor().Claim("tenant").PathPrefix().MetricsTenants("acme").Pre, nil).Monitor(func(w http.ResponseWriter, r *http.Request) *http.Request {
+		if r.Header.Get("X-User") == "joe" {
+			return r.WithContext(ContextWithClaims(r.Context(), Claims{"sub": "joe", "tenant": "initech"}))
+		}
+		return r
+	}, nil)
+	router.HandleFunc("/{tenant}/orders", func(ctx context.Context) string { return TenantFromContext(ctx) })
+	h := NewServer().Handler(router).server.Handler
+	for _, path := range []string{"/acme/orders", "/globex/orders"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(`"`+strings.Split(path, "/")[1]+`"`, w.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/umbrella/orders", nil)
+	req.Header.Set("X-User", "joe")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(reader.Collect(context.Background(), &rm))
+	duration := findMetric(rm, "http.server.request.duration")
+	if assert.NotNil(duration) {
+		var tenants []string
+		for _, dp := range duration.Data.(metricdata.Histogram[float64]).DataPoints {
+			if tenant, ok := dp.Attributes.Value(TenantKey); ok {
+				tenants = append(tenants, tenant.AsString())
+			}
+		}
+		assert.ElementsMatch([]string{"acme", "initech"}, tenants) // Not labeled by the path not listed.
+	}
+}
+
+func TestTenantContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(TenantFromContext(context.Background()))
+	ctx := ContextWithTenant(context.Background(), "acme")
+	assert.Equal("acme", TenantFromContext(ctx))
+
+	header := http.Header{}
+	setTenantHeader(ctx, header)
+	assert.Equal("acme", header.Get(TenantHeader))
+	header.Set(TenantHeader, "other")
+	setTenantHeader(ctx, header)
+	assert.Equal("other", header.Get(TenantHeader))
+
+	assert.Empty(tenantAttributes(ctx))
+	TenantMetrics = true
+	defer func() { TenantMetrics = false }()
+	assert.Len(tenantAttributes(ctx), 1)
+	assert.Empty(tenantAttributes(contextWithTenant(context.Background(), "acme", false)))
+}
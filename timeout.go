@@ -0,0 +1,118 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// timeoutWriter buffers the response, so that either the response of the handler or the timeout response is sent, never both.
+type timeoutWriter struct {
+	mutex       sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	statusCode  int
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(statusCode)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(statusCode int) {
+	tw.wroteHeader = true
+	tw.statusCode = statusCode
+}
+
+// Timeout wraps the handler, limiting its execution time.
+// The request context gets a deadline, so that the handler and the clients it uses can tell when to give up.
+// If the handler does not return by the deadline, then 504 Gateway Timeout is responded with a problem body.
+// Whatever the handler writes afterwards is dropped, getting http.ErrHandlerTimeout, so exactly one response is sent.
+// The response of the handler is buffered, so streaming and flushing are not supported.
+//
+//	restful.NewServer().Addr(":8080").Handler(restful.Timeout(router, 10*time.Second))
+//
+// For route groups see Router's Timeout.
+func Timeout(h http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicChan := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			h.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+			tw.mutex.Lock()
+			defer tw.mutex.Unlock()
+			header := w.Header()
+			for k, v := range tw.header {
+				header[k] = v
+			}
+			if !tw.wroteHeader {
+				tw.statusCode = http.StatusOK
+			}
+			w.WriteHeader(tw.statusCode)
+			_, _ = w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mutex.Lock()
+			tw.timedOut = true
+			tw.mutex.Unlock()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				pd := ProblemDetails{Title: http.StatusText(http.StatusGatewayTimeout), Status: http.StatusGatewayTimeout, Detail: "request timed out"}
+				_ = SendProblemResponse(w, r, http.StatusGatewayTimeout, pd.String())
+			} // Otherwise the client is gone, no response.
+		}
+	})
+}
+
+// Timeout limits the execution time of the handlers added after calling this function, as Monitor does.
+// Subrouters inherit that, so a timeout can be set per route group. See the Timeout function.
+//
+//	reports := router.PathPrefix("/reports").Subrouter().Timeout(time.Minute)
+func (r *Router) Timeout(timeout time.Duration) *Router {
+	r.monitors.appendWrapper(func(h http.Handler) http.Handler { return Timeout(h, timeout) })
+	return r
+}
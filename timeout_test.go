@@ -0,0 +1,71 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutHandler(t *testing.T) {
+	assert := assert.New(t)
+	lateErr := make(chan error, 1)
+	h := Timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			_, err := w.Write([]byte("late"))
+			lateErr <- err
+			return
+		}
+		w.Header().Set("X-Fast", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("fast"))
+	}), 50*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(http.StatusCreated, w.Code)
+	assert.Equal("1", w.Header().Get("X-Fast"))
+	assert.Equal("fast", w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(http.StatusGatewayTimeout, w.Code)
+	assert.Contains(w.Body.String(), "timed out")
+	assert.ErrorIs(<-lateErr, http.ErrHandlerTimeout)
+	assert.NotContains(w.Body.String(), "late")
+
+	// Panics are propagated.
+	h = Timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("oops") }), time.Second)
+	assert.PanicsWithValue("oops", func() { h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) })
+}
+
+func TestRouterTimeout(t *testing.T) {
+	assert := assert.New(t)
+	router := NewRouter()
+	router.HandleFunc("/status", func(ctx context.Context) bool {
+		_, hasDeadline := ctx.Deadline()
+		return hasDeadline
+	})
+	reports := router.PathPrefix("/reports").Subrouter().Timeout(20 * time.Millisecond)
+	reports.HandleFunc("/daily", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal("false", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/daily", nil))
+	assert.Equal(http.StatusGatewayTimeout, w.Code)
+}
@@ -0,0 +1,77 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"net/http"
+)
+
+// PeerIdentity is the identity of a client authenticated by mTLS, taken from its verified certificate.
+type PeerIdentity struct {
+	CommonName     string   `json:"cn,omitempty"`
+	DNSNames       []string `json:"dns_names,omitempty"`
+	URIs           []string `json:"uris,omitempty"` // E.g. SPIFFE ID.
+	EmailAddresses []string `json:"emails,omitempty"`
+	Issuer         string   `json:"issuer,omitempty"`
+	SerialNumber   string   `json:"serial,omitempty"`
+}
+
+// Name returns the most specific name of the peer: the first URI SAN, DNS SAN or the CN.
+func (p *PeerIdentity) Name() string {
+	switch {
+	case p == nil:
+		return ""
+	case len(p.URIs) > 0:
+		return p.URIs[0]
+	case len(p.DNSNames) > 0:
+		return p.DNSNames[0]
+	}
+	return p.CommonName
+}
+
+type peerIdentityCtxKeyType string
+
+const peerIdentityCtxName = peerIdentityCtxKeyType("restfulPeerIdentity")
+
+// PeerIdentityFromContext returns the identity of the client authenticated by mTLS, or nil if there is none.
+//
+//	func handle(ctx context.Context) error {
+//	    if restful.PeerIdentityFromContext(ctx).Name() != "spiffe://example.com/billing" {
+//	        return restful.NewError(nil, http.StatusForbidden)
+//	    }
+//	}
+func PeerIdentityFromContext(ctx context.Context) *PeerIdentity {
+	id, _ := ctx.Value(peerIdentityCtxName).(*PeerIdentity)
+	return id
+}
+
+func peerIdentity(r *http.Request) *PeerIdentity {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	id := &PeerIdentity{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		Issuer:         cert.Issuer.String(),
+		SerialNumber:   cert.SerialNumber.String(),
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+	return id
+}
+
+// peerIdentityHandler stores the identity of the client authenticated by mTLS in the request context.
+func peerIdentityHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := peerIdentity(r); id != nil {
+			r = r.WithContext(context.WithValue(r.Context(), peerIdentityCtxName, id))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
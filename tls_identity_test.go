@@ -0,0 +1,43 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerIdentity(t *testing.T) {
+	assert := assert.New(t)
+	ca := newTestCA(t)
+	cert := ca.issue(t, 2, "billing", "")
+	cert.URIs = []*url.URL{{Scheme: "spiffe", Host: "example.com", Path: "/billing"}}
+
+	var id *PeerIdentity
+	router := NewRouter()
+	router.HandleFunc("/", func(ctx context.Context) { id = PeerIdentityFromContext(ctx) })
+	h := NewServer().Handler(router).server.Handler
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert, ca.cert}}}
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NotNil(id) {
+		assert.Equal("billing", id.CommonName)
+		assert.Equal("spiffe://example.com/billing", id.Name())
+		assert.Equal("2", id.SerialNumber)
+		assert.Equal("CN=test CA", id.Issuer)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(id)
+	assert.Empty(id.Name())
+}
@@ -0,0 +1,288 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ErrCertRevoked is returned if a peer certificate is revoked.
+var ErrCertRevoked = errors.New("certificate revoked")
+
+// ErrRevocationUnknown is returned in hard-fail mode if the revocation status of a peer certificate cannot be determined.
+var ErrRevocationUnknown = errors.New("certificate revocation status unknown")
+
+// CRLReloadInterval is the min time between checking CRL files for changes.
+var CRLReloadInterval = 5 * time.Minute
+
+// OCSPTimeout is the max time to wait for an OCSP responder.
+var OCSPTimeout = 5 * time.Second
+
+// OCSPCacheSize is the max number of OCSP responses cached per checker.
+// When full, expired responses are dropped, and if none expired, an arbitrary one.
+var OCSPCacheSize = 10000
+
+// RevocationChecker checks whether peer certificates are revoked, by CRL files and/or OCSP.
+// Its VerifyPeerCertificate is used as a TLS verification hook, e.g. at Server's TLSVerifyPeer.
+// By default the check is soft-fail: if there is no valid CRL of the issuer and OCSP is not available, the certificate is accepted.
+// A CRL past its next update time is stale: certificates listed are revoked, others are of unknown status.
+//
+//	revocation := restful.NewRevocationChecker().CRL("/etc/crl").OCSP()
+//	server.TLSClientCert("/etc/clientcas", false).TLSVerifyPeer(revocation.VerifyPeerCertificate)
+type RevocationChecker struct {
+	crlPaths []string
+	ocsp     bool
+	hardFail bool
+	client   *http.Client
+
+	crls      atomic.Pointer[[]*revocationList]
+	nextCheck atomic.Int64 // Unix nano.
+	mutex     sync.Mutex
+	modTime   time.Time
+
+	ocspMutex sync.Mutex
+	ocspCache map[string]*ocsp.Response // By issuer and serial.
+}
+
+type revocationList struct {
+	crl     *x509.RevocationList
+	revoked map[string]bool // By serial number.
+}
+
+// NewRevocationChecker creates a revocation checker, having no CRLs and OCSP disabled.
+func NewRevocationChecker() *RevocationChecker {
+	return &RevocationChecker{client: &http.Client{Timeout: OCSPTimeout}, ocspCache: map[string]*ocsp.Response{}}
+}
+
+// CRL sets CRL files, PEM or DER, or directories of those, matching *.crl or *.pem.
+// Files are reloaded when changed, checked at most once per CRLReloadInterval.
+func (c *RevocationChecker) CRL(paths ...string) *RevocationChecker {
+	c.crlPaths = paths
+	c.modTime = time.Time{}
+	c.reloadCRLs()
+	c.nextCheck.Store(time.Now().Add(CRLReloadInterval).UnixNano())
+	return c
+}
+
+// OCSP enables asking the OCSP responders of the certificates, if no CRL of the issuer is loaded.
+// Responses are cached until their next update time.
+func (c *RevocationChecker) OCSP() *RevocationChecker {
+	c.ocsp = true
+	return c
+}
+
+// HardFail makes certificates rejected if their revocation status cannot be determined.
+func (c *RevocationChecker) HardFail() *RevocationChecker {
+	c.hardFail = true
+	return c
+}
+
+func loadCRLFile(path string) (lists []*revocationList, err error) {
+	data, err := os.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	var ders [][]byte
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type == "X509 CRL" {
+				ders = append(ders, block.Bytes)
+			}
+		}
+	} else {
+		ders = [][]byte{data}
+	}
+	for _, der := range ders {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return nil, err
+		}
+		list := &revocationList{crl: crl, revoked: make(map[string]bool, len(crl.RevokedCertificateEntries))}
+		for _, entry := range crl.RevokedCertificateEntries {
+			list.revoked[entry.SerialNumber.String()] = true
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// reloadCRLs loads the CRL files, if changed. On error the former CRLs are kept.
+func (c *RevocationChecker) reloadCRLs() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	modTime := latestModTime(c.crlPaths...)
+	if modTime.Equal(c.modTime) && c.crls.Load() != nil {
+		return
+	}
+
+	var lists []*revocationList
+	for _, root := range c.crlPaths {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if ext := strings.ToLower(filepath.Ext(path)); path != root && ext != ".crl" && ext != ".pem" {
+				return nil
+			}
+			loaded, err := loadCRLFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			lists = append(lists, loaded...)
+			return nil
+		})
+		if err != nil {
+			logging.Errorf(context.Background(), "Failed to load CRL: %v", err)
+			return
+		}
+	}
+	c.crls.Store(&lists)
+	c.modTime = modTime
+	logging.Debugf(context.Background(), "Loaded %d CRLs", len(lists))
+}
+
+func (c *RevocationChecker) checkCRLReload() {
+	now := time.Now()
+	next := c.nextCheck.Load()
+	if len(c.crlPaths) > 0 && now.UnixNano() >= next && c.nextCheck.CompareAndSwap(next, now.Add(CRLReloadInterval).UnixNano()) {
+		c.reloadCRLs()
+	}
+}
+
+// crlStatus tells whether the cert is revoked, and whether a CRL of the issuer not stale was found.
+func (c *RevocationChecker) crlStatus(cert, issuer *x509.Certificate) (revoked, known bool) {
+	lists := c.crls.Load()
+	if lists == nil {
+		return false, false
+	}
+	for _, list := range *lists {
+		if !bytes.Equal(list.crl.RawIssuer, cert.RawIssuer) || list.crl.CheckSignatureFrom(issuer) != nil {
+			continue
+		}
+		if list.revoked[cert.SerialNumber.String()] {
+			return true, true
+		}
+		if !list.crl.NextUpdate.IsZero() && time.Now().After(list.crl.NextUpdate) {
+			logging.Warnf(context.Background(), "CRL of '%s' is stale, next update was %v", issuer.Subject, list.crl.NextUpdate)
+			continue
+		}
+		known = true
+	}
+	return false, known
+}
+
+// ocspStatus asks the OCSP responder of the cert, using the cache.
+func (c *RevocationChecker) ocspStatus(cert, issuer *x509.Certificate) (revoked, known bool) {
+	if len(cert.OCSPServer) == 0 {
+		return false, false
+	}
+	key := string(issuer.RawSubjectPublicKeyInfo) + cert.SerialNumber.String()
+	c.ocspMutex.Lock()
+	resp, ok := c.ocspCache[key]
+	c.ocspMutex.Unlock()
+	if !ok || time.Now().After(resp.NextUpdate) {
+		var err error
+		if resp, err = c.askOCSP(cert, issuer); err != nil {
+			logging.Errorf(context.Background(), "OCSP check of '%s' failed: %v", cert.Subject, err)
+			return false, false
+		}
+		if !resp.NextUpdate.IsZero() {
+			c.cacheOCSP(key, resp)
+		}
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return false, true
+	case ocsp.Revoked:
+		return true, true
+	}
+	return false, false
+}
+
+// cacheOCSP stores the response, making room if the cache is full.
+func (c *RevocationChecker) cacheOCSP(key string, resp *ocsp.Response) {
+	c.ocspMutex.Lock()
+	defer c.ocspMutex.Unlock()
+	if _, ok := c.ocspCache[key]; !ok && len(c.ocspCache) >= OCSPCacheSize {
+		now := time.Now()
+		for k, cached := range c.ocspCache {
+			if now.After(cached.NextUpdate) {
+				delete(c.ocspCache, k)
+			}
+		}
+		for k := range c.ocspCache {
+			if len(c.ocspCache) < OCSPCacheSize {
+				break
+			}
+			delete(c.ocspCache, k)
+		}
+	}
+	c.ocspCache[key] = resp
+}
+
+func (c *RevocationChecker) askOCSP(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := c.client.Post(cert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", httpResp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return ocsp.ParseResponseForCert(body, cert, issuer)
+}
+
+// Check checks the certs of a verified chain, leaf first, except the root.
+func (c *RevocationChecker) Check(chain []*x509.Certificate) error {
+	c.checkCRLReload()
+	for i := 0; i+1 < len(chain); i++ {
+		cert, issuer := chain[i], chain[i+1]
+		revoked, known := c.crlStatus(cert, issuer)
+		if !known && c.ocsp {
+			revoked, known = c.ocspStatus(cert, issuer)
+		}
+		if revoked {
+			return fmt.Errorf("%w: %s, serial %s", ErrCertRevoked, cert.Subject, cert.SerialNumber)
+		}
+		if !known && c.hardFail {
+			return fmt.Errorf("%w: %s, serial %s", ErrRevocationUnknown, cert.Subject, cert.SerialNumber)
+		}
+	}
+	return nil
+}
+
+// VerifyPeerCertificate checks the verified chains, as tls.Config VerifyPeerCertificate hook.
+func (c *RevocationChecker) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	for _, chain := range verifiedChains {
+		if err := c.Check(chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
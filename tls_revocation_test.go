@@ -0,0 +1,170 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package restful
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, cn string, ocspServer string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if ocspServer != "" {
+		tmpl.OCSPServer = []string{ocspServer}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func (ca *testCA) writeCRL(t *testing.T, path string, revoked ...int64) {
+	ca.writeCRLUntil(t, path, time.Now().Add(time.Hour), revoked...)
+}
+
+func (ca *testCA) writeCRLUntil(t *testing.T, path string, nextUpdate time.Time, revoked ...int64) {
+	tmpl := &x509.RevocationList{Number: big.NewInt(time.Now().UnixNano()), ThisUpdate: nextUpdate.Add(-2 * time.Hour), NextUpdate: nextUpdate}
+	for _, serial := range revoked {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.cert, ca.key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0o600))
+}
+
+func TestRevocationCRL(t *testing.T) {
+	assert := assert.New(t)
+	prevInterval := CRLReloadInterval
+	CRLReloadInterval = 0
+	defer func() { CRLReloadInterval = prevInterval }()
+
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	ca.writeCRL(t, filepath.Join(dir, "ca.crl"), 2)
+	good, revoked := ca.issue(t, 3, "good", ""), ca.issue(t, 2, "revoked", "")
+
+	checker := NewRevocationChecker().CRL(dir)
+	assert.NoError(checker.Check([]*x509.Certificate{good, ca.cert}))
+	assert.ErrorIs(checker.Check([]*x509.Certificate{revoked, ca.cert}), ErrCertRevoked)
+	assert.ErrorIs(checker.VerifyPeerCertificate(nil, [][]*x509.Certificate{{revoked, ca.cert}}), ErrCertRevoked)
+
+	// Reloaded
+	ca.writeCRL(t, filepath.Join(dir, "ca.crl"), 3)
+	future := time.Now().Add(time.Second)
+	assert.NoError(os.Chtimes(filepath.Join(dir, "ca.crl"), future, future))
+	assert.ErrorIs(checker.Check([]*x509.Certificate{good, ca.cert}), ErrCertRevoked)
+	assert.NoError(checker.Check([]*x509.Certificate{revoked, ca.cert}))
+
+	// Unknown issuer
+	other := newTestCA(t)
+	cert := other.issue(t, 2, "other", "")
+	assert.NoError(checker.Check([]*x509.Certificate{cert, other.cert}))
+	assert.ErrorIs(checker.HardFail().Check([]*x509.Certificate{cert, other.cert}), ErrRevocationUnknown)
+}
+
+func TestRevocationCRLStale(t *testing.T) {
+	assert := assert.New(t)
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	ca.writeCRLUntil(t, filepath.Join(dir, "ca.crl"), time.Now().Add(-time.Minute), 2)
+	good, revoked := ca.issue(t, 3, "good", ""), ca.issue(t, 2, "revoked", "")
+
+	checker := NewRevocationChecker().CRL(dir)
+	assert.NoError(checker.Check([]*x509.Certificate{good, ca.cert})) // Soft-fail.
+	assert.ErrorIs(checker.Check([]*x509.Certificate{revoked, ca.cert}), ErrCertRevoked)
+	checker.HardFail()
+	assert.ErrorIs(checker.Check([]*x509.Certificate{good, ca.cert}), ErrRevocationUnknown)
+	assert.ErrorIs(checker.Check([]*x509.Certificate{revoked, ca.cert}), ErrCertRevoked)
+}
+
+func TestRevocationOCSP(t *testing.T) {
+	assert := assert.New(t)
+	ca := newTestCA(t)
+	calls := 0
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if !assert.NoError(err) {
+			return
+		}
+		status := ocsp.Good
+		if req.SerialNumber.Int64() == 2 {
+			status = ocsp.Revoked
+		}
+		resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+			Status: status, SerialNumber: req.SerialNumber, ThisUpdate: time.Now(), NextUpdate: time.Now().Add(time.Hour), RevokedAt: time.Now(),
+		}, ca.key)
+		assert.NoError(err)
+		_, _ = w.Write(resp)
+	}))
+	defer responder.Close()
+
+	checker := NewRevocationChecker().OCSP().HardFail()
+	good, revoked := ca.issue(t, 3, "good", responder.URL), ca.issue(t, 2, "revoked", responder.URL)
+	assert.NoError(checker.Check([]*x509.Certificate{good, ca.cert}))
+	assert.NoError(checker.Check([]*x509.Certificate{good, ca.cert}))
+	assert.Equal(1, calls) // Cached.
+	assert.ErrorIs(checker.Check([]*x509.Certificate{revoked, ca.cert}), ErrCertRevoked)
+	assert.ErrorIs(checker.Check([]*x509.Certificate{ca.issue(t, 4, "no OCSP", ""), ca.cert}), ErrRevocationUnknown)
+
+	// Cache is bounded.
+	prevSize := OCSPCacheSize
+	OCSPCacheSize = 2
+	defer func() { OCSPCacheSize = prevSize }()
+	for serial := int64(5); serial < 10; serial++ {
+		assert.NoError(checker.Check([]*x509.Certificate{ca.issue(t, serial, "good", responder.URL), ca.cert}))
+	}
+	assert.Len(checker.ocspCache, 2)
+}
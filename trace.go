@@ -5,14 +5,41 @@
 package restful
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/nokia/restful/lambda"
+	"github.com/nokia/restful/trace/tracecommon"
 	"github.com/nokia/restful/trace/tracer"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var isTraced = true
 var serverName = ""
+var spanNameFunc = defaultSpanName
+var spanAttributesFunc func(r *http.Request) []attribute.KeyValue
+var routeSamplingUsed atomic.Bool // Any route has sampling set, so routes are to be matched in advance.
+var debugTraceHeader, debugTraceSecret string
 
 // SetOTel enables/disables Open Telemetry. By default it is disabled.
 // Trace provider can be set, when enabling.
@@ -21,6 +48,15 @@ func SetOTel(enabled bool, tp *sdktrace.TracerProvider) {
 	defaultClient = NewClient()
 }
 
+// SetOTelResource sets the service name, version, deployment environment and further resource attributes of the exported spans, metrics and logs.
+// Empty values are not set. By default the service name is taken from OTEL_SERVICE_NAME environment variable, or from the executable name.
+// Call it before SetOTelGrpc, SetOTelHTTP and the like.
+//
+//	restful.SetOTelResource("payments", version, os.Getenv("ENVIRONMENT"))
+func SetOTelResource(serviceName, serviceVersion, environment string, attrs ...attribute.KeyValue) {
+	tracer.SetOTelResource(serviceName, serviceVersion, environment, attrs...)
+}
+
 // SetOTelGrpc enables Open Telemetry.
 // Activates trace export to the OTLP gRPC collector target address defined.
 // Port is 4317, unless defined otherwise in provided target string.
@@ -34,6 +70,179 @@ func SetOTelGrpc(target string, fraction float64) error {
 	return tracer.SetOTelGrpc(target, fraction)
 }
 
+// SetOTelHTTP enables Open Telemetry.
+// Activates trace export to the OTLP HTTP/protobuf collector target URL defined, e.g. "http://collector:4318/v1/traces".
+//
+// Fraction tells the fraction of spans to report, unless parent is sampled. See SetOTelGrpc.
+//
+// Further exporter options can be provided, e.g. otlptracehttp.WithProxy or otlptracehttp.WithTLSClientConfig.
+func SetOTelHTTP(target string, fraction float64, opts ...otlptracehttp.Option) error {
+	return tracer.SetOTelHTTP(target, fraction, opts...)
+}
+
+// SetOTelSampler sets the sampler of new traces used by SetOTelGrpc and SetOTelHTTP, instead of the fraction based one.
+// E.g. tracer.NewRateLimitingSampler(100) or tracer.NewAdaptiveSampler(100).
+// Call it before SetOTelGrpc or SetOTelHTTP.
+func SetOTelSampler(sampler sdktrace.Sampler) {
+	tracer.SetOTelSampler(sampler)
+}
+
+// SetOTelSpanFilter sets a filter deciding on span export at span end, used by SetOTelGrpc and SetOTelHTTP.
+// E.g. tracer.KeepSlowOrErrorSpans(time.Second) keeps slow and failed spans, even if not selected by the sampler.
+// Call it before SetOTelGrpc or SetOTelHTTP.
+func SetOTelSpanFilter(filter tracer.SpanFilter) {
+	tracer.SetOTelSpanFilter(filter)
+}
+
+// SetOTelMetrics enables/disables Open Telemetry metrics. By default it is disabled.
+// If enabled, servers emit http.server.request.duration, and new clients emit http.client.request.duration histograms, among others.
+// See ServerMetrics and Client.Metrics.
+// Meter provider can be set with a reader you need, e.g. a Prometheus exporter. If not set, the global meter provider is used.
+func SetOTelMetrics(enabled bool, mp *sdkmetric.MeterProvider) {
+	tracer.SetOTelMetrics(enabled, mp)
+	defaultClient = NewClient()
+}
+
+// SetOTelMetricsGrpc enables Open Telemetry metrics.
+// Activates metric export to the OTLP gRPC collector target address defined, every interval.
+// Port is 4317, unless defined otherwise in provided target string.
+// Interval 0 means the default 60s.
+func SetOTelMetricsGrpc(target string, interval time.Duration) error {
+	if err := tracer.SetOTelMetricsGrpc(target, interval); err != nil {
+		return err
+	}
+	defaultClient = NewClient()
+	return nil
+}
+
+// SetOTelMetricsHTTP enables Open Telemetry metrics.
+// Activates metric export to the OTLP HTTP/protobuf collector target URL defined, e.g. "http://collector:4318/v1/metrics", every interval.
+// Interval 0 means the default 60s.
+//
+// Further exporter options can be provided, e.g. otlpmetrichttp.WithProxy or otlpmetrichttp.WithTLSClientConfig.
+func SetOTelMetricsHTTP(target string, interval time.Duration, opts ...otlpmetrichttp.Option) error {
+	if err := tracer.SetOTelMetricsHTTP(target, interval, opts...); err != nil {
+		return err
+	}
+	defaultClient = NewClient()
+	return nil
+}
+
+// SetOTelLogsGrpc activates export of restful logging and logrus standard logger records to the OTLP gRPC collector target address defined.
+// Port is 4317, unless defined otherwise in provided target string.
+// Records logged with a context, e.g. logging.FromContext(ctx).InfoContext(ctx, "..."), are correlated to the trace of the request.
+func SetOTelLogsGrpc(target string) error {
+	return tracer.SetOTelLogsGrpc(target)
+}
+
+// SetOTelLogsHTTP activates export of restful logging and logrus standard logger records to the OTLP HTTP/protobuf collector target URL defined,
+// e.g. "http://collector:4318/v1/logs".
+//
+// Further exporter options can be provided, e.g. otlploghttp.WithProxy or otlploghttp.WithTLSClientConfig.
+func SetOTelLogsHTTP(target string, opts ...otlploghttp.Option) error {
+	return tracer.SetOTelLogsHTTP(target, opts...)
+}
+
+// SetDebugTraceHeader sets a request header that forces the trace of the request to be sampled, if OpenTelemetry is enabled.
+// The sampling decision is propagated to the services called, so the full trace can be captured for a specific call.
+// The header value must match the shared secret. If the secret is empty, any non-empty value is accepted, which is not recommended.
+// Empty header disables the feature, that is the default.
+//
+//	restful.SetDebugTraceHeader("X-Debug-Trace", os.Getenv("DEBUG_TRACE_SECRET"))
+func SetDebugTraceHeader(header, secret string) {
+	debugTraceHeader = header
+	debugTraceSecret = secret
+}
+
+func isDebugTraced(r *http.Request) bool {
+	if debugTraceHeader == "" {
+		return false
+	}
+	value := r.Header.Get(debugTraceHeader)
+	if debugTraceSecret == "" {
+		return value != ""
+	}
+	return subtle.ConstantTimeCompare([]byte(value), []byte(debugTraceSecret)) == 1
+}
+
+// TraceResponseW3C is the W3C Trace Context Level 2 response header. See Router's TraceResponseHeader.
+const TraceResponseW3C = "traceresponse"
+
+func traceResponsePre(header string) MonitorFuncPre {
+	return func(w http.ResponseWriter, r *http.Request) *http.Request {
+		var traceID, spanID string
+		sampled := false
+		if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.IsValid() {
+			traceID, spanID, sampled = spanCtx.TraceID().String(), spanCtx.SpanID().String(), spanCtx.IsSampled()
+		} else {
+			traceData := traceFromContextOrRequestOrRandom(r)
+			if !traceData.IsReceived() { // Make handlers use the same trace.
+				traceData.SetHeader(r.Header)
+			}
+			traceID, spanID = traceData.TraceID(), traceData.SpanID()
+			if spanID == "" {
+				spanID = tracecommon.NewSpanID()
+			}
+		}
+		if traceID == "" {
+			return nil
+		}
+
+		if strings.EqualFold(header, TraceResponseW3C) {
+			flags := "00"
+			if sampled {
+				flags = "01"
+			}
+			w.Header().Set(header, "00-"+padHex(traceID, 32)+"-"+padHex(spanID, 16)+"-"+flags)
+		} else {
+			w.Header().Set(header, traceID)
+		}
+		return nil
+	}
+}
+
+// padHex pads hex string with leading zeros, e.g. for 64-bit B3 trace IDs.
+func padHex(s string, length int) string {
+	if len(s) >= length {
+		return s
+	}
+	return strings.Repeat("0", length-len(s)) + s
+}
+
+// ContextWithTraceMap returns a context holding the trace data and baggage of a map of message headers, e.g. of Kafka or NATS.
+// Client calls made with the returned context propagate that trace, keeping traces intact across async hops.
+// If no trace data is found, then a new trace is started.
+//
+//	ctx := restful.ContextWithTraceMap(context.Background(), msg.Headers)
+//	err := restful.Post(ctx, target, msg.Body, nil)
+func ContextWithTraceMap(ctx context.Context, m map[string]string) context.Context {
+	r := (&http.Request{Header: tracer.MapToHeader(m), URL: &url.URL{}}).WithContext(ctx)
+	return lambda.AddLambdaToContext(ctx, lambda.L(lambda.NewRequestCtx(nil, r)))
+}
+
+// InjectTraceMap puts the trace data and baggage of the context into a map of message headers, e.g. of Kafka or NATS.
+// Keys are lowercase, e.g. "traceparent".
+//
+//	headers := map[string]string{}
+//	restful.InjectTraceMap(ctx, headers)
+func InjectTraceMap(ctx context.Context, m map[string]string) {
+	if tracer.GetOTel() && trace.SpanContextFromContext(ctx).IsValid() {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(m))
+		return
+	}
+
+	header := http.Header{}
+	if t := traceFromContext(ctx); t != nil {
+		if injector, ok := t.(interface{ InjectMap(map[string]string) }); ok {
+			injector.InjectMap(m)
+		} else {
+			t.SetHeader(header)
+		}
+	}
+	tracer.SetBaggageHeader(ctx, header)
+	tracer.HeaderToMap(header, m)
+}
+
 // SetTrace can enable/disable HTTP tracing.
 // By default trace header generation and propagation is enabled.
 func SetTrace(b bool) {
@@ -46,9 +255,263 @@ func SetServerName(s string) {
 	serverName = s
 }
 
-func spanNameFormatter(operation string, req *http.Request) string {
+// SetSpanNameFormatter sets a function that makes the name of the server spans.
+// Parameter routeTemplate is the path template of the Router route matched, e.g. "/users/{id}", or empty if none matched yet.
+// The default formatter makes "METHOD /route/{template}" names, or just "METHOD" if no route matched.
+// Server name is prepended, if set. See SetServerName.
+// Route's SpanName overrides the formatter.
+func SetSpanNameFormatter(f func(r *http.Request, routeTemplate string) string) {
+	if f == nil {
+		f = defaultSpanName
+	}
+	spanNameFunc = f
+}
+
+// SetSpanAttributes sets a function that returns extra attributes of the server spans, e.g. tenant or API version.
+// See also Route's SpanAttributes.
+func SetSpanAttributes(f func(r *http.Request) []attribute.KeyValue) {
+	spanAttributesFunc = f
+}
+
+func defaultSpanName(r *http.Request, routeTemplate string) string {
+	name := r.Method
+	if routeTemplate != "" {
+		name += " " + routeTemplate
+	}
 	if serverName != "" {
-		return serverName + ":" + req.URL.Path
+		return serverName + ":" + name
+	}
+	return name
+}
+
+func spanNameFormatter(operation string, req *http.Request) string {
+	return spanNameFunc(req, "")
+}
+
+type routeSpan struct {
+	name     string
+	attrs    []attribute.KeyValue
+	sampling *float64
+}
+
+// routeSampling overrides the sampling of the server span of debug traced requests, probes and routes with sampling settings.
+// Applied before the server span is started, so routes are matched in advance.
+func routeSampling(h http.Handler, handler http.Handler) http.Handler {
+	router, _ := handler.(*Router)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isDebugTraced(r) {
+			r = r.WithContext(tracer.ContextWithSampling(r.Context(), 1))
+		} else if r.URL.Path == LivenessProbePath || r.URL.Path == HealthCheckPath || r.URL.Path == ReadinessProbePath {
+			r = r.WithContext(tracer.ContextWithSampling(r.Context(), 0))
+		} else if router != nil && routeSamplingUsed.Load() {
+			var match mux.RouteMatch
+			if router.router.Match(r, &match) && match.Route != nil {
+				if settings := routeSettingsOf(match.Route); settings != nil && settings.span.sampling != nil {
+					r = r.WithContext(tracer.ContextWithSampling(r.Context(), *settings.span.sampling))
+				}
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// hostAttributes returns server.address and server.port attributes of a host[:port] string.
+func hostAttributes(host string) []attribute.KeyValue {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		if port, err := strconv.Atoi(p); err == nil {
+			return []attribute.KeyValue{semconv.ServerAddress(h), semconv.ServerPort(port)}
+		}
+		return []attribute.KeyValue{semconv.ServerAddress(h)}
+	}
+	return []attribute.KeyValue{semconv.ServerAddress(host)}
+}
+
+// setSpanStatusCode sets the response status code attribute of the span, and marks the span as error on 5xx.
+func setSpanStatusCode(span trace.Span, statusCode int) {
+	span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+	if statusCode >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+}
+
+// recordSpanError records the error returned by a handler on the span of the request, as an exception event with the error chain.
+// Span status is set to error if the response status is 5xx.
+func recordSpanError(r *http.Request, err error, statusCode int) {
+	span := trace.SpanFromContext(r.Context())
+	if !span.IsRecording() {
+		return
+	}
+
+	var chain []string
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
+	}
+	var opts []trace.EventOption
+	if len(chain) > 0 {
+		opts = append(opts, trace.WithAttributes(attribute.StringSlice("exception.chain", chain)))
+	}
+	span.RecordError(err, opts...)
+	if statusCode >= 500 {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// serverSpanAttributes adds HTTP semantic convention attributes to the server span started by otelhttp.
+func serverSpanAttributes(h http.Handler) http.Handler {
+	return Monitor(h,
+		func(w http.ResponseWriter, r *http.Request) *http.Request {
+			span := trace.SpanFromContext(r.Context())
+			if span.IsRecording() {
+				span.SetAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLPath(r.URL.Path))
+				span.SetAttributes(hostAttributes(r.Host)...)
+				if spanAttributesFunc != nil {
+					span.SetAttributes(spanAttributesFunc(r)...)
+				}
+			}
+			return nil
+		},
+		func(w http.ResponseWriter, r *http.Request, statusCode int) {
+			span := trace.SpanFromContext(r.Context())
+			if span.IsRecording() {
+				if statusCode == 0 {
+					statusCode = http.StatusOK
+				}
+				setSpanStatusCode(span, statusCode)
+			}
+		})
+}
+
+// routeSpanAttributes is a mux middleware adding the matched route template to the server span as http.route,
+// and renaming the span according to the route. The route template is passed to server metrics, too.
+func routeSpanAttributes(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		var tmpl string
+		if route != nil {
+			tmpl, _ = route.GetPathTemplate()
+			setServerMetricsRoute(r, tmpl)
+			setRouteTemplate(r, tmpl)
+		}
+		if span := trace.SpanFromContext(r.Context()); span.IsRecording() {
+			if route != nil {
+				if tmpl != "" {
+					span.SetAttributes(semconv.HTTPRoute(tmpl))
+				}
+				name := spanNameFunc(r, tmpl)
+				if settings := routeSettingsOf(route); settings != nil {
+					span.SetAttributes(settings.span.attrs...)
+					if settings.span.name != "" {
+						name = settings.span.name
+					}
+				}
+				span.SetName(name)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// spanAttributesTransport adds HTTP semantic convention attributes to the client span started by otelhttp.
+type spanAttributesTransport struct {
+	http.RoundTripper
+}
+
+func newOTelTransport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(spanAttributesTransport{RoundTripper: rt}, otelhttp.WithFilter(clientSpanAllowed))
+}
+
+const tracerScope = "github.com/nokia/restful"
+
+type noClientSpanCtxKeyType string
+
+const noClientSpanCtxName = noClientSpanCtxKeyType("restfulNoClientSpan")
+
+// clientSpanAllowed tells otelhttp whether to create a client span, i.e. if not suppressed by the span filter of the Client.
+func clientSpanAllowed(req *http.Request) bool {
+	return req.Context().Value(noClientSpanCtxName) == nil
+}
+
+// startAttemptsSpan applies the span filter of the client, and starts a span for the request of a retrying client,
+// so that the client spans of the attempts are its children.
+// Returned function ends the span.
+func (c *Client) startAttemptsSpan(req *http.Request) (*http.Request, func(*http.Response, error)) {
+	noop := func(*http.Response, error) {}
+	if !isTraced || !tracer.GetOTel() {
+		return req, noop
+	}
+	if c.spanFilter != nil && !c.spanFilter(req) {
+		return req.WithContext(context.WithValue(req.Context(), noClientSpanCtxName, true)), noop
+	}
+	if c.retries <= 0 {
+		return req, noop
+	}
+
+	ctx, span := otel.Tracer(tracerScope).Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.URLFull(req.URL.Redacted())))
+	return req.WithContext(ctx), func(resp *http.Response, err error) {
+		if err != nil {
+			span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			setSpanStatusCode(span, resp.StatusCode)
+		}
+		span.End()
+	}
+}
+
+// RoundTrip sends the request, setting span attributes.
+func (t spanAttributesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !clientSpanAllowed(req) { // Propagate the parent span.
+		otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	span := trace.SpanFromContext(req.Context())
+	setClientMetricsSpan(req, span.SpanContext())
+	if span.IsRecording() {
+		span.SetAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.URLFull(req.URL.Redacted()))
+		span.SetAttributes(hostAttributes(req.URL.Host)...)
+		if info, ok := req.Context().Value(retryInfoCtxName).(retryInfo); ok {
+			span.SetAttributes(semconv.HTTPRequestResendCount(info.attempt))
+			span.AddEvent("retry", trace.WithAttributes(
+				semconv.HTTPRequestResendCount(info.attempt),
+				attribute.Int64("retry.wait_ms", info.wait.Milliseconds()),
+				attribute.String("retry.reason", info.reason)))
+		}
+	}
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if span.IsRecording() {
+		if err != nil {
+			span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+		} else {
+			setSpanStatusCode(span, resp.StatusCode)
+		}
+	}
+	return resp, err
+}
+
+type retryInfoCtxKeyType string
+
+const retryInfoCtxName = retryInfoCtxKeyType("restfulRetryInfo")
+
+type retryInfo struct {
+	attempt int
+	wait    time.Duration
+	reason  string
+}
+
+// withRetryInfo stores retry info in the request context, so that the span of the attempt can tell why and when the request was resent.
+func withRetryInfo(req *http.Request, attempt int, wait time.Duration, reason string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), retryInfoCtxName, retryInfo{attempt: attempt, wait: wait, reason: reason}))
+}
+
+// retryReason tells why a request is retried, e.g. "503" or "connection_refused".
+func retryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return errorType(err)
+	}
+	if resp != nil {
+		return strconv.Itoa(resp.StatusCode)
 	}
-	return req.URL.Path
+	return "unknown"
 }
@@ -5,11 +5,12 @@
 package traceb3
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
+	"github.com/nokia/restful/logging"
 	"github.com/nokia/restful/trace/tracecommon"
-	log "github.com/sirupsen/logrus"
 )
 
 /* Zipkin (B3) and LightStep trace data.
@@ -29,6 +30,16 @@ const (
 	headerLightStepSpanC = "X-Ot-Span-Context"
 )
 
+var (
+	// MultiHeader tells whether random trace data is injected as X-B3-* multi headers, instead of the single b3 header.
+	// Received trace data is propagated in the style received. Default is false.
+	MultiHeader = false
+
+	// TraceID64Bit tells whether random trace data has 64-bit trace IDs, instead of 128-bit ones.
+	// Useful for older Zipkin deployments rejecting 128-bit IDs. Default is false.
+	TraceID64Bit = false
+)
+
 // TraceB3 HTTP trace object of B3 or X-B3 kind.
 type TraceB3 struct {
 	traceID, parentSpanID, spanID, sampled, flags, requestID, spanCtx string
@@ -102,14 +113,23 @@ func NewFromRequest(r *http.Request) *TraceB3 {
 }
 
 // NewRandom creates new TraceB3 object with random content.
+// See MultiHeader and TraceID64Bit for the style.
 func NewRandom() *TraceB3 {
-	return newTraceB3WithID(tracecommon.NewTraceID(), log.IsLevelEnabled(log.TraceLevel))
+	traceID := tracecommon.NewTraceID()
+	if TraceID64Bit {
+		traceID = tracecommon.NewSpanID()
+	}
+	return newTraceB3WithID(traceID, logging.Logger().Enabled(context.Background(), logging.LevelTrace))
 }
 
 func newTraceB3WithID(traceID string, debug bool) *TraceB3 {
-	b3 := TraceB3{traceID: traceID, singleLine: true, random: true}
+	b3 := TraceB3{traceID: traceID, singleLine: !MultiHeader, random: true}
 	if debug {
-		b3.sampled = "d"
+		if b3.singleLine {
+			b3.sampled = "d"
+		} else {
+			b3.flags = "1"
+		}
 	}
 	return &b3
 }
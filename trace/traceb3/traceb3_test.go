@@ -51,6 +51,22 @@ func TestRandom(t *testing.T) {
 	assert.Len(trace.TraceID(), 32)
 }
 
+func TestRandomStyle(t *testing.T) {
+	assert := assert.New(t)
+	MultiHeader, TraceID64Bit = true, true
+	defer func() { MultiHeader, TraceID64Bit = false, false }()
+
+	trace := NewRandom()
+	assert.Len(trace.TraceID(), 16)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	trace.Span(r)
+	assert.Equal(trace.TraceID(), r.Header.Get("X-B3-TraceId"))
+	assert.Len(r.Header.Get("X-B3-SpanId"), 16)
+	assert.Empty(r.Header.Get("b3"))
+
+	assert.Equal("1", newTraceB3WithID(trace.TraceID(), true).flags)
+}
+
 func TestEmpty(t *testing.T) {
 	assert.Nil(t, NewFromRequest(&http.Request{}))
 	assert.Nil(t, NewFromRequest(&http.Request{Header: http.Header{}}))
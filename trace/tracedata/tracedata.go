@@ -31,3 +31,13 @@ type TraceData interface {
 	// SpanID returns the span ID of the trace data.
 	SpanID() string
 }
+
+// TraceStater is implemented by trace data supporting W3C tracestate, i.e. vendor specific key/value pairs.
+// See https://www.w3.org/TR/trace-context/#tracestate-header
+type TraceStater interface {
+	// TraceState returns the tracestate header value.
+	TraceState() string
+
+	// SetTraceState sets the tracestate header value, propagated on spans.
+	SetTraceState(state string)
+}
@@ -0,0 +1,120 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracejaeger
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nokia/restful/trace/tracecommon"
+)
+
+/* Jaeger native propagation.
+   https://www.jaegertracing.io/docs/latest/client-libraries/#propagation-format
+*/
+
+const (
+	headerUberTraceID = "Uber-Trace-Id"
+	headerBaggagePfx  = "Uberctx-"
+)
+
+// TraceJaeger HTTP trace object of Jaeger's uber-trace-id kind.
+type TraceJaeger struct {
+	traceID, spanID, parentSpanID, flags string
+	baggage                              http.Header
+}
+
+// NewFromRequest creates new TraceJaeger object. If there is no trace data in request, then returns nil.
+func NewFromRequest(r *http.Request) *TraceJaeger {
+	if r.Header == nil {
+		return nil
+	}
+
+	value := r.Header.Get(headerUberTraceID)
+	if value == "" {
+		return nil
+	}
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+
+	fields := strings.Split(value, ":")
+	if len(fields) != 4 || !isHex(fields[0], 32) || !isHex(fields[1], 16) || fields[3] == "" {
+		return nil
+	}
+
+	j := &TraceJaeger{traceID: fields[0], spanID: fields[1], parentSpanID: fields[2], flags: fields[3]}
+	for name, values := range r.Header {
+		if strings.HasPrefix(name, headerBaggagePfx) {
+			if j.baggage == nil {
+				j.baggage = make(http.Header)
+			}
+			j.baggage[name] = values
+		}
+	}
+	return j
+}
+
+func isHex(s string, maxLen int) bool {
+	if s == "" || len(s) > maxLen {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func (j *TraceJaeger) span() *TraceJaeger {
+	span := *j
+	span.parentSpanID = j.spanID
+	span.spanID = tracecommon.NewSpanID()
+	return &span
+}
+
+// Span spans the existing trace data and puts that into the request.
+// Returns the updated request and a trace string for logging.
+// Does not change the input trace data.
+func (j *TraceJaeger) Span(r *http.Request) (*http.Request, string) {
+	span := j.span()
+	span.SetHeader(r.Header)
+	return r, span.String()
+}
+
+// SetHeader sets request headers according to the trace data.
+// Input headers object must not be nil.
+func (j *TraceJaeger) SetHeader(headers http.Header) {
+	parent := j.parentSpanID
+	if parent == "" {
+		parent = "0"
+	}
+	headers.Set(headerUberTraceID, j.traceID+":"+j.spanID+":"+parent+":"+j.flags)
+	for name, values := range j.baggage {
+		headers[name] = values
+	}
+}
+
+// IsReceived tells whether trace data was received (parsed from a request) or a random one.
+func (j *TraceJaeger) IsReceived() bool {
+	return true // Must have been created by NewFromRequest.
+}
+
+// String makes a log string from trace data.
+func (j *TraceJaeger) String() string {
+	return j.traceID + "-" + j.spanID
+}
+
+// TraceID returns the trace ID of the trace data.
+func (j *TraceJaeger) TraceID() string {
+	return j.traceID
+}
+
+// SpanID returns the span ID of the trace data.
+func (j *TraceJaeger) SpanID() string {
+	return j.spanID
+}
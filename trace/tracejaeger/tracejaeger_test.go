@@ -0,0 +1,45 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracejaeger
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaeger(t *testing.T) {
+	assert := assert.New(t)
+	r, _ := http.NewRequest("POST", "", nil)
+	r.Header.Set("uber-trace-id", "4bf92f3577b34da6a3ce929d0e0e4736%3A00f067aa0ba902b7%3A0%3A1")
+	r.Header.Set("uberctx-tenant", "a")
+	trace := NewFromRequest(r)
+	assert.True(trace.IsReceived())
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", trace.TraceID())
+	assert.Equal("00f067aa0ba902b7", trace.SpanID())
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", trace.String())
+
+	headers := http.Header{}
+	trace.SetHeader(headers)
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", headers.Get("uber-trace-id"))
+
+	out, _ := http.NewRequest("GET", "", nil)
+	_, span := trace.Span(out)
+	assert.NotContains(span, "00f067aa0ba902b7")
+	fields := strings.Split(out.Header.Get("uber-trace-id"), ":")
+	assert.Equal([]string{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", "1"}, []string{fields[0], fields[2], fields[3]})
+	assert.Equal("a", out.Header.Get("uberctx-tenant"))
+}
+
+func TestBad(t *testing.T) {
+	assert.Nil(t, NewFromRequest(&http.Request{}))
+	for _, value := range []string{"", "1:2:3", "x:1:0:1", "1::0:1", "1:2:0:"} {
+		r, _ := http.NewRequest("POST", "", nil)
+		r.Header.Set("uber-trace-id", value)
+		assert.Nil(t, NewFromRequest(r), value)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"context"
 	"net/http"
 
-	"go.opentelemetry.io/contrib/propagators/b3"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -63,12 +62,11 @@ func NewRandom() *TraceOTel {
 	return &TraceOTel{ctx: ctx}
 }
 
-// TraceHeadersToContext maps trace headers in request to context.
+// TraceHeadersToContext maps trace headers in request to context, using the global propagator.
 // If there were no tracing headers to be propagated, the original context is returned.
 // The returned bool indicates if the original and the new contexts are the same.
 func TraceHeadersToContext(parentCtx context.Context, r *http.Request) (context.Context, bool) {
-	prop := b3.New()
-	ctx := prop.Extract(parentCtx, propagation.HeaderCarrier(r.Header))
+	ctx := otel.GetTextMapPropagator().Extract(parentCtx, propagation.HeaderCarrier(r.Header))
 	spanCtx := trace.SpanContextFromContext(ctx)
 	if spanCtx.IsValid() {
 		return ctx, true
@@ -130,3 +128,28 @@ func (t *TraceOTel) TraceID() string {
 func (t *TraceOTel) SpanID() string {
 	return trace.SpanContextFromContext(t.ctx).SpanID().String()
 }
+
+// Context returns the context holding the span context of the trace data.
+func (t *TraceOTel) Context() context.Context {
+	return t.ctx
+}
+
+// TraceState returns the tracestate of the span context.
+func (t *TraceOTel) TraceState() string {
+	return trace.SpanContextFromContext(t.ctx).TraceState().String()
+}
+
+// SetTraceState sets the tracestate of the span context, propagated on spans.
+// Invalid tracestate is ignored.
+func (t *TraceOTel) SetTraceState(state string) {
+	ts, err := trace.ParseTraceState(state)
+	if err != nil {
+		return
+	}
+	spanCtx := trace.SpanContextFromContext(t.ctx).WithTraceState(ts)
+	if spanCtx.IsRemote() {
+		t.ctx = trace.ContextWithRemoteSpanContext(t.ctx, spanCtx)
+	} else {
+		t.ctx = trace.ContextWithSpanContext(t.ctx, spanCtx)
+	}
+}
@@ -5,6 +5,8 @@
 package traceparent
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -23,24 +25,84 @@ type TraceParent struct {
 	state  string
 }
 
-// NewFromRequest creates new TraceParent object. If there is no trace data in request, then returns nil.
+// Parse errors.
+var (
+	ErrFormat   = errors.New("traceparent: invalid format")
+	ErrVersion  = errors.New("traceparent: invalid version")
+	ErrTraceID  = errors.New("traceparent: invalid trace ID")
+	ErrParentID = errors.New("traceparent: invalid parent ID")
+	ErrFlags    = errors.New("traceparent: invalid trace flags")
+)
+
+// versionLen is the length of the fields of version 00, that all versions start with.
+const versionLen = 55
+
+// NewFromRequest creates new TraceParent object. If there is no valid trace data in request, then returns nil.
 func NewFromRequest(r *http.Request) *TraceParent {
 	if r.Header == nil {
 		return nil
 	}
 
-	return newTraceParentFromHeaderValue(r.Header.Get(headerTraceParent), r.Header.Get(headerTraceState))
+	p, _ := Parse(r.Header.Get(headerTraceParent), r.Header.Get(headerTraceState))
+	return p
 }
 
-func newTraceParentFromHeaderValue(traceparent, tracestate string) *TraceParent {
-	parent := strings.Split(traceparent, "-")
-	if len(parent) != 4 {
-		return nil
+// Parse parses traceparent and tracestate header values.
+// Versions higher than 00 are accepted, as W3C Trace Context requires: fields of version 00 are parsed, the rest is ignored.
+// Trace data is propagated as version 00.
+// Returns an error wrapping one of ErrFormat, ErrVersion, ErrTraceID, ErrParentID or ErrFlags if invalid.
+func Parse(traceparent, tracestate string) (*TraceParent, error) {
+	if len(traceparent) < versionLen {
+		return nil, fmt.Errorf("%w: length %d", ErrFormat, len(traceparent))
 	}
-	if parent[0] != "00" {
-		return nil
+	version := traceparent[0:2]
+	if !isHex(version) || version == "ff" {
+		return nil, fmt.Errorf("%w: %q", ErrVersion, version)
+	}
+	if err := checkFormat(traceparent, version); err != nil {
+		return nil, err
+	}
+
+	traceID, parentID, flags := traceparent[3:35], traceparent[36:52], traceparent[53:55]
+	if !isHex(traceID) || isZero(traceID) {
+		return nil, fmt.Errorf("%w: %q", ErrTraceID, traceID)
+	}
+	if !isHex(parentID) || isZero(parentID) {
+		return nil, fmt.Errorf("%w: %q", ErrParentID, parentID)
+	}
+	if !isHex(flags) {
+		return nil, fmt.Errorf("%w: %q", ErrFlags, flags)
+	}
+	return &TraceParent{parent: []string{"00", traceID, parentID, flags}, state: tracestate}, nil
+}
+
+// checkFormat checks the length and the separators of the fields of traceparent of the version.
+func checkFormat(traceparent, version string) error {
+	if version == "00" && len(traceparent) != versionLen {
+		return fmt.Errorf("%w: length %d", ErrFormat, len(traceparent))
+	}
+	if len(traceparent) > versionLen && traceparent[versionLen] != '-' {
+		return fmt.Errorf("%w: no separator after flags", ErrFormat)
+	}
+	if traceparent[2] != '-' || traceparent[35] != '-' || traceparent[52] != '-' {
+		return fmt.Errorf("%w: separators", ErrFormat)
+	}
+	return nil
+}
+
+// isHex tells whether s consists of lowercase hex digits.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
 	}
-	return &TraceParent{parent: parent, state: tracestate}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
 }
 
 func (p *TraceParent) span() *TraceParent {
@@ -91,3 +153,13 @@ func (p *TraceParent) SpanID() string {
 	}
 	return ""
 }
+
+// TraceState returns the tracestate header value.
+func (p *TraceParent) TraceState() string {
+	return p.state
+}
+
+// SetTraceState sets the tracestate header value, propagated on spans.
+func (p *TraceParent) SetTraceState(state string) {
+	p.state = state
+}
@@ -37,3 +37,52 @@ func TestBad(t *testing.T) {
 	trace := NewFromRequest(r)
 	assert.Nil(t, trace)
 }
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	const traceID, parentID = "0af7651916cd43dd8448eb211c80319c", "b9c7c989f97918e1"
+
+	p, err := Parse("01-"+traceID+"-"+parentID+"-09-future-fields", "k=v")
+	if assert.NoError(err) {
+		assert.Equal(traceID, p.TraceID())
+		assert.Equal(parentID, p.SpanID())
+		assert.Equal("k=v", p.TraceState())
+		assert.Equal("00-"+traceID+"-"+parentID+"-09", p.String()) // Propagated as version 00.
+	}
+	_, err = Parse("cc-"+traceID+"-"+parentID+"-01", "")
+	assert.NoError(err)
+
+	for value, expected := range map[string]error{
+		"": ErrFormat,
+		"00-" + traceID + "-" + parentID + "-01-":                 ErrFormat,
+		"01-" + traceID + "-" + parentID + "-01x":                 ErrFormat,
+		"00_" + traceID + "-" + parentID + "-01":                  ErrFormat,
+		"ff-" + traceID + "-" + parentID + "-01":                  ErrVersion,
+		"0X-" + traceID + "-" + parentID + "-01":                  ErrVersion,
+		"00-0AF7651916CD43DD8448EB211C80319C-" + parentID + "-01": ErrTraceID,
+		"00-00000000000000000000000000000000-" + parentID + "-01": ErrTraceID,
+		"00-" + traceID + "-0000000000000000-01":                  ErrParentID,
+		"00-" + traceID + "-" + parentID + "-0g":                  ErrFlags,
+	} {
+		p, err := Parse(value, "")
+		assert.Nil(p, value)
+		assert.ErrorIs(err, expected, value)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("00-0af7651916cd43dd8448eb211c80319c-b9c7c989f97918e1-01")
+	f.Add("01-0af7651916cd43dd8448eb211c80319c-b9c7c989f97918e1-01-extra")
+	f.Add("ff-0af7651916cd43dd8448eb211c80319c-b9c7c989f97918e1-01")
+	f.Add("hello")
+	f.Fuzz(func(t *testing.T, value string) {
+		p, err := Parse(value, "")
+		if err != nil {
+			assert.Nil(t, p)
+			return
+		}
+		again, err := Parse(p.String(), "") // Propagated value is valid.
+		assert.NoError(t, err)
+		assert.Equal(t, p.String(), again.String())
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// BaggageHeader is the W3C Baggage header name.
+const BaggageHeader = "Baggage"
+
+// BaggageFromRequest parses the W3C Baggage header(s) of the request.
+// Invalid members are dropped. Returns empty baggage if not found.
+func BaggageFromRequest(r *http.Request) baggage.Baggage {
+	var b baggage.Baggage
+	for _, value := range r.Header.Values(BaggageHeader) {
+		for _, member := range strings.Split(value, ",") {
+			m, err := baggage.Parse(member)
+			if err != nil || m.Len() == 0 {
+				continue
+			}
+			b, _ = b.SetMember(m.Members()[0])
+		}
+	}
+	return b
+}
+
+// BaggageToContext adds the baggage of the request to the context, unless the context has baggage already.
+func BaggageToContext(ctx context.Context, r *http.Request) context.Context {
+	if baggage.FromContext(ctx).Len() > 0 {
+		return ctx
+	}
+	if b := BaggageFromRequest(r); b.Len() > 0 {
+		return baggage.ContextWithBaggage(ctx, b)
+	}
+	return ctx
+}
+
+// SetBaggageHeader sets W3C Baggage header according to the baggage of the context.
+// Does not change the header if there is no baggage or the header is set already.
+func SetBaggageHeader(ctx context.Context, header http.Header) {
+	if header.Get(BaggageHeader) != "" {
+		return
+	}
+	if b := baggage.FromContext(ctx); b.Len() > 0 {
+		header.Set(BaggageHeader, b.String())
+	}
+}
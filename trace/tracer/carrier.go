@@ -0,0 +1,51 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nokia/restful/trace/traceotel"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// NewFromMap creates new tracer object from a map of message headers, e.g. of Kafka or NATS. Returns nil if not found.
+// Keys are case insensitive, as HTTP headers are.
+func NewFromMap(m map[string]string) *Tracer {
+	return NewFromRequest(&http.Request{Header: MapToHeader(m)})
+}
+
+// InjectMap puts the trace data into a map of message headers, e.g. of Kafka or NATS.
+// Keys are lowercase, e.g. "traceparent".
+func (t *Tracer) InjectMap(m map[string]string) {
+	if o, ok := t.traceData.(*traceotel.TraceOTel); ok {
+		otel.GetTextMapPropagator().Inject(o.Context(), propagation.MapCarrier(m))
+		return
+	}
+	header := http.Header{}
+	t.SetHeader(header)
+	HeaderToMap(header, m)
+}
+
+// MapToHeader converts a map of message headers to HTTP header.
+func MapToHeader(m map[string]string) http.Header {
+	header := make(http.Header, len(m))
+	for k, v := range m {
+		header.Set(k, v)
+	}
+	return header
+}
+
+// HeaderToMap puts HTTP header into a map of message headers, with lowercase keys.
+// Only the first value of a header is kept.
+func HeaderToMap(header http.Header, m map[string]string) {
+	for k, v := range header {
+		if len(v) > 0 {
+			m[strings.ToLower(k)] = v[0]
+		}
+	}
+}
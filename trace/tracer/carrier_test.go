@@ -0,0 +1,29 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(NewFromMap(map[string]string{"key": "value"}))
+
+	tracer := NewFromMap(map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "tracestate": "rojo=1"})
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", tracer.TraceID())
+	m := map[string]string{}
+	tracer.InjectMap(m)
+	assert.Equal(map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "tracestate": "rojo=1"}, m)
+
+	SetOTel(true, nil)
+	defer SetOTel(false, nil)
+	tracer = NewFromMap(map[string]string{"X-B3-TraceId": "80f198ee56343ba864fe8b2a57d3eff7", "X-B3-SpanId": "e457b5a2e4d86bd1", "X-B3-Sampled": "1"})
+	m = map[string]string{}
+	tracer.InjectMap(m)
+	assert.Equal("00-80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-01", m["traceparent"])
+}
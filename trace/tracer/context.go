@@ -0,0 +1,37 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+
+	"github.com/nokia/restful/trace/traceotel"
+)
+
+type tracerCtxKeyType string
+
+const tracerCtxName = tracerCtxKeyType("restfulTracer")
+
+// NewContext returns a context holding the tracer.
+// Lambda handlers have that context automatically, so libraries deep in the call stack can access the tracer of the request.
+// Client functions called with the context propagate the trace.
+func NewContext(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerCtxName, t)
+}
+
+// FromContext returns the tracer stored in the context by NewContext.
+// If not found and Open Telemetry is enabled, returns a tracer of the span of the context, if any.
+// Returns nil otherwise.
+func FromContext(ctx context.Context) *Tracer {
+	if t, ok := ctx.Value(tracerCtxName).(*Tracer); ok && t != nil {
+		return t
+	}
+	if OtelEnabled {
+		if t := traceotel.NewFromContext(ctx); t != nil {
+			return &Tracer{traceData: t, received: true}
+		}
+	}
+	return nil
+}
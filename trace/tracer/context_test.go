@@ -0,0 +1,42 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(FromContext(context.Background()))
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	tr := NewFromRequest(r)
+	ctx := NewContext(context.Background(), tr)
+	assert.Equal(tr, FromContext(ctx))
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", FromContext(ctx).TraceID())
+
+	assert.Nil(FromContext(NewContext(context.Background(), nil)))
+}
+
+func TestContextOTel(t *testing.T) {
+	assert := assert.New(t)
+	tp := sdktrace.NewTracerProvider()
+	SetOTel(true, tp)
+	defer SetOTel(false, nil)
+
+	ctx, span := tp.Tracer("").Start(context.Background(), "test")
+	defer span.End()
+	if tr := FromContext(ctx); assert.NotNil(tr) {
+		assert.Equal(span.SpanContext().TraceID().String(), tr.TraceID())
+		assert.Equal(span.SpanContext().SpanID().String(), tr.SpanID())
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scopeName = "github.com/nokia/restful"
+
+// Link makes an OpenTelemetry span link to a span of another trace, e.g. of a queued message being processed.
+// Trace ID and span ID are hex strings, as in traceparent header. Returns error if those are invalid.
+// Links are used at StartSpan.
+func Link(traceID, spanID string, attrs ...attribute.KeyValue) (trace.Link, error) {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return trace.Link{}, err
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return trace.Link{}, err
+	}
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled, Remote: true})
+	return trace.Link{SpanContext: spanCtx, Attributes: attrs}, nil
+}
+
+// Link makes an OpenTelemetry span link to the span of the trace data.
+// Trace IDs shorter than 128 bits, e.g. of B3, are padded.
+// Returns a link with invalid span context if the IDs cannot be parsed. Such links are ignored at StartSpan.
+func (t *Tracer) Link(attrs ...attribute.KeyValue) trace.Link {
+	traceID := t.TraceID()
+	if len(traceID) < 32 {
+		traceID = strings.Repeat("0", 32-len(traceID)) + traceID
+	}
+	link, _ := Link(traceID, t.SpanID(), attrs...)
+	return link
+}
+
+// StartSpan starts an internal OpenTelemetry span, child of the span in ctx, if any, and linked to the spans of other traces.
+// Client calls made with the returned context are children of the new span.
+// The span must be ended by the caller. If OpenTelemetry is not enabled, the span is not recorded.
+//
+//	link, _ := tracer.Link(msg.TraceID, msg.SpanID)
+//	ctx, span := tracer.StartSpan(ctx, "process message", link)
+//	defer span.End()
+func StartSpan(ctx context.Context, name string, links ...trace.Link) (context.Context, trace.Span) {
+	var validLinks []trace.Link
+	for _, link := range links {
+		if link.SpanContext.IsValid() {
+			validLinks = append(validLinks, link)
+		}
+	}
+	return otel.Tracer(scopeName).Start(ctx, name, trace.WithLinks(validLinks...), trace.WithSpanKind(trace.SpanKindInternal))
+}
@@ -0,0 +1,48 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLink(t *testing.T) {
+	assert := assert.New(t)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-B3-TraceId", "463ac35c9f6413ad")
+	r.Header.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	b3Link := NewFromRequest(r).Link()
+	assert.Equal("0000000000000000463ac35c9f6413ad", b3Link.SpanContext.TraceID().String())
+
+	_, err := Link("bad", "b7ad6b7169203331")
+	assert.Error(err)
+	_, err = Link("0af7651916cd43dd8448eb211c80319c", "bad")
+	assert.Error(err)
+	link, err := Link("0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", attribute.String("messaging.system", "kafka"))
+	assert.NoError(err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+	ctx, span := StartSpan(context.Background(), "process", link, b3Link, trace.Link{})
+	assert.True(trace.SpanContextFromContext(ctx).IsValid())
+	span.End()
+
+	spans := exporter.GetSpans()
+	if assert.Len(spans, 1) {
+		assert.Equal("process", spans[0].Name)
+		assert.Len(spans[0].Links, 2)
+		assert.Equal("b7ad6b7169203331", spans[0].Links[0].SpanContext.SpanID().String())
+		assert.Equal("kafka", spans[0].Links[0].Attributes[0].Value.AsString())
+	}
+}
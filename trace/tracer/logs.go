@@ -0,0 +1,208 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful/logging"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+var (
+	otelLoggerProvider *sdklog.LoggerProvider
+	logHooksOnce       sync.Once
+)
+
+// SetOTelLogs makes the records of restful logging and logrus standard logger exported by the logger provider, besides being printed as usual.
+// Records logged with a context, e.g. log.WithContext(ctx).Info("..."), are correlated to the span of the context.
+// Nil logger provider stops exporting.
+func SetOTelLogs(lp *sdklog.LoggerProvider) {
+	if lp == nil {
+		otelLoggerProvider = nil
+		return
+	}
+	logHooksOnce.Do(func() {
+		logrus.AddHook(logBridgeHook{})
+		logging.AddHook(slogBridgeHandler{})
+	})
+	otelLoggerProvider = lp
+	global.SetLoggerProvider(lp)
+}
+
+// SetOTelLogsGrpc activates log export to the OTLP gRPC collector target address defined.
+// Port is 4317, unless defined otherwise in provided target string.
+func SetOTelLogsGrpc(target string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter, err := otlploggrpc.New(ctx, otlploggrpc.WithEndpointURL(target))
+	if err != nil {
+		return err
+	}
+	return setOTelLogExporter(ctx, exporter)
+}
+
+// SetOTelLogsHTTP activates log export to the OTLP HTTP/protobuf collector target URL defined, e.g. "http://collector:4318/v1/logs".
+//
+// Further exporter options can be provided, e.g. otlploghttp.WithProxy or otlploghttp.WithTLSClientConfig.
+func SetOTelLogsHTTP(target string, opts ...otlploghttp.Option) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter, err := otlploghttp.New(ctx, append([]otlploghttp.Option{otlploghttp.WithEndpointURL(target)}, opts...)...)
+	if err != nil {
+		return err
+	}
+	return setOTelLogExporter(ctx, exporter)
+}
+
+func setOTelLogExporter(ctx context.Context, exporter sdklog.Exporter) error {
+	res, err := newOTelResource(ctx)
+	if err != nil {
+		return err
+	}
+
+	SetOTelLogs(sdklog.NewLoggerProvider(sdklog.WithResource(res), sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter))))
+	return nil
+}
+
+// logBridgeHook emits logrus entries as OpenTelemetry log records.
+type logBridgeHook struct{}
+
+// Levels returns the levels the hook is fired at.
+func (logBridgeHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+var logSeverities = map[logrus.Level]otellog.Severity{
+	logrus.TraceLevel: otellog.SeverityTrace,
+	logrus.DebugLevel: otellog.SeverityDebug,
+	logrus.InfoLevel:  otellog.SeverityInfo,
+	logrus.WarnLevel:  otellog.SeverityWarn,
+	logrus.ErrorLevel: otellog.SeverityError,
+	logrus.FatalLevel: otellog.SeverityFatal,
+	logrus.PanicLevel: otellog.SeverityFatal4,
+}
+
+// Fire emits the log entry. Trace and span IDs are taken from the context of the entry by the SDK.
+func (logBridgeHook) Fire(entry *logrus.Entry) error {
+	lp := otelLoggerProvider
+	if lp == nil {
+		return nil
+	}
+
+	var record otellog.Record
+	record.SetTimestamp(entry.Time)
+	record.SetSeverity(logSeverities[entry.Level])
+	record.SetSeverityText(entry.Level.String())
+	record.SetBody(otellog.StringValue(entry.Message))
+	for k, v := range entry.Data {
+		record.AddAttributes(logKeyValue(k, v))
+	}
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	lp.Logger(scopeName).Emit(ctx, record)
+	return nil
+}
+
+func logKeyValue(k string, v any) otellog.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return otellog.String(k, v)
+	case int:
+		return otellog.Int(k, v)
+	case int64:
+		return otellog.Int64(k, v)
+	case float64:
+		return otellog.Float64(k, v)
+	case bool:
+		return otellog.Bool(k, v)
+	case error:
+		return otellog.String(k, v.Error())
+	default:
+		return otellog.String(k, fmt.Sprint(v))
+	}
+}
+
+// slogBridgeHandler is a restful logging hook emitting slog records as OpenTelemetry log records.
+// Groups are flattened into dotted key prefixes.
+type slogBridgeHandler struct {
+	attrs  []otellog.KeyValue
+	prefix string
+}
+
+// Enabled tells whether logs are exported.
+func (h slogBridgeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return otelLoggerProvider != nil
+}
+
+// Handle emits the record. Trace and span IDs are taken from the context by the SDK.
+func (h slogBridgeHandler) Handle(ctx context.Context, r slog.Record) error {
+	lp := otelLoggerProvider
+	if lp == nil {
+		return nil
+	}
+
+	var record otellog.Record
+	record.SetTimestamp(r.Time)
+	record.SetSeverity(otellog.Severity(min(max(r.Level+9, 1), 24))) // Debug-4 is trace (1), Debug is 5, Info is 9, ...
+	record.SetSeverityText(r.Level.String())
+	record.SetBody(otellog.StringValue(r.Message))
+	record.AddAttributes(h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttributes(slogKeyValues(h.prefix, a)...)
+		return true
+	})
+	lp.Logger(scopeName).Emit(ctx, record)
+	return nil
+}
+
+// WithAttrs returns a handler adding the attributes to records.
+func (h slogBridgeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kvs := append([]otellog.KeyValue{}, h.attrs...)
+	for _, a := range attrs {
+		kvs = append(kvs, slogKeyValues(h.prefix, a)...)
+	}
+	return slogBridgeHandler{attrs: kvs, prefix: h.prefix}
+}
+
+// WithGroup returns a handler prefixing keys with the group name.
+func (h slogBridgeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return slogBridgeHandler{attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+func slogKeyValues(prefix string, a slog.Attr) []otellog.KeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return nil
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		var kvs []otellog.KeyValue
+		for _, ga := range a.Value.Group() {
+			kvs = append(kvs, slogKeyValues(prefix, ga)...)
+		}
+		return kvs
+	}
+	return []otellog.KeyValue{logKeyValue(prefix+a.Key, a.Value.Any())}
+}
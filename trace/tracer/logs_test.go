@@ -0,0 +1,91 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/nokia/restful/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type memLogExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memLogExporter) Shutdown(ctx context.Context) error   { return nil }
+func (e *memLogExporter) ForceFlush(ctx context.Context) error { return nil }
+
+func TestSetOTelLogs(t *testing.T) {
+	assert := assert.New(t)
+	exporter := &memLogExporter{}
+	SetOTelLogs(sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter))))
+	defer SetOTelLogs(nil)
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	logrus.WithContext(ctx).WithField("user", "joe").Warn("hello")
+	logrus.Info("no context")
+
+	SetOTelLogs(nil)
+	logrus.Info("not exported")
+
+	if assert.Len(exporter.records, 2) {
+		r := exporter.records[0]
+		assert.Equal("hello", r.Body().AsString())
+		assert.Equal(otellog.SeverityWarn, r.Severity())
+		assert.Equal(span.SpanContext().TraceID(), r.TraceID())
+		assert.Equal(span.SpanContext().SpanID(), r.SpanID())
+		r.WalkAttributes(func(kv otellog.KeyValue) bool {
+			if kv.Key == "user" {
+				assert.Equal("joe", kv.Value.AsString())
+			}
+			return true
+		})
+
+		assert.False(exporter.records[1].TraceID().IsValid())
+	}
+}
+
+func TestSetOTelLogsSlog(t *testing.T) {
+	assert := assert.New(t)
+	exporter := &memLogExporter{}
+	SetOTelLogs(sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter))))
+	defer SetOTelLogs(nil)
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	logging.FromContext(ctx).With("user", "joe").WithGroup("req").ErrorContext(ctx, "hello", "id", 42)
+	logging.Debugf(ctx, "below level")
+
+	if assert.Len(exporter.records, 1) {
+		r := exporter.records[0]
+		assert.Equal("hello", r.Body().AsString())
+		assert.Equal(otellog.SeverityError, r.Severity())
+		assert.Equal(span.SpanContext().TraceID(), r.TraceID())
+		attrs := map[string]string{}
+		r.WalkAttributes(func(kv otellog.KeyValue) bool {
+			attrs[kv.Key] = kv.Value.String()
+			return true
+		})
+		assert.Equal(map[string]string{"user": "joe", "req.id": "42"}, attrs)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// OtelMetricsEnabled tells if OpenTelemetry metrics were activated.
+var OtelMetricsEnabled = false
+
+var otelMeterProvider *sdkmetric.MeterProvider
+
+// GetOTelMetrics returns if Open Telemetry metrics are enabled.
+func GetOTelMetrics() bool {
+	return OtelMetricsEnabled
+}
+
+// SetOTelMetrics enables/disables Open Telemetry metrics. By default it is disabled.
+// Meter provider can be set with a reader you need, e.g. a Prometheus exporter.
+// If not set, the global meter provider is used.
+func SetOTelMetrics(enabled bool, mp *sdkmetric.MeterProvider) {
+	OtelMetricsEnabled = enabled
+	if enabled && mp != nil {
+		otelMeterProvider = mp
+		otel.SetMeterProvider(mp)
+	}
+}
+
+// SetOTelMetricsGrpc enables Open Telemetry metrics.
+// Activates metric export to the OTLP gRPC collector target address defined, every interval.
+// Port is 4317, unless defined otherwise in provided target string.
+// Interval 0 means the default 60s, or as set by OTEL_METRIC_EXPORT_INTERVAL.
+func SetOTelMetricsGrpc(target string, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpointURL(target))
+	if err != nil {
+		return err
+	}
+	return setOTelMetricExporter(ctx, exporter, interval)
+}
+
+// SetOTelMetricsHTTP enables Open Telemetry metrics.
+// Activates metric export to the OTLP HTTP/protobuf collector target URL defined, e.g. "http://collector:4318/v1/metrics", every interval.
+// Interval 0 means the default 60s, or as set by OTEL_METRIC_EXPORT_INTERVAL.
+//
+// Further exporter options can be provided, e.g. otlpmetrichttp.WithProxy or otlpmetrichttp.WithTLSClientConfig.
+func SetOTelMetricsHTTP(target string, interval time.Duration, opts ...otlpmetrichttp.Option) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter, err := otlpmetrichttp.New(ctx, append([]otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(target)}, opts...)...)
+	if err != nil {
+		return err
+	}
+	return setOTelMetricExporter(ctx, exporter, interval)
+}
+
+func setOTelMetricExporter(ctx context.Context, exporter sdkmetric.Exporter, interval time.Duration) error {
+	res, err := newOTelResource(ctx)
+	if err != nil {
+		return err
+	}
+
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if interval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(interval))
+	}
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+	)
+	SetOTelMetrics(true, meterProvider)
+	return nil
+}
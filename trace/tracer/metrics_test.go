@@ -0,0 +1,33 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetOTelMetricsHTTP(t *testing.T) {
+	assert := assert.New(t)
+	prevProvider := otel.GetMeterProvider()
+	defer func() {
+		otel.SetMeterProvider(prevProvider)
+		otelMeterProvider = nil
+		SetOTelMetrics(false, nil)
+	}()
+
+	assert.False(GetOTelMetrics())
+	assert.NoError(SetOTelMetricsHTTP("http://127.0.0.1:1/v1/metrics", time.Hour))
+	assert.True(GetOTelMetrics())
+	assert.Equal(otelMeterProvider, otel.GetMeterProvider())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Error(ForceFlush(ctx)) // Collector is not reachable.
+}
@@ -0,0 +1,130 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nokia/restful/trace/traceb3"
+	"github.com/nokia/restful/trace/tracedata"
+	"github.com/nokia/restful/trace/tracejaeger"
+	"github.com/nokia/restful/trace/traceparent"
+	"github.com/nokia/restful/trace/tracexray"
+)
+
+// Propagator is a trace data kind used when OpenTelemetry is not enabled.
+type Propagator struct {
+	// Name of the propagator, e.g. "b3".
+	Name string
+
+	// Priority of the propagator. Trace data of lower priority values are extracted first.
+	Priority int
+
+	// FromRequest creates trace data from the headers of a request. Returns nil if not found.
+	// Trace data is injected to outbound requests by its Span method.
+	FromRequest func(r *http.Request) tracedata.TraceData
+
+	// NewRandom creates trace data with a random trace ID. Optional.
+	// The propagator of the lowest priority value that has NewRandom defines what trace headers are sent if none was received.
+	NewRandom func() tracedata.TraceData
+}
+
+// Default propagator priorities.
+const (
+	PriorityB3          = 100
+	PriorityTraceParent = 200
+	PriorityJaeger      = 300
+	PriorityXRay        = 400
+)
+
+var (
+	propagatorsMutex sync.Mutex
+	propagators      atomic.Pointer[[]Propagator]
+)
+
+func init() {
+	propagators.Store(&[]Propagator{
+		{Name: "b3", Priority: PriorityB3,
+			FromRequest: func(r *http.Request) tracedata.TraceData {
+				if t := traceb3.NewFromRequest(r); t != nil {
+					return t
+				}
+				return nil
+			},
+			NewRandom: func() tracedata.TraceData { return traceb3.NewRandom() }},
+		{Name: "traceparent", Priority: PriorityTraceParent,
+			FromRequest: func(r *http.Request) tracedata.TraceData {
+				if t := traceparent.NewFromRequest(r); t != nil {
+					return t
+				}
+				return nil
+			}},
+		{Name: "jaeger", Priority: PriorityJaeger,
+			FromRequest: func(r *http.Request) tracedata.TraceData {
+				if t := tracejaeger.NewFromRequest(r); t != nil {
+					return t
+				}
+				return nil
+			}},
+		{Name: "xray", Priority: PriorityXRay,
+			FromRequest: func(r *http.Request) tracedata.TraceData {
+				if t := tracexray.NewFromRequest(r); t != nil {
+					return t
+				}
+				return nil
+			}},
+	})
+}
+
+// RegisterPropagator registers a trace data kind, e.g. of proprietary headers.
+// A registered propagator of the same name is replaced. That way default ones, such as "b3", can be re-prioritized, too.
+// Note that FromRequest and NewRandom functions must return untyped nil, not a nil pointer of a type.
+//
+//	tracer.RegisterPropagator(tracer.Propagator{Name: "my", Priority: 50, FromRequest: myFromRequest})
+func RegisterPropagator(p Propagator) {
+	propagatorsMutex.Lock()
+	defer propagatorsMutex.Unlock()
+	list := slices.DeleteFunc(slices.Clone(*propagators.Load()), func(old Propagator) bool { return old.Name == p.Name })
+	list = append(list, p)
+	slices.SortStableFunc(list, func(a, b Propagator) int { return a.Priority - b.Priority })
+	propagators.Store(&list)
+}
+
+// UnregisterPropagator removes the propagator of the given name.
+func UnregisterPropagator(name string) {
+	propagatorsMutex.Lock()
+	defer propagatorsMutex.Unlock()
+	list := slices.DeleteFunc(slices.Clone(*propagators.Load()), func(old Propagator) bool { return old.Name == name })
+	propagators.Store(&list)
+}
+
+// Propagators returns the registered propagators in priority order.
+func Propagators() []Propagator {
+	return slices.Clone(*propagators.Load())
+}
+
+func traceDataFromRequest(r *http.Request) tracedata.TraceData {
+	for _, p := range *propagators.Load() {
+		if p.FromRequest == nil {
+			continue
+		}
+		if t := p.FromRequest(r); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+func newRandomTraceData() tracedata.TraceData {
+	for _, p := range *propagators.Load() {
+		if p.NewRandom != nil {
+			return p.NewRandom()
+		}
+	}
+	return traceb3.NewRandom()
+}
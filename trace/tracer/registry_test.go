@@ -0,0 +1,69 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nokia/restful/trace/tracedata"
+	"github.com/stretchr/testify/assert"
+)
+
+type myTrace struct {
+	id       string
+	received bool
+}
+
+func (m *myTrace) Span(r *http.Request) (*http.Request, string) {
+	m.SetHeader(r.Header)
+	return r, m.id
+}
+func (m *myTrace) SetHeader(header http.Header) { header.Set("X-My-Trace", m.id) }
+func (m *myTrace) IsReceived() bool             { return m.received }
+func (m *myTrace) String() string               { return m.id }
+func (m *myTrace) TraceID() string              { return m.id }
+func (m *myTrace) SpanID() string               { return "" }
+
+func TestRegistry(t *testing.T) {
+	assert := assert.New(t)
+	defaults := Propagators()
+	assert.Equal("b3", defaults[0].Name)
+	defer func() {
+		UnregisterPropagator("my")
+		RegisterPropagator(defaults[0])
+	}()
+
+	RegisterPropagator(Propagator{
+		Name:     "my",
+		Priority: 50,
+		FromRequest: func(r *http.Request) tracedata.TraceData {
+			if id := r.Header.Get("X-My-Trace"); id != "" {
+				return &myTrace{id: id, received: true}
+			}
+			return nil
+		},
+		NewRandom: func() tracedata.TraceData { return &myTrace{id: "random"} },
+	})
+	assert.Equal("my", Propagators()[0].Name)
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-My-Trace", "abc")
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b9c7c989f97918e1-01")
+	assert.Equal("abc", NewFromRequest(r).TraceID())
+
+	header := http.Header{}
+	NewRandom().SetHeader(header)
+	assert.Equal("random", header.Get("X-My-Trace"))
+
+	// Re-prioritize traceparent.
+	RegisterPropagator(Propagator{Name: "my", Priority: 1000, FromRequest: Propagators()[0].FromRequest})
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", NewFromRequest(r).TraceID())
+	assert.Equal("b3", Propagators()[0].Name)
+
+	UnregisterPropagator("b3")
+	assert.Equal("traceparent", Propagators()[0].Name)
+	assert.NotNil(NewRandom()) // Fallback to B3.
+}
@@ -0,0 +1,226 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nokia/restful/logging"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RemoteSamplerInterval is the default polling interval of remote samplers.
+var RemoteSamplerInterval = time.Minute
+
+// remoteStrategy is the sampling strategy response of Jaeger remote sampling protocol.
+// See https://www.jaegertracing.io/docs/latest/sampling/#remote-sampling
+type remoteStrategy struct {
+	StrategyType          string `json:"strategyType"`
+	ProbabilisticSampling *struct {
+		SamplingRate float64 `json:"samplingRate"`
+	} `json:"probabilisticSampling,omitempty"`
+	RateLimitingSampling *struct {
+		MaxTracesPerSecond float64 `json:"maxTracesPerSecond"`
+	} `json:"rateLimitingSampling,omitempty"`
+	OperationSampling *struct {
+		DefaultSamplingProbability float64 `json:"defaultSamplingProbability"`
+		PerOperationStrategies     []struct {
+			Operation             string `json:"operation"`
+			ProbabilisticSampling struct {
+				SamplingRate float64 `json:"samplingRate"`
+			} `json:"probabilisticSampling"`
+		} `json:"perOperationStrategies"`
+	} `json:"operationSampling,omitempty"`
+}
+
+type remoteSamplers struct {
+	def          sdktrace.Sampler
+	perOperation map[string]sdktrace.Sampler
+}
+
+func (s *remoteStrategy) samplers() (*remoteSamplers, error) {
+	if s.OperationSampling != nil {
+		samplers := &remoteSamplers{def: sdktrace.TraceIDRatioBased(s.OperationSampling.DefaultSamplingProbability), perOperation: map[string]sdktrace.Sampler{}}
+		for _, op := range s.OperationSampling.PerOperationStrategies {
+			samplers.perOperation[op.Operation] = sdktrace.TraceIDRatioBased(op.ProbabilisticSampling.SamplingRate)
+		}
+		return samplers, nil
+	}
+
+	switch s.StrategyType {
+	case "PROBABILISTIC", "":
+		if s.ProbabilisticSampling != nil {
+			return &remoteSamplers{def: sdktrace.TraceIDRatioBased(s.ProbabilisticSampling.SamplingRate)}, nil
+		}
+	case "RATE_LIMITING":
+		if s.RateLimitingSampling != nil {
+			return &remoteSamplers{def: NewRateLimitingSampler(s.RateLimitingSampling.MaxTracesPerSecond)}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported sampling strategy: %q", s.StrategyType)
+}
+
+type remoteSampler struct {
+	endpoint    *url.URL
+	serviceName string
+	client      *http.Client
+	samplers    atomic.Pointer[remoteSamplers]
+	mu          sync.Mutex
+	last        string
+}
+
+// NewRemoteSampler creates a sampler that fetches its sampling strategy from a Jaeger remote sampling endpoint,
+// e.g. "http://jaeger-agent:5778/sampling", or from a file of the same JSON format, e.g. "file:///etc/sampling/strategy.json".
+// Changes are applied at runtime, polled every interval until ctx is cancelled. If interval is 0, RemoteSamplerInterval is used.
+// Until the first successful fetch the initial sampler is used, e.g. sdktrace.TraceIDRatioBased(0.001).
+//
+// Probabilistic, rate limiting and per-operation probabilistic strategies are supported. Operation is the name of the span at start.
+//
+//	sampler, err := tracer.NewRemoteSampler(ctx, "http://jaeger-agent:5778/sampling", "myservice", 0, sdktrace.TraceIDRatioBased(0.001))
+//	tracer.SetOTelSampler(sampler)
+func NewRemoteSampler(ctx context.Context, endpoint, serviceName string, interval time.Duration, initial sdktrace.Sampler) (sdktrace.Sampler, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = RemoteSamplerInterval
+	}
+
+	s := &remoteSampler{endpoint: u, serviceName: serviceName, client: &http.Client{Timeout: 10 * time.Second}}
+	s.samplers.Store(&remoteSamplers{def: initial})
+	if err := s.update(ctx); err != nil {
+		logging.Module("tracer").Error("Remote sampling strategy fetch failed", "error", err)
+	}
+	go s.poll(ctx, interval)
+	return s, nil
+}
+
+func (s *remoteSampler) poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.update(ctx); err != nil {
+				logging.Module("tracer").Error("Remote sampling strategy fetch failed", "error", err)
+			}
+		}
+	}
+}
+
+func (s *remoteSampler) fetch(ctx context.Context) ([]byte, error) {
+	if s.endpoint.Scheme == "file" || s.endpoint.Scheme == "" {
+		return os.ReadFile(filepath.Clean(s.endpoint.Path))
+	}
+
+	u := *s.endpoint
+	q := u.Query()
+	q.Set("service", s.serviceName)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote sampling status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (s *remoteSampler) update(ctx context.Context) error {
+	body, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if string(body) == s.last {
+		return nil // Keep rate limiter state.
+	}
+
+	var strategy remoteStrategy
+	if err := json.Unmarshal(body, &strategy); err != nil {
+		return err
+	}
+	samplers, err := strategy.samplers()
+	if err != nil {
+		return err
+	}
+	s.samplers.Store(samplers)
+	s.last = string(body)
+	logging.Module("tracer").Debug("Remote sampling strategy applied", "strategy", string(body))
+	return nil
+}
+
+// ShouldSample tells whether to sample the span.
+func (s *remoteSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	samplers := s.samplers.Load()
+	if sampler, ok := samplers.perOperation[p.Name]; ok {
+		return sampler.ShouldSample(p)
+	}
+	return samplers.def.ShouldSample(p)
+}
+
+// Description returns the name of the sampler.
+func (s *remoteSampler) Description() string {
+	return "RemoteSampler{" + s.endpoint.String() + "}"
+}
+
+// newEnvSampler creates a remote sampler, if configured by OpenTelemetry environment variables
+// OTEL_TRACES_SAMPLER=jaeger_remote or parentbased_jaeger_remote, and OTEL_TRACES_SAMPLER_ARG,
+// e.g. "endpoint=http://jaeger-agent:5778/sampling,pollingIntervalMs=5000,initialSamplingRate=0.25".
+func newEnvSampler() sdktrace.Sampler {
+	kind := os.Getenv("OTEL_TRACES_SAMPLER")
+	if kind != "jaeger_remote" && kind != "parentbased_jaeger_remote" {
+		return nil
+	}
+
+	endpoint := "http://localhost:5778/sampling"
+	interval := RemoteSamplerInterval
+	initialRate := 0.001
+	for _, arg := range strings.Split(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(arg), "=")
+		switch k {
+		case "endpoint":
+			endpoint = v
+		case "pollingIntervalMs":
+			if ms, err := strconv.Atoi(v); err == nil {
+				interval = time.Duration(ms) * time.Millisecond
+			}
+		case "initialSamplingRate":
+			if rate, err := strconv.ParseFloat(v, 64); err == nil {
+				initialRate = rate
+			}
+		}
+	}
+
+	sampler, err := NewRemoteSampler(context.Background(), endpoint, serviceName(), interval, sdktrace.TraceIDRatioBased(initialRate))
+	if err != nil {
+		logging.Module("tracer").Error("Remote sampler config error", "error", err)
+		return nil
+	}
+	return sampler
+}
@@ -0,0 +1,73 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestRemoteSampler(t *testing.T) {
+	assert := assert.New(t)
+	var strategy atomic.Value
+	strategy.Store(`{"strategyType":"PROBABILISTIC","probabilisticSampling":{"samplingRate":0}}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("mysvc", r.URL.Query().Get("service"))
+		_, _ = w.Write([]byte(strategy.Load().(string)))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := NewRemoteSampler(ctx, srv.URL+"/sampling", "mysvc", time.Millisecond, sdktrace.AlwaysSample())
+	assert.NoError(err)
+	assert.Equal(0, countSampled(s, 10))
+
+	strategy.Store(`{"strategyType":"RATE_LIMITING","rateLimitingSampling":{"maxTracesPerSecond":2}}`)
+	assert.Eventually(func() bool { return countSampled(s, 10) > 0 }, time.Second, time.Millisecond)
+
+	strategy.Store(`{"operationSampling":{"defaultSamplingProbability":0,"perOperationStrategies":[{"operation":"GET","probabilisticSampling":{"samplingRate":1}}]}}`)
+	assert.Eventually(func() bool {
+		return s.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), Name: "GET"}).Decision == sdktrace.RecordAndSample
+	}, time.Second, time.Millisecond)
+	assert.Equal(0, countSampled(s, 10))
+}
+
+func TestRemoteSamplerFile(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "strategy.json")
+	assert.NoError(os.WriteFile(path, []byte(`{"strategyType":"PROBABILISTIC","probabilisticSampling":{"samplingRate":1}}`), 0o600))
+
+	s, err := NewRemoteSampler(context.Background(), "file://"+path, "mysvc", time.Hour, sdktrace.NeverSample())
+	assert.NoError(err)
+	assert.Equal(10, countSampled(s, 10))
+
+	s, err = NewRemoteSampler(context.Background(), "/no/such/file", "mysvc", time.Hour, sdktrace.NeverSample())
+	assert.NoError(err)
+	assert.Equal(0, countSampled(s, 10)) // Initial sampler
+	assert.Contains(s.Description(), "/no/such/file")
+}
+
+func TestEnvSampler(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(newEnvSampler())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"strategyType":"PROBABILISTIC","probabilisticSampling":{"samplingRate":1}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("OTEL_TRACES_SAMPLER", "parentbased_jaeger_remote")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "endpoint="+srv.URL+",pollingIntervalMs=3600000,initialSamplingRate=0")
+	assert.Equal(10, countSampled(newOTelSampler(0), 10))
+}
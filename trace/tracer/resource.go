@@ -0,0 +1,61 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+var (
+	otelResourceAttrs []attribute.KeyValue
+	otelServiceName   string
+)
+
+// SetOTelResource sets the resource attributes of the exported spans, metrics and logs, used by SetOTelGrpc, SetOTelHTTP, SetZipkin,
+// and by the metrics and logs setups. Call it before those.
+//
+// Empty service name, version and environment are not set.
+// By default the service name is taken from OTEL_SERVICE_NAME environment variable, or from the executable name.
+// OTEL_RESOURCE_ATTRIBUTES environment variable is applied, too. The values set here override the ones of environment variables.
+//
+//	tracer.SetOTelResource("payments", "1.2.3", "production", attribute.String("k8s.cluster.name", "eu-1"))
+func SetOTelResource(serviceName, serviceVersion, environment string, attrs ...attribute.KeyValue) {
+	otelResourceAttrs = nil
+	otelServiceName = serviceName
+	if serviceName != "" {
+		otelResourceAttrs = append(otelResourceAttrs, semconv.ServiceNameKey.String(serviceName))
+	}
+	if serviceVersion != "" {
+		otelResourceAttrs = append(otelResourceAttrs, semconv.ServiceVersionKey.String(serviceVersion))
+	}
+	if environment != "" {
+		otelResourceAttrs = append(otelResourceAttrs, semconv.DeploymentEnvironmentKey.String(environment))
+	}
+	otelResourceAttrs = append(otelResourceAttrs, attrs...)
+}
+
+func newOTelResource(ctx context.Context) (*resource.Resource, error) {
+	return resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String(filepath.Base(os.Args[0]))),
+		resource.WithFromEnv(),
+		resource.WithAttributes(otelResourceAttrs...))
+}
+
+// serviceName returns the service name set by SetOTelResource, or by OTEL_SERVICE_NAME environment variable, or the executable name.
+func serviceName() string {
+	if otelServiceName != "" {
+		return otelServiceName
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return filepath.Base(os.Args[0])
+}
@@ -0,0 +1,42 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSetOTelResource(t *testing.T) {
+	assert := assert.New(t)
+	defer SetOTelResource("", "", "")
+
+	res, err := newOTelResource(context.Background())
+	assert.NoError(err)
+	name, _ := res.Set().Value("service.name")
+	assert.Equal(filepath.Base(os.Args[0]), name.AsString())
+	assert.Equal(filepath.Base(os.Args[0]), serviceName())
+
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "team=core,service.version=0.1")
+	SetOTelResource("payments", "1.2.3", "production", attribute.String("k8s.cluster.name", "eu-1"))
+	res, err = newOTelResource(context.Background())
+	assert.NoError(err)
+	for k, v := range map[attribute.Key]string{
+		"service.name":           "payments",
+		"service.version":        "1.2.3",
+		"deployment.environment": "production",
+		"k8s.cluster.name":       "eu-1",
+		"team":                   "core",
+	} {
+		value, _ := res.Set().Value(k)
+		assert.Equal(v, value.AsString(), k)
+	}
+	assert.Equal("payments", serviceName())
+}
@@ -0,0 +1,185 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var otelSampler sdktrace.Sampler
+
+// SetOTelSampler sets the sampler of new traces used by SetOTelGrpc and SetOTelHTTP, instead of the fraction based one.
+// Received sampling decisions of parent spans are respected, as with the fraction based sampler.
+// If not set, OTEL_TRACES_SAMPLER=jaeger_remote environment variable activates a remote sampler. See NewRemoteSampler.
+// Call it before SetOTelGrpc or SetOTelHTTP. Nil restores the fraction based sampler.
+//
+//	tracer.SetOTelSampler(tracer.NewRateLimitingSampler(100))
+//	tracer.SetOTelGrpc("collector:4317", 0)
+func SetOTelSampler(sampler sdktrace.Sampler) {
+	otelSampler = sampler
+}
+
+func newOTelSampler(fraction float64) sdktrace.Sampler {
+	if otelSampler != nil {
+		return NewOverridableSampler(recordingIfFiltered(sdktrace.ParentBased(otelSampler)))
+	}
+	if envSampler := newEnvSampler(); envSampler != nil {
+		return NewOverridableSampler(recordingIfFiltered(sdktrace.ParentBased(envSampler)))
+	}
+	return NewOverridableSampler(recordingIfFiltered(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(fraction))))
+}
+
+type samplingCtxKeyType string
+
+const samplingCtxName = samplingCtxKeyType("restfulSampling")
+
+// ContextWithSampling returns a context that overrides the sampling of spans started with that parent context.
+// Fraction 0 means never sampled, 1 means always sampled, otherwise the fraction of traces to be sampled, based on the trace ID.
+// Applies if the sampler of the tracer provider is an overridable one. See NewOverridableSampler.
+func ContextWithSampling(ctx context.Context, fraction float64) context.Context {
+	return context.WithValue(ctx, samplingCtxName, fraction)
+}
+
+type overridableSampler struct {
+	base sdktrace.Sampler
+}
+
+// NewOverridableSampler creates a sampler that applies the sampling fraction set in the parent context by ContextWithSampling,
+// e.g. by route sampling settings. Otherwise, the base sampler decides.
+// SetOTel without a tracer provider, SetOTelGrpc and SetOTelHTTP use overridable samplers.
+// Use it when you create your own tracer provider.
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(tracer.NewOverridableSampler(sdktrace.ParentBased(sdktrace.AlwaysSample()))))
+func NewOverridableSampler(base sdktrace.Sampler) sdktrace.Sampler {
+	return overridableSampler{base: base}
+}
+
+// ShouldSample tells whether to sample the span.
+func (s overridableSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.ParentContext != nil {
+		if fraction, ok := p.ParentContext.Value(samplingCtxName).(float64); ok {
+			return sdktrace.TraceIDRatioBased(fraction).ShouldSample(p)
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// Description returns the name of the sampler.
+func (s overridableSampler) Description() string {
+	return "OverridableSampler{" + s.base.Description() + "}"
+}
+
+type rateLimitingSampler struct {
+	mu      sync.Mutex
+	rate    float64
+	balance float64
+	max     float64
+	last    time.Time
+	now     func() time.Time
+}
+
+// NewRateLimitingSampler creates a sampler that samples at most spansPerSecond traces per second, e.g. 100.
+// Bursts up to one second worth of spans are allowed.
+// Keeps the collector costs predictable during traffic spikes.
+func NewRateLimitingSampler(spansPerSecond float64) sdktrace.Sampler {
+	return newRateLimitingSampler(spansPerSecond, time.Now)
+}
+
+func newRateLimitingSampler(spansPerSecond float64, now func() time.Time) *rateLimitingSampler {
+	max := spansPerSecond
+	if max < 1 {
+		max = 1
+	}
+	return &rateLimitingSampler{rate: spansPerSecond, balance: max, max: max, last: now(), now: now}
+}
+
+func (s *rateLimitingSampler) take() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.balance += now.Sub(s.last).Seconds() * s.rate
+	if s.balance > s.max {
+		s.balance = s.max
+	}
+	s.last = now
+	if s.balance < 1 {
+		return false
+	}
+	s.balance--
+	return true
+}
+
+// ShouldSample tells whether to sample the span.
+func (s *rateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	decision := sdktrace.Drop
+	if s.take() {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{Decision: decision, Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState()}
+}
+
+// Description returns the name of the sampler.
+func (s *rateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%g}", s.rate)
+}
+
+// AdaptiveSamplerInterval is the period the sampling ratio of adaptive samplers is adjusted at.
+var AdaptiveSamplerInterval = time.Second
+
+type adaptiveSampler struct {
+	mu      sync.Mutex
+	target  float64
+	ratio   float64
+	sampler sdktrace.Sampler
+	count   int
+	start   time.Time
+	now     func() time.Time
+}
+
+// NewAdaptiveSampler creates a sampler that samples a fraction of traces, targeting a budget of spansPerSecond traces per second.
+// The fraction is adjusted every AdaptiveSamplerInterval according to the traffic observed in the previous interval.
+// Unlike the rate limiting sampler, sampling is based on the trace ID, so spans of the same trace are sampled consistently
+// even if the parent decision is not propagated.
+func NewAdaptiveSampler(spansPerSecond float64) sdktrace.Sampler {
+	return newAdaptiveSampler(spansPerSecond, time.Now)
+}
+
+func newAdaptiveSampler(spansPerSecond float64, now func() time.Time) *adaptiveSampler {
+	return &adaptiveSampler{target: spansPerSecond, ratio: 1, sampler: sdktrace.AlwaysSample(), start: now(), now: now}
+}
+
+func (s *adaptiveSampler) current() sdktrace.Sampler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if elapsed := now.Sub(s.start); elapsed >= AdaptiveSamplerInterval {
+		observed := float64(s.count) / elapsed.Seconds()
+		s.ratio = 1
+		if observed > s.target {
+			s.ratio = s.target / observed
+		}
+		s.sampler = sdktrace.TraceIDRatioBased(s.ratio)
+		s.count = 0
+		s.start = now
+	}
+	s.count++
+	return s.sampler
+}
+
+// ShouldSample tells whether to sample the span.
+func (s *adaptiveSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.current().ShouldSample(p)
+}
+
+// Description returns the name of the sampler.
+func (s *adaptiveSampler) Description() string {
+	return fmt.Sprintf("AdaptiveSampler{%g}", s.target)
+}
@@ -0,0 +1,89 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func countSampled(s sdktrace.Sampler, n int) (sampled int) {
+	for i := 0; i < n; i++ {
+		var traceID trace.TraceID
+		traceID[8], traceID[9], traceID[10] = byte(i*7), byte(i*13), byte(i*31)
+		if s.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: traceID}).Decision == sdktrace.RecordAndSample {
+			sampled++
+		}
+	}
+	return
+}
+
+func TestRateLimitingSampler(t *testing.T) {
+	assert := assert.New(t)
+	clock := &fakeClock{t: time.Now()}
+	s := newRateLimitingSampler(10, clock.now)
+	assert.Equal("RateLimitingSampler{10}", s.Description())
+
+	assert.Equal(10, countSampled(s, 100)) // Burst
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	assert.Equal(5, countSampled(s, 100))
+	clock.t = clock.t.Add(time.Hour)
+	assert.Equal(10, countSampled(s, 100)) // Max burst
+
+	s = newRateLimitingSampler(0.5, clock.now)
+	assert.Equal(1, countSampled(s, 10))
+	clock.t = clock.t.Add(time.Second)
+	assert.Equal(0, countSampled(s, 10))
+	clock.t = clock.t.Add(time.Second)
+	assert.Equal(1, countSampled(s, 10))
+}
+
+func TestAdaptiveSampler(t *testing.T) {
+	assert := assert.New(t)
+	clock := &fakeClock{t: time.Now()}
+	s := newAdaptiveSampler(100, clock.now)
+	assert.Equal("AdaptiveSampler{100}", s.Description())
+
+	assert.Equal(1000, countSampled(s, 1000)) // All, until first adjustment.
+	clock.t = clock.t.Add(time.Second)
+	assert.InDelta(100, countSampled(s, 1000), 30) // 1000/s observed, 10% sampled.
+	assert.InDelta(0.1, s.ratio, 0.001)
+	clock.t = clock.t.Add(time.Second)
+	countSampled(s, 10)
+	clock.t = clock.t.Add(time.Second)
+	countSampled(s, 1)
+	assert.Equal(1.0, s.ratio) // Low traffic, all sampled.
+}
+
+func TestSetOTelSampler(t *testing.T) {
+	assert := assert.New(t)
+	defer SetOTelSampler(nil)
+	assert.Contains(newOTelSampler(0.5).Description(), "TraceIDRatioBased{0.5}")
+	SetOTelSampler(NewRateLimitingSampler(1))
+	assert.Contains(newOTelSampler(0.5).Description(), "RateLimitingSampler{1}")
+}
+
+func TestOverridableSampler(t *testing.T) {
+	assert := assert.New(t)
+	s := NewOverridableSampler(sdktrace.AlwaysSample())
+	assert.Equal("OverridableSampler{AlwaysOnSampler}", s.Description())
+	assert.Equal(10, countSampled(s, 10))
+
+	params := sdktrace.SamplingParameters{ParentContext: ContextWithSampling(context.Background(), 0)}
+	assert.Equal(sdktrace.Drop, s.ShouldSample(params).Decision)
+
+	s = NewOverridableSampler(sdktrace.NeverSample())
+	params.ParentContext = ContextWithSampling(context.Background(), 1)
+	assert.Equal(sdktrace.RecordAndSample, s.ShouldSample(params).Decision)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanFilter tells whether an ended span is to be exported.
+// The span can be inspected by its attributes, such as http.route and http.response.status_code, status and duration.
+// Whether the sampler selected the span is told by span.SpanContext().IsSampled().
+type SpanFilter func(span sdktrace.ReadOnlySpan) bool
+
+var otelSpanFilter SpanFilter
+
+// SetOTelSpanFilter sets a filter deciding on span export at span end, used by SetOTelGrpc, SetOTelHTTP and SetZipkin.
+// Call it before those. Nil removes the filter.
+//
+// With a filter, spans not selected by the sampler are recorded, too, so that the filter can keep them, e.g. slow or failed ones.
+// That costs some CPU and memory for each span. Spans kept that way may have no parent or children exported.
+//
+//	tracer.SetOTelSpanFilter(tracer.KeepSlowOrErrorSpans(time.Second))
+//	tracer.SetOTelGrpc("collector:4317", 0.01)
+func SetOTelSpanFilter(filter SpanFilter) {
+	otelSpanFilter = filter
+}
+
+// KeepSlowOrErrorSpans returns a span filter exporting spans selected by the sampler,
+// plus the spans with error status or lasting at least threshold.
+func KeepSlowOrErrorSpans(threshold time.Duration) SpanFilter {
+	return func(span sdktrace.ReadOnlySpan) bool {
+		return span.SpanContext().IsSampled() || span.Status().Code == codes.Error || span.EndTime().Sub(span.StartTime()) >= threshold
+	}
+}
+
+type recordingSampler struct {
+	base sdktrace.Sampler
+}
+
+// NewRecordingSampler creates a sampler that records the spans dropped by the base sampler, without selecting them for export.
+// Use it with NewFilteringSpanProcessor when you create your own tracer provider.
+func NewRecordingSampler(base sdktrace.Sampler) sdktrace.Sampler {
+	return recordingSampler{base: base}
+}
+
+// ShouldSample tells whether to sample the span.
+func (s recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	res := s.base.ShouldSample(p)
+	if res.Decision == sdktrace.Drop {
+		res.Decision = sdktrace.RecordOnly
+	}
+	return res
+}
+
+// Description returns the name of the sampler.
+func (s recordingSampler) Description() string {
+	return "RecordingSampler{" + s.base.Description() + "}"
+}
+
+type filteringSpanProcessor struct {
+	sdktrace.SpanProcessor
+	filter SpanFilter
+}
+
+// NewFilteringSpanProcessor creates a span processor that batches the spans the filter keeps, for export by the exporter.
+// Spans kept are exported as sampled ones.
+//
+//	tp := sdktrace.NewTracerProvider(
+//		sdktrace.WithSampler(tracer.NewRecordingSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.01)))),
+//		sdktrace.WithSpanProcessor(tracer.NewFilteringSpanProcessor(exporter, tracer.KeepSlowOrErrorSpans(time.Second))))
+func NewFilteringSpanProcessor(exporter sdktrace.SpanExporter, filter SpanFilter) sdktrace.SpanProcessor {
+	return filteringSpanProcessor{SpanProcessor: sdktrace.NewBatchSpanProcessor(exporter), filter: filter}
+}
+
+// OnEnd is called when a span is ended. Passes the span to batching if the filter keeps it.
+func (p filteringSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !p.filter(s) {
+		return
+	}
+	if !s.SpanContext().IsSampled() {
+		s = sampledSpan{ReadOnlySpan: s}
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// sampledSpan marks a span not selected by the sampler as sampled, so that it gets exported.
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+// SpanContext returns the span context, with sampled flag set.
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+// recordingIfFiltered makes the sampler record dropped spans, if a span filter is set.
+func recordingIfFiltered(sampler sdktrace.Sampler) sdktrace.Sampler {
+	if otelSpanFilter != nil {
+		return NewRecordingSampler(sampler)
+	}
+	return sampler
+}
+
+func newOTelSpanProcessor(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+	if otelSpanFilter != nil {
+		return NewFilteringSpanProcessor(exporter, otelSpanFilter)
+	}
+	return sdktrace.NewBatchSpanProcessor(exporter)
+}
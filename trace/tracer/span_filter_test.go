@@ -0,0 +1,56 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanFilter(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(NewRecordingSampler(sdktrace.NeverSample())),
+		sdktrace.WithSpanProcessor(NewFilteringSpanProcessor(exporter, KeepSlowOrErrorSpans(time.Second))))
+	tr := tp.Tracer("test")
+
+	_, fast := tr.Start(context.Background(), "fast")
+	fast.End()
+
+	_, failed := tr.Start(context.Background(), "failed")
+	failed.SetStatus(codes.Error, "boom")
+	failed.End()
+
+	start := time.Now()
+	_, slow := tr.Start(context.Background(), "slow", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+
+	assert.NoError(tp.ForceFlush(context.Background()))
+	names := []string{}
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+		assert.True(s.SpanContext.IsSampled())
+	}
+	assert.ElementsMatch([]string{"failed", "slow"}, names)
+}
+
+func TestSetOTelSpanFilter(t *testing.T) {
+	assert := assert.New(t)
+	p := sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: trace.TraceID{1}}
+	assert.Equal(sdktrace.Drop, newOTelSampler(0).ShouldSample(p).Decision)
+
+	SetOTelSpanFilter(KeepSlowOrErrorSpans(time.Second))
+	defer SetOTelSpanFilter(nil)
+	assert.Equal(sdktrace.RecordOnly, newOTelSampler(0).ShouldSample(p).Decision)
+	assert.Equal(sdktrace.Drop, newOTelSampler(0).ShouldSample(sdktrace.SamplingParameters{ParentContext: ContextWithSampling(context.Background(), 0)}).Decision)
+}
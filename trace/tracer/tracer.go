@@ -6,23 +6,21 @@ package tracer
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
-	"path/filepath"
-	"reflect"
 	"time"
 
-	"github.com/nokia/restful/trace/traceb3"
 	"github.com/nokia/restful/trace/tracedata"
 	"github.com/nokia/restful/trace/traceotel"
-	"github.com/nokia/restful/trace/traceparent"
 	"go.opentelemetry.io/contrib/propagators/b3"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/zipkin"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // OtelEnabled tells if OpenTelemetry tracing was activated.
@@ -31,6 +29,8 @@ import (
 // If OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, then tracing is activated automatically.
 var OtelEnabled = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
 
+var otelTracerProvider *sdktrace.TracerProvider
+
 // GetOTel returns if Open Telemetry is enabled.
 func GetOTel() bool {
 	return OtelEnabled
@@ -43,14 +43,31 @@ func SetOTel(enabled bool, tp *sdktrace.TracerProvider) {
 
 	if enabled {
 		if tp == nil {
-			tp = sdktrace.NewTracerProvider()
+			tp = sdktrace.NewTracerProvider(sdktrace.WithSampler(NewOverridableSampler(sdktrace.ParentBased(sdktrace.AlwaysSample()))))
 		}
+		otelTracerProvider = tp
 		traceotel.SetTraceProvider(tp)
 		otel.SetTracerProvider(tp)
-		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, b3.New(), b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader))))
+		setOTelPropagator()
+	}
+}
+
+var otelPropagators []propagation.TextMapPropagator
+
+// AddOTelPropagator adds an OpenTelemetry propagator to the default ones (W3C Trace Context, B3 and W3C Baggage), e.g. tracexray.Propagator{}.
+// Propagators are used on trace extraction from requests and injection into requests, if OpenTelemetry is enabled.
+func AddOTelPropagator(p propagation.TextMapPropagator) {
+	otelPropagators = append(otelPropagators, p)
+	if OtelEnabled {
+		setOTelPropagator()
 	}
 }
 
+func setOTelPropagator() {
+	propagators := []propagation.TextMapPropagator{propagation.TraceContext{}, b3.New(), b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)), propagation.Baggage{}}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(append(propagators, otelPropagators...)...))
+}
+
 // SetOTelGrpc enables Open Telemetry.
 // Activates trace export to the OTLP gRPC collector target address defined.
 // Port is 4317, unless defined otherwise in provided target string.
@@ -60,31 +77,102 @@ func SetOTel(enabled bool, tp *sdktrace.TracerProvider) {
 //   - Less or equal 0 means no sampling, unless parent is sampled.
 //   - Greater or equal 1 means always sampled.
 //   - Else the sampling fraction, e.g. 0.01 for 1%.
+//
+// Other samplers can be set by SetOTelSampler, e.g. NewRateLimitingSampler.
 func SetOTelGrpc(target string, fraction float64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	name := filepath.Base(os.Args[0])
-	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceNameKey.String(name)))
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(target))
 	if err != nil {
 		return err
 	}
+	return setOTelExporter(ctx, exporter, fraction)
+}
 
-	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(target))
+// SetOTelHTTP enables Open Telemetry.
+// Activates trace export to the OTLP HTTP/protobuf collector target URL defined, e.g. "http://collector:4318/v1/traces".
+// Port is 4318 and path is /v1/traces, unless defined otherwise in provided target string.
+//
+// Fraction tells the fraction of spans to report, unless parent is sampled. See SetOTelGrpc.
+//
+// Further exporter options can be provided, e.g. otlptracehttp.WithProxy or otlptracehttp.WithTLSClientConfig.
+// Options are applied after the target, overriding that.
+func SetOTelHTTP(target string, fraction float64, opts ...otlptracehttp.Option) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter, err := otlptracehttp.New(ctx, append([]otlptracehttp.Option{otlptracehttp.WithEndpointURL(target)}, opts...)...)
+	if err != nil {
+		return err
+	}
+	return setOTelExporter(ctx, exporter, fraction)
+}
+
+// SetZipkin enables Open Telemetry.
+// Activates span export directly to the Zipkin collector endpoint defined, e.g. "http://zipkin:9411/api/v2/spans".
+// For environments without an OTLP collector.
+//
+// Fraction tells the fraction of spans to report, unless parent is sampled. See SetOTelGrpc.
+func SetZipkin(endpoint string, fraction float64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exporter, err := zipkin.New(endpoint)
+	if err != nil {
+		return err
+	}
+	return setOTelExporter(ctx, exporter, fraction)
+}
+
+func setOTelExporter(ctx context.Context, exporter sdktrace.SpanExporter, fraction float64) error {
+	res, err := newOTelResource(ctx)
 	if err != nil {
 		return err
 	}
 
-	batchSpanProcessor := sdktrace.NewBatchSpanProcessor(exporter)
 	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(fraction))),
+		sdktrace.WithSampler(newOTelSampler(fraction)),
 		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(batchSpanProcessor),
+		sdktrace.WithSpanProcessor(newOTelSpanProcessor(exporter)),
 	)
 	SetOTel(true, tracerProvider)
 	return nil
 }
 
+// ForceFlush exports the spans batched for export by the Open Telemetry tracer provider, and the metrics and logs of the meter and logger providers, if set.
+func ForceFlush(ctx context.Context) error {
+	var errs []error
+	if otelTracerProvider != nil {
+		errs = append(errs, otelTracerProvider.ForceFlush(ctx))
+	}
+	if otelMeterProvider != nil {
+		errs = append(errs, otelMeterProvider.ForceFlush(ctx))
+	}
+	if otelLoggerProvider != nil {
+		errs = append(errs, otelLoggerProvider.ForceFlush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown flushes the spans batched for export and shuts down the Open Telemetry tracer provider, if set.
+// The meter and logger providers set by SetOTelMetrics and SetOTelLogs functions are flushed and shut down, as well.
+// Call it before the process exits, so that the spans of the last seconds are not lost.
+// Graceful server shutdown calls it automatically.
+func Shutdown(ctx context.Context) error {
+	var errs []error
+	if otelTracerProvider != nil {
+		errs = append(errs, otelTracerProvider.Shutdown(ctx))
+	}
+	if otelMeterProvider != nil {
+		errs = append(errs, otelMeterProvider.Shutdown(ctx))
+	}
+	if otelLoggerProvider != nil {
+		errs = append(errs, otelLoggerProvider.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
 // Tracer is a HTTP trace handler of various kinds.
 type Tracer struct {
 	traceData tracedata.TraceData
@@ -95,15 +183,14 @@ type Tracer struct {
 func NewFromRequest(r *http.Request) *Tracer {
 	var traceData tracedata.TraceData
 	if OtelEnabled {
-		traceData = traceotel.NewFromRequest(r)
-	} else {
-		traceData = traceb3.NewFromRequest(r)
-		if reflect.ValueOf(traceData).IsNil() {
-			traceData = traceparent.NewFromRequest(r)
+		if t := traceotel.NewFromRequest(r); t != nil {
+			traceData = t
 		}
+	} else {
+		traceData = traceDataFromRequest(r)
 	}
 
-	if traceData == nil || reflect.ValueOf(traceData).IsNil() {
+	if traceData == nil {
 		return nil
 	}
 	t := Tracer{traceData: traceData, received: true}
@@ -114,15 +201,14 @@ func NewFromRequest(r *http.Request) *Tracer {
 func NewFromRequestWithContext(parentCtx context.Context, r *http.Request) *Tracer {
 	var traceData tracedata.TraceData
 	if OtelEnabled {
-		traceData = traceotel.NewFromRequestWithContext(parentCtx, r)
-	} else {
-		traceData = traceb3.NewFromRequest(r)
-		if reflect.ValueOf(traceData).IsNil() {
-			traceData = traceparent.NewFromRequest(r)
+		if t := traceotel.NewFromRequestWithContext(parentCtx, r); t != nil {
+			traceData = t
 		}
+	} else {
+		traceData = traceDataFromRequest(r)
 	}
 
-	if traceData == nil || reflect.ValueOf(traceData).IsNil() {
+	if traceData == nil {
 		return nil
 	}
 	t := Tracer{traceData: traceData, received: true}
@@ -146,7 +232,7 @@ func NewRandom() *Tracer {
 	if OtelEnabled {
 		randomTraceData = traceotel.NewRandom()
 	} else {
-		randomTraceData = traceb3.NewRandom()
+		randomTraceData = newRandomTraceData()
 	}
 	return &Tracer{traceData: randomTraceData, received: false}
 }
@@ -183,3 +269,47 @@ func (t *Tracer) TraceID() string {
 func (t *Tracer) SpanID() string {
 	return t.traceData.SpanID()
 }
+
+// ErrTraceStateNotSupported is returned on setting tracestate of trace data not supporting that, e.g. B3.
+var ErrTraceStateNotSupported = errors.New("tracestate not supported by trace kind")
+
+// TraceState returns the W3C tracestate of the trace data.
+// Empty if the trace kind does not support that, e.g. B3.
+func (t *Tracer) TraceState() trace.TraceState {
+	if stater, ok := t.traceData.(tracedata.TraceStater); ok {
+		if ts, err := trace.ParseTraceState(stater.TraceState()); err == nil {
+			return ts
+		}
+	}
+	return trace.TraceState{}
+}
+
+// TraceStateValue returns the value of a vendor key of the tracestate, or empty string if not found.
+func (t *Tracer) TraceStateValue(key string) string {
+	return t.TraceState().Get(key)
+}
+
+// SetTraceStateValue inserts or updates a vendor key/value pair of the tracestate, propagated on spans.
+// The key is moved to the front, as W3C requires for modified entries.
+func (t *Tracer) SetTraceStateValue(key, value string) error {
+	stater, ok := t.traceData.(tracedata.TraceStater)
+	if !ok {
+		return ErrTraceStateNotSupported
+	}
+	ts, err := t.TraceState().Insert(key, value)
+	if err != nil {
+		return err
+	}
+	stater.SetTraceState(ts.String())
+	return nil
+}
+
+// DeleteTraceStateValue deletes a vendor key of the tracestate.
+func (t *Tracer) DeleteTraceStateValue(key string) error {
+	stater, ok := t.traceData.(tracedata.TraceStater)
+	if !ok {
+		return ErrTraceStateNotSupported
+	}
+	stater.SetTraceState(t.TraceState().Delete(key).String())
+	return nil
+}
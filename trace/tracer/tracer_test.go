@@ -1,13 +1,152 @@
 package tracer
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNotReceived(t *testing.T) {
 	tracer := NewFromRequestOrRandom(&http.Request{})
 	assert.False(t, tracer.IsReceived())
 }
+
+func TestBaggage(t *testing.T) {
+	assert := assert.New(t)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	assert.Zero(BaggageFromRequest(r).Len())
+	assert.Equal(context.Background(), BaggageToContext(context.Background(), r))
+
+	r.Header.Add(BaggageHeader, "tenant=a,bad member")
+	r.Header.Add(BaggageHeader, "user=joe;prop")
+	ctx := BaggageToContext(context.Background(), r)
+	assert.Equal("a", baggage.FromContext(ctx).Member("tenant").Value())
+	assert.Equal("joe", baggage.FromContext(ctx).Member("user").Value())
+
+	header := http.Header{}
+	SetBaggageHeader(ctx, header)
+	assert.Contains(header.Get(BaggageHeader), "tenant=a")
+	assert.Contains(header.Get(BaggageHeader), "user=joe;prop")
+
+	header = http.Header{}
+	SetBaggageHeader(context.Background(), header)
+	assert.Empty(header)
+}
+
+func TestSetOTelHTTP(t *testing.T) {
+	assert := assert.New(t)
+	defer SetOTel(false, nil)
+
+	assert.NoError(SetOTelHTTP("http://127.0.0.1:4318/v1/traces", 0.5, otlptracehttp.WithInsecure(), otlptracehttp.WithProxy(nil)))
+	assert.True(GetOTel())
+}
+
+func TestSetZipkin(t *testing.T) {
+	assert := assert.New(t)
+	defer SetOTel(false, nil)
+
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	assert.Error(SetZipkin("not a URL", 1))
+	assert.NoError(SetZipkin(srv.URL+"/api/v2/spans", 1))
+	assert.True(GetOTel())
+
+	_, span := StartSpan(context.Background(), "zipkin span")
+	span.End()
+	assert.NoError(ForceFlush(context.Background()))
+	assert.Contains(string(body), `"name":"zipkin span"`)
+}
+
+// keepingExporter keeps the exported spans on shutdown.
+type keepingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keepingExporter) Shutdown(context.Context) error { return nil }
+
+func TestShutdown(t *testing.T) {
+	assert := assert.New(t)
+	defer SetOTel(false, nil)
+
+	exporter := keepingExporter{tracetest.NewInMemoryExporter()}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	SetOTel(true, tp)
+
+	_, span := tp.Tracer("").Start(context.Background(), "first")
+	span.End()
+	assert.Empty(exporter.GetSpans()) // Batched.
+	assert.NoError(ForceFlush(context.Background()))
+	assert.Len(exporter.GetSpans(), 1)
+
+	_, span = tp.Tracer("").Start(context.Background(), "last")
+	span.End()
+	assert.NoError(Shutdown(context.Background()))
+	assert.Len(exporter.GetSpans(), 2)
+}
+
+func TestJaeger(t *testing.T) {
+	assert := assert.New(t)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("uber-trace-id", "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1")
+	tracer := NewFromRequest(r)
+	if assert.NotNil(tracer) {
+		assert.True(tracer.IsReceived())
+		assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", tracer.TraceID())
+	}
+}
+
+func TestTraceState(t *testing.T) {
+	assert := assert.New(t)
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	r.Header.Set("tracestate", "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7")
+	tracer := NewFromRequest(r)
+	assert.Equal("t61rcWkgMzE", tracer.TraceStateValue("congo"))
+
+	assert.NoError(tracer.SetTraceStateValue("rojo", "1"))
+	assert.NoError(tracer.SetTraceStateValue("nokia", "hint"))
+	assert.NoError(tracer.DeleteTraceStateValue("congo"))
+	assert.Error(tracer.SetTraceStateValue("Bad Key", "x"))
+	assert.Equal("nokia=hint,rojo=1", tracer.TraceState().String())
+
+	out, _ := http.NewRequest(http.MethodGet, "/", nil)
+	tracer.Span(out)
+	assert.Equal("nokia=hint,rojo=1", out.Header.Get("tracestate"))
+
+	r.Header = http.Header{"X-B3-Traceid": {"463ac35c9f6413ad"}}
+	tracer = NewFromRequest(r)
+	assert.Empty(tracer.TraceStateValue("rojo"))
+	assert.ErrorIs(tracer.SetTraceStateValue("rojo", "1"), ErrTraceStateNotSupported)
+}
+
+func TestTraceStateOTel(t *testing.T) {
+	assert := assert.New(t)
+	SetOTel(true, nil)
+	defer SetOTel(false, nil)
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	r.Header.Set("tracestate", "rojo=00f067aa0ba902b7")
+	tracer := NewFromRequest(r)
+	assert.Equal("00f067aa0ba902b7", tracer.TraceStateValue("rojo"))
+	assert.NoError(tracer.SetTraceStateValue("nokia", "hint"))
+	assert.Equal("nokia=hint,rojo=00f067aa0ba902b7", tracer.TraceState().String())
+
+	out, _ := http.NewRequest(http.MethodGet, "/", nil)
+	out, _ = tracer.Span(out)
+	assert.Equal("nokia=hint,rojo=00f067aa0ba902b7", trace.SpanContextFromContext(out.Context()).TraceState().String())
+}
@@ -0,0 +1,60 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracexray
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Propagator is an OpenTelemetry propagator of X-Amzn-Trace-Id header.
+//
+//	tracer.AddOTelPropagator(tracexray.Propagator{})
+type Propagator struct{}
+
+var _ propagation.TextMapPropagator = Propagator{}
+
+// Inject sets X-Amzn-Trace-Id header from the span context of ctx.
+func (Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	sampled := "0"
+	if sc.IsSampled() {
+		sampled = "1"
+	}
+	x := TraceXRay{root: RootFromTraceID(sc.TraceID().String()), parent: sc.SpanID().String(), sampled: sampled}
+	carrier.Set(headerXRay, x.headerValue())
+}
+
+// Extract reads X-Amzn-Trace-Id header and returns a context with the remote span context, if the header is valid.
+func (Propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	x := parse(carrier.Get(headerXRay))
+	if x == nil {
+		return ctx
+	}
+	traceID, err := trace.TraceIDFromHex(x.TraceID())
+	if err != nil {
+		return ctx
+	}
+	spanID, err := trace.SpanIDFromHex(x.parent)
+	if err != nil {
+		return ctx
+	}
+	var flags trace.TraceFlags
+	if x.sampled == "1" {
+		flags = trace.FlagsSampled
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: flags, Remote: true})
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields returns the header names used by the propagator.
+func (Propagator) Fields() []string {
+	return []string{headerXRay}
+}
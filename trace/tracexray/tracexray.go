@@ -0,0 +1,120 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracexray
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nokia/restful/trace/tracecommon"
+)
+
+/* AWS X-Ray trace header, as added by Application Load Balancer.
+   https://docs.aws.amazon.com/xray/latest/devguide/xray-concepts.html#xray-concepts-tracingheader
+*/
+
+const headerXRay = "X-Amzn-Trace-Id"
+
+// TraceXRay HTTP trace object of AWS X-Ray kind.
+type TraceXRay struct {
+	root, parent, sampled string
+}
+
+// NewFromRequest creates new TraceXRay object. If there is no trace data in request, then returns nil.
+func NewFromRequest(r *http.Request) *TraceXRay {
+	if r.Header == nil {
+		return nil
+	}
+	return parse(r.Header.Get(headerXRay))
+}
+
+func parse(value string) *TraceXRay {
+	x := TraceXRay{}
+	for _, field := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch k {
+		case "Root":
+			x.root = v
+		case "Parent":
+			x.parent = v
+		case "Sampled":
+			x.sampled = v
+		}
+	}
+	if TraceIDFromRoot(x.root) == "" {
+		return nil
+	}
+	return &x
+}
+
+// TraceIDFromRoot converts X-Ray root, e.g. "1-5759e988-bd862e3fe1be46a994272793", to 32 hex digit trace ID, e.g. "5759e988bd862e3fe1be46a994272793".
+// Returns empty string if root is invalid.
+func TraceIDFromRoot(root string) string {
+	fields := strings.Split(root, "-")
+	if len(fields) != 3 || fields[0] != "1" || len(fields[1]) != 8 || len(fields[2]) != 24 {
+		return ""
+	}
+	return fields[1] + fields[2]
+}
+
+// RootFromTraceID converts 32 hex digit trace ID to X-Ray root. Returns empty string if trace ID is invalid.
+func RootFromTraceID(traceID string) string {
+	if len(traceID) != 32 {
+		return ""
+	}
+	return "1-" + traceID[:8] + "-" + traceID[8:]
+}
+
+func (x *TraceXRay) span() *TraceXRay {
+	span := *x
+	span.parent = tracecommon.NewSpanID()
+	return &span
+}
+
+// Span spans the existing trace data and puts that into the request.
+// Returns the updated request and a trace string for logging.
+// Does not change the input trace data.
+func (x *TraceXRay) Span(r *http.Request) (*http.Request, string) {
+	span := x.span()
+	span.SetHeader(r.Header)
+	return r, span.String()
+}
+
+// SetHeader sets request headers according to the trace data.
+// Input headers object must not be nil.
+func (x *TraceXRay) SetHeader(headers http.Header) {
+	headers.Set(headerXRay, x.headerValue())
+}
+
+func (x *TraceXRay) headerValue() string {
+	value := "Root=" + x.root
+	if x.parent != "" {
+		value += ";Parent=" + x.parent
+	}
+	if x.sampled != "" {
+		value += ";Sampled=" + x.sampled
+	}
+	return value
+}
+
+// IsReceived tells whether trace data was received (parsed from a request) or a random one.
+func (x *TraceXRay) IsReceived() bool {
+	return true // Must have been created by NewFromRequest.
+}
+
+// String makes a log string from trace data.
+func (x *TraceXRay) String() string {
+	return x.TraceID() + "-" + x.parent
+}
+
+// TraceID returns the trace ID of the trace data, in 32 hex digit format.
+func (x *TraceXRay) TraceID() string {
+	return TraceIDFromRoot(x.root)
+}
+
+// SpanID returns the span ID of the trace data. That is the Parent field of the header.
+func (x *TraceXRay) SpanID() string {
+	return x.parent
+}
@@ -0,0 +1,67 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package tracexray
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const header = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+func TestXRay(t *testing.T) {
+	assert := assert.New(t)
+	r, _ := http.NewRequest("POST", "", nil)
+	r.Header.Set("X-Amzn-Trace-Id", "Self=1-67891234-12456789abcdef012345678;"+header)
+	x := NewFromRequest(r)
+	assert.True(x.IsReceived())
+	assert.Equal("5759e988bd862e3fe1be46a994272793", x.TraceID())
+	assert.Equal("53995c3f42cd8ad8", x.SpanID())
+
+	headers := http.Header{}
+	x.SetHeader(headers)
+	assert.Equal(header, headers.Get("X-Amzn-Trace-Id"))
+
+	out, _ := http.NewRequest("GET", "", nil)
+	_, span := x.Span(out)
+	assert.NotContains(span, "53995c3f42cd8ad8")
+	assert.Contains(out.Header.Get("X-Amzn-Trace-Id"), "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=")
+}
+
+func TestBad(t *testing.T) {
+	assert.Nil(t, NewFromRequest(&http.Request{}))
+	for _, value := range []string{"", "Root=2-5759e988-bd862e3fe1be46a994272793", "Root=1-5759e988", "Parent=53995c3f42cd8ad8"} {
+		r, _ := http.NewRequest("POST", "", nil)
+		r.Header.Set("X-Amzn-Trace-Id", value)
+		assert.Nil(t, NewFromRequest(r), value)
+	}
+	assert.Empty(t, RootFromTraceID("123"))
+}
+
+func TestPropagator(t *testing.T) {
+	assert := assert.New(t)
+	carrier := propagation.HeaderCarrier(http.Header{})
+	carrier.Set("X-Amzn-Trace-Id", header)
+
+	ctx := Propagator{}.Extract(context.Background(), carrier)
+	sc := trace.SpanContextFromContext(ctx)
+	assert.True(sc.IsRemote())
+	assert.True(sc.IsSampled())
+	assert.Equal("5759e988bd862e3fe1be46a994272793", sc.TraceID().String())
+
+	out := propagation.HeaderCarrier(http.Header{})
+	Propagator{}.Inject(ctx, out)
+	assert.Equal(header, out.Get("X-Amzn-Trace-Id"))
+
+	Propagator{}.Inject(context.Background(), out)
+	assert.Equal(header, out.Get("X-Amzn-Trace-Id"))
+	assert.Equal(context.Background(), Propagator{}.Extract(context.Background(), propagation.HeaderCarrier(http.Header{})))
+	assert.Equal([]string{"X-Amzn-Trace-Id"}, Propagator{}.Fields())
+}
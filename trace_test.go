@@ -6,6 +6,10 @@ package restful
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -13,8 +17,14 @@ import (
 	"testing"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/nokia/restful/logging"
+	"github.com/nokia/restful/trace/tracedata"
+	"github.com/nokia/restful/trace/tracer"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -37,7 +47,7 @@ func TestClient(t *testing.T) {
 
 func TestTracePropagation(t *testing.T) {
 	assert := assert.New(t)
-	logrus.SetLevel(logrus.DebugLevel)
+	logging.Level.Set(slog.LevelDebug)
 
 	// Server
 	srvURL := ""
@@ -126,3 +136,391 @@ func Test_Trace_Propagate(t *testing.T) {
 		http.DefaultClient.Do(req)
 	}
 }
+
+func spanAttrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestSpanAttributes(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func() error { return nil })
+	r.HandleFunc("/fail", func() error { return NewError(nil, http.StatusServiceUnavailable) })
+	srv := httptest.NewServer(NewServer().Handler(r).server.Handler)
+	defer srv.Close()
+
+	assert.NoError(NewClient().Get(context.Background(), srv.URL+"/users/1", nil))
+	var server, client sdktrace.ReadOnlySpan
+	for _, s := range exporter.GetSpans().Snapshots() {
+		switch s.SpanKind() {
+		case trace.SpanKindServer:
+			server = s
+		case trace.SpanKindClient:
+			client = s
+		}
+	}
+	if assert.NotNil(server) && assert.NotNil(client) {
+		attrs := spanAttrs(server)
+		assert.Equal("GET", attrs["http.request.method"].AsString())
+		assert.Equal("/users/1", attrs["url.path"].AsString())
+		assert.Equal("/users/{id}", attrs["http.route"].AsString())
+		assert.Equal(int64(204), attrs["http.response.status_code"].AsInt64())
+		assert.Equal("127.0.0.1", attrs["server.address"].AsString())
+		assert.NotEqual(codes.Error, server.Status().Code)
+
+		attrs = spanAttrs(client)
+		assert.Equal("GET", attrs["http.request.method"].AsString())
+		assert.Equal(srv.URL+"/users/1", attrs["url.full"].AsString())
+		assert.Equal(int64(204), attrs["http.response.status_code"].AsInt64())
+		assert.Equal("127.0.0.1", attrs["server.address"].AsString())
+	}
+
+	exporter.Reset()
+	assert.Error(NewClient().Get(context.Background(), srv.URL+"/fail", nil))
+	errSpans := 0
+	for _, s := range exporter.GetSpans().Snapshots() {
+		if s.SpanKind() == trace.SpanKindServer || s.SpanKind() == trace.SpanKindClient {
+			errSpans++
+			assert.Equal(codes.Error, s.Status().Code)
+			assert.Equal(int64(503), spanAttrs(s)["http.response.status_code"].AsInt64())
+		}
+	}
+	assert.Equal(2, errSpans)
+}
+
+func TestSpanErrorRecording(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+
+	r := NewRouter()
+	r.HandleFunc("/fail", func() error { return NewError(fmt.Errorf("db query: %w", io.EOF), http.StatusInternalServerError) })
+	r.HandleFunc("/bad", func() error { return NewError(errors.New("bad id"), http.StatusBadRequest) })
+	handler := NewServer().Handler(r).server.Handler
+
+	for path, status := range map[string]codes.Code{"/fail": codes.Error, "/bad": codes.Unset} {
+		exporter.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		spans := exporter.GetSpans().Snapshots()
+		if assert.Len(spans, 1) {
+			span := spans[0]
+			assert.Equal(status, span.Status().Code, path)
+			if assert.Len(span.Events(), 1) {
+				event := span.Events()[0]
+				assert.Equal("exception", event.Name)
+				attrs := map[attribute.Key]attribute.Value{}
+				for _, a := range event.Attributes {
+					attrs[a.Key] = a.Value
+				}
+				assert.NotEmpty(attrs["exception.message"].AsString())
+				if path == "/fail" {
+					assert.Equal([]string{"db query: EOF", "EOF"}, attrs["exception.chain"].AsStringSlice())
+				}
+			}
+		}
+	}
+}
+
+func TestSpanNaming(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+	SetSpanAttributes(func(r *http.Request) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.String("tenant", r.Header.Get("X-Tenant"))}
+	})
+	defer SetSpanAttributes(nil)
+
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func() error { return nil }).Methods(http.MethodGet)
+	r.HandleFunc("/v2/users/{id}", func() error { return nil }).SpanName("get-user").SpanAttributes(attribute.String("api.version", "v2"))
+	srv := httptest.NewServer(NewServer().Handler(r).server.Handler)
+	defer srv.Close()
+
+	serverSpan := func(path string) sdktrace.ReadOnlySpan {
+		exporter.Reset()
+		_, _ = NewClient().SendRecv(context.Background(), http.MethodGet, srv.URL+path, http.Header{"X-Tenant": {"t1"}}, nil, nil)
+		for _, s := range exporter.GetSpans().Snapshots() {
+			if s.SpanKind() == trace.SpanKindServer {
+				return s
+			}
+		}
+		return nil
+	}
+
+	if span := serverSpan("/users/1"); assert.NotNil(span) {
+		assert.Equal("GET /users/{id}", span.Name())
+		assert.Equal("t1", spanAttrs(span)["tenant"].AsString())
+	}
+	if span := serverSpan("/v2/users/1"); assert.NotNil(span) {
+		assert.Equal("get-user", span.Name())
+		assert.Equal("v2", spanAttrs(span)["api.version"].AsString())
+		assert.Equal("t1", spanAttrs(span)["tenant"].AsString())
+	}
+	if span := serverSpan("/unknown"); assert.NotNil(span) {
+		assert.Equal("GET", span.Name())
+	}
+
+	SetSpanNameFormatter(func(r *http.Request, routeTemplate string) string { return "my " + routeTemplate })
+	defer SetSpanNameFormatter(nil)
+	if span := serverSpan("/users/1"); assert.NotNil(span) {
+		assert.Equal("my /users/{id}", span.Name())
+	}
+}
+
+func TestSpanRetryEvents(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	assert.Error(NewClient().Retry(2, time.Millisecond, time.Millisecond).Get(context.Background(), srv.URL, nil))
+	var resends []int64
+	for _, s := range exporter.GetSpans().Snapshots() {
+		if s.SpanKind() != trace.SpanKindClient {
+			continue
+		}
+		if count, ok := spanAttrs(s)["http.request.resend_count"]; ok {
+			resends = append(resends, count.AsInt64())
+			if assert.Len(s.Events(), 1) {
+				event := s.Events()[0]
+				assert.Equal("retry", event.Name)
+				assert.Contains(event.Attributes, attribute.String("retry.reason", "503"))
+				assert.Contains(event.Attributes, attribute.Int64("retry.wait_ms", 1))
+			}
+		}
+	}
+	assert.ElementsMatch([]int64{1, 2}, resends)
+}
+
+func TestClientAttemptSpans(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	assert.NoError(NewClient().Retry(1, time.Millisecond, time.Millisecond).Get(context.Background(), srv.URL, nil))
+	var parent sdktrace.ReadOnlySpan
+	var attempts []sdktrace.ReadOnlySpan
+	for _, s := range exporter.GetSpans().Snapshots() {
+		switch s.SpanKind() {
+		case trace.SpanKindInternal:
+			if s.Name() == "HTTP GET" {
+				parent = s
+			}
+		case trace.SpanKindClient:
+			attempts = append(attempts, s)
+		}
+	}
+	if assert.NotNil(parent) && assert.Len(attempts, 2) {
+		for _, s := range attempts {
+			assert.Equal(parent.SpanContext().SpanID(), s.Parent().SpanID())
+		}
+	}
+}
+
+func TestClientSpanFilter(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer SetOTel(false, nil)
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := NewClient().SpanFilter(func(req *http.Request) bool { return req.URL.Path != "/heartbeat" })
+	assert.NoError(client.Get(context.Background(), srv.URL+"/heartbeat", nil))
+	for _, s := range exporter.GetSpans().Snapshots() {
+		assert.NotEqual(trace.SpanKindClient, s.SpanKind())
+	}
+	assert.NotEmpty(traceparent)
+
+	exporter.Reset()
+	assert.NoError(client.Get(context.Background(), srv.URL+"/users", nil))
+	kinds := []trace.SpanKind{}
+	for _, s := range exporter.GetSpans().Snapshots() {
+		kinds = append(kinds, s.SpanKind())
+	}
+	assert.Contains(kinds, trace.SpanKindClient)
+}
+
+func TestRouteSampling(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRouter()
+	r.HandleFunc("/users", func() error { return nil })
+	r.HandleFunc("/important", func() error { return nil }).Sampling(1)
+	r.HandleFunc("/metrics", func() error { return nil }).Sampling(0)
+
+	sampledServerSpans := func(base sdktrace.Sampler) (names []string) {
+		exporter := tracetest.NewInMemoryExporter()
+		sampler := tracer.NewOverridableSampler(sdktrace.ParentBased(base))
+		SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), sdktrace.WithSampler(sampler)))
+		defer SetOTel(false, nil)
+		srv := httptest.NewServer(NewServer().Handler(r).server.Handler)
+		defer srv.Close()
+
+		for _, path := range []string{"/users", "/important", "/metrics", HealthCheckPath} {
+			_, _ = NewClient().SendRecv(context.Background(), http.MethodGet, srv.URL+path, nil, nil, nil)
+		}
+		for _, s := range exporter.GetSpans().Snapshots() {
+			if s.SpanKind() == trace.SpanKindServer {
+				names = append(names, s.Name())
+			}
+		}
+		return
+	}
+
+	assert.Equal([]string{"GET /important"}, sampledServerSpans(sdktrace.NeverSample()))
+	assert.Equal([]string{"GET /users", "GET /important"}, sampledServerSpans(sdktrace.AlwaysSample()))
+}
+
+func TestDebugTraceHeader(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	sampler := tracer.NewOverridableSampler(sdktrace.ParentBased(sdktrace.NeverSample()))
+	SetOTel(true, sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), sdktrace.WithSampler(sampler)))
+	defer SetOTel(false, nil)
+	SetDebugTraceHeader("X-Debug-Trace", "secret")
+	defer SetDebugTraceHeader("", "")
+
+	var sampled []bool
+	r := NewRouter()
+	r.HandleFunc("/users", func(ctx context.Context) error {
+		sampled = append(sampled, trace.SpanContextFromContext(ctx).IsSampled())
+		return nil
+	})
+	srv := httptest.NewServer(NewServer().Handler(r).server.Handler)
+	defer srv.Close()
+
+	for _, value := range []string{"", "bad", "secret"} {
+		_, _ = NewClient().SendRecv(context.Background(), http.MethodGet, srv.URL+"/users", http.Header{"X-Debug-Trace": {value}}, nil, nil)
+	}
+	assert.Equal([]bool{false, false, true}, sampled)
+	assert.Len(exporter.GetSpans(), 1)
+}
+
+func TestTraceResponseHeader(t *testing.T) {
+	assert := assert.New(t)
+	var handlerTraceID string
+	r := NewRouter().TraceResponseHeader("X-Trace-Id")
+	r.HandleFunc("/id", func(ctx context.Context) error { handlerTraceID = L(ctx).TraceID(); return nil })
+	w3c := NewRouter().TraceResponseHeader(TraceResponseW3C)
+	w3c.HandleFunc("/w3c", func() error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.NotEmpty(handlerTraceID)
+	assert.Equal(handlerTraceID, rec.Header().Get("X-Trace-Id")) // Generated
+
+	req = httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal("0af7651916cd43dd8448eb211c80319c", rec.Header().Get("X-Trace-Id"))
+
+	req = httptest.NewRequest(http.MethodGet, "/w3c", nil)
+	req.Header.Set("X-B3-TraceId", "463ac35c9f6413ad")
+	req.Header.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	rec = httptest.NewRecorder()
+	w3c.ServeHTTP(rec, req)
+	assert.Equal("00-0000000000000000463ac35c9f6413ad-a2fb4a1d1a96d312-00", rec.Header().Get(TraceResponseW3C))
+}
+
+func TestTraceMap(t *testing.T) {
+	assert := assert.New(t)
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer srv.Close()
+
+	in := map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "baggage": "tenant=a"}
+	ctx := ContextWithTraceMap(context.Background(), in)
+	assert.Equal("a", BaggageValue(ctx, "tenant"))
+	assert.NoError(Get(ctx, srv.URL, nil))
+	assert.Contains(received.Get("traceparent"), "0af7651916cd43dd8448eb211c80319c")
+	assert.Equal("tenant=a", received.Get("baggage"))
+
+	out := map[string]string{}
+	InjectTraceMap(ctx, out)
+	assert.Equal(in, out)
+
+	ctx = ContextWithTraceMap(context.Background(), nil)
+	out = map[string]string{}
+	InjectTraceMap(ctx, out)
+	assert.NotEmpty(out) // New trace.
+}
+
+func TestTracerFromContext(t *testing.T) {
+	assert := assert.New(t)
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer srv.Close()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx := tracer.NewContext(context.Background(), tracer.NewFromRequest(r))
+	assert.NoError(Get(ctx, srv.URL, nil))
+	assert.Contains(received.Get("traceparent"), "0af7651916cd43dd8448eb211c80319c")
+
+	router := NewRouter()
+	router.HandleFunc("/", func(ctx context.Context) error {
+		assert.Equal("0af7651916cd43dd8448eb211c80319c", tracer.FromContext(ctx).TraceID())
+		return nil
+	})
+	router.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestTraceFromContextNil(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(traceFromContext(context.Background()))
+
+	ctx := NewTestCtx(http.MethodGet, "/", http.Header{}, nil)
+	assert.NotNil(traceFromContext(ctx))
+	L(ctx).Trace = (*tracer.Tracer)(nil)
+	assert.Equal(tracedata.TraceData(tracer.FromContext(ctx)), traceFromContext(ctx)) // Typed nil skipped.
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.NotNil(traceFromContextOrRequestOrRandom(r))
+}
+
+func BenchmarkTraceFromContext(b *testing.B) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	r = r.WithContext(NewRequestCtx(httptest.NewRecorder(), r))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		traceFromContextOrRequestOrRandom(r)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrDeliveryNotFound is returned if the delivery record does not exist.
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// DeliveryStatus is the status of an outgoing webhook delivery.
+type DeliveryStatus string
+
+// Delivery statuses.
+const (
+	DeliveryPending   DeliveryStatus = "pending" // Queued or being retried.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Attempt is an attempt of sending a delivery.
+type Attempt struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	// StatusCode of the response. Zero on transport error.
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Delivery is the record of an outgoing webhook delivery.
+type Delivery struct {
+	ID         string          `json:"id"`
+	EndpointID string          `json:"endpointId"`
+	EventType  string          `json:"eventType,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     DeliveryStatus  `json:"status"`
+	Attempts   []Attempt       `json:"attempts,omitempty"`
+	Created    time.Time       `json:"created"`
+}
+
+// DeliveryStore stores delivery records. Implement it by a database, if records are to be persistent.
+type DeliveryStore interface {
+	// Save creates or updates the record.
+	Save(d Delivery) error
+	// Get returns the record of the ID, or ErrDeliveryNotFound.
+	Get(id string) (Delivery, error)
+	// List returns the records of the endpoint, ordered by creation.
+	List(endpointID string) ([]Delivery, error)
+}
+
+// MemoryDeliveryStore is an in-memory DeliveryStore, keeping the latest records.
+type MemoryDeliveryStore struct {
+	limit int
+
+	mutex   sync.Mutex
+	records map[string]Delivery
+	order   []string // IDs by creation.
+}
+
+// NewMemoryDeliveryStore creates an in-memory delivery store, keeping at most limit records. Zero limit means no limit.
+func NewMemoryDeliveryStore(limit int) *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{limit: limit, records: make(map[string]Delivery)}
+}
+
+// Save creates or updates the record. If there are too many records, the oldest one is removed.
+func (s *MemoryDeliveryStore) Save(d Delivery) error {
+	d.Attempts = slices.Clone(d.Attempts)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.records[d.ID]; !ok {
+		s.order = append(s.order, d.ID)
+		if s.limit > 0 && len(s.order) > s.limit {
+			delete(s.records, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	s.records[d.ID] = d
+	return nil
+}
+
+// Get returns the record of the ID.
+func (s *MemoryDeliveryStore) Get(id string) (Delivery, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	d, ok := s.records[id]
+	if !ok {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	d.Attempts = slices.Clone(d.Attempts)
+	return d, nil
+}
+
+// List returns the records of the endpoint, ordered by creation.
+func (s *MemoryDeliveryStore) List(endpointID string) ([]Delivery, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var list []Delivery
+	for _, id := range s.order {
+		if d := s.records[id]; d.EndpointID == endpointID {
+			d.Attempts = slices.Clone(d.Attempts)
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
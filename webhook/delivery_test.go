@@ -0,0 +1,38 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDeliveryStore(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryDeliveryStore(2)
+
+	attempts := []Attempt{{StatusCode: 500}}
+	assert.NoError(store.Save(Delivery{ID: "1", EndpointID: "a", Attempts: attempts}))
+	assert.NoError(store.Save(Delivery{ID: "2", EndpointID: "b"}))
+	attempts[0].StatusCode = 200 // Not aliased.
+	d, err := store.Get("1")
+	assert.NoError(err)
+	assert.Equal(500, d.Attempts[0].StatusCode)
+
+	d.Status = DeliveryDelivered
+	assert.NoError(store.Save(d)) // Update does not evict.
+	d, _ = store.Get("1")
+	assert.Equal(DeliveryDelivered, d.Status)
+
+	assert.NoError(store.Save(Delivery{ID: "3", EndpointID: "a"})) // Evicts the oldest.
+	_, err = store.Get("1")
+	assert.Equal(ErrDeliveryNotFound, err)
+
+	list, err := store.List("a")
+	assert.NoError(err)
+	assert.Len(list, 1)
+	assert.Equal("3", list[0].ID)
+}
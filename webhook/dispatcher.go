@@ -0,0 +1,444 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/nokia/restful/logging"
+)
+
+// Headers of outgoing webhook requests, besides the ones of the signer.
+// Receivers of this package can tell the event type by TypeHeader(EventHeader).
+// DeliveryHeader identifies the delivery, e.g. for logging. It is not signed.
+const (
+	DeliveryHeader = "X-Webhook-Delivery"
+	EventHeader    = "X-Webhook-Event"
+)
+
+var (
+	// ErrEndpointNotFound is returned if the endpoint does not exist.
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	// ErrQueueFull is returned if the queue of the endpoint is full.
+	ErrQueueFull = errors.New("webhook queue full")
+	// ErrClosed is returned if the dispatcher is closed.
+	ErrClosed = errors.New("webhook dispatcher closed")
+	// ErrDeliveryPending is returned on redelivery of a delivery not completed yet.
+	ErrDeliveryPending = errors.New("webhook delivery pending")
+)
+
+// Signer signs outgoing webhook requests. HMAC and Stripe verifiers implement it.
+type Signer interface {
+	Sign(header http.Header, body []byte)
+}
+
+// Endpoint is a destination of outgoing webhooks.
+type Endpoint struct {
+	// ID of the endpoint. If empty, a random one is generated.
+	ID string
+	// URL deliveries are POSTed to.
+	URL string
+	// Signer of the requests. Nil means unsigned requests.
+	Signer Signer
+	// Header is added to the requests, e.g. authorization.
+	Header http.Header
+}
+
+// breaker is a circuit breaker of an endpoint. Once open, a single failure after the cooldown opens it again.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+func (b *breaker) success() {
+	b.failures = 0
+}
+
+// failure counts a failure. Returns true if the circuit opens.
+func (b *breaker) failure(now time.Time) bool {
+	b.failures++
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return false
+	}
+	b.failures = b.threshold - 1
+	b.openUntil = now.Add(b.cooldown)
+	return true
+}
+
+type endpointWorker struct {
+	endpoint Endpoint // Guarded by the mutex of the dispatcher.
+	queue    chan string
+	breaker  breaker
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// sleep waits for d. Returns false if the worker is stopped meanwhile.
+func (w *endpointWorker) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return w.ctx.Err() == nil
+	}
+	select {
+	case <-w.ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// Dispatcher delivers outgoing webhooks by the restful Client.
+// Each endpoint has its own queue and worker, so that a slow or failing endpoint does not delay others.
+// Requests are signed, and retried with exponential backoff and jitter on transport errors, 5xx and 429 responses.
+// If an endpoint fails several times in a row, its circuit opens: no requests are sent to it for a cooldown period.
+// Deliveries are recorded with their attempts, and can be redelivered.
+type Dispatcher struct {
+	client           *restful.Client
+	store            DeliveryStore
+	attempts         int
+	backoffInit      time.Duration
+	backoffMax       time.Duration
+	jitter           float64
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	queueSize        int
+	deadLetter       func(d Delivery)
+
+	mutex     sync.Mutex
+	endpoints map[string]*endpointWorker
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewDispatcher creates a webhook dispatcher. Requests are sent by client. If client is nil, a new default client is used.
+// By default deliveries are attempted 5 times, with exponential backoff starting at 1s with 20% jitter.
+// Circuits open after 5 failures for 30s. Queues have capacity of QueueSize.
+// The latest 10000 delivery records are kept in memory.
+func NewDispatcher(client *restful.Client) *Dispatcher {
+	if client == nil {
+		client = restful.NewClient()
+	}
+	return &Dispatcher{
+		client:           client,
+		store:            NewMemoryDeliveryStore(10000),
+		attempts:         5,
+		backoffInit:      time.Second,
+		backoffMax:       time.Minute,
+		jitter:           0.2,
+		breakerThreshold: 5,
+		breakerCooldown:  30 * time.Second,
+		queueSize:        QueueSize,
+		endpoints:        make(map[string]*endpointWorker),
+	}
+}
+
+// Retry sets the number of delivery attempts and the exponential backoff between them.
+func (d *Dispatcher) Retry(attempts int, backoffInit, backoffMax time.Duration) *Dispatcher {
+	d.attempts = max(attempts, 1)
+	d.backoffInit = backoffInit
+	d.backoffMax = backoffMax
+	return d
+}
+
+// Jitter sets the random variation of backoff, as a fraction of it, e.g. 0.2 for ±20%.
+func (d *Dispatcher) Jitter(fraction float64) *Dispatcher {
+	d.jitter = fraction
+	return d
+}
+
+// CircuitBreaker sets the number of failures in a row opening the circuit of an endpoint, and the cooldown period while it is open.
+// Zero failures disables circuit breaking. Applies to endpoints added afterwards.
+func (d *Dispatcher) CircuitBreaker(failures int, cooldown time.Duration) *Dispatcher {
+	d.breakerThreshold = failures
+	d.breakerCooldown = cooldown
+	return d
+}
+
+// QueueSize sets the capacity of the queues of endpoints added afterwards.
+func (d *Dispatcher) QueueSize(size int) *Dispatcher {
+	d.queueSize = max(size, 0)
+	return d
+}
+
+// Store sets the store of delivery records.
+func (d *Dispatcher) Store(store DeliveryStore) *Dispatcher {
+	d.store = store
+	return d
+}
+
+// DeadLetter sets the function called if a delivery failed after all the attempts.
+func (d *Dispatcher) DeadLetter(f func(d Delivery)) *Dispatcher {
+	d.deadLetter = f
+	return d
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AddEndpoint adds the endpoint, starting its worker, and returns its ID.
+// If the endpoint exists, its URL, signer and header are updated.
+func (d *Dispatcher) AddEndpoint(endpoint Endpoint) string {
+	if endpoint.ID == "" {
+		endpoint.ID = newID()
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if w, ok := d.endpoints[endpoint.ID]; ok {
+		w.endpoint = endpoint
+		return endpoint.ID
+	}
+
+	w := &endpointWorker{
+		endpoint: endpoint,
+		queue:    make(chan string, d.queueSize),
+		breaker:  breaker{threshold: d.breakerThreshold, cooldown: d.breakerCooldown},
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	d.endpoints[endpoint.ID] = w
+	if d.closed {
+		w.cancel()
+	}
+	d.wg.Add(1)
+	go d.work(w)
+	return endpoint.ID
+}
+
+// RemoveEndpoint removes the endpoint, stopping its worker. Deliveries not completed are recorded as failed, so that those can be redelivered.
+func (d *Dispatcher) RemoveEndpoint(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	w, ok := d.endpoints[id]
+	if !ok {
+		return ErrEndpointNotFound
+	}
+	w.cancel()
+	delete(d.endpoints, id)
+	return nil
+}
+
+// Send queues the JSON payload to the endpoint, and returns the ID of the delivery.
+// If the queue is full or the dispatcher is closed, the delivery is recorded as failed, so that it can be redelivered later.
+func (d *Dispatcher) Send(endpointID, eventType string, payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	if !d.hasEndpoint(endpointID) {
+		return "", ErrEndpointNotFound
+	}
+
+	delivery := Delivery{ID: newID(), EndpointID: endpointID, EventType: eventType, Payload: body, Status: DeliveryPending, Created: time.Now()}
+	if err := d.store.Save(delivery); err != nil {
+		return "", err
+	}
+	return delivery.ID, d.enqueue(delivery)
+}
+
+// Redeliver queues a delivered or failed delivery again, keeping its record of attempts.
+func (d *Dispatcher) Redeliver(id string) error {
+	delivery, err := d.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if delivery.Status == DeliveryPending {
+		return ErrDeliveryPending
+	}
+	delivery.Status = DeliveryPending
+	if err := d.store.Save(delivery); err != nil {
+		return err
+	}
+	return d.enqueue(delivery)
+}
+
+// Delivery returns the record of the delivery.
+func (d *Dispatcher) Delivery(id string) (Delivery, error) {
+	return d.store.Get(id)
+}
+
+// Deliveries returns the records of the endpoint, ordered by creation.
+func (d *Dispatcher) Deliveries(endpointID string) ([]Delivery, error) {
+	return d.store.List(endpointID)
+}
+
+// Close stops the workers. Deliveries not completed are recorded as failed, so that those can be redelivered.
+// Send and Redeliver return ErrClosed afterwards.
+func (d *Dispatcher) Close() {
+	d.mutex.Lock()
+	d.closed = true
+	for _, w := range d.endpoints {
+		w.cancel()
+	}
+	d.mutex.Unlock()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) hasEndpoint(id string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	_, ok := d.endpoints[id]
+	return ok
+}
+
+func (d *Dispatcher) enqueue(delivery Delivery) error {
+	d.mutex.Lock()
+	w, ok := d.endpoints[delivery.EndpointID]
+	closed := d.closed
+	queued := false
+	if ok && !closed {
+		select {
+		case w.queue <- delivery.ID:
+			queued = true
+		default:
+		}
+	}
+	d.mutex.Unlock()
+	if queued {
+		return nil
+	}
+
+	delivery.Status = DeliveryFailed
+	_ = d.store.Save(delivery)
+	switch {
+	case closed:
+		return ErrClosed
+	case !ok:
+		return ErrEndpointNotFound
+	}
+	return ErrQueueFull
+}
+
+func (d *Dispatcher) work(w *endpointWorker) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-w.ctx.Done():
+			for {
+				select {
+				case id := <-w.queue:
+					d.stopped(id)
+				default:
+					return
+				}
+			}
+		case id := <-w.queue:
+			d.deliver(w, id)
+		}
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	backoff := (1 << attempt) * d.backoffInit
+	if backoff > d.backoffMax || backoff <= 0 {
+		backoff = d.backoffMax
+	}
+	if d.jitter > 0 {
+		backoff += time.Duration((mathrand.Float64()*2 - 1) * d.jitter * float64(backoff))
+	}
+	return backoff
+}
+
+func retriable(attempt Attempt) bool {
+	return attempt.StatusCode == 0 || attempt.StatusCode >= 500 || attempt.StatusCode == http.StatusTooManyRequests
+}
+
+// stopped records the delivery as failed, as its worker is stopped.
+func (d *Dispatcher) stopped(id string) {
+	if delivery, err := d.store.Get(id); err == nil {
+		delivery.Status = DeliveryFailed
+		_ = d.store.Save(delivery)
+	}
+}
+
+// deliver attempts the delivery till success, non-retriable failure or the number of attempts.
+func (d *Dispatcher) deliver(w *endpointWorker, id string) {
+	delivery, err := d.store.Get(id)
+	if err != nil {
+		logging.Module("webhook").Error("Webhook delivery record failed", "id", id, "error", err)
+		return
+	}
+
+	for i := 0; i < d.attempts; i++ {
+		if (i > 0 && !w.sleep(d.backoff(i-1))) || !w.sleep(time.Until(w.breaker.openUntil)) {
+			d.stopped(id)
+			return
+		}
+		attempt := d.attempt(w, &delivery)
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		if attempt.Error == "" {
+			w.breaker.success()
+			delivery.Status = DeliveryDelivered
+			_ = d.store.Save(delivery)
+			return
+		}
+		_ = d.store.Save(delivery)
+		if !retriable(attempt) {
+			break
+		}
+		if w.breaker.failure(time.Now()) {
+			logging.Module("webhook").Warn("Webhook endpoint circuit opened", "endpoint", delivery.EndpointID, "cooldown", w.breaker.cooldown)
+		}
+	}
+
+	delivery.Status = DeliveryFailed
+	_ = d.store.Save(delivery)
+	if d.deadLetter != nil {
+		d.deadLetter(delivery)
+	}
+}
+
+// attempt sends the delivery. The request is signed at each attempt, so that signature timestamps are recent.
+func (d *Dispatcher) attempt(w *endpointWorker, delivery *Delivery) Attempt {
+	d.mutex.Lock()
+	endpoint := w.endpoint
+	d.mutex.Unlock()
+
+	attempt := Attempt{Time: time.Now()}
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	for name, values := range endpoint.Header {
+		req.Header[name] = slices.Clone(values) // Signers and the client may change them.
+	}
+	req.Header.Set(restful.ContentTypeHeader, restful.ContentTypeApplicationJSON)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	if delivery.EventType != "" {
+		req.Header.Set(EventHeader, delivery.EventType)
+	}
+	if endpoint.Signer != nil {
+		endpoint.Signer.Sign(req.Header, delivery.Payload)
+	}
+
+	resp, err := d.client.Do(req)
+	attempt.Duration = time.Since(attempt.Time)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = resp.Status
+	}
+	return attempt
+}
@@ -0,0 +1,180 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitStatus(t *testing.T, d *Dispatcher, id string, status DeliveryStatus) Delivery {
+	var delivery Delivery
+	assert.Eventually(t, func() bool {
+		delivery, _ = d.Delivery(id)
+		return delivery.Status == status
+	}, 5*time.Second, time.Millisecond, id)
+	return delivery
+}
+
+func TestDispatcher(t *testing.T) {
+	assert := assert.New(t)
+	signer := NewHMAC("X-Signature", testSecret).Timestamp("X-Timestamp")
+	var mutex sync.Mutex
+	var received []string
+	var failures atomic.Int32
+	failures.Store(2)
+	receiver := NewReceiver(signer).Sync().EventType(TypeHeader(EventHeader)).
+		On("invoice.paid", func(inv invoice) error {
+			if failures.Add(-1) >= 0 {
+				return restful.NewError(nil, http.StatusServiceUnavailable)
+			}
+			mutex.Lock()
+			received = append(received, inv.ID)
+			mutex.Unlock()
+			return nil
+		})
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	var deadLetters atomic.Int32
+	d := NewDispatcher(nil).Retry(3, time.Millisecond, 2*time.Millisecond).DeadLetter(func(delivery Delivery) { deadLetters.Add(1) })
+	defer d.Close()
+	endpointID := d.AddEndpoint(Endpoint{URL: srv.URL, Signer: signer})
+
+	id, err := d.Send(endpointID, "invoice.paid", invoice{ID: "in_1"})
+	assert.NoError(err)
+	delivery := waitStatus(t, d, id, DeliveryDelivered)
+	assert.Equal(endpointID, delivery.EndpointID)
+	assert.JSONEq(`{"id":"in_1","type":""}`, string(delivery.Payload))
+	if assert.Len(delivery.Attempts, 3) {
+		assert.Equal(http.StatusServiceUnavailable, delivery.Attempts[0].StatusCode)
+		assert.Equal("503 Service Unavailable", delivery.Attempts[0].Error)
+		assert.Equal(http.StatusNoContent, delivery.Attempts[2].StatusCode)
+		assert.Empty(delivery.Attempts[2].Error)
+	}
+
+	id, err = d.Send(endpointID, "invoice.paid", invoice{})
+	assert.NoError(err)
+	delivery = waitStatus(t, d, id, DeliveryFailed)
+	assert.Len(delivery.Attempts, 1) // 422 is not retried.
+	assert.Equal(int32(1), deadLetters.Load())
+
+	d.AddEndpoint(Endpoint{ID: endpointID, URL: srv.URL}) // Unsigned.
+	failedID, _ := d.Send(endpointID, "invoice.paid", invoice{ID: "in_2"})
+	delivery = waitStatus(t, d, failedID, DeliveryFailed)
+	assert.Equal(http.StatusUnauthorized, delivery.Attempts[0].StatusCode)
+
+	d.AddEndpoint(Endpoint{ID: endpointID, URL: srv.URL, Signer: signer})
+	assert.NoError(d.Redeliver(failedID))
+	delivery = waitStatus(t, d, failedID, DeliveryDelivered)
+	assert.Len(delivery.Attempts, 2)
+	mutex.Lock()
+	assert.Equal([]string{"in_1", "in_2"}, received)
+	mutex.Unlock()
+
+	deliveries, err := d.Deliveries(endpointID)
+	assert.NoError(err)
+	assert.Len(deliveries, 3)
+
+	_, err = d.Send("nope", "invoice.paid", invoice{})
+	assert.Equal(ErrEndpointNotFound, err)
+	_, err = d.Send(endpointID, "x", func() {})
+	assert.Error(err)
+	assert.Equal(ErrDeliveryNotFound, d.Redeliver("nope"))
+}
+
+func TestDispatcherCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil).Retry(4, time.Millisecond, time.Millisecond).Jitter(0).CircuitBreaker(2, 50*time.Millisecond)
+	defer d.Close()
+	endpointID := d.AddEndpoint(Endpoint{URL: srv.URL})
+	id, err := d.Send(endpointID, "", map[string]string{"a": "b"})
+	assert.NoError(err)
+	delivery := waitStatus(t, d, id, DeliveryFailed)
+	if assert.Len(delivery.Attempts, 4) {
+		assert.Less(delivery.Attempts[1].Time.Sub(delivery.Attempts[0].Time), 40*time.Millisecond)
+		assert.GreaterOrEqual(delivery.Attempts[2].Time.Sub(delivery.Attempts[1].Time), 50*time.Millisecond) // Opened.
+		assert.GreaterOrEqual(delivery.Attempts[3].Time.Sub(delivery.Attempts[2].Time), 50*time.Millisecond) // Opened again.
+	}
+}
+
+func TestDispatcherQueue(t *testing.T) {
+	assert := assert.New(t)
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	d := NewDispatcher(nil).QueueSize(1)
+	endpointID := d.AddEndpoint(Endpoint{URL: srv.URL})
+	first, err := d.Send(endpointID, "", 1)
+	assert.NoError(err)
+	<-arrived
+	queued, err := d.Send(endpointID, "", 2)
+	assert.NoError(err)
+	assert.Equal(ErrDeliveryPending, d.Redeliver(queued))
+	full, err := d.Send(endpointID, "", 3)
+	assert.Equal(ErrQueueFull, err)
+	delivery, _ := d.Delivery(full)
+	assert.Equal(DeliveryFailed, delivery.Status)
+
+	assert.NoError(d.RemoveEndpoint(endpointID))
+	assert.Equal(ErrEndpointNotFound, d.RemoveEndpoint(endpointID))
+	d.Close()
+	for _, id := range []string{first, queued} {
+		delivery, _ := d.Delivery(id)
+		assert.Equal(DeliveryFailed, delivery.Status, id)
+	}
+	_, err = d.Send(d.AddEndpoint(Endpoint{URL: srv.URL}), "", 4)
+	assert.Equal(ErrClosed, err)
+	assert.Equal(ErrClosed, d.Redeliver(first))
+}
+
+type signerFunc func(header http.Header, body []byte)
+
+func (f signerFunc) Sign(header http.Header, body []byte) {
+	f(header, body)
+}
+
+func TestDispatcherEndpointHeader(t *testing.T) {
+	assert := assert.New(t)
+	var mutex sync.Mutex
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		received = append(received, r.Header.Get("X-Tags"))
+		mutex.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil).Retry(2, time.Millisecond, time.Millisecond)
+	defer d.Close()
+	header := http.Header{"X-Tags": {"a"}}
+	signer := signerFunc(func(header http.Header, body []byte) { header["X-Tags"][0] += "+signed" })
+	id, err := d.Send(d.AddEndpoint(Endpoint{URL: srv.URL, Signer: signer, Header: header}), "", 1)
+	assert.NoError(err)
+	waitStatus(t, d, id, DeliveryFailed)
+	mutex.Lock()
+	assert.Equal([]string{"a+signed", "a+signed"}, received) // Each attempt has its own copy.
+	mutex.Unlock()
+	assert.Equal("a", header.Get("X-Tags"))
+}
@@ -0,0 +1,69 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceCache remembers keys of deliveries received, for replay protection.
+// Implement it by a shared store, e.g. Redis, if webhooks are received by several instances.
+type NonceCache interface {
+	// Seen tells if the key has been seen recently, remembering it otherwise.
+	Seen(key string) bool
+	// Forget forgets the key, so that the delivery can be retried, e.g. if its processing failed.
+	Forget(key string)
+}
+
+// MemoryNonceCache is an in-memory NonceCache, remembering keys for a TTL.
+type MemoryNonceCache struct {
+	ttl time.Duration
+
+	mutex  sync.Mutex
+	keys   map[string]time.Time // Expiry of the key.
+	purged time.Time
+}
+
+// NewMemoryNonceCache creates an in-memory nonce cache. The TTL should cover the tolerance window of signature timestamps.
+func NewMemoryNonceCache(ttl time.Duration) *MemoryNonceCache {
+	return &MemoryNonceCache{ttl: ttl, keys: make(map[string]time.Time), purged: time.Now()}
+}
+
+// Seen tells if the key has been seen within TTL, remembering it otherwise.
+func (c *MemoryNonceCache) Seen(key string) bool {
+	now := time.Now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if now.Sub(c.purged) >= c.ttl {
+		for k, expiry := range c.keys {
+			if !now.Before(expiry) {
+				delete(c.keys, k)
+			}
+		}
+		c.purged = now
+	}
+
+	if expiry, ok := c.keys[key]; ok && now.Before(expiry) {
+		return true
+	}
+	c.keys[key] = now.Add(c.ttl)
+	return false
+}
+
+// Forget forgets the key.
+func (c *MemoryNonceCache) Forget(key string) {
+	c.mutex.Lock()
+	delete(c.keys, key)
+	c.mutex.Unlock()
+}
+
+// Len returns the number of keys remembered, including expired ones not purged yet.
+func (c *MemoryNonceCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.keys)
+}
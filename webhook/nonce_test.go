@@ -0,0 +1,28 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryNonceCache(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewMemoryNonceCache(20 * time.Millisecond)
+
+	assert.False(cache.Seen("a"))
+	assert.True(cache.Seen("a"))
+	assert.False(cache.Seen("b"))
+	cache.Forget("b")
+	assert.False(cache.Seen("b"))
+	assert.Equal(2, cache.Len())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(cache.Seen("a")) // Expired, others purged.
+	assert.Equal(1, cache.Len())
+}
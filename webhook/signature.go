@@ -0,0 +1,218 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTolerance is the default maximum difference of signature timestamps from the current time.
+var DefaultTolerance = 5 * time.Minute
+
+// StripeSignatureHeader is the header of Stripe webhook signatures.
+const StripeSignatureHeader = "Stripe-Signature"
+
+var (
+	// ErrSignature is returned if the signature of a webhook is missing or invalid.
+	ErrSignature = errors.New("invalid webhook signature")
+	// ErrTimestamp is returned if the signature timestamp of a webhook is out of the tolerance window.
+	ErrTimestamp = errors.New("webhook timestamp out of tolerance")
+)
+
+// Verifier verifies signatures of webhooks.
+type Verifier interface {
+	// Verify checks the signature of the body. Returns the key of the delivery for replay protection.
+	// The key must be derived from signed data only, e.g. the timestamp and the MAC verified, so that senders cannot vary it.
+	Verify(header http.Header, body []byte) (key string, err error)
+}
+
+// VerifierFunc is a function implementing Verifier.
+type VerifierFunc func(header http.Header, body []byte) (string, error)
+
+// Verify calls f.
+func (f VerifierFunc) Verify(header http.Header, body []byte) (string, error) {
+	return f(header, body)
+}
+
+func computeMAC(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// verifyMAC returns the MAC of the first secret any of macs is equal to, so that secrets can be rotated.
+// The result depends on the payload and the secrets only, not on the encoding or order of macs. Nil if none is valid.
+func verifyMAC(secrets [][]byte, payload []byte, macs ...[]byte) []byte {
+	for _, secret := range secrets {
+		expected := computeMAC(secret, payload)
+		for _, mac := range macs {
+			if hmac.Equal(expected, mac) {
+				return expected
+			}
+		}
+	}
+	return nil
+}
+
+// replayKey is the key of a delivery for replay protection, made of signed data only.
+func replayKey(timestamp string, mac []byte) string {
+	return timestamp + "." + hex.EncodeToString(mac)
+}
+
+// checkTimestamp checks that the Unix timestamp is within tolerance of the current time.
+func checkTimestamp(timestamp string, tolerance time.Duration) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if diff := time.Since(time.Unix(sec, 0)); tolerance > 0 && (diff > tolerance || diff < -tolerance) {
+		return ErrTimestamp
+	}
+	return nil
+}
+
+// HMAC verifies hex encoded HMAC-SHA256 signatures of a header.
+// If a timestamp header is set, the signed payload is the timestamp, a dot and the body, and the timestamp must be within tolerance.
+// The key of the delivery is the timestamp and the MAC.
+type HMAC struct {
+	secrets         [][]byte
+	signatureHeader string
+	prefix          string
+	timestampHeader string
+	tolerance       time.Duration
+}
+
+// NewHMAC creates an HMAC-SHA256 verifier of the signature header.
+// Signatures of any of the secrets are accepted, so that secrets can be rotated. Signing uses the first one.
+func NewHMAC(signatureHeader string, secrets ...[]byte) *HMAC {
+	return &HMAC{secrets: secrets, signatureHeader: signatureHeader, tolerance: DefaultTolerance}
+}
+
+// GitHub creates a verifier of GitHub webhooks, signed in X-Hub-Signature-256 header.
+// GitHub signatures have no timestamp, so a delivery can be replayed once the nonce cache has forgotten its key,
+// i.e. after 2*DefaultTolerance by default. Set a nonce cache of longer TTL to narrow that.
+// X-GitHub-Delivery header is not signed, so it is not used as key.
+func GitHub(secrets ...[]byte) *HMAC {
+	return NewHMAC("X-Hub-Signature-256", secrets...).Prefix("sha256=")
+}
+
+// Prefix sets the prefix of the signature header value, e.g. "sha256=".
+func (h *HMAC) Prefix(prefix string) *HMAC {
+	h.prefix = prefix
+	return h
+}
+
+// Timestamp sets the header of the Unix timestamp of the signature.
+func (h *HMAC) Timestamp(header string) *HMAC {
+	h.timestampHeader = header
+	return h
+}
+
+// Tolerance sets the maximum difference of the timestamp from the current time. Zero means no limit.
+// Default is DefaultTolerance.
+func (h *HMAC) Tolerance(tolerance time.Duration) *HMAC {
+	h.tolerance = tolerance
+	return h
+}
+
+func (h *HMAC) payload(timestamp string, body []byte) []byte {
+	if h.timestampHeader == "" {
+		return body
+	}
+	return append([]byte(timestamp+"."), body...)
+}
+
+// Verify checks the signature of the body.
+func (h *HMAC) Verify(header http.Header, body []byte) (string, error) {
+	signature, ok := strings.CutPrefix(header.Get(h.signatureHeader), h.prefix)
+	mac, err := hex.DecodeString(signature)
+	if !ok || err != nil || len(mac) == 0 {
+		return "", ErrSignature
+	}
+	var timestamp string
+	if h.timestampHeader != "" {
+		timestamp = header.Get(h.timestampHeader)
+		if err := checkTimestamp(timestamp, h.tolerance); err != nil {
+			return "", err
+		}
+	}
+	mac = verifyMAC(h.secrets, h.payload(timestamp, body), mac)
+	if mac == nil {
+		return "", ErrSignature
+	}
+	return replayKey(timestamp, mac), nil
+}
+
+// Sign sets the signature headers of the body, by the first secret. E.g. for testing receivers.
+func (h *HMAC) Sign(header http.Header, body []byte) {
+	var timestamp string
+	if h.timestampHeader != "" {
+		timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set(h.timestampHeader, timestamp)
+	}
+	header.Set(h.signatureHeader, h.prefix+hex.EncodeToString(computeMAC(h.secrets[0], h.payload(timestamp, body))))
+}
+
+// Stripe verifies Stripe-style signatures: header "t=<timestamp>,v1=<signature>", possibly with several v1 signatures,
+// signing the timestamp, a dot and the body by HMAC-SHA256.
+type Stripe struct {
+	secrets   [][]byte
+	tolerance time.Duration
+}
+
+// NewStripe creates a verifier of Stripe webhooks. Signatures of any of the secrets are accepted. Signing uses the first one.
+func NewStripe(secrets ...[]byte) *Stripe {
+	return &Stripe{secrets: secrets, tolerance: DefaultTolerance}
+}
+
+// Tolerance sets the maximum difference of the timestamp from the current time. Zero means no limit.
+// Default is DefaultTolerance.
+func (s *Stripe) Tolerance(tolerance time.Duration) *Stripe {
+	s.tolerance = tolerance
+	return s
+}
+
+// Verify checks the signature of the body. The key of the delivery is the timestamp and the MAC.
+func (s *Stripe) Verify(header http.Header, body []byte) (string, error) {
+	var timestamp string
+	var macs [][]byte
+	for _, item := range strings.Split(header.Get(StripeSignatureHeader), ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			if mac, err := hex.DecodeString(v); err == nil {
+				macs = append(macs, mac)
+			}
+		}
+	}
+	if timestamp == "" || len(macs) == 0 {
+		return "", ErrSignature
+	}
+	if err := checkTimestamp(timestamp, s.tolerance); err != nil {
+		return "", err
+	}
+
+	mac := verifyMAC(s.secrets, append([]byte(timestamp+"."), body...), macs...)
+	if mac == nil {
+		return "", ErrSignature
+	}
+	return replayKey(timestamp, mac), nil
+}
+
+// Sign sets the signature header of the body, by the first secret. E.g. for testing receivers.
+func (s *Stripe) Sign(header http.Header, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := computeMAC(s.secrets[0], append([]byte(timestamp+"."), body...))
+	header.Set(StripeSignatureHeader, "t="+timestamp+",v1="+hex.EncodeToString(mac))
+}
@@ -0,0 +1,102 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitHub(t *testing.T) {
+	assert := assert.New(t)
+	body := []byte("Hello, World!")
+	header := http.Header{}
+	header.Set("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17") // GitHub docs example.
+	header.Set("X-GitHub-Delivery", "72d3162e")
+
+	key, err := GitHub([]byte("old"), []byte("It's a Secret to Everybody")).Verify(header, body)
+	assert.NoError(err)
+	assert.Equal(".757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", key)
+
+	header.Set("X-Hub-Signature-256", "sha256=757107EA0EB2509FC211221CCE984B8A37570B6D7586C22C46F4379C8B043E17")
+	header.Set("X-GitHub-Delivery", "other")
+	upperKey, err := GitHub([]byte("It's a Secret to Everybody")).Verify(header, body)
+	assert.NoError(err)
+	assert.Equal(key, upperKey) // Unsigned changes do not change the key.
+
+	_, err = GitHub([]byte("It's a Secret to Everybody")).Verify(header, []byte("Hello, World?"))
+	assert.ErrorIs(err, ErrSignature)
+	_, err = GitHub([]byte("other")).Verify(header, body)
+	assert.ErrorIs(err, ErrSignature)
+	header.Set("X-Hub-Signature-256", "757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17")
+	_, err = GitHub([]byte("It's a Secret to Everybody")).Verify(header, body)
+	assert.ErrorIs(err, ErrSignature)
+}
+
+func TestHMACTimestamp(t *testing.T) {
+	assert := assert.New(t)
+	verifier := NewHMAC("X-Signature", []byte("secret")).Timestamp("X-Timestamp").Tolerance(time.Minute)
+	body := []byte(`{"type":"created"}`)
+	header := http.Header{}
+	verifier.Sign(header, body)
+
+	key, err := verifier.Verify(header, body)
+	assert.NoError(err)
+	assert.Equal(header.Get("X-Timestamp")+"."+header.Get("X-Signature"), key)
+
+	old := strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10)
+	header.Set("X-Timestamp", old)
+	header.Set("X-Signature", hex.EncodeToString(computeMAC([]byte("secret"), []byte(old+"."+string(body)))))
+	_, err = verifier.Verify(header, body)
+	assert.ErrorIs(err, ErrTimestamp)
+	_, err = verifier.Tolerance(0).Verify(header, body)
+	assert.NoError(err)
+
+	header.Del("X-Timestamp")
+	_, err = verifier.Verify(header, body)
+	assert.ErrorIs(err, ErrSignature)
+}
+
+func TestStripe(t *testing.T) {
+	assert := assert.New(t)
+	body := []byte(`{"type":"invoice.paid"}`)
+	header := http.Header{}
+	NewStripe([]byte("whsec_new")).Sign(header, body)
+
+	verifier := NewStripe([]byte("whsec_old"), []byte("whsec_new"))
+	key, err := verifier.Verify(header, body)
+	assert.NoError(err)
+	timestamp, mac, _ := strings.Cut(strings.TrimPrefix(header.Get(StripeSignatureHeader), "t="), ",v1=")
+	assert.Equal(timestamp+"."+mac, key)
+
+	oldMAC := hex.EncodeToString(computeMAC([]byte("whsec_old"), []byte(timestamp+"."+string(body))))
+	header.Set(StripeSignatureHeader, "t="+timestamp+",v1="+strings.ToUpper(mac)+",v1="+oldMAC+",v0=x")
+	otherKey, err := verifier.Verify(header, body)
+	assert.NoError(err)
+	assert.Equal(timestamp+"."+oldMAC, otherKey) // By the order of secrets, not of signatures.
+
+	_, err = verifier.Verify(header, []byte(`{"type":"invoice.void"}`))
+	assert.ErrorIs(err, ErrSignature)
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	mac = hex.EncodeToString(computeMAC([]byte("whsec_old"), []byte(old+"."+string(body))))
+	header.Set(StripeSignatureHeader, "t="+old+",v1=00,v1="+mac+",v0=ignored")
+	_, err = verifier.Verify(header, body)
+	assert.ErrorIs(err, ErrTimestamp)
+	_, err = verifier.Tolerance(0).Verify(header, body)
+	assert.NoError(err)
+
+	for _, value := range []string{"", "t=1", "v1=" + mac, "t=x,v1=" + mac} {
+		header.Set(StripeSignatureHeader, value)
+		_, err = verifier.Verify(header, body)
+		assert.ErrorIs(err, ErrSignature, value)
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package webhook receives inbound webhooks and delivers outgoing ones.
+// Signatures are verified, replays are rejected, and deliveries are acknowledged fast while processed by a queue in the background,
+// dispatched to handlers by event type with typed payloads.
+// Outgoing webhooks are signed and delivered by per-endpoint queues, with retries, circuit breaking and delivery records.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/nokia/restful"
+	"github.com/nokia/restful/logging"
+)
+
+// QueueWorkers is the number of workers processing queued deliveries of new receivers.
+// QueueSize is the capacity of the queue of new receivers, and of endpoints of new dispatchers.
+var (
+	QueueWorkers = 4
+	QueueSize    = 1000
+)
+
+// AnyType is the event type of handlers of events without own handler.
+const AnyType = "*"
+
+// Event is a webhook delivery received.
+type Event struct {
+	// Type of the event, as told by the event type function of the receiver.
+	Type string
+	// Key of the delivery, as returned by the verifier.
+	Key string
+	// Header of the request.
+	Header http.Header
+	// Body of the request.
+	Body []byte
+}
+
+// TypeHeader returns an event type function telling the type by a header, e.g. X-GitHub-Event.
+func TypeHeader(name string) func(event *Event) string {
+	return func(event *Event) string {
+		return event.Header.Get(name)
+	}
+}
+
+// TypeField returns an event type function telling the type by a top-level string field of the JSON body, e.g. type of Stripe events.
+func TypeField(name string) func(event *Event) string {
+	return func(event *Event) string {
+		var fields map[string]json.RawMessage
+		var eventType string
+		if json.Unmarshal(event.Body, &fields) == nil {
+			_ = json.Unmarshal(fields[name], &eventType)
+		}
+		return eventType
+	}
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	eventType   = reflect.TypeOf(&Event{})
+)
+
+type handler struct {
+	f           reflect.Value
+	hasCtx      bool
+	payloadType reflect.Type // Nil if the handler has no payload parameter.
+}
+
+// newHandler checks that f is a func([ctx], [payload]) [error].
+func newHandler(f any) *handler {
+	t := reflect.TypeOf(f)
+	if t == nil || t.Kind() != reflect.Func {
+		panic("function expected")
+	}
+	h := handler{f: reflect.ValueOf(f)}
+	in := 0
+	if in < t.NumIn() && t.In(in).Implements(contextType) {
+		h.hasCtx = true
+		in++
+	}
+	if in < t.NumIn() {
+		h.payloadType = t.In(in)
+		in++
+	}
+	if in < t.NumIn() || t.NumOut() > 1 || (t.NumOut() == 1 && t.Out(0) != errorType) {
+		panic(fmt.Sprintf("func([ctx], [payload]) [error] expected: %s", t))
+	}
+	return &h
+}
+
+// payload unmarshals and validates the payload of the event, the same way as Lambda request bodies are.
+func (h *handler) payload(event *Event) (reflect.Value, error) {
+	if h.payloadType == nil {
+		return reflect.Value{}, nil
+	}
+	if h.payloadType == eventType {
+		return reflect.ValueOf(event), nil
+	}
+
+	isPtr := h.payloadType.Kind() == reflect.Ptr
+	t := h.payloadType
+	if isPtr {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(event.Body, v.Interface()); err != nil {
+		return v, restful.NewError(err, http.StatusBadRequest, "Invalid payload")
+	}
+	if restful.LambdaValidator && t.Kind() == reflect.Struct {
+		if err := restful.Validate.Struct(v.Interface()); err != nil {
+			return v, restful.NewError(err, restful.LambdaValidationErrorStatus)
+		}
+	}
+	if !isPtr {
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+type delivery struct {
+	ctx     context.Context
+	handler *handler
+	event   *Event
+	payload reflect.Value
+}
+
+func (d *delivery) run() (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	var args []reflect.Value
+	if d.handler.hasCtx {
+		args = append(args, reflect.ValueOf(d.ctx))
+	}
+	if d.handler.payloadType != nil {
+		args = append(args, d.payload)
+	}
+	if res := d.handler.f.Call(args); len(res) > 0 && !res[0].IsNil() {
+		return res[0].Interface().(error)
+	}
+	return nil
+}
+
+// Receiver is an http.Handler receiving webhooks.
+// Requests of invalid signature are responded by 401, replays are acknowledged by 204 without processing.
+// Deliveries are acknowledged by 202 once queued, and processed by handlers of their event type in the background.
+// If the queue is full, 503 is responded, so that the sender retries later. Events with no handler are acknowledged by 204.
+//
+//	receiver := webhook.NewReceiver(webhook.NewStripe(secret)).On("invoice.paid", invoicePaid)
+//	defer receiver.Close()
+//	router.Handle("/webhooks/stripe", receiver).Methods(http.MethodPost)
+type Receiver struct {
+	verifier  Verifier
+	nonces    NonceCache
+	eventType func(event *Event) string
+	handlers  map[string]*handler
+	sync      bool
+	workers   int
+	queueSize int
+	onError   func(event *Event, err error)
+
+	startOnce sync.Once
+	queue     chan *delivery
+	wg        sync.WaitGroup
+	mutex     sync.RWMutex
+	closed    bool
+}
+
+// NewReceiver creates a webhook receiver of the signature verifier.
+// By default, replays are detected by an in-memory nonce cache covering the DefaultTolerance window both ways,
+// and the event type is told by the "type" field of the JSON body.
+func NewReceiver(verifier Verifier) *Receiver {
+	return &Receiver{
+		verifier:  verifier,
+		nonces:    NewMemoryNonceCache(2 * DefaultTolerance),
+		eventType: TypeField("type"),
+		handlers:  make(map[string]*handler),
+		workers:   QueueWorkers,
+		queueSize: QueueSize,
+	}
+}
+
+// Nonces sets the nonce cache of replay protection. Nil disables replay protection.
+func (rc *Receiver) Nonces(cache NonceCache) *Receiver {
+	rc.nonces = cache
+	return rc
+}
+
+// EventType sets the function telling the type of events, e.g. TypeHeader("X-GitHub-Event").
+func (rc *Receiver) EventType(f func(event *Event) string) *Receiver {
+	rc.eventType = f
+	return rc
+}
+
+// On sets the handler of the event type, or of events without own handler if eventType is AnyType.
+// The handler is a func([ctx], [payload]) [error], where payload is any type the JSON body is unmarshaled to, or *Event for the raw delivery.
+// Panics if f is not such a function.
+func (rc *Receiver) On(eventType string, f any) *Receiver {
+	rc.handlers[eventType] = newHandler(f)
+	return rc
+}
+
+// Queue sets the number of workers processing deliveries and the capacity of the queue. Default is QueueWorkers and QueueSize.
+func (rc *Receiver) Queue(workers, size int) *Receiver {
+	rc.workers = max(workers, 1)
+	rc.queueSize = max(size, 0)
+	return rc
+}
+
+// Sync makes deliveries processed before responding, instead of queueing.
+// Errors of handlers are responded then, e.g. 500, and the delivery is forgotten by the nonce cache, so that the sender can retry.
+func (rc *Receiver) Sync() *Receiver {
+	rc.sync = true
+	return rc
+}
+
+// OnError sets the function called if processing of a queued delivery fails. By default failures are logged.
+func (rc *Receiver) OnError(f func(event *Event, err error)) *Receiver {
+	rc.onError = f
+	return rc
+}
+
+// ServeHTTP receives a webhook.
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := restful.GetDataBytes(r.Header, r.Body, restful.LambdaMaxBytesToParse)
+	if err != nil {
+		_ = restful.SendResp(w, r, restful.NewError(err, http.StatusBadRequest), nil)
+		return
+	}
+	key, err := rc.verifier.Verify(r.Header, body)
+	if err != nil {
+		logging.Module("webhook").Debug("Webhook signature verification failed", "path", r.URL.Path, "error", err)
+		_ = restful.SendResp(w, r, restful.NewError(err, http.StatusUnauthorized), nil)
+		return
+	}
+
+	event := &Event{Key: key, Header: r.Header, Body: body}
+	event.Type = rc.eventType(event)
+	h := rc.handlers[event.Type]
+	if h == nil {
+		h = rc.handlers[AnyType]
+	}
+	if h == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	payload, err := h.payload(event)
+	if err != nil {
+		_ = restful.SendResp(w, r, err, nil)
+		return
+	}
+	if rc.nonces != nil && rc.nonces.Seen(key) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rc.deliver(w, r, &delivery{ctx: r.Context(), handler: h, event: event, payload: payload})
+}
+
+func (rc *Receiver) deliver(w http.ResponseWriter, r *http.Request, d *delivery) {
+	if rc.sync {
+		if err := d.run(); err != nil {
+			rc.forget(d.event.Key)
+			_ = restful.SendResp(w, r, err, nil)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	d.ctx = context.WithoutCancel(d.ctx) // Processed after responding.
+	if !rc.enqueue(d) {
+		rc.forget(d.event.Key)
+		w.Header().Set("Retry-After", "1")
+		_ = restful.SendResp(w, r, restful.NewError(nil, http.StatusServiceUnavailable, "Webhook queue is full"), nil)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (rc *Receiver) forget(key string) {
+	if rc.nonces != nil {
+		rc.nonces.Forget(key)
+	}
+}
+
+func (rc *Receiver) start() {
+	rc.queue = make(chan *delivery, rc.queueSize)
+	for range rc.workers {
+		rc.wg.Add(1)
+		go rc.work()
+	}
+}
+
+func (rc *Receiver) enqueue(d *delivery) bool {
+	rc.startOnce.Do(rc.start)
+	rc.mutex.RLock()
+	defer rc.mutex.RUnlock()
+	if rc.closed {
+		return false
+	}
+	select {
+	case rc.queue <- d:
+		return true
+	default:
+		return false
+	}
+}
+
+func (rc *Receiver) work() {
+	defer rc.wg.Done()
+	for d := range rc.queue {
+		if err := d.run(); err != nil {
+			if rc.onError != nil {
+				rc.onError(d.event, err)
+			} else {
+				logging.Module("webhook").Error("Webhook processing failed", "type", d.event.Type, "error", err)
+			}
+		}
+	}
+}
+
+// Close stops accepting deliveries, responding 503, and waits for the queued ones to be processed.
+func (rc *Receiver) Close() {
+	rc.startOnce.Do(func() {}) // No workers to start after closing.
+	rc.mutex.Lock()
+	if !rc.closed {
+		rc.closed = true
+		if rc.queue != nil {
+			close(rc.queue)
+		}
+	}
+	rc.mutex.Unlock()
+	rc.wg.Wait()
+}
@@ -0,0 +1,157 @@
+// Copyright 2021- Nokia
+// Licensed under the BSD 3-Clause License.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nokia/restful"
+	"github.com/stretchr/testify/assert"
+)
+
+type invoice struct {
+	Type string `json:"type"`
+	ID   string `json:"id" validate:"required"`
+}
+
+var testSecret = []byte("whsec_test")
+
+func deliver(receiver http.Handler, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	receiver.ServeHTTP(w, req)
+	return w
+}
+
+func signed(body string) http.Header {
+	header := http.Header{}
+	NewStripe(testSecret).Sign(header, []byte(body))
+	return header
+}
+
+func TestReceiverSync(t *testing.T) {
+	assert := assert.New(t)
+	var paid []string
+	fail := true
+	receiver := NewReceiver(NewStripe(testSecret)).Sync().
+		On("invoice.paid", func(ctx context.Context, inv *invoice) error {
+			assert.NotNil(ctx)
+			if fail {
+				fail = false
+				return restful.NewError(nil, http.StatusServiceUnavailable)
+			}
+			paid = append(paid, inv.ID)
+			return nil
+		})
+
+	body := `{"type":"invoice.paid","id":"in_1"}`
+	header := signed(body)
+	w := deliver(receiver, body, header)
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	w = deliver(receiver, body, header) // Retry of failed is not a replay.
+	assert.Equal(http.StatusNoContent, w.Code)
+	w = deliver(receiver, body, header) // Replay.
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal([]string{"in_1"}, paid)
+
+	w = deliver(receiver, body, http.Header{StripeSignatureHeader: {"t=1,v1=00"}})
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	body = `{"type":"invoice.paid"}`
+	w = deliver(receiver, body, signed(body))
+	assert.Equal(restful.LambdaValidationErrorStatus, w.Code)
+	body = `{"type":"invoice.paid","id":1}`
+	w = deliver(receiver, body, signed(body))
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	body = `{"type":"invoice.void","id":"in_2"}`
+	w = deliver(receiver, body, signed(body))
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal([]string{"in_1"}, paid)
+}
+
+func TestReceiverQueue(t *testing.T) {
+	assert := assert.New(t)
+	var mutex sync.Mutex
+	var events []string
+	var failed []string
+	release := make(chan struct{})
+	receiver := NewReceiver(GitHub(testSecret)).EventType(TypeHeader("X-GitHub-Event")).Queue(1, 1).
+		On("push", func(event *Event) {
+			<-release
+			mutex.Lock()
+			events = append(events, event.Type+" "+string(event.Body))
+			mutex.Unlock()
+		}).
+		On(AnyType, func() error { return errors.New("unsupported") }).
+		OnError(func(event *Event, err error) {
+			mutex.Lock()
+			failed = append(failed, event.Type+" "+err.Error())
+			mutex.Unlock()
+		})
+
+	push := func(id, body string) *httptest.ResponseRecorder {
+		header := http.Header{"X-Github-Event": {"push"}, "X-Github-Delivery": {id}}
+		GitHub(testSecret).Sign(header, []byte(body))
+		return deliver(receiver, body, header)
+	}
+	assert.Equal(http.StatusAccepted, push("1", "a").Code) // Processing, blocked.
+	for push("2", "b").Code == http.StatusServiceUnavailable {
+	} // Queued when the worker has taken the first.
+	w := push("3", "c")
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.Equal("1", w.Header().Get("Retry-After"))
+
+	header := http.Header{"X-Github-Event": {"ping"}, "X-Github-Delivery": {"4"}}
+	GitHub(testSecret).Sign(header, []byte("{}"))
+	close(release)
+	for deliver(receiver, "{}", header).Code == http.StatusServiceUnavailable {
+	}
+
+	receiver.Close()
+	assert.Equal([]string{"push a", "push b"}, events)
+	assert.Equal([]string{"ping unsupported"}, failed)
+	assert.Equal(http.StatusServiceUnavailable, push("5", "e").Code)
+}
+
+func TestReceiverReplays(t *testing.T) {
+	assert := assert.New(t)
+	var calls int
+	body := `{"type":"invoice.paid","id":"in_1"}`
+
+	receiver := NewReceiver(GitHub(testSecret)).Sync().On("invoice.paid", func() { calls++ })
+	header := http.Header{"X-Github-Delivery": {"1"}}
+	GitHub(testSecret).Sign(header, []byte(body))
+	assert.Equal(http.StatusNoContent, deliver(receiver, body, header).Code)
+	header.Set("X-Hub-Signature-256", "sha256="+strings.ToUpper(strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")))
+	assert.Equal(http.StatusNoContent, deliver(receiver, body, header).Code) // Hex case changed.
+	header.Set("X-Github-Delivery", "2")
+	assert.Equal(http.StatusNoContent, deliver(receiver, body, header).Code) // Delivery ID changed.
+	assert.Equal(1, calls)
+
+	receiver = NewReceiver(NewStripe(testSecret)).Sync().On("invoice.paid", func() { calls++ })
+	header = signed(body)
+	assert.Equal(http.StatusNoContent, deliver(receiver, body, header).Code)
+	header.Set(StripeSignatureHeader, header.Get(StripeSignatureHeader)+",v0=00")
+	assert.Equal(http.StatusNoContent, deliver(receiver, body, header).Code) // Item added.
+	assert.Equal(2, calls)
+}
+
+func TestReceiverHandlerPanics(t *testing.T) {
+	assert := assert.New(t)
+	receiver := NewReceiver(GitHub(testSecret))
+	assert.Panics(func() { receiver.On("x", "x") })
+	assert.Panics(func() { receiver.On("x", func(a, b int) {}) })
+	assert.Panics(func() { receiver.On("x", func() int { return 0 }) })
+}